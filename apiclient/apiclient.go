@@ -0,0 +1,345 @@
+// Package apiclient is the HTTP transport shared by the typed clients of the
+// Leeforge plugins. It sends JSON requests, applies authentication and the
+// tenant header, unwraps the responder envelope and turns error responses
+// into *Error values that the plugin clients map to their sentinel errors.
+package apiclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/leeforge/plugins/apierror"
+)
+
+// DefaultTenantHeader is the header carrying the tenant of a request, as
+// configured by the tenant plugin's tenantHeader setting.
+const DefaultTenantHeader = "X-Tenant-ID"
+
+// maxErrorBody bounds how much of an error response is read.
+const maxErrorBody = 1 << 20
+
+// Authenticator adds credentials to an outgoing request.
+type Authenticator interface {
+	Authenticate(req *http.Request) error
+}
+
+// AuthFunc adapts a function to an Authenticator.
+type AuthFunc func(req *http.Request) error
+
+func (f AuthFunc) Authenticate(req *http.Request) error { return f(req) }
+
+// BearerToken authenticates with "Authorization: Bearer <token>".
+func BearerToken(token string) Authenticator {
+	return AuthFunc(func(req *http.Request) error {
+		req.Header.Set("Authorization", "Bearer "+token)
+		return nil
+	})
+}
+
+// APIKey authenticates with a tenant service account key, sent as
+// "Authorization: ApiKey <token>".
+func APIKey(token string) Authenticator {
+	return AuthFunc(func(req *http.Request) error {
+		req.Header.Set("Authorization", "ApiKey "+token)
+		return nil
+	})
+}
+
+// ErrorMapper returns the sentinel error matching an error response, or nil
+// when there is none.
+type ErrorMapper func(e *Error) error
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithHTTPClient sets the HTTP client used to send requests.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		if hc != nil {
+			c.http = hc
+		}
+	}
+}
+
+// WithAuth sets the authenticator applied to every request.
+func WithAuth(auth Authenticator) Option {
+	return func(c *Client) { c.auth = auth }
+}
+
+// WithTenant sends tenant in the tenant header of every request. The tenant
+// plugin resolves the header as a tenant code.
+func WithTenant(tenant string) Option {
+	return func(c *Client) { c.tenant = tenant }
+}
+
+// WithTenantHeader changes the name of the tenant header.
+func WithTenantHeader(name string) Option {
+	return func(c *Client) {
+		if name != "" {
+			c.tenantHeader = name
+		}
+	}
+}
+
+// WithUserAgent sets the User-Agent header of every request.
+func WithUserAgent(ua string) Option {
+	return func(c *Client) { c.userAgent = ua }
+}
+
+// Client sends requests to a Leeforge API. It is safe for concurrent use.
+type Client struct {
+	base         *url.URL
+	http         *http.Client
+	auth         Authenticator
+	tenant       string
+	tenantHeader string
+	userAgent    string
+	mapError     ErrorMapper
+}
+
+// New returns a client for the API at baseURL, for example
+// "https://api.example.com/api/v1". mapError may be nil.
+func New(baseURL string, mapError ErrorMapper, opts ...Option) (*Client, error) {
+	base, err := url.Parse(strings.TrimRight(baseURL, "/"))
+	if err != nil || (base.Scheme != "http" && base.Scheme != "https") || base.Host == "" {
+		return nil, fmt.Errorf("apiclient: invalid base URL %q", baseURL)
+	}
+	c := &Client{
+		base:         base,
+		http:         http.DefaultClient,
+		tenantHeader: DefaultTenantHeader,
+		mapError:     mapError,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// With returns a copy of the client with opts applied, for example to act
+// in another tenant.
+func (c *Client) With(opts ...Option) *Client {
+	cp := *c
+	for _, opt := range opts {
+		opt(&cp)
+	}
+	return &cp
+}
+
+// Error is an error response of the API. Err is the plugin's sentinel error
+// for the response, if it has one, so that errors.Is works across the wire.
+// Data is the data sent along with the error, such as the current
+// representation answered with 412 Precondition Failed.
+type Error struct {
+	StatusCode int
+	Code       int
+	Message    string
+	Details    json.RawMessage
+	Data       json.RawMessage
+	Err        error
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("api: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("api: %d %s", e.StatusCode, e.Message)
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// envelope is the responder's response body.
+type envelope struct {
+	Data  json.RawMessage `json:"data"`
+	Error *struct {
+		Code    int             `json:"code"`
+		Message string          `json:"message"`
+		Details json.RawMessage `json:"details"`
+	} `json:"error"`
+}
+
+// Do sends a JSON request and decodes the data of the response into out,
+// which may be nil. Path is relative to the base URL.
+func (c *Client) Do(ctx context.Context, method, path string, query url.Values, body, out any) error {
+	return c.DoHeader(ctx, method, path, query, nil, body, out)
+}
+
+// DoHeader is Do with further request headers, such as If-Match.
+func (c *Client) DoHeader(ctx context.Context, method, path string, query url.Values, header http.Header, body, out any) error {
+	var r io.Reader
+	contentType := ""
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("apiclient: encode request: %w", err)
+		}
+		r, contentType = bytes.NewReader(b), "application/json"
+	}
+	resp, err := c.send(ctx, method, path, query, header, contentType, r)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var env envelope
+	if err := json.NewDecoder(resp.Body).Decode(&env); err != nil {
+		return fmt.Errorf("apiclient: decode response: %w", err)
+	}
+	if out == nil || len(env.Data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(env.Data, out); err != nil {
+		return fmt.Errorf("apiclient: decode response data: %w", err)
+	}
+	return nil
+}
+
+// Send sends a request with a raw body and returns the successful response,
+// whose body the caller must close. Error responses are returned as *Error.
+func (c *Client) Send(ctx context.Context, method, path string, query url.Values, contentType string, body io.Reader) (*http.Response, error) {
+	return c.send(ctx, method, path, query, nil, contentType, body)
+}
+
+func (c *Client) send(ctx context.Context, method, path string, query url.Values, header http.Header, contentType string, body io.Reader) (*http.Response, error) {
+	u := c.base.JoinPath(path)
+	if len(query) > 0 {
+		u.RawQuery = query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("apiclient: %w", err)
+	}
+	for name, values := range header {
+		req.Header[http.CanonicalHeaderKey(name)] = values
+	}
+	req.Header.Set("Accept", "application/json")
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+	if c.tenant != "" {
+		req.Header.Set(c.tenantHeader, c.tenant)
+	}
+	if c.auth != nil {
+		if err := c.auth.Authenticate(req); err != nil {
+			return nil, fmt.Errorf("apiclient: authenticate: %w", err)
+		}
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	return nil, c.decodeError(resp)
+}
+
+func (c *Client) decodeError(resp *http.Response) error {
+	apiErr := &Error{StatusCode: resp.StatusCode}
+	var env envelope
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	if json.Unmarshal(raw, &env) == nil && env.Error != nil {
+		apiErr.Code = env.Error.Code
+		apiErr.Message = env.Error.Message
+		apiErr.Details = env.Error.Details
+		apiErr.Data = env.Data
+	} else {
+		apiErr.Message = strings.TrimSpace(string(raw))
+	}
+	if c.mapError != nil {
+		apiErr.Err = c.mapError(apiErr)
+	}
+	return apiErr
+}
+
+// MatchCode returns the sentinel whose code is the code in the details of an
+// error response answered by apierror.Write, carrying the field violations
+// of the response. It returns nil when no sentinel matches.
+func MatchCode(details json.RawMessage, sentinels ...*apierror.Error) error {
+	if len(details) == 0 {
+		return nil
+	}
+	var d apierror.Details
+	if err := json.Unmarshal(details, &d); err != nil || d.Code == "" {
+		return nil
+	}
+	for _, s := range sentinels {
+		if s.Code != d.Code {
+			continue
+		}
+		fields := make([]apierror.FieldError, len(d.Fields))
+		for i, f := range d.Fields {
+			fields[i] = apierror.FieldError{Field: f.Field, Code: f.Code, Params: f.Params}
+		}
+		return s.With(fields...)
+	}
+	return nil
+}
+
+// MatchSentinel returns the sentinel whose message the error message starts
+// with, ignoring case. Plugin handlers answer with the sentinel message,
+// capitalized and possibly followed by details; aliases cover the responses
+// worded differently. The longest match wins.
+func MatchSentinel(message string, aliases map[string]error, sentinels ...error) error {
+	msg := strings.ToLower(strings.TrimSpace(message))
+	var (
+		best    error
+		bestLen int
+	)
+	consider := func(prefix string, err error) {
+		prefix = strings.ToLower(prefix)
+		if len(prefix) > bestLen && strings.HasPrefix(msg, prefix) {
+			best, bestLen = err, len(prefix)
+		}
+	}
+	for _, err := range sentinels {
+		consider(err.Error(), err)
+	}
+	for prefix, err := range aliases {
+		consider(prefix, err)
+	}
+	return best
+}
+
+// IsStatus reports whether err is an API error with the given HTTP status.
+func IsStatus(err error, status int) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.StatusCode == status
+}
+
+// Paginate iterates over the items of every page, fetching pages lazily
+// from page 1 until fetch reports the last page or returns no items.
+// Iteration stops at the first error, which is yielded with a zero item.
+func Paginate[T any](fetch func(page int) (items []T, totalPages int, err error)) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for page := 1; ; page++ {
+			items, totalPages, err := fetch(page)
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+			for _, item := range items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+			if len(items) == 0 || page >= totalPages {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package apiclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/leeforge/plugins/apierror"
+)
+
+var (
+	errNotFound        = errors.New("widget not found")
+	errNotFoundInScope = errors.New("widget not found in scope")
+	errLocked          = errors.New("widget is locked")
+)
+
+func TestMatchSentinel(t *testing.T) {
+	aliases := map[string]error{"locked": errLocked}
+	cases := []struct {
+		message string
+		want    error
+	}{
+		{"Widget not found", errNotFound},
+		{"widget not found: id 7", errNotFound},
+		{"Widget not found in scope", errNotFoundInScope},
+		{"Locked by another user", errLocked},
+		{"Something else", nil},
+		{"", nil},
+	}
+	for _, tc := range cases {
+		require.Equal(t, tc.want, MatchSentinel(tc.message, aliases, errNotFound, errNotFoundInScope), tc.message)
+	}
+}
+
+func TestMatchCode(t *testing.T) {
+	errInvalid := apierror.New(http.StatusUnprocessableEntity, "widget.invalid", "invalid widget")
+	errExists := apierror.New(http.StatusConflict, "widget.exists", "widget exists")
+
+	err := MatchCode(json.RawMessage(`{"code":"widget.invalid","fields":[{"field":"name","code":"required","message":"name 为必填项"}]}`),
+		errExists, errInvalid)
+	require.ErrorIs(t, err, errInvalid)
+	require.EqualError(t, err, "invalid widget: name is required")
+
+	require.Nil(t, MatchCode(json.RawMessage(`{"code":"widget.other"}`), errInvalid))
+	require.Nil(t, MatchCode(json.RawMessage(`"not an object"`), errInvalid))
+	require.Nil(t, MatchCode(nil, errInvalid))
+}
+
+func TestPaginate(t *testing.T) {
+	pages := [][]int{{1, 2}, {3, 4}, {5}}
+	var fetched []int
+	fetch := func(page int) ([]int, int, error) {
+		fetched = append(fetched, page)
+		return pages[page-1], len(pages), nil
+	}
+
+	var got []int
+	for v, err := range Paginate(fetch) {
+		require.NoError(t, err)
+		got = append(got, v)
+	}
+	require.Equal(t, []int{1, 2, 3, 4, 5}, got)
+	require.Equal(t, []int{1, 2, 3}, fetched)
+
+	fetched = nil
+	for v := range Paginate(fetch) {
+		if v == 2 {
+			break
+		}
+	}
+	require.Equal(t, []int{1}, fetched)
+
+	boom := errors.New("boom")
+	var errs []error
+	for _, err := range Paginate(func(int) ([]int, int, error) { return nil, 0, boom }) {
+		errs = append(errs, err)
+	}
+	require.Equal(t, []error{boom}, errs)
+}
+
+func TestClient_Do(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/widgets/1":
+			require.Equal(t, "Bearer t0k", r.Header.Get("Authorization"))
+			require.Equal(t, "acme", r.Header.Get("X-Org"))
+			require.Equal(t, "x", r.URL.Query().Get("q"))
+			_, _ = w.Write([]byte(`{"data":{"name":"gear"}}`))
+		case "/api/v1/widgets/2":
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":{"code":4003,"message":"Widget not found","details":{"id":2}}}`))
+		case "/api/v1/widgets/4":
+			require.Equal(t, `"7"`, r.Header.Get("If-Match"))
+			w.WriteHeader(http.StatusPreconditionFailed)
+			_, _ = w.Write([]byte(`{"data":{"name":"cog"},"error":{"code":4008,"message":"Modified"}}`))
+		default:
+			w.WriteHeader(http.StatusBadGateway)
+			_, _ = w.Write([]byte("upstream down"))
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	mapError := func(e *Error) error { return MatchSentinel(e.Message, nil, errNotFound) }
+	c, err := New(srv.URL+"/api/v1/", mapError,
+		WithAuth(BearerToken("t0k")), WithTenant("acme"), WithTenantHeader("X-Org"))
+	require.NoError(t, err)
+
+	var out struct{ Name string }
+	require.NoError(t, c.Do(context.Background(), http.MethodGet, "widgets/1", map[string][]string{"q": {"x"}}, nil, &out))
+	require.Equal(t, "gear", out.Name)
+
+	err = c.Do(context.Background(), http.MethodGet, "widgets/2", nil, nil, nil)
+	require.ErrorIs(t, err, errNotFound)
+	var apiErr *Error
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, 4003, apiErr.Code)
+	require.JSONEq(t, `{"id":2}`, string(apiErr.Details))
+
+	// Data sent with an error response is kept.
+	err = c.DoHeader(context.Background(), http.MethodPut, "widgets/4", nil,
+		http.Header{"If-Match": {`"7"`}}, map[string]string{"name": "gear"}, nil)
+	require.True(t, IsStatus(err, http.StatusPreconditionFailed))
+	require.ErrorAs(t, err, &apiErr)
+	require.JSONEq(t, `{"name":"cog"}`, string(apiErr.Data))
+
+	err = c.Do(context.Background(), http.MethodGet, "widgets/3", nil, nil, nil)
+	require.True(t, IsStatus(err, http.StatusBadGateway))
+	require.EqualError(t, err, "api: 502 upstream down")
+
+	_, err = New("ftp://example.com", nil)
+	require.Error(t, err)
+}
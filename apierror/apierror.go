@@ -0,0 +1,187 @@
+// Package apierror is the typed error model of the Leeforge plugins' HTTP
+// APIs.
+//
+// Services report requests that are malformed or fail validation (400) or
+// conflict with the current state (409) as *Error values, usually a
+// sentinel copied with per-field violations by With. An Error has a
+// machine-readable code that stays stable across languages; Write answers
+// it in the responder envelope with a message from the catalogue, in the
+// language the request's Accept-Language header prefers.
+package apierror
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/leeforge/framework/http/responder"
+)
+
+// Field violation codes shared by the plugins. Their messages take the
+// field name as {field} and the params listed.
+const (
+	Required   = "required"     // {field} is required
+	TooLong    = "too_long"     // {max} characters
+	TooLarge   = "too_large"    // {max} bytes
+	TooMany    = "too_many"     // {max} items
+	TooDeep    = "too_deep"     // {max} levels
+	OutOfRange = "out_of_range" // {min} and {max}
+	Future     = "future"       // must lie in the future
+	After      = "after"        // must be after {other}
+	NotBefore  = "not_before"   // must not be before {other}
+	Unknown    = "unknown"      // {value} is not a known value
+	OneOf      = "one_of"       // must be one of {values}
+	Invalid    = "invalid"      // malformed {value}
+	NotFound   = "not_found"    // {value} does not exist
+)
+
+// FieldError is a violation of one request field. Field is the JSON name or
+// query parameter, dotted for nested fields such as labels.plan; Params are
+// the values the message refers to.
+type FieldError struct {
+	Field  string
+	Code   string
+	Params map[string]any
+}
+
+// Field returns the violation code of field. params are key/value pairs,
+// such as Field("name", TooLong, "max", 64).
+func Field(field, code string, params ...any) FieldError {
+	f := FieldError{Field: field, Code: code}
+	for i := 0; i+1 < len(params); i += 2 {
+		if f.Params == nil {
+			f.Params = make(map[string]any, len(params)/2)
+		}
+		key, _ := params[i].(string)
+		f.Params[key] = params[i+1]
+	}
+	return f
+}
+
+// Error is an API error. Errors with the same code match with errors.Is, so
+// a sentinel copied by With still matches the sentinel.
+type Error struct {
+	// Status is the HTTP status, such as 400 or 409.
+	Status int
+	// Code is the machine-readable error code, such as tenant.invalid.
+	Code   string
+	Fields []FieldError
+
+	message string
+}
+
+// New returns an error with status, code and the English message used by
+// Error. Plugins declare their sentinels with it.
+func New(status int, code, message string) *Error {
+	return &Error{Status: status, Code: code, message: message}
+}
+
+// With returns a copy of e carrying the field violations.
+func (e *Error) With(fields ...FieldError) *Error {
+	cp := *e
+	cp.Fields = append(append([]FieldError(nil), e.Fields...), fields...)
+	return &cp
+}
+
+// Error returns the English message followed by the field violations.
+func (e *Error) Error() string {
+	if len(e.Fields) == 0 {
+		return e.message
+	}
+	return e.message + ": " + strings.Join(e.fieldMessages(English), "; ")
+}
+
+// Is reports whether target is an *Error with the same code.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+func (e *Error) fieldMessages(lang string) []string {
+	msgs := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		msgs[i] = fieldMessage(lang, f)
+	}
+	return msgs
+}
+
+func fieldMessage(lang string, f FieldError) string {
+	params := make(map[string]any, len(f.Params)+1)
+	for k, v := range f.Params {
+		params[k] = v
+	}
+	params["field"] = f.Field
+	return Message(lang, f.Code, params)
+}
+
+// Details is the details of an error response written by Write.
+type Details struct {
+	// Code is the machine-readable error code.
+	Code   string        `json:"code"`
+	Fields []FieldDetail `json:"fields,omitempty"`
+	// Reason is the untranslated diagnostic of an error wrapped with
+	// further context rather than field violations.
+	Reason string `json:"reason,omitempty"`
+}
+
+// FieldDetail is a field violation in an error response.
+type FieldDetail struct {
+	Field   string         `json:"field"`
+	Code    string         `json:"code"`
+	Message string         `json:"message"`
+	Params  map[string]any `json:"params,omitempty"`
+}
+
+// responderCodes are the responder error codes of the statuses; other
+// statuses answer ErrCodeBadRequest. Validation failures are 400, so 422 is
+// answered only for a request that is well formed but cannot be processed
+// as sent: an idempotency key reused with another payload
+// (idempotency.key_reused).
+var responderCodes = map[int]int{
+	http.StatusBadRequest:          responder.ErrCodeBadRequest,
+	http.StatusConflict:            responder.ErrCodeConflict,
+	http.StatusUnprocessableEntity: responder.ErrCodeValidationFailed,
+}
+
+// Write answers err when it is or wraps an *Error and reports whether it
+// did. The message is localized by the request's Accept-Language header,
+// which also sets Content-Language.
+func Write(w http.ResponseWriter, r *http.Request, err error) bool {
+	var e *Error
+	if !errors.As(err, &e) {
+		return false
+	}
+
+	lang := Language(r.Header.Get("Accept-Language"))
+	details := Details{Code: e.Code}
+	msg := Message(lang, e.Code, nil)
+	if msg == e.Code {
+		msg = capitalize(e.message)
+	}
+	if len(e.Fields) > 0 {
+		msgs := e.fieldMessages(lang)
+		for i, f := range e.Fields {
+			details.Fields = append(details.Fields, FieldDetail{
+				Field: f.Field, Code: f.Code, Message: msgs[i], Params: f.Params,
+			})
+		}
+		msg += ": " + strings.Join(msgs, "; ")
+	} else if err.Error() != e.Error() {
+		details.Reason = err.Error()
+	}
+
+	code, ok := responderCodes[e.Status]
+	if !ok {
+		code = responder.ErrCodeBadRequest
+	}
+	w.Header().Set("Content-Language", lang)
+	responder.CustomError(w, r, e.Status, code, msg, details)
+	return true
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
@@ -0,0 +1,94 @@
+package apierror
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var errInvalidWidget = New(http.StatusUnprocessableEntity, "widget.invalid", "invalid widget")
+
+func init() {
+	Register(Messages{
+		English: {"widget.invalid": "Invalid widget"},
+		Chinese: {"widget.invalid": "组件无效"},
+	})
+}
+
+func TestError(t *testing.T) {
+	err := errInvalidWidget.With(Field("name", Required), Field("name", TooLong, "max", 64))
+	require.ErrorIs(t, err, errInvalidWidget)
+	require.ErrorIs(t, fmt.Errorf("create: %w", err), errInvalidWidget)
+	require.False(t, errors.Is(err, New(http.StatusConflict, "widget.exists", "widget exists")))
+	require.EqualError(t, err, "invalid widget: name is required; name exceeds 64 characters")
+	require.EqualError(t, errInvalidWidget, "invalid widget")
+	require.Empty(t, errInvalidWidget.Fields)
+}
+
+func TestLanguage(t *testing.T) {
+	for header, want := range map[string]string{
+		"":                         English,
+		"zh-CN,zh;q=0.9,en;q=0.8":  Chinese,
+		"en-US,zh;q=0.5":           English,
+		"fr-FR, zh-Hans;q=0.7":     Chinese,
+		"de, *;q=0.1":              English,
+		"zh;q=0, en;q=0.2":         English,
+		"ja":                       English,
+		"en;q=0.3, zh-TW;q=0.8, x": Chinese,
+	} {
+		require.Equal(t, want, Language(header), header)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	write := func(err error, acceptLanguage string) (*httptest.ResponseRecorder, map[string]any) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/widgets", nil)
+		req.Header.Set("Accept-Language", acceptLanguage)
+		require.True(t, Write(rec, req, err))
+		var body struct {
+			Error map[string]any `json:"error"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		return rec, body.Error
+	}
+
+	rec, body := write(errInvalidWidget.With(Field("size", OutOfRange, "min", 1, "max", 10)), "zh-CN")
+	require.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	require.Equal(t, Chinese, rec.Header().Get("Content-Language"))
+	require.Equal(t, "组件无效: size 必须介于 1 与 10 之间", body["message"])
+	require.EqualValues(t, 4002, body["code"])
+	require.Equal(t, map[string]any{
+		"code": "widget.invalid",
+		"fields": []any{map[string]any{
+			"field":   "size",
+			"code":    "out_of_range",
+			"message": "size 必须介于 1 与 10 之间",
+			"params":  map[string]any{"min": 1.0, "max": 10.0},
+		}},
+	}, body["details"])
+
+	rec, body = write(fmt.Errorf("%w: too blue", errInvalidWidget), "")
+	require.Equal(t, English, rec.Header().Get("Content-Language"))
+	require.Equal(t, "Invalid widget", body["message"])
+	require.Equal(t, map[string]any{"code": "widget.invalid", "reason": "invalid widget: too blue"}, body["details"])
+
+	// Codes without catalogue messages fall back to the capitalized error.
+	rec, body = write(New(http.StatusConflict, "widget.exists", "widget already exists"), "zh")
+	require.Equal(t, http.StatusConflict, rec.Code)
+	require.Equal(t, "Widget already exists", body["message"])
+	require.EqualValues(t, 4008, body["code"])
+
+	require.False(t, Write(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil), errors.New("boom")))
+}
+
+func TestRegister_PanicsOnDuplicate(t *testing.T) {
+	require.Panics(t, func() {
+		Register(Messages{English: {"widget.invalid": "Again"}})
+	})
+}
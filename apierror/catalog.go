@@ -0,0 +1,134 @@
+package apierror
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Catalogue languages. English is the fallback for codes and languages
+// without a message.
+const (
+	English = "en"
+	Chinese = "zh"
+)
+
+// Messages maps languages to message templates by code. Templates refer to
+// params as {name}.
+type Messages map[string]map[string]string
+
+var (
+	catalogueMu sync.RWMutex
+	catalogue   = Messages{
+		English: {
+			Required:   "{field} is required",
+			TooLong:    "{field} exceeds {max} characters",
+			TooLarge:   "{field} exceeds {max} bytes",
+			TooMany:    "at most {max} {field} are allowed",
+			TooDeep:    "{field} nests deeper than {max} levels",
+			OutOfRange: "{field} must be between {min} and {max}",
+			Future:     "{field} must be in the future",
+			After:      "{field} must be after {other}",
+			NotBefore:  "{field} must not be before {other}",
+			Unknown:    `unknown {field} "{value}"`,
+			OneOf:      "{field} must be one of {values}",
+			Invalid:    `{field} "{value}" is not valid`,
+			NotFound:   `{field} "{value}" does not exist`,
+		},
+		Chinese: {
+			Required:   "{field} 为必填项",
+			TooLong:    "{field} 不能超过 {max} 个字符",
+			TooLarge:   "{field} 不能超过 {max} 字节",
+			TooMany:    "{field} 最多 {max} 个",
+			TooDeep:    "{field} 嵌套不能超过 {max} 层",
+			OutOfRange: "{field} 必须介于 {min} 与 {max} 之间",
+			Future:     "{field} 必须晚于当前时间",
+			After:      "{field} 必须晚于 {other}",
+			NotBefore:  "{field} 不能早于 {other}",
+			Unknown:    `{field} 的取值 "{value}" 无效`,
+			OneOf:      "{field} 必须是 {values} 之一",
+			Invalid:    `{field} "{value}" 格式无效`,
+			NotFound:   `{field} "{value}" 不存在`,
+		},
+	}
+)
+
+// Register adds the messages of a plugin's codes to the catalogue. It
+// panics when a code already has a message in a language, since codes are
+// declared once at init.
+func Register(m Messages) {
+	catalogueMu.Lock()
+	defer catalogueMu.Unlock()
+	for lang, msgs := range m {
+		if catalogue[lang] == nil {
+			catalogue[lang] = make(map[string]string, len(msgs))
+		}
+		for code, msg := range msgs {
+			if _, ok := catalogue[lang][code]; ok {
+				panic(fmt.Sprintf("apierror: %s message of %q registered twice", lang, code))
+			}
+			catalogue[lang][code] = msg
+		}
+	}
+}
+
+// Message returns the message of code in lang, falling back to English and
+// then to the code itself, with the {name} placeholders replaced by params.
+func Message(lang, code string, params map[string]any) string {
+	catalogueMu.RLock()
+	tmpl, ok := catalogue[lang][code]
+	if !ok {
+		tmpl, ok = catalogue[English][code]
+	}
+	catalogueMu.RUnlock()
+	if !ok {
+		return code
+	}
+	if len(params) == 0 || !strings.Contains(tmpl, "{") {
+		return tmpl
+	}
+	pairs := make([]string, 0, 2*len(params))
+	for k, v := range params {
+		pairs = append(pairs, "{"+k+"}", fmt.Sprint(v))
+	}
+	return strings.NewReplacer(pairs...).Replace(tmpl)
+}
+
+// Language returns the catalogue language an Accept-Language header value
+// prefers, matching on the primary subtag so that zh-CN selects Chinese.
+// It returns English when no catalogue language is acceptable.
+func Language(acceptLanguage string) string {
+	type candidate struct {
+		lang string
+		q    float64
+	}
+	var candidates []candidate
+	catalogueMu.RLock()
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		primary, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		if primary == "*" {
+			primary = English
+		}
+		if _, ok := catalogue[primary]; ok && q > 0 {
+			candidates = append(candidates, candidate{primary, q})
+		}
+	}
+	catalogueMu.RUnlock()
+
+	if len(candidates) == 0 {
+		return English
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+	return candidates[0].lang
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+
+	"github.com/google/uuid"
+
+	"github.com/leeforge/plugins/apiclient"
+	"github.com/leeforge/plugins/ou/organization"
+	tenantmod "github.com/leeforge/plugins/tenant/tenant"
+)
+
+// backend carries out the commands, either against the services directly
+// or through the HTTP API. Errors wrap the plugins' sentinel errors either
+// way.
+type backend interface {
+	// Tenant returns the tenant with the given ID or code.
+	Tenant(ctx context.Context, ref string) (*tenantmod.TenantDTO, error)
+	ListTenants(ctx context.Context, filters tenantmod.ListFilters) (*tenantmod.ListResult, error)
+	CreateTenant(ctx context.Context, req *tenantmod.CreateRequest) (*tenantmod.TenantDTO, error)
+	DeleteTenant(ctx context.Context, id uuid.UUID) error
+
+	ListMembers(ctx context.Context, tenantID uuid.UUID, filters tenantmod.MemberListFilters) (*tenantmod.MemberListResult, error)
+	AddMember(ctx context.Context, tenantID uuid.UUID, req *tenantmod.AddMemberRequest) error
+	RemoveMember(ctx context.Context, tenantID, userID uuid.UUID) error
+	SuspendMember(ctx context.Context, tenantID, userID uuid.UUID, reason string) error
+	ReactivateMember(ctx context.Context, tenantID, userID uuid.UUID) error
+
+	// Organization calls act in the domain of tenant.
+	OrganizationTree(ctx context.Context, tenant *tenantmod.TenantDTO) ([]*organization.OrganizationTreeNode, error)
+	CreateOrganization(ctx context.Context, tenant *tenantmod.TenantDTO, req *organization.CreateOrganizationRequest) (*organization.OrganizationResponse, error)
+	AddOrganizationMember(ctx context.Context, tenant *tenantmod.TenantDTO, orgID uuid.UUID, req *organization.AddOrganizationMemberRequest) (*organization.OrganizationMemberResponse, error)
+
+	Close() error
+}
+
+// openBackend connects to the database when --dsn is set and to the API
+// when --api-url is set.
+func openBackend(ctx context.Context, o *options) (backend, error) {
+	switch {
+	case o.dsn != "" && o.apiURL != "":
+		return nil, errors.New("set either --dsn or --api-url, not both")
+	case o.dsn != "":
+		b, err := openDirect(ctx, o)
+		if err != nil {
+			return nil, err
+		}
+		return b, nil
+	case o.apiURL != "":
+		b, err := openRemote(o)
+		if err != nil {
+			return nil, err
+		}
+		return b, nil
+	default:
+		return nil, errors.New("set --dsn for direct database access or --api-url for the HTTP API")
+	}
+}
+
+// allTenants and allMembers page through a list until it is exhausted.
+func allTenants(ctx context.Context, b backend, filters tenantmod.ListFilters) iter.Seq2[*tenantmod.TenantDTO, error] {
+	return apiclient.Paginate(func(page int) ([]*tenantmod.TenantDTO, int, error) {
+		filters.Page = page
+		res, err := b.ListTenants(ctx, filters)
+		if err != nil {
+			return nil, 0, err
+		}
+		return res.Tenants, res.TotalPages, nil
+	})
+}
+
+func allMembers(ctx context.Context, b backend, tenantID uuid.UUID, filters tenantmod.MemberListFilters) iter.Seq2[*tenantmod.MemberDTO, error] {
+	return apiclient.Paginate(func(page int) ([]*tenantmod.MemberDTO, int, error) {
+		filters.Page = page
+		res, err := b.ListMembers(ctx, tenantID, filters)
+		if err != nil {
+			return nil, 0, err
+		}
+		return res.Members, res.TotalPages, nil
+	})
+}
+
+// findOrganization returns the organization with id in tree, or nil.
+func findOrganization(tree []*organization.OrganizationTreeNode, id uuid.UUID) *organization.OrganizationTreeNode {
+	for _, n := range tree {
+		if n.ID == id {
+			return n
+		}
+		if found := findOrganization(n.Children, id); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func parseUUID(name, s string) (uuid.UUID, error) {
+	id, err := uuid.Parse(s)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("invalid %s %q", name, s)
+	}
+	return id, nil
+}
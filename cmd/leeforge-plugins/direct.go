@@ -0,0 +1,270 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"entgo.io/ent/dialect"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/leeforge/framework/logging"
+	"github.com/leeforge/framework/plugin"
+
+	"github.com/leeforge/core"
+	coremod "github.com/leeforge/core/core"
+	coreent "github.com/leeforge/core/server/ent"
+	domainsvc "github.com/leeforge/core/server/services/domain"
+
+	oufactory "github.com/leeforge/plugins/ou/factory"
+	"github.com/leeforge/plugins/ou/organization"
+	tenantfactory "github.com/leeforge/plugins/tenant/factory"
+	"github.com/leeforge/plugins/tenant/shared"
+	tenantmod "github.com/leeforge/plugins/tenant/tenant"
+
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// directBackend calls the tenant and organization services on a database
+// connection. Calls act in the platform domain, as --actor when set.
+type directBackend struct {
+	client  *coreent.Client
+	tenants *tenantmod.Service
+	orgs    *organization.Service
+	actor   uuid.UUID
+}
+
+func openDirect(ctx context.Context, o *options) (*directBackend, error) {
+	if o.driver != dialect.Postgres && o.driver != dialect.SQLite {
+		return nil, fmt.Errorf("unsupported --driver %q: use %q or %q", o.driver, dialect.Postgres, dialect.SQLite)
+	}
+	cfg, err := loadTenantConfig(o.tenantConfig)
+	if err != nil {
+		return nil, err
+	}
+	var actor uuid.UUID
+	if o.actor != "" {
+		if actor, err = parseUUID("--actor", o.actor); err != nil {
+			return nil, err
+		}
+	}
+
+	client, err := coreent.Open(o.driver, o.dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open database: %w", err)
+	}
+	return newDirectBackend(client, cfg, actor), nil
+}
+
+// newDirectBackend wires the services the way the plugins' Enable does.
+func newDirectBackend(client *coreent.Client, cfg shared.Config, actor uuid.UUID) *directBackend {
+	logger := logging.FromZap(zap.NewNop())
+	factory := tenantfactory.NewEntFactory(client)
+	bus := &webhookBus{}
+	tenants := factory.NewTenantService(domainWriter{svc: domainsvc.NewService(client, logger)}, bus, logger)
+	tenants.SetConfig(cfg)
+	tenants.SetRoleCatalog(factory.RoleCatalog())
+	tenants.SetMemberTermStore(factory.MemberTerms())
+	tenants.SetPermissionResolver(factory.Permissions())
+	tenants.SetRoleTemplateSource(factory.RoleTemplateSource())
+	bus.tenants = tenants
+
+	return &directBackend{
+		client:  client,
+		tenants: tenants,
+		orgs:    oufactory.NewEntFactory(client).NewOrganizationService(),
+		actor:   actor,
+	}
+}
+
+// loadTenantConfig reads the tenant plugin's config section from a JSON
+// file, so that role templates and other settings match the host's.
+func loadTenantConfig(path string) (shared.Config, error) {
+	var cfg shared.Config
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return shared.Config{}, fmt.Errorf("read tenant config: %w", err)
+		}
+		var raw map[string]any
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return shared.Config{}, fmt.Errorf("parse tenant config: %w", err)
+		}
+		if err := shared.CheckConfigKeys(raw); err != nil {
+			return shared.Config{}, fmt.Errorf("tenant config: %w", err)
+		}
+		if err := json.Unmarshal(data, &cfg); err != nil {
+			return shared.Config{}, fmt.Errorf("parse tenant config: %w", err)
+		}
+	}
+	cfg = cfg.WithDefaults()
+	if err := cfg.Validate(); err != nil {
+		return shared.Config{}, fmt.Errorf("tenant config: %w", err)
+	}
+	return cfg, nil
+}
+
+// ctx returns ctx acting in the platform domain as the actor.
+func (d *directBackend) ctx(ctx context.Context) context.Context {
+	ctx = coremod.WithActingContext(ctx, &coremod.ActingContext{
+		ActorID: d.actor,
+		Domain:  &coremod.ResolvedDomain{TypeCode: string(coremod.DomainPlatform), Key: "root"},
+	})
+	if d.actor != uuid.Nil {
+		ctx = core.WithIdentity(ctx, core.Identity{UserID: d.actor})
+	}
+	return ctx
+}
+
+// orgCtx returns ctx acting in the domain of tenant.
+func (d *directBackend) orgCtx(ctx context.Context, tenant *tenantmod.TenantDTO) context.Context {
+	return coremod.WithDomainID(d.ctx(ctx), tenant.DomainID.String())
+}
+
+func (d *directBackend) Tenant(ctx context.Context, ref string) (*tenantmod.TenantDTO, error) {
+	if id, err := uuid.Parse(ref); err == nil {
+		return d.tenants.GetTenant(d.ctx(ctx), id)
+	}
+	return d.tenants.GetTenantByCode(d.ctx(ctx), ref)
+}
+
+func (d *directBackend) ListTenants(ctx context.Context, filters tenantmod.ListFilters) (*tenantmod.ListResult, error) {
+	return d.tenants.ListTenants(d.ctx(ctx), filters)
+}
+
+func (d *directBackend) CreateTenant(ctx context.Context, req *tenantmod.CreateRequest) (*tenantmod.TenantDTO, error) {
+	return d.tenants.CreateTenant(d.ctx(ctx), req)
+}
+
+func (d *directBackend) DeleteTenant(ctx context.Context, id uuid.UUID) error {
+	return d.tenants.DeleteTenant(d.ctx(ctx), id)
+}
+
+func (d *directBackend) ListMembers(ctx context.Context, tenantID uuid.UUID, filters tenantmod.MemberListFilters) (*tenantmod.MemberListResult, error) {
+	return d.tenants.ListMembers(d.ctx(ctx), tenantID, filters)
+}
+
+func (d *directBackend) AddMember(ctx context.Context, tenantID uuid.UUID, req *tenantmod.AddMemberRequest) error {
+	userID, err := parseUUID("user ID", req.UserID)
+	if err != nil {
+		return err
+	}
+	return d.tenants.AddMember(d.ctx(ctx), tenantID, userID, req.Role, req.MemberTerms)
+}
+
+func (d *directBackend) RemoveMember(ctx context.Context, tenantID, userID uuid.UUID) error {
+	return d.tenants.RemoveMember(d.ctx(ctx), tenantID, userID)
+}
+
+func (d *directBackend) SuspendMember(ctx context.Context, tenantID, userID uuid.UUID, reason string) error {
+	return d.tenants.SuspendMember(d.ctx(ctx), tenantID, userID, reason)
+}
+
+func (d *directBackend) ReactivateMember(ctx context.Context, tenantID, userID uuid.UUID) error {
+	return d.tenants.ReactivateMember(d.ctx(ctx), tenantID, userID)
+}
+
+func (d *directBackend) OrganizationTree(ctx context.Context, tenant *tenantmod.TenantDTO) ([]*organization.OrganizationTreeNode, error) {
+	return d.orgs.GetOrganizationTree(d.orgCtx(ctx, tenant))
+}
+
+func (d *directBackend) CreateOrganization(ctx context.Context, tenant *tenantmod.TenantDTO, req *organization.CreateOrganizationRequest) (*organization.OrganizationResponse, error) {
+	return d.orgs.CreateOrganization(d.orgCtx(ctx, tenant), req)
+}
+
+func (d *directBackend) AddOrganizationMember(ctx context.Context, tenant *tenantmod.TenantDTO, orgID uuid.UUID, req *organization.AddOrganizationMemberRequest) (*organization.OrganizationMemberResponse, error) {
+	return d.orgs.AddOrganizationMember(d.orgCtx(ctx, tenant), orgID, req)
+}
+
+func (d *directBackend) Close() error { return d.client.Close() }
+
+// webhookBus stands in for the host's event bus. The CLI has no subscribers,
+// so events are only queued for the tenant webhooks, which the host's
+// dispatcher then delivers.
+type webhookBus struct {
+	tenants *tenantmod.Service
+}
+
+func (b *webhookBus) Publish(ctx context.Context, e plugin.Event) error {
+	return b.tenants.EnqueueWebhookEvent(ctx, e)
+}
+
+func (b *webhookBus) Subscribe(string, plugin.EventHandler) plugin.Subscription { return nil }
+func (b *webhookBus) Close() error                                              { return nil }
+
+// domainWriter adapts core's domain service, which works with the
+// core/core domain types, to the core.DomainWriter the tenant service takes.
+type domainWriter struct {
+	svc *domainsvc.Service
+}
+
+func (w domainWriter) EnsureDomain(ctx context.Context, typeCode, key, displayName string) (*core.ResolvedDomain, error) {
+	d, err := w.svc.EnsureDomain(ctx, typeCode, key, displayName)
+	return toResolvedDomain(d), err
+}
+
+func (w domainWriter) AddMembership(ctx context.Context, domainID, subjectID uuid.UUID, memberRole string, isDefault bool) error {
+	return w.svc.AddMembership(ctx, domainID, subjectID, memberRole, isDefault)
+}
+
+func (w domainWriter) RemoveMembership(ctx context.Context, domainID, subjectID uuid.UUID) error {
+	return w.svc.RemoveMembership(ctx, domainID, subjectID)
+}
+
+func (w domainWriter) ResolveDomain(ctx context.Context, typeCode, key string) (*core.ResolvedDomain, error) {
+	d, err := w.svc.ResolveDomain(ctx, typeCode, key)
+	return toResolvedDomain(d), err
+}
+
+func (w domainWriter) ResolveDomainByID(ctx context.Context, domainID uuid.UUID) (*core.ResolvedDomain, error) {
+	d, err := w.svc.ResolveDomainByID(ctx, domainID)
+	return toResolvedDomain(d), err
+}
+
+func (w domainWriter) CheckMembership(ctx context.Context, domainID, subjectID uuid.UUID) (bool, error) {
+	return w.svc.CheckMembership(ctx, domainID, subjectID)
+}
+
+func (w domainWriter) GetUserDefaultDomain(ctx context.Context, userID uuid.UUID) (*core.ResolvedDomain, error) {
+	d, err := w.svc.GetUserDefaultDomain(ctx, userID)
+	return toResolvedDomain(d), err
+}
+
+func (w domainWriter) GetDomainString(typeCode, key string) string {
+	return w.svc.GetDomainString(typeCode, key)
+}
+
+func (w domainWriter) ListUserDomains(ctx context.Context, userID uuid.UUID) ([]*core.UserDomainInfo, error) {
+	items, err := w.svc.ListUserDomains(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*core.UserDomainInfo, 0, len(items))
+	for _, item := range items {
+		out = append(out, &core.UserDomainInfo{
+			DomainID:    item.DomainID,
+			TypeCode:    item.TypeCode,
+			Key:         item.Key,
+			DisplayName: item.DisplayName,
+			MemberRole:  item.MemberRole,
+			IsDefault:   item.IsDefault,
+		})
+	}
+	return out, nil
+}
+
+func toResolvedDomain(d *coremod.ResolvedDomain) *core.ResolvedDomain {
+	if d == nil {
+		return nil
+	}
+	return &core.ResolvedDomain{DomainID: d.DomainID, TypeCode: d.TypeCode, Key: d.Key, DisplayName: d.DisplayName}
+}
+
+var (
+	_ backend           = (*directBackend)(nil)
+	_ core.DomainWriter = domainWriter{}
+	_ plugin.EventBus   = (*webhookBus)(nil)
+)
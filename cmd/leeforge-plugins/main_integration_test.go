@@ -0,0 +1,319 @@
+//go:build integration
+// +build integration
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"entgo.io/ent/dialect"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/leeforge/framework/logging"
+
+	"github.com/leeforge/core"
+	coremod "github.com/leeforge/core/core"
+	coreent "github.com/leeforge/core/server/ent"
+	"github.com/leeforge/core/server/ent/enttest"
+
+	"github.com/leeforge/plugins/apiclient"
+	oufactory "github.com/leeforge/plugins/ou/factory"
+	"github.com/leeforge/plugins/ou/organization"
+	tenantfactory "github.com/leeforge/plugins/tenant/factory"
+	"github.com/leeforge/plugins/tenant/shared"
+	tenantmod "github.com/leeforge/plugins/tenant/tenant"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// memDomains is an in-memory core.DomainWriter.
+type memDomains struct {
+	core.DomainWriter
+
+	mu      sync.Mutex
+	domains map[string]*core.ResolvedDomain
+	members map[[2]uuid.UUID]bool
+}
+
+func (m *memDomains) ResolveDomain(_ context.Context, typeCode, key string) (*core.ResolvedDomain, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if d, ok := m.domains[typeCode+":"+key]; ok {
+		return d, nil
+	}
+	return nil, shared.ErrTenantNotFound
+}
+
+func (m *memDomains) EnsureDomain(_ context.Context, typeCode, key, displayName string) (*core.ResolvedDomain, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if d, ok := m.domains[typeCode+":"+key]; ok {
+		return d, nil
+	}
+	d := &core.ResolvedDomain{DomainID: uuid.New(), TypeCode: typeCode, Key: key, DisplayName: displayName}
+	m.domains[typeCode+":"+key] = d
+	return d, nil
+}
+
+func (m *memDomains) CheckMembership(_ context.Context, domainID, subjectID uuid.UUID) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.members[[2]uuid.UUID{domainID, subjectID}], nil
+}
+
+func (m *memDomains) AddMembership(_ context.Context, domainID, subjectID uuid.UUID, _ string, _ bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.members[[2]uuid.UUID{domainID, subjectID}] = true
+	return nil
+}
+
+func (m *memDomains) RemoveMembership(_ context.Context, domainID, subjectID uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.members, [2]uuid.UUID{domainID, subjectID})
+	return nil
+}
+
+type nopSeeder struct{}
+
+func (nopSeeder) SeedBaselineRoles(context.Context, uuid.UUID) error { return nil }
+
+// cli runs commands against a direct backend on client.
+type cli struct {
+	t      *testing.T
+	client *coreent.Client
+	direct *directBackend
+	args   []string
+}
+
+// newCLI wires the services as newDirectBackend does, except for the domain
+// service and role seeder: they write outside the tenant transaction, which
+// an SQLite database locks.
+func newCLI(t *testing.T) *cli {
+	t.Helper()
+	client := enttest.Open(t, dialect.SQLite, "file:"+t.Name()+"?mode=memory&cache=shared&_fk=1")
+	t.Cleanup(func() { _ = client.Close() })
+
+	domains := &memDomains{domains: map[string]*core.ResolvedDomain{}, members: map[[2]uuid.UUID]bool{}}
+	factory := tenantfactory.NewEntFactory(client)
+	bus := &webhookBus{}
+	tenants := tenantmod.NewService(client, domains, bus, logging.FromZap(zap.NewNop()), nopSeeder{}, factory.UserLookup())
+	tenants.SetMemberTermStore(factory.MemberTerms())
+	tenants.SetAttributeStore(factory.TenantAttributes())
+	tenants.SetWebhookStore(factory.Webhooks())
+	tenants.SetConfig(shared.Config{}.WithDefaults())
+	bus.tenants = tenants
+
+	return &cli{t: t, client: client, direct: &directBackend{
+		client:  client,
+		tenants: tenants,
+		orgs:    oufactory.NewEntFactory(client).NewOrganizationService(),
+	}}
+}
+
+func (c *cli) run(args ...string) (string, error) {
+	c.t.Helper()
+	var out bytes.Buffer
+	cmd := newRootCmd(&out, &out)
+	cmd.SetArgs(append(append([]string{}, c.args...), args...))
+	err := cmd.ExecuteContext(context.Background())
+	return out.String(), err
+}
+
+func (c *cli) runDirect(args ...string) (string, error) {
+	c.t.Helper()
+	var out bytes.Buffer
+	// The backend is swapped for one on the test database. Flags still
+	// parse as usual; --dsn only has to be set.
+	cmd := newCommand(&options{open: func(context.Context, *options) (backend, error) {
+		return unclosable{c.direct}, nil
+	}}, &out, &out)
+	cmd.SetArgs(append([]string{"--dsn", "test"}, args...))
+	err := cmd.ExecuteContext(context.Background())
+	return out.String(), err
+}
+
+// unclosable keeps the shared test client open between commands.
+type unclosable struct{ *directBackend }
+
+func (unclosable) Close() error { return nil }
+
+func (c *cli) createUser(username string) uuid.UUID {
+	c.t.Helper()
+	u, err := c.client.User.Create().SetUsername(username).SetEmail(username + "@example.com").Save(context.Background())
+	require.NoError(c.t, err)
+	return u.ID
+}
+
+func decode[T any](t *testing.T, out string) T {
+	t.Helper()
+	var v T
+	require.NoError(t, json.Unmarshal([]byte(out), &v), out)
+	return v
+}
+
+func TestCLI_Direct(t *testing.T) {
+	c := newCLI(t)
+	c.direct.actor = c.createUser("operator")
+	alice := c.createUser("alice")
+
+	out, err := c.runDirect("tenant", "create", "--code", "acme", "--name", "Acme", "--label", "plan=pro", "--dry-run")
+	require.NoError(t, err)
+	require.Equal(t, "Would create tenant acme (dry run, nothing changed)\n", out)
+	_, err = c.runDirect("tenant", "get", "acme")
+	require.ErrorIs(t, err, shared.ErrTenantNotFound)
+
+	out, err = c.runDirect("-o", "json", "tenant", "create", "--code", "acme", "--name", "Acme", "--label", "plan=pro")
+	require.NoError(t, err)
+	created := decode[struct {
+		DryRun bool                `json:"dryRun"`
+		Result tenantmod.TenantDTO `json:"result"`
+	}](t, out)
+	require.False(t, created.DryRun)
+	require.Equal(t, "acme", created.Result.Code)
+	require.Equal(t, map[string]string{"plan": "pro"}, created.Result.Labels)
+	require.Equal(t, c.direct.actor, *created.Result.OwnerID)
+
+	_, err = c.runDirect("tenant", "create", "--code", "acme", "--name", "Again", "--dry-run")
+	require.ErrorIs(t, err, shared.ErrTenantCodeExists)
+	_, err = c.runDirect("tenant", "create", "--code", "acme", "--name", "Again")
+	require.ErrorIs(t, err, shared.ErrTenantCodeExists)
+
+	out, err = c.runDirect("tenant", "list", "--selector", "plan=pro")
+	require.NoError(t, err)
+	require.Contains(t, out, "ID")
+	require.Contains(t, out, "acme")
+
+	// Members.
+	out, err = c.runDirect("tenant", "members", "add", "acme", alice.String(), "--dry-run")
+	require.NoError(t, err)
+	require.Contains(t, out, "Would add member "+alice.String())
+	out, err = c.runDirect("tenant", "members", "suspend", "acme", alice.String(), "--dry-run")
+	require.ErrorIs(t, err, shared.ErrMemberNotFound)
+
+	_, err = c.runDirect("tenant", "members", "add", "acme", alice.String())
+	require.NoError(t, err)
+	out, err = c.runDirect("tenant", "members", "suspend", "acme", alice.String(), "--reason", "incident")
+	require.NoError(t, err)
+	require.Contains(t, out, "Suspended member "+alice.String())
+	_, err = c.runDirect("tenant", "members", "suspend", "acme", alice.String())
+	require.ErrorIs(t, err, shared.ErrMemberSuspended)
+
+	out, err = c.runDirect("-o", "json", "tenant", "members", "list", "acme", "--status", "all", "--all")
+	require.NoError(t, err)
+	members := decode[tenantmod.MemberListResult](t, out)
+	require.Len(t, members.Members, 2)
+
+	// Organizations.
+	out, err = c.runDirect("-o", "json", "ou", "create", "--tenant", "acme", "--code", "hq", "--name", "HQ")
+	require.NoError(t, err)
+	hq := decode[struct {
+		Result organization.OrganizationResponse `json:"result"`
+	}](t, out).Result
+	_, err = c.runDirect("ou", "create", "--tenant", "acme", "--code", "sales", "--name", "Sales", "--parent", uuid.NewString(), "--dry-run")
+	require.ErrorIs(t, err, organization.ErrOrganizationNotFound)
+	_, err = c.runDirect("ou", "create", "--tenant", "acme", "--code", "sales", "--name", "Sales", "--parent", hq.ID.String())
+	require.NoError(t, err)
+	_, err = c.runDirect("ou", "members", "add", "--tenant", "acme", hq.ID.String(), alice.String(), "--primary")
+	require.NoError(t, err)
+
+	out, err = c.runDirect("ou", "tree", "--tenant", "acme")
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	require.Len(t, lines, 3)
+	require.True(t, strings.HasPrefix(lines[1], "HQ "))
+	require.True(t, strings.HasPrefix(lines[2], "  Sales "))
+
+	out, err = c.runDirect("tenant", "delete", "acme")
+	require.NoError(t, err)
+	require.Contains(t, out, "Deleted tenant acme")
+}
+
+func TestCLI_Remote(t *testing.T) {
+	c := newCLI(t)
+	admin := c.createUser("admin")
+	bob := c.createUser("bob")
+
+	// The API server shares the database with the CLI. Its middleware stands
+	// in for the host's: the bearer token is the caller's ID and the tenant
+	// header selects the tenant domain by code.
+	direct := c.direct
+	tenantH := tenantmod.NewHandler(direct.tenants, logging.FromZap(zap.NewNop()))
+	orgH := organization.NewHandler(direct.orgs, logging.FromZap(zap.NewNop()))
+	r := chi.NewRouter()
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			userID, err := uuid.Parse(strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer "))
+			if err != nil {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			ctx := core.WithIdentity(req.Context(), core.Identity{UserID: userID})
+			ctx = coremod.WithActingContext(ctx, &coremod.ActingContext{
+				Domain: &coremod.ResolvedDomain{TypeCode: string(coremod.DomainPlatform), Key: "root"},
+			})
+			if code := req.Header.Get(apiclient.DefaultTenantHeader); code != "" {
+				t, err := direct.Tenant(ctx, code)
+				if err != nil {
+					w.WriteHeader(http.StatusForbidden)
+					return
+				}
+				ctx = coremod.WithDomainID(ctx, t.DomainID.String())
+			}
+			next.ServeHTTP(w, req.WithContext(ctx))
+		})
+	})
+	r.Route("/api/v1/tenants", func(r chi.Router) {
+		r.Get("/", tenantH.ListTenants)
+		r.Post("/", tenantH.CreateTenant)
+		r.Get("/{id}", tenantH.GetTenant)
+		r.Post("/{id}/members", tenantH.AddMember)
+		r.Get("/{id}/members", tenantH.ListMembers)
+	})
+	r.Route("/api/v1/ou/organizations", func(r chi.Router) {
+		r.Post("/", orgH.CreateOrganization)
+		r.Get("/tree", orgH.GetOrganizationTree)
+	})
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+
+	c.args = []string{"--api-url", srv.URL + "/api/v1", "--token", admin.String()}
+	out, err := c.run("tenant", "create", "--code", "globex", "--name", "Globex")
+	require.NoError(t, err)
+	require.Contains(t, out, "Created tenant globex")
+
+	_, err = c.run("tenant", "create", "--code", "globex", "--name", "Globex", "--dry-run")
+	require.ErrorIs(t, err, shared.ErrTenantCodeExists)
+
+	_, err = c.run("tenant", "members", "add", "globex", bob.String(), "--role", "member")
+	require.NoError(t, err)
+	out, err = c.run("tenant", "members", "list", "globex")
+	require.NoError(t, err)
+	require.Contains(t, out, "bob@example.com")
+
+	_, err = c.run("ou", "create", "--tenant", "globex", "--code", "hq", "--name", "HQ")
+	require.NoError(t, err)
+	out, err = c.run("-o", "json", "ou", "tree", "--tenant", "globex")
+	require.NoError(t, err)
+	tree := decode[[]*organization.OrganizationTreeNode](t, out)
+	require.Len(t, tree, 1)
+	require.Equal(t, "hq", tree[0].Code)
+
+	_, err = c.run("ou", "tree", "--tenant", "initech")
+	require.ErrorIs(t, err, shared.ErrTenantNotFound)
+
+	c.args = []string{"--api-url", srv.URL + "/api/v1"}
+	_, err = c.run("tenant", "list")
+	require.True(t, apiclient.IsStatus(err, http.StatusUnauthorized))
+}
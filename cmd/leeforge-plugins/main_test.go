@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/leeforge/plugins/ou/organization"
+	tenantmod "github.com/leeforge/plugins/tenant/tenant"
+)
+
+func execute(args ...string) (string, error) {
+	var out bytes.Buffer
+	cmd := newRootCmd(&out, &out)
+	cmd.SetArgs(args)
+	err := cmd.ExecuteContext(context.Background())
+	return out.String(), err
+}
+
+func TestRootCmd_Validation(t *testing.T) {
+	_, err := execute("tenant", "list")
+	require.ErrorContains(t, err, "set --dsn for direct database access or --api-url")
+
+	_, err = execute("--dsn", "x", "--api-url", "http://localhost", "tenant", "list")
+	require.ErrorContains(t, err, "not both")
+
+	_, err = execute("--dsn", "x", "--driver", "oracle", "tenant", "list")
+	require.ErrorContains(t, err, `unsupported --driver "oracle"`)
+
+	_, err = execute("--api-url", "http://localhost", "--actor", uuid.NewString(), "tenant", "list")
+	require.ErrorContains(t, err, "--actor applies to direct access")
+
+	_, err = execute("-o", "yaml", "tenant", "list")
+	require.ErrorContains(t, err, "--output must be")
+
+	_, err = execute("--api-url", "http://localhost", "tenant", "members", "add", "acme", "not-a-uuid")
+	require.ErrorContains(t, err, `invalid user ID "not-a-uuid"`)
+
+	_, err = execute("--api-url", "http://localhost", "ou", "tree")
+	require.ErrorContains(t, err, `required flag(s) "tenant" not set`)
+}
+
+func TestPrinter(t *testing.T) {
+	var buf bytes.Buffer
+	p := printer{w: &buf, format: outputTable}
+
+	require.NoError(t, p.change(change{DryRun: true, Action: "suspend member", Target: "bob in acme"}))
+	require.Equal(t, "Would suspend member bob in acme (dry run, nothing changed)\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, p.change(change{Action: "add organization member", Target: "bob to sales"}))
+	require.Equal(t, "Added organization member bob to sales\n", buf.String())
+
+	buf.Reset()
+	root, child := uuid.New(), uuid.New()
+	require.NoError(t, p.orgTree([]*organization.OrganizationTreeNode{{
+		ID: root, Code: "hq", Name: "HQ", Path: "hq",
+		Children: []*organization.OrganizationTreeNode{{ID: child, Code: "sales", Name: "Sales", Path: "hq/sales"}},
+	}}))
+	require.Equal(t, "NAME     CODE   PATH      ID\n"+
+		"HQ       hq     hq        "+root.String()+"\n"+
+		"  Sales  sales  hq/sales  "+child.String()+"\n", buf.String())
+
+	buf.Reset()
+	p.format = outputJSON
+	require.NoError(t, p.tenants(&tenantmod.ListResult{Tenants: []*tenantmod.TenantDTO{}}))
+	require.JSONEq(t, `{"tenants":[],"total":0,"page":0,"pageSize":0,"totalPages":0}`, buf.String())
+
+	buf.Reset()
+	require.NoError(t, p.orgTree(nil))
+	require.JSONEq(t, `[]`, buf.String())
+}
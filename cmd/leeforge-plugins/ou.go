@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+
+	"github.com/leeforge/plugins/ou/organization"
+	tenantmod "github.com/leeforge/plugins/tenant/tenant"
+)
+
+func newOUCmd(o *options) *cobra.Command {
+	var tenant string
+	cmd := &cobra.Command{
+		Use:   "ou",
+		Short: "Manage the organization units of a tenant",
+	}
+	cmd.PersistentFlags().StringVarP(&tenant, "tenant", "t", "", "tenant ID or code (required)")
+	_ = cmd.MarkPersistentFlagRequired("tenant")
+
+	members := &cobra.Command{
+		Use:   "members",
+		Short: "Manage the members of organizations",
+	}
+	members.AddCommand(newOUMembersAddCmd(o, &tenant))
+	cmd.AddCommand(newOUTreeCmd(o, &tenant), newOUCreateCmd(o, &tenant), members)
+	return cmd
+}
+
+// withTenant opens the backend and resolves the --tenant flag.
+func (o *options) withTenant(cmd *cobra.Command, ref string, fn func(ctx context.Context, b backend, t *tenantmod.TenantDTO) error) error {
+	return o.withBackend(cmd, func(ctx context.Context, b backend) error {
+		t, err := b.Tenant(ctx, ref)
+		if err != nil {
+			return err
+		}
+		return fn(ctx, b, t)
+	})
+}
+
+func newOUTreeCmd(o *options, tenant *string) *cobra.Command {
+	return &cobra.Command{
+		Use:   "tree",
+		Short: "Show the organization tree of a tenant",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return o.withTenant(cmd, *tenant, func(ctx context.Context, b backend, t *tenantmod.TenantDTO) error {
+				tree, err := b.OrganizationTree(ctx, t)
+				if err != nil {
+					return err
+				}
+				return o.printer(cmd.OutOrStdout()).orgTree(tree)
+			})
+		},
+	}
+}
+
+func newOUCreateCmd(o *options, tenant *string) *cobra.Command {
+	var (
+		req    organization.CreateOrganizationRequest
+		parent string
+		dryRun bool
+	)
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "Create an organization",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if parent != "" {
+				id, err := parseUUID("--parent", parent)
+				if err != nil {
+					return err
+				}
+				req.ParentID = &id
+			}
+			return o.withTenant(cmd, *tenant, func(ctx context.Context, b backend, t *tenantmod.TenantDTO) error {
+				c := change{DryRun: dryRun, Action: "create organization", Target: fmt.Sprintf("%s in %s", req.Code, tenantLabel(t)), Request: &req}
+				if dryRun {
+					if req.ParentID != nil {
+						if err := findOrganizationIn(ctx, b, t, *req.ParentID); err != nil {
+							return err
+						}
+					}
+					return o.printer(cmd.OutOrStdout()).change(c)
+				}
+				org, err := b.CreateOrganization(ctx, t, &req)
+				if err != nil {
+					return err
+				}
+				c.Target, c.Result = fmt.Sprintf("%s (%s) in %s", org.Path, org.ID, tenantLabel(t)), org
+				return o.printer(cmd.OutOrStdout()).change(c)
+			})
+		},
+	}
+	f := cmd.Flags()
+	f.StringVar(&req.Code, "code", "", "organization code (required)")
+	f.StringVar(&req.Name, "name", "", "organization name (required)")
+	f.StringVar(&parent, "parent", "", "parent organization ID")
+	f.BoolVar(&dryRun, "dry-run", false, "check and print the change without making it")
+	_ = cmd.MarkFlagRequired("code")
+	_ = cmd.MarkFlagRequired("name")
+	return cmd
+}
+
+func newOUMembersAddCmd(o *options, tenant *string) *cobra.Command {
+	var (
+		req    organization.AddOrganizationMemberRequest
+		dryRun bool
+	)
+	cmd := &cobra.Command{
+		Use:   "add <organization-id> <user-id>",
+		Short: "Add a user to an organization",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			orgID, err := parseUUID("organization ID", args[0])
+			if err != nil {
+				return err
+			}
+			if req.UserID, err = parseUUID("user ID", args[1]); err != nil {
+				return err
+			}
+			return o.withTenant(cmd, *tenant, func(ctx context.Context, b backend, t *tenantmod.TenantDTO) error {
+				c := change{DryRun: dryRun, Action: "add organization member", Target: fmt.Sprintf("%s to %s", req.UserID, orgID), Request: &req}
+				if dryRun {
+					if err := findOrganizationIn(ctx, b, t, orgID); err != nil {
+						return err
+					}
+					return o.printer(cmd.OutOrStdout()).change(c)
+				}
+				member, err := b.AddOrganizationMember(ctx, t, orgID, &req)
+				if err != nil {
+					return err
+				}
+				c.Result = member
+				return o.printer(cmd.OutOrStdout()).change(c)
+			})
+		},
+	}
+	f := cmd.Flags()
+	f.BoolVar(&req.IsPrimary, "primary", false, "make it the user's primary organization")
+	f.BoolVar(&dryRun, "dry-run", false, "check and print the change without making it")
+	return cmd
+}
+
+// findOrganizationIn returns organization.ErrOrganizationNotFound unless id
+// is an organization of the tenant.
+func findOrganizationIn(ctx context.Context, b backend, t *tenantmod.TenantDTO, id uuid.UUID) error {
+	tree, err := b.OrganizationTree(ctx, t)
+	if err != nil {
+		return err
+	}
+	if findOrganization(tree, id) == nil {
+		return organization.ErrOrganizationNotFound
+	}
+	return nil
+}
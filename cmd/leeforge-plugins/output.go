@@ -0,0 +1,167 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/leeforge/plugins/ou/organization"
+	tenantmod "github.com/leeforge/plugins/tenant/tenant"
+)
+
+// Output formats.
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+// printer writes command results in the selected format. JSON output is the
+// value as the API would return it; table output is for reading.
+type printer struct {
+	w      io.Writer
+	format string
+}
+
+func (o *options) printer(w io.Writer) printer {
+	return printer{w: w, format: o.output}
+}
+
+// print writes v as JSON, or calls table to write it as a table.
+func (p printer) print(v any, table func(tw *tabwriter.Writer)) error {
+	if p.format == outputJSON {
+		enc := json.NewEncoder(p.w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+	tw := tabwriter.NewWriter(p.w, 0, 4, 2, ' ', 0)
+	table(tw)
+	return tw.Flush()
+}
+
+// change is the result of a mutation, or of its dry run.
+type change struct {
+	DryRun bool   `json:"dryRun"`
+	Action string `json:"action"`
+	Target string `json:"target"`
+	// Request is what the mutation sends; Result what it returned.
+	Request any `json:"request,omitempty"`
+	Result  any `json:"result,omitempty"`
+}
+
+func (p printer) change(c change) error {
+	return p.print(c, func(tw *tabwriter.Writer) {
+		if c.DryRun {
+			fmt.Fprintf(tw, "Would %s %s (dry run, nothing changed)\n", c.Action, c.Target)
+			return
+		}
+		fmt.Fprintf(tw, "%s %s\n", pastTense(c.Action), c.Target)
+	})
+}
+
+func pastTense(action string) string {
+	verb, rest, _ := strings.Cut(action, " ")
+	if strings.HasSuffix(verb, "e") {
+		verb += "d"
+	} else {
+		verb += "ed"
+	}
+	verb = strings.ToUpper(verb[:1]) + verb[1:]
+	if rest == "" {
+		return verb
+	}
+	return verb + " " + rest
+}
+
+func (p printer) tenants(res *tenantmod.ListResult) error {
+	return p.print(res, func(tw *tabwriter.Writer) {
+		fmt.Fprintln(tw, "ID\tCODE\tNAME\tSTATUS\tPARENT\tCREATED")
+		for _, t := range res.Tenants {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", t.ID, t.Code, t.Name, t.Status, optionalID(t.ParentTenantID), formatTime(t.CreatedAt))
+		}
+		if res.TotalPages > 1 {
+			fmt.Fprintf(tw, "(page %d of %d, %d tenants)\n", res.Page, res.TotalPages, res.Total)
+		}
+	})
+}
+
+func (p printer) tenant(t *tenantmod.TenantDTO) error {
+	return p.print(t, func(tw *tabwriter.Writer) {
+		fmt.Fprintf(tw, "ID:\t%s\n", t.ID)
+		fmt.Fprintf(tw, "Code:\t%s\n", t.Code)
+		fmt.Fprintf(tw, "Name:\t%s\n", t.Name)
+		if t.Description != "" {
+			fmt.Fprintf(tw, "Description:\t%s\n", t.Description)
+		}
+		fmt.Fprintf(tw, "Status:\t%s\n", t.Status)
+		fmt.Fprintf(tw, "Owner:\t%s\n", optionalID(t.OwnerID))
+		fmt.Fprintf(tw, "Parent:\t%s\n", optionalID(t.ParentTenantID))
+		fmt.Fprintf(tw, "Domain:\t%s\n", t.DomainID)
+		for _, k := range slices.Sorted(maps.Keys(t.Labels)) {
+			fmt.Fprintf(tw, "Label:\t%s=%s\n", k, t.Labels[k])
+		}
+		fmt.Fprintf(tw, "Created:\t%s\n", formatTime(t.CreatedAt))
+		fmt.Fprintf(tw, "Updated:\t%s\n", formatTime(t.UpdatedAt))
+	})
+}
+
+func (p printer) members(res *tenantmod.MemberListResult) error {
+	return p.print(res, func(tw *tabwriter.Writer) {
+		fmt.Fprintln(tw, "USER ID\tUSERNAME\tEMAIL\tROLE\tSTATUS\tTYPE\tEXPIRES")
+		for _, m := range res.Members {
+			expires := "-"
+			if m.ExpiresAt != nil {
+				expires = formatTime(*m.ExpiresAt)
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", m.ID, m.Username, m.Email, orDash(m.Role), m.Status, m.MembershipType, expires)
+		}
+		if res.TotalPages > 1 {
+			fmt.Fprintf(tw, "(page %d of %d, %d members)\n", res.Page, res.TotalPages, res.Total)
+		}
+	})
+}
+
+// orgTree writes the tree with names indented by depth.
+func (p printer) orgTree(tree []*organization.OrganizationTreeNode) error {
+	if tree == nil {
+		tree = []*organization.OrganizationTreeNode{}
+	}
+	return p.print(tree, func(tw *tabwriter.Writer) {
+		fmt.Fprintln(tw, "NAME\tCODE\tPATH\tID")
+		var walk func(nodes []*organization.OrganizationTreeNode, depth int)
+		walk = func(nodes []*organization.OrganizationTreeNode, depth int) {
+			for _, n := range nodes {
+				fmt.Fprintf(tw, "%s%s\t%s\t%s\t%s\n", strings.Repeat("  ", depth), n.Name, n.Code, n.Path, n.ID)
+				walk(n.Children, depth+1)
+			}
+		}
+		walk(tree, 0)
+	})
+}
+
+func optionalID(id *uuid.UUID) string {
+	if id == nil {
+		return "-"
+	}
+	return id.String()
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.UTC().Format(time.RFC3339)
+}
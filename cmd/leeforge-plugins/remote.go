@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/leeforge/plugins/apiclient"
+	ouclient "github.com/leeforge/plugins/ou/client"
+	"github.com/leeforge/plugins/ou/organization"
+	tenantclient "github.com/leeforge/plugins/tenant/client"
+	"github.com/leeforge/plugins/tenant/shared"
+	tenantmod "github.com/leeforge/plugins/tenant/tenant"
+)
+
+// remoteBackend calls a host's HTTP API with the plugins' clients.
+type remoteBackend struct {
+	tenants *tenantclient.Client
+	orgs    *ouclient.Client
+}
+
+func openRemote(o *options) (*remoteBackend, error) {
+	if o.token != "" && o.apiKey != "" {
+		return nil, errors.New("set either --token or --api-key, not both")
+	}
+	if o.actor != "" {
+		return nil, errors.New("--actor applies to direct access; the API acts as the authenticated caller")
+	}
+	opts := []apiclient.Option{
+		apiclient.WithHTTPClient(&http.Client{Timeout: 30 * time.Second}),
+		apiclient.WithUserAgent("leeforge-plugins"),
+	}
+	switch {
+	case o.token != "":
+		opts = append(opts, apiclient.WithAuth(apiclient.BearerToken(o.token)))
+	case o.apiKey != "":
+		opts = append(opts, apiclient.WithAuth(apiclient.APIKey(o.apiKey)))
+	}
+	tenants, err := tenantclient.New(o.apiURL, opts...)
+	if err != nil {
+		return nil, err
+	}
+	orgs, err := ouclient.New(o.apiURL, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &remoteBackend{tenants: tenants, orgs: orgs}, nil
+}
+
+// Tenant looks codes up through the tenant list, which matches them by
+// substring.
+func (r *remoteBackend) Tenant(ctx context.Context, ref string) (*tenantmod.TenantDTO, error) {
+	if id, err := uuid.Parse(ref); err == nil {
+		return r.tenants.GetTenant(ctx, id)
+	}
+	for t, err := range r.tenants.Tenants(ctx, tenantmod.ListFilters{Query: ref, PageSize: 100}) {
+		if err != nil {
+			return nil, err
+		}
+		if t.Code == ref {
+			return t, nil
+		}
+	}
+	return nil, shared.ErrTenantNotFound
+}
+
+func (r *remoteBackend) ListTenants(ctx context.Context, filters tenantmod.ListFilters) (*tenantmod.ListResult, error) {
+	return r.tenants.ListTenants(ctx, filters)
+}
+
+func (r *remoteBackend) CreateTenant(ctx context.Context, req *tenantmod.CreateRequest) (*tenantmod.TenantDTO, error) {
+	return r.tenants.CreateTenant(ctx, req)
+}
+
+func (r *remoteBackend) DeleteTenant(ctx context.Context, id uuid.UUID) error {
+	return r.tenants.DeleteTenant(ctx, id)
+}
+
+func (r *remoteBackend) ListMembers(ctx context.Context, tenantID uuid.UUID, filters tenantmod.MemberListFilters) (*tenantmod.MemberListResult, error) {
+	return r.tenants.ListMembers(ctx, tenantID, filters)
+}
+
+func (r *remoteBackend) AddMember(ctx context.Context, tenantID uuid.UUID, req *tenantmod.AddMemberRequest) error {
+	return r.tenants.AddMember(ctx, tenantID, req)
+}
+
+func (r *remoteBackend) RemoveMember(ctx context.Context, tenantID, userID uuid.UUID) error {
+	return r.tenants.RemoveMember(ctx, tenantID, userID)
+}
+
+func (r *remoteBackend) SuspendMember(ctx context.Context, tenantID, userID uuid.UUID, reason string) error {
+	return r.tenants.SuspendMember(ctx, tenantID, userID, reason)
+}
+
+func (r *remoteBackend) ReactivateMember(ctx context.Context, tenantID, userID uuid.UUID) error {
+	return r.tenants.ReactivateMember(ctx, tenantID, userID)
+}
+
+// orgsIn returns the organization client acting in tenant, whose code the
+// host resolves from the tenant header.
+func (r *remoteBackend) orgsIn(tenant *tenantmod.TenantDTO) *ouclient.Client {
+	return r.orgs.With(apiclient.WithTenant(tenant.Code))
+}
+
+func (r *remoteBackend) OrganizationTree(ctx context.Context, tenant *tenantmod.TenantDTO) ([]*organization.OrganizationTreeNode, error) {
+	return r.orgsIn(tenant).GetOrganizationTree(ctx)
+}
+
+func (r *remoteBackend) CreateOrganization(ctx context.Context, tenant *tenantmod.TenantDTO, req *organization.CreateOrganizationRequest) (*organization.OrganizationResponse, error) {
+	return r.orgsIn(tenant).CreateOrganization(ctx, req)
+}
+
+func (r *remoteBackend) AddOrganizationMember(ctx context.Context, tenant *tenantmod.TenantDTO, orgID uuid.UUID, req *organization.AddOrganizationMemberRequest) (*organization.OrganizationMemberResponse, error) {
+	return r.orgsIn(tenant).AddOrganizationMember(ctx, orgID, req)
+}
+
+func (r *remoteBackend) Close() error { return nil }
+
+var _ backend = (*remoteBackend)(nil)
@@ -0,0 +1,344 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+
+	"github.com/leeforge/plugins/tenant/shared"
+	tenantmod "github.com/leeforge/plugins/tenant/tenant"
+)
+
+func newTenantCmd(o *options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "tenant",
+		Short: "Manage tenants and their members",
+	}
+	cmd.AddCommand(
+		newTenantListCmd(o),
+		newTenantGetCmd(o),
+		newTenantCreateCmd(o),
+		newTenantDeleteCmd(o),
+		newMembersCmd(o),
+	)
+	return cmd
+}
+
+func newTenantListCmd(o *options) *cobra.Command {
+	var (
+		filters tenantmod.ListFilters
+		all     bool
+	)
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List tenants",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return o.withBackend(cmd, func(ctx context.Context, b backend) error {
+				if !all {
+					res, err := b.ListTenants(ctx, filters)
+					if err != nil {
+						return err
+					}
+					return o.printer(cmd.OutOrStdout()).tenants(res)
+				}
+				res := &tenantmod.ListResult{Tenants: []*tenantmod.TenantDTO{}, Page: 1, TotalPages: 1}
+				for t, err := range allTenants(ctx, b, filters) {
+					if err != nil {
+						return err
+					}
+					res.Tenants = append(res.Tenants, t)
+				}
+				res.Total, res.PageSize = len(res.Tenants), len(res.Tenants)
+				return o.printer(cmd.OutOrStdout()).tenants(res)
+			})
+		},
+	}
+	f := cmd.Flags()
+	f.StringVarP(&filters.Query, "query", "q", "", "match code or name")
+	f.StringSliceVar(&filters.Statuses, "status", nil, "tenant statuses to include")
+	f.StringVarP(&filters.LabelSelector, "selector", "l", "", "label selector, for example plan=pro")
+	f.BoolVar(&filters.IncludeDeleted, "include-deleted", false, "include deleted tenants")
+	f.IntVar(&filters.Page, "page", 1, "page to list")
+	f.IntVar(&filters.PageSize, "page-size", 50, "tenants per page")
+	f.BoolVar(&all, "all", false, "list every page")
+	return cmd
+}
+
+func newTenantGetCmd(o *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "get <tenant>",
+		Short: "Show a tenant by ID or code",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.withBackend(cmd, func(ctx context.Context, b backend) error {
+				t, err := b.Tenant(ctx, args[0])
+				if err != nil {
+					return err
+				}
+				return o.printer(cmd.OutOrStdout()).tenant(t)
+			})
+		},
+	}
+}
+
+func newTenantCreateCmd(o *options) *cobra.Command {
+	var (
+		req    tenantmod.CreateRequest
+		parent string
+		dryRun bool
+	)
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "Create a tenant",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return o.withBackend(cmd, func(ctx context.Context, b backend) error {
+				if parent != "" {
+					p, err := b.Tenant(ctx, parent)
+					if err != nil {
+						return fmt.Errorf("parent tenant: %w", err)
+					}
+					req.ParentTenantID = p.ID.String()
+				}
+				c := change{DryRun: dryRun, Action: "create tenant", Target: req.Code, Request: &req}
+				if dryRun {
+					if _, err := b.Tenant(ctx, req.Code); err == nil {
+						return shared.ErrTenantCodeExists
+					} else if !errors.Is(err, shared.ErrTenantNotFound) {
+						return err
+					}
+					return o.printer(cmd.OutOrStdout()).change(c)
+				}
+				t, err := b.CreateTenant(ctx, &req)
+				if err != nil {
+					return err
+				}
+				c.Target, c.Result = fmt.Sprintf("%s (%s)", t.Code, t.ID), t
+				return o.printer(cmd.OutOrStdout()).change(c)
+			})
+		},
+	}
+	f := cmd.Flags()
+	f.StringVar(&req.Code, "code", "", "tenant code (required)")
+	f.StringVar(&req.Name, "name", "", "tenant name (required)")
+	f.StringVar(&req.Description, "description", "", "tenant description")
+	f.StringVar(&req.Status, "status", "", "initial status")
+	f.StringVar(&parent, "parent", "", "parent tenant ID or code")
+	f.StringToStringVar(&req.Labels, "label", nil, "label as key=value, repeatable")
+	f.BoolVar(&dryRun, "dry-run", false, "check and print the change without making it")
+	_ = cmd.MarkFlagRequired("code")
+	_ = cmd.MarkFlagRequired("name")
+	return cmd
+}
+
+func newTenantDeleteCmd(o *options) *cobra.Command {
+	var dryRun bool
+	cmd := &cobra.Command{
+		Use:   "delete <tenant>",
+		Short: "Soft-delete a tenant",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.withBackend(cmd, func(ctx context.Context, b backend) error {
+				t, err := b.Tenant(ctx, args[0])
+				if err != nil {
+					return err
+				}
+				c := change{DryRun: dryRun, Action: "delete", Target: tenantLabel(t)}
+				if !dryRun {
+					if err := b.DeleteTenant(ctx, t.ID); err != nil {
+						return err
+					}
+				}
+				return o.printer(cmd.OutOrStdout()).change(c)
+			})
+		},
+	}
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "check and print the change without making it")
+	return cmd
+}
+
+func newMembersCmd(o *options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "members",
+		Short: "Manage the members of a tenant",
+	}
+	cmd.AddCommand(
+		newMembersListCmd(o),
+		newMembersAddCmd(o),
+		newMemberChangeCmd(o, "remove", "Remove a member from a tenant", func(ctx context.Context, b backend, tenantID, userID uuid.UUID) error {
+			return b.RemoveMember(ctx, tenantID, userID)
+		}),
+		newMembersSuspendCmd(o),
+		newMemberChangeCmd(o, "reactivate", "Reactivate a suspended member", func(ctx context.Context, b backend, tenantID, userID uuid.UUID) error {
+			return b.ReactivateMember(ctx, tenantID, userID)
+		}),
+	)
+	return cmd
+}
+
+func newMembersListCmd(o *options) *cobra.Command {
+	var (
+		filters tenantmod.MemberListFilters
+		all     bool
+	)
+	cmd := &cobra.Command{
+		Use:   "list <tenant>",
+		Short: "List the members of a tenant",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.withBackend(cmd, func(ctx context.Context, b backend) error {
+				t, err := b.Tenant(ctx, args[0])
+				if err != nil {
+					return err
+				}
+				if !all {
+					res, err := b.ListMembers(ctx, t.ID, filters)
+					if err != nil {
+						return err
+					}
+					return o.printer(cmd.OutOrStdout()).members(res)
+				}
+				res := &tenantmod.MemberListResult{Members: []*tenantmod.MemberDTO{}, Page: 1, TotalPages: 1}
+				for m, err := range allMembers(ctx, b, t.ID, filters) {
+					if err != nil {
+						return err
+					}
+					res.Members = append(res.Members, m)
+				}
+				res.Total, res.PageSize = len(res.Members), len(res.Members)
+				return o.printer(cmd.OutOrStdout()).members(res)
+			})
+		},
+	}
+	f := cmd.Flags()
+	f.StringVarP(&filters.Query, "query", "q", "", "match username, email or nickname")
+	f.StringSliceVar(&filters.Roles, "role", nil, "roles to include")
+	f.StringVar(&filters.Status, "status", "", "active (default), suspended or all")
+	f.IntVar(&filters.Page, "page", 1, "page to list")
+	f.IntVar(&filters.PageSize, "page-size", 50, "members per page")
+	f.BoolVar(&all, "all", false, "list every page")
+	return cmd
+}
+
+func newMembersAddCmd(o *options) *cobra.Command {
+	var (
+		req                  tenantmod.AddMemberRequest
+		validFrom, expiresAt string
+		dryRun               bool
+	)
+	cmd := &cobra.Command{
+		Use:   "add <tenant> <user-id>",
+		Short: "Add a user to a tenant",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			userID, err := parseUUID("user ID", args[1])
+			if err != nil {
+				return err
+			}
+			req.UserID = userID.String()
+			if req.ValidFrom, err = parseTime("--valid-from", validFrom); err != nil {
+				return err
+			}
+			if req.ExpiresAt, err = parseTime("--expires-at", expiresAt); err != nil {
+				return err
+			}
+			return o.withBackend(cmd, func(ctx context.Context, b backend) error {
+				t, err := b.Tenant(ctx, args[0])
+				if err != nil {
+					return err
+				}
+				c := change{DryRun: dryRun, Action: "add member", Target: fmt.Sprintf("%s to %s", userID, tenantLabel(t)), Request: &req}
+				if !dryRun {
+					if err := b.AddMember(ctx, t.ID, &req); err != nil {
+						return err
+					}
+				}
+				return o.printer(cmd.OutOrStdout()).change(c)
+			})
+		},
+	}
+	f := cmd.Flags()
+	f.StringVar(&req.Role, "role", "", "member role (default: the defaultMemberRole config)")
+	f.StringVar(&req.Type, "type", "", "membership type: standard or guest")
+	f.StringVar(&validFrom, "valid-from", "", "start of the membership (RFC 3339)")
+	f.StringVar(&expiresAt, "expires-at", "", "end of the membership (RFC 3339)")
+	f.BoolVar(&dryRun, "dry-run", false, "check and print the change without making it")
+	return cmd
+}
+
+func newMembersSuspendCmd(o *options) *cobra.Command {
+	var reason string
+	cmd := newMemberChangeCmd(o, "suspend", "Suspend a member of a tenant", func(ctx context.Context, b backend, tenantID, userID uuid.UUID) error {
+		return b.SuspendMember(ctx, tenantID, userID, reason)
+	})
+	cmd.Flags().StringVar(&reason, "reason", "", "reason recorded with the suspension")
+	return cmd
+}
+
+// newMemberChangeCmd builds a command that changes an existing membership.
+// The dry run checks that the user is a member.
+func newMemberChangeCmd(o *options, verb, short string, apply func(ctx context.Context, b backend, tenantID, userID uuid.UUID) error) *cobra.Command {
+	var dryRun bool
+	cmd := &cobra.Command{
+		Use:   verb + " <tenant> <user-id>",
+		Short: short,
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			userID, err := parseUUID("user ID", args[1])
+			if err != nil {
+				return err
+			}
+			return o.withBackend(cmd, func(ctx context.Context, b backend) error {
+				t, err := b.Tenant(ctx, args[0])
+				if err != nil {
+					return err
+				}
+				c := change{DryRun: dryRun, Action: verb + " member", Target: fmt.Sprintf("%s in %s", userID, tenantLabel(t))}
+				if dryRun {
+					if err := findMember(ctx, b, t.ID, userID); err != nil {
+						return err
+					}
+				} else if err := apply(ctx, b, t.ID, userID); err != nil {
+					return err
+				}
+				return o.printer(cmd.OutOrStdout()).change(c)
+			})
+		},
+	}
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "check and print the change without making it")
+	return cmd
+}
+
+// findMember returns shared.ErrMemberNotFound unless userID is a member of
+// the tenant, active or suspended.
+func findMember(ctx context.Context, b backend, tenantID, userID uuid.UUID) error {
+	for m, err := range allMembers(ctx, b, tenantID, tenantmod.MemberListFilters{Status: "all", PageSize: 100}) {
+		if err != nil {
+			return err
+		}
+		if m.ID == userID {
+			return nil
+		}
+	}
+	return shared.ErrMemberNotFound
+}
+
+func tenantLabel(t *tenantmod.TenantDTO) string {
+	return fmt.Sprintf("tenant %s (%s)", t.Code, t.ID)
+}
+
+func parseTime(flag, s string) (*time.Time, error) {
+	if s == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s %q, expected RFC 3339", flag, s)
+	}
+	return &t, nil
+}
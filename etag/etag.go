@@ -0,0 +1,125 @@
+// Package etag implements optimistic concurrency for the Leeforge plugins'
+// HTTP APIs. Read endpoints answer with an ETag carrying the version of the
+// resource, derived from its updated_at time; updates sent with If-Match
+// only apply while the resource is still at that version and are otherwise
+// answered with 412 Precondition Failed and the current representation.
+package etag
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/leeforge/framework/http/responder"
+
+	"github.com/leeforge/plugins/apierror"
+	"github.com/leeforge/plugins/openapi"
+)
+
+const (
+	// Header is the response header carrying the entity tag.
+	Header = "ETag"
+	// IfMatchHeader is the request header carrying the expected entity tag.
+	IfMatchHeader = "If-Match"
+	// PreconditionFailedCode is the error code in the details of 412
+	// responses.
+	PreconditionFailedCode = "etag.precondition_failed"
+)
+
+// ErrInvalidIfMatch rejects a malformed If-Match header.
+var ErrInvalidIfMatch = apierror.New(http.StatusBadRequest, "etag.invalid_if_match", "invalid If-Match header")
+
+func init() {
+	apierror.Register(apierror.Messages{
+		apierror.English: {
+			ErrInvalidIfMatch.Code: "Invalid If-Match header",
+			PreconditionFailedCode: "Resource was modified since the expected version",
+		},
+		apierror.Chinese: {
+			ErrInvalidIfMatch.Code: "If-Match 请求头无效",
+			PreconditionFailedCode: "资源在预期版本之后已被修改",
+		},
+	})
+}
+
+// Version returns the version of a resource last updated at t. Versions
+// have microsecond precision, the precision databases keep timestamps in.
+func Version(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return strconv.FormatInt(t.UnixMicro(), 10)
+}
+
+// Now returns the current time at the precision of Version. Services set it
+// as updated_at on update, so that the version they answer with is the one
+// later read back from the database.
+func Now() time.Time {
+	return time.Now().Truncate(time.Microsecond)
+}
+
+// Set sets the ETag header to the strong entity tag of version. An empty
+// version sets nothing.
+func Set(w http.ResponseWriter, version string) {
+	if version != "" {
+		w.Header().Set(Header, strconv.Quote(version))
+	}
+}
+
+// IfMatch returns the version of the request's If-Match header. It returns
+// "" when the header is absent or "*", which any current version matches.
+// Weak tags, which If-Match never matches, lists of several tags and
+// malformed values are rejected with ErrInvalidIfMatch.
+func IfMatch(r *http.Request) (string, error) {
+	v := strings.TrimSpace(r.Header.Get(IfMatchHeader))
+	if v == "" || v == "*" {
+		return "", nil
+	}
+	version, err := strconv.Unquote(v)
+	if err != nil || !strings.HasPrefix(v, `"`) || version == "" || strings.ContainsAny(version, `",`) {
+		return "", ErrInvalidIfMatch.With(apierror.Field(IfMatchHeader, apierror.Invalid, "value", v))
+	}
+	return version, nil
+}
+
+// PreconditionFailed answers 412 with the current representation of the
+// resource, at version, as the data of an error response. The message is
+// localized like apierror.Write.
+func PreconditionFailed(w http.ResponseWriter, r *http.Request, version string, current any) {
+	lang := apierror.Language(r.Header.Get("Accept-Language"))
+	body, err := json.Marshal(responder.Response{
+		Data: current,
+		Error: &responder.Error{
+			Code:    responder.ErrCodeConflict,
+			Message: apierror.Message(lang, PreconditionFailedCode, nil),
+			Details: apierror.Details{Code: PreconditionFailedCode},
+		},
+		Meta: *responder.NewMeta(),
+	})
+	if err != nil {
+		responder.InternalServerError(w, r, "Failed to encode current representation")
+		return
+	}
+	Set(w, version)
+	w.Header().Set("Content-Language", lang)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusPreconditionFailed)
+	_, _ = w.Write(body)
+}
+
+// Describe adds the If-Match header and the 412 response, carrying a value
+// of current's type, to an update route.
+func Describe(rt *openapi.Route, current any) {
+	rt.Headers = append(rt.Headers, openapi.Param{
+		Name: IfMatchHeader,
+		Description: "ETag of the version the update is based on; another current version " +
+			"is answered with 412 and the current representation",
+	})
+	if rt.ErrorData == nil {
+		rt.ErrorData = make(map[int]any)
+	}
+	rt.ErrorData[http.StatusPreconditionFailed] = current
+	rt.Errors = openapi.Statuses(rt.Errors, []int{http.StatusBadRequest})
+}
@@ -0,0 +1,89 @@
+package etag
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/leeforge/plugins/apierror"
+	"github.com/leeforge/plugins/openapi"
+)
+
+func TestVersion(t *testing.T) {
+	at := time.Date(2026, 10, 18, 12, 0, 0, 123456789, time.UTC)
+	require.Equal(t, "1792324800123456", Version(at))
+	require.Equal(t, Version(at), Version(at.Truncate(time.Microsecond)))
+	require.Equal(t, Version(at), Version(at.In(time.FixedZone("CST", 8*3600))))
+	require.Empty(t, Version(time.Time{}))
+	require.Zero(t, Now().Nanosecond()%1000)
+}
+
+func TestIfMatch(t *testing.T) {
+	for header, want := range map[string]string{
+		"":        "",
+		"*":       "",
+		`"42"`:    "42",
+		` "42" `:  "42",
+		`"a-b.c"`: "a-b.c",
+	} {
+		r := httptest.NewRequest(http.MethodPut, "/", nil)
+		r.Header.Set(IfMatchHeader, header)
+		got, err := IfMatch(r)
+		require.NoError(t, err, header)
+		require.Equal(t, want, got, header)
+	}
+
+	for _, header := range []string{`42`, `W/"42"`, `"42", "43"`, `""`, `"42`} {
+		r := httptest.NewRequest(http.MethodPut, "/", nil)
+		r.Header.Set(IfMatchHeader, header)
+		_, err := IfMatch(r)
+		require.ErrorIs(t, err, ErrInvalidIfMatch, header)
+	}
+}
+
+func TestPreconditionFailed(t *testing.T) {
+	rec := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPut, "/", nil)
+	r.Header.Set("Accept-Language", "zh")
+	PreconditionFailed(rec, r, "7", map[string]string{"name": "current"})
+
+	require.Equal(t, http.StatusPreconditionFailed, rec.Code)
+	require.Equal(t, `"7"`, rec.Header().Get(Header))
+	require.Equal(t, "zh", rec.Header().Get("Content-Language"))
+	var body struct {
+		Data  map[string]string `json:"data"`
+		Error struct {
+			Message string           `json:"message"`
+			Details apierror.Details `json:"details"`
+		} `json:"error"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	require.Equal(t, "current", body.Data["name"])
+	require.Equal(t, PreconditionFailedCode, body.Error.Details.Code)
+	require.Equal(t, "资源在预期版本之后已被修改", body.Error.Message)
+
+	rec = httptest.NewRecorder()
+	Set(rec, "")
+	require.Empty(t, rec.Header().Get(Header))
+}
+
+func TestDescribe(t *testing.T) {
+	type item struct {
+		Name string `json:"name"`
+	}
+	rt := openapi.Route{Method: http.MethodPut, Path: "/items/{id}", ID: "UpdateItem", Body: item{}, Response: item{}}
+	Describe(&rt, item{})
+	doc := openapi.NewBuilder(openapi.Info{}).Add(rt).Document()
+
+	op := doc.Operation(http.MethodPut, "/items/{id}")
+	require.Equal(t, IfMatchHeader, op.Parameters[1].Name)
+	require.Equal(t, "header", op.Parameters[1].In)
+	require.Contains(t, op.Responses, "400")
+	schema := op.Responses["412"].Content["application/json"].Schema
+	require.Equal(t, "#/components/schemas/item", schema.Properties["data"].Ref)
+	require.Equal(t, []string{"data", "meta", "error"}, schema.Required)
+}
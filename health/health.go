@@ -0,0 +1,194 @@
+// Package health provides structured liveness and readiness reports shared
+// by the Leeforge plugins.
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/leeforge/framework/http/responder"
+)
+
+// DefaultTimeout bounds a probe that does not set its own timeout.
+const DefaultTimeout = 2 * time.Second
+
+// Status is the state of a single check or of a whole report.
+type Status string
+
+const (
+	StatusUp       Status = "up"
+	StatusDegraded Status = "degraded"
+	StatusDown     Status = "down"
+	StatusSkipped  Status = "skipped"
+)
+
+// Report kinds.
+const (
+	KindLiveness  = "liveness"
+	KindReadiness = "readiness"
+)
+
+var (
+	// ErrDegraded marks a probe result as degraded rather than down.
+	ErrDegraded = errors.New("degraded")
+	// ErrNotConfigured marks a dependency the host did not provide; the check is skipped.
+	ErrNotConfigured = errors.New("not configured")
+)
+
+// Degraded returns an error that reports the check as degraded.
+func Degraded(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrDegraded, fmt.Sprintf(format, args...))
+}
+
+// Check describes one dependency probe.
+type Check struct {
+	Name string
+	// Critical checks take the report down when they fail; other failing
+	// checks only degrade it.
+	Critical bool
+	// Timeout bounds the probe. Zero means DefaultTimeout.
+	Timeout time.Duration
+	// SlowThreshold degrades a successful probe that takes longer. Zero disables it.
+	SlowThreshold time.Duration
+	Probe         func(ctx context.Context) error
+}
+
+// CheckResult is the outcome of a single check.
+type CheckResult struct {
+	Name      string  `json:"name"`
+	Status    Status  `json:"status"`
+	Critical  bool    `json:"critical"`
+	LatencyMs float64 `json:"latencyMs"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report aggregates the results of a set of checks for one plugin.
+type Report struct {
+	Plugin    string        `json:"plugin"`
+	Kind      string        `json:"kind"`
+	Status    Status        `json:"status"`
+	CheckedAt time.Time     `json:"checkedAt"`
+	Checks    []CheckResult `json:"checks"`
+}
+
+// Run executes checks concurrently and aggregates them into a report.
+func Run(ctx context.Context, plugin, kind string, checks []Check) *Report {
+	report := &Report{
+		Plugin:    plugin,
+		Kind:      kind,
+		Status:    StatusUp,
+		CheckedAt: time.Now(),
+		Checks:    make([]CheckResult, len(checks)),
+	}
+
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func(i int, c Check) {
+			defer wg.Done()
+			report.Checks[i] = runCheck(ctx, c)
+		}(i, c)
+	}
+	wg.Wait()
+
+	for _, res := range report.Checks {
+		switch {
+		case res.Status == StatusDown && res.Critical:
+			report.Status = StatusDown
+		case res.Status == StatusDown, res.Status == StatusDegraded:
+			if report.Status == StatusUp {
+				report.Status = StatusDegraded
+			}
+		}
+	}
+	return report
+}
+
+func runCheck(ctx context.Context, c Check) CheckResult {
+	res := CheckResult{Name: c.Name, Critical: c.Critical, Status: StatusUp}
+	if c.Probe == nil {
+		res.Status = StatusSkipped
+		res.Error = ErrNotConfigured.Error()
+		return res
+	}
+
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	probeCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := probe(probeCtx, c.Probe)
+	elapsed := time.Since(start)
+	res.LatencyMs = float64(elapsed.Microseconds()) / 1000
+
+	switch {
+	case err == nil && c.SlowThreshold > 0 && elapsed > c.SlowThreshold:
+		res.Status = StatusDegraded
+		res.Error = fmt.Sprintf("slow response: %s exceeds %s", elapsed.Round(time.Millisecond), c.SlowThreshold)
+	case err == nil:
+	case errors.Is(err, ErrNotConfigured):
+		res.Status = StatusSkipped
+		res.Error = err.Error()
+	case errors.Is(err, ErrDegraded):
+		res.Status = StatusDegraded
+		res.Error = err.Error()
+	default:
+		res.Status = StatusDown
+		res.Error = err.Error()
+	}
+	return res
+}
+
+// probe runs fn and gives up when ctx expires, even if fn ignores ctx.
+func probe(ctx context.Context, fn func(context.Context) error) error {
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("probe panicked: %v", r)
+			}
+		}()
+		done <- fn(ctx)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Err returns nil unless the report is down, in which case the error names
+// the plugin and every failing critical check.
+func (r *Report) Err() error {
+	if r == nil || r.Status != StatusDown {
+		return nil
+	}
+	var failing []string
+	for _, c := range r.Checks {
+		if c.Status == StatusDown && c.Critical {
+			failing = append(failing, c.Name+": "+c.Error)
+		}
+	}
+	return fmt.Errorf("%s plugin %s check failed: %s", r.Plugin, r.Kind, strings.Join(failing, "; "))
+}
+
+// Handler serves the report returned by fn. Down reports answer with 503.
+func Handler(fn func(ctx context.Context) *Report) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		report := fn(r.Context())
+		status := http.StatusOK
+		if report.Status == StatusDown {
+			status = http.StatusServiceUnavailable
+		}
+		responder.Write(w, r, status, report)
+	}
+}
@@ -0,0 +1,93 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func ok(context.Context) error { return nil }
+
+func TestRun_AllUp(t *testing.T) {
+	r := Run(context.Background(), "demo", KindReadiness, []Check{
+		{Name: "a", Critical: true, Probe: ok},
+		{Name: "b", Probe: ok},
+	})
+	require.Equal(t, StatusUp, r.Status)
+	require.NoError(t, r.Err())
+	require.Len(t, r.Checks, 2)
+	require.Equal(t, "a", r.Checks[0].Name)
+}
+
+func TestRun_NonCriticalFailureDegrades(t *testing.T) {
+	r := Run(context.Background(), "demo", KindReadiness, []Check{
+		{Name: "db", Critical: true, Probe: ok},
+		{Name: "cache", Probe: func(context.Context) error { return errors.New("refused") }},
+	})
+	require.Equal(t, StatusDegraded, r.Status)
+	require.Equal(t, StatusDown, r.Checks[1].Status)
+	require.NoError(t, r.Err())
+}
+
+func TestRun_CriticalFailureTakesReportDown(t *testing.T) {
+	r := Run(context.Background(), "demo", KindReadiness, []Check{
+		{Name: "db", Critical: true, Probe: func(context.Context) error { return errors.New("refused") }},
+		{Name: "cache", Probe: ok},
+	})
+	require.Equal(t, StatusDown, r.Status)
+	require.EqualError(t, r.Err(), "demo plugin readiness check failed: db: refused")
+}
+
+func TestRun_ProbeOutcomes(t *testing.T) {
+	r := Run(context.Background(), "demo", KindReadiness, []Check{
+		{Name: "skipped", Probe: func(context.Context) error { return ErrNotConfigured }},
+		{Name: "nil-probe"},
+		{Name: "degraded", Probe: func(context.Context) error { return Degraded("backlog %d", 7) }},
+		{Name: "slow", SlowThreshold: time.Millisecond, Probe: func(context.Context) error {
+			time.Sleep(5 * time.Millisecond)
+			return nil
+		}},
+		{Name: "hung", Critical: true, Timeout: 10 * time.Millisecond, Probe: func(context.Context) error {
+			select {}
+		}},
+	})
+	require.Equal(t, StatusSkipped, r.Checks[0].Status)
+	require.Equal(t, StatusSkipped, r.Checks[1].Status)
+	require.Equal(t, StatusDegraded, r.Checks[2].Status)
+	require.Contains(t, r.Checks[2].Error, "backlog 7")
+	require.Equal(t, StatusDegraded, r.Checks[3].Status)
+	require.GreaterOrEqual(t, r.Checks[3].LatencyMs, 5.0)
+	require.Equal(t, StatusDown, r.Checks[4].Status)
+	require.Equal(t, StatusDown, r.Status)
+}
+
+func TestHandler_StatusCodes(t *testing.T) {
+	tests := []struct {
+		status Status
+		code   int
+	}{
+		{StatusUp, http.StatusOK},
+		{StatusDegraded, http.StatusOK},
+		{StatusDown, http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		h := Handler(func(context.Context) *Report {
+			return &Report{Plugin: "demo", Kind: KindLiveness, Status: tt.status}
+		})
+		rec := httptest.NewRecorder()
+		h(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		require.Equal(t, tt.code, rec.Code)
+
+		var body struct {
+			Data Report `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		require.Equal(t, tt.status, body.Data.Status)
+	}
+}
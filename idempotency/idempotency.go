@@ -0,0 +1,271 @@
+// Package idempotency makes retried mutating requests safe. A client sends
+// an Idempotency-Key header with a POST, PUT, PATCH or DELETE request; the
+// first request with a key runs and its response is stored for a TTL, and
+// later requests with the same key and payload get the stored response
+// instead of running again. Reusing a key with a different payload is
+// rejected with 422, a repeat that arrives while the first request is
+// still running with 409, and a body larger than MaxBodyBytes with 413.
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/leeforge/core"
+	coremod "github.com/leeforge/core/core"
+	"github.com/leeforge/framework/http/responder"
+
+	"github.com/leeforge/plugins/apierror"
+	"github.com/leeforge/plugins/openapi"
+)
+
+const (
+	// Header is the request header carrying the idempotency key.
+	Header = "Idempotency-Key"
+	// ReplayedHeader is set to "true" on replayed responses.
+	ReplayedHeader = "Idempotent-Replayed"
+	// MaxKeyLength bounds the length of a key.
+	MaxKeyLength = 255
+	// DefaultTTL is how long keys are kept when a plugin does not set a TTL.
+	DefaultTTL = 24 * time.Hour
+	// Lease is how long a key stays claimed by a request that has not
+	// completed. It is short next to the TTL, so that a key whose request
+	// died without releasing it, for example when the process was killed,
+	// can be retried soon instead of answering 409 until the TTL passes.
+	Lease = 5 * time.Minute
+	// MaxBodyBytes bounds the body of requests carrying a key, which the
+	// middleware reads into memory to fingerprint.
+	MaxBodyBytes = 1 << 20
+)
+
+// Errors answered by the middleware.
+var (
+	ErrInvalidKey = apierror.New(http.StatusBadRequest, "idempotency.invalid_key", "invalid idempotency key")
+	ErrKeyReused  = apierror.New(http.StatusUnprocessableEntity, "idempotency.key_reused",
+		"idempotency key was used with a different request")
+	ErrInProgress = apierror.New(http.StatusConflict, "idempotency.in_progress",
+		"a request with this idempotency key is in progress")
+	ErrBodyTooLarge = apierror.New(http.StatusRequestEntityTooLarge, "idempotency.body_too_large",
+		"request body is too large")
+)
+
+func init() {
+	apierror.Register(apierror.Messages{
+		apierror.English: {
+			ErrInvalidKey.Code:   "Invalid idempotency key",
+			ErrKeyReused.Code:    "Idempotency key was used with a different request",
+			ErrInProgress.Code:   "A request with this idempotency key is in progress",
+			ErrBodyTooLarge.Code: "Request body is too large",
+		},
+		apierror.Chinese: {
+			ErrInvalidKey.Code:   "幂等键无效",
+			ErrKeyReused.Code:    "幂等键已用于不同的请求",
+			ErrInProgress.Code:   "使用该幂等键的请求正在处理中",
+			ErrBodyTooLarge.Code: "请求体过大",
+		},
+	})
+}
+
+// Middleware applies Idempotency-Key handling to the mutating requests of a
+// plugin. Keys are scoped by namespace, usually the plugin name, by the
+// caller and domain of the request and by the values of the scope headers,
+// such as the tenant header, so that the same key sent for different
+// tenants names different requests. A key is claimed for Lease while its
+// request runs and kept for ttl once it completes; responses with a 5xx
+// status are not stored, so that the request can be retried. A zero ttl
+// means DefaultTTL.
+func Middleware(store Store, ttl time.Duration, namespace string, scope ...string) func(http.Handler) http.Handler {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key, ok := r.Header[http.CanonicalHeaderKey(Header)]
+			if !ok || !mutating(r.Method) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if err := checkKey(key); err != nil {
+				apierror.Write(w, r, err)
+				return
+			}
+
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaxBodyBytes))
+			if err != nil {
+				var tooLarge *http.MaxBytesError
+				if errors.As(err, &tooLarge) {
+					apierror.Write(w, r, ErrBodyTooLarge.With(apierror.Field("body", apierror.TooLarge, "max", MaxBodyBytes)))
+					return
+				}
+				responder.BindError(w, r, nil)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			storeKey := scopedKey(r, namespace, key[0], scope)
+			fp := fingerprint(r, body)
+			rec, claimed, err := store.Claim(r.Context(), storeKey, fp, Lease)
+			if err != nil {
+				responder.InternalServerError(w, r, "Idempotency store unavailable")
+				return
+			}
+			if !claimed {
+				switch {
+				case rec.Fingerprint != fp:
+					apierror.Write(w, r, ErrKeyReused)
+				case rec.Response == nil:
+					apierror.Write(w, r, ErrInProgress)
+				default:
+					replay(w, rec.Response)
+				}
+				return
+			}
+
+			before := w.Header().Clone()
+			rw := &recorder{ResponseWriter: w, status: http.StatusOK}
+			// The key is settled even when the client went away meanwhile;
+			// otherwise it would stay claimed until the lease passes.
+			settle := context.WithoutCancel(r.Context())
+			completed := false
+			defer func() {
+				// Release the key when the handler panics or fails, so that a
+				// retry runs again instead of waiting for the lease.
+				if !completed {
+					_ = store.Release(settle, storeKey)
+				}
+			}()
+			next.ServeHTTP(rw, r)
+			if rw.status >= http.StatusInternalServerError {
+				return
+			}
+			resp := &Response{Status: rw.status, Header: handlerHeader(before, w.Header()), Body: rw.body.Bytes()}
+			if err := store.Complete(settle, storeKey, resp, ttl); err == nil {
+				completed = true
+			}
+		})
+	}
+}
+
+func mutating(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// checkKey accepts a single key of 1 to MaxKeyLength visible ASCII
+// characters.
+func checkKey(values []string) error {
+	if len(values) != 1 {
+		return ErrInvalidKey.With(apierror.Field(Header, apierror.TooMany, "max", 1))
+	}
+	key := values[0]
+	if key == "" || len(key) > MaxKeyLength {
+		return ErrInvalidKey.With(apierror.Field(Header, apierror.OutOfRange, "min", 1, "max", MaxKeyLength))
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < 0x21 || key[i] > 0x7e {
+			return ErrInvalidKey.With(apierror.Field(Header, apierror.Invalid, "value", key))
+		}
+	}
+	return nil
+}
+
+// scopedKey returns the store key of key: the namespace, the caller, the
+// domain and the scope header values, hashed together with the key.
+func scopedKey(r *http.Request, namespace, key string, scope []string) string {
+	h := sha256.New()
+	caller, _ := core.GetUserID(r.Context())
+	domain, _ := coremod.GetDomainID(r.Context())
+	parts := []string{caller.String(), domain}
+	for _, name := range scope {
+		parts = append(parts, r.Header.Get(name))
+	}
+	parts = append(parts, key)
+	io.WriteString(h, strings.Join(parts, "\x00"))
+	return namespace + ":" + hex.EncodeToString(h.Sum(nil))
+}
+
+// fingerprint identifies the request a key was used with: its method,
+// path, query and body.
+func fingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, r.Method+" "+r.URL.RequestURI()+"\x00")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// handlerHeader returns the headers the handler set: those of after that
+// differ from before, when the middleware in front had already set some.
+func handlerHeader(before, after http.Header) http.Header {
+	out := make(http.Header, len(after))
+	for name, values := range after {
+		if !slices.Equal(before[name], values) {
+			out[name] = append([]string(nil), values...)
+		}
+	}
+	return out
+}
+
+func replay(w http.ResponseWriter, resp *Response) {
+	for name, values := range resp.Header {
+		w.Header()[name] = append([]string(nil), values...)
+	}
+	w.Header().Set(ReplayedHeader, "true")
+	w.WriteHeader(resp.Status)
+	_, _ = w.Write(resp.Body)
+}
+
+// recorder passes the response through while keeping a copy of its status
+// and body.
+type recorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (w *recorder) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.status = code
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *recorder) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *recorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Describe adds the Idempotency-Key header and the statuses Middleware
+// answers with to a mutating route, for use with openapi.Builder.Decorate.
+func Describe(rt *openapi.Route) {
+	if !mutating(rt.Method) {
+		return
+	}
+	rt.Headers = append(rt.Headers, openapi.Param{
+		Name: Header,
+		Description: "Replays the stored response when the request is repeated with the same key " +
+			"and payload; a different payload is answered with 422",
+	})
+	rt.Errors = openapi.Statuses(rt.Errors, []int{
+		http.StatusBadRequest, http.StatusConflict, http.StatusRequestEntityTooLarge,
+		http.StatusUnprocessableEntity, http.StatusInternalServerError,
+	})
+}
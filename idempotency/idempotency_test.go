@@ -0,0 +1,270 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/leeforge/core"
+)
+
+func TestMiddleware(t *testing.T) {
+	var calls atomic.Int32
+	handler := Middleware(NewMemoryStore(), time.Hour, "widgets", "X-Tenant-ID")(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			n := calls.Add(1)
+			body, _ := io.ReadAll(r.Body)
+			w.Header().Set("Location", "/widgets/"+string(body))
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"n":` + string(rune('0'+n)) + `}`))
+		}))
+
+	send := func(method, key, tenant, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/widgets", strings.NewReader(body))
+		if key != "" {
+			req.Header.Set(Header, key)
+		}
+		req.Header.Set("X-Tenant-ID", tenant)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := send(http.MethodPost, "k1", "acme", "a")
+	require.Equal(t, http.StatusCreated, first.Code)
+	require.Equal(t, `{"n":1}`, first.Body.String())
+	require.Empty(t, first.Header().Get(ReplayedHeader))
+
+	again := send(http.MethodPost, "k1", "acme", "a")
+	require.Equal(t, http.StatusCreated, again.Code)
+	require.Equal(t, `{"n":1}`, again.Body.String())
+	require.Equal(t, "/widgets/a", again.Header().Get("Location"))
+	require.Equal(t, "true", again.Header().Get(ReplayedHeader))
+	require.EqualValues(t, 1, calls.Load())
+
+	reused := send(http.MethodPost, "k1", "acme", "b")
+	require.Equal(t, http.StatusUnprocessableEntity, reused.Code)
+	require.Contains(t, reused.Body.String(), "idempotency.key_reused")
+
+	// Keys are scoped by the scope headers, and requests without a key or
+	// with a safe method run every time.
+	require.Equal(t, `{"n":2}`, send(http.MethodPost, "k1", "globex", "b").Body.String())
+	require.Equal(t, `{"n":3}`, send(http.MethodPost, "", "acme", "a").Body.String())
+	require.Equal(t, `{"n":4}`, send(http.MethodGet, "k1", "acme", "").Body.String())
+
+	bad := send(http.MethodPost, strings.Repeat("k", MaxKeyLength+1), "acme", "a")
+	require.Equal(t, http.StatusBadRequest, bad.Code)
+	require.EqualValues(t, 4, calls.Load())
+}
+
+func TestMiddleware_ScopesKeysByCaller(t *testing.T) {
+	var calls atomic.Int32
+	handler := Middleware(NewMemoryStore(), 0, "widgets")(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	for _, user := range []uuid.UUID{uuid.New(), uuid.New()} {
+		req := httptest.NewRequest(http.MethodDelete, "/widgets/1", nil)
+		req = req.WithContext(core.WithIdentity(req.Context(), core.Identity{UserID: user}))
+		req.Header.Set(Header, "same")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	require.EqualValues(t, 2, calls.Load())
+}
+
+func TestMiddleware_InProgressAndFailures(t *testing.T) {
+	store := NewMemoryStore()
+	release := make(chan struct{})
+	started := make(chan struct{})
+	var calls atomic.Int32
+	handler := Middleware(store, time.Hour, "widgets")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			close(started)
+			<-release
+		}
+		if r.URL.Query().Get("fail") != "" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	send := func(target string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, target, nil)
+		req.Header.Set(Header, "k")
+		req.Header.Set("Accept-Language", "zh")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- send("/widgets?fail=1") }()
+	<-started
+	inProgress := send("/widgets?fail=1")
+	require.Equal(t, http.StatusConflict, inProgress.Code)
+	var body struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	require.NoError(t, json.Unmarshal(inProgress.Body.Bytes(), &body))
+	require.Equal(t, "使用该幂等键的请求正在处理中", body.Error.Message)
+
+	close(release)
+	require.Equal(t, http.StatusInternalServerError, (<-done).Code)
+
+	// A failed request releases its key, so the retry runs again.
+	require.Equal(t, http.StatusInternalServerError, send("/widgets?fail=1").Code)
+	require.EqualValues(t, 2, calls.Load())
+}
+
+func TestMemoryStore_Expires(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	_, claimed, err := store.Claim(ctx, "k", "fp", time.Minute)
+	require.NoError(t, err)
+	require.True(t, claimed)
+	require.NoError(t, store.Complete(ctx, "k", &Response{Status: http.StatusOK}, time.Hour))
+
+	now = now.Add(30 * time.Minute)
+	rec, claimed, err := store.Claim(ctx, "k", "other", time.Minute)
+	require.NoError(t, err)
+	require.False(t, claimed)
+	require.Equal(t, "fp", rec.Fingerprint)
+	require.Equal(t, http.StatusOK, rec.Response.Status)
+
+	now = now.Add(time.Hour)
+	_, claimed, err = store.Claim(ctx, "k", "other", time.Minute)
+	require.NoError(t, err)
+	require.True(t, claimed)
+	require.Len(t, store.records, 1)
+}
+
+func TestMiddleware_LeasesInProgressKeys(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	// A request that died while holding its key, without releasing it, stops
+	// blocking retries once the lease passes, well before the TTL.
+	_, claimed, err := store.Claim(ctx, "widgets:k", "fp", Lease)
+	require.NoError(t, err)
+	require.True(t, claimed)
+
+	now = now.Add(Lease - time.Second)
+	_, claimed, err = store.Claim(ctx, "widgets:k", "fp", Lease)
+	require.NoError(t, err)
+	require.False(t, claimed)
+
+	now = now.Add(2 * time.Second)
+	_, claimed, err = store.Claim(ctx, "widgets:k", "fp", Lease)
+	require.NoError(t, err)
+	require.True(t, claimed)
+
+	// Completed responses are kept for the TTL instead.
+	handler := Middleware(store, time.Hour, "widgets")(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+	send := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/widgets", nil)
+		req.Header.Set(Header, "k2")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+	require.Equal(t, http.StatusCreated, send().Code)
+	now = now.Add(30 * time.Minute)
+	require.Equal(t, "true", send().Header().Get(ReplayedHeader))
+}
+
+func TestMiddleware_BoundsBody(t *testing.T) {
+	var calls atomic.Int32
+	handler := Middleware(NewMemoryStore(), 0, "widgets")(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	send := func(size int) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/widgets", strings.NewReader(strings.Repeat("a", size)))
+		req.Header.Set(Header, "k")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	tooLarge := send(MaxBodyBytes + 1)
+	require.Equal(t, http.StatusRequestEntityTooLarge, tooLarge.Code)
+	require.Contains(t, tooLarge.Body.String(), "idempotency.body_too_large")
+	require.EqualValues(t, 0, calls.Load())
+
+	require.Equal(t, http.StatusCreated, send(MaxBodyBytes).Code)
+	require.EqualValues(t, 1, calls.Load())
+}
+
+// cancelAwareStore fails the calls made with a cancelled context, as a store
+// talking to a database would.
+type cancelAwareStore struct {
+	*MemoryStore
+}
+
+func (s cancelAwareStore) Complete(ctx context.Context, key string, resp *Response, ttl time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.MemoryStore.Complete(ctx, key, resp, ttl)
+}
+
+func (s cancelAwareStore) Release(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.MemoryStore.Release(ctx, key)
+}
+
+func TestMiddleware_SettlesKeysOfCancelledRequests(t *testing.T) {
+	store := cancelAwareStore{NewMemoryStore()}
+	var calls atomic.Int32
+	handler := Middleware(store, time.Hour, "widgets")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		// The client disconnects while the request runs.
+		r.Context().Value(cancelKey{}).(context.CancelFunc)()
+		if r.URL.Query().Get("fail") != "" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	send := func(target, key string) *httptest.ResponseRecorder {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		req := httptest.NewRequest(http.MethodPost, target, nil)
+		req = req.WithContext(context.WithValue(ctx, cancelKey{}, context.CancelFunc(cancel)))
+		req.Header.Set(Header, key)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// The response of a cancelled request is still stored and replayed.
+	require.Equal(t, http.StatusCreated, send("/widgets", "done").Code)
+	require.Equal(t, "true", send("/widgets", "done").Header().Get(ReplayedHeader))
+
+	// A failed cancelled request still releases its key.
+	require.Equal(t, http.StatusInternalServerError, send("/widgets?fail=1", "failed").Code)
+	require.Equal(t, http.StatusInternalServerError, send("/widgets?fail=1", "failed").Code)
+	require.EqualValues(t, 3, calls.Load())
+}
+
+type cancelKey struct{}
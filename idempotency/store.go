@@ -0,0 +1,126 @@
+package idempotency
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/leeforge/framework/plugin"
+)
+
+// ServiceKeyStore is the service registry key under which hosts may
+// register a shared Store, for example one backed by Redis so that keys
+// hold across instances.
+const ServiceKeyStore = "idempotency.store"
+
+// Response is a stored response, replayed for repeated keys.
+type Response struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// Record is the state of a key: the fingerprint of the request that claimed
+// it and, once that request completed, its response.
+type Record struct {
+	Fingerprint string
+	Response    *Response
+}
+
+// Store keeps idempotency records. Implementations must be safe for
+// concurrent use and must make Claim atomic.
+type Store interface {
+	// Claim reserves key for a request with fingerprint until lease passes,
+	// unless the request completes first. When the key is already taken it
+	// returns the existing record and false.
+	Claim(ctx context.Context, key, fingerprint string, lease time.Duration) (*Record, bool, error)
+	// Complete stores the response of the request that claimed key, kept
+	// until ttl passes.
+	Complete(ctx context.Context, key string, resp *Response, ttl time.Duration) error
+	// Release forgets key, so that the request may be retried.
+	Release(ctx context.Context, key string) error
+}
+
+// FromServices resolves the host Store registered under ServiceKeyStore, or
+// returns a new MemoryStore when none is registered.
+func FromServices(services *plugin.ServiceRegistry) (Store, error) {
+	if services == nil || !services.Has(ServiceKeyStore) {
+		return NewMemoryStore(), nil
+	}
+	store, err := plugin.Resolve[Store](services, ServiceKeyStore)
+	if err != nil {
+		return nil, fmt.Errorf("resolve idempotency store: %w", err)
+	}
+	return store, nil
+}
+
+// pruneInterval bounds how often MemoryStore drops expired records.
+const pruneInterval = time.Minute
+
+// MemoryStore is a Store held in process memory. Keys do not survive a
+// restart and are not shared between instances.
+type MemoryStore struct {
+	mu        sync.Mutex
+	records   map[string]memoryRecord
+	lastPrune time.Time
+	now       func() time.Time
+}
+
+type memoryRecord struct {
+	Record
+	expires time.Time
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{records: make(map[string]memoryRecord), now: time.Now}
+}
+
+func (s *MemoryStore) Claim(_ context.Context, key, fingerprint string, lease time.Duration) (*Record, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	s.prune(now)
+	if rec, ok := s.records[key]; ok && now.Before(rec.expires) {
+		cp := rec.Record
+		return &cp, false, nil
+	}
+	s.records[key] = memoryRecord{Record: Record{Fingerprint: fingerprint}, expires: now.Add(lease)}
+	return nil, true, nil
+}
+
+func (s *MemoryStore) Complete(_ context.Context, key string, resp *Response, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec, ok := s.records[key]
+	if !ok {
+		return nil
+	}
+	rec.Response = resp
+	rec.expires = s.now().Add(ttl)
+	s.records[key] = rec
+	return nil
+}
+
+func (s *MemoryStore) Release(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, key)
+	return nil
+}
+
+// prune drops expired records at most once per pruneInterval. The caller
+// holds s.mu.
+func (s *MemoryStore) prune(now time.Time) {
+	if now.Sub(s.lastPrune) < pruneInterval {
+		return
+	}
+	s.lastPrune = now
+	for key, rec := range s.records {
+		if !now.Before(rec.expires) {
+			delete(s.records, key)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+// Package mergepatch implements JSON Merge Patch (RFC 7396) for the Leeforge
+// plugins' PATCH endpoints. A patch only names the fields it changes: an
+// absent field is left unchanged, null clears a field and any other value
+// replaces it, objects being merged member by member.
+package mergepatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/http"
+
+	"github.com/leeforge/plugins/apierror"
+	"github.com/leeforge/plugins/openapi"
+)
+
+// MediaType is the media type of merge patch documents.
+const MediaType = "application/merge-patch+json"
+
+// ErrUnsupportedMediaType rejects a patch sent with a media type other than
+// MediaType or application/json.
+var ErrUnsupportedMediaType = apierror.New(http.StatusUnsupportedMediaType, "mergepatch.unsupported_media_type", "unsupported patch media type")
+
+func init() {
+	apierror.Register(apierror.Messages{
+		apierror.English: {
+			ErrUnsupportedMediaType.Code: "Patches must be sent as " + MediaType,
+		},
+		apierror.Chinese: {
+			ErrUnsupportedMediaType.Code: "补丁必须以 " + MediaType + " 格式发送",
+		},
+	})
+}
+
+// Field is a member of a patch document that records whether it was
+// present: Set reports a present member, Null a present null. Declare
+// Fields with the omitzero option so that unset Fields are not encoded.
+type Field[T any] struct {
+	Set   bool
+	Null  bool
+	Value T
+}
+
+// Value returns a Field setting v.
+func Value[T any](v T) Field[T] {
+	return Field[T]{Set: true, Value: v}
+}
+
+// Null returns a Field clearing its member.
+func Null[T any]() Field[T] {
+	return Field[T]{Set: true, Null: true}
+}
+
+// UnmarshalJSON implements json.Unmarshaler. encoding/json only calls it
+// for members present in the document.
+func (f *Field[T]) UnmarshalJSON(data []byte) error {
+	var zero T
+	f.Set, f.Value = true, zero
+	f.Null = bytes.Equal(bytes.TrimSpace(data), []byte("null"))
+	if f.Null {
+		return nil
+	}
+	return json.Unmarshal(data, &f.Value)
+}
+
+// MarshalJSON implements json.Marshaler.
+func (f Field[T]) MarshalJSON() ([]byte, error) {
+	if f.Null {
+		return []byte("null"), nil
+	}
+	return json.Marshal(f.Value)
+}
+
+// NullableValue returns a value of T, describing the Field in OpenAPI
+// schemas as T or null.
+func (f Field[T]) NullableValue() any {
+	var zero T
+	return zero
+}
+
+// CheckMediaType accepts requests sent as MediaType, as application/json or
+// without Content-Type.
+func CheckMediaType(r *http.Request) error {
+	ct := r.Header.Get("Content-Type")
+	if ct == "" {
+		return nil
+	}
+	mt, _, err := mime.ParseMediaType(ct)
+	if err != nil || (mt != MediaType && mt != "application/json") {
+		return ErrUnsupportedMediaType.With(apierror.Field("Content-Type", apierror.OneOf,
+			"values", MediaType+", application/json"))
+	}
+	return nil
+}
+
+// Apply returns the result of applying patch to the JSON document target
+// following RFC 7396: an object patch is merged into target, treated as an
+// empty object unless it is one, removing the members patched with null; any
+// other patch replaces target.
+func Apply(target, patch json.RawMessage) (json.RawMessage, error) {
+	var p any
+	if err := json.Unmarshal(patch, &p); err != nil {
+		return nil, err
+	}
+	var t any
+	if len(bytes.TrimSpace(target)) > 0 {
+		if err := json.Unmarshal(target, &t); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(merge(t, p))
+}
+
+func merge(target, patch any) any {
+	p, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]any)
+	if !ok {
+		t = make(map[string]any, len(p))
+	}
+	for k, v := range p {
+		if v == nil {
+			delete(t, k)
+		} else {
+			t[k] = merge(t[k], v)
+		}
+	}
+	return t
+}
+
+// Describe marks the body of a PATCH route as a merge patch and adds the
+// status CheckMediaType answers with, for use with openapi.Builder.
+func Describe(rt *openapi.Route) {
+	rt.BodyType = MediaType
+	rt.Errors = openapi.Statuses(rt.Errors, []int{http.StatusUnsupportedMediaType})
+}
@@ -0,0 +1,98 @@
+package mergepatch
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/leeforge/plugins/openapi"
+)
+
+type patch struct {
+	Name        Field[string]             `json:"name,omitzero"`
+	Description Field[string]             `json:"description,omitzero"`
+	ParentID    Field[uuid.UUID]          `json:"parentId,omitzero"`
+	Labels      Field[map[string]*string] `json:"labels,omitzero"`
+}
+
+func TestField(t *testing.T) {
+	var p patch
+	require.NoError(t, json.Unmarshal([]byte(`{"name":"Acme","description":null,"labels":{"a":"1","b":null}}`), &p))
+	require.Equal(t, Value("Acme"), p.Name)
+	require.Equal(t, Null[string](), p.Description)
+	require.False(t, p.ParentID.Set)
+	require.True(t, p.Labels.Set)
+	require.Equal(t, "1", *p.Labels.Value["a"])
+	require.Contains(t, p.Labels.Value, "b")
+	require.Nil(t, p.Labels.Value["b"])
+
+	require.Error(t, json.Unmarshal([]byte(`{"parentId":"x"}`), &p))
+
+	b, err := json.Marshal(patch{Name: Value("Acme"), ParentID: Null[uuid.UUID]()})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"name":"Acme","parentId":null}`, string(b))
+}
+
+func TestApply(t *testing.T) {
+	// The examples of RFC 7396, appendix A.
+	for _, tc := range []struct{ target, patch, want string }{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+		{``, `{"a":1}`, `{"a":1}`},
+	} {
+		got, err := Apply(json.RawMessage(tc.target), json.RawMessage(tc.patch))
+		require.NoError(t, err, tc.patch)
+		require.JSONEq(t, tc.want, string(got), tc.patch)
+	}
+
+	_, err := Apply(json.RawMessage(`{}`), json.RawMessage(`{`))
+	require.Error(t, err)
+}
+
+func TestCheckMediaType(t *testing.T) {
+	for ct, ok := range map[string]bool{
+		"":                                   true,
+		MediaType:                            true,
+		"application/json; charset=utf-8":    true,
+		"application/json-patch+json":        false,
+		"text/plain":                         false,
+		"application/merge-patch+json; q=;;": false,
+	} {
+		r := httptest.NewRequest(http.MethodPatch, "/", nil)
+		r.Header.Set("Content-Type", ct)
+		if ok {
+			require.NoError(t, CheckMediaType(r), ct)
+		} else {
+			require.ErrorIs(t, CheckMediaType(r), ErrUnsupportedMediaType, ct)
+		}
+	}
+}
+
+func TestFieldSchema(t *testing.T) {
+	doc := openapi.NewBuilder(openapi.Info{}).
+		Add(openapi.Route{Method: http.MethodPatch, Path: "/items", ID: "PatchItem", Body: patch{}, BodyType: MediaType}).
+		Document()
+
+	schema := doc.Components.Schemas["patch"]
+	require.Empty(t, schema.Required)
+	require.Equal(t, []string{"string", "null"}, schema.Properties["description"].Type)
+	require.Equal(t, "uuid", schema.Properties["parentId"].Format)
+	require.Equal(t, []string{"object", "null"}, schema.Properties["labels"].Type)
+}
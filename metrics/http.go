@@ -0,0 +1,66 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// HTTPMiddleware counts requests and records their latency under
+// <prefix>_http_requests_total and <prefix>_http_request_duration_seconds,
+// labelled by method, chi route pattern and status code.
+func HTTPMiddleware(rec Recorder, prefix string) func(http.Handler) http.Handler {
+	counter := prefix + "_http_requests_total"
+	histogram := prefix + "_http_request_duration_seconds"
+	Describe(rec, counter, "HTTP requests handled by the "+prefix+" plugin.")
+	Describe(rec, histogram, "Latency of HTTP requests handled by the "+prefix+" plugin.")
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(sw, r)
+
+			route := "unmatched"
+			if rctx := chi.RouteContext(r.Context()); rctx != nil {
+				if pattern := rctx.RoutePattern(); pattern != "" {
+					route = pattern
+				}
+			}
+			labels := Labels{
+				"method": r.Method,
+				"route":  route,
+				"status": strconv.Itoa(sw.status),
+			}
+			rec.IncCounter(counter, labels)
+			rec.ObserveDuration(histogram, time.Since(start), labels)
+		})
+	}
+}
+
+// statusWriter captures the status code written by the wrapped handler.
+type statusWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.status = code
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
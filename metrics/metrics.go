@@ -0,0 +1,148 @@
+// Package metrics defines the pluggable metrics interface used by the
+// Leeforge plugins and an in-memory Registry that serves the Prometheus
+// text exposition format.
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/leeforge/framework/plugin"
+)
+
+// ServiceKeyRecorder is the ServiceRegistry key under which hosts register
+// a Recorder. Plugins fall back to Nop when it is absent.
+const ServiceKeyRecorder = "metrics.recorder"
+
+// Labels are metric dimensions. Keep label values low-cardinality.
+type Labels map[string]string
+
+// Recorder receives metric samples.
+type Recorder interface {
+	// IncCounter increments the counter name by one.
+	IncCounter(name string, labels Labels)
+	// ObserveDuration records d, in seconds, in the histogram name.
+	ObserveDuration(name string, d time.Duration, labels Labels)
+	// SetGauge sets the gauge name to value.
+	SetGauge(name string, value float64, labels Labels)
+}
+
+// Describer is optionally implemented by recorders that expose help text.
+type Describer interface {
+	Describe(name, help string)
+}
+
+// Collector refreshes pull-style metrics, typically gauges backed by a
+// database count, right before they are exposed.
+type Collector func(ctx context.Context, rec Recorder) error
+
+// CollectorRegistrar is optionally implemented by recorders that can run
+// collectors on demand, for example on every scrape.
+type CollectorRegistrar interface {
+	RegisterCollector(c Collector)
+}
+
+// Describe attaches help text to name when rec supports it.
+func Describe(rec Recorder, name, help string) {
+	if d, ok := rec.(Describer); ok {
+		d.Describe(name, help)
+	}
+}
+
+// RegisterCollector registers c when rec supports pull-style collection.
+// It reports whether the collector was registered.
+func RegisterCollector(rec Recorder, c Collector) bool {
+	if r, ok := rec.(CollectorRegistrar); ok {
+		r.RegisterCollector(c)
+		return true
+	}
+	return false
+}
+
+// OrNop returns rec, or Nop when rec is nil.
+func OrNop(rec Recorder) Recorder {
+	if rec == nil {
+		return Nop
+	}
+	return rec
+}
+
+// Nop discards every sample.
+var Nop Recorder = nopRecorder{}
+
+type nopRecorder struct{}
+
+func (nopRecorder) IncCounter(string, Labels)                     {}
+func (nopRecorder) ObserveDuration(string, time.Duration, Labels) {}
+func (nopRecorder) SetGauge(string, float64, Labels)              {}
+
+// Outcome labels shared by operation metrics.
+const (
+	OutcomeSuccess   = "success"
+	OutcomeError     = "error"
+	OutcomeNotFound  = "not_found"
+	OutcomeForbidden = "forbidden"
+	OutcomeConflict  = "conflict"
+	OutcomeInvalid   = "invalid"
+)
+
+// StatusOutcome maps the HTTP status an operation error is answered with to
+// an outcome label. Every client error (4xx) is labelled by its kind, the
+// statuses without a label of their own as OutcomeInvalid; any other status
+// is OutcomeError.
+func StatusOutcome(status int) string {
+	switch {
+	case status == http.StatusNotFound:
+		return OutcomeNotFound
+	case status == http.StatusUnauthorized, status == http.StatusForbidden:
+		return OutcomeForbidden
+	case status == http.StatusConflict, status == http.StatusPreconditionFailed:
+		return OutcomeConflict
+	case status >= 400 && status < 500:
+		return OutcomeInvalid
+	default:
+		return OutcomeError
+	}
+}
+
+// Operation records the count and latency of one operation under the
+// counter and histogram names given, labelled by operation and outcome.
+type Operation struct {
+	Counter   string
+	Histogram string
+	// Classify maps an operation error to an outcome label. A nil Classify
+	// maps every error to OutcomeError.
+	Classify func(err error) string
+}
+
+// Observe records one call of op that started at start and ended with err.
+func (o Operation) Observe(rec Recorder, op string, start time.Time, err error) {
+	if rec == nil {
+		return
+	}
+	outcome := OutcomeSuccess
+	if err != nil {
+		outcome = OutcomeError
+		if o.Classify != nil {
+			outcome = o.Classify(err)
+		}
+	}
+	labels := Labels{"operation": op, "outcome": outcome}
+	rec.IncCounter(o.Counter, labels)
+	rec.ObserveDuration(o.Histogram, time.Since(start), labels)
+}
+
+// FromServices resolves the host Recorder registered under
+// ServiceKeyRecorder, or returns Nop when none is registered.
+func FromServices(services *plugin.ServiceRegistry) (Recorder, error) {
+	if services == nil || !services.Has(ServiceKeyRecorder) {
+		return Nop, nil
+	}
+	rec, err := plugin.Resolve[Recorder](services, ServiceKeyRecorder)
+	if err != nil {
+		return nil, fmt.Errorf("resolve metrics recorder: %w", err)
+	}
+	return OrNop(rec), nil
+}
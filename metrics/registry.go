@@ -0,0 +1,281 @@
+package metrics
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultBuckets are the histogram upper bounds, in seconds.
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+type metricKind string
+
+const (
+	kindCounter   metricKind = "counter"
+	kindGauge     metricKind = "gauge"
+	kindHistogram metricKind = "histogram"
+)
+
+type family struct {
+	kind   metricKind
+	help   string
+	series map[string]*series
+}
+
+type series struct {
+	labels  []labelPair
+	value   float64
+	buckets []uint64
+	sum     float64
+	count   uint64
+}
+
+type labelPair struct {
+	name, value string
+}
+
+// Registry is an in-memory Recorder that exposes its samples in the
+// Prometheus text format. It is safe for concurrent use.
+type Registry struct {
+	mu         sync.Mutex
+	buckets    []float64
+	families   map[string]*family
+	help       map[string]string
+	collectors []Collector
+}
+
+// NewRegistry creates an empty registry using DefaultBuckets.
+func NewRegistry() *Registry {
+	return &Registry{
+		buckets:  DefaultBuckets,
+		families: make(map[string]*family),
+		help:     make(map[string]string),
+	}
+}
+
+// Describe sets the help text of name.
+func (r *Registry) Describe(name, help string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.help[name] = help
+}
+
+// RegisterCollector adds a collector that runs before every exposition.
+func (r *Registry) RegisterCollector(c Collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.collectors = append(r.collectors, c)
+}
+
+// IncCounter increments the counter name by one.
+func (r *Registry) IncCounter(name string, labels Labels) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.series(name, kindCounter, labels).value++
+}
+
+// SetGauge sets the gauge name to value.
+func (r *Registry) SetGauge(name string, value float64, labels Labels) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.series(name, kindGauge, labels).value = value
+}
+
+// ObserveDuration records d, in seconds, in the histogram name.
+func (r *Registry) ObserveDuration(name string, d time.Duration, labels Labels) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s := r.series(name, kindHistogram, labels)
+	v := d.Seconds()
+	for i, le := range r.buckets {
+		if v <= le {
+			s.buckets[i]++
+		}
+	}
+	s.sum += v
+	s.count++
+}
+
+// Collect runs every registered collector. Collector errors are joined so
+// that one failing source does not hide the others.
+func (r *Registry) Collect(ctx context.Context) error {
+	r.mu.Lock()
+	collectors := append([]Collector(nil), r.collectors...)
+	r.mu.Unlock()
+
+	var errs []string
+	for _, c := range collectors {
+		if err := c(ctx, r); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("collect metrics: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// series returns the series for name and labels, creating it if needed.
+// The caller must hold r.mu. A name keeps the kind it was first used with;
+// samples of another kind are recorded under name + "_" + kind.
+func (r *Registry) series(name string, kind metricKind, labels Labels) *series {
+	f, ok := r.families[name]
+	if ok && f.kind != kind {
+		name = name + "_" + string(kind)
+		f, ok = r.families[name]
+	}
+	if !ok {
+		f = &family{kind: kind, series: make(map[string]*series)}
+		r.families[name] = f
+	}
+
+	pairs := sortedPairs(labels)
+	key := seriesKey(pairs)
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{labels: pairs}
+		if kind == kindHistogram {
+			s.buckets = make([]uint64, len(r.buckets))
+		}
+		f.series[key] = s
+	}
+	return s
+}
+
+// Value returns the current value of a counter or gauge series, or the
+// observation count of a histogram series. It is intended for tests.
+func (r *Registry) Value(name string, labels Labels) float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	f, ok := r.families[name]
+	if !ok {
+		return 0
+	}
+	s, ok := f.series[seriesKey(sortedPairs(labels))]
+	if !ok {
+		return 0
+	}
+	if f.kind == kindHistogram {
+		return float64(s.count)
+	}
+	return s.value
+}
+
+// WriteText writes all samples in the Prometheus text exposition format.
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	bw := bufio.NewWriter(w)
+	names := make([]string, 0, len(r.families))
+	for name := range r.families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		f := r.families[name]
+		if help, ok := r.help[name]; ok {
+			fmt.Fprintf(bw, "# HELP %s %s\n", name, escapeHelp(help))
+		}
+		fmt.Fprintf(bw, "# TYPE %s %s\n", name, f.kind)
+
+		keys := make([]string, 0, len(f.series))
+		for key := range f.series {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			s := f.series[key]
+			if f.kind != kindHistogram {
+				fmt.Fprintf(bw, "%s%s %s\n", name, formatLabels(s.labels), formatFloat(s.value))
+				continue
+			}
+			for i, le := range r.buckets {
+				fmt.Fprintf(bw, "%s_bucket%s %d\n", name, formatLabels(s.labels, labelPair{"le", formatFloat(le)}), s.buckets[i])
+			}
+			fmt.Fprintf(bw, "%s_bucket%s %d\n", name, formatLabels(s.labels, labelPair{"le", "+Inf"}), s.count)
+			fmt.Fprintf(bw, "%s_sum%s %s\n", name, formatLabels(s.labels), formatFloat(s.sum))
+			fmt.Fprintf(bw, "%s_count%s %d\n", name, formatLabels(s.labels), s.count)
+		}
+	}
+	return bw.Flush()
+}
+
+// Handler serves the registry in the Prometheus text format. Collectors run
+// first; a failing collector is reported in a comment but does not fail the
+// scrape.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		collectErr := r.Collect(req.Context())
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		if collectErr != nil {
+			fmt.Fprintf(w, "# %s\n", strings.ReplaceAll(collectErr.Error(), "\n", " "))
+		}
+		_ = r.WriteText(w)
+	})
+}
+
+func sortedPairs(labels Labels) []labelPair {
+	pairs := make([]labelPair, 0, len(labels))
+	for k, v := range labels {
+		pairs = append(pairs, labelPair{k, v})
+	}
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i].name < pairs[j].name })
+	return pairs
+}
+
+func seriesKey(pairs []labelPair) string {
+	var b strings.Builder
+	for _, p := range pairs {
+		b.WriteString(p.name)
+		b.WriteByte(0)
+		b.WriteString(p.value)
+		b.WriteByte(0)
+	}
+	return b.String()
+}
+
+func formatLabels(pairs []labelPair, extra ...labelPair) string {
+	all := append(append([]labelPair(nil), pairs...), extra...)
+	if len(all) == 0 {
+		return ""
+	}
+	parts := make([]string, len(all))
+	for i, p := range all {
+		parts[i] = p.name + `="` + escapeLabel(p.value) + `"`
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
+func escapeHelp(s string) string  { return helpEscaper.Replace(s) }
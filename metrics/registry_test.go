@@ -0,0 +1,113 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry_WriteText(t *testing.T) {
+	r := NewRegistry()
+	r.Describe("demo_ops_total", "Demo operations.")
+	r.IncCounter("demo_ops_total", Labels{"outcome": "success", "operation": "create"})
+	r.IncCounter("demo_ops_total", Labels{"operation": "create", "outcome": "success"})
+	r.SetGauge("demo_items", 42, nil)
+	r.ObserveDuration("demo_latency_seconds", 30*time.Millisecond, Labels{"operation": "create"})
+
+	var b strings.Builder
+	require.NoError(t, r.WriteText(&b))
+	out := b.String()
+
+	require.Contains(t, out, "# HELP demo_ops_total Demo operations.\n# TYPE demo_ops_total counter\n")
+	require.Contains(t, out, `demo_ops_total{operation="create",outcome="success"} 2`)
+	require.Contains(t, out, "# TYPE demo_items gauge\ndemo_items 42\n")
+	require.Contains(t, out, `demo_latency_seconds_bucket{operation="create",le="0.025"} 0`)
+	require.Contains(t, out, `demo_latency_seconds_bucket{operation="create",le="0.05"} 1`)
+	require.Contains(t, out, `demo_latency_seconds_bucket{operation="create",le="+Inf"} 1`)
+	require.Contains(t, out, `demo_latency_seconds_count{operation="create"} 1`)
+
+	require.Equal(t, 2.0, r.Value("demo_ops_total", Labels{"operation": "create", "outcome": "success"}))
+	require.Equal(t, 1.0, r.Value("demo_latency_seconds", Labels{"operation": "create"}))
+}
+
+func TestRegistry_EscapesLabelValues(t *testing.T) {
+	r := NewRegistry()
+	r.IncCounter("demo_total", Labels{"path": "a\"b\\c\nd"})
+
+	var b strings.Builder
+	require.NoError(t, r.WriteText(&b))
+	require.Contains(t, b.String(), `demo_total{path="a\"b\\c\nd"} 1`)
+}
+
+func TestRegistry_Handler_RunsCollectors(t *testing.T) {
+	r := NewRegistry()
+	calls := 0
+	r.RegisterCollector(func(_ context.Context, rec Recorder) error {
+		calls++
+		rec.SetGauge("demo_rows", float64(calls*10), nil)
+		return nil
+	})
+	r.RegisterCollector(func(context.Context, Recorder) error { return errors.New("db down") })
+
+	rec := httptest.NewRecorder()
+	r.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "text/plain; version=0.0.4; charset=utf-8", rec.Header().Get("Content-Type"))
+	require.Contains(t, rec.Body.String(), "# collect metrics: db down")
+	require.Contains(t, rec.Body.String(), "demo_rows 10")
+}
+
+func TestOperation_Observe(t *testing.T) {
+	r := NewRegistry()
+	op := Operation{
+		Counter:   "demo_ops_total",
+		Histogram: "demo_ops_seconds",
+		Classify:  func(error) string { return "not_found" },
+	}
+	op.Observe(r, "get", time.Now(), nil)
+	op.Observe(r, "get", time.Now(), errors.New("missing"))
+
+	require.Equal(t, 1.0, r.Value("demo_ops_total", Labels{"operation": "get", "outcome": OutcomeSuccess}))
+	require.Equal(t, 1.0, r.Value("demo_ops_total", Labels{"operation": "get", "outcome": "not_found"}))
+	require.Equal(t, 1.0, r.Value("demo_ops_seconds", Labels{"operation": "get", "outcome": "not_found"}))
+}
+
+func TestStatusOutcome(t *testing.T) {
+	for status, want := range map[int]string{
+		http.StatusBadRequest:          OutcomeInvalid,
+		http.StatusUnprocessableEntity: OutcomeInvalid,
+		http.StatusTooManyRequests:     OutcomeInvalid,
+		http.StatusNotFound:            OutcomeNotFound,
+		http.StatusForbidden:           OutcomeForbidden,
+		http.StatusConflict:            OutcomeConflict,
+		http.StatusInternalServerError: OutcomeError,
+		0:                              OutcomeError,
+	} {
+		require.Equal(t, want, StatusOutcome(status), status)
+	}
+}
+
+func TestHTTPMiddleware_RecordsRoutePattern(t *testing.T) {
+	r := NewRegistry()
+	router := chi.NewRouter()
+	router.Use(HTTPMiddleware(r, "demo"))
+	router.Get("/items/{id}", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	for _, id := range []string{"1", "2"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/items/"+id, nil))
+	}
+
+	labels := Labels{"method": http.MethodGet, "route": "/items/{id}", "status": "404"}
+	require.Equal(t, 2.0, r.Value("demo_http_requests_total", labels))
+	require.Equal(t, 2.0, r.Value("demo_http_request_duration_seconds", labels))
+}
@@ -0,0 +1,489 @@
+// Package openapi builds OpenAPI 3.1 documents for the Leeforge plugins'
+// HTTP APIs from their routes and DTO types, and serves them.
+//
+// Plugins describe each route with a Route and add them to a Builder.
+// Schemas are derived from the Go types of request bodies and response
+// data; success responses are wrapped in the responder envelope and error
+// statuses refer to shared error responses carrying the responder's error
+// codes. Check compares a document with a chi router so that tests fail
+// when a route is registered without an operation.
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/leeforge/framework/http/responder"
+
+	"github.com/leeforge/plugins/apierror"
+)
+
+// Version is the OpenAPI version of built documents.
+const Version = "3.1.0"
+
+// Document is an OpenAPI document.
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Servers    []Server            `json:"servers,omitempty"`
+	Tags       []Tag               `json:"tags,omitempty"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+}
+
+// Info is the document metadata.
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// Server is a base URL the paths are relative to. Relative URLs resolve
+// against the URL the document is served from.
+type Server struct {
+	URL         string `json:"url"`
+	Description string `json:"description,omitempty"`
+}
+
+// Tag groups operations.
+type Tag struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem holds the operations of a path, keyed by lower-case method.
+type PathItem map[string]*Operation
+
+// Operation is a single API operation.
+type Operation struct {
+	OperationID string               `json:"operationId"`
+	Summary     string               `json:"summary,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	Parameters  []*Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+// Parameter is a path, query or header parameter.
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody describes the body of a request.
+type RequestBody struct {
+	Description string                `json:"description,omitempty"`
+	Required    bool                  `json:"required,omitempty"`
+	Content     map[string]*MediaType `json:"content"`
+}
+
+// Response describes a response, or refers to a shared one with Ref.
+type Response struct {
+	Ref         string                `json:"$ref,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// MediaType holds the schema of one content type.
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Components holds the shared schemas and responses.
+type Components struct {
+	Schemas   map[string]*Schema   `json:"schemas,omitempty"`
+	Responses map[string]*Response `json:"responses,omitempty"`
+}
+
+// Operation returns the operation for method and the chi route pattern
+// path, or nil. A trailing slash is ignored.
+func (d *Document) Operation(method, path string) *Operation {
+	item, ok := d.Paths[normalizePath(path)]
+	if !ok {
+		return nil
+	}
+	return item[strings.ToLower(method)]
+}
+
+// Route describes one operation for Builder.Add.
+type Route struct {
+	Method string
+	// Path is the chi route pattern, such as /tenants/{id}.
+	Path string
+	// ID is the unique operationId, by convention the handler name.
+	ID          string
+	Summary     string
+	Description string
+	Tags        []string
+	Query       []Param
+	Headers     []Param
+
+	// Body is a value of the JSON request body type; nil means no body.
+	Body any
+	// BodyType is the media type of Body, application/json by default.
+	BodyType string
+	// BodyOptional marks a body that may be omitted.
+	BodyOptional bool
+	// Consumes lists further media types accepted for the body, as binary.
+	Consumes []string
+
+	// Response is a value of the type answered with 200 as data of the
+	// responder envelope. Nil answers an envelope without data.
+	Response any
+	// Raw answers Response as is rather than in the envelope.
+	Raw bool
+	// Produces lists further media types answered with 200, as binary.
+	Produces []string
+	// Others are further statuses answered like Response, such as 503 for
+	// a health report that is down.
+	Others map[int]any
+	// ErrorData are further error statuses answered with data of the given
+	// type next to the error, such as 412 with the current representation
+	// of a resource.
+	ErrorData map[int]any
+
+	// Errors are the statuses the route answers with a responder error.
+	Errors []int
+}
+
+// Message is the data of responses that only confirm an action, which the
+// handlers answer as map[string]string{"message": ...}.
+type Message struct {
+	Message string `json:"message"`
+}
+
+// Param describes a path, query or header parameter.
+type Param struct {
+	Name        string
+	Description string
+	// Type is a value of the parameter's Go type, such as 0, true,
+	// uuid.UUID{} or []string{} for a repeated parameter. Nil means string.
+	Type     any
+	Required bool
+}
+
+// Builder assembles a Document from routes.
+type Builder struct {
+	doc        *Document
+	schemas    *generator
+	pathParams map[string]Param
+	ids        map[string]string
+	decorators []func(*Route)
+}
+
+// NewBuilder starts a document with info.
+func NewBuilder(info Info) *Builder {
+	b := &Builder{
+		doc: &Document{
+			OpenAPI: Version,
+			Info:    info,
+			Paths:   make(map[string]PathItem),
+			Components: Components{
+				Schemas:   make(map[string]*Schema),
+				Responses: make(map[string]*Response),
+			},
+		},
+		pathParams: make(map[string]Param),
+		ids:        make(map[string]string),
+	}
+	b.schemas = newGenerator(b.doc.Components.Schemas)
+	return b
+}
+
+// Server adds a server URL.
+func (b *Builder) Server(url, description string) *Builder {
+	b.doc.Servers = append(b.doc.Servers, Server{URL: url, Description: description})
+	return b
+}
+
+// Tag declares a tag used by the routes.
+func (b *Builder) Tag(name, description string) *Builder {
+	b.doc.Tags = append(b.doc.Tags, Tag{Name: name, Description: description})
+	return b
+}
+
+// PathParam describes the path parameter p.Name wherever a route pattern
+// contains it. Undescribed path parameters are plain strings.
+func (b *Builder) PathParam(p Param) *Builder {
+	b.pathParams[p.Name] = p
+	return b
+}
+
+// Decorate adjusts every route added after it with fn, for middleware
+// that adds request headers or error statuses to the routes it wraps.
+func (b *Builder) Decorate(fn func(*Route)) *Builder {
+	b.decorators = append(b.decorators, fn)
+	return b
+}
+
+var pathParamRe = regexp.MustCompile(`\{([^}:]+)(?::[^}]*)?\}`)
+
+// Add adds routes. It panics when a route repeats a method and path or an
+// operationId, since route tables are static.
+func (b *Builder) Add(routes ...Route) *Builder {
+	for _, rt := range routes {
+		for _, fn := range b.decorators {
+			fn(&rt)
+		}
+		path := normalizePath(rt.Path)
+		method := strings.ToLower(rt.Method)
+		if b.doc.Operation(method, path) != nil {
+			panic(fmt.Sprintf("openapi: duplicate route %s %s", rt.Method, path))
+		}
+		if prev, ok := b.ids[rt.ID]; ok || rt.ID == "" {
+			panic(fmt.Sprintf("openapi: operationId %q of %s %s is empty or already used by %s", rt.ID, rt.Method, path, prev))
+		}
+		b.ids[rt.ID] = rt.Method + " " + path
+
+		op := &Operation{
+			OperationID: rt.ID,
+			Summary:     rt.Summary,
+			Description: rt.Description,
+			Tags:        rt.Tags,
+			Responses:   make(map[string]*Response),
+		}
+		for _, m := range pathParamRe.FindAllStringSubmatch(path, -1) {
+			p, ok := b.pathParams[m[1]]
+			if !ok {
+				p = Param{Name: m[1]}
+			}
+			op.Parameters = append(op.Parameters, b.parameter(p, "path", true))
+		}
+		for _, p := range rt.Query {
+			op.Parameters = append(op.Parameters, b.parameter(p, "query", p.Required))
+		}
+		for _, p := range rt.Headers {
+			op.Parameters = append(op.Parameters, b.parameter(p, "header", p.Required))
+		}
+		if rt.Body != nil || len(rt.Consumes) > 0 {
+			body := &RequestBody{Required: !rt.BodyOptional, Content: make(map[string]*MediaType)}
+			if rt.Body != nil {
+				bodyType := rt.BodyType
+				if bodyType == "" {
+					bodyType = "application/json"
+				}
+				body.Content[bodyType] = &MediaType{Schema: b.schemas.of(rt.Body)}
+			}
+			for _, ct := range rt.Consumes {
+				body.Content[ct] = &MediaType{Schema: binarySchema(ct)}
+			}
+			op.RequestBody = body
+		}
+
+		op.Responses["200"] = b.success(http.StatusOK, rt.Response, rt.Raw, rt.Produces)
+		for status, v := range rt.Others {
+			op.Responses[strconv.Itoa(status)] = b.success(status, v, rt.Raw, nil)
+		}
+		for status, v := range rt.ErrorData {
+			resp := b.success(status, v, false, nil)
+			s := resp.Content["application/json"].Schema
+			s.Properties["error"] = b.schemas.of(responder.Error{})
+			s.Required = append(s.Required, "error")
+			op.Responses[strconv.Itoa(status)] = resp
+		}
+		for _, status := range rt.Errors {
+			if _, ok := op.Responses[strconv.Itoa(status)]; !ok {
+				op.Responses[strconv.Itoa(status)] = b.errorResponse(status)
+			}
+		}
+
+		item, ok := b.doc.Paths[path]
+		if !ok {
+			item = make(PathItem)
+			b.doc.Paths[path] = item
+		}
+		item[method] = op
+	}
+	return b
+}
+
+// Document returns the built document.
+func (b *Builder) Document() *Document {
+	return b.doc
+}
+
+func (b *Builder) parameter(p Param, in string, required bool) *Parameter {
+	var s *Schema
+	if p.Type == nil {
+		s = &Schema{Type: "string"}
+	} else {
+		s = b.schemas.of(p.Type)
+	}
+	return &Parameter{Name: p.Name, In: in, Description: p.Description, Required: required, Schema: s}
+}
+
+func (b *Builder) success(status int, v any, raw bool, produces []string) *Response {
+	var data *Schema
+	if v != nil {
+		data = b.schemas.of(v)
+	}
+	var s *Schema
+	if raw {
+		s = data
+		if s == nil {
+			s = &Schema{}
+		}
+	} else {
+		s = &Schema{
+			Type:       "object",
+			Properties: map[string]*Schema{"meta": b.schemas.of(responder.Meta{})},
+			Required:   []string{"meta"},
+		}
+		if data != nil {
+			s.Properties["data"] = data
+			s.Required = []string{"data", "meta"}
+		}
+	}
+	resp := &Response{
+		Description: http.StatusText(status),
+		Content:     map[string]*MediaType{"application/json": {Schema: s}},
+	}
+	for _, ct := range produces {
+		resp.Content[ct] = &MediaType{Schema: binarySchema(ct)}
+	}
+	return resp
+}
+
+// errorCodes are the responder error codes the plugins answer each status
+// with, as mapped by apierror.Write.
+var errorCodes = map[int][]int{
+	http.StatusBadRequest:          {responder.ErrCodeBadRequest, responder.ErrCodeBindFailed},
+	http.StatusUnauthorized:        {responder.ErrCodeUnauthorized},
+	http.StatusForbidden:           {responder.ErrCodeForbidden},
+	http.StatusNotFound:            {responder.ErrCodeNotFound},
+	http.StatusConflict:            {responder.ErrCodeConflict},
+	http.StatusUnprocessableEntity: {responder.ErrCodeValidationFailed},
+	http.StatusInternalServerError: {responder.ErrCodeInternalServer, responder.ErrCodeDatabase},
+	http.StatusServiceUnavailable:  {5003},
+}
+
+// errorDetails are the details of the statuses answered only by
+// apierror.Write.
+var errorDetails = map[int]any{
+	http.StatusUnprocessableEntity: apierror.Details{},
+}
+
+// errorResponse returns a reference to the shared response for status,
+// adding it to the components on first use.
+func (b *Builder) errorResponse(status int) *Response {
+	name := strings.ReplaceAll(http.StatusText(status), " ", "")
+	if name == "" {
+		name = "Status" + strconv.Itoa(status)
+	}
+	if _, ok := b.doc.Components.Responses[name]; !ok {
+		errSchema := b.schemas.of(responder.Error{})
+		if codes := errorCodes[status]; len(codes) > 0 {
+			enum := make([]any, len(codes))
+			for i, c := range codes {
+				enum[i] = c
+			}
+			errSchema = &Schema{
+				Ref:        errSchema.Ref,
+				Properties: map[string]*Schema{"code": {Type: "integer", Enum: enum}},
+			}
+			if details, ok := errorDetails[status]; ok {
+				errSchema.Properties["details"] = b.schemas.of(details)
+			}
+		}
+		b.doc.Components.Responses[name] = &Response{
+			Description: http.StatusText(status),
+			Content: map[string]*MediaType{"application/json": {Schema: &Schema{
+				Type: "object",
+				Properties: map[string]*Schema{
+					"error": errSchema,
+					"meta":  b.schemas.of(responder.Meta{}),
+				},
+				Required: []string{"error", "meta"},
+			}}},
+		}
+	}
+	return &Response{Ref: "#/components/responses/" + name}
+}
+
+func binarySchema(contentType string) *Schema {
+	return &Schema{Type: "string", ContentMediaType: contentType}
+}
+
+func normalizePath(path string) string {
+	if len(path) > 1 {
+		return strings.TrimSuffix(path, "/")
+	}
+	return path
+}
+
+// Handler serves doc as JSON. The document is encoded once; later changes
+// to doc are not served.
+func Handler(doc *Document) http.HandlerFunc {
+	body, err := json.Marshal(doc)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err != nil {
+			responder.InternalServerError(w, r, "Failed to encode OpenAPI document")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body)
+	}
+}
+
+// Check compares doc with the routes registered on routes. It fails when a
+// route has no operation or an operation has no route, naming each.
+func Check(doc *Document, routes chi.Routes) error {
+	seen := make(map[string]bool)
+	var undocumented []string
+	err := chi.Walk(routes, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		key := method + " " + normalizePath(route)
+		seen[key] = true
+		if doc.Operation(method, route) == nil {
+			undocumented = append(undocumented, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	var unrouted []string
+	for path, item := range doc.Paths {
+		for method := range item {
+			if key := strings.ToUpper(method) + " " + path; !seen[key] {
+				unrouted = append(unrouted, key)
+			}
+		}
+	}
+	sort.Strings(undocumented)
+	sort.Strings(unrouted)
+
+	var problems []string
+	if len(undocumented) > 0 {
+		problems = append(problems, "routes without an operation: "+strings.Join(undocumented, ", "))
+	}
+	if len(unrouted) > 0 {
+		problems = append(problems, "operations without a route: "+strings.Join(unrouted, ", "))
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("openapi: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// Statuses returns the sorted union of status lists, for composing the
+// Errors of a route from the statuses of the error mappers it uses.
+func Statuses(lists ...[]int) []int {
+	var all []int
+	for _, l := range lists {
+		all = append(all, l...)
+	}
+	slices.Sort(all)
+	return slices.Compact(all)
+}
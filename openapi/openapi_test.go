@@ -0,0 +1,190 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+type terms struct {
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+type node struct {
+	ID       uuid.UUID         `json:"id"`
+	Name     string            `json:"name"`
+	Parent   *uuid.UUID        `json:"parent"`
+	Children []*node           `json:"children,omitempty"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Metadata json.RawMessage   `json:"metadata,omitempty"`
+	Count    int64             `json:"count,string"`
+	Secret   string            `json:"-"`
+	internal string
+	terms
+}
+
+// optionalUUID encodes as a UUID or null.
+type optionalUUID struct{}
+
+func (optionalUUID) NullableValue() any { return uuid.UUID{} }
+
+func schemaJSON(t *testing.T, s *Schema) string {
+	t.Helper()
+	b, err := json.Marshal(s)
+	require.NoError(t, err)
+	return string(b)
+}
+
+func TestGenerator(t *testing.T) {
+	components := map[string]*Schema{}
+	g := newGenerator(components)
+
+	require.JSONEq(t, `{"$ref":"#/components/schemas/node"}`, schemaJSON(t, g.of(&node{})))
+	require.JSONEq(t, `{
+		"type": "object",
+		"properties": {
+			"id": {"type": "string", "format": "uuid"},
+			"name": {"type": "string"},
+			"parent": {"type": ["string", "null"], "format": "uuid"},
+			"children": {"type": "array", "items": {"$ref": "#/components/schemas/node"}},
+			"labels": {"type": "object", "additionalProperties": {"type": "string"}},
+			"metadata": {},
+			"count": {"type": "string"},
+			"expiresAt": {"type": "string", "format": "date-time"}
+		},
+		"required": ["id", "name", "parent", "count"]
+	}`, schemaJSON(t, components["node"]))
+
+	require.JSONEq(t, `{"type":"array","items":{"type":"integer"}}`, schemaJSON(t, g.of([]int{})))
+	require.JSONEq(t, `{"type":"string","contentEncoding":"base64"}`, schemaJSON(t, g.of([]byte{})))
+	require.JSONEq(t, `{"anyOf":[{"$ref":"#/components/schemas/node"},{"type":"null"}]}`,
+		schemaJSON(t, orNull(g.of(node{}))))
+	require.JSONEq(t, `{"type":["string","null"],"format":"uuid"}`, schemaJSON(t, g.of(optionalUUID{})))
+
+	// A second type with a taken name is qualified by its package.
+	type node struct{}
+	require.JSONEq(t, `{"$ref":"#/components/schemas/Openapinode"}`, schemaJSON(t, g.of(node{})))
+	require.JSONEq(t, `{"$ref":"#/components/schemas/Openapinode"}`, schemaJSON(t, g.of(node{})))
+	require.Len(t, components, 2)
+}
+
+func TestBuilder(t *testing.T) {
+	type createRequest struct {
+		Code string `json:"code"`
+	}
+	doc := NewBuilder(Info{Title: "Test", Version: "1.0.0"}).
+		PathParam(Param{Name: "id", Description: "Node ID", Type: uuid.UUID{}}).
+		Add(
+			Route{Method: http.MethodPost, Path: "/nodes/", ID: "CreateNode", Body: createRequest{},
+				Response: node{}, Errors: Statuses([]int{409, 400}, []int{400, 422})},
+			Route{Method: http.MethodDelete, Path: "/nodes/{id}", ID: "DeleteNode",
+				Query: []Param{{Name: "force", Type: true}}, Errors: []int{404}},
+		).
+		Document()
+
+	create := doc.Operation(http.MethodPost, "/nodes")
+	require.NotNil(t, create)
+	require.True(t, create.RequestBody.Required)
+	require.JSONEq(t, `{"$ref":"#/components/schemas/createRequest"}`,
+		schemaJSON(t, create.RequestBody.Content["application/json"].Schema))
+	require.JSONEq(t, `{
+		"type": "object",
+		"properties": {"data": {"$ref": "#/components/schemas/node"}, "meta": {"$ref": "#/components/schemas/Meta"}},
+		"required": ["data", "meta"]
+	}`, schemaJSON(t, create.Responses["200"].Content["application/json"].Schema))
+	require.Equal(t, "#/components/responses/BadRequest", create.Responses["400"].Ref)
+	require.Equal(t, "#/components/responses/Conflict", create.Responses["409"].Ref)
+
+	del := doc.Operation(http.MethodDelete, "/nodes/{id}")
+	require.Len(t, del.Parameters, 2)
+	require.Equal(t, Parameter{Name: "id", In: "path", Description: "Node ID", Required: true,
+		Schema: &Schema{Type: "string", Format: "uuid"}}, *del.Parameters[0])
+	require.Equal(t, "query", del.Parameters[1].In)
+	require.False(t, del.Parameters[1].Required)
+
+	require.JSONEq(t, `{
+		"description": "Not Found",
+		"content": {"application/json": {"schema": {
+			"type": "object",
+			"properties": {
+				"error": {"$ref": "#/components/schemas/Error", "properties": {"code": {"type": "integer", "enum": [4003]}}},
+				"meta": {"$ref": "#/components/schemas/Meta"}
+			},
+			"required": ["error", "meta"]
+		}}}
+	}`, func() string {
+		b, err := json.Marshal(doc.Components.Responses["NotFound"])
+		require.NoError(t, err)
+		return string(b)
+	}())
+
+	// Validation failures document the apierror details.
+	require.Equal(t, "#/components/responses/UnprocessableEntity", create.Responses["422"].Ref)
+	require.JSONEq(t, `{"$ref":"#/components/schemas/Details"}`, schemaJSON(t,
+		doc.Components.Responses["UnprocessableEntity"].Content["application/json"].Schema.Properties["error"].Properties["details"]))
+	require.Contains(t, doc.Components.Schemas, "FieldDetail")
+
+	patch := NewBuilder(Info{}).
+		Add(Route{Method: http.MethodPatch, Path: "/nodes/{id}", ID: "PatchNode", Body: createRequest{},
+			BodyType: "application/merge-patch+json"}).
+		Document().
+		Operation(http.MethodPatch, "/nodes/{id}")
+	require.Contains(t, patch.RequestBody.Content, "application/merge-patch+json")
+	require.NotContains(t, patch.RequestBody.Content, "application/json")
+
+	require.Panics(t, func() {
+		NewBuilder(Info{}).Add(Route{Method: "GET", Path: "/a", ID: "A"}, Route{Method: "GET", Path: "/b", ID: "A"})
+	})
+}
+
+func TestBuilder_Decorate(t *testing.T) {
+	doc := NewBuilder(Info{}).
+		Add(Route{Method: http.MethodPost, Path: "/before", ID: "Before"}).
+		Decorate(func(rt *Route) {
+			rt.Headers = append(rt.Headers, Param{Name: "X-Request-Key"})
+			rt.Errors = Statuses(rt.Errors, []int{409})
+		}).
+		Add(Route{Method: http.MethodPost, Path: "/after", ID: "After", Errors: []int{404}}).
+		Document()
+
+	require.Empty(t, doc.Operation(http.MethodPost, "/before").Parameters)
+	after := doc.Operation(http.MethodPost, "/after")
+	require.Equal(t, []*Parameter{{Name: "X-Request-Key", In: "header", Schema: &Schema{Type: "string"}}}, after.Parameters)
+	require.Contains(t, after.Responses, "404")
+	require.Contains(t, after.Responses, "409")
+}
+
+func TestCheck(t *testing.T) {
+	doc := NewBuilder(Info{}).Add(
+		Route{Method: http.MethodGet, Path: "/items", ID: "ListItems"},
+		Route{Method: http.MethodGet, Path: "/items/{id}", ID: "GetItem"},
+	).Document()
+
+	r := chi.NewRouter()
+	r.Route("/items", func(r chi.Router) {
+		r.Get("/", func(http.ResponseWriter, *http.Request) {})
+		r.Get("/{id}", func(http.ResponseWriter, *http.Request) {})
+	})
+	require.NoError(t, Check(doc, r))
+
+	r.Post("/items/{id}/archive", func(http.ResponseWriter, *http.Request) {})
+	doc.Paths["/gone"] = PathItem{"delete": {OperationID: "Gone"}}
+	require.EqualError(t, Check(doc, r),
+		"openapi: routes without an operation: POST /items/{id}/archive; operations without a route: DELETE /gone")
+}
+
+func TestHandler(t *testing.T) {
+	doc := NewBuilder(Info{Title: "Test", Version: "1.0.0"}).Document()
+	rec := httptest.NewRecorder()
+	Handler(doc)(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	require.JSONEq(t, `{"openapi":"3.1.0","info":{"title":"Test","version":"1.0.0"},"paths":{},"components":{}}`, rec.Body.String())
+}
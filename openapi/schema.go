@@ -0,0 +1,199 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Schema is a JSON Schema (draft 2020-12), as used by OpenAPI 3.1.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 any                `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	AnyOf                []*Schema          `json:"anyOf,omitempty"`
+	ContentMediaType     string             `json:"contentMediaType,omitempty"`
+	ContentEncoding      string             `json:"contentEncoding,omitempty"`
+}
+
+var (
+	timeType       = reflect.TypeFor[time.Time]()
+	uuidType       = reflect.TypeFor[uuid.UUID]()
+	rawMessageType = reflect.TypeFor[json.RawMessage]()
+	nullableType   = reflect.TypeFor[Nullable]()
+)
+
+// Nullable is implemented by types that encode as null or as a value of
+// another type, such as mergepatch.Field. NullableValue returns a value of
+// that type.
+type Nullable interface {
+	NullableValue() any
+}
+
+// generator derives schemas from Go types the way encoding/json encodes
+// them. Named structs become components referenced with $ref; a field is
+// required unless it is tagged omitempty or omitzero, and a required
+// pointer field may be null.
+type generator struct {
+	components map[string]*Schema
+	names      map[reflect.Type]string
+	owners     map[string]reflect.Type
+}
+
+func newGenerator(components map[string]*Schema) *generator {
+	return &generator{
+		components: components,
+		names:      make(map[reflect.Type]string),
+		owners:     make(map[string]reflect.Type),
+	}
+}
+
+// of returns the schema of the type of v.
+func (g *generator) of(v any) *Schema {
+	return g.schema(reflect.TypeOf(v))
+}
+
+func (g *generator) schema(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case uuidType:
+		return &Schema{Type: "string", Format: "uuid"}
+	case rawMessageType:
+		return &Schema{}
+	}
+	if t.Implements(nullableType) {
+		return orNull(g.of(reflect.Zero(t).Interface().(Nullable).NullableValue()))
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", ContentEncoding: "base64"}
+		}
+		return &Schema{Type: "array", Items: g.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + g.component(t)}
+	default:
+		// Interfaces and anything else encoding/json accepts hold any value.
+		return &Schema{}
+	}
+}
+
+// component registers the named struct t and returns its component name.
+// Names are the Go type names, qualified by package when two types share
+// one.
+func (g *generator) component(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+	name := t.Name()
+	if owner, ok := g.owners[name]; ok && owner != t {
+		pkg := t.PkgPath()
+		pkg = pkg[strings.LastIndex(pkg, "/")+1:]
+		name = strings.ToUpper(pkg[:1]) + pkg[1:] + name
+	}
+	// Name the type before building it so that recursive types refer to
+	// themselves.
+	g.names[t] = name
+	g.owners[name] = t
+	g.components[name] = g.object(t)
+	return name
+}
+
+func (g *generator) object(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	g.fields(t, s)
+	return s
+}
+
+func (g *generator) fields(t reflect.Type, s *Schema) {
+	for i := range t.NumField() {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				g.fields(ft, s)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		fs := g.schema(f.Type)
+		if hasOption(opts, "string") {
+			fs = &Schema{Type: "string"}
+		}
+		if !hasOption(opts, "omitempty") && !hasOption(opts, "omitzero") {
+			if f.Type.Kind() == reflect.Pointer {
+				fs = orNull(fs)
+			}
+			s.Required = append(s.Required, name)
+		}
+		s.Properties[name] = fs
+	}
+}
+
+func hasOption(opts, name string) bool {
+	for opts != "" {
+		var opt string
+		opt, opts, _ = strings.Cut(opts, ",")
+		if opt == name {
+			return true
+		}
+	}
+	return false
+}
+
+// orNull returns a schema that also accepts null.
+func orNull(s *Schema) *Schema {
+	switch typ := s.Type.(type) {
+	case string:
+		n := *s
+		n.Type = []string{typ, "null"}
+		return &n
+	case nil:
+		if s.Ref == "" {
+			return s
+		}
+	}
+	return &Schema{AnyOf: []*Schema{s, {Type: "null"}}}
+}
@@ -0,0 +1,135 @@
+// Package client is a typed Go client for the ou plugin's HTTP API. Error
+// responses are mapped back to the organization package's sentinel errors.
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+
+	"github.com/leeforge/plugins/apiclient"
+	"github.com/leeforge/plugins/apierror"
+	"github.com/leeforge/plugins/etag"
+	"github.com/leeforge/plugins/ou/organization"
+)
+
+// Client calls the /ou/organizations routes. Organizations belong to the
+// domain of the request's tenant, so create the client with
+// apiclient.WithTenant or switch tenants with With.
+type Client struct {
+	api *apiclient.Client
+}
+
+// New returns a client for the API at baseURL, the prefix the host mounts
+// plugin routes under, for example "https://api.example.com/api/v1".
+func New(baseURL string, opts ...apiclient.Option) (*Client, error) {
+	api, err := apiclient.New(baseURL, mapError, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{api: api}, nil
+}
+
+// With returns a copy of the client with opts applied.
+func (c *Client) With(opts ...apiclient.Option) *Client {
+	return &Client{api: c.api.With(opts...)}
+}
+
+// codes are the typed sentinels, which the handler answers with their code
+// in the error details.
+var codes = []*apierror.Error{
+	organization.ErrDomainContextMissing,
+	organization.ErrInvalidDomainID,
+	organization.ErrMemberAlreadyExists,
+	organization.ErrInvalidOrganization,
+	organization.ErrOrganizationExists,
+}
+
+// messages are the handler responses of the other organization sentinels.
+var messages = map[string]error{
+	"organization not found": organization.ErrOrganizationNotFound,
+}
+
+func mapError(e *apiclient.Error) error {
+	if e.StatusCode == http.StatusPreconditionFailed {
+		return organization.ErrVersionMismatch
+	}
+	if err := apiclient.MatchCode(e.Details, codes...); err != nil {
+		return err
+	}
+	return apiclient.MatchSentinel(e.Message, messages)
+}
+
+// CreateOrganization creates an organization, below req.ParentID if set.
+func (c *Client) CreateOrganization(ctx context.Context, req *organization.CreateOrganizationRequest) (*organization.OrganizationResponse, error) {
+	var out organization.OrganizationResponse
+	if err := c.api.Do(ctx, http.MethodPost, "ou/organizations", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetOrganizationTree returns the root organizations of the domain with
+// their descendants.
+func (c *Client) GetOrganizationTree(ctx context.Context) ([]*organization.OrganizationTreeNode, error) {
+	var out []*organization.OrganizationTreeNode
+	if err := c.api.Do(ctx, http.MethodGet, "ou/organizations/tree", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetOrganization returns an organization. Its Version is the one
+// PatchOrganization takes as ExpectedVersion.
+func (c *Client) GetOrganization(ctx context.Context, id uuid.UUID) (*organization.OrganizationResponse, error) {
+	var out organization.OrganizationResponse
+	if err := c.api.Do(ctx, http.MethodGet, "ou/organizations/"+id.String(), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// PatchOrganization applies a JSON merge patch to an organization: only the
+// fields set in req are sent, a mergepatch.Null parent making it a root.
+// req.ExpectedVersion is sent as If-Match; when the organization has another
+// version the error matches organization.ErrVersionMismatch and
+// CurrentOrganization returns the current organization.
+func (c *Client) PatchOrganization(ctx context.Context, id uuid.UUID, req *organization.PatchOrganizationRequest) (*organization.OrganizationResponse, error) {
+	var header http.Header
+	if req != nil && req.ExpectedVersion != "" {
+		header = http.Header{etag.IfMatchHeader: {strconv.Quote(req.ExpectedVersion)}}
+	}
+	var out organization.OrganizationResponse
+	if err := c.api.DoHeader(ctx, http.MethodPatch, "ou/organizations/"+id.String(), nil, header, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CurrentOrganization returns the current organization sent with a version
+// mismatch error of PatchOrganization.
+func CurrentOrganization(err error) (*organization.OrganizationResponse, bool) {
+	var apiErr *apiclient.Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusPreconditionFailed || len(apiErr.Data) == 0 {
+		return nil, false
+	}
+	var out organization.OrganizationResponse
+	if json.Unmarshal(apiErr.Data, &out) != nil {
+		return nil, false
+	}
+	return &out, true
+}
+
+// AddOrganizationMember adds a user to an organization.
+func (c *Client) AddOrganizationMember(ctx context.Context, organizationID uuid.UUID, req *organization.AddOrganizationMemberRequest) (*organization.OrganizationMemberResponse, error) {
+	var out organization.OrganizationMemberResponse
+	path := "ou/organizations/" + organizationID.String() + "/members"
+	if err := c.api.Do(ctx, http.MethodPost, path, nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
@@ -0,0 +1,153 @@
+//go:build integration
+// +build integration
+
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"entgo.io/ent/dialect"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/leeforge/framework/logging"
+
+	"github.com/leeforge/core/core"
+	"github.com/leeforge/core/server/ent/enttest"
+
+	"github.com/leeforge/plugins/apiclient"
+	"github.com/leeforge/plugins/mergepatch"
+	"github.com/leeforge/plugins/ou/organization"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestClient_Organizations(t *testing.T) {
+	client := enttest.Open(t, dialect.SQLite, "file:ou_client?mode=memory&cache=shared&_fk=1")
+	t.Cleanup(func() { _ = client.Close() })
+
+	h := organization.NewHandler(organization.NewService(client), logging.FromZap(zap.NewNop()))
+	var gotAuth string
+	r := chi.NewRouter()
+	// Stands in for the host's tenant middleware: the tenant header carries
+	// the domain ID.
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			gotAuth = req.Header.Get("Authorization")
+			ctx := req.Context()
+			if domain := req.Header.Get(apiclient.DefaultTenantHeader); domain != "" {
+				ctx = core.WithDomainID(ctx, domain)
+			}
+			next.ServeHTTP(w, req.WithContext(ctx))
+		})
+	})
+	r.Route("/api/v1/ou/organizations", func(r chi.Router) {
+		r.Post("/", h.CreateOrganization)
+		r.Get("/tree", h.GetOrganizationTree)
+		r.Get("/{id}", h.GetOrganization)
+		r.Patch("/{id}", h.PatchOrganization)
+		r.Post("/{id}/members", h.AddOrganizationMember)
+	})
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+
+	c, err := New(srv.URL+"/api/v1", apiclient.WithAuth(apiclient.APIKey("lf_test")))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	_, err = c.GetOrganizationTree(ctx)
+	require.ErrorIs(t, err, organization.ErrDomainContextMissing)
+	_, err = c.With(apiclient.WithTenant("not-a-uuid")).GetOrganizationTree(ctx)
+	require.ErrorIs(t, err, organization.ErrInvalidDomainID)
+	require.Equal(t, "ApiKey lf_test", gotAuth)
+
+	domain := uuid.New()
+	c = c.With(apiclient.WithTenant(domain.String()))
+	root, err := c.CreateOrganization(ctx, &organization.CreateOrganizationRequest{Code: "hq", Name: "HQ"})
+	require.NoError(t, err)
+	require.Equal(t, domain, root.DomainID)
+	sales, err := c.CreateOrganization(ctx, &organization.CreateOrganizationRequest{Code: "sales", Name: "Sales", ParentID: &root.ID})
+	require.NoError(t, err)
+	require.Equal(t, "hq/sales", sales.Path)
+
+	missing := uuid.New()
+	_, err = c.CreateOrganization(ctx, &organization.CreateOrganizationRequest{Code: "x", Name: "X", ParentID: &missing})
+	require.ErrorIs(t, err, organization.ErrOrganizationNotFound)
+	require.True(t, apiclient.IsStatus(err, http.StatusNotFound))
+
+	user := client.User.Create().SetUsername("rep").SetEmail("rep@example.com").SaveX(ctx)
+	member, err := c.AddOrganizationMember(ctx, sales.ID, &organization.AddOrganizationMemberRequest{UserID: user.ID, IsPrimary: true})
+	require.NoError(t, err)
+	require.Equal(t, user.ID, member.UserID)
+	_, err = c.AddOrganizationMember(ctx, sales.ID, &organization.AddOrganizationMemberRequest{UserID: user.ID})
+	require.ErrorIs(t, err, organization.ErrMemberAlreadyExists)
+
+	tree, err := c.GetOrganizationTree(ctx)
+	require.NoError(t, err)
+	require.Len(t, tree, 1)
+	require.Equal(t, root.ID, tree[0].ID)
+	require.Len(t, tree[0].Children, 1)
+	require.Equal(t, sales.ID, tree[0].Children[0].ID)
+
+	// The tree answers its version as the ETag.
+	req, err := http.NewRequest(http.MethodGet, srv.URL+"/api/v1/ou/organizations/tree", nil)
+	require.NoError(t, err)
+	req.Header.Set(apiclient.DefaultTenantHeader, domain.String())
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	require.NotEmpty(t, resp.Header.Get("ETag"))
+
+	// A merge patch only changes the members it names.
+	detached, err := c.PatchOrganization(ctx, sales.ID, &organization.PatchOrganizationRequest{
+		ParentID: mergepatch.Null[uuid.UUID](), ExpectedVersion: tree[0].Children[0].Version,
+	})
+	require.NoError(t, err)
+	require.Nil(t, detached.ParentID)
+	require.Equal(t, "Sales", detached.Name)
+	require.Equal(t, "sales", detached.Path)
+
+	// Patches based on a stale version are answered with the current one.
+	_, err = c.PatchOrganization(ctx, sales.ID, &organization.PatchOrganizationRequest{
+		Name: mergepatch.Value("Lost patch"), ExpectedVersion: sales.Version,
+	})
+	require.ErrorIs(t, err, organization.ErrVersionMismatch)
+	require.True(t, apiclient.IsStatus(err, http.StatusPreconditionFailed))
+	current, ok := CurrentOrganization(err)
+	require.True(t, ok)
+	require.Equal(t, detached.Version, current.Version)
+	require.Equal(t, "Sales", current.Name)
+	_, err = c.PatchOrganization(ctx, sales.ID, &organization.PatchOrganizationRequest{Name: mergepatch.Null[string]()})
+	require.ErrorIs(t, err, organization.ErrInvalidOrganization)
+
+	// The ETag of a read is accepted as If-Match by a patch.
+	got, err := c.GetOrganization(ctx, sales.ID)
+	require.NoError(t, err)
+	require.Equal(t, detached.Version, got.Version)
+	send := func(method, ifMatch, body string) *http.Response {
+		req, err := http.NewRequest(method, srv.URL+"/api/v1/ou/organizations/"+sales.ID.String(), strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set(apiclient.DefaultTenantHeader, domain.String())
+		req.Header.Set("Content-Type", mergepatch.MediaType)
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		return resp
+	}
+	read := send(http.MethodGet, "", "")
+	require.Equal(t, http.StatusOK, read.StatusCode)
+	patchedResp := send(http.MethodPatch, read.Header.Get("ETag"), `{"name":"Sales EU"}`)
+	require.Equal(t, http.StatusOK, patchedResp.StatusCode)
+	require.NotEqual(t, read.Header.Get("ETag"), patchedResp.Header.Get("ETag"))
+	require.Equal(t, http.StatusPreconditionFailed, send(http.MethodPatch, read.Header.Get("ETag"), `{"name":"Lost"}`).StatusCode)
+	require.Equal(t, http.StatusOK, send(http.MethodPatch, patchedResp.Header.Get("ETag"), `{"name":"Sales"}`).StatusCode)
+}
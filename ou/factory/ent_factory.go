@@ -3,22 +3,41 @@ package factory
 import (
 	"github.com/leeforge/core/server/ent"
 	organizationmod "github.com/leeforge/plugins/ou/organization"
+	"github.com/leeforge/plugins/tracing"
 )
 
 // EntFactory implements ou.ServiceFactory using a core Ent client.
 type EntFactory struct {
 	client *ent.Client
+	tracer tracing.Tracer
+}
+
+// Option configures an EntFactory.
+type Option func(*EntFactory)
+
+// WithTracer makes the plugin trace organization service operations with t.
+func WithTracer(t tracing.Tracer) Option {
+	return func(f *EntFactory) { f.tracer = t }
 }
 
 // NewEntFactory creates a factory backed by the given Ent client.
-func NewEntFactory(client *ent.Client) *EntFactory {
-	return &EntFactory{client: client}
+func NewEntFactory(client *ent.Client, opts ...Option) *EntFactory {
+	f := &EntFactory{client: client}
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f
 }
 
 func (f *EntFactory) NewOrganizationService() *organizationmod.Service {
 	return organizationmod.NewService(f.client)
 }
 
+// Tracer implements ou.TracerProvider.
+func (f *EntFactory) Tracer() tracing.Tracer {
+	return tracing.OrNop(f.tracer)
+}
+
 func (f *EntFactory) Models() []any {
 	return []any{"organization", "organization_member"}
 }
@@ -0,0 +1,78 @@
+package ou
+
+import (
+	"context"
+	"time"
+
+	"github.com/leeforge/plugins/health"
+	"github.com/leeforge/plugins/ou/shared"
+)
+
+// pinger is implemented by dependencies that offer a cheap, side-effect-free
+// reachability probe. Dependencies without one are ready once configured.
+type pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// slowProbeThreshold degrades a dependency that answers but is slow.
+const slowProbeThreshold = 500 * time.Millisecond
+
+// Liveness reports whether the plugin is initialized and able to serve.
+func (p *OUPlugin) Liveness(ctx context.Context) *health.Report {
+	return health.Run(ctx, p.Name(), health.KindLiveness, []health.Check{
+		{
+			Name:     "service",
+			Critical: true,
+			Probe: func(context.Context) error {
+				if p.orgSvc == nil {
+					return shared.ErrNotInitialized
+				}
+				return nil
+			},
+		},
+	})
+}
+
+// Readiness reports the state of every dependency the plugin needs to
+// serve requests, one entry per dependency.
+func (p *OUPlugin) Readiness(ctx context.Context) *health.Report {
+	return health.Run(ctx, p.Name(), health.KindReadiness, []health.Check{
+		{
+			Name:          "database",
+			Critical:      true,
+			SlowThreshold: slowProbeThreshold,
+			Probe: func(ctx context.Context) error {
+				if p.orgSvc == nil {
+					return shared.ErrNotInitialized
+				}
+				return p.orgSvc.Ping(ctx)
+			},
+		},
+		{
+			Name:  "event_bus",
+			Probe: p.probeEventBus,
+		},
+		{
+			Name:          "cache",
+			SlowThreshold: slowProbeThreshold,
+			Probe:         p.probeCache,
+		},
+	})
+}
+
+func (p *OUPlugin) probeEventBus(ctx context.Context) error {
+	if p.events == nil {
+		return health.ErrNotConfigured
+	}
+	if b, ok := p.events.(pinger); ok {
+		return b.Ping(ctx)
+	}
+	return nil
+}
+
+func (p *OUPlugin) probeCache(ctx context.Context) error {
+	if p.redis == nil {
+		return health.ErrNotConfigured
+	}
+	return p.redis.Ping(ctx).Err()
+}
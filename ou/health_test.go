@@ -0,0 +1,17 @@
+package ou
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/leeforge/plugins/health"
+	"github.com/leeforge/plugins/ou/shared"
+)
+
+func TestOUPlugin_HealthCheck_NotInitialized(t *testing.T) {
+	p := &OUPlugin{}
+	require.ErrorIs(t, p.HealthCheck(context.Background()), shared.ErrNotInitialized)
+	require.Equal(t, health.StatusDown, p.Liveness(context.Background()).Status)
+}
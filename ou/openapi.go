@@ -0,0 +1,36 @@
+package ou
+
+import (
+	"net/http"
+
+	"github.com/leeforge/plugins/health"
+	"github.com/leeforge/plugins/idempotency"
+	"github.com/leeforge/plugins/openapi"
+	organizationmod "github.com/leeforge/plugins/ou/organization"
+)
+
+// OpenAPI returns the OpenAPI document of the routes RegisterRoutes mounts.
+// It is served from GET /ou/openapi.json.
+func (p *OUPlugin) OpenAPI() *openapi.Document {
+	b := openapi.NewBuilder(openapi.Info{
+		Title:       "Leeforge OU plugin API",
+		Description: "Organization units: the organization tree of a domain and its members.",
+		Version:     p.Version(),
+	}).
+		Server("..", "The API prefix the plugin routes are mounted under").
+		Tag("OUPlugin-Health", "Liveness and readiness probes")
+
+	b.Add(
+		openapi.Route{Method: http.MethodGet, Path: "/ou/health/live", ID: "OULiveness",
+			Summary: "Liveness probe", Tags: []string{"OUPlugin-Health"}, Response: health.Report{},
+			Others: map[int]any{http.StatusServiceUnavailable: health.Report{}}},
+		openapi.Route{Method: http.MethodGet, Path: "/ou/health/ready", ID: "OUReadiness",
+			Summary: "Readiness probe", Tags: []string{"OUPlugin-Health"}, Response: health.Report{},
+			Others: map[int]any{http.StatusServiceUnavailable: health.Report{}}},
+		openapi.Route{Method: http.MethodGet, Path: "/ou/openapi.json", ID: "GetOUOpenAPI",
+			Summary: "This OpenAPI document", Response: map[string]any{}, Raw: true},
+	)
+	b.Decorate(idempotency.Describe)
+	organizationmod.DescribeRoutes(b)
+	return b.Document()
+}
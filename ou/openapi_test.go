@@ -0,0 +1,35 @@
+package ou
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/leeforge/framework/logging"
+
+	"github.com/leeforge/plugins/openapi"
+	organizationmod "github.com/leeforge/plugins/ou/organization"
+)
+
+func TestOUPlugin_OpenAPI_CoversRoutes(t *testing.T) {
+	p := &OUPlugin{orgHdlr: organizationmod.NewHandler(nil, logging.FromZap(zap.NewNop()))}
+	router := chi.NewRouter()
+	p.RegisterRoutes(router)
+	require.NoError(t, openapi.Check(p.OpenAPI(), router))
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ou/openapi.json", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var doc openapi.Document
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &doc))
+	op := doc.Operation(http.MethodGet, "/ou/organizations/tree")
+	require.NotNil(t, op)
+	require.Equal(t, "#/components/schemas/OrganizationTreeNode",
+		op.Responses["200"].Content["application/json"].Schema.Properties["data"].Items.Ref)
+}
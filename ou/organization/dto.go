@@ -1,6 +1,10 @@
 package organization
 
-import "github.com/google/uuid"
+import (
+	"github.com/google/uuid"
+
+	"github.com/leeforge/plugins/mergepatch"
+)
 
 type CreateOrganizationRequest struct {
 	Code     string     `json:"code" binding:"required"`
@@ -8,6 +12,21 @@ type CreateOrganizationRequest struct {
 	ParentID *uuid.UUID `json:"parentId,omitempty"`
 }
 
+// PatchOrganizationRequest is a JSON merge patch (RFC 7396) of an
+// organization, as sent to PATCH /ou/organizations/{id}. Absent members are
+// left unchanged and a null parentId makes the organization a root; code and
+// name cannot be cleared. Moving an organization moves its subtree along.
+type PatchOrganizationRequest struct {
+	Code     mergepatch.Field[string]    `json:"code,omitzero"`
+	Name     mergepatch.Field[string]    `json:"name,omitzero"`
+	ParentID mergepatch.Field[uuid.UUID] `json:"parentId,omitzero"`
+
+	// ExpectedVersion, when set, makes the patch fail with
+	// ErrVersionMismatch unless the organization is still at this
+	// OrganizationResponse.Version. Over HTTP it is sent as If-Match.
+	ExpectedVersion string `json:"-"`
+}
+
 type AddOrganizationMemberRequest struct {
 	UserID    uuid.UUID `json:"userId" binding:"required"`
 	IsPrimary bool      `json:"isPrimary"`
@@ -20,6 +39,9 @@ type OrganizationResponse struct {
 	Code     string     `json:"code"`
 	Name     string     `json:"name"`
 	Path     string     `json:"path"`
+	// Version changes with every update; GET and PATCH answer it as the
+	// ETag.
+	Version string `json:"version"`
 }
 
 type OrganizationTreeNode struct {
@@ -29,6 +51,7 @@ type OrganizationTreeNode struct {
 	Code     string                  `json:"code"`
 	Name     string                  `json:"name"`
 	Path     string                  `json:"path"`
+	Version  string                  `json:"version"`
 	Children []*OrganizationTreeNode `json:"children,omitempty"`
 }
 
@@ -38,3 +61,17 @@ type OrganizationMemberResponse struct {
 	UserID         uuid.UUID `json:"userId"`
 	IsPrimary      bool      `json:"isPrimary"`
 }
+
+// OrganizationSnapshot is an organization with its members, as exported by
+// ExportOrganizations.
+type OrganizationSnapshot struct {
+	Code    string           `json:"code"`
+	Name    string           `json:"name"`
+	Path    string           `json:"path"`
+	Members []MemberSnapshot `json:"members,omitempty"`
+}
+
+type MemberSnapshot struct {
+	UserID    uuid.UUID `json:"userId"`
+	IsPrimary bool      `json:"isPrimary"`
+}
@@ -1,8 +1,11 @@
 package organization
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
@@ -11,6 +14,10 @@ import (
 	"github.com/leeforge/core/server/httplog"
 	"github.com/leeforge/framework/http/responder"
 	"github.com/leeforge/framework/logging"
+
+	"github.com/leeforge/plugins/apierror"
+	"github.com/leeforge/plugins/etag"
+	"github.com/leeforge/plugins/mergepatch"
 )
 
 type Handler struct {
@@ -32,7 +39,7 @@ func NewHandler(service *Service, logger logging.Logger) *Handler {
 // @Accept json
 // @Produce json
 // @Param body body CreateOrganizationRequest true "Organization payload"
-// @Success 200 {object} map[string]interface{}
+// @Success 200 {object} OrganizationResponse
 // @Failure 400 {object} map[string]interface{}
 // @Failure 404 {object} map[string]interface{}
 // @Failure 409 {object} map[string]interface{}
@@ -58,7 +65,7 @@ func (h *Handler) CreateOrganization(w http.ResponseWriter, r *http.Request) {
 // @Summary Get organization tree
 // @Tags OUPlugin-Organizations
 // @Produce json
-// @Success 200 {object} map[string]interface{}
+// @Success 200 {array} OrganizationTreeNode
 // @Failure 400 {object} map[string]interface{}
 // @Failure 500 {object} map[string]interface{}
 // @Router /api/v1/ou/organizations/tree [get]
@@ -68,6 +75,87 @@ func (h *Handler) GetOrganizationTree(w http.ResponseWriter, r *http.Request) {
 		h.mapServiceError(w, r, err)
 		return
 	}
+	etag.Set(w, treeVersion(result))
+	responder.OK(w, r, result)
+}
+
+// GetOrganization handles GET /ou/organizations/{id}. The ETag it answers is
+// the one PatchOrganization takes as If-Match.
+//
+// @Summary Get organization
+// @Tags OUPlugin-Organizations
+// @Produce json
+// @Param id path string true "Organization ID"
+// @Success 200 {object} OrganizationResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/ou/organizations/{id} [get]
+func (h *Handler) GetOrganization(w http.ResponseWriter, r *http.Request) {
+	organizationID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		responder.BadRequest(w, r, "Invalid organization ID")
+		return
+	}
+
+	result, err := h.service.GetOrganization(r.Context(), organizationID)
+	if err != nil {
+		h.mapServiceError(w, r, err)
+		return
+	}
+	etag.Set(w, result.Version)
+	responder.OK(w, r, result)
+}
+
+// PatchOrganization handles PATCH /ou/organizations/{id} with a JSON merge
+// patch (RFC 7396): absent members are left unchanged and a null parentId
+// makes the organization a root. With an If-Match header the patch only
+// applies while the organization is at that ETag, as answered by
+// GetOrganization and PatchOrganization; otherwise it is answered with 412
+// and the current organization. The tree ETag covers the whole tree and is
+// not an organization version.
+//
+// @Summary Patch organization
+// @Tags OUPlugin-Organizations
+// @Accept application/merge-patch+json
+// @Produce json
+// @Param id path string true "Organization ID"
+// @Param If-Match header string false "ETag of the organization version the patch is based on"
+// @Param body body PatchOrganizationRequest true "Organization merge patch"
+// @Success 200 {object} OrganizationResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 412 {object} OrganizationResponse
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/ou/organizations/{id} [patch]
+func (h *Handler) PatchOrganization(w http.ResponseWriter, r *http.Request) {
+	organizationID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		responder.BadRequest(w, r, "Invalid organization ID")
+		return
+	}
+	if err := mergepatch.CheckMediaType(r); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	var req PatchOrganizationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		responder.BindError(w, r, nil)
+		return
+	}
+	if req.ExpectedVersion, err = etag.IfMatch(r); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	result, err := h.service.PatchOrganization(r.Context(), organizationID, &req)
+	if err != nil {
+		h.mapServiceError(w, r, err)
+		return
+	}
+	etag.Set(w, result.Version)
 	responder.OK(w, r, result)
 }
 
@@ -79,7 +167,7 @@ func (h *Handler) GetOrganizationTree(w http.ResponseWriter, r *http.Request) {
 // @Produce json
 // @Param id path string true "Organization ID"
 // @Param body body AddOrganizationMemberRequest true "Organization member payload"
-// @Success 200 {object} map[string]interface{}
+// @Success 200 {object} OrganizationMemberResponse
 // @Failure 400 {object} map[string]interface{}
 // @Failure 404 {object} map[string]interface{}
 // @Failure 409 {object} map[string]interface{}
@@ -106,18 +194,44 @@ func (h *Handler) AddOrganizationMember(w http.ResponseWriter, r *http.Request)
 	responder.OK(w, r, result)
 }
 
+// mapServiceError maps service errors to HTTP responses. Typed errors are
+// answered by apierror.Write with their own status and a localized message.
 func (h *Handler) mapServiceError(w http.ResponseWriter, r *http.Request, err error) {
 	switch {
-	case errors.Is(err, ErrDomainContextMissing):
-		responder.BadRequest(w, r, "Missing domain context")
-	case errors.Is(err, ErrInvalidDomainID):
-		responder.BadRequest(w, r, "Invalid domain context")
+	case apierror.Write(w, r, err):
 	case errors.Is(err, ErrOrganizationNotFound):
 		responder.NotFound(w, r, "Organization not found")
-	case errors.Is(err, ErrMemberAlreadyExists):
-		responder.Conflict(w, r, "Organization member already exists")
+	case errors.Is(err, ErrVersionMismatch):
+		h.writeCurrentOrganization(w, r)
 	default:
 		httplog.Error(h.logger, r, "OU organization operation failed", err)
 		responder.DatabaseError(w, r, "OU organization operation failed")
 	}
 }
+
+// writeCurrentOrganization answers a failed If-Match precondition with 412
+// and the current organization, or with 404 when it was deleted meanwhile.
+func (h *Handler) writeCurrentOrganization(w http.ResponseWriter, r *http.Request) {
+	id, _ := uuid.Parse(chi.URLParam(r, "id"))
+	current, err := h.service.GetOrganization(r.Context(), id)
+	if err != nil {
+		h.mapServiceError(w, r, err)
+		return
+	}
+	etag.PreconditionFailed(w, r, current.Version, current)
+}
+
+// treeVersion is the version of a tree, which changes whenever a node is
+// added, removed or updated.
+func treeVersion(roots []*OrganizationTreeNode) string {
+	hash := sha256.New()
+	var walk func(nodes []*OrganizationTreeNode)
+	walk = func(nodes []*OrganizationTreeNode) {
+		for _, n := range nodes {
+			fmt.Fprintf(hash, "%s:%s;", n.ID, n.Version)
+			walk(n.Children)
+		}
+	}
+	walk(roots)
+	return hex.EncodeToString(hash.Sum(nil)[:16])
+}
@@ -0,0 +1,44 @@
+package organization
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/leeforge/core/core"
+	"github.com/leeforge/framework/logging"
+)
+
+func TestHandler_ValidationErrors(t *testing.T) {
+	h := NewHandler(NewService(nil), logging.FromZap(zap.NewNop()))
+	ctx := core.WithDomainID(context.Background(), uuid.NewString())
+
+	send := func(req *http.Request, handle http.HandlerFunc) (*httptest.ResponseRecorder, map[string]any) {
+		rec := httptest.NewRecorder()
+		handle(rec, req)
+		var body struct {
+			Error map[string]any `json:"error"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		return rec, body.Error
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/ou/organizations", strings.NewReader(`{"name":"Sales"}`)).WithContext(ctx)
+	rec, body := send(req, h.CreateOrganization)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	require.Equal(t, "Invalid organization data: code is required", body["message"])
+	require.Equal(t, "ou.invalid_organization", body["details"].(map[string]any)["code"])
+
+	req = httptest.NewRequest(http.MethodPost, "/ou/organizations", strings.NewReader(`{"code":"sales","name":"Sales"}`))
+	req.Header.Set("Accept-Language", "zh")
+	rec, body = send(req, h.CreateOrganization)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	require.Equal(t, "缺少域上下文", body["message"])
+}
@@ -0,0 +1,22 @@
+package organization
+
+import "github.com/leeforge/plugins/apierror"
+
+func init() {
+	apierror.Register(apierror.Messages{
+		apierror.English: {
+			ErrDomainContextMissing.Code: "Missing domain context",
+			ErrInvalidDomainID.Code:      "Invalid domain context",
+			ErrMemberAlreadyExists.Code:  "Organization member already exists",
+			ErrInvalidOrganization.Code:  "Invalid organization data",
+			ErrOrganizationExists.Code:   "Organization code already exists",
+		},
+		apierror.Chinese: {
+			ErrDomainContextMissing.Code: "缺少域上下文",
+			ErrInvalidDomainID.Code:      "域上下文无效",
+			ErrMemberAlreadyExists.Code:  "组织成员已存在",
+			ErrInvalidOrganization.Code:  "组织数据无效",
+			ErrOrganizationExists.Code:   "组织编码已存在",
+		},
+	})
+}
@@ -0,0 +1,69 @@
+package organization
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/leeforge/plugins/apierror"
+	"github.com/leeforge/plugins/metrics"
+)
+
+// Metric names exported by the organization service.
+const (
+	MetricOperations        = "ou_operations_total"
+	MetricOperationDuration = "ou_operation_duration_seconds"
+	MetricOrganizations     = "ou_organizations"
+)
+
+var operationMetrics = metrics.Operation{
+	Counter:   MetricOperations,
+	Histogram: MetricOperationDuration,
+	Classify:  outcomeOf,
+}
+
+// SetMetrics replaces the metrics recorder. Gauges are refreshed by
+// CollectGauges, which the plugin registers as a metrics collector.
+func (s *Service) SetMetrics(rec metrics.Recorder) {
+	s.metrics = metrics.OrNop(rec)
+	metrics.Describe(s.metrics, MetricOperations, "Organization service operations by operation and outcome.")
+	metrics.Describe(s.metrics, MetricOperationDuration, "Latency of organization service operations.")
+	metrics.Describe(s.metrics, MetricOrganizations, "Organizations across all domains.")
+}
+
+// CollectGauges refreshes the organization count gauge.
+func (s *Service) CollectGauges(ctx context.Context, rec metrics.Recorder) error {
+	if s.client == nil {
+		return errors.New("ou gauges: database client not initialized")
+	}
+	n, err := s.client.Organization.Query().Count(ctx)
+	if err != nil {
+		return fmt.Errorf("count organizations: %w", err)
+	}
+	rec.SetGauge(MetricOrganizations, float64(n), nil)
+	return nil
+}
+
+// observe records the outcome and latency of a service operation that
+// ended with *errp.
+func (s *Service) observe(op string, start time.Time, errp *error) {
+	operationMetrics.Observe(s.metrics, op, start, *errp)
+}
+
+// outcomeOf maps a service error to a low-cardinality outcome label. API
+// errors are classified by their status; the plain sentinels below are
+// answered with the status they are classified as.
+func outcomeOf(err error) string {
+	var apiErr *apierror.Error
+	switch {
+	case errors.As(err, &apiErr):
+		return metrics.StatusOutcome(apiErr.Status)
+	case errors.Is(err, ErrOrganizationNotFound):
+		return metrics.OutcomeNotFound
+	case errors.Is(err, ErrVersionMismatch):
+		return metrics.OutcomeConflict
+	default:
+		return metrics.OutcomeError
+	}
+}
@@ -0,0 +1,23 @@
+package organization
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/leeforge/plugins/metrics"
+)
+
+func TestService_Metrics_RecordsOutcome(t *testing.T) {
+	reg := metrics.NewRegistry()
+	svc := NewService(nil)
+	svc.SetMetrics(reg)
+
+	_, err := svc.GetOrganizationTree(context.Background())
+	require.ErrorIs(t, err, ErrDomainContextMissing)
+
+	labels := metrics.Labels{"operation": "get_organization_tree", "outcome": "invalid"}
+	require.Equal(t, 1.0, reg.Value(MetricOperations, labels))
+	require.Equal(t, 1.0, reg.Value(MetricOperationDuration, labels))
+}
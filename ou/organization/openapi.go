@@ -0,0 +1,48 @@
+package organization
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"github.com/leeforge/plugins/etag"
+	"github.com/leeforge/plugins/mergepatch"
+	"github.com/leeforge/plugins/openapi"
+)
+
+// serviceErrorStatuses are the statuses mapServiceError answers with.
+// TestMapServiceErrorStatuses keeps them in line with the mapper.
+var serviceErrorStatuses = []int{400, 404, 409, 500}
+
+const openAPITag = "OUPlugin-Organizations"
+
+// DescribeRoutes adds the handler routes, as mounted under /ou/organizations
+// by the plugin, to b.
+func DescribeRoutes(b *openapi.Builder) {
+	b.Tag(openAPITag, "Organization units of the current domain").
+		PathParam(openapi.Param{Name: "id", Description: "Organization ID", Type: uuid.UUID{}})
+
+	tags := []string{openAPITag}
+	patch := openapi.Route{Method: http.MethodPatch, Path: "/ou/organizations/{id}", ID: "PatchOrganization",
+		Summary: "Patch organization",
+		Description: "Applies a JSON merge patch (RFC 7396): absent members are left unchanged and a null " +
+			"parentId makes the organization a root. Moving an organization moves its subtree along.",
+		Tags: tags, Body: PatchOrganizationRequest{}, Response: OrganizationResponse{}, Errors: serviceErrorStatuses}
+	etag.Describe(&patch, OrganizationResponse{})
+	mergepatch.Describe(&patch)
+	b.Add(
+		openapi.Route{Method: http.MethodPost, Path: "/ou/organizations", ID: "CreateOrganization",
+			Summary: "Create organization", Tags: tags,
+			Body: CreateOrganizationRequest{}, Response: OrganizationResponse{}, Errors: serviceErrorStatuses},
+		openapi.Route{Method: http.MethodGet, Path: "/ou/organizations/tree", ID: "GetOrganizationTree",
+			Summary: "Get organization tree", Tags: tags,
+			Response: []*OrganizationTreeNode{}, Errors: serviceErrorStatuses},
+		openapi.Route{Method: http.MethodGet, Path: "/ou/organizations/{id}", ID: "GetOrganization",
+			Summary: "Get organization", Tags: tags,
+			Response: OrganizationResponse{}, Errors: serviceErrorStatuses},
+		patch,
+		openapi.Route{Method: http.MethodPost, Path: "/ou/organizations/{id}/members", ID: "AddOrganizationMember",
+			Summary: "Add organization member", Tags: tags,
+			Body: AddOrganizationMemberRequest{}, Response: OrganizationMemberResponse{}, Errors: serviceErrorStatuses},
+	)
+}
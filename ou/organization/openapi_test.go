@@ -0,0 +1,33 @@
+package organization
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/leeforge/framework/logging"
+)
+
+func TestMapServiceErrorStatuses(t *testing.T) {
+	h := NewHandler(nil, logging.FromZap(zap.NewNop()))
+
+	var got []int
+	for _, err := range []error{
+		ErrDomainContextMissing, ErrInvalidDomainID, ErrOrganizationNotFound, ErrMemberAlreadyExists, ErrInvalidOrganization,
+		ErrOrganizationExists,
+		errors.New("database is down"),
+	} {
+		rec := httptest.NewRecorder()
+		h.mapServiceError(rec, httptest.NewRequest(http.MethodGet, "/ou/organizations/tree", nil), err)
+		if !slices.Contains(got, rec.Code) {
+			got = append(got, rec.Code)
+		}
+	}
+	slices.Sort(got)
+	require.Equal(t, serviceErrorStatuses, got)
+}
@@ -3,6 +3,8 @@ package organization
 import (
 	"context"
 	"errors"
+	"net/http"
+	"sort"
 	"strings"
 
 	"github.com/google/uuid"
@@ -12,24 +14,48 @@ import (
 	organizationMemberEnt "github.com/leeforge/core/server/ent/organizationmember"
 
 	"github.com/leeforge/core/core"
+
+	"github.com/leeforge/plugins/apierror"
+	"github.com/leeforge/plugins/etag"
+	"github.com/leeforge/plugins/metrics"
+	"github.com/leeforge/plugins/tracing"
 )
 
+// Organization errors. Those declared with apierror.New are answered by the
+// handler with their status, code and catalogue message (see messages.go).
 var (
-	ErrDomainContextMissing = errors.New("ou organization: missing domain context")
-	ErrInvalidDomainID      = errors.New("ou organization: invalid domain id")
+	ErrDomainContextMissing = apierror.New(http.StatusBadRequest, "ou.domain_context_missing", "ou organization: missing domain context")
+	ErrInvalidDomainID      = apierror.New(http.StatusBadRequest, "ou.invalid_domain", "ou organization: invalid domain id")
 	ErrOrganizationNotFound = errors.New("ou organization: organization not found")
-	ErrMemberAlreadyExists  = errors.New("ou organization: member already exists")
+	ErrMemberAlreadyExists  = apierror.New(http.StatusConflict, "ou.member_exists", "ou organization: member already exists")
+	ErrInvalidOrganization  = apierror.New(http.StatusBadRequest, "ou.invalid_organization", "ou organization: invalid organization data")
+	ErrOrganizationExists   = apierror.New(http.StatusConflict, "ou.organization_exists", "ou organization: organization code already exists")
+	ErrVersionMismatch      = errors.New("ou organization: organization was modified since the expected version")
 )
 
 type Service struct {
-	client *ent.Client
+	client  *ent.Client
+	metrics metrics.Recorder
+	tracer  tracing.Tracer
 }
 
 func NewService(client *ent.Client) *Service {
-	return &Service{client: client}
+	return &Service{client: client, metrics: metrics.Nop, tracer: tracing.Nop}
+}
+
+// Ping verifies database connectivity.
+func (s *Service) Ping(ctx context.Context) error {
+	if s.client == nil {
+		return errors.New("ou organization: database client not initialized")
+	}
+	_, err := s.client.Organization.Query().Limit(1).All(ctx)
+	return err
 }
 
-func (s *Service) CreateOrganization(ctx context.Context, req *CreateOrganizationRequest) (*OrganizationResponse, error) {
+func (s *Service) CreateOrganization(ctx context.Context, req *CreateOrganizationRequest) (_ *OrganizationResponse, err error) {
+	ctx, end := s.instrument(ctx, "create_organization")
+	defer end(&err)
+
 	if req == nil {
 		return nil, errors.New("ou organization: request is nil")
 	}
@@ -40,8 +66,15 @@ func (s *Service) CreateOrganization(ctx context.Context, req *CreateOrganizatio
 
 	code := strings.TrimSpace(req.Code)
 	name := strings.TrimSpace(req.Name)
-	if code == "" || name == "" {
-		return nil, errors.New("ou organization: code and name are required")
+	var fields []apierror.FieldError
+	if code == "" {
+		fields = append(fields, apierror.Field("code", apierror.Required))
+	}
+	if name == "" {
+		fields = append(fields, apierror.Field("name", apierror.Required))
+	}
+	if len(fields) > 0 {
+		return nil, ErrInvalidOrganization.With(fields...)
 	}
 
 	create := s.client.Organization.Create().
@@ -79,7 +112,10 @@ func (s *Service) CreateOrganization(ctx context.Context, req *CreateOrganizatio
 	return toOrganizationResponse(item), nil
 }
 
-func (s *Service) GetOrganizationTree(ctx context.Context) ([]*OrganizationTreeNode, error) {
+func (s *Service) GetOrganizationTree(ctx context.Context) (_ []*OrganizationTreeNode, err error) {
+	ctx, end := s.instrument(ctx, "get_organization_tree")
+	defer end(&err)
+
 	domainID, err := domainIDFromContext(ctx)
 	if err != nil {
 		return nil, err
@@ -104,6 +140,7 @@ func (s *Service) GetOrganizationTree(ctx context.Context) ([]*OrganizationTreeN
 			Code:     item.Code,
 			Name:     item.Name,
 			Path:     item.Path,
+			Version:  etag.Version(item.UpdatedAt),
 		}
 	}
 
@@ -124,11 +161,184 @@ func (s *Service) GetOrganizationTree(ctx context.Context) ([]*OrganizationTreeN
 	return roots, nil
 }
 
+// GetOrganization returns an organization of the current domain.
+func (s *Service) GetOrganization(ctx context.Context, id uuid.UUID) (_ *OrganizationResponse, err error) {
+	ctx, end := s.instrument(ctx, "get_organization")
+	defer end(&err)
+
+	domainID, err := domainIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	item, err := s.client.Organization.Query().
+		Where(
+			organizationEnt.IDEQ(id),
+			organizationEnt.DomainIDEQ(domainID),
+		).
+		Only(ctx)
+	if err != nil {
+		if ent.IsNotFound(err) {
+			return nil, ErrOrganizationNotFound
+		}
+		return nil, err
+	}
+	return toOrganizationResponse(item), nil
+}
+
+// PatchOrganization applies a JSON merge patch to an organization of the
+// current domain, see PatchOrganizationRequest, and rewrites the paths of its
+// subtree. With req.ExpectedVersion set it fails with ErrVersionMismatch when
+// the organization was updated since.
+func (s *Service) PatchOrganization(ctx context.Context, id uuid.UUID, req *PatchOrganizationRequest) (_ *OrganizationResponse, err error) {
+	ctx, end := s.instrument(ctx, "patch_organization")
+	defer end(&err)
+
+	if req == nil {
+		return nil, errors.New("ou organization: request is nil")
+	}
+	domainID, err := domainIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields []apierror.FieldError
+	if req.Code.Set && strings.TrimSpace(req.Code.Value) == "" {
+		fields = append(fields, apierror.Field("code", apierror.Required))
+	}
+	if req.Name.Set && strings.TrimSpace(req.Name.Value) == "" {
+		fields = append(fields, apierror.Field("name", apierror.Required))
+	}
+	if len(fields) > 0 {
+		return nil, ErrInvalidOrganization.With(fields...)
+	}
+
+	tx, err := s.client.Tx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	item, err := updateOrganization(ctx, tx, domainID, id, req)
+	if err != nil {
+		_ = tx.Rollback()
+		if ent.IsConstraintError(err) {
+			return nil, ErrOrganizationExists
+		}
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return toOrganizationResponse(item), nil
+}
+
+func updateOrganization(
+	ctx context.Context,
+	tx *ent.Tx,
+	domainID, id uuid.UUID,
+	req *PatchOrganizationRequest,
+) (*ent.Organization, error) {
+	item, err := tx.Organization.Query().
+		Where(
+			organizationEnt.IDEQ(id),
+			organizationEnt.DomainIDEQ(domainID),
+		).
+		Only(ctx)
+	if err != nil {
+		if ent.IsNotFound(err) {
+			return nil, ErrOrganizationNotFound
+		}
+		return nil, err
+	}
+
+	code, name := item.Code, item.Name
+	if req.Code.Set {
+		code = strings.TrimSpace(req.Code.Value)
+	}
+	if req.Name.Set {
+		name = strings.TrimSpace(req.Name.Value)
+	}
+
+	update := tx.Organization.UpdateOne(item).
+		SetCode(code).
+		SetName(name).
+		SetUpdatedAt(etag.Now())
+	if req.ExpectedVersion != "" {
+		// The updated_at guard makes the version check atomic with the
+		// update; a concurrent update leaves no row to update.
+		if etag.Version(item.UpdatedAt) != req.ExpectedVersion {
+			return nil, ErrVersionMismatch
+		}
+		update.Where(organizationEnt.UpdatedAtEQ(item.UpdatedAt))
+	}
+
+	// Paths are the codes from the root down, joined by slashes.
+	path := strings.TrimSuffix(item.Path, item.Code) + code
+	switch {
+	case !req.ParentID.Set:
+	case req.ParentID.Null:
+		update.ClearParentID()
+		path = code
+	default:
+		parentID := req.ParentID.Value
+		parent, err := tx.Organization.Query().
+			Where(
+				organizationEnt.IDEQ(parentID),
+				organizationEnt.DomainIDEQ(domainID),
+			).
+			Only(ctx)
+		if err != nil {
+			if ent.IsNotFound(err) {
+				return nil, ErrInvalidOrganization.With(
+					apierror.Field("parentId", apierror.NotFound, "value", parentID.String()))
+			}
+			return nil, err
+		}
+		// An organization cannot move below itself.
+		if parent.ID == item.ID || strings.HasPrefix(parent.Path, item.Path+"/") {
+			return nil, ErrInvalidOrganization.With(
+				apierror.Field("parentId", apierror.Invalid, "value", parentID.String()))
+		}
+		update.SetParentID(parent.ID)
+		path = parent.Path + "/" + code
+	}
+
+	updated, err := update.SetPath(path).Save(ctx)
+	if err != nil {
+		if ent.IsNotFound(err) && req.ExpectedVersion != "" {
+			return nil, ErrVersionMismatch
+		}
+		return nil, err
+	}
+	if path == item.Path {
+		return updated, nil
+	}
+
+	descendants, err := tx.Organization.Query().
+		Where(
+			organizationEnt.DomainIDEQ(domainID),
+			organizationEnt.PathHasPrefix(item.Path+"/"),
+		).
+		All(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range descendants {
+		if err := tx.Organization.UpdateOne(d).
+			SetPath(path + strings.TrimPrefix(d.Path, item.Path)).
+			Exec(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return updated, nil
+}
+
 func (s *Service) AddOrganizationMember(
 	ctx context.Context,
 	organizationID uuid.UUID,
 	req *AddOrganizationMemberRequest,
-) (*OrganizationMemberResponse, error) {
+) (_ *OrganizationMemberResponse, err error) {
+	ctx, end := s.instrument(ctx, "add_organization_member")
+	defer end(&err)
+
 	if req == nil {
 		return nil, errors.New("ou organization: request is nil")
 	}
@@ -137,7 +347,7 @@ func (s *Service) AddOrganizationMember(
 		return nil, err
 	}
 	if req.UserID == uuid.Nil {
-		return nil, errors.New("ou organization: user id is required")
+		return nil, ErrInvalidOrganization.With(apierror.Field("userId", apierror.Required))
 	}
 
 	_, err = s.client.Organization.Query().
@@ -186,7 +396,209 @@ func (s *Service) AddOrganizationMember(
 	}, nil
 }
 
-func (s *Service) GetPrimaryOrganizationID(ctx context.Context, domainID, userID uuid.UUID) (uuid.UUID, error) {
+// CloneOrganizationTree copies the organizations of fromDomainID, without
+// their members, into toDomainID and returns the number copied. Codes and
+// paths are kept; parents are remapped to the copies.
+func (s *Service) CloneOrganizationTree(ctx context.Context, fromDomainID, toDomainID uuid.UUID) (_ int, err error) {
+	ctx, end := s.instrument(ctx, "clone_organization_tree")
+	defer end(&err)
+
+	if fromDomainID == uuid.Nil || toDomainID == uuid.Nil {
+		return 0, ErrInvalidDomainID
+	}
+
+	// Ordering by path creates parents before their children.
+	orgs, err := s.client.Organization.Query().
+		Where(organizationEnt.DomainIDEQ(fromDomainID)).
+		Order(ent.Asc(organizationEnt.FieldPath)).
+		All(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if len(orgs) == 0 {
+		return 0, nil
+	}
+
+	tx, err := s.client.Tx(ctx)
+	if err != nil {
+		return 0, err
+	}
+	copies := make(map[uuid.UUID]uuid.UUID, len(orgs))
+	for _, item := range orgs {
+		create := tx.Organization.Create().
+			SetDomainID(toDomainID).
+			SetCode(item.Code).
+			SetName(item.Name).
+			SetPath(item.Path)
+		if item.ParentID != nil {
+			if parentID, ok := copies[*item.ParentID]; ok {
+				create.SetParentID(parentID)
+			}
+		}
+		created, err := create.Save(ctx)
+		if err != nil {
+			_ = tx.Rollback()
+			return 0, err
+		}
+		copies[item.ID] = created.ID
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return len(orgs), nil
+}
+
+// ExportOrganizations returns the organizations of domainID with their
+// members, ordered by path so that parents come before their children.
+func (s *Service) ExportOrganizations(ctx context.Context, domainID uuid.UUID) (_ []OrganizationSnapshot, err error) {
+	ctx, end := s.instrument(ctx, "export_organizations")
+	defer end(&err)
+
+	orgs, err := s.client.Organization.Query().
+		Where(organizationEnt.DomainIDEQ(domainID)).
+		Order(ent.Asc(organizationEnt.FieldPath)).
+		All(ctx)
+	if err != nil {
+		return nil, err
+	}
+	members, err := s.client.OrganizationMember.Query().
+		Where(organizationMemberEnt.DomainIDEQ(domainID)).
+		Order(ent.Asc(organizationMemberEnt.FieldCreatedAt)).
+		All(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byOrg := make(map[uuid.UUID][]MemberSnapshot, len(orgs))
+	for _, m := range members {
+		byOrg[m.OrganizationID] = append(byOrg[m.OrganizationID], MemberSnapshot{UserID: m.UserID, IsPrimary: m.IsPrimary})
+	}
+
+	out := make([]OrganizationSnapshot, 0, len(orgs))
+	for _, item := range orgs {
+		out = append(out, OrganizationSnapshot{
+			Code:    item.Code,
+			Name:    item.Name,
+			Path:    item.Path,
+			Members: byOrg[item.ID],
+		})
+	}
+	return out, nil
+}
+
+// ImportOrganizations creates the organizations of orgs that domainID does
+// not have yet, matched by code, and adds their missing members. Parents are
+// found by path. A member keeps IsPrimary only if the user has no primary
+// organization in the domain yet. It returns the number of organizations
+// created.
+func (s *Service) ImportOrganizations(ctx context.Context, domainID uuid.UUID, orgs []OrganizationSnapshot) (_ int, err error) {
+	ctx, end := s.instrument(ctx, "import_organizations")
+	defer end(&err)
+
+	if domainID == uuid.Nil {
+		return 0, ErrInvalidDomainID
+	}
+	existing, err := s.client.Organization.Query().
+		Where(organizationEnt.DomainIDEQ(domainID)).
+		All(ctx)
+	if err != nil {
+		return 0, err
+	}
+	byCode := make(map[string]uuid.UUID, len(existing)+len(orgs))
+	byPath := make(map[string]uuid.UUID, len(existing)+len(orgs))
+	for _, item := range existing {
+		byCode[item.Code] = item.ID
+		byPath[item.Path] = item.ID
+	}
+
+	sorted := append([]OrganizationSnapshot(nil), orgs...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Path < sorted[j].Path })
+
+	tx, err := s.client.Tx(ctx)
+	if err != nil {
+		return 0, err
+	}
+	created := 0
+	for _, o := range sorted {
+		id, ok := byCode[o.Code]
+		if !ok {
+			create := tx.Organization.Create().
+				SetDomainID(domainID).
+				SetCode(o.Code).
+				SetName(o.Name)
+			path := o.Code
+			if i := strings.LastIndex(o.Path, "/"); i > 0 {
+				if parentID, ok := byPath[o.Path[:i]]; ok {
+					create.SetParentID(parentID)
+					path = o.Path
+				}
+			}
+			item, err := create.SetPath(path).Save(ctx)
+			if err != nil {
+				_ = tx.Rollback()
+				return 0, err
+			}
+			id = item.ID
+			byCode[o.Code] = id
+			created++
+		}
+		byPath[o.Path] = id
+
+		if err := importMembers(ctx, tx, domainID, id, o.Members); err != nil {
+			_ = tx.Rollback()
+			return 0, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return created, nil
+}
+
+func importMembers(ctx context.Context, tx *ent.Tx, domainID, orgID uuid.UUID, members []MemberSnapshot) error {
+	for _, m := range members {
+		exists, err := tx.OrganizationMember.Query().
+			Where(
+				organizationMemberEnt.DomainIDEQ(domainID),
+				organizationMemberEnt.OrganizationIDEQ(orgID),
+				organizationMemberEnt.UserIDEQ(m.UserID),
+			).
+			Exist(ctx)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+		primary := m.IsPrimary
+		if primary {
+			hasPrimary, err := tx.OrganizationMember.Query().
+				Where(
+					organizationMemberEnt.DomainIDEQ(domainID),
+					organizationMemberEnt.UserIDEQ(m.UserID),
+					organizationMemberEnt.IsPrimaryEQ(true),
+				).
+				Exist(ctx)
+			if err != nil {
+				return err
+			}
+			primary = !hasPrimary
+		}
+		if err := tx.OrganizationMember.Create().
+			SetDomainID(domainID).
+			SetOrganizationID(orgID).
+			SetUserID(m.UserID).
+			SetIsPrimary(primary).
+			Exec(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Service) GetPrimaryOrganizationID(ctx context.Context, domainID, userID uuid.UUID) (_ uuid.UUID, err error) {
+	ctx, end := s.instrument(ctx, "get_primary_organization_id")
+	defer end(&err)
+
 	primary, err := s.client.OrganizationMember.Query().
 		Where(
 			organizationMemberEnt.DomainIDEQ(domainID),
@@ -213,7 +625,10 @@ func (s *Service) GetPrimaryOrganizationID(ctx context.Context, domainID, userID
 	return anyMember.OrganizationID, nil
 }
 
-func (s *Service) ListOrganizationUserIDs(ctx context.Context, domainID, orgID uuid.UUID) ([]uuid.UUID, error) {
+func (s *Service) ListOrganizationUserIDs(ctx context.Context, domainID, orgID uuid.UUID) (_ []uuid.UUID, err error) {
+	ctx, end := s.instrument(ctx, "list_organization_user_ids")
+	defer end(&err)
+
 	members, err := s.client.OrganizationMember.Query().
 		Where(
 			organizationMemberEnt.DomainIDEQ(domainID),
@@ -226,7 +641,10 @@ func (s *Service) ListOrganizationUserIDs(ctx context.Context, domainID, orgID u
 	return uniqueUserIDs(members), nil
 }
 
-func (s *Service) ListSubtreeUserIDs(ctx context.Context, domainID, orgID uuid.UUID) ([]uuid.UUID, error) {
+func (s *Service) ListSubtreeUserIDs(ctx context.Context, domainID, orgID uuid.UUID) (_ []uuid.UUID, err error) {
+	ctx, end := s.instrument(ctx, "list_subtree_user_ids")
+	defer end(&err)
+
 	org, err := s.client.Organization.Query().
 		Where(
 			organizationEnt.IDEQ(orgID),
@@ -293,6 +711,7 @@ func toOrganizationResponse(item *ent.Organization) *OrganizationResponse {
 		Code:     item.Code,
 		Name:     item.Name,
 		Path:     item.Path,
+		Version:  etag.Version(item.UpdatedAt),
 	}
 }
 
@@ -0,0 +1,193 @@
+//go:build integration
+// +build integration
+
+package organization
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"entgo.io/ent/dialect"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/leeforge/core/core"
+	"github.com/leeforge/core/server/ent/enttest"
+
+	"github.com/leeforge/plugins/mergepatch"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestService_CloneOrganizationTree(t *testing.T) {
+	client := enttest.Open(t, dialect.SQLite, "file:ou_clone_tree?mode=memory&cache=shared&_fk=1")
+	t.Cleanup(func() { _ = client.Close() })
+
+	svc := NewService(client)
+	from, to := uuid.New(), uuid.New()
+	ctx := core.WithDomainID(context.Background(), from.String())
+
+	root, err := svc.CreateOrganization(ctx, &CreateOrganizationRequest{Code: "hq", Name: "HQ"})
+	require.NoError(t, err)
+	sales, err := svc.CreateOrganization(ctx, &CreateOrganizationRequest{Code: "sales", Name: "Sales", ParentID: &root.ID})
+	require.NoError(t, err)
+	_, err = svc.CreateOrganization(ctx, &CreateOrganizationRequest{Code: "emea", Name: "EMEA", ParentID: &sales.ID})
+	require.NoError(t, err)
+	user := client.User.Create().SetUsername("rep").SetEmail("rep@example.com").SaveX(ctx)
+	_, err = svc.AddOrganizationMember(ctx, sales.ID, &AddOrganizationMemberRequest{UserID: user.ID})
+	require.NoError(t, err)
+
+	n, err := svc.CloneOrganizationTree(context.Background(), from, to)
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+
+	tree, err := svc.GetOrganizationTree(core.WithDomainID(context.Background(), to.String()))
+	require.NoError(t, err)
+	require.Len(t, tree, 1)
+	require.NotEqual(t, root.ID, tree[0].ID)
+	require.Equal(t, "hq/sales/emea", tree[0].Children[0].Children[0].Path)
+
+	members, err := svc.ListSubtreeUserIDs(context.Background(), to, tree[0].ID)
+	require.NoError(t, err)
+	require.Empty(t, members)
+
+	n, err = svc.CloneOrganizationTree(context.Background(), uuid.New(), uuid.New())
+	require.NoError(t, err)
+	require.Zero(t, n)
+	_, err = svc.CloneOrganizationTree(context.Background(), uuid.Nil, to)
+	require.ErrorIs(t, err, ErrInvalidDomainID)
+}
+
+func TestService_ExportImportOrganizations(t *testing.T) {
+	client := enttest.Open(t, dialect.SQLite, "file:ou_export_import?mode=memory&cache=shared&_fk=1")
+	t.Cleanup(func() { _ = client.Close() })
+
+	svc := NewService(client)
+	from, to := uuid.New(), uuid.New()
+	ctx := core.WithDomainID(context.Background(), from.String())
+
+	root, err := svc.CreateOrganization(ctx, &CreateOrganizationRequest{Code: "hq", Name: "HQ"})
+	require.NoError(t, err)
+	sales, err := svc.CreateOrganization(ctx, &CreateOrganizationRequest{Code: "sales", Name: "Sales", ParentID: &root.ID})
+	require.NoError(t, err)
+	rep := client.User.Create().SetUsername("rep").SetEmail("rep@example.com").SaveX(ctx)
+	_, err = svc.AddOrganizationMember(ctx, sales.ID, &AddOrganizationMemberRequest{UserID: rep.ID, IsPrimary: true})
+	require.NoError(t, err)
+
+	snapshot, err := svc.ExportOrganizations(context.Background(), from)
+	require.NoError(t, err)
+	require.Equal(t, []OrganizationSnapshot{
+		{Code: "hq", Name: "HQ", Path: "hq"},
+		{Code: "sales", Name: "Sales", Path: "hq/sales", Members: []MemberSnapshot{{UserID: rep.ID, IsPrimary: true}}},
+	}, snapshot)
+
+	// Children listed before their parents are still attached to them.
+	reversed := []OrganizationSnapshot{snapshot[1], snapshot[0]}
+	n, err := svc.ImportOrganizations(context.Background(), to, reversed)
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+
+	imported, err := svc.ExportOrganizations(context.Background(), to)
+	require.NoError(t, err)
+	require.Equal(t, snapshot, imported)
+
+	// Importing again creates nothing and keeps members unique.
+	n, err = svc.ImportOrganizations(context.Background(), to, snapshot)
+	require.NoError(t, err)
+	require.Zero(t, n)
+	imported, err = svc.ExportOrganizations(context.Background(), to)
+	require.NoError(t, err)
+	require.Len(t, imported[1].Members, 1)
+
+	_, err = svc.ImportOrganizations(context.Background(), uuid.Nil, snapshot)
+	require.ErrorIs(t, err, ErrInvalidDomainID)
+}
+
+func TestService_GetOrganization(t *testing.T) {
+	client := enttest.Open(t, dialect.SQLite, "file:ou_get?mode=memory&cache=shared&_fk=1")
+	t.Cleanup(func() { _ = client.Close() })
+
+	svc := NewService(client)
+	ctx := core.WithDomainID(context.Background(), uuid.NewString())
+
+	hq, err := svc.CreateOrganization(ctx, &CreateOrganizationRequest{Code: "hq", Name: "HQ"})
+	require.NoError(t, err)
+	require.NotEmpty(t, hq.Version)
+
+	got, err := svc.GetOrganization(ctx, hq.ID)
+	require.NoError(t, err)
+	require.Equal(t, "hq", got.Path)
+	require.Equal(t, hq.Version, got.Version)
+	tree, err := svc.GetOrganizationTree(ctx)
+	require.NoError(t, err)
+	require.Equal(t, hq.Version, tree[0].Version)
+
+	_, err = svc.GetOrganization(ctx, uuid.New())
+	require.ErrorIs(t, err, ErrOrganizationNotFound)
+	// Organizations of other domains are not found either.
+	_, err = svc.GetOrganization(core.WithDomainID(context.Background(), uuid.NewString()), hq.ID)
+	require.ErrorIs(t, err, ErrOrganizationNotFound)
+}
+
+func TestService_PatchOrganization(t *testing.T) {
+	client := enttest.Open(t, dialect.SQLite, "file:ou_patch?mode=memory&cache=shared&_fk=1")
+	t.Cleanup(func() { _ = client.Close() })
+
+	svc := NewService(client)
+	ctx := core.WithDomainID(context.Background(), uuid.NewString())
+
+	hq, err := svc.CreateOrganization(ctx, &CreateOrganizationRequest{Code: "hq", Name: "HQ"})
+	require.NoError(t, err)
+	sales, err := svc.CreateOrganization(ctx, &CreateOrganizationRequest{Code: "sales", Name: "Sales", ParentID: &hq.ID})
+	require.NoError(t, err)
+	emea, err := svc.CreateOrganization(ctx, &CreateOrganizationRequest{Code: "emea", Name: "EMEA", ParentID: &sales.ID})
+	require.NoError(t, err)
+
+	// Absent members are kept; renaming the code renames the subtree paths.
+	var patch PatchOrganizationRequest
+	require.NoError(t, json.Unmarshal([]byte(`{"code":"sm"}`), &patch))
+	patch.ExpectedVersion = sales.Version
+	patched, err := svc.PatchOrganization(ctx, sales.ID, &patch)
+	require.NoError(t, err)
+	require.Equal(t, "Sales", patched.Name)
+	require.Equal(t, hq.ID, *patched.ParentID)
+	require.Equal(t, "hq/sm", patched.Path)
+	got, err := svc.GetOrganization(ctx, emea.ID)
+	require.NoError(t, err)
+	require.Equal(t, "hq/sm/emea", got.Path)
+
+	// A null parent makes the organization a root.
+	patch = PatchOrganizationRequest{}
+	require.NoError(t, json.Unmarshal([]byte(`{"parentId":null}`), &patch))
+	patched, err = svc.PatchOrganization(ctx, emea.ID, &patch)
+	require.NoError(t, err)
+	require.Nil(t, patched.ParentID)
+	require.Equal(t, "emea", patched.Path)
+
+	patched, err = svc.PatchOrganization(ctx, emea.ID, &PatchOrganizationRequest{
+		Name: mergepatch.Value("Europe"), ParentID: mergepatch.Value(hq.ID),
+	})
+	require.NoError(t, err)
+	require.Equal(t, "Europe", patched.Name)
+	require.Equal(t, "hq/emea", patched.Path)
+
+	_, err = svc.PatchOrganization(ctx, emea.ID, &PatchOrganizationRequest{Code: mergepatch.Null[string]()})
+	require.ErrorIs(t, err, ErrInvalidOrganization)
+	_, err = svc.PatchOrganization(ctx, hq.ID, &PatchOrganizationRequest{ParentID: mergepatch.Value(emea.ID)})
+	require.ErrorIs(t, err, ErrInvalidOrganization)
+	require.NotEqual(t, emea.Version, patched.Version)
+
+	// A stale version is rejected and changes nothing.
+	_, err = svc.PatchOrganization(ctx, emea.ID, &PatchOrganizationRequest{Name: mergepatch.Value("Stale"), ExpectedVersion: emea.Version})
+	require.ErrorIs(t, err, ErrVersionMismatch)
+	got, err = svc.GetOrganization(ctx, emea.ID)
+	require.NoError(t, err)
+	require.Equal(t, "Europe", got.Name)
+	require.Equal(t, patched.Version, got.Version)
+
+	_, err = svc.PatchOrganization(ctx, emea.ID, &PatchOrganizationRequest{Code: mergepatch.Value("hq"), ParentID: mergepatch.Null[uuid.UUID]()})
+	require.ErrorIs(t, err, ErrOrganizationExists)
+	_, err = svc.PatchOrganization(ctx, uuid.New(), &PatchOrganizationRequest{})
+	require.ErrorIs(t, err, ErrOrganizationNotFound)
+}
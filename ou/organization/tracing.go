@@ -0,0 +1,26 @@
+package organization
+
+import (
+	"context"
+	"time"
+
+	"github.com/leeforge/plugins/tracing"
+)
+
+// SetTracer wraps every service operation in spans started by t. The
+// plugin calls it with the tracer supplied by the service factory.
+func (s *Service) SetTracer(t tracing.Tracer) {
+	s.tracer = tracing.OrNop(t)
+}
+
+// instrument starts the span of a service operation. The returned function
+// is deferred with a pointer to the operation's named error result; it ends
+// the span and records the operation metrics.
+func (s *Service) instrument(ctx context.Context, op string) (context.Context, func(*error)) {
+	start := time.Now()
+	ctx, span := s.tracer.Start(ctx, "ou."+op)
+	return ctx, func(errp *error) {
+		tracing.End(span, errp)
+		s.observe(op, start, errp)
+	}
+}
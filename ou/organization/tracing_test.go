@@ -0,0 +1,23 @@
+package organization
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/leeforge/plugins/tracing"
+)
+
+func TestService_Tracer_RecordsOperationSpan(t *testing.T) {
+	exp := tracing.NewInMemoryExporter()
+	svc := NewService(nil)
+	svc.SetTracer(tracing.NewTracer(exp))
+
+	_, err := svc.GetOrganizationTree(context.Background())
+	require.ErrorIs(t, err, ErrDomainContextMissing)
+
+	span, ok := exp.Find("ou.get_organization_tree")
+	require.True(t, ok)
+	require.ErrorIs(t, span.Err, ErrDomainContextMissing)
+}
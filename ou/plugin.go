@@ -3,14 +3,21 @@ package ou
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/go-redis/redis/v8"
 
 	"github.com/leeforge/framework/logging"
 	"github.com/leeforge/framework/plugin"
 
+	"github.com/leeforge/plugins/health"
+	"github.com/leeforge/plugins/idempotency"
+	"github.com/leeforge/plugins/metrics"
+	"github.com/leeforge/plugins/openapi"
 	organizationmod "github.com/leeforge/plugins/ou/organization"
 	"github.com/leeforge/plugins/ou/shared"
+	"github.com/leeforge/plugins/tracing"
 )
 
 const (
@@ -20,8 +27,18 @@ const (
 
 // OUPlugin implements the optional organization-unit plugin.
 type OUPlugin struct {
+	// IdempotencyTTL is how long the response to a request with an
+	// Idempotency-Key header is kept for replay. Zero means
+	// idempotency.DefaultTTL.
+	IdempotencyTTL time.Duration
+
 	logger  logging.Logger
 	factory ServiceFactory
+	events  plugin.EventBus
+	redis   *redis.Client
+	metrics metrics.Recorder
+	tracer  tracing.Tracer
+	idem    idempotency.Store
 	orgSvc  *organizationmod.Service
 	orgHdlr *organizationmod.Handler
 }
@@ -38,13 +55,30 @@ func (p *OUPlugin) Enable(_ context.Context, app *plugin.AppContext) error {
 		return shared.ErrNilServiceRegistry
 	}
 	p.logger = logging.FromZap(app.Logger)
+	p.events = app.Events
+	p.redis = app.Redis
+
+	rec, err := metrics.FromServices(app.Services)
+	if err != nil {
+		return err
+	}
+	p.metrics = rec
 
 	factory, err := plugin.Resolve[ServiceFactory](app.Services, ServiceKeyOUFactory)
 	if err != nil {
 		return fmt.Errorf("resolve ou service factory: %w", err)
 	}
 	p.factory = factory
+	p.tracer = tracing.FromFactory(factory)
+	idem, err := idempotency.FromServices(app.Services)
+	if err != nil {
+		return err
+	}
+	p.idem = idem
 	p.orgSvc = p.factory.NewOrganizationService()
+	p.orgSvc.SetMetrics(p.metrics)
+	p.orgSvc.SetTracer(p.tracer)
+	metrics.RegisterCollector(p.metrics, p.orgSvc.CollectGauges)
 	p.orgHdlr = organizationmod.NewHandler(p.orgSvc, p.logger)
 
 	if err := app.Services.Register(serviceKeyOrganization, p.orgSvc); err != nil {
@@ -61,13 +95,32 @@ func (p *OUPlugin) RegisterRoutes(router chi.Router) {
 		return
 	}
 
+	router.Route("/ou/health", func(r chi.Router) {
+		r.Get("/live", health.Handler(p.Liveness))
+		r.Get("/ready", health.Handler(p.Readiness))
+	})
+	router.Get("/ou/openapi.json", openapi.Handler(p.OpenAPI()))
 	router.Route("/ou/organizations", func(r chi.Router) {
+		r.Use(metrics.HTTPMiddleware(metrics.OrNop(p.metrics), p.Name()))
+		r.Use(tracing.HTTPMiddleware(p.tracer, p.Name()))
+		r.Use(idempotency.Middleware(p.idem, p.IdempotencyTTL, p.Name()))
 		r.Post("/", p.orgHdlr.CreateOrganization)
 		r.Get("/tree", p.orgHdlr.GetOrganizationTree)
+		r.Get("/{id}", p.orgHdlr.GetOrganization)
+		r.Patch("/{id}", p.orgHdlr.PatchOrganization)
 		r.Post("/{id}/members", p.orgHdlr.AddOrganizationMember)
 	})
 }
 
+// HealthCheck runs the readiness checks and fails when a critical
+// dependency is down. Use Readiness for the per-dependency report.
+func (p *OUPlugin) HealthCheck(ctx context.Context) error {
+	if p.orgSvc == nil {
+		return shared.ErrNotInitialized
+	}
+	return p.Readiness(ctx).Err()
+}
+
 // RegisterModels declares OU-related Ent models for plugin runtime collection.
 func (p *OUPlugin) RegisterModels() []any {
 	if p.factory == nil {
@@ -75,3 +128,10 @@ func (p *OUPlugin) RegisterModels() []any {
 	}
 	return p.factory.Models()
 }
+
+var (
+	_ plugin.Plugin         = (*OUPlugin)(nil)
+	_ plugin.RouteProvider  = (*OUPlugin)(nil)
+	_ plugin.HealthReporter = (*OUPlugin)(nil)
+	_ plugin.ModelProvider  = (*OUPlugin)(nil)
+)
@@ -5,16 +5,25 @@ package ou
 
 import (
 	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"entgo.io/ent/dialect"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 
 	"github.com/leeforge/framework/plugin"
 
+	"github.com/leeforge/core/core"
 	"github.com/leeforge/core/server/ent"
 	"github.com/leeforge/core/server/ent/enttest"
+	"github.com/leeforge/plugins/health"
+	"github.com/leeforge/plugins/idempotency"
 	organizationmod "github.com/leeforge/plugins/ou/organization"
 
 	_ "github.com/mattn/go-sqlite3"
@@ -69,3 +78,63 @@ func TestOUPlugin_RegisterModels_IncludesOrganizationEntities(t *testing.T) {
 	models := p.RegisterModels()
 	require.GreaterOrEqual(t, len(models), 2)
 }
+
+func TestOUPlugin_Readiness_ReportsDatabase(t *testing.T) {
+	client := enttest.Open(t, dialect.SQLite, "file:ou_plugin_health?mode=memory&cache=shared&_fk=1")
+	t.Cleanup(func() { _ = client.Close() })
+
+	services := plugin.NewServiceRegistry()
+	services.MustRegister(ServiceKeyOUFactory, &mockOUFactory{client: client})
+
+	p := &OUPlugin{}
+	require.NoError(t, p.Enable(context.Background(), &plugin.AppContext{
+		Logger:   zap.NewNop(),
+		Services: services,
+	}))
+
+	report := p.Readiness(context.Background())
+	require.Equal(t, "ou", report.Plugin)
+	require.Equal(t, health.StatusUp, report.Status)
+	require.Len(t, report.Checks, 3)
+	require.Equal(t, "database", report.Checks[0].Name)
+	require.Equal(t, health.StatusUp, report.Checks[0].Status)
+	require.NoError(t, p.HealthCheck(context.Background()))
+}
+
+func TestOUPlugin_IdempotencyKey_CreatesOrganizationOnce(t *testing.T) {
+	client := enttest.Open(t, dialect.SQLite, "file:ou_plugin_idempotency?mode=memory&cache=shared&_fk=1")
+	t.Cleanup(func() { _ = client.Close() })
+
+	services := plugin.NewServiceRegistry()
+	services.MustRegister(ServiceKeyOUFactory, &mockOUFactory{client: client})
+	p := &OUPlugin{IdempotencyTTL: time.Hour}
+	require.NoError(t, p.Enable(context.Background(), &plugin.AppContext{Logger: zap.NewNop(), Services: services}))
+
+	domainID := uuid.NewString()
+	router := chi.NewRouter()
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(core.WithDomainID(r.Context(), domainID)))
+		})
+	})
+	p.RegisterRoutes(router)
+
+	send := func(key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/ou/organizations", strings.NewReader(body))
+		req.Header.Set(idempotency.Header, key)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := send("k1", `{"code":"sales","name":"Sales"}`)
+	require.Equal(t, http.StatusOK, first.Code)
+	retry := send("k1", `{"code":"sales","name":"Sales"}`)
+	require.Equal(t, http.StatusOK, retry.Code)
+	require.Equal(t, first.Body.String(), retry.Body.String())
+	require.Equal(t, "true", retry.Header().Get(idempotency.ReplayedHeader))
+	require.Equal(t, 1, client.Organization.Query().CountX(context.Background()))
+
+	require.Equal(t, http.StatusUnprocessableEntity, send("k1", `{"code":"ops","name":"Ops"}`).Code)
+	require.Equal(t, http.StatusOK, send("k2", `{"code":"ops","name":"Ops"}`).Code)
+}
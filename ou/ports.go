@@ -2,6 +2,7 @@ package ou
 
 import (
 	organizationmod "github.com/leeforge/plugins/ou/organization"
+	"github.com/leeforge/plugins/tracing"
 )
 
 const ServiceKeyOUFactory = "adapter.ou.factory"
@@ -11,3 +12,8 @@ type ServiceFactory interface {
 	NewOrganizationService() *organizationmod.Service
 	Models() []any
 }
+
+// TracerProvider is optionally implemented by a ServiceFactory to supply the
+// tracer used for organization service spans. Tracing is disabled when the
+// factory does not implement it.
+type TracerProvider = tracing.TracerProvider
@@ -5,4 +5,5 @@ import "errors"
 var (
 	ErrNilAppContext      = errors.New("ou plugin: app context is nil")
 	ErrNilServiceRegistry = errors.New("ou plugin: service registry is nil")
+	ErrNotInitialized     = errors.New("ou plugin: organization service not initialized")
 )
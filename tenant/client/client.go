@@ -0,0 +1,656 @@
+// Package client is a typed Go client for the tenant plugin's HTTP API. It
+// reuses the request and response types of the tenant service and maps
+// error responses back to the shared sentinel errors, so that
+//
+//	errors.Is(err, shared.ErrTenantNotFound)
+//
+// works the same against the API as against the service.
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/leeforge/plugins/apiclient"
+	"github.com/leeforge/plugins/apierror"
+	"github.com/leeforge/plugins/etag"
+	"github.com/leeforge/plugins/tenant/shared"
+	tenantmod "github.com/leeforge/plugins/tenant/tenant"
+)
+
+// Client calls the /tenants routes. Create it with New.
+type Client struct {
+	api *apiclient.Client
+}
+
+// New returns a client for the API at baseURL, the prefix the host mounts
+// plugin routes under, for example "https://api.example.com/api/v1".
+func New(baseURL string, opts ...apiclient.Option) (*Client, error) {
+	api, err := apiclient.New(baseURL, mapError, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{api: api}, nil
+}
+
+// With returns a copy of the client with opts applied, for example
+// apiclient.WithTenant to act in another tenant.
+func (c *Client) With(opts ...apiclient.Option) *Client {
+	return &Client{api: c.api.With(opts...)}
+}
+
+// codes are the typed sentinels, which the tenant handlers answer with their
+// code in the error details.
+var codes = []*apierror.Error{
+	shared.ErrTenantCodeExists,
+	shared.ErrInvalidTenant,
+	shared.ErrMemberExists,
+	shared.ErrParentTenantInvalid,
+	shared.ErrInvalidMemberTerm,
+	shared.ErrMemberSuspended,
+	shared.ErrMemberNotSuspended,
+	shared.ErrInvalidMemberFilter,
+	shared.ErrInvalidRoleTemplate,
+	shared.ErrInvalidRole,
+	shared.ErrTenantTemplateExists,
+	shared.ErrInvalidTenantTemplate,
+	shared.ErrInvalidArchive,
+	shared.ErrImpersonationInactive,
+	shared.ErrInvalidImpersonation,
+	shared.ErrServiceAccountExists,
+	shared.ErrInvalidServiceAccount,
+	shared.ErrServiceAccountDisabled,
+	shared.ErrAPIKeyInactive,
+	shared.ErrInvalidLabels,
+	shared.ErrInvalidLabelSelector,
+	shared.ErrInvalidTenantFilter,
+	shared.ErrInvalidWebhook,
+}
+
+// sentinels are the other errors the tenant handlers answer with their
+// message.
+var sentinels = []error{
+	shared.ErrTenantNotFound,
+	shared.ErrMemberNotFound,
+	shared.ErrPlatformDomainOnly,
+	shared.ErrTenantTemplateNotFound,
+	shared.ErrProvisioningFailed,
+	shared.ErrOrganizationsDisabled,
+	shared.ErrImpersonationNotFound,
+	shared.ErrServiceAccountNotFound,
+	shared.ErrAPIKeyNotFound,
+	shared.ErrWebhookNotFound,
+	shared.ErrWebhookDeliveryNotFound,
+	shared.ErrTenantAdminRequired,
+}
+
+// aliases are the handler messages that differ from their sentinel's.
+var aliases = map[string]error{
+	"platform domain required": shared.ErrPlatformDomainOnly,
+	"tenant admin required":    shared.ErrTenantAdminRequired,
+}
+
+func mapError(e *apiclient.Error) error {
+	if e.StatusCode == http.StatusPreconditionFailed {
+		return shared.ErrVersionMismatch
+	}
+	if err := apiclient.MatchCode(e.Details, codes...); err != nil {
+		return err
+	}
+	return apiclient.MatchSentinel(e.Message, aliases, sentinels...)
+}
+
+// --- Tenants ---
+
+// CreateTenant creates a tenant.
+func (c *Client) CreateTenant(ctx context.Context, req *tenantmod.CreateRequest) (*tenantmod.TenantDTO, error) {
+	var out tenantmod.TenantDTO
+	if err := c.api.Do(ctx, http.MethodPost, "tenants", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListTenants returns one page of tenants.
+func (c *Client) ListTenants(ctx context.Context, filters tenantmod.ListFilters) (*tenantmod.ListResult, error) {
+	var out tenantmod.ListResult
+	if err := c.api.Do(ctx, http.MethodGet, "tenants", listQuery(filters), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Tenants iterates over every tenant matching filters, fetching pages of
+// filters.PageSize as needed. filters.Page is ignored.
+func (c *Client) Tenants(ctx context.Context, filters tenantmod.ListFilters) iter.Seq2[*tenantmod.TenantDTO, error] {
+	return apiclient.Paginate(func(page int) ([]*tenantmod.TenantDTO, int, error) {
+		filters.Page = page
+		res, err := c.ListTenants(ctx, filters)
+		if err != nil {
+			return nil, 0, err
+		}
+		return res.Tenants, res.TotalPages, nil
+	})
+}
+
+// SearchTenants returns one page of tenants matching a filter expression.
+func (c *Client) SearchTenants(ctx context.Context, req *tenantmod.SearchRequest) (*tenantmod.ListResult, error) {
+	var out tenantmod.ListResult
+	if err := c.api.Do(ctx, http.MethodPost, "tenants/search", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SearchAll iterates over every tenant matching a filter expression.
+// req.Page is ignored.
+func (c *Client) SearchAll(ctx context.Context, req tenantmod.SearchRequest) iter.Seq2[*tenantmod.TenantDTO, error] {
+	return apiclient.Paginate(func(page int) ([]*tenantmod.TenantDTO, int, error) {
+		req.Page = page
+		res, err := c.SearchTenants(ctx, &req)
+		if err != nil {
+			return nil, 0, err
+		}
+		return res.Tenants, res.TotalPages, nil
+	})
+}
+
+// GetTenant returns a tenant.
+func (c *Client) GetTenant(ctx context.Context, id uuid.UUID) (*tenantmod.TenantDTO, error) {
+	var out tenantmod.TenantDTO
+	if err := c.api.Do(ctx, http.MethodGet, "tenants/"+id.String(), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateTenant replaces a tenant. req.ExpectedVersion is sent as If-Match;
+// when the tenant has another version the error matches
+// shared.ErrVersionMismatch and CurrentTenant returns the current tenant.
+func (c *Client) UpdateTenant(ctx context.Context, id uuid.UUID, req *tenantmod.UpdateRequest) (*tenantmod.TenantDTO, error) {
+	var header http.Header
+	if req != nil && req.ExpectedVersion != "" {
+		header = http.Header{etag.IfMatchHeader: {strconv.Quote(req.ExpectedVersion)}}
+	}
+	var out tenantmod.TenantDTO
+	if err := c.api.DoHeader(ctx, http.MethodPut, "tenants/"+id.String(), nil, header, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// PatchTenant applies a JSON merge patch to a tenant: only the fields set in
+// req are sent, mergepatch.Null clearing them. req.ExpectedVersion works as
+// in UpdateTenant.
+func (c *Client) PatchTenant(ctx context.Context, id uuid.UUID, req *tenantmod.PatchRequest) (*tenantmod.TenantDTO, error) {
+	var header http.Header
+	if req != nil && req.ExpectedVersion != "" {
+		header = http.Header{etag.IfMatchHeader: {strconv.Quote(req.ExpectedVersion)}}
+	}
+	var out tenantmod.TenantDTO
+	if err := c.api.DoHeader(ctx, http.MethodPatch, "tenants/"+id.String(), nil, header, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CurrentTenant returns the current tenant sent with a version mismatch
+// error of UpdateTenant or PatchTenant.
+func CurrentTenant(err error) (*tenantmod.TenantDTO, bool) {
+	var apiErr *apiclient.Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusPreconditionFailed || len(apiErr.Data) == 0 {
+		return nil, false
+	}
+	var dto tenantmod.TenantDTO
+	if json.Unmarshal(apiErr.Data, &dto) != nil {
+		return nil, false
+	}
+	return &dto, true
+}
+
+// DeleteTenant soft-deletes a tenant.
+func (c *Client) DeleteTenant(ctx context.Context, id uuid.UUID) error {
+	return c.api.Do(ctx, http.MethodDelete, "tenants/"+id.String(), nil, nil, nil)
+}
+
+// ListMyTenants returns the tenants of the authenticated user.
+func (c *Client) ListMyTenants(ctx context.Context) (*tenantmod.MyTenantListResult, error) {
+	var out tenantmod.MyTenantListResult
+	if err := c.api.Do(ctx, http.MethodGet, "tenants/me", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetMyTenant returns the authenticated user's membership in a tenant.
+func (c *Client) GetMyTenant(ctx context.Context, id uuid.UUID) (*tenantmod.MyTenantDTO, error) {
+	var out tenantmod.MyTenantDTO
+	if err := c.api.Do(ctx, http.MethodGet, "tenants/me/"+id.String(), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// --- Members ---
+
+// AddMember adds a user to a tenant.
+func (c *Client) AddMember(ctx context.Context, tenantID uuid.UUID, req *tenantmod.AddMemberRequest) error {
+	return c.api.Do(ctx, http.MethodPost, tenantPath(tenantID, "members"), nil, req, nil)
+}
+
+// ListMembers returns one page of the members of a tenant.
+func (c *Client) ListMembers(ctx context.Context, tenantID uuid.UUID, filters tenantmod.MemberListFilters) (*tenantmod.MemberListResult, error) {
+	q := url.Values{}
+	setInt(q, "page", filters.Page)
+	setInt(q, "pageSize", filters.PageSize)
+	setString(q, "query", filters.Query)
+	setString(q, "role", strings.Join(filters.Roles, ","))
+	setString(q, "status", filters.Status)
+	setTime(q, "joinedAfter", filters.JoinedAfter)
+	setTime(q, "joinedBefore", filters.JoinedBefore)
+	setString(q, "sort", filters.Sort)
+	setString(q, "order", filters.Order)
+
+	var out tenantmod.MemberListResult
+	if err := c.api.Do(ctx, http.MethodGet, tenantPath(tenantID, "members"), q, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Members iterates over every member of a tenant matching filters.
+// filters.Page is ignored.
+func (c *Client) Members(ctx context.Context, tenantID uuid.UUID, filters tenantmod.MemberListFilters) iter.Seq2[*tenantmod.MemberDTO, error] {
+	return apiclient.Paginate(func(page int) ([]*tenantmod.MemberDTO, int, error) {
+		filters.Page = page
+		res, err := c.ListMembers(ctx, tenantID, filters)
+		if err != nil {
+			return nil, 0, err
+		}
+		return res.Members, res.TotalPages, nil
+	})
+}
+
+// RemoveMember removes a user from a tenant.
+func (c *Client) RemoveMember(ctx context.Context, tenantID, userID uuid.UUID) error {
+	return c.api.Do(ctx, http.MethodDelete, tenantPath(tenantID, "members", userID.String()), nil, nil, nil)
+}
+
+// SuspendMember suspends a membership; reason is optional.
+func (c *Client) SuspendMember(ctx context.Context, tenantID, userID uuid.UUID, reason string) error {
+	req := &tenantmod.SuspendMemberRequest{Reason: reason}
+	return c.api.Do(ctx, http.MethodPost, tenantPath(tenantID, "members", userID.String(), "suspend"), nil, req, nil)
+}
+
+// ReactivateMember reactivates a suspended membership.
+func (c *Client) ReactivateMember(ctx context.Context, tenantID, userID uuid.UUID) error {
+	return c.api.Do(ctx, http.MethodPost, tenantPath(tenantID, "members", userID.String(), "reactivate"), nil, nil, nil)
+}
+
+// --- Roles ---
+
+// ListRoles returns the roles defined in a tenant's domain.
+func (c *Client) ListRoles(ctx context.Context, tenantID uuid.UUID) (*tenantmod.RoleListResult, error) {
+	var out tenantmod.RoleListResult
+	if err := c.api.Do(ctx, http.MethodGet, tenantPath(tenantID, "roles"), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SyncRoleTemplates pushes role template changes into a tenant.
+func (c *Client) SyncRoleTemplates(ctx context.Context, tenantID uuid.UUID) (*tenantmod.RoleSyncReport, error) {
+	var out tenantmod.RoleSyncReport
+	if err := c.api.Do(ctx, http.MethodPost, tenantPath(tenantID, "roles", "sync"), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SyncAllRoleTemplates pushes role template changes into every tenant.
+func (c *Client) SyncAllRoleTemplates(ctx context.Context) (*tenantmod.RoleSyncListResult, error) {
+	var out tenantmod.RoleSyncListResult
+	if err := c.api.Do(ctx, http.MethodPost, "tenants/roles/sync", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// --- Cloning, export and import ---
+
+// CloneTenant creates a tenant from an existing one. When provisioning fails
+// after the tenant was created, the error details hold the CloneResult.
+func (c *Client) CloneTenant(ctx context.Context, sourceID uuid.UUID, req *tenantmod.CloneRequest) (*tenantmod.CloneResult, error) {
+	var out tenantmod.CloneResult
+	if err := c.api.Do(ctx, http.MethodPost, tenantPath(sourceID, "clone"), nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListTenantTemplates returns the named tenant templates.
+func (c *Client) ListTenantTemplates(ctx context.Context) (*tenantmod.TenantTemplateListResult, error) {
+	var out tenantmod.TenantTemplateListResult
+	if err := c.api.Do(ctx, http.MethodGet, "tenants/templates", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CloneTenantTemplate creates a tenant from a named template.
+func (c *Client) CloneTenantTemplate(ctx context.Context, name string, req *tenantmod.CloneRequest) (*tenantmod.CloneResult, error) {
+	var out tenantmod.CloneResult
+	path := "tenants/templates/" + url.PathEscape(name) + "/clone"
+	if err := c.api.Do(ctx, http.MethodPost, path, nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ExportTenant downloads a tenant archive.
+func (c *Client) ExportTenant(ctx context.Context, tenantID uuid.UUID) (*tenantmod.TenantArchive, error) {
+	q := url.Values{"format": {tenantmod.ArchiveFormatJSON}}
+	resp, err := c.api.Send(ctx, http.MethodGet, tenantPath(tenantID, "export"), q, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return tenantmod.ReadArchive(resp.Body, tenantmod.ArchiveFormatJSON)
+}
+
+// ImportTenant imports a tenant archive, or reports the changes it would
+// make when opts.DryRun is set.
+func (c *Client) ImportTenant(ctx context.Context, archive *tenantmod.TenantArchive, opts tenantmod.ImportOptions) (*tenantmod.ImportReport, error) {
+	q := url.Values{}
+	setString(q, "code", opts.Code)
+	setString(q, "name", opts.Name)
+	setString(q, "onConflict", opts.OnConflict)
+	if opts.DryRun {
+		q.Set("dryRun", "true")
+	}
+	var out tenantmod.ImportReport
+	if err := c.api.Do(ctx, http.MethodPost, "tenants/import", q, archive, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// --- Impersonation ---
+
+// Impersonate starts an impersonation session in a tenant.
+func (c *Client) Impersonate(ctx context.Context, tenantID uuid.UUID, req *tenantmod.ImpersonateRequest) (*tenantmod.ImpersonationGrant, error) {
+	var out tenantmod.ImpersonationGrant
+	if err := c.api.Do(ctx, http.MethodPost, tenantPath(tenantID, "impersonate"), nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListImpersonations returns impersonation sessions.
+func (c *Client) ListImpersonations(ctx context.Context, filters tenantmod.ImpersonationFilters) (*tenantmod.ImpersonationListResult, error) {
+	q := url.Values{}
+	setUUID(q, "tenantId", filters.TenantID)
+	setUUID(q, "actorId", filters.ActorID)
+	setString(q, "status", filters.Status)
+
+	var out tenantmod.ImpersonationListResult
+	if err := c.api.Do(ctx, http.MethodGet, "tenants/impersonations", q, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RevokeImpersonation revokes an active impersonation session.
+func (c *Client) RevokeImpersonation(ctx context.Context, sessionID uuid.UUID) (*tenantmod.ImpersonationDTO, error) {
+	var out tenantmod.ImpersonationDTO
+	path := "tenants/impersonations/" + sessionID.String() + "/revoke"
+	if err := c.api.Do(ctx, http.MethodPost, path, nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// --- Service accounts and API keys ---
+
+// CreateServiceAccount creates a service account in a tenant.
+func (c *Client) CreateServiceAccount(ctx context.Context, tenantID uuid.UUID, req *tenantmod.CreateServiceAccountRequest) (*tenantmod.ServiceAccountDTO, error) {
+	var out tenantmod.ServiceAccountDTO
+	if err := c.api.Do(ctx, http.MethodPost, tenantPath(tenantID, "service-accounts"), nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListServiceAccounts returns the service accounts of a tenant.
+func (c *Client) ListServiceAccounts(ctx context.Context, tenantID uuid.UUID) (*tenantmod.ServiceAccountListResult, error) {
+	var out tenantmod.ServiceAccountListResult
+	if err := c.api.Do(ctx, http.MethodGet, tenantPath(tenantID, "service-accounts"), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetServiceAccount returns a service account.
+func (c *Client) GetServiceAccount(ctx context.Context, tenantID, accountID uuid.UUID) (*tenantmod.ServiceAccountDTO, error) {
+	var out tenantmod.ServiceAccountDTO
+	if err := c.api.Do(ctx, http.MethodGet, tenantPath(tenantID, "service-accounts", accountID.String()), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateServiceAccount changes a service account.
+func (c *Client) UpdateServiceAccount(ctx context.Context, tenantID, accountID uuid.UUID, req *tenantmod.UpdateServiceAccountRequest) (*tenantmod.ServiceAccountDTO, error) {
+	var out tenantmod.ServiceAccountDTO
+	if err := c.api.Do(ctx, http.MethodPut, tenantPath(tenantID, "service-accounts", accountID.String()), nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteServiceAccount deletes a service account and its keys.
+func (c *Client) DeleteServiceAccount(ctx context.Context, tenantID, accountID uuid.UUID) error {
+	return c.api.Do(ctx, http.MethodDelete, tenantPath(tenantID, "service-accounts", accountID.String()), nil, nil, nil)
+}
+
+// CreateAPIKey issues an API key. The token is only returned by this call.
+func (c *Client) CreateAPIKey(ctx context.Context, tenantID, accountID uuid.UUID, req *tenantmod.CreateAPIKeyRequest) (*tenantmod.APIKeyGrant, error) {
+	var out tenantmod.APIKeyGrant
+	if err := c.api.Do(ctx, http.MethodPost, tenantPath(tenantID, "service-accounts", accountID.String(), "keys"), nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListAPIKeys returns the keys of a service account.
+func (c *Client) ListAPIKeys(ctx context.Context, tenantID, accountID uuid.UUID) (*tenantmod.APIKeyListResult, error) {
+	var out tenantmod.APIKeyListResult
+	if err := c.api.Do(ctx, http.MethodGet, tenantPath(tenantID, "service-accounts", accountID.String(), "keys"), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RevokeAPIKey revokes an API key.
+func (c *Client) RevokeAPIKey(ctx context.Context, tenantID, accountID, keyID uuid.UUID) (*tenantmod.APIKeyDTO, error) {
+	var out tenantmod.APIKeyDTO
+	path := tenantPath(tenantID, "service-accounts", accountID.String(), "keys", keyID.String())
+	if err := c.api.Do(ctx, http.MethodDelete, path, nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RotateAPIKey replaces an API key; req may be nil.
+func (c *Client) RotateAPIKey(ctx context.Context, tenantID, accountID, keyID uuid.UUID, req *tenantmod.RotateAPIKeyRequest) (*tenantmod.APIKeyGrant, error) {
+	if req == nil {
+		req = &tenantmod.RotateAPIKeyRequest{}
+	}
+	var out tenantmod.APIKeyGrant
+	path := tenantPath(tenantID, "service-accounts", accountID.String(), "keys", keyID.String(), "rotate")
+	if err := c.api.Do(ctx, http.MethodPost, path, nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// --- Webhooks ---
+//
+// A nil tenantID addresses the platform endpoints.
+
+// CreateWebhook registers a webhook endpoint. The secret is only returned by
+// this call.
+func (c *Client) CreateWebhook(ctx context.Context, tenantID *uuid.UUID, req *tenantmod.CreateWebhookRequest) (*tenantmod.WebhookGrant, error) {
+	var out tenantmod.WebhookGrant
+	if err := c.api.Do(ctx, http.MethodPost, webhookPath(tenantID), nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListWebhooks returns the webhook endpoints of a scope.
+func (c *Client) ListWebhooks(ctx context.Context, tenantID *uuid.UUID) (*tenantmod.WebhookListResult, error) {
+	var out tenantmod.WebhookListResult
+	if err := c.api.Do(ctx, http.MethodGet, webhookPath(tenantID), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetWebhook returns a webhook endpoint.
+func (c *Client) GetWebhook(ctx context.Context, tenantID *uuid.UUID, webhookID uuid.UUID) (*tenantmod.WebhookDTO, error) {
+	var out tenantmod.WebhookDTO
+	if err := c.api.Do(ctx, http.MethodGet, webhookPath(tenantID, webhookID.String()), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateWebhook changes a webhook endpoint.
+func (c *Client) UpdateWebhook(ctx context.Context, tenantID *uuid.UUID, webhookID uuid.UUID, req *tenantmod.UpdateWebhookRequest) (*tenantmod.WebhookDTO, error) {
+	var out tenantmod.WebhookDTO
+	if err := c.api.Do(ctx, http.MethodPut, webhookPath(tenantID, webhookID.String()), nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteWebhook deletes a webhook endpoint and its delivery log.
+func (c *Client) DeleteWebhook(ctx context.Context, tenantID *uuid.UUID, webhookID uuid.UUID) error {
+	return c.api.Do(ctx, http.MethodDelete, webhookPath(tenantID, webhookID.String()), nil, nil, nil)
+}
+
+// ListWebhookDeliveries returns one page of the delivery log of an endpoint.
+func (c *Client) ListWebhookDeliveries(ctx context.Context, tenantID *uuid.UUID, webhookID uuid.UUID, filters tenantmod.WebhookDeliveryFilters) (*tenantmod.WebhookDeliveryListResult, error) {
+	q := url.Values{}
+	setInt(q, "page", filters.Page)
+	setInt(q, "pageSize", filters.PageSize)
+	setString(q, "status", filters.Status)
+	setString(q, "event", filters.Event)
+
+	var out tenantmod.WebhookDeliveryListResult
+	if err := c.api.Do(ctx, http.MethodGet, webhookPath(tenantID, webhookID.String(), "deliveries"), q, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// WebhookDeliveries iterates over the delivery log of an endpoint.
+// filters.Page is ignored.
+func (c *Client) WebhookDeliveries(ctx context.Context, tenantID *uuid.UUID, webhookID uuid.UUID, filters tenantmod.WebhookDeliveryFilters) iter.Seq2[*tenantmod.WebhookDeliveryDTO, error] {
+	return apiclient.Paginate(func(page int) ([]*tenantmod.WebhookDeliveryDTO, int, error) {
+		filters.Page = page
+		res, err := c.ListWebhookDeliveries(ctx, tenantID, webhookID, filters)
+		if err != nil {
+			return nil, 0, err
+		}
+		return res.Deliveries, res.TotalPages, nil
+	})
+}
+
+// RedeliverWebhook queues a delivery to be sent again.
+func (c *Client) RedeliverWebhook(ctx context.Context, tenantID *uuid.UUID, webhookID, deliveryID uuid.UUID) (*tenantmod.WebhookDeliveryDTO, error) {
+	var out tenantmod.WebhookDeliveryDTO
+	path := webhookPath(tenantID, webhookID.String(), "deliveries", deliveryID.String(), "redeliver")
+	if err := c.api.Do(ctx, http.MethodPost, path, nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// --- Helpers ---
+
+func tenantPath(tenantID uuid.UUID, elems ...string) string {
+	return "tenants/" + tenantID.String() + "/" + strings.Join(elems, "/")
+}
+
+func webhookPath(tenantID *uuid.UUID, elems ...string) string {
+	if tenantID == nil {
+		return strings.Join(append([]string{"tenants/webhooks"}, elems...), "/")
+	}
+	return tenantPath(*tenantID, append([]string{"webhooks"}, elems...)...)
+}
+
+func listQuery(f tenantmod.ListFilters) url.Values {
+	q := url.Values{}
+	setInt(q, "page", f.Page)
+	setInt(q, "pageSize", f.PageSize)
+	setString(q, "query", f.Query)
+	statuses := f.Statuses
+	if f.Status != "" {
+		statuses = append([]string{f.Status}, statuses...)
+	}
+	setString(q, "status", strings.Join(statuses, ","))
+	if f.IncludeDeleted {
+		q.Set("includeDeleted", "true")
+	}
+	setString(q, "labelSelector", f.LabelSelector)
+	for name, id := range map[string]*uuid.UUID{
+		"ownerId":          f.OwnerID,
+		"parentTenantId":   f.ParentTenantID,
+		"ancestorTenantId": f.AncestorTenantID,
+		"memberId":         f.MemberID,
+	} {
+		if id != nil {
+			q.Set(name, id.String())
+		}
+	}
+	setTime(q, "createdAfter", f.CreatedAfter)
+	setTime(q, "createdBefore", f.CreatedBefore)
+	setTime(q, "updatedAfter", f.UpdatedAfter)
+	setTime(q, "updatedBefore", f.UpdatedBefore)
+	return q
+}
+
+func setString(q url.Values, name, v string) {
+	if v != "" {
+		q.Set(name, v)
+	}
+}
+
+func setInt(q url.Values, name string, v int) {
+	if v != 0 {
+		q.Set(name, strconv.Itoa(v))
+	}
+}
+
+func setUUID(q url.Values, name string, id uuid.UUID) {
+	if id != uuid.Nil {
+		q.Set(name, id.String())
+	}
+}
+
+func setTime(q url.Values, name string, t *time.Time) {
+	if t != nil {
+		q.Set(name, t.Format(time.RFC3339Nano))
+	}
+}
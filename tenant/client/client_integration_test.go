@@ -0,0 +1,450 @@
+//go:build integration
+// +build integration
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"entgo.io/ent/dialect"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/leeforge/framework/logging"
+	"github.com/leeforge/framework/plugin"
+
+	"github.com/leeforge/core"
+	coremod "github.com/leeforge/core/core"
+	coreent "github.com/leeforge/core/server/ent"
+	"github.com/leeforge/core/server/ent/enttest"
+
+	"github.com/leeforge/plugins/apiclient"
+	"github.com/leeforge/plugins/mergepatch"
+	"github.com/leeforge/plugins/tenant/shared"
+	tenantmod "github.com/leeforge/plugins/tenant/tenant"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// memDomains is an in-memory core.DomainWriter.
+type memDomains struct {
+	core.DomainWriter
+
+	mu      sync.Mutex
+	domains map[string]*core.ResolvedDomain
+	members map[[2]uuid.UUID]bool
+}
+
+func (m *memDomains) ResolveDomain(_ context.Context, typeCode, key string) (*core.ResolvedDomain, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if d, ok := m.domains[typeCode+":"+key]; ok {
+		return d, nil
+	}
+	return nil, shared.ErrTenantNotFound
+}
+
+func (m *memDomains) EnsureDomain(_ context.Context, typeCode, key, displayName string) (*core.ResolvedDomain, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if d, ok := m.domains[typeCode+":"+key]; ok {
+		return d, nil
+	}
+	d := &core.ResolvedDomain{DomainID: uuid.New(), TypeCode: typeCode, Key: key, DisplayName: displayName}
+	m.domains[typeCode+":"+key] = d
+	return d, nil
+}
+
+func (m *memDomains) CheckMembership(_ context.Context, domainID, subjectID uuid.UUID) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.members[[2]uuid.UUID{domainID, subjectID}], nil
+}
+
+func (m *memDomains) AddMembership(_ context.Context, domainID, subjectID uuid.UUID, _ string, _ bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.members[[2]uuid.UUID{domainID, subjectID}] = true
+	return nil
+}
+
+func (m *memDomains) RemoveMembership(_ context.Context, domainID, subjectID uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.members, [2]uuid.UUID{domainID, subjectID})
+	return nil
+}
+
+type nopBus struct{}
+
+func (nopBus) Publish(context.Context, plugin.Event) error               { return nil }
+func (nopBus) Subscribe(string, plugin.EventHandler) plugin.Subscription { return nil }
+func (nopBus) Close() error                                              { return nil }
+
+type nopSeeder struct{}
+
+func (nopSeeder) SeedBaselineRoles(context.Context, uuid.UUID) error { return nil }
+
+type entUsers struct{ client *coreent.Client }
+
+func (l entUsers) GetUser(ctx context.Context, userID uuid.UUID) (*shared.UserInfo, error) {
+	u, err := l.client.User.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &shared.UserInfo{ID: u.ID, Username: u.Username, Email: u.Email}, nil
+}
+
+// testServer serves the tenant handlers under /api/v1. Its auth middleware
+// stands in for the host's: the bearer token is the caller's user ID, and the
+// X-Tenant-ID header, a tenant code, selects the tenant domain instead of the
+// platform domain.
+type testServer struct {
+	*httptest.Server
+	client *coreent.Client
+	svc    *tenantmod.Service
+	ctx    context.Context
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	client := enttest.Open(t, dialect.SQLite, "file:"+t.Name()+"?mode=memory&cache=shared&_fk=1")
+	t.Cleanup(func() { _ = client.Close() })
+
+	domains := &memDomains{domains: map[string]*core.ResolvedDomain{}, members: map[[2]uuid.UUID]bool{}}
+	svc := tenantmod.NewService(client, domains, nopBus{}, logging.FromZap(zap.NewNop()), nopSeeder{}, entUsers{client: client})
+	h := tenantmod.NewHandler(svc, logging.FromZap(zap.NewNop()))
+	platform := &coremod.ResolvedDomain{TypeCode: string(coremod.DomainPlatform), Key: "root"}
+
+	r := chi.NewRouter()
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			userID, err := uuid.Parse(strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer "))
+			if err != nil {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			domain := platform
+			if code := req.Header.Get(apiclient.DefaultTenantHeader); code != "" {
+				d, err := domains.ResolveDomain(req.Context(), "tenant", code)
+				if err != nil {
+					w.WriteHeader(http.StatusForbidden)
+					return
+				}
+				domain = &coremod.ResolvedDomain{DomainID: d.DomainID, TypeCode: d.TypeCode, Key: d.Key}
+			}
+			ctx := core.WithIdentity(req.Context(), core.Identity{UserID: userID})
+			ctx = coremod.WithActingContext(ctx, &coremod.ActingContext{Domain: domain})
+			next.ServeHTTP(w, req.WithContext(ctx))
+		})
+	})
+	r.Route("/api/v1/tenants", func(r chi.Router) {
+		r.Get("/me", h.ListMyTenants)
+		r.Get("/me/{id}", h.GetMyTenant)
+		r.Get("/", h.ListTenants)
+		r.Post("/", h.CreateTenant)
+		r.Post("/search", h.SearchTenants)
+		r.Post("/webhooks", h.CreateWebhook)
+		r.Get("/webhooks", h.ListWebhooks)
+		r.Get("/webhooks/{webhookId}", h.GetWebhook)
+		r.Delete("/webhooks/{webhookId}", h.DeleteWebhook)
+		r.Get("/{id}", h.GetTenant)
+		r.Put("/{id}", h.UpdateTenant)
+		r.Patch("/{id}", h.PatchTenant)
+		r.Delete("/{id}", h.DeleteTenant)
+		r.Post("/{id}/members", h.AddMember)
+		r.Get("/{id}/members", h.ListMembers)
+		r.Post("/{id}/members/{userId}/suspend", h.SuspendMember)
+		r.Post("/{id}/members/{userId}/reactivate", h.ReactivateMember)
+		r.Get("/{id}/export", h.ExportTenant)
+		r.Post("/{id}/service-accounts", h.CreateServiceAccount)
+		r.Post("/{id}/service-accounts/{accountId}/keys", h.CreateAPIKey)
+		r.Post("/{id}/service-accounts/{accountId}/keys/{keyId}/rotate", h.RotateAPIKey)
+		r.Delete("/{id}/service-accounts/{accountId}/keys/{keyId}", h.RevokeAPIKey)
+	})
+
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	return &testServer{
+		Server: srv,
+		client: client,
+		svc:    svc,
+		ctx:    coremod.WithActingContext(context.Background(), &coremod.ActingContext{Domain: platform}),
+	}
+}
+
+func (s *testServer) createUser(t *testing.T, username string) uuid.UUID {
+	t.Helper()
+	u, err := s.client.User.Create().SetUsername(username).SetEmail(username + "@example.com").Save(s.ctx)
+	require.NoError(t, err)
+	return u.ID
+}
+
+func (s *testServer) newClient(t *testing.T, userID uuid.UUID, opts ...apiclient.Option) *Client {
+	t.Helper()
+	opts = append([]apiclient.Option{apiclient.WithAuth(apiclient.BearerToken(userID.String()))}, opts...)
+	c, err := New(s.URL+"/api/v1", opts...)
+	require.NoError(t, err)
+	return c
+}
+
+func TestClient_TenantsAndErrors(t *testing.T) {
+	srv := newTestServer(t)
+	admin := srv.createUser(t, "admin")
+	c := srv.newClient(t, admin)
+	ctx := context.Background()
+
+	acme, err := c.CreateTenant(ctx, &tenantmod.CreateRequest{Code: "acme", Name: "Acme"})
+	require.NoError(t, err)
+	require.Equal(t, "acme", acme.Code)
+
+	_, err = c.CreateTenant(ctx, &tenantmod.CreateRequest{Code: "acme", Name: "Again"})
+	require.ErrorIs(t, err, shared.ErrTenantCodeExists)
+	require.True(t, apiclient.IsStatus(err, http.StatusConflict))
+
+	_, err = c.GetTenant(ctx, uuid.New())
+	require.ErrorIs(t, err, shared.ErrTenantNotFound)
+
+	name := "Acme Corp"
+	updated, err := c.UpdateTenant(ctx, acme.ID, &tenantmod.UpdateRequest{Name: name})
+	require.NoError(t, err)
+	require.Equal(t, name, updated.Name)
+
+	got, err := c.GetTenant(ctx, acme.ID)
+	require.NoError(t, err)
+	require.Equal(t, name, got.Name)
+	require.Equal(t, updated.Version, got.Version)
+
+	// An update based on a stale version is answered with the current tenant.
+	_, err = c.UpdateTenant(ctx, acme.ID, &tenantmod.UpdateRequest{Name: "Lost", ExpectedVersion: acme.Version})
+	require.ErrorIs(t, err, shared.ErrVersionMismatch)
+	require.True(t, apiclient.IsStatus(err, http.StatusPreconditionFailed))
+	current, ok := CurrentTenant(err)
+	require.True(t, ok)
+	require.Equal(t, name, current.Name)
+	updated, err = c.UpdateTenant(ctx, acme.ID, &tenantmod.UpdateRequest{Name: name, Description: "Widgets", ExpectedVersion: current.Version})
+	require.NoError(t, err)
+	require.NotEqual(t, current.Version, updated.Version)
+	require.Equal(t, "Widgets", updated.Description)
+
+	// PUT replaces the tenant: the name is required and the description is
+	// cleared when left out.
+	_, err = c.UpdateTenant(ctx, acme.ID, &tenantmod.UpdateRequest{Description: "Nameless"})
+	require.ErrorIs(t, err, shared.ErrInvalidTenant)
+	require.True(t, apiclient.IsStatus(err, http.StatusBadRequest))
+	updated, err = c.UpdateTenant(ctx, acme.ID, &tenantmod.UpdateRequest{Name: name})
+	require.NoError(t, err)
+	require.Empty(t, updated.Description)
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL+"/api/v1/tenants/"+acme.ID.String(), nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+admin.String())
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	require.Equal(t, `"`+updated.Version+`"`, resp.Header.Get("ETag"))
+
+	// Requests without credentials are rejected before the handlers.
+	anon, err := New(srv.URL + "/api/v1")
+	require.NoError(t, err)
+	_, err = anon.GetTenant(ctx, acme.ID)
+	require.True(t, apiclient.IsStatus(err, http.StatusUnauthorized))
+
+	// The tenant header moves the caller out of the platform domain.
+	inAcme := c.With(apiclient.WithTenant("acme"))
+	_, err = inAcme.CreateTenant(ctx, &tenantmod.CreateRequest{Code: "other", Name: "Other"})
+	require.ErrorIs(t, err, shared.ErrPlatformDomainOnly)
+	_, err = c.CreateTenant(ctx, &tenantmod.CreateRequest{Code: "other", Name: "Other"})
+	require.NoError(t, err)
+
+	require.NoError(t, c.DeleteTenant(ctx, acme.ID))
+	list, err := c.ListTenants(ctx, tenantmod.ListFilters{})
+	require.NoError(t, err)
+	require.Len(t, list.Tenants, 1)
+	require.Equal(t, "other", list.Tenants[0].Code)
+}
+
+func TestClient_PatchTenant(t *testing.T) {
+	srv := newTestServer(t)
+	admin := srv.createUser(t, "admin")
+	c := srv.newClient(t, admin)
+	ctx := context.Background()
+
+	_, err := c.CreateTenant(ctx, &tenantmod.CreateRequest{Code: "holding", Name: "Holding"})
+	require.NoError(t, err)
+	acme, err := c.CreateTenant(ctx, &tenantmod.CreateRequest{
+		Code: "acme", Name: "Acme", Description: "Widgets", ParentTenantID: "holding"})
+	require.NoError(t, err)
+
+	// Null members clear what an update leaves unchanged.
+	patched, err := c.PatchTenant(ctx, acme.ID, &tenantmod.PatchRequest{
+		Description:     mergepatch.Null[string](),
+		ParentTenantID:  mergepatch.Null[string](),
+		ExpectedVersion: acme.Version,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "Acme", patched.Name)
+	require.Empty(t, patched.Description)
+	require.Nil(t, patched.ParentTenantID)
+
+	_, err = c.PatchTenant(ctx, acme.ID, &tenantmod.PatchRequest{Name: mergepatch.Value("Lost"), ExpectedVersion: acme.Version})
+	require.ErrorIs(t, err, shared.ErrVersionMismatch)
+	current, ok := CurrentTenant(err)
+	require.True(t, ok)
+	require.Equal(t, patched.Version, current.Version)
+
+	_, err = c.PatchTenant(ctx, acme.ID, &tenantmod.PatchRequest{Name: mergepatch.Null[string]()})
+	require.ErrorIs(t, err, shared.ErrInvalidTenant)
+
+	patch := func(contentType, body string) *http.Response {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodPatch, srv.URL+"/api/v1/tenants/"+acme.ID.String(), strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer "+admin.String())
+		req.Header.Set("Content-Type", contentType)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		return resp
+	}
+	resp := patch(mergepatch.MediaType, `{"description":"Gadgets"}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.NotEmpty(t, resp.Header.Get("ETag"))
+	got, err := c.GetTenant(ctx, acme.ID)
+	require.NoError(t, err)
+	require.Equal(t, "Gadgets", got.Description)
+
+	resp = patch("application/json-patch+json", `[{"op":"remove","path":"/description"}]`)
+	require.Equal(t, http.StatusUnsupportedMediaType, resp.StatusCode)
+}
+
+func TestClient_PaginationIterators(t *testing.T) {
+	srv := newTestServer(t)
+	admin := srv.createUser(t, "admin")
+	c := srv.newClient(t, admin)
+	ctx := context.Background()
+
+	for i := range 5 {
+		_, err := c.CreateTenant(ctx, &tenantmod.CreateRequest{Code: fmt.Sprintf("t%d", i), Name: fmt.Sprintf("T%d", i)})
+		require.NoError(t, err)
+	}
+
+	var codes []string
+	for tenant, err := range c.Tenants(ctx, tenantmod.ListFilters{PageSize: 2}) {
+		require.NoError(t, err)
+		codes = append(codes, tenant.Code)
+	}
+	require.ElementsMatch(t, []string{"t0", "t1", "t2", "t3", "t4"}, codes)
+
+	var searched int
+	for _, err := range c.SearchAll(ctx, tenantmod.SearchRequest{Filter: tenantmod.TenantFilter{Query: "t"}, PageSize: 2}) {
+		require.NoError(t, err)
+		searched++
+	}
+	require.Equal(t, 5, searched)
+
+	// Breaking out of the loop stops fetching.
+	n := 0
+	for range c.Tenants(ctx, tenantmod.ListFilters{PageSize: 2}) {
+		n++
+		break
+	}
+	require.Equal(t, 1, n)
+
+	// Errors end the iteration.
+	var errs []error
+	for _, err := range c.Tenants(ctx, tenantmod.ListFilters{Statuses: []string{"bogus"}}) {
+		errs = append(errs, err)
+	}
+	require.Len(t, errs, 1)
+	require.ErrorIs(t, errs[0], shared.ErrInvalidTenantFilter)
+}
+
+func TestClient_Members(t *testing.T) {
+	srv := newTestServer(t)
+	admin := srv.createUser(t, "admin")
+	c := srv.newClient(t, admin)
+	ctx := context.Background()
+
+	acme, err := c.CreateTenant(ctx, &tenantmod.CreateRequest{Code: "acme", Name: "Acme"})
+	require.NoError(t, err)
+
+	var users []uuid.UUID
+	for i := range 3 {
+		id := srv.createUser(t, fmt.Sprintf("user%d", i))
+		users = append(users, id)
+		require.NoError(t, c.AddMember(ctx, acme.ID, &tenantmod.AddMemberRequest{UserID: id.String()}))
+	}
+
+	var seen []uuid.UUID
+	for m, err := range c.Members(ctx, acme.ID, tenantmod.MemberListFilters{PageSize: 1}) {
+		require.NoError(t, err)
+		seen = append(seen, m.ID)
+	}
+	// The creator joined as owner.
+	require.ElementsMatch(t, append([]uuid.UUID{admin}, users...), seen)
+
+	require.NoError(t, c.SuspendMember(ctx, acme.ID, users[1], "incident"))
+	err = c.SuspendMember(ctx, acme.ID, users[1], "")
+	require.ErrorIs(t, err, shared.ErrMemberSuspended)
+	err = c.AddMember(ctx, acme.ID, &tenantmod.AddMemberRequest{UserID: users[1].String()})
+	require.ErrorIs(t, err, shared.ErrMemberSuspended)
+	require.NoError(t, c.ReactivateMember(ctx, acme.ID, users[1]))
+	err = c.ReactivateMember(ctx, acme.ID, users[1])
+	require.ErrorIs(t, err, shared.ErrMemberNotSuspended)
+
+	// The member sees the tenant among their own.
+	mine, err := srv.newClient(t, users[2]).ListMyTenants(ctx)
+	require.NoError(t, err)
+	require.Len(t, mine.Tenants, 1)
+	require.Equal(t, acme.ID, mine.Tenants[0].ID)
+
+	archive, err := c.ExportTenant(ctx, acme.ID)
+	require.NoError(t, err)
+	require.Equal(t, "acme", archive.Tenant.Code)
+}
+
+func TestClient_ServiceAccountsAndWebhooks(t *testing.T) {
+	srv := newTestServer(t)
+	admin := srv.createUser(t, "admin")
+	c := srv.newClient(t, admin)
+	ctx := context.Background()
+
+	acme, err := c.CreateTenant(ctx, &tenantmod.CreateRequest{Code: "acme", Name: "Acme"})
+	require.NoError(t, err)
+
+	account, err := c.CreateServiceAccount(ctx, acme.ID, &tenantmod.CreateServiceAccountRequest{Name: "ci"})
+	require.NoError(t, err)
+	grant, err := c.CreateAPIKey(ctx, acme.ID, account.ID, &tenantmod.CreateAPIKeyRequest{Name: "deploy"})
+	require.NoError(t, err)
+	require.NotEmpty(t, grant.Token)
+	rotated, err := c.RotateAPIKey(ctx, acme.ID, account.ID, grant.Key.ID, nil)
+	require.NoError(t, err)
+	require.NotEqual(t, grant.Token, rotated.Token)
+	_, err = c.RevokeAPIKey(ctx, acme.ID, account.ID, uuid.New())
+	require.ErrorIs(t, err, shared.ErrAPIKeyNotFound)
+
+	hook, err := c.CreateWebhook(ctx, nil, &tenantmod.CreateWebhookRequest{
+		URL:    "https://hooks.example.com/tenants",
+		Events: []string{shared.EventTenantCreated},
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, hook.Secret)
+	_, err = c.CreateWebhook(ctx, nil, &tenantmod.CreateWebhookRequest{URL: "ftp://example.com"})
+	require.ErrorIs(t, err, shared.ErrInvalidWebhook)
+
+	hooks, err := c.ListWebhooks(ctx, nil)
+	require.NoError(t, err)
+	require.Len(t, hooks.Webhooks, 1)
+	require.NoError(t, c.DeleteWebhook(ctx, nil, hook.Webhook.ID))
+	_, err = c.GetWebhook(ctx, nil, hook.Webhook.ID)
+	require.ErrorIs(t, err, shared.ErrWebhookNotFound)
+}
@@ -0,0 +1,242 @@
+package factory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"entgo.io/ent/dialect"
+	"entgo.io/ent/dialect/sql"
+	"github.com/google/uuid"
+
+	coreent "github.com/leeforge/core/server/ent"
+	"github.com/leeforge/core/server/ent/predicate"
+	"github.com/leeforge/core/server/ent/systemconfig"
+	entTenant "github.com/leeforge/core/server/ent/tenant"
+
+	"github.com/leeforge/plugins/tenant/shared"
+)
+
+// attributeKeyPrefix namespaces tenant labels and metadata in the system
+// config table.
+const attributeKeyPrefix = "tenant.attributes:"
+
+// labelKeyPrefix namespaces the label index: one entry per tenant and label,
+// keyed by label key and tenant ID and holding the label value. Label keys
+// cannot contain ':', so the prefix of one key never matches another.
+const labelKeyPrefix = "tenant.label:"
+
+// entTenantAttributeStore persists the labels and metadata of each tenant as
+// a JSON system config entry keyed by tenant ID, and indexes labels by key.
+type entTenantAttributeStore struct {
+	client *coreent.Client
+}
+
+func attributeKey(tenantID uuid.UUID) string {
+	return attributeKeyPrefix + tenantID.String()
+}
+
+func labelPrefix(key string) string {
+	return labelKeyPrefix + key + ":"
+}
+
+func labelIndexKey(key string, tenantID uuid.UUID) string {
+	return labelPrefix(key) + tenantID.String()
+}
+
+func (s *entTenantAttributeStore) GetAttributes(ctx context.Context, tenantID uuid.UUID) (*shared.TenantAttributes, error) {
+	if tx := coreent.TxFromContext(ctx); tx != nil {
+		return getAttributes(ctx, tx.Client(), tenantID)
+	}
+	return getAttributes(ctx, s.client, tenantID)
+}
+
+func getAttributes(ctx context.Context, client *coreent.Client, tenantID uuid.UUID) (*shared.TenantAttributes, error) {
+	row, err := client.SystemConfig.Query().
+		Where(
+			systemconfig.Key(attributeKey(tenantID)),
+			systemconfig.DeletedAtIsNil(),
+		).
+		Only(ctx)
+	if err != nil {
+		if coreent.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return decodeAttributes(row)
+}
+
+// PutAttributes replaces the attributes entry and the label index entries of
+// the tenant in one transaction: the one carried by ctx, if any, or its own.
+func (s *entTenantAttributeStore) PutAttributes(ctx context.Context, attrs shared.TenantAttributes) error {
+	value, err := json.Marshal(attrs)
+	if err != nil {
+		return fmt.Errorf("encode tenant attributes: %w", err)
+	}
+	if tx := coreent.TxFromContext(ctx); tx != nil {
+		return putAttributes(ctx, tx.Client(), attrs, string(value))
+	}
+	tx, err := s.client.Tx(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	if err := putAttributes(ctx, tx.Client(), attrs, string(value)); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func putAttributes(ctx context.Context, client *coreent.Client, attrs shared.TenantAttributes, value string) error {
+	current, err := getAttributes(ctx, client, attrs.TenantID)
+	if err != nil {
+		return fmt.Errorf("get tenant attributes: %w", err)
+	}
+	if current != nil {
+		var stale []string
+		for k := range current.Labels {
+			if _, ok := attrs.Labels[k]; !ok {
+				stale = append(stale, labelIndexKey(k, attrs.TenantID))
+			}
+		}
+		if len(stale) > 0 {
+			if _, err := client.SystemConfig.Delete().Where(systemconfig.KeyIn(stale...)).Exec(ctx); err != nil {
+				return fmt.Errorf("delete tenant label index: %w", err)
+			}
+		}
+	}
+	for k, v := range attrs.Labels {
+		if current != nil {
+			if old, ok := current.Labels[k]; ok && old == v {
+				continue
+			}
+		}
+		if err := putConfig(ctx, client, labelIndexKey(k, attrs.TenantID), v, "tenant label index"); err != nil {
+			return fmt.Errorf("index tenant label: %w", err)
+		}
+	}
+	if err := putConfig(ctx, client, attributeKey(attrs.TenantID), value, "tenant labels and metadata"); err != nil {
+		return fmt.Errorf("update tenant attributes: %w", err)
+	}
+	return nil
+}
+
+// putConfig creates or replaces the system config entry key.
+func putConfig(ctx context.Context, client *coreent.Client, key, value, description string) error {
+	n, err := client.SystemConfig.Update().
+		Where(systemconfig.Key(key)).
+		SetValue(value).
+		ClearDeletedAt().
+		Save(ctx)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return nil
+	}
+	return client.SystemConfig.Create().
+		SetKey(key).
+		SetValue(value).
+		SetDescription(description).
+		Exec(ctx)
+}
+
+func (s *entTenantAttributeStore) ListAttributesOf(ctx context.Context, tenantIDs []uuid.UUID) ([]shared.TenantAttributes, error) {
+	if len(tenantIDs) == 0 {
+		return nil, nil
+	}
+	keys := make([]string, len(tenantIDs))
+	for i, id := range tenantIDs {
+		keys[i] = attributeKey(id)
+	}
+	rows, err := s.client.SystemConfig.Query().
+		Where(
+			systemconfig.KeyIn(keys...),
+			systemconfig.DeletedAtIsNil(),
+		).
+		All(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]shared.TenantAttributes, 0, len(rows))
+	for _, row := range rows {
+		attrs, err := decodeAttributes(row)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *attrs)
+	}
+	return out, nil
+}
+
+func (s *entTenantAttributeStore) LabelValues(ctx context.Context, key string) (map[uuid.UUID]string, error) {
+	prefix := labelPrefix(key)
+	rows, err := s.client.SystemConfig.Query().
+		Where(
+			systemconfig.KeyHasPrefix(prefix),
+			systemconfig.DeletedAtIsNil(),
+		).
+		All(ctx)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[uuid.UUID]string, len(rows))
+	for _, row := range rows {
+		tenantID, err := uuid.Parse(strings.TrimPrefix(row.Key, prefix))
+		if err != nil {
+			return nil, fmt.Errorf("decode tenant label index %s: %w", row.Key, err)
+		}
+		values[tenantID] = row.Value
+	}
+	return values, nil
+}
+
+// LabelPredicate matches tenants through an EXISTS subquery on the label
+// index, joined on the exact index key of each tenant.
+func (s *entTenantAttributeStore) LabelPredicate(r shared.LabelRequirement) predicate.Tenant {
+	return predicate.Tenant(func(sel *sql.Selector) {
+		t := sql.Table(systemconfig.Table)
+		conds := []*sql.Predicate{
+			sql.P(func(b *sql.Builder) {
+				b.Ident(t.C(systemconfig.FieldKey)).WriteOp(sql.OpEQ)
+				if b.Dialect() == dialect.MySQL {
+					b.WriteString("CONCAT(").Arg(labelPrefix(r.Key)).Comma().Ident(sel.C(entTenant.FieldID)).WriteString(")")
+					return
+				}
+				b.Arg(labelPrefix(r.Key)).WriteString(" || CAST(").Ident(sel.C(entTenant.FieldID)).WriteString(" AS TEXT)")
+			}),
+			sql.IsNull(t.C(systemconfig.FieldDeletedAt)),
+		}
+		if len(r.Values) > 0 {
+			values := make([]any, len(r.Values))
+			for i, v := range r.Values {
+				values[i] = v
+			}
+			conds = append(conds, sql.In(t.C(systemconfig.FieldValue), values...))
+		}
+		index := sql.Select(t.C(systemconfig.FieldKey)).From(t).Where(sql.And(conds...))
+		switch r.Operator {
+		case shared.SelectorExists, shared.SelectorEquals, shared.SelectorIn:
+			sel.Where(sql.Exists(index))
+		case shared.SelectorDoesNotExist, shared.SelectorNotEquals, shared.SelectorNotIn:
+			sel.Where(sql.NotExists(index))
+		default:
+			sel.Where(sql.False())
+		}
+	})
+}
+
+func decodeAttributes(row *coreent.SystemConfig) (*shared.TenantAttributes, error) {
+	var attrs shared.TenantAttributes
+	if err := json.Unmarshal([]byte(row.Value), &attrs); err != nil {
+		return nil, fmt.Errorf("decode tenant attributes %s: %w", row.Key, err)
+	}
+	return &attrs, nil
+}
+
+var (
+	_ shared.TenantAttributeStore = (*entTenantAttributeStore)(nil)
+	_ shared.TenantLabelIndex     = (*entTenantAttributeStore)(nil)
+)
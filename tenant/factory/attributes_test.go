@@ -0,0 +1,121 @@
+//go:build integration
+// +build integration
+
+package factory
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"entgo.io/ent/dialect"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	coreent "github.com/leeforge/core/server/ent"
+	"github.com/leeforge/core/server/ent/enttest"
+	entTenant "github.com/leeforge/core/server/ent/tenant"
+
+	"github.com/leeforge/plugins/tenant/shared"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestEntTenantAttributeStore_RoundTrip(t *testing.T) {
+	client := enttest.Open(t, dialect.SQLite, "file:tenant_attributes?mode=memory&cache=shared&_fk=1")
+	t.Cleanup(func() { _ = client.Close() })
+
+	ctx := context.Background()
+	store := NewEntFactory(client).TenantAttributes()
+	tenantID := uuid.New()
+
+	got, err := store.GetAttributes(ctx, tenantID)
+	require.NoError(t, err)
+	require.Nil(t, got)
+
+	attrs := shared.TenantAttributes{
+		TenantID:  tenantID,
+		Labels:    map[string]string{"plan": "pro"},
+		Metadata:  json.RawMessage(`{"crm":42}`),
+		UpdatedAt: time.Now().UTC().Truncate(time.Second),
+	}
+	require.NoError(t, store.PutAttributes(ctx, attrs))
+	got, err = store.GetAttributes(ctx, tenantID)
+	require.NoError(t, err)
+	require.Equal(t, attrs, *got)
+
+	other := uuid.New()
+	require.NoError(t, store.PutAttributes(ctx, shared.TenantAttributes{TenantID: other, Labels: map[string]string{"plan": "free", "region": "eu"}}))
+	values, err := store.LabelValues(ctx, "plan")
+	require.NoError(t, err)
+	require.Equal(t, map[uuid.UUID]string{tenantID: "pro", other: "free"}, values)
+
+	attrs.Labels = nil
+	require.NoError(t, store.PutAttributes(ctx, attrs))
+
+	found, err := store.ListAttributesOf(ctx, []uuid.UUID{tenantID, other, uuid.New()})
+	require.NoError(t, err)
+	require.Len(t, found, 2)
+	got, err = store.GetAttributes(ctx, tenantID)
+	require.NoError(t, err)
+	require.Nil(t, got.Labels)
+
+	values, err = store.LabelValues(ctx, "plan")
+	require.NoError(t, err)
+	require.Equal(t, map[uuid.UUID]string{other: "free"}, values, "removed labels leave the index")
+	values, err = store.LabelValues(ctx, "plan.tier")
+	require.NoError(t, err)
+	require.Empty(t, values)
+
+	// Reads and writes join the transaction carried by the context.
+	tx, err := client.Tx(ctx)
+	require.NoError(t, err)
+	txCtx := coreent.NewTxContext(ctx, tx)
+	require.NoError(t, store.PutAttributes(txCtx, shared.TenantAttributes{TenantID: other, Labels: map[string]string{"plan": "pro"}}))
+	got, err = store.GetAttributes(txCtx, other)
+	require.NoError(t, err)
+	require.Equal(t, "pro", got.Labels["plan"])
+	require.NoError(t, tx.Rollback())
+	got, err = store.GetAttributes(ctx, other)
+	require.NoError(t, err)
+	require.Equal(t, "free", got.Labels["plan"])
+}
+
+func TestEntTenantAttributeStore_LabelPredicate(t *testing.T) {
+	client := enttest.Open(t, dialect.SQLite, "file:tenant_label_predicate?mode=memory&cache=shared&_fk=1")
+	t.Cleanup(func() { _ = client.Close() })
+
+	ctx := context.Background()
+	store := NewEntFactory(client).TenantAttributes()
+	index := store.(shared.TenantLabelIndex)
+	labels := map[string]map[string]string{
+		"acme":  {"plan": "pro", "region": "eu"},
+		"beta":  {"plan": "free", "region": "us"},
+		"gamma": nil,
+	}
+	for code, l := range labels {
+		tn, err := client.Tenant.Create().SetCode(code).SetName(code).Save(ctx)
+		require.NoError(t, err)
+		require.NoError(t, store.PutAttributes(ctx, shared.TenantAttributes{TenantID: tn.ID, Labels: l}))
+	}
+
+	codes := func(selector string) []string {
+		t.Helper()
+		sel, err := shared.ParseLabelSelector(selector)
+		require.NoError(t, err)
+		q := client.Tenant.Query()
+		for _, r := range sel {
+			q.Where(index.LabelPredicate(r))
+		}
+		out, err := q.Order(coreent.Asc(entTenant.FieldCode)).Select(entTenant.FieldCode).Strings(ctx)
+		require.NoError(t, err)
+		return out
+	}
+	require.Equal(t, []string{"acme"}, codes("plan=pro,region in (eu,us)"))
+	require.Equal(t, []string{"acme", "beta"}, codes("region"))
+	require.Equal(t, []string{"beta", "gamma"}, codes("plan!=pro"))
+	require.Equal(t, []string{"beta", "gamma"}, codes("region notin (eu)"))
+	require.Equal(t, []string{"gamma"}, codes("!plan"))
+	require.Empty(t, codes("plan in (enterprise)"))
+}
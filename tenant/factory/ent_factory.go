@@ -2,6 +2,9 @@ package factory
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
 
 	"github.com/google/uuid"
 
@@ -11,20 +14,42 @@ import (
 	"github.com/leeforge/core"
 	coreent "github.com/leeforge/core/server/ent"
 	"github.com/leeforge/core/server/ent/role"
+	"github.com/leeforge/core/server/ent/systemconfig"
 	"github.com/leeforge/core/server/ent/user"
 
 	tenantplugin "github.com/leeforge/plugins/tenant"
 	"github.com/leeforge/plugins/tenant/shared"
 	tenantmod "github.com/leeforge/plugins/tenant/tenant"
+	"github.com/leeforge/plugins/tracing"
 )
 
 // EntFactory adapts ent-backed dependencies to tenant plugin services.
 type EntFactory struct {
-	client *coreent.Client
+	client    *coreent.Client
+	tracer    tracing.Tracer
+	templates shared.RoleTemplateSource
+}
+
+// Option configures an EntFactory.
+type Option func(*EntFactory)
+
+// WithTracer makes the plugin trace service operations and port calls with t.
+func WithTracer(t tracing.Tracer) Option {
+	return func(f *EntFactory) { f.tracer = t }
 }
 
-func NewEntFactory(client *coreent.Client) *EntFactory {
-	return &EntFactory{client: client}
+// WithRoleTemplateSource provisions tenants from the role templates supplied
+// by src instead of the roleTemplates config.
+func WithRoleTemplateSource(src shared.RoleTemplateSource) Option {
+	return func(f *EntFactory) { f.templates = src }
+}
+
+func NewEntFactory(client *coreent.Client, opts ...Option) *EntFactory {
+	f := &EntFactory{client: client}
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f
 }
 
 func (f *EntFactory) NewTenantService(
@@ -32,22 +57,88 @@ func (f *EntFactory) NewTenantService(
 	events plugin.EventBus,
 	logger logging.Logger,
 ) *tenantmod.Service {
-	return tenantmod.NewService(f.client, domainSvc, events, logger, f.RoleSeeder(), f.UserLookup())
+	svc := tenantmod.NewService(f.client, domainSvc, events, logger, f.RoleSeeder(), f.UserLookup())
+	svc.SetImpersonationStore(f.Impersonations())
+	svc.SetImpersonationAuditLog(f.ImpersonationAudits())
+	svc.SetServiceAccountStore(f.ServiceAccounts())
+	svc.SetAttributeStore(f.TenantAttributes())
+	svc.SetWebhookStore(f.Webhooks())
+	return svc
 }
 
 func (f *EntFactory) RoleSeeder() shared.RoleSeeder {
 	return &entRoleSeeder{client: f.client}
 }
 
+// RoleCatalog implements tenant.RoleCatalogProvider.
+func (f *EntFactory) RoleCatalog() shared.RoleCatalog {
+	return &entRoleCatalog{client: f.client}
+}
+
 func (f *EntFactory) UserLookup() shared.UserLookup {
 	return &entUserLookup{client: f.client}
 }
 
+// MemberTerms implements tenant.MemberTermProvider.
+func (f *EntFactory) MemberTerms() shared.MemberTermStore {
+	return &entMemberTermStore{client: f.client}
+}
+
+// Impersonations returns the store of impersonation sessions.
+func (f *EntFactory) Impersonations() shared.ImpersonationStore {
+	return &entImpersonationStore{client: f.client}
+}
+
+// ImpersonationAudits returns the log of impersonated requests, kept in the
+// core audit log.
+func (f *EntFactory) ImpersonationAudits() shared.ImpersonationAuditLog {
+	return &entImpersonationAuditLog{client: f.client}
+}
+
+// ServiceAccounts returns the store of service accounts and API keys.
+func (f *EntFactory) ServiceAccounts() shared.ServiceAccountStore {
+	return &entServiceAccountStore{client: f.client}
+}
+
+// TenantAttributes returns the store of tenant labels and metadata.
+func (f *EntFactory) TenantAttributes() shared.TenantAttributeStore {
+	return &entTenantAttributeStore{client: f.client}
+}
+
+// Webhooks returns the store of webhook endpoints and deliveries.
+func (f *EntFactory) Webhooks() shared.WebhookStore {
+	return &entWebhookStore{client: f.client}
+}
+
+// Permissions implements tenant.PermissionProvider.
+func (f *EntFactory) Permissions() shared.PermissionResolver {
+	return &entPermissionResolver{client: f.client}
+}
+
+// Tracer implements tenant.TracerProvider.
+func (f *EntFactory) Tracer() tracing.Tracer {
+	return tracing.OrNop(f.tracer)
+}
+
+// RoleTemplateSource implements tenant.RoleTemplateProvider.
+func (f *EntFactory) RoleTemplateSource() shared.RoleTemplateSource {
+	return f.templates
+}
+
 func (f *EntFactory) Models() []any {
 	return []any{"tenant", "tenant_user"}
 }
 
-var _ tenantplugin.ServiceFactory = (*EntFactory)(nil)
+var (
+	_ tenantplugin.ServiceFactory       = (*EntFactory)(nil)
+	_ tenantplugin.TracerProvider       = (*EntFactory)(nil)
+	_ shared.RoleTemplateSeeder         = (*entRoleSeeder)(nil)
+	_ shared.UserFinder                 = (*entUserLookup)(nil)
+	_ tenantplugin.RoleCatalogProvider  = (*EntFactory)(nil)
+	_ tenantplugin.MemberTermProvider   = (*EntFactory)(nil)
+	_ tenantplugin.PermissionProvider   = (*EntFactory)(nil)
+	_ tenantplugin.RoleTemplateProvider = (*EntFactory)(nil)
+)
 
 // --- RoleSeeder ---
 
@@ -55,6 +146,14 @@ type entRoleSeeder struct {
 	client *coreent.Client
 }
 
+// roleTemplateKeyPrefix namespaces the last provisioned template of each
+// domain role in the system config table.
+const roleTemplateKeyPrefix = "tenant.role_template:"
+
+func roleTemplateKey(domainID uuid.UUID, code string) string {
+	return roleTemplateKeyPrefix + domainID.String() + ":" + code
+}
+
 // SeedBaselineRoles creates the baseline owner and member roles for a new domain.
 // It is idempotent: existing roles are skipped if they already exist.
 func (s *entRoleSeeder) SeedBaselineRoles(ctx context.Context, domainID uuid.UUID) error {
@@ -92,6 +191,212 @@ func (s *entRoleSeeder) SeedBaselineRoles(ctx context.Context, domainID uuid.UUI
 	return nil
 }
 
+// SeedRoles implements shared.RoleTemplateSeeder, provisioning templates into domainID. The template each role was
+// last provisioned from is kept as a snapshot; a role that no longer matches
+// its snapshot was changed by the tenant and is not overwritten. System roles
+// seeded before snapshots existed are treated as unchanged.
+func (s *entRoleSeeder) SeedRoles(ctx context.Context, domainID uuid.UUID, templates []shared.RoleTemplate) (shared.RoleSyncResult, error) {
+	result := shared.RoleSyncResult{
+		Created:    []string{},
+		Updated:    []string{},
+		Unchanged:  []string{},
+		Customized: []string{},
+	}
+	for _, tpl := range templates {
+		existing, err := s.client.Role.Query().
+			Where(
+				role.OwnerDomainID(domainID),
+				role.Code(tpl.Code),
+			).
+			Only(ctx)
+		if err != nil && !coreent.IsNotFound(err) {
+			return result, err
+		}
+
+		if existing == nil {
+			if err := s.client.Role.Create().
+				SetOwnerDomainID(domainID).
+				SetName(tpl.Name).
+				SetCode(tpl.Code).
+				SetDescription(tpl.Description).
+				SetIsSystem(true).
+				SetPermissions(permissionsOf(tpl.Permissions)).
+				Exec(ctx); err != nil {
+				return result, err
+			}
+			if err := s.putSnapshot(ctx, domainID, tpl); err != nil {
+				return result, err
+			}
+			result.Created = append(result.Created, tpl.Code)
+			continue
+		}
+
+		snapshot, err := s.getSnapshot(ctx, domainID, tpl.Code)
+		if err != nil {
+			return result, err
+		}
+		switch {
+		case snapshot == nil && !existing.IsSystem,
+			snapshot != nil && !roleMatches(existing, *snapshot):
+			result.Customized = append(result.Customized, tpl.Code)
+			continue
+		case roleMatches(existing, tpl):
+			if snapshot == nil {
+				if err := s.putSnapshot(ctx, domainID, tpl); err != nil {
+					return result, err
+				}
+			}
+			result.Unchanged = append(result.Unchanged, tpl.Code)
+			continue
+		}
+
+		if err := s.client.Role.UpdateOneID(existing.ID).
+			SetName(tpl.Name).
+			SetDescription(tpl.Description).
+			SetPermissions(permissionsOf(tpl.Permissions)).
+			Exec(ctx); err != nil {
+			return result, err
+		}
+		if err := s.putSnapshot(ctx, domainID, tpl); err != nil {
+			return result, err
+		}
+		result.Updated = append(result.Updated, tpl.Code)
+	}
+	return result, nil
+}
+
+func (s *entRoleSeeder) getSnapshot(ctx context.Context, domainID uuid.UUID, code string) (*shared.RoleTemplate, error) {
+	row, err := s.client.SystemConfig.Query().
+		Where(
+			systemconfig.Key(roleTemplateKey(domainID, code)),
+			systemconfig.DeletedAtIsNil(),
+		).
+		Only(ctx)
+	if err != nil {
+		if coreent.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var tpl shared.RoleTemplate
+	if err := json.Unmarshal([]byte(row.Value), &tpl); err != nil {
+		return nil, fmt.Errorf("decode role template %s: %w", row.Key, err)
+	}
+	return &tpl, nil
+}
+
+func (s *entRoleSeeder) putSnapshot(ctx context.Context, domainID uuid.UUID, tpl shared.RoleTemplate) error {
+	value, err := json.Marshal(tpl)
+	if err != nil {
+		return fmt.Errorf("encode role template: %w", err)
+	}
+	key := roleTemplateKey(domainID, tpl.Code)
+
+	n, err := s.client.SystemConfig.Update().
+		Where(systemconfig.Key(key)).
+		SetValue(string(value)).
+		ClearDeletedAt().
+		Save(ctx)
+	if err != nil {
+		return fmt.Errorf("update role template: %w", err)
+	}
+	if n > 0 {
+		return nil
+	}
+	return s.client.SystemConfig.Create().
+		SetKey(key).
+		SetValue(string(value)).
+		SetDescription("tenant role template").
+		Exec(ctx)
+}
+
+// roleMatches reports whether r has the name, description and permissions
+// of tpl.
+func roleMatches(r *coreent.Role, tpl shared.RoleTemplate) bool {
+	if r.Name != tpl.Name || r.Description != tpl.Description {
+		return false
+	}
+	have, want := slices.Clone(r.Permissions), permissionsOf(tpl.Permissions)
+	slices.Sort(have)
+	slices.Sort(want)
+	return slices.Equal(have, want)
+}
+
+func permissionsOf(perms []string) []string {
+	if perms == nil {
+		return []string{}
+	}
+	return slices.Clone(perms)
+}
+
+// --- RoleCatalog ---
+
+type entRoleCatalog struct {
+	client *coreent.Client
+}
+
+// ListRoles returns the roles owned by domainID ordered by sort and code.
+func (c *entRoleCatalog) ListRoles(ctx context.Context, domainID uuid.UUID) ([]shared.RoleInfo, error) {
+	roles, err := c.client.Role.Query().
+		Where(role.OwnerDomainID(domainID)).
+		Order(coreent.Asc(role.FieldSort), coreent.Asc(role.FieldCode)).
+		All(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]shared.RoleInfo, 0, len(roles))
+	for _, r := range roles {
+		perms := r.Permissions
+		if perms == nil {
+			perms = []string{}
+		}
+		out = append(out, shared.RoleInfo{
+			Code:        r.Code,
+			Name:        r.Name,
+			Description: r.Description,
+			Permissions: perms,
+			IsSystem:    r.IsSystem,
+		})
+	}
+	return out, nil
+}
+
+// CreateRole adds r to domainID as a custom, non-system role.
+func (c *entRoleCatalog) CreateRole(ctx context.Context, domainID uuid.UUID, r shared.RoleInfo) error {
+	return c.client.Role.Create().
+		SetOwnerDomainID(domainID).
+		SetName(r.Name).
+		SetCode(r.Code).
+		SetDescription(r.Description).
+		SetIsSystem(false).
+		SetPermissions(permissionsOf(r.Permissions)).
+		Exec(ctx)
+}
+
+// --- PermissionResolver ---
+
+type entPermissionResolver struct {
+	client *coreent.Client
+}
+
+// RolePermissions returns the permissions of the role with the given code in
+// domainID, or none when the domain has no such role.
+func (r *entPermissionResolver) RolePermissions(ctx context.Context, domainID uuid.UUID, roleCode string) ([]string, error) {
+	ro, err := r.client.Role.Query().
+		Where(
+			role.OwnerDomainID(domainID),
+			role.Code(roleCode),
+		).
+		Only(ctx)
+	if err != nil {
+		if coreent.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return ro.Permissions, nil
+}
+
 // --- UserLookup ---
 
 type entUserLookup struct {
@@ -106,11 +411,38 @@ func (l *entUserLookup) GetUser(ctx context.Context, userID uuid.UUID) (*shared.
 	if err != nil {
 		return nil, err
 	}
+	return toUserInfo(u), nil
+}
+
+// FindUser implements shared.UserFinder, fetching a user by username, then by email.
+func (l *entUserLookup) FindUser(ctx context.Context, username, email string) (*shared.UserInfo, error) {
+	if username != "" {
+		u, err := l.client.User.Query().Where(user.Username(username)).First(ctx)
+		if err == nil {
+			return toUserInfo(u), nil
+		}
+		if !coreent.IsNotFound(err) {
+			return nil, err
+		}
+	}
+	if email != "" {
+		u, err := l.client.User.Query().Where(user.EmailEqualFold(email)).First(ctx)
+		if err == nil {
+			return toUserInfo(u), nil
+		}
+		if !coreent.IsNotFound(err) {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+func toUserInfo(u *coreent.User) *shared.UserInfo {
 	return &shared.UserInfo{
 		ID:       u.ID,
 		Username: u.Username,
 		Email:    u.Email,
 		Nickname: u.Nickname,
 		Status:   u.Status.String(),
-	}, nil
+	}
 }
@@ -0,0 +1,229 @@
+package factory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	coreent "github.com/leeforge/core/server/ent"
+	"github.com/leeforge/core/server/ent/systemconfig"
+
+	"github.com/leeforge/plugins/tenant/shared"
+)
+
+// impersonationKeyPrefix namespaces impersonation sessions in the system
+// config table.
+const impersonationKeyPrefix = "tenant.impersonation:"
+
+// impersonationOpenKeyPrefix namespaces the index of sessions that have not
+// been revoked, keyed by domain and actor so that a request looks up the
+// sessions of its caller without scanning every session.
+const impersonationOpenKeyPrefix = "tenant.impersonation.open:"
+
+// entImpersonationStore persists impersonation sessions as JSON system config
+// entries keyed by session ID, plus an index entry per unrevoked session.
+type entImpersonationStore struct {
+	client *coreent.Client
+}
+
+func impersonationKey(id uuid.UUID) string {
+	return impersonationKeyPrefix + id.String()
+}
+
+func impersonationOpenPrefix(domainID, actorID uuid.UUID) string {
+	return impersonationOpenKeyPrefix + domainID.String() + ":" + actorID.String() + ":"
+}
+
+func impersonationOpenKey(session shared.ImpersonationSession) string {
+	return impersonationOpenPrefix(session.DomainID, session.ActorID) + session.ID.String()
+}
+
+// PutSession writes the session and its index entry in one transaction; the
+// index entry holds a copy of the session and is removed on revocation.
+func (s *entImpersonationStore) PutSession(ctx context.Context, session shared.ImpersonationSession) error {
+	value, err := json.Marshal(session)
+	if err != nil {
+		return fmt.Errorf("encode impersonation session: %w", err)
+	}
+	tx, err := s.client.Tx(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	if err := putImpersonationRow(ctx, tx.Client(), impersonationKey(session.ID), string(value)); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	openKey := impersonationOpenKey(session)
+	if session.RevokedAt == nil {
+		err = putImpersonationRow(ctx, tx.Client(), openKey, string(value))
+	} else {
+		_, err = tx.SystemConfig.Delete().Where(systemconfig.Key(openKey)).Exec(ctx)
+	}
+	if err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("index impersonation session: %w", err)
+	}
+	return tx.Commit()
+}
+
+func putImpersonationRow(ctx context.Context, client *coreent.Client, key, value string) error {
+	n, err := client.SystemConfig.Update().
+		Where(systemconfig.Key(key)).
+		SetValue(value).
+		ClearDeletedAt().
+		Save(ctx)
+	if err != nil {
+		return fmt.Errorf("update impersonation session: %w", err)
+	}
+	if n > 0 {
+		return nil
+	}
+	return client.SystemConfig.Create().
+		SetKey(key).
+		SetValue(value).
+		SetDescription("tenant impersonation session").
+		Exec(ctx)
+}
+
+func (s *entImpersonationStore) GetSession(ctx context.Context, id uuid.UUID) (*shared.ImpersonationSession, error) {
+	row, err := s.client.SystemConfig.Query().
+		Where(
+			systemconfig.Key(impersonationKey(id)),
+			systemconfig.DeletedAtIsNil(),
+		).
+		Only(ctx)
+	if err != nil {
+		if coreent.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return decodeImpersonation(row)
+}
+
+func (s *entImpersonationStore) ListSessions(ctx context.Context, tenantID uuid.UUID) ([]shared.ImpersonationSession, error) {
+	rows, err := s.client.SystemConfig.Query().
+		Where(
+			systemconfig.KeyHasPrefix(impersonationKeyPrefix),
+			systemconfig.DeletedAtIsNil(),
+		).
+		All(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sessions := make([]shared.ImpersonationSession, 0, len(rows))
+	for _, row := range rows {
+		session, err := decodeImpersonation(row)
+		if err != nil {
+			return nil, err
+		}
+		if tenantID == uuid.Nil || session.TenantID == tenantID {
+			sessions = append(sessions, *session)
+		}
+	}
+	return sessions, nil
+}
+
+func (s *entImpersonationStore) OpenSessions(ctx context.Context, domainID, actorID uuid.UUID) ([]shared.ImpersonationSession, error) {
+	rows, err := s.client.SystemConfig.Query().
+		Where(
+			systemconfig.KeyHasPrefix(impersonationOpenPrefix(domainID, actorID)),
+			systemconfig.DeletedAtIsNil(),
+		).
+		All(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sessions := make([]shared.ImpersonationSession, 0, len(rows))
+	for _, row := range rows {
+		session, err := decodeImpersonation(row)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, *session)
+	}
+	return sessions, nil
+}
+
+// DeleteEndedSessions only decodes the sessions last written before
+// endedBefore: a session is last written no later than it ends.
+func (s *entImpersonationStore) DeleteEndedSessions(ctx context.Context, endedBefore time.Time) (int, error) {
+	rows, err := s.client.SystemConfig.Query().
+		Where(
+			systemconfig.KeyHasPrefix(impersonationKeyPrefix),
+			systemconfig.UpdatedAtLT(endedBefore),
+		).
+		All(ctx)
+	if err != nil {
+		return 0, err
+	}
+	removed := 0
+	for _, row := range rows {
+		session, err := decodeImpersonation(row)
+		if err != nil {
+			return removed, err
+		}
+		if !session.EndedAt().Before(endedBefore) {
+			continue
+		}
+		keys := []string{row.Key, impersonationOpenKey(*session)}
+		if _, err := s.client.SystemConfig.Delete().Where(systemconfig.KeyIn(keys...)).Exec(ctx); err != nil {
+			return removed, fmt.Errorf("delete impersonation session: %w", err)
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+func decodeImpersonation(row *coreent.SystemConfig) (*shared.ImpersonationSession, error) {
+	var session shared.ImpersonationSession
+	if err := json.Unmarshal([]byte(row.Value), &session); err != nil {
+		return nil, fmt.Errorf("decode impersonation session %s: %w", row.Key, err)
+	}
+	return &session, nil
+}
+
+// Action and resource of impersonated requests in the audit log. Records
+// are keyed by session ID as the resource ID.
+const (
+	impersonationAuditAction   = "tenant.impersonated_request"
+	impersonationAuditResource = "tenant.impersonation"
+)
+
+// entImpersonationAuditLog writes the records of impersonated requests to
+// the core audit log, attributed to the impersonator in the tenant domain.
+type entImpersonationAuditLog struct {
+	client *coreent.Client
+}
+
+func (l *entImpersonationAuditLog) RecordImpersonation(ctx context.Context, audit shared.ImpersonationAudit) error {
+	return l.client.AuditLog.Create().
+		SetID(audit.ID).
+		SetCreatedAt(audit.At).
+		SetCreatedByID(audit.ImpersonatorID).
+		SetOwnerDomainID(audit.DomainID).
+		SetAction(impersonationAuditAction).
+		SetResource(impersonationAuditResource).
+		SetResourceID(audit.SessionID.String()).
+		SetAfter(map[string]any{
+			"impersonated":   audit.Impersonated,
+			"impersonatorId": audit.ImpersonatorID.String(),
+			"sessionId":      audit.SessionID.String(),
+			"tenantId":       audit.TenantID.String(),
+			"tenantCode":     audit.TenantCode,
+			"method":         audit.Method,
+			"path":           audit.Path,
+		}).
+		SetIPAddress(audit.IPAddress).
+		SetUserAgent(audit.UserAgent).
+		AddUserIDs(audit.ImpersonatorID).
+		Exec(ctx)
+}
+
+var (
+	_ shared.ImpersonationStore    = (*entImpersonationStore)(nil)
+	_ shared.ImpersonationAuditLog = (*entImpersonationAuditLog)(nil)
+)
@@ -0,0 +1,154 @@
+//go:build integration
+// +build integration
+
+package factory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"entgo.io/ent/dialect"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/leeforge/core/server/ent/auditlog"
+	"github.com/leeforge/core/server/ent/enttest"
+
+	"github.com/leeforge/plugins/tenant/shared"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestEntImpersonationStore_RoundTrip(t *testing.T) {
+	client := enttest.Open(t, dialect.SQLite, "file:tenant_impersonations?mode=memory&cache=shared&_fk=1")
+	t.Cleanup(func() { _ = client.Close() })
+
+	ctx := context.Background()
+	store := NewEntFactory(client).Impersonations()
+	tenantID, other := uuid.New(), uuid.New()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	session := shared.ImpersonationSession{
+		ID:        uuid.New(),
+		TenantID:  tenantID,
+		ActorID:   uuid.New(),
+		Reason:    "support ticket",
+		TokenHash: "hash",
+		CreatedAt: now,
+		ExpiresAt: now.Add(time.Hour),
+	}
+	require.NoError(t, store.PutSession(ctx, session))
+	require.NoError(t, store.PutSession(ctx, shared.ImpersonationSession{ID: uuid.New(), TenantID: other, CreatedAt: now, ExpiresAt: now.Add(time.Hour)}))
+
+	missing, err := store.GetSession(ctx, uuid.New())
+	require.NoError(t, err)
+	require.Nil(t, missing)
+
+	revokedBy := uuid.New()
+	session.RevokedAt, session.RevokedBy = &now, &revokedBy
+	require.NoError(t, store.PutSession(ctx, session))
+
+	got, err := store.GetSession(ctx, session.ID)
+	require.NoError(t, err)
+	require.NotNil(t, got)
+	require.Equal(t, shared.ImpersonationRevoked, got.StatusAt(now), "PutSession replaces an existing session")
+	require.Equal(t, revokedBy, *got.RevokedBy)
+
+	sessions, err := store.ListSessions(ctx, tenantID)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+
+	all, err := store.ListSessions(ctx, uuid.Nil)
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+}
+
+func TestEntImpersonationStore_OpenSessionsAndPruning(t *testing.T) {
+	client := enttest.Open(t, dialect.SQLite, "file:tenant_impersonations_open?mode=memory&cache=shared&_fk=1")
+	t.Cleanup(func() { _ = client.Close() })
+
+	ctx := context.Background()
+	store := NewEntFactory(client).Impersonations()
+	domainID, actorID := uuid.New(), uuid.New()
+	now := time.Now().UTC().Truncate(time.Second)
+	session := func(expiresAt time.Time) shared.ImpersonationSession {
+		return shared.ImpersonationSession{
+			ID:        uuid.New(),
+			DomainID:  domainID,
+			ActorID:   actorID,
+			CreatedAt: now,
+			ExpiresAt: expiresAt,
+		}
+	}
+
+	active, expired, revoked := session(now.Add(time.Hour)), session(now.Add(-time.Hour)), session(now.Add(time.Hour))
+	for _, s := range []shared.ImpersonationSession{active, expired, revoked} {
+		require.NoError(t, store.PutSession(ctx, s))
+	}
+	require.NoError(t, store.PutSession(ctx, shared.ImpersonationSession{ID: uuid.New(), DomainID: domainID, ActorID: uuid.New(), ExpiresAt: now.Add(time.Hour)}))
+	revoked.RevokedAt = &now
+	require.NoError(t, store.PutSession(ctx, revoked))
+
+	open, err := store.OpenSessions(ctx, domainID, actorID)
+	require.NoError(t, err)
+	ids := make([]uuid.UUID, 0, len(open))
+	for _, s := range open {
+		ids = append(ids, s.ID)
+	}
+	require.ElementsMatch(t, []uuid.UUID{active.ID, expired.ID}, ids, "revoked sessions and other actors are not returned")
+
+	removed, err := store.DeleteEndedSessions(ctx, now.Add(time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, 2, removed, "the expired and the revoked session are removed")
+
+	open, err = store.OpenSessions(ctx, domainID, actorID)
+	require.NoError(t, err)
+	require.Len(t, open, 1)
+	require.Equal(t, active.ID, open[0].ID)
+	all, err := store.ListSessions(ctx, uuid.Nil)
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+}
+
+func TestEntImpersonationAuditLog_RecordsRequest(t *testing.T) {
+	client := enttest.Open(t, dialect.SQLite, "file:tenant_impersonation_audits?mode=memory&cache=shared&_fk=1")
+	t.Cleanup(func() { _ = client.Close() })
+
+	ctx := context.Background()
+	impersonator, err := client.User.Create().SetUsername("support").SetEmail("support@example.com").Save(ctx)
+	require.NoError(t, err)
+	audit := shared.ImpersonationAudit{
+		ID:             uuid.New(),
+		SessionID:      uuid.New(),
+		TenantID:       uuid.New(),
+		TenantCode:     "acme",
+		DomainID:       uuid.New(),
+		ImpersonatorID: impersonator.ID,
+		Impersonated:   true,
+		Method:         "DELETE",
+		Path:           "/tenants/acme/members/42",
+		IPAddress:      "192.0.2.1",
+		UserAgent:      "curl/8",
+		At:             time.Now().UTC().Truncate(time.Second),
+	}
+	require.NoError(t, NewEntFactory(client).ImpersonationAudits().RecordImpersonation(ctx, audit))
+
+	row, err := client.AuditLog.Query().
+		Where(
+			auditlog.Action(impersonationAuditAction),
+			auditlog.ResourceID(audit.SessionID.String()),
+		).
+		WithUser().
+		Only(ctx)
+	require.NoError(t, err)
+	require.Equal(t, impersonationAuditResource, row.Resource)
+	require.Equal(t, audit.DomainID, *row.OwnerDomainID)
+	require.Equal(t, impersonator.ID, row.CreatedByID)
+	require.Len(t, row.Edges.User, 1)
+	require.Equal(t, impersonator.ID, row.Edges.User[0].ID)
+	require.Equal(t, true, row.After["impersonated"])
+	require.Equal(t, impersonator.ID.String(), row.After["impersonatorId"])
+	require.Equal(t, "DELETE", row.After["method"])
+	require.Equal(t, "192.0.2.1", row.IPAddress)
+}
@@ -0,0 +1,131 @@
+package factory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	coreent "github.com/leeforge/core/server/ent"
+	"github.com/leeforge/core/server/ent/systemconfig"
+
+	"github.com/leeforge/plugins/tenant/shared"
+)
+
+// memberTermKeyPrefix namespaces membership terms in the system config table.
+const memberTermKeyPrefix = "tenant.member_term:"
+
+// entMemberTermStore persists membership terms as JSON system config entries
+// keyed by tenant and user, since the TenantUser schema has no columns for
+// them.
+type entMemberTermStore struct {
+	client *coreent.Client
+}
+
+func memberTermKey(tenantID, userID uuid.UUID) string {
+	return memberTermKeyPrefix + tenantID.String() + ":" + userID.String()
+}
+
+func (s *entMemberTermStore) PutTerm(ctx context.Context, term shared.MemberTerm) error {
+	value, err := json.Marshal(term)
+	if err != nil {
+		return fmt.Errorf("encode membership term: %w", err)
+	}
+	key := memberTermKey(term.TenantID, term.UserID)
+
+	n, err := s.client.SystemConfig.Update().
+		Where(systemconfig.Key(key)).
+		SetValue(string(value)).
+		ClearDeletedAt().
+		Save(ctx)
+	if err != nil {
+		return fmt.Errorf("update membership term: %w", err)
+	}
+	if n > 0 {
+		return nil
+	}
+	return s.client.SystemConfig.Create().
+		SetKey(key).
+		SetValue(string(value)).
+		SetDescription("tenant membership term").
+		Exec(ctx)
+}
+
+func (s *entMemberTermStore) GetTerm(ctx context.Context, tenantID, userID uuid.UUID) (*shared.MemberTerm, error) {
+	row, err := s.client.SystemConfig.Query().
+		Where(
+			systemconfig.Key(memberTermKey(tenantID, userID)),
+			systemconfig.DeletedAtIsNil(),
+		).
+		Only(ctx)
+	if err != nil {
+		if coreent.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return decodeMemberTerm(row)
+}
+
+func (s *entMemberTermStore) DeleteTerm(ctx context.Context, tenantID, userID uuid.UUID) error {
+	_, err := s.client.SystemConfig.Delete().
+		Where(systemconfig.Key(memberTermKey(tenantID, userID))).
+		Exec(ctx)
+	return err
+}
+
+func (s *entMemberTermStore) ListTerms(ctx context.Context, tenantID uuid.UUID) ([]shared.MemberTerm, error) {
+	return s.list(ctx, memberTermKeyPrefix+tenantID.String()+":")
+}
+
+func (s *entMemberTermStore) ListExpired(ctx context.Context, now time.Time, limit int) ([]shared.MemberTerm, error) {
+	terms, err := s.list(ctx, memberTermKeyPrefix)
+	if err != nil {
+		return nil, err
+	}
+	expired := terms[:0]
+	for _, term := range terms {
+		if term.ExpiredAt(now) {
+			expired = append(expired, term)
+		}
+	}
+	sort.Slice(expired, func(i, j int) bool { return expired[i].ExpiresAt.Before(*expired[j].ExpiresAt) })
+	if limit > 0 && len(expired) > limit {
+		expired = expired[:limit]
+	}
+	return expired, nil
+}
+
+func (s *entMemberTermStore) list(ctx context.Context, prefix string) ([]shared.MemberTerm, error) {
+	rows, err := s.client.SystemConfig.Query().
+		Where(
+			systemconfig.KeyHasPrefix(prefix),
+			systemconfig.DeletedAtIsNil(),
+		).
+		All(ctx)
+	if err != nil {
+		return nil, err
+	}
+	terms := make([]shared.MemberTerm, 0, len(rows))
+	for _, row := range rows {
+		term, err := decodeMemberTerm(row)
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, *term)
+	}
+	return terms, nil
+}
+
+func decodeMemberTerm(row *coreent.SystemConfig) (*shared.MemberTerm, error) {
+	var term shared.MemberTerm
+	if err := json.Unmarshal([]byte(row.Value), &term); err != nil {
+		return nil, fmt.Errorf("decode membership term %s: %w", row.Key, err)
+	}
+	return &term, nil
+}
+
+var _ shared.MemberTermStore = (*entMemberTermStore)(nil)
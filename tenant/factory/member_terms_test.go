@@ -0,0 +1,54 @@
+//go:build integration
+// +build integration
+
+package factory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"entgo.io/ent/dialect"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/leeforge/core/server/ent/enttest"
+
+	"github.com/leeforge/plugins/tenant/shared"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestEntMemberTermStore_RoundTrip(t *testing.T) {
+	client := enttest.Open(t, dialect.SQLite, "file:tenant_member_terms?mode=memory&cache=shared&_fk=1")
+	t.Cleanup(func() { _ = client.Close() })
+
+	ctx := context.Background()
+	store := NewEntFactory(client).MemberTerms()
+	tenantID, other := uuid.New(), uuid.New()
+	userA, userB := uuid.New(), uuid.New()
+	now := time.Now().UTC().Truncate(time.Second)
+	soon, later := now.Add(time.Minute), now.Add(time.Hour)
+
+	require.NoError(t, store.PutTerm(ctx, shared.MemberTerm{TenantID: tenantID, UserID: userA, Type: shared.MembershipTypeGuest, ExpiresAt: &later}))
+	require.NoError(t, store.PutTerm(ctx, shared.MemberTerm{TenantID: tenantID, UserID: userA, Type: shared.MembershipTypeGuest, ExpiresAt: &soon}))
+	require.NoError(t, store.PutTerm(ctx, shared.MemberTerm{TenantID: other, UserID: userB, Type: shared.MembershipTypeStandard, ExpiresAt: &later}))
+
+	term, err := store.GetTerm(ctx, tenantID, userA)
+	require.NoError(t, err)
+	require.Equal(t, soon, term.ExpiresAt.UTC(), "PutTerm replaces an existing term")
+
+	terms, err := store.ListTerms(ctx, tenantID)
+	require.NoError(t, err)
+	require.Len(t, terms, 1)
+
+	expired, err := store.ListExpired(ctx, now.Add(30*time.Minute), 10)
+	require.NoError(t, err)
+	require.Len(t, expired, 1)
+	require.Equal(t, userA, expired[0].UserID)
+
+	require.NoError(t, store.DeleteTerm(ctx, tenantID, userA))
+	term, err = store.GetTerm(ctx, tenantID, userA)
+	require.NoError(t, err)
+	require.Nil(t, term)
+}
@@ -0,0 +1,45 @@
+//go:build integration
+// +build integration
+
+package factory
+
+import (
+	"context"
+	"testing"
+
+	"entgo.io/ent/dialect"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/leeforge/core/server/ent/enttest"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestEntPermissionResolver_RolePermissions(t *testing.T) {
+	client := enttest.Open(t, dialect.SQLite, "file:tenant_role_permissions?mode=memory&cache=shared&_fk=1")
+	t.Cleanup(func() { _ = client.Close() })
+
+	ctx := context.Background()
+	domainID := uuid.New()
+	require.NoError(t, client.Role.Create().
+		SetOwnerDomainID(domainID).
+		SetName("Admin").
+		SetCode("admin").
+		SetPermissions([]string{"tenant:read", "tenant:write"}).
+		Exec(ctx))
+
+	resolver := NewEntFactory(client).Permissions()
+
+	perms, err := resolver.RolePermissions(ctx, domainID, "admin")
+	require.NoError(t, err)
+	require.Equal(t, []string{"tenant:read", "tenant:write"}, perms)
+
+	perms, err = resolver.RolePermissions(ctx, domainID, "viewer")
+	require.NoError(t, err)
+	require.Empty(t, perms)
+
+	perms, err = resolver.RolePermissions(ctx, uuid.New(), "admin")
+	require.NoError(t, err)
+	require.Empty(t, perms)
+}
@@ -0,0 +1,113 @@
+//go:build integration
+// +build integration
+
+package factory
+
+import (
+	"context"
+	"testing"
+
+	"entgo.io/ent/dialect"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/leeforge/core/server/ent/enttest"
+	"github.com/leeforge/core/server/ent/role"
+
+	"github.com/leeforge/plugins/tenant/shared"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestEntRoleSeeder_SeedRolesAndResync(t *testing.T) {
+	client := enttest.Open(t, dialect.SQLite, "file:tenant_role_seeder?mode=memory&cache=shared&_fk=1")
+	t.Cleanup(func() { _ = client.Close() })
+
+	ctx := context.Background()
+	seeder := NewEntFactory(client).RoleSeeder().(shared.RoleTemplateSeeder)
+	domainID := uuid.New()
+
+	templates := []shared.RoleTemplate{
+		{Code: "admin", Name: "Admin", Owner: true, Permissions: []string{"tenant:*"}},
+		{Code: "member", Name: "Member", Permissions: []string{"tenant:read"}},
+		{Code: "auditor", Name: "Auditor", Permissions: []string{"audit:read"}},
+	}
+	res, err := seeder.SeedRoles(ctx, domainID, templates)
+	require.NoError(t, err)
+	require.Equal(t, []string{"admin", "member", "auditor"}, res.Created)
+
+	// The tenant edits the auditor role; the member role stays as seeded.
+	auditor := client.Role.Query().Where(role.OwnerDomainID(domainID), role.Code("auditor")).OnlyX(ctx)
+	client.Role.UpdateOneID(auditor.ID).SetPermissions([]string{"audit:read", "audit:export"}).ExecX(ctx)
+
+	templates[1].Permissions = []string{"tenant:read", "members:read"}
+	templates[2].Permissions = []string{"audit:read", "audit:list"}
+	res, err = seeder.SeedRoles(ctx, domainID, templates)
+	require.NoError(t, err)
+	require.Empty(t, res.Created)
+	require.Equal(t, []string{"member"}, res.Updated)
+	require.Equal(t, []string{"admin"}, res.Unchanged)
+	require.Equal(t, []string{"auditor"}, res.Customized)
+
+	member := client.Role.Query().Where(role.OwnerDomainID(domainID), role.Code("member")).OnlyX(ctx)
+	require.Equal(t, []string{"tenant:read", "members:read"}, member.Permissions)
+	auditor = client.Role.Query().Where(role.OwnerDomainID(domainID), role.Code("auditor")).OnlyX(ctx)
+	require.Equal(t, []string{"audit:read", "audit:export"}, auditor.Permissions)
+
+	roles, err := NewEntFactory(client).RoleCatalog().ListRoles(ctx, domainID)
+	require.NoError(t, err)
+	codes := make([]string, 0, len(roles))
+	for _, r := range roles {
+		codes = append(codes, r.Code)
+	}
+	require.Equal(t, []string{"admin", "auditor", "member"}, codes)
+	require.True(t, roles[0].IsSystem)
+
+	roles, err = NewEntFactory(client).RoleCatalog().ListRoles(ctx, uuid.New())
+	require.NoError(t, err)
+	require.Empty(t, roles)
+
+	catalog := NewEntFactory(client).RoleCatalog()
+	require.NoError(t, catalog.CreateRole(ctx, domainID, shared.RoleInfo{Code: "sales", Name: "Sales"}))
+	roles, err = catalog.ListRoles(ctx, domainID)
+	require.NoError(t, err)
+	require.Len(t, roles, 4)
+	require.Equal(t, "sales", roles[3].Code)
+	require.False(t, roles[3].IsSystem)
+	require.Empty(t, roles[3].Permissions)
+}
+
+func TestEntRoleSeeder_AdoptsLegacySystemRoles(t *testing.T) {
+	client := enttest.Open(t, dialect.SQLite, "file:tenant_role_seeder_legacy?mode=memory&cache=shared&_fk=1")
+	t.Cleanup(func() { _ = client.Close() })
+
+	ctx := context.Background()
+	domainID := uuid.New()
+	// Roles seeded before templates existed carry no snapshot.
+	client.Role.Create().SetOwnerDomainID(domainID).SetName("Member").SetCode("member").
+		SetIsSystem(true).SetPermissions([]string{}).ExecX(ctx)
+	client.Role.Create().SetOwnerDomainID(domainID).SetName("Custom").SetCode("custom").
+		SetPermissions([]string{"x"}).ExecX(ctx)
+
+	res, err := NewEntFactory(client).RoleSeeder().(shared.RoleTemplateSeeder).SeedRoles(ctx, domainID, []shared.RoleTemplate{
+		{Code: "member", Name: "Member", Permissions: []string{"tenant:read"}},
+		{Code: "custom", Name: "Custom"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"member"}, res.Updated)
+	require.Equal(t, []string{"custom"}, res.Customized)
+}
+
+func TestEntRoleSeeder_SeedBaselineRoles(t *testing.T) {
+	client := enttest.Open(t, dialect.SQLite, "file:tenant_role_seeder_baseline?mode=memory&cache=shared&_fk=1")
+	t.Cleanup(func() { _ = client.Close() })
+
+	ctx := context.Background()
+	domainID := uuid.New()
+	seeder := NewEntFactory(client).RoleSeeder()
+	require.NoError(t, seeder.SeedBaselineRoles(ctx, domainID))
+	require.NoError(t, seeder.SeedBaselineRoles(ctx, domainID))
+
+	codes := client.Role.Query().Where(role.OwnerDomainID(domainID)).Order(role.ByCode()).Select(role.FieldCode).StringsX(ctx)
+	require.Equal(t, []string{"member", "owner"}, codes)
+}
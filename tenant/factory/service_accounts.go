@@ -0,0 +1,233 @@
+package factory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	coreent "github.com/leeforge/core/server/ent"
+	"github.com/leeforge/core/server/ent/systemconfig"
+
+	"github.com/leeforge/plugins/tenant/shared"
+)
+
+// Key prefixes of service accounts and API keys in the system config table.
+// Accounts and keys are keyed by their own ID, so that a key is read with an
+// exact key lookup on every request it authenticates. Scope entries copy
+// each account under its tenant and each key under its account, so that
+// both can be listed by prefix.
+const (
+	serviceAccountKeyPrefix      = "tenant.service_account:"
+	serviceAccountScopeKeyPrefix = "tenant.service_account_scope:"
+	apiKeyKeyPrefix              = "tenant.api_key:"
+	apiKeyScopeKeyPrefix         = "tenant.api_key_scope:"
+)
+
+// entServiceAccountStore persists service accounts and API keys as JSON
+// system config entries.
+type entServiceAccountStore struct {
+	client *coreent.Client
+}
+
+func serviceAccountScopePrefix(tenantID uuid.UUID) string {
+	return serviceAccountScopeKeyPrefix + tenantID.String() + ":"
+}
+
+func serviceAccountScopeKey(account shared.ServiceAccount) string {
+	return serviceAccountScopePrefix(account.TenantID) + account.ID.String()
+}
+
+func apiKeyScopePrefix(accountID uuid.UUID) string {
+	return apiKeyScopeKeyPrefix + accountID.String() + ":"
+}
+
+func apiKeyScopeKey(key shared.APIKey) string {
+	return apiKeyScopePrefix(key.AccountID) + key.ID.String()
+}
+
+// PutAccount writes the account and its scope entry in one transaction.
+func (s *entServiceAccountStore) PutAccount(ctx context.Context, account shared.ServiceAccount) error {
+	return s.inTx(ctx, func(client *coreent.Client) error {
+		if err := putEntry(ctx, client, serviceAccountKeyPrefix+account.ID.String(), account, "tenant service account"); err != nil {
+			return err
+		}
+		return putEntry(ctx, client, serviceAccountScopeKey(account), account, "tenant service account scope")
+	})
+}
+
+func (s *entServiceAccountStore) GetAccount(ctx context.Context, id uuid.UUID) (*shared.ServiceAccount, error) {
+	var account shared.ServiceAccount
+	found, err := getEntry(ctx, s.client, serviceAccountKeyPrefix+id.String(), &account)
+	if err != nil || !found {
+		return nil, err
+	}
+	return &account, nil
+}
+
+func (s *entServiceAccountStore) ListAccounts(ctx context.Context, tenantID uuid.UUID) ([]shared.ServiceAccount, error) {
+	rows, err := s.list(ctx, serviceAccountScopePrefix(tenantID))
+	if err != nil {
+		return nil, err
+	}
+	accounts := make([]shared.ServiceAccount, 0, len(rows))
+	for _, row := range rows {
+		var account shared.ServiceAccount
+		if err := json.Unmarshal([]byte(row.Value), &account); err != nil {
+			return nil, fmt.Errorf("decode service account %s: %w", row.Key, err)
+		}
+		accounts = append(accounts, account)
+	}
+	return accounts, nil
+}
+
+// DeleteAccount removes the account, its keys and their scope entries in
+// one transaction.
+func (s *entServiceAccountStore) DeleteAccount(ctx context.Context, id uuid.UUID) error {
+	return s.inTx(ctx, func(client *coreent.Client) error {
+		var account shared.ServiceAccount
+		found, err := getEntry(ctx, client, serviceAccountKeyPrefix+id.String(), &account)
+		if err != nil || !found {
+			return err
+		}
+		keyIDs, err := client.SystemConfig.Query().
+			Where(systemconfig.KeyHasPrefix(apiKeyScopePrefix(id))).
+			Select(systemconfig.FieldKey).
+			Strings(ctx)
+		if err != nil {
+			return err
+		}
+		keys := []string{serviceAccountKeyPrefix + id.String(), serviceAccountScopeKey(account)}
+		for _, scopeKey := range keyIDs {
+			keys = append(keys, apiKeyKeyPrefix+strings.TrimPrefix(scopeKey, apiKeyScopePrefix(id)))
+		}
+		_, err = client.SystemConfig.Delete().
+			Where(systemconfig.Or(
+				systemconfig.KeyIn(keys...),
+				systemconfig.KeyHasPrefix(apiKeyScopePrefix(id)),
+			)).
+			Exec(ctx)
+		return err
+	})
+}
+
+// PutKey writes the key and its scope entry in one transaction.
+func (s *entServiceAccountStore) PutKey(ctx context.Context, key shared.APIKey) error {
+	return s.inTx(ctx, func(client *coreent.Client) error {
+		return putKey(ctx, client, key)
+	})
+}
+
+func putKey(ctx context.Context, client *coreent.Client, key shared.APIKey) error {
+	if err := putEntry(ctx, client, apiKeyKeyPrefix+key.ID.String(), key, "tenant api key"); err != nil {
+		return err
+	}
+	return putEntry(ctx, client, apiKeyScopeKey(key), key, "tenant api key scope")
+}
+
+func (s *entServiceAccountStore) GetKey(ctx context.Context, id uuid.UUID) (*shared.APIKey, error) {
+	var key shared.APIKey
+	found, err := getEntry(ctx, s.client, apiKeyKeyPrefix+id.String(), &key)
+	if err != nil || !found {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (s *entServiceAccountStore) ListKeys(ctx context.Context, accountID uuid.UUID) ([]shared.APIKey, error) {
+	rows, err := s.list(ctx, apiKeyScopePrefix(accountID))
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]shared.APIKey, 0, len(rows))
+	for _, row := range rows {
+		var key shared.APIKey
+		if err := json.Unmarshal([]byte(row.Value), &key); err != nil {
+			return nil, fmt.Errorf("decode api key %s: %w", row.Key, err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// TouchKey rewrites the stored key in a transaction so that a concurrent
+// revocation is never overwritten.
+func (s *entServiceAccountStore) TouchKey(ctx context.Context, id uuid.UUID, usedAt time.Time) error {
+	return s.inTx(ctx, func(client *coreent.Client) error {
+		var key shared.APIKey
+		found, err := getEntry(ctx, client, apiKeyKeyPrefix+id.String(), &key)
+		if err != nil || !found {
+			return err
+		}
+		key.LastUsedAt = &usedAt
+		return putKey(ctx, client, key)
+	})
+}
+
+// putEntry creates or replaces the entry key with v.
+func putEntry(ctx context.Context, client *coreent.Client, key string, v any, description string) error {
+	value, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("encode %s: %w", description, err)
+	}
+	n, err := client.SystemConfig.Update().
+		Where(systemconfig.Key(key)).
+		SetValue(string(value)).
+		ClearDeletedAt().
+		Save(ctx)
+	if err != nil {
+		return fmt.Errorf("update %s: %w", description, err)
+	}
+	if n > 0 {
+		return nil
+	}
+	return client.SystemConfig.Create().
+		SetKey(key).
+		SetValue(string(value)).
+		SetDescription(description).
+		Exec(ctx)
+}
+
+// getEntry decodes the entry key into v.
+func getEntry(ctx context.Context, client *coreent.Client, key string, v any) (bool, error) {
+	row, err := client.SystemConfig.Query().
+		Where(systemconfig.Key(key), systemconfig.DeletedAtIsNil()).
+		Only(ctx)
+	if err != nil {
+		if coreent.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	if err := json.Unmarshal([]byte(row.Value), v); err != nil {
+		return false, fmt.Errorf("decode %s: %w", row.Key, err)
+	}
+	return true, nil
+}
+
+// inTx runs fn with a transactional client and commits unless fn fails.
+func (s *entServiceAccountStore) inTx(ctx context.Context, fn func(client *coreent.Client) error) error {
+	tx, err := s.client.Tx(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	if err := fn(tx.Client()); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *entServiceAccountStore) list(ctx context.Context, prefix string) ([]*coreent.SystemConfig, error) {
+	return s.client.SystemConfig.Query().
+		Where(
+			systemconfig.KeyHasPrefix(prefix),
+			systemconfig.DeletedAtIsNil(),
+		).
+		All(ctx)
+}
+
+var _ shared.ServiceAccountStore = (*entServiceAccountStore)(nil)
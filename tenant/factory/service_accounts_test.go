@@ -0,0 +1,88 @@
+//go:build integration
+// +build integration
+
+package factory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"entgo.io/ent/dialect"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/leeforge/core/server/ent/enttest"
+	"github.com/leeforge/core/server/ent/systemconfig"
+
+	"github.com/leeforge/plugins/tenant/shared"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestEntServiceAccountStore_RoundTrip(t *testing.T) {
+	client := enttest.Open(t, dialect.SQLite, "file:tenant_service_accounts?mode=memory&cache=shared&_fk=1")
+	t.Cleanup(func() { _ = client.Close() })
+
+	ctx := context.Background()
+	store := NewEntFactory(client).ServiceAccounts()
+	tenantID := uuid.New()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	ci := shared.ServiceAccount{ID: uuid.New(), TenantID: tenantID, Name: "ci", Role: "member", CreatedAt: now}
+	other := shared.ServiceAccount{ID: uuid.New(), TenantID: uuid.New(), Name: "ci", Role: "member", CreatedAt: now}
+	require.NoError(t, store.PutAccount(ctx, ci))
+	require.NoError(t, store.PutAccount(ctx, other))
+
+	ci.Disabled = true
+	require.NoError(t, store.PutAccount(ctx, ci))
+	got, err := store.GetAccount(ctx, ci.ID)
+	require.NoError(t, err)
+	require.True(t, got.Disabled, "PutAccount replaces an existing account")
+
+	accounts, err := store.ListAccounts(ctx, tenantID)
+	require.NoError(t, err)
+	require.Len(t, accounts, 1)
+
+	key := shared.APIKey{ID: uuid.New(), AccountID: ci.ID, TenantID: tenantID, Name: "deploy", KeyHash: "hash", CreatedAt: now}
+	require.NoError(t, store.PutKey(ctx, key))
+	require.NoError(t, store.PutKey(ctx, shared.APIKey{ID: uuid.New(), AccountID: other.ID, CreatedAt: now}))
+
+	// A revocation stored after the key was read survives a touch.
+	key.RevokedAt = &now
+	require.NoError(t, store.PutKey(ctx, key))
+	require.NoError(t, store.TouchKey(ctx, key.ID, now.Add(time.Minute)))
+	require.NoError(t, store.TouchKey(ctx, uuid.New(), now), "missing keys are ignored")
+
+	exact, err := client.SystemConfig.Query().Where(systemconfig.Key(apiKeyKeyPrefix + key.ID.String())).Exist(ctx)
+	require.NoError(t, err)
+	require.True(t, exact, "keys are stored under their own ID")
+	stored, err := store.GetKey(ctx, key.ID)
+	require.NoError(t, err)
+	require.NotNil(t, stored.RevokedAt)
+	require.Equal(t, now.Add(time.Minute), stored.LastUsedAt.UTC())
+
+	keys, err := store.ListKeys(ctx, ci.ID)
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+
+	require.NoError(t, store.DeleteAccount(ctx, ci.ID))
+	got, err = store.GetAccount(ctx, ci.ID)
+	require.NoError(t, err)
+	require.Nil(t, got)
+	stored, err = store.GetKey(ctx, key.ID)
+	require.NoError(t, err)
+	require.Nil(t, stored, "DeleteAccount removes the account's keys")
+	left, err := client.SystemConfig.Query().
+		Where(systemconfig.Or(
+			systemconfig.KeyHasPrefix(apiKeyScopePrefix(ci.ID)),
+			systemconfig.KeyHasPrefix(serviceAccountScopeKey(ci)),
+		)).
+		Count(ctx)
+	require.NoError(t, err)
+	require.Zero(t, left, "DeleteAccount removes the scope entries")
+
+	accounts, err = store.ListAccounts(ctx, other.TenantID)
+	require.NoError(t, err)
+	require.Len(t, accounts, 1)
+}
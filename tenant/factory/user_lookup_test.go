@@ -0,0 +1,40 @@
+//go:build integration
+// +build integration
+
+package factory
+
+import (
+	"context"
+	"testing"
+
+	"entgo.io/ent/dialect"
+	"github.com/stretchr/testify/require"
+
+	"github.com/leeforge/core/server/ent/enttest"
+
+	"github.com/leeforge/plugins/tenant/shared"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestEntUserLookup_FindUser(t *testing.T) {
+	client := enttest.Open(t, dialect.SQLite, "file:tenant_find_user?mode=memory&cache=shared&_fk=1")
+	t.Cleanup(func() { _ = client.Close() })
+
+	ctx := context.Background()
+	ann := client.User.Create().SetUsername("ann").SetEmail("Ann@Example.com").SaveX(ctx)
+	lookup := NewEntFactory(client).UserLookup().(shared.UserFinder)
+
+	u, err := lookup.FindUser(ctx, "ann", "")
+	require.NoError(t, err)
+	require.Equal(t, ann.ID, u.ID)
+
+	// The email is the fallback and is matched case-insensitively.
+	u, err = lookup.FindUser(ctx, "ann.smith", "ann@example.com")
+	require.NoError(t, err)
+	require.Equal(t, ann.ID, u.ID)
+
+	u, err = lookup.FindUser(ctx, "bob", "bob@example.com")
+	require.NoError(t, err)
+	require.Nil(t, u)
+}
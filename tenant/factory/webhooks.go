@@ -0,0 +1,418 @@
+package factory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	coreent "github.com/leeforge/core/server/ent"
+	"github.com/leeforge/core/server/ent/predicate"
+	"github.com/leeforge/core/server/ent/systemconfig"
+
+	"github.com/leeforge/plugins/tenant/shared"
+)
+
+// Key prefixes of webhook entries in the system config table. Endpoints
+// and deliveries are keyed by their own ID, so that they are read with an
+// exact key lookup. Scope entries copy each endpoint under its tenant, so
+// that an event only reads the endpoints that may receive it, and index
+// each delivery under its endpoint, so that the log of an endpoint is listed
+// by prefix. Queue entries are keyed by due time and finished entries by the
+// time a delivery finished, so that the worker and the pruning read the
+// entries they act on in order and stop at the first one past the bound.
+const (
+	webhookKeyPrefix              = "tenant.webhook:"
+	webhookScopeKeyPrefix         = "tenant.webhook_scope:"
+	webhookDeliveryKeyPrefix      = "tenant.webhook_delivery:"
+	webhookDeliveryScopeKeyPrefix = "tenant.webhook_delivery_scope:"
+	webhookQueueKeyPrefix         = "tenant.webhook_queue:"
+	webhookFinishedKeyPrefix      = "tenant.webhook_finished:"
+)
+
+// webhookTimeFormat renders times with a fixed width, so that queue and
+// finished keys sort by time.
+const webhookTimeFormat = "20060102T150405.000000000Z"
+
+// platformWebhookScope is the scope of endpoints without a tenant.
+const platformWebhookScope = "platform"
+
+// entWebhookStore persists webhook endpoints, deliveries and the queue of
+// pending deliveries as JSON system config entries.
+type entWebhookStore struct {
+	client *coreent.Client
+}
+
+func webhookScopePrefix(tenantID *uuid.UUID) string {
+	if tenantID == nil {
+		return webhookScopeKeyPrefix + platformWebhookScope + ":"
+	}
+	return webhookScopeKeyPrefix + tenantID.String() + ":"
+}
+
+func webhookScopeKey(endpoint shared.WebhookEndpoint) string {
+	return webhookScopePrefix(endpoint.TenantID) + endpoint.ID.String()
+}
+
+func webhookDeliveryKey(id uuid.UUID) string {
+	return webhookDeliveryKeyPrefix + id.String()
+}
+
+func webhookDeliveryScopePrefix(endpointID uuid.UUID) string {
+	return webhookDeliveryScopeKeyPrefix + endpointID.String() + ":"
+}
+
+func webhookDeliveryScopeKey(delivery shared.WebhookDelivery) string {
+	return webhookDeliveryScopePrefix(delivery.EndpointID) + delivery.ID.String()
+}
+
+func webhookTimeKey(prefix string, at time.Time) string {
+	return prefix + at.UTC().Format(webhookTimeFormat)
+}
+
+// webhookQueueKey returns the queue key of a pending delivery, or "" when
+// the delivery is not queued.
+func webhookQueueKey(delivery shared.WebhookDelivery) string {
+	if delivery.Status != shared.WebhookDeliveryPending || delivery.NextAttemptAt == nil {
+		return ""
+	}
+	return webhookTimeKey(webhookQueueKeyPrefix, *delivery.NextAttemptAt) + ":" + delivery.ID.String()
+}
+
+// webhookFinishedKey returns the finished key of a delivered or dead
+// delivery, or "" when the delivery is pending. It names the endpoint, so
+// that pruning finds the scope entry without reading the delivery.
+func webhookFinishedKey(delivery shared.WebhookDelivery) string {
+	if delivery.Status == shared.WebhookDeliveryPending {
+		return ""
+	}
+	return webhookTimeKey(webhookFinishedKeyPrefix, delivery.UpdatedAt) + ":" + delivery.EndpointID.String() + ":" + delivery.ID.String()
+}
+
+// PutEndpoint writes the endpoint and its scope entry in one transaction.
+func (s *entWebhookStore) PutEndpoint(ctx context.Context, endpoint shared.WebhookEndpoint) error {
+	value, err := json.Marshal(endpoint)
+	if err != nil {
+		return fmt.Errorf("encode tenant webhook: %w", err)
+	}
+	return s.inTx(ctx, func(client *coreent.Client) error {
+		if err := putConfig(ctx, client, webhookKeyPrefix+endpoint.ID.String(), string(value), "tenant webhook"); err != nil {
+			return fmt.Errorf("update tenant webhook: %w", err)
+		}
+		if err := putConfig(ctx, client, webhookScopeKey(endpoint), string(value), "tenant webhook scope"); err != nil {
+			return fmt.Errorf("update tenant webhook scope: %w", err)
+		}
+		return nil
+	})
+}
+
+func (s *entWebhookStore) GetEndpoint(ctx context.Context, id uuid.UUID) (*shared.WebhookEndpoint, error) {
+	return getEndpoint(ctx, s.client, id)
+}
+
+func getEndpoint(ctx context.Context, client *coreent.Client, id uuid.UUID) (*shared.WebhookEndpoint, error) {
+	var endpoint shared.WebhookEndpoint
+	found, err := getConfig(ctx, client, systemconfig.Key(webhookKeyPrefix+id.String()), &endpoint)
+	if err != nil || !found {
+		return nil, err
+	}
+	return &endpoint, nil
+}
+
+func (s *entWebhookStore) ListEndpoints(ctx context.Context, tenantID *uuid.UUID) ([]shared.WebhookEndpoint, error) {
+	rows, err := s.list(ctx, webhookScopePrefix(tenantID))
+	if err != nil {
+		return nil, err
+	}
+	endpoints := make([]shared.WebhookEndpoint, 0, len(rows))
+	for _, row := range rows {
+		var endpoint shared.WebhookEndpoint
+		if err := json.Unmarshal([]byte(row.Value), &endpoint); err != nil {
+			return nil, fmt.Errorf("decode webhook %s: %w", row.Key, err)
+		}
+		endpoints = append(endpoints, endpoint)
+	}
+	return endpoints, nil
+}
+
+// DeleteEndpoint removes the endpoint, its scope entry, its deliveries and
+// their index entries in one transaction.
+func (s *entWebhookStore) DeleteEndpoint(ctx context.Context, id uuid.UUID) error {
+	return s.inTx(ctx, func(client *coreent.Client) error {
+		endpoint, err := getEndpoint(ctx, client, id)
+		if err != nil || endpoint == nil {
+			return err
+		}
+		deliveries, err := listDeliveries(ctx, client, id)
+		if err != nil {
+			return err
+		}
+		keys := []string{webhookKeyPrefix + id.String(), webhookScopeKey(*endpoint)}
+		for _, delivery := range deliveries {
+			keys = append(keys, webhookDeliveryKey(delivery.ID))
+			if key := webhookQueueKey(delivery); key != "" {
+				keys = append(keys, key)
+			}
+			if key := webhookFinishedKey(delivery); key != "" {
+				keys = append(keys, key)
+			}
+		}
+		_, err = client.SystemConfig.Delete().
+			Where(systemconfig.Or(
+				systemconfig.KeyIn(keys...),
+				systemconfig.KeyHasPrefix(webhookDeliveryScopePrefix(id)),
+			)).
+			Exec(ctx)
+		return err
+	})
+}
+
+// PutDelivery stores the delivery and moves its index entries in the same
+// transaction: pending deliveries are queued at NextAttemptAt, others are
+// removed from the queue and indexed by the time they finished.
+func (s *entWebhookStore) PutDelivery(ctx context.Context, delivery shared.WebhookDelivery) error {
+	return s.inTx(ctx, func(client *coreent.Client) error {
+		var current *shared.WebhookDelivery
+		var stored shared.WebhookDelivery
+		found, err := getConfig(ctx, client, systemconfig.Key(webhookDeliveryKey(delivery.ID)), &stored)
+		if err != nil {
+			return err
+		}
+		if found {
+			current = &stored
+		}
+		return putDelivery(ctx, client, current, delivery)
+	})
+}
+
+// putDelivery writes delivery over current, which is nil for a new
+// delivery, and moves the index entries that changed.
+func putDelivery(ctx context.Context, client *coreent.Client, current *shared.WebhookDelivery, delivery shared.WebhookDelivery) error {
+	value, err := json.Marshal(delivery)
+	if err != nil {
+		return fmt.Errorf("encode tenant webhook delivery: %w", err)
+	}
+	id := delivery.ID.String()
+	var stale, added []string
+	for _, index := range []func(shared.WebhookDelivery) string{webhookQueueKey, webhookFinishedKey} {
+		oldKey, newKey := "", index(delivery)
+		if current != nil {
+			oldKey = index(*current)
+		}
+		if oldKey != "" && oldKey != newKey {
+			stale = append(stale, oldKey)
+		}
+		if newKey != "" && newKey != oldKey {
+			added = append(added, newKey)
+		}
+	}
+	if current == nil {
+		added = append(added, webhookDeliveryScopeKey(delivery))
+	}
+	if len(stale) > 0 {
+		if _, err := client.SystemConfig.Delete().Where(systemconfig.KeyIn(stale...)).Exec(ctx); err != nil {
+			return fmt.Errorf("unindex webhook delivery: %w", err)
+		}
+	}
+	if err := putConfig(ctx, client, webhookDeliveryKey(delivery.ID), string(value), "tenant webhook delivery"); err != nil {
+		return fmt.Errorf("update tenant webhook delivery: %w", err)
+	}
+	for _, key := range added {
+		if err := putConfig(ctx, client, key, id, "tenant webhook delivery index entry"); err != nil {
+			return fmt.Errorf("index webhook delivery: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *entWebhookStore) GetDelivery(ctx context.Context, id uuid.UUID) (*shared.WebhookDelivery, error) {
+	var delivery shared.WebhookDelivery
+	found, err := getConfig(ctx, s.client, systemconfig.Key(webhookDeliveryKey(id)), &delivery)
+	if err != nil || !found {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+func (s *entWebhookStore) ListDeliveries(ctx context.Context, endpointID uuid.UUID) ([]shared.WebhookDelivery, error) {
+	return listDeliveries(ctx, s.client, endpointID)
+}
+
+// listDeliveries reads the delivery IDs of an endpoint from its scope
+// entries and the deliveries by key.
+func listDeliveries(ctx context.Context, client *coreent.Client, endpointID uuid.UUID) ([]shared.WebhookDelivery, error) {
+	ids, err := client.SystemConfig.Query().
+		Where(
+			systemconfig.KeyHasPrefix(webhookDeliveryScopePrefix(endpointID)),
+			systemconfig.DeletedAtIsNil(),
+		).
+		Select(systemconfig.FieldValue).
+		Strings(ctx)
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = webhookDeliveryKeyPrefix + id
+	}
+	rows, err := client.SystemConfig.Query().
+		Where(
+			systemconfig.KeyIn(keys...),
+			systemconfig.DeletedAtIsNil(),
+		).
+		All(ctx)
+	if err != nil {
+		return nil, err
+	}
+	deliveries := make([]shared.WebhookDelivery, 0, len(rows))
+	for _, row := range rows {
+		var delivery shared.WebhookDelivery
+		if err := json.Unmarshal([]byte(row.Value), &delivery); err != nil {
+			return nil, fmt.Errorf("decode webhook delivery %s: %w", row.Key, err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, nil
+}
+
+// ClaimDueDeliveries reads the due queue entries in due order and moves
+// each to until with an update conditional on the entry still having the
+// key that was read, so that of several concurrent workers only one claims
+// a delivery. The claimed delivery's NextAttemptAt is moved with it.
+func (s *entWebhookStore) ClaimDueDeliveries(ctx context.Context, now, until time.Time, limit int) ([]shared.WebhookDelivery, error) {
+	q := s.client.SystemConfig.Query().
+		Where(
+			systemconfig.KeyGT(webhookQueueKeyPrefix),
+			systemconfig.KeyLT(webhookTimeKey(webhookQueueKeyPrefix, now.Add(time.Nanosecond))),
+		).
+		Order(coreent.Asc(systemconfig.FieldKey))
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+	rows, err := q.All(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []shared.WebhookDelivery
+	for _, row := range rows {
+		var delivery shared.WebhookDelivery
+		claimed := false
+		err := s.inTx(ctx, func(client *coreent.Client) error {
+			found, err := getConfig(ctx, client, systemconfig.Key(webhookDeliveryKeyPrefix+row.Value), &delivery)
+			if err != nil || !found {
+				return err
+			}
+			delivery.NextAttemptAt = &until
+			n, err := client.SystemConfig.Update().
+				Where(systemconfig.ID(row.ID), systemconfig.Key(row.Key)).
+				SetKey(webhookQueueKey(delivery)).
+				Save(ctx)
+			if err != nil || n == 0 {
+				// Claimed or completed by another worker.
+				return err
+			}
+			value, err := json.Marshal(delivery)
+			if err != nil {
+				return fmt.Errorf("encode tenant webhook delivery: %w", err)
+			}
+			if err := client.SystemConfig.Update().
+				Where(systemconfig.Key(webhookDeliveryKey(delivery.ID))).
+				SetValue(string(value)).
+				Exec(ctx); err != nil {
+				return fmt.Errorf("update tenant webhook delivery: %w", err)
+			}
+			claimed = true
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("claim webhook delivery: %w", err)
+		}
+		if claimed {
+			out = append(out, delivery)
+		}
+	}
+	return out, nil
+}
+
+// DeleteFinishedDeliveries reads the finished entries in order up to
+// finishedBefore and deletes them with their deliveries and scope entries,
+// without reading the deliveries.
+func (s *entWebhookStore) DeleteFinishedDeliveries(ctx context.Context, finishedBefore time.Time) (int, error) {
+	removed := 0
+	err := s.inTx(ctx, func(client *coreent.Client) error {
+		finished, err := client.SystemConfig.Query().
+			Where(
+				systemconfig.KeyGT(webhookFinishedKeyPrefix),
+				systemconfig.KeyLT(webhookTimeKey(webhookFinishedKeyPrefix, finishedBefore)),
+			).
+			Select(systemconfig.FieldKey).
+			Strings(ctx)
+		if err != nil || len(finished) == 0 {
+			return err
+		}
+		keys := make([]string, 0, 3*len(finished))
+		for _, key := range finished {
+			// <prefix><finished at>:<endpoint ID>:<delivery ID>
+			parts := strings.Split(strings.TrimPrefix(key, webhookFinishedKeyPrefix), ":")
+			if len(parts) != 3 {
+				return fmt.Errorf("decode webhook finished entry %s", key)
+			}
+			keys = append(keys,
+				key,
+				webhookDeliveryKeyPrefix+parts[2],
+				webhookDeliveryScopeKeyPrefix+parts[1]+":"+parts[2],
+			)
+		}
+		if _, err := client.SystemConfig.Delete().Where(systemconfig.KeyIn(keys...)).Exec(ctx); err != nil {
+			return err
+		}
+		removed = len(finished)
+		return nil
+	})
+	return removed, err
+}
+
+// getConfig decodes the entry matching where into v.
+func getConfig(ctx context.Context, client *coreent.Client, where predicate.SystemConfig, v any) (bool, error) {
+	row, err := client.SystemConfig.Query().
+		Where(where, systemconfig.DeletedAtIsNil()).
+		Only(ctx)
+	if err != nil {
+		if coreent.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	if err := json.Unmarshal([]byte(row.Value), v); err != nil {
+		return false, fmt.Errorf("decode %s: %w", row.Key, err)
+	}
+	return true, nil
+}
+
+// inTx runs fn with a transactional client and commits unless fn fails.
+func (s *entWebhookStore) inTx(ctx context.Context, fn func(client *coreent.Client) error) error {
+	tx, err := s.client.Tx(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	if err := fn(tx.Client()); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *entWebhookStore) list(ctx context.Context, prefix string) ([]*coreent.SystemConfig, error) {
+	return s.client.SystemConfig.Query().
+		Where(
+			systemconfig.KeyHasPrefix(prefix),
+			systemconfig.DeletedAtIsNil(),
+		).
+		All(ctx)
+}
+
+var _ shared.WebhookStore = (*entWebhookStore)(nil)
@@ -0,0 +1,162 @@
+//go:build integration
+// +build integration
+
+package factory
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"entgo.io/ent/dialect"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/leeforge/core/server/ent/enttest"
+	"github.com/leeforge/core/server/ent/systemconfig"
+
+	"github.com/leeforge/plugins/tenant/shared"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestEntWebhookStore_QueueAndClaim(t *testing.T) {
+	client := enttest.Open(t, dialect.SQLite, "file:tenant_webhooks?mode=memory&cache=shared&_fk=1")
+	t.Cleanup(func() { _ = client.Close() })
+
+	ctx := context.Background()
+	store := NewEntFactory(client).Webhooks()
+	now := time.Now().UTC().Truncate(time.Second)
+	tenantID := uuid.New()
+
+	endpoint := shared.WebhookEndpoint{ID: uuid.New(), TenantID: &tenantID, URL: "https://example.com/hook", Events: []string{"*"}, Secret: "whsec_x"}
+	require.NoError(t, store.PutEndpoint(ctx, endpoint))
+	got, err := store.GetEndpoint(ctx, endpoint.ID)
+	require.NoError(t, err)
+	require.Equal(t, endpoint.URL, got.URL)
+	missing, err := store.GetEndpoint(ctx, uuid.New())
+	require.NoError(t, err)
+	require.Nil(t, missing)
+
+	delivery := func(due time.Time) shared.WebhookDelivery {
+		return shared.WebhookDelivery{
+			ID:            uuid.New(),
+			EndpointID:    endpoint.ID,
+			EventID:       uuid.New(),
+			Event:         shared.EventTenantCreated,
+			Body:          json.RawMessage(`{"event":"tenant.created"}`),
+			Status:        shared.WebhookDeliveryPending,
+			NextAttemptAt: &due,
+		}
+	}
+	due, later := delivery(now.Add(-time.Minute)), delivery(now.Add(time.Hour))
+	require.NoError(t, store.PutDelivery(ctx, due))
+	require.NoError(t, store.PutDelivery(ctx, later))
+
+	// A claimed delivery is hidden from other workers until the claim ends.
+	claimed, err := store.ClaimDueDeliveries(ctx, now, now.Add(time.Minute), 10)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+	require.Equal(t, due.ID, claimed[0].ID)
+	claimed, err = store.ClaimDueDeliveries(ctx, now, now.Add(time.Minute), 10)
+	require.NoError(t, err)
+	require.Empty(t, claimed)
+
+	// Completed deliveries leave the queue but stay in the log.
+	due.Status, due.NextAttemptAt = shared.WebhookDeliveryDelivered, nil
+	require.NoError(t, store.PutDelivery(ctx, due))
+	claimed, err = store.ClaimDueDeliveries(ctx, now.Add(2*time.Hour), now.Add(3*time.Hour), 10)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+	require.Equal(t, later.ID, claimed[0].ID)
+	stored, err := store.GetDelivery(ctx, due.ID)
+	require.NoError(t, err)
+	require.Equal(t, shared.WebhookDeliveryDelivered, stored.Status)
+
+	require.NoError(t, store.DeleteEndpoint(ctx, endpoint.ID))
+	log, err := store.ListDeliveries(ctx, endpoint.ID)
+	require.NoError(t, err)
+	require.Empty(t, log)
+	claimed, err = store.ClaimDueDeliveries(ctx, now.Add(24*time.Hour), now.Add(25*time.Hour), 10)
+	require.NoError(t, err)
+	require.Empty(t, claimed)
+	left, err := client.SystemConfig.Query().Where(systemconfig.KeyHasPrefix("tenant.webhook")).Count(ctx)
+	require.NoError(t, err)
+	require.Zero(t, left, "deleting the endpoint removes every entry of its deliveries")
+}
+
+func TestEntWebhookStore_ScopesOrderAndPruning(t *testing.T) {
+	client := enttest.Open(t, dialect.SQLite, "file:tenant_webhooks_scopes?mode=memory&cache=shared&_fk=1")
+	t.Cleanup(func() { _ = client.Close() })
+
+	ctx := context.Background()
+	store := NewEntFactory(client).Webhooks()
+	now := time.Now().UTC().Truncate(time.Second)
+	acme, globex := uuid.New(), uuid.New()
+
+	platform := shared.WebhookEndpoint{ID: uuid.New(), URL: "https://example.com/platform", Events: []string{"*"}}
+	acmeHook := shared.WebhookEndpoint{ID: uuid.New(), TenantID: &acme, URL: "https://example.com/acme", Events: []string{"*"}}
+	globexHook := shared.WebhookEndpoint{ID: uuid.New(), TenantID: &globex, URL: "https://example.com/globex", Events: []string{"*"}}
+	for _, e := range []shared.WebhookEndpoint{platform, acmeHook, globexHook} {
+		require.NoError(t, store.PutEndpoint(ctx, e))
+	}
+	acmeHook.Description = "renamed"
+	require.NoError(t, store.PutEndpoint(ctx, acmeHook))
+
+	listed, err := store.ListEndpoints(ctx, nil)
+	require.NoError(t, err)
+	require.Len(t, listed, 1)
+	require.Equal(t, platform.ID, listed[0].ID)
+	listed, err = store.ListEndpoints(ctx, &acme)
+	require.NoError(t, err)
+	require.Len(t, listed, 1)
+	require.Equal(t, "renamed", listed[0].Description)
+
+	// Due deliveries are claimed oldest first, up to the limit.
+	var queued []shared.WebhookDelivery
+	for i := range 3 {
+		due := now.Add(-time.Duration(i+1) * time.Minute)
+		d := shared.WebhookDelivery{ID: uuid.New(), EndpointID: acmeHook.ID, Status: shared.WebhookDeliveryPending, NextAttemptAt: &due}
+		require.NoError(t, store.PutDelivery(ctx, d))
+		queued = append(queued, d)
+	}
+	claimed, err := store.ClaimDueDeliveries(ctx, now, now.Add(time.Minute), 2)
+	require.NoError(t, err)
+	require.Len(t, claimed, 2)
+	require.Equal(t, queued[2].ID, claimed[0].ID)
+	require.Equal(t, queued[1].ID, claimed[1].ID)
+	require.Equal(t, now.Add(time.Minute), claimed[0].NextAttemptAt.UTC(), "claims move the delivery with its queue entry")
+
+	// Finished deliveries are pruned after the retention; pending ones stay.
+	finished := claimed[0]
+	finished.Status, finished.NextAttemptAt, finished.UpdatedAt = shared.WebhookDeliveryDelivered, nil, now.Add(-time.Hour)
+	require.NoError(t, store.PutDelivery(ctx, finished))
+	removed, err := store.DeleteFinishedDeliveries(ctx, now.Add(time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, 1, removed)
+	log, err := store.ListDeliveries(ctx, acmeHook.ID)
+	require.NoError(t, err)
+	require.Len(t, log, 2)
+	pruned, err := store.GetDelivery(ctx, finished.ID)
+	require.NoError(t, err)
+	require.Nil(t, pruned)
+	left, err := client.SystemConfig.Query().Where(systemconfig.KeyHasPrefix(webhookFinishedKeyPrefix)).Count(ctx)
+	require.NoError(t, err)
+	require.Zero(t, left)
+
+	// A finished delivery that is queued again leaves the finished index.
+	stored, err := store.GetDelivery(ctx, claimed[1].ID)
+	require.NoError(t, err)
+	redelivered := *stored
+	redelivered.Status, redelivered.UpdatedAt = shared.WebhookDeliveryDead, now.Add(-time.Hour)
+	require.NoError(t, store.PutDelivery(ctx, redelivered))
+	redelivered.Status, redelivered.NextAttemptAt = shared.WebhookDeliveryPending, &now
+	require.NoError(t, store.PutDelivery(ctx, redelivered))
+	removed, err = store.DeleteFinishedDeliveries(ctx, now.Add(time.Minute))
+	require.NoError(t, err)
+	require.Zero(t, removed)
+	claimed, err = store.ClaimDueDeliveries(ctx, now, now.Add(time.Minute), 10)
+	require.NoError(t, err)
+	require.Len(t, claimed, 2)
+}
@@ -0,0 +1,126 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/leeforge/plugins/health"
+)
+
+// slowProbeThreshold degrades a dependency that answers but is slow.
+const slowProbeThreshold = 500 * time.Millisecond
+
+// pinger is implemented by dependencies that offer a cheap, side-effect-free
+// reachability probe. Dependencies without one are ready once configured.
+type pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// Liveness reports whether the plugin is initialized and able to serve.
+func (p *TenantPlugin) Liveness(ctx context.Context) *health.Report {
+	return health.Run(ctx, p.Name(), health.KindLiveness, []health.Check{
+		{
+			Name:     "service",
+			Critical: true,
+			Probe: func(context.Context) error {
+				if p.service() == nil {
+					return errors.New("tenant service not initialized")
+				}
+				return nil
+			},
+		},
+	})
+}
+
+// Readiness reports the state of every dependency the plugin needs to
+// serve requests, one entry per dependency.
+func (p *TenantPlugin) Readiness(ctx context.Context) *health.Report {
+	return health.Run(ctx, p.Name(), health.KindReadiness, []health.Check{
+		{
+			Name:     "lifecycle",
+			Critical: true,
+			Probe: func(context.Context) error {
+				if p.lc.isStopping() {
+					return errors.New("plugin is shutting down")
+				}
+				return nil
+			},
+		},
+		{
+			Name:          "database",
+			Critical:      true,
+			SlowThreshold: slowProbeThreshold,
+			Probe: func(ctx context.Context) error {
+				svc := p.service()
+				if svc == nil {
+					return errors.New("tenant service not initialized")
+				}
+				return svc.Ping(ctx)
+			},
+		},
+		{
+			Name:          "domain_writer",
+			Critical:      true,
+			SlowThreshold: slowProbeThreshold,
+			Probe:         p.probeDomainWriter,
+		},
+		{
+			Name:  "event_bus",
+			Probe: p.probeEventBus,
+		},
+		{
+			Name:  "outbox",
+			Probe: p.probeOutbox,
+		},
+		{
+			Name:          "cache",
+			SlowThreshold: slowProbeThreshold,
+			Probe:         p.probeCache,
+		},
+	})
+}
+
+func (p *TenantPlugin) probeDomainWriter(ctx context.Context) error {
+	domainSvc := p.requestDeps().domainSvc
+	if domainSvc == nil {
+		return errors.New("domain service not resolved")
+	}
+	if d, ok := domainSvc.(pinger); ok {
+		return d.Ping(ctx)
+	}
+	return nil
+}
+
+func (p *TenantPlugin) probeEventBus(ctx context.Context) error {
+	if p.events == nil {
+		return health.ErrNotConfigured
+	}
+	if b, ok := p.events.(pinger); ok {
+		return b.Ping(ctx)
+	}
+	return nil
+}
+
+func (p *TenantPlugin) probeOutbox(ctx context.Context) error {
+	monitor, ok := p.factory.(OutboxMonitor)
+	if !ok {
+		return health.ErrNotConfigured
+	}
+	backlog, err := monitor.OutboxBacklog(ctx)
+	if err != nil {
+		return fmt.Errorf("read outbox backlog: %w", err)
+	}
+	if limit := p.config().OutboxBacklogWarn; backlog > limit {
+		return health.Degraded("outbox backlog %d exceeds %d", backlog, limit)
+	}
+	return nil
+}
+
+func (p *TenantPlugin) probeCache(ctx context.Context) error {
+	if p.redis == nil {
+		return health.ErrNotConfigured
+	}
+	return p.redis.Ping(ctx).Err()
+}
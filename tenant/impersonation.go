@@ -0,0 +1,123 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/leeforge/framework/http/responder"
+	"github.com/leeforge/framework/plugin"
+
+	"github.com/leeforge/core"
+	coremod "github.com/leeforge/core/core"
+	"github.com/leeforge/core/server/httplog"
+
+	"github.com/leeforge/plugins/tenant/shared"
+	tenantmod "github.com/leeforge/plugins/tenant/tenant"
+)
+
+// WrapImpersonationEventBus marks the payload of every event published while
+// impersonating. Hosts wrap their shared event bus with it so that events of
+// every plugin are marked; the tenant service marks its own events.
+func WrapImpersonationEventBus(bus plugin.EventBus) plugin.EventBus {
+	return tenantmod.WrapImpersonationEventBus(bus)
+}
+
+// impersonationSession returns the session of the impersonation token sent
+// with r, or nil when there is none. The token must belong to the caller.
+func (p *TenantPlugin) impersonationSession(ctx context.Context, r *http.Request) (*shared.ImpersonationSession, error) {
+	token := r.Header.Get(p.config().ImpersonationHeader)
+	svc := p.service()
+	if token == "" || svc == nil {
+		return nil, nil
+	}
+	userID, _ := core.GetUserID(ctx)
+	return svc.ResolveImpersonation(ctx, token, userID)
+}
+
+// ImpersonationMiddleware applies the impersonation token of a request, if
+// any. It runs after domain resolution: the acting context is switched to
+// the tenant domain of the session and flagged as impersonating, with the
+// platform user as actor, and every impersonated request is recorded in the
+// impersonation audit log before it is served; requests whose record cannot
+// be written get 500. Invalid, expired and revoked tokens are rejected with
+// 401.
+func (p *TenantPlugin) ImpersonationMiddleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			session, err := p.impersonationSession(ctx, r)
+			switch {
+			case errors.Is(err, shared.ErrImpersonationNotFound), errors.Is(err, shared.ErrImpersonationInactive):
+				responder.Unauthorized(w, r, "Invalid impersonation token")
+				return
+			case err != nil:
+				httplog.Error(p.logger, r, "Failed to resolve impersonation", err)
+				responder.DatabaseError(w, r, "Failed to resolve impersonation")
+				return
+			case session == nil:
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ac, err := p.impersonationActingContext(ctx, session)
+			if err != nil {
+				httplog.Error(p.logger, r, "Failed to resolve impersonated domain", err)
+				responder.DatabaseError(w, r, "Failed to resolve impersonation")
+				return
+			}
+			ctx = coremod.WithActingContext(ctx, ac)
+			ctx = tenantmod.WithImpersonation(ctx, session)
+
+			audit := shared.ImpersonationAudit{
+				Method:    r.Method,
+				Path:      r.URL.Path,
+				IPAddress: remoteIP(r),
+				UserAgent: r.UserAgent(),
+			}
+			if err := p.service().AuditImpersonation(ctx, session, audit); err != nil {
+				httplog.Error(p.logger, r, "Failed to audit impersonated request", err)
+				responder.DatabaseError(w, r, "Failed to audit impersonation")
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// impersonationActingContext returns a copy of the acting context of ctx
+// pointed at the session's tenant domain and flagged as impersonating.
+func (p *TenantPlugin) impersonationActingContext(ctx context.Context, session *shared.ImpersonationSession) (*coremod.ActingContext, error) {
+	ac := &coremod.ActingContext{}
+	if cur := coremod.GetActingContext(ctx); cur != nil {
+		*ac = *cur
+	}
+	if ac.Domain == nil || ac.Domain.DomainID != session.DomainID {
+		dom, err := p.requestDeps().domainSvc.ResolveDomainByID(ctx, session.DomainID)
+		if err != nil {
+			return nil, fmt.Errorf("resolve domain: %w", err)
+		}
+		ac.Domain = &coremod.ResolvedDomain{
+			DomainID:    dom.DomainID,
+			TypeCode:    dom.TypeCode,
+			Key:         dom.Key,
+			DisplayName: dom.DisplayName,
+		}
+	}
+	ac.ActorID = session.ActorID
+	ac.IsImpersonating = true
+	ac.ImpersonateReason = session.Reason
+	ac.ImpersonateExpiry = session.ExpiresAt
+	return ac, nil
+}
+
+// remoteIP returns the host part of the request's remote address.
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
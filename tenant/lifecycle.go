@@ -0,0 +1,161 @@
+package tenant
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/leeforge/framework/http/responder"
+	"github.com/leeforge/framework/plugin"
+)
+
+// lifecycle tracks event subscriptions, background workers and in-flight
+// requests so that Disable can shut the plugin down cleanly. The zero value
+// is ready to use and accepts work until stop is called.
+type lifecycle struct {
+	mu       sync.Mutex
+	stopping bool
+	active   int
+	idle     chan struct{}
+	subs     []plugin.Subscription
+	cancel   context.CancelFunc
+	workCtx  context.Context
+}
+
+// start (re)opens the lifecycle for new work after Enable.
+func (l *lifecycle) start() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.cancel != nil {
+		l.cancel()
+	}
+	l.stopping = false
+	l.workCtx, l.cancel = context.WithCancel(context.Background())
+}
+
+// isStopping reports whether stop has been called since the last start.
+func (l *lifecycle) isStopping() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.stopping
+}
+
+// acquire registers a unit of in-flight work. It returns false once the
+// lifecycle is stopping, in which case the caller must not proceed.
+func (l *lifecycle) acquire() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.stopping {
+		return false
+	}
+	l.active++
+	return true
+}
+
+// release marks a unit of work acquired via acquire as finished.
+func (l *lifecycle) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.active--
+	if l.active == 0 && l.idle != nil {
+		close(l.idle)
+		l.idle = nil
+	}
+}
+
+// subscribe registers handler on bus and keeps the subscription so that it
+// can be released on stop. Events delivered while stopping are dropped.
+func (l *lifecycle) subscribe(bus plugin.EventBus, topic string, handler plugin.EventHandler) {
+	sub := bus.Subscribe(topic, func(ctx context.Context, e plugin.Event) error {
+		if !l.acquire() {
+			return nil
+		}
+		defer l.release()
+		return handler(ctx, e)
+	})
+
+	l.mu.Lock()
+	l.subs = append(l.subs, sub)
+	l.mu.Unlock()
+}
+
+// unsubscribeAll releases every tracked subscription.
+func (l *lifecycle) unsubscribeAll() {
+	l.mu.Lock()
+	subs := l.subs
+	l.subs = nil
+	l.mu.Unlock()
+
+	for _, sub := range subs {
+		if sub != nil {
+			sub.Unsubscribe()
+		}
+	}
+}
+
+// goWorker runs fn in a background goroutine. The context passed to fn is
+// cancelled when the lifecycle stops; stop waits for fn to return.
+func (l *lifecycle) goWorker(fn func(ctx context.Context)) bool {
+	l.mu.Lock()
+	ctx := l.workCtx
+	l.mu.Unlock()
+	if ctx == nil || !l.acquire() {
+		return false
+	}
+
+	go func() {
+		defer l.release()
+		fn(ctx)
+	}()
+	return true
+}
+
+// middleware rejects requests with 503 while the plugin is shutting down
+// and tracks accepted requests as in-flight work.
+func (l *lifecycle) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !l.acquire() {
+			responder.ServiceUnavailable(w, r, "Tenant plugin is shutting down")
+			return
+		}
+		defer l.release()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// stop rejects new work, unsubscribes all handlers, cancels background
+// workers and waits for in-flight work until ctx is done.
+func (l *lifecycle) stop(ctx context.Context) error {
+	l.mu.Lock()
+	l.stopping = true
+	cancel := l.cancel
+	l.cancel = nil
+	l.workCtx = nil
+	var idle chan struct{}
+	if l.active > 0 {
+		if l.idle == nil {
+			l.idle = make(chan struct{})
+		}
+		idle = l.idle
+	}
+	l.mu.Unlock()
+
+	l.unsubscribeAll()
+	if cancel != nil {
+		cancel()
+	}
+
+	if idle == nil {
+		return nil
+	}
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("wait for in-flight work: %w", ctx.Err())
+	}
+}
@@ -0,0 +1,37 @@
+package tenant
+
+import (
+	"net/http"
+
+	"github.com/leeforge/plugins/health"
+	"github.com/leeforge/plugins/idempotency"
+	"github.com/leeforge/plugins/openapi"
+	tenantmod "github.com/leeforge/plugins/tenant/tenant"
+)
+
+// OpenAPI returns the OpenAPI document of the routes RegisterRoutes mounts.
+// It is served from GET /tenants/openapi.json.
+func (p *TenantPlugin) OpenAPI() *openapi.Document {
+	b := openapi.NewBuilder(openapi.Info{
+		Title:       "Leeforge tenant plugin API",
+		Description: "Multi-tenancy management: tenants, members, roles, provisioning, impersonation, service accounts and webhooks.",
+		Version:     p.Version(),
+	}).
+		Server("..", "The API prefix the plugin routes are mounted under").
+		Tag("TenantPlugin-Health", "Liveness and readiness probes")
+
+	b.Add(
+		openapi.Route{Method: http.MethodGet, Path: "/tenants/health/live", ID: "TenantLiveness",
+			Summary: "Liveness probe", Tags: []string{"TenantPlugin-Health"}, Response: health.Report{},
+			Others: map[int]any{http.StatusServiceUnavailable: health.Report{}}},
+		openapi.Route{Method: http.MethodGet, Path: "/tenants/health/ready", ID: "TenantReadiness",
+			Summary: "Readiness probe", Tags: []string{"TenantPlugin-Health"}, Response: health.Report{},
+			Others: map[int]any{http.StatusServiceUnavailable: health.Report{}}},
+		openapi.Route{Method: http.MethodGet, Path: "/tenants/openapi.json", ID: "GetTenantOpenAPI",
+			Summary: "This OpenAPI document", Response: map[string]any{}, Raw: true},
+	)
+	// The lifecycle middleware answers 503 while the plugin shuts down.
+	b.Decorate(idempotency.Describe)
+	tenantmod.DescribeRoutes(b, http.StatusServiceUnavailable)
+	return b.Document()
+}
@@ -2,19 +2,30 @@ package tenant
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"sync/atomic"
+	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/go-redis/redis/v8"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
 
+	"github.com/leeforge/framework/http/responder"
 	"github.com/leeforge/framework/logging"
 	"github.com/leeforge/framework/plugin"
 
 	"github.com/leeforge/core"
+	"github.com/leeforge/plugins/health"
+	"github.com/leeforge/plugins/idempotency"
+	"github.com/leeforge/plugins/metrics"
+	"github.com/leeforge/plugins/openapi"
+	organizationmod "github.com/leeforge/plugins/ou/organization"
 	"github.com/leeforge/plugins/tenant/shared"
 	tenantmod "github.com/leeforge/plugins/tenant/tenant"
+	"github.com/leeforge/plugins/tracing"
 )
 
 // Re-export shared types so external consumers can import from this package.
@@ -23,8 +34,67 @@ type (
 	TenantInfo       = shared.TenantInfo
 	TenantEventData  = shared.TenantEventData
 	MemberEventData  = shared.MemberEventData
+	Config           = shared.Config
+	MemberTerm       = shared.MemberTerm
+	RoleTemplate     = shared.RoleTemplate
+	RoleSyncResult   = shared.RoleSyncResult
+	RoleInfo         = shared.RoleInfo
+	TenantTemplate   = shared.TenantTemplate
+
+	ProvisionEventData = shared.ProvisionEventData
+	CloneEventData     = shared.CloneEventData
+
+	ImpersonationSession   = shared.ImpersonationSession
+	ImpersonationMark      = shared.ImpersonationMark
+	ImpersonationEventData = shared.ImpersonationEventData
+
+	ServiceAccount          = shared.ServiceAccount
+	APIKey                  = shared.APIKey
+	ServiceAccountEventData = shared.ServiceAccountEventData
+
+	TenantAttributes = shared.TenantAttributes
+	LabelSelector    = shared.LabelSelector
+
+	WebhookEndpoint = shared.WebhookEndpoint
+	WebhookDelivery = shared.WebhookDelivery
+	WebhookAttempt  = shared.WebhookAttempt
+	WebhookMessage  = shared.WebhookMessage
 )
 
+// DefaultConfig returns the built-in tenant plugin settings.
+func DefaultConfig() Config { return shared.DefaultConfig() }
+
+// ParseLabelSelector parses a Kubernetes-style label selector, for matching
+// TenantInfo.Labels.
+func ParseLabelSelector(selector string) (LabelSelector, error) {
+	return shared.ParseLabelSelector(selector)
+}
+
+// Re-export webhook headers and delivery statuses.
+const (
+	WebhookHeaderEvent     = shared.WebhookHeaderEvent
+	WebhookHeaderDelivery  = shared.WebhookHeaderDelivery
+	WebhookHeaderTimestamp = shared.WebhookHeaderTimestamp
+	WebhookHeaderSignature = shared.WebhookHeaderSignature
+
+	WebhookAllEvents         = shared.WebhookAllEvents
+	WebhookDeliveryPending   = shared.WebhookDeliveryPending
+	WebhookDeliveryDelivered = shared.WebhookDeliveryDelivered
+	WebhookDeliveryDead      = shared.WebhookDeliveryDead
+)
+
+// SignWebhook returns the signature header value of a webhook body sent at
+// timestamp.
+func SignWebhook(secret string, timestamp int64, body []byte) string {
+	return shared.SignWebhook(secret, timestamp, body)
+}
+
+// VerifyWebhook checks the timestamp and signature headers of a received
+// webhook, for use by receivers.
+func VerifyWebhook(secret, timestamp, signature string, body []byte, tolerance time.Duration, now time.Time) error {
+	return shared.VerifyWebhook(secret, timestamp, signature, body, tolerance, now)
+}
+
 // Re-export sentinel errors.
 var (
 	ErrTenantNotFound      = shared.ErrTenantNotFound
@@ -34,26 +104,101 @@ var (
 	ErrMemberNotFound      = shared.ErrMemberNotFound
 	ErrPlatformDomainOnly  = shared.ErrPlatformDomainOnly
 	ErrParentTenantInvalid = shared.ErrParentTenantInvalid
+	ErrInvalidConfig       = shared.ErrInvalidConfig
+	ErrInvalidMemberTerm   = shared.ErrInvalidMemberTerm
+	ErrMemberSuspended     = shared.ErrMemberSuspended
+	ErrMemberNotSuspended  = shared.ErrMemberNotSuspended
+	ErrInvalidMemberFilter = shared.ErrInvalidMemberFilter
+	ErrInvalidRoleTemplate = shared.ErrInvalidRoleTemplate
+	ErrInvalidRole         = shared.ErrInvalidRole
+
+	ErrTenantTemplateNotFound = shared.ErrTenantTemplateNotFound
+	ErrTenantTemplateExists   = shared.ErrTenantTemplateExists
+	ErrInvalidTenantTemplate  = shared.ErrInvalidTenantTemplate
+	ErrProvisioningFailed     = shared.ErrProvisioningFailed
+	ErrOrganizationsDisabled  = shared.ErrOrganizationsDisabled
+	ErrInvalidArchive         = shared.ErrInvalidArchive
+
+	ErrImpersonationNotFound = shared.ErrImpersonationNotFound
+	ErrImpersonationInactive = shared.ErrImpersonationInactive
+	ErrInvalidImpersonation  = shared.ErrInvalidImpersonation
+
+	ErrServiceAccountNotFound = shared.ErrServiceAccountNotFound
+	ErrServiceAccountExists   = shared.ErrServiceAccountExists
+	ErrInvalidServiceAccount  = shared.ErrInvalidServiceAccount
+	ErrServiceAccountDisabled = shared.ErrServiceAccountDisabled
+	ErrAPIKeyNotFound         = shared.ErrAPIKeyNotFound
+	ErrAPIKeyInactive         = shared.ErrAPIKeyInactive
+
+	ErrInvalidLabels        = shared.ErrInvalidLabels
+	ErrInvalidLabelSelector = shared.ErrInvalidLabelSelector
+	ErrInvalidTenantFilter  = shared.ErrInvalidTenantFilter
+
+	ErrWebhookNotFound         = shared.ErrWebhookNotFound
+	ErrWebhookDeliveryNotFound = shared.ErrWebhookDeliveryNotFound
+	ErrInvalidWebhook          = shared.ErrInvalidWebhook
+	ErrTenantAdminRequired     = shared.ErrTenantAdminRequired
 )
 
 // Re-export event constants.
 const (
-	EventTenantCreated       = shared.EventTenantCreated
-	EventTenantUpdated       = shared.EventTenantUpdated
-	EventTenantDeleted       = shared.EventTenantDeleted
-	EventTenantMemberAdded   = shared.EventTenantMemberAdded
-	EventTenantMemberRemoved = shared.EventTenantMemberRemoved
+	EventTenantCreated           = shared.EventTenantCreated
+	EventTenantUpdated           = shared.EventTenantUpdated
+	EventTenantDeleted           = shared.EventTenantDeleted
+	EventTenantMemberAdded       = shared.EventTenantMemberAdded
+	EventTenantMemberRemoved     = shared.EventTenantMemberRemoved
+	EventTenantMemberExpired     = shared.EventTenantMemberExpired
+	EventTenantMemberSuspended   = shared.EventTenantMemberSuspended
+	EventTenantMemberReactivated = shared.EventTenantMemberReactivated
+	EventTenantProvisionProgress = shared.EventTenantProvisionProgress
+	EventTenantCloned            = shared.EventTenantCloned
+	EventTenantImported          = shared.EventTenantImported
+
+	EventTenantImpersonationStarted = shared.EventTenantImpersonationStarted
+	EventTenantImpersonationRevoked = shared.EventTenantImpersonationRevoked
+
+	EventTenantServiceAccountCreated = shared.EventTenantServiceAccountCreated
+	EventTenantServiceAccountUpdated = shared.EventTenantServiceAccountUpdated
+	EventTenantServiceAccountDeleted = shared.EventTenantServiceAccountDeleted
+	EventTenantAPIKeyCreated         = shared.EventTenantAPIKeyCreated
+	EventTenantAPIKeyRevoked         = shared.EventTenantAPIKeyRevoked
+	EventTenantAPIKeyRotated         = shared.EventTenantAPIKeyRotated
 )
 
 // TenantPlugin implements the framework plugin contracts.
+//
+// Config may be set by the host before Enable; the plugin's section of
+// AppContext.Config is overlaid on top, zero fields take their defaults and
+// the result is validated on Enable.
 type TenantPlugin struct {
-	logger    logging.Logger
-	factory   ServiceFactory
-	domainSvc core.DomainWriter
-	events    plugin.EventBus
+	Config *Config
+
+	logger  logging.Logger
+	factory ServiceFactory
+	events  plugin.EventBus
+	redis   *redis.Client
+
+	// cfg, deps, tenantSvc and tenantH are replaced on each Enable while
+	// routes, event handlers, domain resolution and the exported service
+	// keep running, so they are only accessed atomically.
+	cfg       atomic.Pointer[Config]
+	deps      atomic.Pointer[requestDeps]
+	tenantSvc atomic.Pointer[tenantmod.Service]
+	tenantH   atomic.Pointer[tenantmod.Handler]
+
+	exported   *tenantServiceAdapter
+	registered bool
+	lc         lifecycle
+}
 
-	tenantSvc *tenantmod.Service
-	tenantH   *tenantmod.Handler
+// requestDeps are the dependencies an Enable resolves for handling requests
+// and events.
+type requestDeps struct {
+	domainSvc core.DomainWriter
+	tracer    tracing.Tracer
+	// middleware wraps the tenant routes with the Enable's metrics recorder,
+	// tracer, idempotency store and idempotency TTL.
+	middleware chi.Middlewares
 }
 
 func (p *TenantPlugin) Name() string           { return "tenant" }
@@ -71,70 +216,276 @@ func (p *TenantPlugin) Enable(ctx context.Context, app *plugin.AppContext) error
 		p.logger = logging.FromZap(app.Logger)
 	}
 	p.events = app.Events
+	p.redis = app.Redis
+
+	cfg, err := p.loadConfig(app.Config)
+	if err != nil {
+		return err
+	}
+	rec, err := metrics.FromServices(app.Services)
+	if err != nil {
+		return fmt.Errorf("tenant plugin: %w", err)
+	}
 
 	factory, err := plugin.Resolve[ServiceFactory](app.Services, ServiceKeyTenantFactory)
 	if err != nil {
 		return fmt.Errorf("resolve tenant service factory: %w", err)
 	}
 	p.factory = factory
+	tracer := tracing.FromFactory(factory)
+
+	idemStore, err := idempotency.FromServices(app.Services)
+	if err != nil {
+		return fmt.Errorf("tenant plugin: %w", err)
+	}
 
 	domainSvc, err := plugin.Resolve[core.DomainWriter](app.Services, "domain.service")
 	if err != nil {
 		return fmt.Errorf("resolve domain service: %w", err)
 	}
-	p.domainSvc = domainSvc
 
-	p.tenantSvc = p.factory.NewTenantService(p.domainSvc, p.events, p.logger)
-	p.tenantH = tenantmod.NewHandler(p.tenantSvc, p.logger)
-
-	if err := app.Services.Register("tenant.service", p.exportedService()); err != nil {
-		return fmt.Errorf("register tenant service: %w", err)
+	svc := p.factory.NewTenantService(domainSvc, p.events, p.logger)
+	svc.SetConfig(cfg)
+	if rp, ok := p.factory.(RoleCatalogProvider); ok {
+		svc.SetRoleCatalog(rp.RoleCatalog())
+	}
+	if mp, ok := p.factory.(MemberTermProvider); ok {
+		svc.SetMemberTermStore(mp.MemberTerms())
 	}
-	if err := app.Services.Register("domain.plugin.tenant", p); err != nil {
-		return fmt.Errorf("register domain plugin: %w", err)
+	if pp, ok := p.factory.(PermissionProvider); ok {
+		svc.SetPermissionResolver(pp.Permissions())
+	}
+	if tp, ok := p.factory.(RoleTemplateProvider); ok {
+		svc.SetRoleTemplateSource(tp.RoleTemplateSource())
+	}
+	// The ou plugin may be enabled after this one, so its organization
+	// service is looked up when a clone, export or import needs it.
+	orgs := registryOrganizations{services: app.Services}
+	svc.SetOrganizationCloner(orgs)
+	svc.SetOrganizationArchiver(orgs)
+	svc.SetMetrics(rec)
+	svc.SetTracer(tracer)
+	p.cfg.Store(&cfg)
+	p.deps.Store(&requestDeps{
+		domainSvc: domainSvc,
+		tracer:    tracer,
+		middleware: chi.Chain(
+			metrics.HTTPMiddleware(metrics.OrNop(rec), p.Name()),
+			tracing.HTTPMiddleware(tracer, p.Name()),
+			p.lc.middleware,
+			idempotency.Middleware(idemStore,
+				time.Duration(cfg.IdempotencyTTLSeconds)*time.Second, p.Name(), cfg.TenantHeader),
+		),
+	})
+	p.tenantSvc.Store(svc)
+	p.tenantH.Store(tenantmod.NewHandler(svc, p.logger))
+
+	// Services stay registered across a disable/re-enable cycle; the exported
+	// adapter and the routes load the current service on each call.
+	if !p.registered {
+		p.exported = &tenantServiceAdapter{svc: &p.tenantSvc}
+		if err := app.Services.Register("tenant.service", TenantServiceAPI(p.exported)); err != nil {
+			return fmt.Errorf("register tenant service: %w", err)
+		}
+		if err := app.Services.Register("domain.plugin.tenant", p); err != nil {
+			return fmt.Errorf("register domain plugin: %w", err)
+		}
+		// The collector loads the service at scrape time, so it follows re-enables.
+		metrics.RegisterCollector(rec, func(ctx context.Context, rec metrics.Recorder) error {
+			svc := p.service()
+			if svc == nil {
+				return nil
+			}
+			return svc.CollectGauges(ctx, rec)
+		})
+		p.registered = true
 	}
 
+	p.lc.start()
+	sweepInterval := time.Duration(cfg.MemberSweepIntervalSeconds) * time.Second
+	p.lc.goWorker(func(ctx context.Context) { p.runMemberSweeper(ctx, sweepInterval) })
+	webhookInterval := time.Duration(cfg.WebhookPollIntervalSeconds) * time.Second
+	p.lc.goWorker(func(ctx context.Context) { p.runWebhookDispatcher(ctx, webhookInterval) })
 	p.logger.Info("tenant plugin enabled")
 	return nil
 }
 
+// loadConfig builds the effective configuration: the host-provided Config,
+// then the plugin's config section, then defaults for unset settings.
+func (p *TenantPlugin) loadConfig(provider plugin.ConfigProvider) (Config, error) {
+	// Defaults are applied last so that settings derived from others, such
+	// as the default role templates, follow host overrides.
+	var cfg Config
+	if p.Config != nil {
+		cfg = *p.Config
+	}
+	if provider != nil {
+		var raw map[string]any
+		if err := provider.Bind(&raw); err != nil {
+			return Config{}, fmt.Errorf("tenant plugin: bind config: %w", err)
+		}
+		if err := shared.CheckConfigKeys(raw); err != nil {
+			return Config{}, fmt.Errorf("tenant plugin: %w", err)
+		}
+		if err := provider.Bind(&cfg); err != nil {
+			return Config{}, fmt.Errorf("tenant plugin: bind config: %w", err)
+		}
+	}
+	cfg = cfg.WithDefaults()
+	if err := cfg.Validate(); err != nil {
+		return Config{}, fmt.Errorf("tenant plugin: %w", err)
+	}
+	return cfg, nil
+}
+
 // Install seeds domain types and default tenants on first run.
 func (p *TenantPlugin) Install(ctx context.Context, app *plugin.AppContext) error {
 	return nil
 }
 
-// Disable performs cleanup on plugin shutdown.
+// Disable performs cleanup on plugin shutdown. It rejects new requests,
+// unsubscribes event handlers, stops background workers and waits for
+// in-flight work until ctx is done.
 func (p *TenantPlugin) Disable(ctx context.Context, app *plugin.AppContext) error {
-	p.logger.Info("tenant plugin: shutting down")
+	if p.logger != nil {
+		p.logger.Info("tenant plugin: shutting down")
+	}
+	if err := p.lc.stop(ctx); err != nil {
+		return fmt.Errorf("tenant plugin: %w", err)
+	}
 	return nil
 }
 
-// SubscribeEvents registers event handlers.
+// SubscribeEvents registers event handlers. Handlers from a previous call
+// are released first so that re-subscribing never duplicates them. Handlers
+// continue the trace carried by the event payload.
 func (p *TenantPlugin) SubscribeEvents(bus plugin.EventBus) {
-	bus.Subscribe("user.deleted", func(ctx context.Context, e plugin.Event) error {
-		return p.tenantSvc.OnUserDeleted(ctx, e.Data)
+	p.lc.unsubscribeAll()
+	bus = tracing.WrapEventBus(bus, p.requestDeps().tracer)
+	p.lc.subscribe(bus, "user.deleted", func(ctx context.Context, e plugin.Event) error {
+		return p.service().OnUserDeleted(ctx, e.Data)
+	})
+	for _, topic := range shared.WebhookEvents {
+		p.lc.subscribe(bus, topic, func(ctx context.Context, e plugin.Event) error {
+			return p.service().EnqueueWebhookEvent(ctx, e)
+		})
+	}
+}
+
+// config returns the configuration of the current Enable, or the zero
+// Config before the first one.
+func (p *TenantPlugin) config() Config {
+	if cfg := p.cfg.Load(); cfg != nil {
+		return *cfg
+	}
+	return Config{}
+}
+
+// requestDeps returns the request dependencies of the current Enable, all
+// nil before the first one.
+func (p *TenantPlugin) requestDeps() *requestDeps {
+	if deps := p.deps.Load(); deps != nil {
+		return deps
+	}
+	return &requestDeps{}
+}
+
+// enabledMiddleware runs each request through the middleware of the current
+// Enable, so that routes registered once follow re-enables.
+func (p *TenantPlugin) enabledMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p.requestDeps().middleware.Handler(next).ServeHTTP(w, r)
 	})
 }
 
+// service returns the tenant service of the current Enable, or nil before
+// the first one.
+func (p *TenantPlugin) service() *tenantmod.Service {
+	return p.tenantSvc.Load()
+}
+
+// handle returns a handler calling method on the handler of the current
+// Enable, so that routes registered once follow re-enables.
+func (p *TenantPlugin) handle(method func(*tenantmod.Handler, http.ResponseWriter, *http.Request)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		h := p.tenantH.Load()
+		if h == nil {
+			responder.ServiceUnavailable(w, r, "Tenant plugin is not enabled")
+			return
+		}
+		method(h, w, r)
+	}
+}
+
 func (p *TenantPlugin) RegisterRoutes(router chi.Router) {
 	router.Route("/tenants", func(r chi.Router) {
-		r.Get("/me", p.tenantH.ListMyTenants)
-		r.Get("/", p.tenantH.ListTenants)
-		r.Post("/", p.tenantH.CreateTenant)
-		r.Get("/{id}", p.tenantH.GetTenant)
-		r.Put("/{id}", p.tenantH.UpdateTenant)
-		r.Delete("/{id}", p.tenantH.DeleteTenant)
-		r.Post("/{id}/members", p.tenantH.AddMember)
-		r.Get("/{id}/members", p.tenantH.ListMembers)
-		r.Delete("/{id}/members/{userId}", p.tenantH.RemoveMember)
+		// Health endpoints and the API document stay reachable while the
+		// plugin shuts down.
+		r.Get("/health/live", health.Handler(p.Liveness))
+		r.Get("/health/ready", health.Handler(p.Readiness))
+		r.Get("/openapi.json", openapi.Handler(p.OpenAPI()))
+
+		r.Group(func(r chi.Router) {
+			r.Use(p.enabledMiddleware)
+			r.Get("/me", p.handle((*tenantmod.Handler).ListMyTenants))
+			r.Get("/me/{id}", p.handle((*tenantmod.Handler).GetMyTenant))
+			r.Get("/", p.handle((*tenantmod.Handler).ListTenants))
+			r.Post("/", p.handle((*tenantmod.Handler).CreateTenant))
+			r.Post("/roles/sync", p.handle((*tenantmod.Handler).SyncAllRoleTemplates))
+			r.Get("/templates", p.handle((*tenantmod.Handler).ListTenantTemplates))
+			r.Post("/templates/{name}/clone", p.handle((*tenantmod.Handler).CloneTenantTemplate))
+			r.Post("/import", p.handle((*tenantmod.Handler).ImportTenant))
+			r.Post("/search", p.handle((*tenantmod.Handler).SearchTenants))
+			r.Get("/impersonations", p.handle((*tenantmod.Handler).ListImpersonations))
+			r.Post("/impersonations/{sessionId}/revoke", p.handle((*tenantmod.Handler).RevokeImpersonation))
+			r.Post("/webhooks", p.handle((*tenantmod.Handler).CreateWebhook))
+			r.Get("/webhooks", p.handle((*tenantmod.Handler).ListWebhooks))
+			r.Get("/webhooks/{webhookId}", p.handle((*tenantmod.Handler).GetWebhook))
+			r.Put("/webhooks/{webhookId}", p.handle((*tenantmod.Handler).UpdateWebhook))
+			r.Delete("/webhooks/{webhookId}", p.handle((*tenantmod.Handler).DeleteWebhook))
+			r.Get("/webhooks/{webhookId}/deliveries", p.handle((*tenantmod.Handler).ListWebhookDeliveries))
+			r.Post("/webhooks/{webhookId}/deliveries/{deliveryId}/redeliver", p.handle((*tenantmod.Handler).RedeliverWebhook))
+			r.Get("/{id}", p.handle((*tenantmod.Handler).GetTenant))
+			r.Put("/{id}", p.handle((*tenantmod.Handler).UpdateTenant))
+			r.Patch("/{id}", p.handle((*tenantmod.Handler).PatchTenant))
+			r.Delete("/{id}", p.handle((*tenantmod.Handler).DeleteTenant))
+			r.Post("/{id}/members", p.handle((*tenantmod.Handler).AddMember))
+			r.Get("/{id}/members", p.handle((*tenantmod.Handler).ListMembers))
+			r.Delete("/{id}/members/{userId}", p.handle((*tenantmod.Handler).RemoveMember))
+			r.Post("/{id}/members/{userId}/suspend", p.handle((*tenantmod.Handler).SuspendMember))
+			r.Post("/{id}/members/{userId}/reactivate", p.handle((*tenantmod.Handler).ReactivateMember))
+			r.Get("/{id}/roles", p.handle((*tenantmod.Handler).ListRoles))
+			r.Post("/{id}/roles/sync", p.handle((*tenantmod.Handler).SyncRoleTemplates))
+			r.Post("/{id}/clone", p.handle((*tenantmod.Handler).CloneTenant))
+			r.Get("/{id}/export", p.handle((*tenantmod.Handler).ExportTenant))
+			r.Post("/{id}/impersonate", p.handle((*tenantmod.Handler).Impersonate))
+			r.Post("/{id}/service-accounts", p.handle((*tenantmod.Handler).CreateServiceAccount))
+			r.Get("/{id}/service-accounts", p.handle((*tenantmod.Handler).ListServiceAccounts))
+			r.Get("/{id}/service-accounts/{accountId}", p.handle((*tenantmod.Handler).GetServiceAccount))
+			r.Put("/{id}/service-accounts/{accountId}", p.handle((*tenantmod.Handler).UpdateServiceAccount))
+			r.Delete("/{id}/service-accounts/{accountId}", p.handle((*tenantmod.Handler).DeleteServiceAccount))
+			r.Post("/{id}/service-accounts/{accountId}/keys", p.handle((*tenantmod.Handler).CreateAPIKey))
+			r.Get("/{id}/service-accounts/{accountId}/keys", p.handle((*tenantmod.Handler).ListAPIKeys))
+			r.Delete("/{id}/service-accounts/{accountId}/keys/{keyId}", p.handle((*tenantmod.Handler).RevokeAPIKey))
+			r.Post("/{id}/service-accounts/{accountId}/keys/{keyId}/rotate", p.handle((*tenantmod.Handler).RotateAPIKey))
+			r.Post("/{id}/webhooks", p.handle((*tenantmod.Handler).CreateWebhook))
+			r.Get("/{id}/webhooks", p.handle((*tenantmod.Handler).ListWebhooks))
+			r.Get("/{id}/webhooks/{webhookId}", p.handle((*tenantmod.Handler).GetWebhook))
+			r.Put("/{id}/webhooks/{webhookId}", p.handle((*tenantmod.Handler).UpdateWebhook))
+			r.Delete("/{id}/webhooks/{webhookId}", p.handle((*tenantmod.Handler).DeleteWebhook))
+			r.Get("/{id}/webhooks/{webhookId}/deliveries", p.handle((*tenantmod.Handler).ListWebhookDeliveries))
+			r.Post("/{id}/webhooks/{webhookId}/deliveries/{deliveryId}/redeliver", p.handle((*tenantmod.Handler).RedeliverWebhook))
+		})
 	})
 }
 
+// HealthCheck runs the readiness checks and fails when a critical
+// dependency is down. Use Readiness for the per-dependency report.
 func (p *TenantPlugin) HealthCheck(ctx context.Context) error {
-	if p.tenantSvc == nil {
+	if p.service() == nil {
 		return fmt.Errorf("tenant plugin: tenant service not initialized")
 	}
-	return p.tenantSvc.Ping(ctx)
+	return p.Readiness(ctx).Err()
 }
 
 func (p *TenantPlugin) PluginOptions() plugin.PluginOptions {
@@ -143,6 +494,16 @@ func (p *TenantPlugin) PluginOptions() plugin.PluginOptions {
 	}
 }
 
+// ConfigSchema returns the JSON schema of the plugin configuration.
+func (p *TenantPlugin) ConfigSchema() json.RawMessage {
+	return json.RawMessage(shared.ConfigSchema)
+}
+
+// EffectiveConfig returns the configuration the plugin is running with.
+func (p *TenantPlugin) EffectiveConfig() Config {
+	return p.config()
+}
+
 func (p *TenantPlugin) RegisterModels() []any {
 	if p.factory == nil {
 		return nil
@@ -150,16 +511,86 @@ func (p *TenantPlugin) RegisterModels() []any {
 	return p.factory.Models()
 }
 
-func (p *TenantPlugin) exportedService() TenantServiceAPI {
-	return &tenantServiceAdapter{svc: p.tenantSvc}
+// serviceKeyOrganizations is the ou plugin's organization service.
+const serviceKeyOrganizations = "ou.organization.service"
+
+// ouOrganizations is the part of the ou plugin's organization service used
+// for cloning, export and import.
+type ouOrganizations interface {
+	CloneOrganizationTree(ctx context.Context, fromDomainID, toDomainID uuid.UUID) (int, error)
+	ExportOrganizations(ctx context.Context, domainID uuid.UUID) ([]organizationmod.OrganizationSnapshot, error)
+	ImportOrganizations(ctx context.Context, domainID uuid.UUID, orgs []organizationmod.OrganizationSnapshot) (int, error)
+}
+
+// registryOrganizations resolves the ou plugin's organization service from
+// the service registry on each call.
+type registryOrganizations struct {
+	services *plugin.ServiceRegistry
 }
 
+func (c registryOrganizations) resolve() (ouOrganizations, error) {
+	if !c.services.Has(serviceKeyOrganizations) {
+		return nil, shared.ErrOrganizationsDisabled
+	}
+	orgs, err := plugin.Resolve[ouOrganizations](c.services, serviceKeyOrganizations)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", shared.ErrOrganizationsDisabled, err)
+	}
+	return orgs, nil
+}
+
+func (c registryOrganizations) CloneOrganizationTree(ctx context.Context, fromDomainID, toDomainID uuid.UUID) (int, error) {
+	orgs, err := c.resolve()
+	if err != nil {
+		return 0, err
+	}
+	return orgs.CloneOrganizationTree(ctx, fromDomainID, toDomainID)
+}
+
+func (c registryOrganizations) ExportOrganizations(ctx context.Context, domainID uuid.UUID) ([]shared.OrganizationRecord, error) {
+	orgs, err := c.resolve()
+	if err != nil {
+		return nil, err
+	}
+	snapshots, err := orgs.ExportOrganizations(ctx, domainID)
+	if err != nil {
+		return nil, err
+	}
+	records := make([]shared.OrganizationRecord, 0, len(snapshots))
+	for _, o := range snapshots {
+		rec := shared.OrganizationRecord{Code: o.Code, Name: o.Name, Path: o.Path}
+		for _, m := range o.Members {
+			rec.Members = append(rec.Members, shared.OrganizationMemberRecord{UserID: m.UserID, IsPrimary: m.IsPrimary})
+		}
+		records = append(records, rec)
+	}
+	return records, nil
+}
+
+func (c registryOrganizations) ImportOrganizations(ctx context.Context, domainID uuid.UUID, records []shared.OrganizationRecord) (int, error) {
+	orgs, err := c.resolve()
+	if err != nil {
+		return 0, err
+	}
+	snapshots := make([]organizationmod.OrganizationSnapshot, 0, len(records))
+	for _, rec := range records {
+		o := organizationmod.OrganizationSnapshot{Code: rec.Code, Name: rec.Name, Path: rec.Path}
+		for _, m := range rec.Members {
+			o.Members = append(o.Members, organizationmod.MemberSnapshot{UserID: m.UserID, IsPrimary: m.IsPrimary})
+		}
+		snapshots = append(snapshots, o)
+	}
+	return orgs.ImportOrganizations(ctx, domainID, snapshots)
+}
+
+// tenantServiceAdapter exposes the plugin's current tenant service to other
+// plugins.
 type tenantServiceAdapter struct {
-	svc *tenantmod.Service
+	svc *atomic.Pointer[tenantmod.Service]
 }
 
 func (a *tenantServiceAdapter) GetTenant(ctx context.Context, id uuid.UUID) (*TenantInfo, error) {
-	dto, err := a.svc.GetTenant(ctx, id)
+	dto, err := a.svc.Load().GetTenant(ctx, id)
 	if err != nil {
 		return nil, err
 	}
@@ -169,11 +600,12 @@ func (a *tenantServiceAdapter) GetTenant(ctx context.Context, id uuid.UUID) (*Te
 		Name:     dto.Name,
 		Status:   dto.Status,
 		DomainID: dto.DomainID,
+		Labels:   dto.Labels,
 	}, nil
 }
 
 func (a *tenantServiceAdapter) GetTenantByCode(ctx context.Context, code string) (*TenantInfo, error) {
-	dto, err := a.svc.GetTenantByCode(ctx, code)
+	dto, err := a.svc.Load().GetTenantByCode(ctx, code)
 	if err != nil {
 		return nil, err
 	}
@@ -183,39 +615,81 @@ func (a *tenantServiceAdapter) GetTenantByCode(ctx context.Context, code string)
 		Name:     dto.Name,
 		Status:   dto.Status,
 		DomainID: dto.DomainID,
+		Labels:   dto.Labels,
 	}, nil
 }
 
 func (a *tenantServiceAdapter) IsMember(ctx context.Context, tenantID, userID uuid.UUID) (bool, error) {
-	return a.svc.IsMember(ctx, tenantID, userID)
+	return a.svc.Load().IsMember(ctx, tenantID, userID)
 }
 
 func (a *tenantServiceAdapter) GetDomainID(ctx context.Context, tenantCode string) (uuid.UUID, error) {
-	return a.svc.GetDomainID(ctx, tenantCode)
+	return a.svc.Load().GetDomainID(ctx, tenantCode)
 }
 
-func (p *TenantPlugin) TypeCode() string { return "tenant" }
+func (p *TenantPlugin) TypeCode() string {
+	if code := p.config().DomainTypeCode; code != "" {
+		return code
+	}
+	return shared.DefaultConfig().DomainTypeCode
+}
 
+// ResolveDomain resolves the tenant domain of a request: the domain of its
+// impersonation session, if it carries an impersonation token, the domain of
+// its service account, if it carries an API key, or else the tenant named by
+// the tenant header.
 func (p *TenantPlugin) ResolveDomain(ctx context.Context, r *http.Request) (*core.ResolvedDomain, bool, error) {
-	if p.domainSvc == nil || r == nil {
+	domainSvc := p.requestDeps().domainSvc
+	if domainSvc == nil || r == nil {
 		return nil, false, nil
 	}
-	tenantID := r.Header.Get("X-Tenant-ID")
+	session, err := p.impersonationSession(ctx, r)
+	if err != nil {
+		return nil, false, err
+	}
+	if session != nil {
+		resolved, err := domainSvc.ResolveDomainByID(ctx, session.DomainID)
+		if err != nil {
+			return nil, false, err
+		}
+		return resolved, true, nil
+	}
+	account, err := p.serviceAccount(ctx, r)
+	if err != nil {
+		return nil, false, err
+	}
+	if account != nil {
+		resolved, err := domainSvc.ResolveDomainByID(ctx, account.DomainID)
+		if err != nil {
+			return nil, false, err
+		}
+		return resolved, true, nil
+	}
+	tenantID := r.Header.Get(p.config().TenantHeader)
 	if tenantID == "" {
 		return nil, false, nil
 	}
-	resolved, err := p.domainSvc.ResolveDomain(ctx, "tenant", tenantID)
+	resolved, err := domainSvc.ResolveDomain(ctx, p.TypeCode(), tenantID)
 	if err != nil {
 		return nil, false, err
 	}
 	return resolved, true, nil
 }
 
+// ValidateMembership reports whether subjectID may act in domainID. Time-bound
+// memberships outside their validity window are rejected; active
+// impersonation sessions grant access and disabled service accounts are
+// rejected.
 func (p *TenantPlugin) ValidateMembership(ctx context.Context, domainID, subjectID uuid.UUID) (bool, error) {
-	if p.domainSvc == nil {
+	domainSvc := p.requestDeps().domainSvc
+	if domainSvc == nil {
 		return false, fmt.Errorf("domain service is nil")
 	}
-	return p.domainSvc.CheckMembership(ctx, domainID, subjectID)
+	svc := p.service()
+	if svc == nil {
+		return domainSvc.CheckMembership(ctx, domainID, subjectID)
+	}
+	return svc.CheckDomainMembership(ctx, domainID, subjectID)
 }
 
 var (
@@ -228,4 +702,8 @@ var (
 	_ plugin.Configurable    = (*TenantPlugin)(nil)
 	_ plugin.ModelProvider   = (*TenantPlugin)(nil)
 	_ TenantServiceAPI       = (*tenantServiceAdapter)(nil)
+
+	_ shared.OrganizationCloner   = registryOrganizations{}
+	_ shared.OrganizationArchiver = registryOrganizations{}
+	_ ouOrganizations             = (*organizationmod.Service)(nil)
 )
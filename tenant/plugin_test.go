@@ -2,8 +2,21 @@ package tenant
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
@@ -12,8 +25,15 @@ import (
 	"github.com/leeforge/framework/plugin"
 
 	"github.com/leeforge/core"
+	coremod "github.com/leeforge/core/core"
+	"github.com/leeforge/plugins/health"
+	"github.com/leeforge/plugins/idempotency"
+	"github.com/leeforge/plugins/metrics"
+	"github.com/leeforge/plugins/openapi"
+	organizationmod "github.com/leeforge/plugins/ou/organization"
 	"github.com/leeforge/plugins/tenant/shared"
 	tenantmod "github.com/leeforge/plugins/tenant/tenant"
+	"github.com/leeforge/plugins/tracing"
 )
 
 type mockDomainWriter struct {
@@ -94,7 +114,7 @@ func (noopEvents) Close() error { return nil }
 // mockRoleSeeder is a no-op role seeder for plugin tests.
 type mockRoleSeeder struct{}
 
-func (mockRoleSeeder) SeedBaselineRoles(_ context.Context, _ uuid.UUID) error { return nil }
+func (mockRoleSeeder) SeedBaselineRoles(context.Context, uuid.UUID) error { return nil }
 
 // mockUserLookup returns a stub user for plugin tests.
 type mockUserLookup struct{}
@@ -135,6 +155,43 @@ func TestPlugin_Enable_Success(t *testing.T) {
 	require.NoError(t, err)
 }
 
+// providerFactory implements the optional ServiceFactory providers and
+// records which ones the plugin asked for.
+type providerFactory struct {
+	mockFactory
+	asked *[]string
+}
+
+func (f providerFactory) RoleCatalog() RoleCatalog {
+	*f.asked = append(*f.asked, "RoleCatalog")
+	return nil
+}
+
+func (f providerFactory) MemberTerms() MemberTermStore {
+	*f.asked = append(*f.asked, "MemberTerms")
+	return nil
+}
+
+func (f providerFactory) Permissions() PermissionResolver {
+	*f.asked = append(*f.asked, "Permissions")
+	return nil
+}
+
+func TestPlugin_Enable_WiresOptionalProviders(t *testing.T) {
+	var asked []string
+	sr := plugin.NewServiceRegistry()
+	require.NoError(t, sr.Register(ServiceKeyTenantFactory, ServiceFactory(providerFactory{asked: &asked})))
+	require.NoError(t, sr.Register("domain.service", core.DomainWriter(newMockDomainWriter())))
+
+	p := &TenantPlugin{}
+	require.NoError(t, p.Enable(context.Background(), &plugin.AppContext{
+		Logger:   zap.NewNop(),
+		Services: sr,
+		Events:   noopEvents{},
+	}))
+	require.ElementsMatch(t, []string{"RoleCatalog", "MemberTerms", "Permissions"}, asked)
+}
+
 func TestPlugin_Enable_MissingFactory(t *testing.T) {
 	sr := plugin.NewServiceRegistry()
 	require.NoError(t, sr.Register("domain.service", core.DomainWriter(newMockDomainWriter())))
@@ -152,3 +209,1056 @@ func TestPlugin_HealthCheck_NotInitialized(t *testing.T) {
 	p := &TenantPlugin{}
 	require.Error(t, p.HealthCheck(context.Background()))
 }
+
+// recordingBus tracks live subscriptions per topic for lifecycle tests.
+type recordingBus struct {
+	mu       sync.Mutex
+	nextID   int
+	handlers map[string]map[int]plugin.EventHandler
+}
+
+func newRecordingBus() *recordingBus {
+	return &recordingBus{handlers: make(map[string]map[int]plugin.EventHandler)}
+}
+
+type recordingSub struct {
+	bus   *recordingBus
+	topic string
+	id    int
+}
+
+func (s recordingSub) Unsubscribe() {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+	delete(s.bus.handlers[s.topic], s.id)
+}
+
+func (b *recordingBus) Publish(ctx context.Context, e plugin.Event) error {
+	b.mu.Lock()
+	handlers := make([]plugin.EventHandler, 0, len(b.handlers[e.Name]))
+	for _, h := range b.handlers[e.Name] {
+		handlers = append(handlers, h)
+	}
+	b.mu.Unlock()
+	for _, h := range handlers {
+		_ = h(ctx, e)
+	}
+	return nil
+}
+
+func (b *recordingBus) Subscribe(topic string, h plugin.EventHandler) plugin.Subscription {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.handlers[topic] == nil {
+		b.handlers[topic] = make(map[int]plugin.EventHandler)
+	}
+	b.nextID++
+	b.handlers[topic][b.nextID] = h
+	return recordingSub{bus: b, topic: topic, id: b.nextID}
+}
+
+func (b *recordingBus) Close() error { return nil }
+
+func (b *recordingBus) count(topic string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.handlers[topic])
+}
+
+func newEnabledPlugin(t *testing.T, bus plugin.EventBus) (*TenantPlugin, *plugin.AppContext) {
+	t.Helper()
+	sr := plugin.NewServiceRegistry()
+	require.NoError(t, sr.Register(ServiceKeyTenantFactory, mockFactory{}))
+	require.NoError(t, sr.Register("domain.service", core.DomainWriter(newMockDomainWriter())))
+
+	app := &plugin.AppContext{
+		Logger:   zap.NewNop(),
+		Services: sr,
+		Events:   bus,
+	}
+	p := &TenantPlugin{}
+	require.NoError(t, p.Enable(context.Background(), app))
+	return p, app
+}
+
+func TestPlugin_DisableEnableCycle_DoesNotDuplicateHandlers(t *testing.T) {
+	bus := newRecordingBus()
+	p, app := newEnabledPlugin(t, bus)
+
+	for i := 0; i < 3; i++ {
+		p.SubscribeEvents(bus)
+		require.Equal(t, 1, bus.count("user.deleted"))
+
+		require.NoError(t, p.Disable(context.Background(), app))
+		require.Equal(t, 0, bus.count("user.deleted"))
+
+		require.NoError(t, p.Enable(context.Background(), app))
+	}
+
+	svc, err := plugin.Resolve[TenantServiceAPI](app.Services, "tenant.service")
+	require.NoError(t, err)
+	require.Same(t, p.service(), svc.(*tenantServiceAdapter).svc.Load())
+}
+
+func TestPlugin_SubscribeEvents_Twice_ReplacesHandlers(t *testing.T) {
+	bus := newRecordingBus()
+	p, _ := newEnabledPlugin(t, bus)
+
+	p.SubscribeEvents(bus)
+	p.SubscribeEvents(bus)
+	require.Equal(t, 1, bus.count("user.deleted"))
+}
+
+func TestPlugin_Disable_WaitsForInFlightHandlers(t *testing.T) {
+	bus := newRecordingBus()
+	p, app := newEnabledPlugin(t, bus)
+
+	started := make(chan struct{})
+	unblock := make(chan struct{})
+	var finished atomic.Bool
+	p.lc.subscribe(bus, "test.slow", func(context.Context, plugin.Event) error {
+		close(started)
+		<-unblock
+		finished.Store(true)
+		return nil
+	})
+
+	go func() { _ = bus.Publish(context.Background(), plugin.Event{Name: "test.slow"}) }()
+	<-started
+
+	done := make(chan error, 1)
+	go func() { done <- p.Disable(context.Background(), app) }()
+
+	select {
+	case <-done:
+		t.Fatal("Disable returned before the in-flight handler finished")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(unblock)
+	require.NoError(t, <-done)
+	require.True(t, finished.Load())
+}
+
+func TestPlugin_Disable_HonoursContextDeadline(t *testing.T) {
+	bus := newRecordingBus()
+	p, app := newEnabledPlugin(t, bus)
+
+	unblock := make(chan struct{})
+	defer close(unblock)
+	started := make(chan struct{})
+	require.True(t, p.lc.goWorker(func(context.Context) {
+		close(started)
+		<-unblock
+	}))
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := p.Disable(ctx, app)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestPlugin_Disable_CancelsBackgroundWorkers(t *testing.T) {
+	p, app := newEnabledPlugin(t, noopEvents{})
+
+	stopped := make(chan struct{})
+	require.True(t, p.lc.goWorker(func(ctx context.Context) {
+		<-ctx.Done()
+		close(stopped)
+	}))
+
+	require.NoError(t, p.Disable(context.Background(), app))
+	select {
+	case <-stopped:
+	default:
+		t.Fatal("worker was not stopped by Disable")
+	}
+	require.False(t, p.lc.goWorker(func(context.Context) {}))
+}
+
+func TestPlugin_Disable_RejectsRequestsWith503(t *testing.T) {
+	p, app := newEnabledPlugin(t, noopEvents{})
+	router := chi.NewRouter()
+	p.RegisterRoutes(router)
+
+	require.NoError(t, p.Disable(context.Background(), app))
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/tenants/me", nil))
+	require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	require.NoError(t, p.Enable(context.Background(), app))
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/tenants/me", nil))
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestPlugin_RoutesRegisteredBeforeEnable_FollowEnable(t *testing.T) {
+	p := &TenantPlugin{}
+	router := chi.NewRouter()
+	p.RegisterRoutes(router)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/tenants/me", nil))
+	require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	sr := plugin.NewServiceRegistry()
+	require.NoError(t, sr.Register(ServiceKeyTenantFactory, mockFactory{}))
+	require.NoError(t, sr.Register("domain.service", core.DomainWriter(newMockDomainWriter())))
+	require.NoError(t, p.Enable(context.Background(), &plugin.AppContext{
+		Logger:   zap.NewNop(),
+		Services: sr,
+		Events:   noopEvents{},
+	}))
+
+	// Routes registered once serve the handler of the latest Enable.
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/tenants/me", nil))
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestPlugin_Reenable_RoutesFollowCurrentService(t *testing.T) {
+	p, app := newEnabledPlugin(t, noopEvents{})
+	router := chi.NewRouter()
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := coremod.WithActingContext(r.Context(), &coremod.ActingContext{
+				Domain: &coremod.ResolvedDomain{TypeCode: string(coremod.DomainPlatform), Key: "root"},
+			})
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	})
+	p.RegisterRoutes(router)
+
+	templates := func() int {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/tenants/templates", nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+		var body struct {
+			Data tenantmod.TenantTemplateListResult `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		return len(body.Data.Templates)
+	}
+
+	require.NoError(t, p.service().RegisterTenantTemplate(TenantTemplate{Name: "old", SourceTenant: "demo"}))
+	require.Equal(t, 1, templates())
+
+	// Routes registered once serve the service of the latest Enable.
+	require.NoError(t, p.Disable(context.Background(), app))
+	require.NoError(t, p.Enable(context.Background(), app))
+	require.Equal(t, 0, templates())
+}
+
+func TestPlugin_IdempotencyKey_ReplaysMutatingRequests(t *testing.T) {
+	p, _ := newEnabledPlugin(t, noopEvents{})
+	router := chi.NewRouter()
+	p.RegisterRoutes(router)
+
+	send := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/tenants", strings.NewReader(body))
+		req.Header.Set(idempotency.Header, "create-acme")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := send(`{"code":"acme","name":"Acme"}`)
+	require.Equal(t, http.StatusForbidden, first.Code)
+	replayed := send(`{"code":"acme","name":"Acme"}`)
+	require.Equal(t, http.StatusForbidden, replayed.Code)
+	require.Equal(t, "true", replayed.Header().Get(idempotency.ReplayedHeader))
+	require.Equal(t, first.Body.String(), replayed.Body.String())
+
+	reused := send(`{"code":"globex","name":"Globex"}`)
+	require.Equal(t, http.StatusUnprocessableEntity, reused.Code)
+}
+
+func TestPlugin_Reenable_RoutesUseCurrentMiddleware(t *testing.T) {
+	p, app := newEnabledPlugin(t, noopEvents{})
+	router := chi.NewRouter()
+	p.RegisterRoutes(router)
+
+	send := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/tenants", strings.NewReader(`{"code":"acme","name":"Acme"}`))
+		req.Header.Set(idempotency.Header, "create-acme")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+	send()
+	require.Equal(t, "true", send().Header().Get(idempotency.ReplayedHeader))
+
+	// Without a host store each Enable creates its own, and routes
+	// registered once use the store of the latest Enable.
+	require.NoError(t, p.Disable(context.Background(), app))
+	require.NoError(t, p.Enable(context.Background(), app))
+	require.Empty(t, send().Header().Get(idempotency.ReplayedHeader))
+}
+
+func TestPlugin_Reenable_ConcurrentWithRequests(t *testing.T) {
+	p, app := newEnabledPlugin(t, noopEvents{})
+	router := chi.NewRouter()
+	p.RegisterRoutes(router)
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			req := httptest.NewRequest(http.MethodGet, "/tenants/me", nil)
+			req.Header.Set(DefaultConfig().TenantHeader, "acme")
+			_, _, _ = p.ResolveDomain(req.Context(), req)
+			_ = p.TypeCode()
+			router.ServeHTTP(httptest.NewRecorder(), req)
+		}
+	}()
+	for range 5 {
+		require.NoError(t, p.Enable(context.Background(), app))
+	}
+	close(stop)
+	<-done
+}
+
+func enableWithConfig(t *testing.T, p *TenantPlugin, settings map[string]any) error {
+	t.Helper()
+	sr := plugin.NewServiceRegistry()
+	require.NoError(t, sr.Register(ServiceKeyTenantFactory, mockFactory{}))
+	require.NoError(t, sr.Register("domain.service", core.DomainWriter(newMockDomainWriter())))
+	return p.Enable(context.Background(), &plugin.AppContext{
+		Logger:   zap.NewNop(),
+		Services: sr,
+		Events:   noopEvents{},
+		Config:   plugin.NewMapConfigProvider(settings),
+	})
+}
+
+func TestPlugin_Enable_DefaultConfig(t *testing.T) {
+	p := &TenantPlugin{}
+	require.NoError(t, enableWithConfig(t, p, nil))
+	require.Equal(t, DefaultConfig(), p.EffectiveConfig())
+	require.Equal(t, "tenant", p.TypeCode())
+}
+
+func TestPlugin_Enable_AppliesConfigOverrides(t *testing.T) {
+	p := &TenantPlugin{Config: &Config{
+		DefaultPageSize:   10,
+		MaxPageSize:       50,
+		DefaultMemberRole: "viewer",
+		OwnerRole:         "owner",
+		TenantHeader:      "X-Org",
+		DomainTypeCode:    "org",
+	}}
+	require.NoError(t, enableWithConfig(t, p, map[string]any{
+		"maxPageSize":  200,
+		"tenantHeader": "X-Workspace",
+	}))
+
+	cfg := p.EffectiveConfig()
+	require.Equal(t, 10, cfg.DefaultPageSize)
+	require.Equal(t, 200, cfg.MaxPageSize)
+	require.Equal(t, "viewer", cfg.DefaultMemberRole)
+	require.Equal(t, "X-Workspace", cfg.TenantHeader)
+	require.Equal(t, "org", p.TypeCode())
+}
+
+func TestPlugin_Enable_InvalidConfigFailsFast(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings map[string]any
+		contains string
+	}{
+		{"page size bounds", map[string]any{"defaultPageSize": 500}, "must not exceed maxPageSize"},
+		{"empty owner role", map[string]any{"ownerRole": " "}, "ownerRole"},
+		{"bad header", map[string]any{"tenantHeader": "X Tenant"}, "tenantHeader"},
+		{"platform type code", map[string]any{"domainTypeCode": "platform"}, "domainTypeCode"},
+		{"impersonation header clash", map[string]any{"impersonationHeader": "X-Tenant-ID"}, "impersonationHeader"},
+		{"impersonation ttl bounds", map[string]any{"impersonationTtlSeconds": 7200}, "maxImpersonationTtlSeconds"},
+		{"webhook retry bounds", map[string]any{"webhookRetryBaseSeconds": 7200}, "webhookRetryMaxSeconds"},
+		{"negative webhook attempts", map[string]any{"webhookMaxAttempts": -1}, "webhookMaxAttempts"},
+		{"negative idempotency ttl", map[string]any{"idempotencyTtlSeconds": -1}, "idempotencyTtlSeconds"},
+		{"unknown key", map[string]any{"pageSize": 10}, "unknown keys pageSize"},
+		{"wrong type", map[string]any{"maxPageSize": "many"}, "bind config"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := enableWithConfig(t, &TenantPlugin{}, tt.settings)
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tt.contains)
+		})
+	}
+}
+
+func TestPlugin_Enable_RoleTemplates(t *testing.T) {
+	p := &TenantPlugin{}
+	require.NoError(t, enableWithConfig(t, p, map[string]any{"ownerRole": "owner"}))
+	require.Equal(t, shared.DefaultRoleTemplates("owner", "member"), p.EffectiveConfig().RoleTemplates)
+
+	p = &TenantPlugin{}
+	require.NoError(t, enableWithConfig(t, p, map[string]any{
+		"defaultMemberRole": "viewer",
+		"roleTemplates": []any{
+			map[string]any{"code": "admin", "name": "Admin", "owner": true, "permissions": []any{"*"}},
+			map[string]any{"code": "viewer", "name": "Viewer", "permissions": []any{"tenant:read"}},
+		},
+	}))
+	cfg := p.EffectiveConfig()
+	require.Equal(t, "admin", cfg.OwnerRole)
+	require.Equal(t, []string{"tenant:read"}, cfg.RoleTemplates[1].Permissions)
+
+	tests := []struct {
+		name     string
+		settings map[string]any
+		contains string
+	}{
+		{"no owner", map[string]any{"roleTemplates": []any{
+			map[string]any{"code": "member", "name": "Member"},
+		}}, "exactly one owner"},
+		{"duplicate code", map[string]any{"roleTemplates": []any{
+			map[string]any{"code": "member", "name": "Member", "owner": true},
+			map[string]any{"code": "member", "name": "Member again"},
+		}}, "duplicate code"},
+		{"owner mismatch", map[string]any{"ownerRole": "boss", "roleTemplates": []any{
+			map[string]any{"code": "admin", "name": "Admin", "owner": true},
+			map[string]any{"code": "member", "name": "Member"},
+		}}, "must match the owner role template"},
+		{"member role undefined", map[string]any{"roleTemplates": []any{
+			map[string]any{"code": "admin", "name": "Admin", "owner": true},
+		}}, "defaultMemberRole"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := enableWithConfig(t, &TenantPlugin{}, tt.settings)
+			require.ErrorIs(t, err, ErrInvalidConfig)
+			require.Contains(t, err.Error(), tt.contains)
+		})
+	}
+}
+
+func TestPlugin_Enable_TenantTemplates(t *testing.T) {
+	p := &TenantPlugin{}
+	require.NoError(t, enableWithConfig(t, p, map[string]any{
+		"tenantTemplates": []any{
+			map[string]any{"name": "sales-demo", "sourceTenant": "demo", "includeOrganizations": true},
+			map[string]any{"name": "blank", "sourceTenant": "blank-template"},
+		},
+	}))
+	ctx := coremod.WithActingContext(context.Background(), &coremod.ActingContext{
+		Domain: &coremod.ResolvedDomain{TypeCode: string(coremod.DomainPlatform), Key: "root"},
+	})
+	list, err := p.service().ListTenantTemplates(ctx)
+	require.NoError(t, err)
+	require.Len(t, list.Templates, 2)
+	require.Equal(t, "blank", list.Templates[0].Name)
+	require.True(t, list.Templates[1].IncludeOrganizations)
+
+	err = p.service().RegisterTenantTemplate(TenantTemplate{Name: "blank", SourceTenant: "other"})
+	require.ErrorIs(t, err, ErrTenantTemplateExists)
+
+	tests := []struct {
+		name     string
+		settings map[string]any
+		contains string
+	}{
+		{"missing source", map[string]any{"tenantTemplates": []any{
+			map[string]any{"name": "demo"},
+		}}, "no sourceTenant"},
+		{"duplicate name", map[string]any{"tenantTemplates": []any{
+			map[string]any{"name": "demo", "sourceTenant": "a"},
+			map[string]any{"name": "demo", "sourceTenant": "b"},
+		}}, "duplicate name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := enableWithConfig(t, &TenantPlugin{}, tt.settings)
+			require.ErrorIs(t, err, ErrInvalidConfig)
+			require.Contains(t, err.Error(), tt.contains)
+		})
+	}
+}
+
+// fakeOrganizations is a stand-in for the ou plugin's organization service.
+type fakeOrganizations struct {
+	n        int
+	imported []organizationmod.OrganizationSnapshot
+}
+
+func (f *fakeOrganizations) CloneOrganizationTree(context.Context, uuid.UUID, uuid.UUID) (int, error) {
+	return f.n, nil
+}
+
+func (f *fakeOrganizations) ExportOrganizations(context.Context, uuid.UUID) ([]organizationmod.OrganizationSnapshot, error) {
+	return []organizationmod.OrganizationSnapshot{
+		{Code: "hq", Name: "HQ", Path: "hq", Members: []organizationmod.MemberSnapshot{{UserID: uuid.Nil, IsPrimary: true}}},
+	}, nil
+}
+
+func (f *fakeOrganizations) ImportOrganizations(_ context.Context, _ uuid.UUID, orgs []organizationmod.OrganizationSnapshot) (int, error) {
+	f.imported = orgs
+	return len(orgs), nil
+}
+
+func TestRegistryOrganizations_ResolvesLazily(t *testing.T) {
+	sr := plugin.NewServiceRegistry()
+	orgs := registryOrganizations{services: sr}
+	ctx := context.Background()
+
+	_, err := orgs.CloneOrganizationTree(ctx, uuid.New(), uuid.New())
+	require.ErrorIs(t, err, ErrOrganizationsDisabled)
+	_, err = orgs.ExportOrganizations(ctx, uuid.New())
+	require.ErrorIs(t, err, ErrOrganizationsDisabled)
+
+	fake := &fakeOrganizations{n: 3}
+	require.NoError(t, sr.Register(serviceKeyOrganizations, fake))
+	n, err := orgs.CloneOrganizationTree(ctx, uuid.New(), uuid.New())
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+
+	records, err := orgs.ExportOrganizations(ctx, uuid.New())
+	require.NoError(t, err)
+	require.Equal(t, []shared.OrganizationRecord{
+		{Code: "hq", Name: "HQ", Path: "hq", Members: []shared.OrganizationMemberRecord{{IsPrimary: true}}},
+	}, records)
+	n, err = orgs.ImportOrganizations(ctx, uuid.New(), records)
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	require.Equal(t, "hq", fake.imported[0].Path)
+}
+
+func TestPlugin_ResolveDomain_UsesConfiguredHeader(t *testing.T) {
+	p := &TenantPlugin{}
+	require.NoError(t, enableWithConfig(t, p, map[string]any{
+		"tenantHeader":   "X-Org",
+		"domainTypeCode": "org",
+	}))
+	dom, err := p.requestDeps().domainSvc.EnsureDomain(context.Background(), "org", "acme", "Acme")
+	require.NoError(t, err)
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("X-Tenant-ID", "acme")
+	_, ok, err := p.ResolveDomain(context.Background(), r)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	r.Header.Set("X-Org", "acme")
+	resolved, ok, err := p.ResolveDomain(context.Background(), r)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, dom.DomainID, resolved.DomainID)
+}
+
+func TestPlugin_ConfigSchema_MatchesConfig(t *testing.T) {
+	var schema map[string]any
+	require.NoError(t, json.Unmarshal((&TenantPlugin{}).ConfigSchema(), &schema))
+	requireSchemaMatches(t, reflect.TypeFor[Config](), schema, "config")
+
+	// Documented defaults are the built-in ones.
+	defaults, err := json.Marshal(shared.DefaultConfig())
+	require.NoError(t, err)
+	var want map[string]any
+	require.NoError(t, json.Unmarshal(defaults, &want))
+	for key, prop := range schema["properties"].(map[string]any) {
+		if def, ok := prop.(map[string]any)["default"]; ok {
+			require.Equal(t, want[key], def, "default of %s", key)
+		}
+	}
+}
+
+// requireSchemaMatches fails unless the properties of the object schema are
+// exactly the JSON fields of the struct typ, with matching types. Arrays of
+// structs are checked against their item schemas.
+func requireSchemaMatches(t *testing.T, typ reflect.Type, schema map[string]any, path string) {
+	t.Helper()
+	require.Equal(t, "object", schema["type"], path)
+	require.Equal(t, false, schema["additionalProperties"], path)
+	props, ok := schema["properties"].(map[string]any)
+	require.True(t, ok, path)
+
+	fields := make(map[string]reflect.Type, typ.NumField())
+	for i := range typ.NumField() {
+		f := typ.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if !f.IsExported() || name == "-" {
+			continue
+		}
+		fields[name] = f.Type
+	}
+	for name := range props {
+		require.Contains(t, fields, name, "%s.%s is in the schema but not in the struct", path, name)
+	}
+	for name, ft := range fields {
+		require.Contains(t, props, name, "%s.%s is missing from the schema", path, name)
+		prop := props[name].(map[string]any)
+		switch ft.Kind() {
+		case reflect.Int, reflect.Int64:
+			require.Equal(t, "integer", prop["type"], "%s.%s", path, name)
+		case reflect.String:
+			require.Equal(t, "string", prop["type"], "%s.%s", path, name)
+		case reflect.Bool:
+			require.Equal(t, "boolean", prop["type"], "%s.%s", path, name)
+		case reflect.Slice:
+			require.Equal(t, "array", prop["type"], "%s.%s", path, name)
+			if ft.Elem().Kind() == reflect.Struct {
+				requireSchemaMatches(t, ft.Elem(), prop["items"].(map[string]any), path+"."+name)
+			}
+		}
+	}
+}
+
+type outboxFactory struct {
+	mockFactory
+	backlog int
+}
+
+func (f outboxFactory) OutboxBacklog(context.Context) (int, error) { return f.backlog, nil }
+
+func checkStatuses(r *health.Report) map[string]health.Status {
+	out := make(map[string]health.Status, len(r.Checks))
+	for _, c := range r.Checks {
+		out[c.Name] = c.Status
+	}
+	return out
+}
+
+func TestPlugin_Readiness_ReportsEachDependency(t *testing.T) {
+	p, app := newEnabledPlugin(t, noopEvents{})
+
+	report := p.Readiness(context.Background())
+	require.Equal(t, "tenant", report.Plugin)
+	require.Equal(t, health.KindReadiness, report.Kind)
+	require.Equal(t, map[string]health.Status{
+		"lifecycle":     health.StatusUp,
+		"database":      health.StatusDown, // mock factory builds the service without a client
+		"domain_writer": health.StatusUp,
+		"event_bus":     health.StatusUp,
+		"outbox":        health.StatusSkipped,
+		"cache":         health.StatusSkipped,
+	}, checkStatuses(report))
+	require.Equal(t, health.StatusDown, report.Status)
+	require.ErrorContains(t, p.HealthCheck(context.Background()), "database")
+
+	require.Equal(t, health.StatusUp, p.Liveness(context.Background()).Status)
+
+	require.NoError(t, p.Disable(context.Background(), app))
+	require.Equal(t, health.StatusDown, checkStatuses(p.Readiness(context.Background()))["lifecycle"])
+}
+
+// countingEvents counts published events.
+type countingEvents struct {
+	noopEvents
+	published atomic.Int32
+}
+
+func (e *countingEvents) Publish(context.Context, plugin.Event) error {
+	e.published.Add(1)
+	return nil
+}
+
+func TestPlugin_Readiness_PublishesNoEvents(t *testing.T) {
+	events := &countingEvents{}
+	p, _ := newEnabledPlugin(t, events)
+	events.published.Store(0)
+
+	require.Equal(t, health.StatusUp, checkStatuses(p.Readiness(context.Background()))["event_bus"])
+	require.Zero(t, events.published.Load())
+}
+
+func TestPlugin_Readiness_OutboxBacklogDegrades(t *testing.T) {
+	sr := plugin.NewServiceRegistry()
+	require.NoError(t, sr.Register(ServiceKeyTenantFactory, ServiceFactory(outboxFactory{backlog: 5000})))
+	require.NoError(t, sr.Register("domain.service", core.DomainWriter(newMockDomainWriter())))
+
+	p := &TenantPlugin{}
+	require.NoError(t, p.Enable(context.Background(), &plugin.AppContext{
+		Logger:   zap.NewNop(),
+		Services: sr,
+		Events:   noopEvents{},
+	}))
+
+	require.Equal(t, health.StatusDegraded, checkStatuses(p.Readiness(context.Background()))["outbox"])
+}
+
+func TestPlugin_HealthRoutes_StayUpWhileShuttingDown(t *testing.T) {
+	p, app := newEnabledPlugin(t, noopEvents{})
+	router := chi.NewRouter()
+	p.RegisterRoutes(router)
+	require.NoError(t, p.Disable(context.Background(), app))
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/tenants/health/live", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/tenants/health/ready", nil))
+	require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+}
+
+func TestPlugin_OpenAPI_CoversRoutes(t *testing.T) {
+	p, _ := newEnabledPlugin(t, noopEvents{})
+	router := chi.NewRouter()
+	p.RegisterRoutes(router)
+	require.NoError(t, openapi.Check(p.OpenAPI(), router))
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/tenants/openapi.json", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var doc openapi.Document
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &doc))
+	require.Equal(t, openapi.Version, doc.OpenAPI)
+	op := doc.Operation(http.MethodPost, "/tenants/{id}/members")
+	require.NotNil(t, op)
+	require.Equal(t, "#/components/schemas/AddMemberRequest", op.RequestBody.Content["application/json"].Schema.Ref)
+	for _, status := range []string{"200", "400", "403", "404", "409", "500", "503"} {
+		require.Contains(t, op.Responses, status)
+	}
+	require.Contains(t, doc.Components.Schemas, "TenantDTO")
+}
+
+func TestPlugin_Metrics_InstrumentsRoutes(t *testing.T) {
+	reg := metrics.NewRegistry()
+	sr := plugin.NewServiceRegistry()
+	require.NoError(t, sr.Register(ServiceKeyTenantFactory, mockFactory{}))
+	require.NoError(t, sr.Register("domain.service", core.DomainWriter(newMockDomainWriter())))
+	require.NoError(t, sr.Register(metrics.ServiceKeyRecorder, metrics.Recorder(reg)))
+
+	p := &TenantPlugin{}
+	require.NoError(t, p.Enable(context.Background(), &plugin.AppContext{
+		Logger:   zap.NewNop(),
+		Services: sr,
+		Events:   noopEvents{},
+	}))
+	router := chi.NewRouter()
+	p.RegisterRoutes(router)
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/tenants/me", nil))
+	require.Equal(t, 1.0, reg.Value("tenant_http_requests_total", metrics.Labels{
+		"method": http.MethodGet,
+		"route":  "/tenants/me",
+		"status": "401",
+	}))
+
+	// The mock service has no database, so the gauge collector reports an error.
+	rec := httptest.NewRecorder()
+	reg.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Contains(t, rec.Body.String(), "tenant gauges: database client not initialized")
+}
+
+// tracingFactory supplies a tracer through the factory.
+type tracingFactory struct {
+	mockFactory
+	tracer tracing.Tracer
+}
+
+func (f tracingFactory) Tracer() tracing.Tracer { return f.tracer }
+
+func TestPlugin_Tracing_ContinuesTraceAcrossRoutesAndEvents(t *testing.T) {
+	exp := tracing.NewInMemoryExporter()
+	sr := plugin.NewServiceRegistry()
+	require.NoError(t, sr.Register(ServiceKeyTenantFactory, ServiceFactory(tracingFactory{tracer: tracing.NewTracer(exp)})))
+	require.NoError(t, sr.Register("domain.service", core.DomainWriter(newMockDomainWriter())))
+
+	bus := newRecordingBus()
+	p := &TenantPlugin{}
+	require.NoError(t, p.Enable(context.Background(), &plugin.AppContext{
+		Logger:   zap.NewNop(),
+		Services: sr,
+		Events:   bus,
+	}))
+	p.SubscribeEvents(bus)
+	router := chi.NewRouter()
+	p.RegisterRoutes(router)
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := httptest.NewRequest(http.MethodGet, "/tenants/me", nil)
+	req.Header.Set(tracing.TraceParentKey, "00-"+traceID+"-00f067aa0ba902b7-01")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	span, ok := exp.Find("tenant.http")
+	require.True(t, ok)
+	require.Contains(t, span.Context.TraceParent(), traceID)
+	require.Equal(t, "/tenants/me", span.Attributes["http.route"])
+
+	// A subscriber continues the trace carried in the event payload.
+	require.NoError(t, bus.Publish(context.Background(), plugin.Event{
+		Name: "user.deleted",
+		Data: map[string]any{tracing.TraceParentKey: "00-" + traceID + "-00f067aa0ba902b7-01"},
+	}))
+	handle, ok := exp.Find("event_bus.handle")
+	require.True(t, ok)
+	require.Contains(t, handle.Context.TraceParent(), traceID)
+	_, ok = exp.Find("tenant.on_user_deleted")
+	require.True(t, ok)
+}
+
+// seedImpersonation stores an active session for actor in the domain of
+// tenant "acme" and returns its token.
+func seedImpersonation(t *testing.T, p *TenantPlugin, actor uuid.UUID) (*core.ResolvedDomain, string) {
+	t.Helper()
+	domain := &core.ResolvedDomain{DomainID: uuid.New(), TypeCode: "tenant", Key: "acme"}
+	p.requestDeps().domainSvc.(*mockDomainWriter).domains["tenant:acme"] = domain
+
+	secret := []byte("0123456789abcdef0123456789abcdef")
+	sum := sha256.Sum256(secret)
+	store := &memImpersonations{}
+	session := shared.ImpersonationSession{
+		ID:         uuid.New(),
+		TenantCode: "acme",
+		DomainID:   domain.DomainID,
+		ActorID:    actor,
+		Reason:     "support",
+		TokenHash:  hex.EncodeToString(sum[:]),
+		CreatedAt:  time.Now(),
+		ExpiresAt:  time.Now().Add(time.Hour),
+	}
+	require.NoError(t, store.PutSession(context.Background(), session))
+	p.service().SetImpersonationStore(store)
+	return domain, session.ID.String() + "." + base64.RawURLEncoding.EncodeToString(secret)
+}
+
+type memImpersonations struct {
+	sessions []shared.ImpersonationSession
+}
+
+func (m *memImpersonations) PutSession(_ context.Context, s shared.ImpersonationSession) error {
+	m.sessions = append(m.sessions, s)
+	return nil
+}
+
+func (m *memImpersonations) GetSession(_ context.Context, id uuid.UUID) (*shared.ImpersonationSession, error) {
+	for i := range m.sessions {
+		if m.sessions[i].ID == id {
+			return &m.sessions[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memImpersonations) ListSessions(context.Context, uuid.UUID) ([]shared.ImpersonationSession, error) {
+	return m.sessions, nil
+}
+
+func (m *memImpersonations) OpenSessions(_ context.Context, domainID, actorID uuid.UUID) ([]shared.ImpersonationSession, error) {
+	var out []shared.ImpersonationSession
+	for _, s := range m.sessions {
+		if s.DomainID == domainID && s.ActorID == actorID && s.RevokedAt == nil {
+			out = append(out, s)
+		}
+	}
+	return out, nil
+}
+
+func (m *memImpersonations) DeleteEndedSessions(context.Context, time.Time) (int, error) {
+	return 0, nil
+}
+
+func TestPlugin_ResolveDomain_Impersonation(t *testing.T) {
+	p, _ := newEnabledPlugin(t, noopEvents{})
+	actor := uuid.New()
+	domain, token := seedImpersonation(t, p, actor)
+	ctx := core.WithIdentity(context.Background(), core.Identity{UserID: actor})
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("X-Impersonation-Token", token)
+	resolved, ok, err := p.ResolveDomain(ctx, r)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, domain.DomainID, resolved.DomainID)
+
+	_, _, err = p.ResolveDomain(core.WithIdentity(context.Background(), core.Identity{UserID: uuid.New()}), r)
+	require.ErrorIs(t, err, shared.ErrImpersonationNotFound, "tokens are bound to their actor")
+
+	member, err := p.ValidateMembership(ctx, domain.DomainID, actor)
+	require.NoError(t, err)
+	require.True(t, member)
+}
+
+// recordingAuditLog keeps the impersonation audit records it is given, or
+// fails with err.
+type recordingAuditLog struct {
+	mu      sync.Mutex
+	records []shared.ImpersonationAudit
+	err     error
+}
+
+func (l *recordingAuditLog) RecordImpersonation(_ context.Context, audit shared.ImpersonationAudit) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.err != nil {
+		return l.err
+	}
+	l.records = append(l.records, audit)
+	return nil
+}
+
+func TestPlugin_ImpersonationMiddleware(t *testing.T) {
+	p, _ := newEnabledPlugin(t, noopEvents{})
+	actor := uuid.New()
+	domain, token := seedImpersonation(t, p, actor)
+	audits := &recordingAuditLog{}
+	p.service().SetImpersonationAuditLog(audits)
+
+	var seen *coremod.ActingContext
+	var mark shared.ImpersonationMark
+	handler := p.ImpersonationMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = coremod.GetActingContext(r.Context())
+		mark, _ = tenantmod.ImpersonationMarkFromContext(r.Context())
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	serve := func(token string) int {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r = r.WithContext(core.WithIdentity(r.Context(), core.Identity{UserID: actor}))
+		if token != "" {
+			r.Header.Set("X-Impersonation-Token", token)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, r)
+		return rec.Code
+	}
+
+	require.Equal(t, http.StatusNoContent, serve(""))
+	require.Nil(t, seen, "requests without a token pass through")
+
+	require.Equal(t, http.StatusNoContent, serve(token))
+	require.NotNil(t, seen)
+	require.True(t, seen.IsImpersonating)
+	require.Equal(t, actor, seen.ActorID)
+	require.Equal(t, domain.DomainID, seen.Domain.DomainID)
+	require.Equal(t, "support", seen.ImpersonateReason)
+	require.Equal(t, actor, mark.ActorID)
+
+	// Each impersonated request is recorded before it is served.
+	require.Len(t, audits.records, 1)
+	record := audits.records[0]
+	require.True(t, record.Impersonated)
+	require.Equal(t, actor, record.ImpersonatorID)
+	require.Equal(t, mark.SessionID, record.SessionID)
+	require.Equal(t, domain.DomainID, record.DomainID)
+	require.Equal(t, http.MethodGet, record.Method)
+
+	require.Equal(t, http.StatusUnauthorized, serve("not-a-token"))
+	require.Len(t, audits.records, 1)
+
+	seen = nil
+	audits.err = errors.New("audit log down")
+	require.Equal(t, http.StatusInternalServerError, serve(token))
+	require.Nil(t, seen, "requests that cannot be audited are not served")
+}
+
+// memServiceAccounts is a ServiceAccountStore holding one account and its
+// keys.
+type memServiceAccounts struct {
+	account shared.ServiceAccount
+	keys    map[uuid.UUID]shared.APIKey
+}
+
+func (m *memServiceAccounts) PutAccount(_ context.Context, a shared.ServiceAccount) error {
+	m.account = a
+	return nil
+}
+
+func (m *memServiceAccounts) GetAccount(_ context.Context, id uuid.UUID) (*shared.ServiceAccount, error) {
+	if m.account.ID != id {
+		return nil, nil
+	}
+	a := m.account
+	return &a, nil
+}
+
+func (m *memServiceAccounts) ListAccounts(context.Context, uuid.UUID) ([]shared.ServiceAccount, error) {
+	return []shared.ServiceAccount{m.account}, nil
+}
+
+func (m *memServiceAccounts) DeleteAccount(context.Context, uuid.UUID) error { return nil }
+
+func (m *memServiceAccounts) PutKey(_ context.Context, k shared.APIKey) error {
+	m.keys[k.ID] = k
+	return nil
+}
+
+func (m *memServiceAccounts) GetKey(_ context.Context, id uuid.UUID) (*shared.APIKey, error) {
+	k, ok := m.keys[id]
+	if !ok {
+		return nil, nil
+	}
+	return &k, nil
+}
+
+func (m *memServiceAccounts) ListKeys(context.Context, uuid.UUID) ([]shared.APIKey, error) {
+	return nil, nil
+}
+
+func (m *memServiceAccounts) TouchKey(_ context.Context, id uuid.UUID, at time.Time) error {
+	if k, ok := m.keys[id]; ok {
+		k.LastUsedAt = &at
+		m.keys[id] = k
+	}
+	return nil
+}
+
+// seedServiceAccount stores a service account of tenant "acme" with one API
+// key and returns the account and the key token.
+func seedServiceAccount(t *testing.T, p *TenantPlugin) (*memServiceAccounts, string) {
+	t.Helper()
+	domain := &core.ResolvedDomain{DomainID: uuid.New(), TypeCode: "tenant", Key: "acme"}
+	p.requestDeps().domainSvc.(*mockDomainWriter).domains["tenant:acme"] = domain
+
+	secret := []byte("0123456789abcdef0123456789abcdef")
+	sum := sha256.Sum256(secret)
+	store := &memServiceAccounts{
+		account: shared.ServiceAccount{ID: uuid.New(), TenantID: uuid.New(), TenantCode: "acme", DomainID: domain.DomainID, Name: "ci", Role: "member"},
+		keys:    make(map[uuid.UUID]shared.APIKey),
+	}
+	key := shared.APIKey{ID: uuid.New(), AccountID: store.account.ID, KeyHash: hex.EncodeToString(sum[:]), CreatedAt: time.Now()}
+	require.NoError(t, store.PutKey(context.Background(), key))
+	p.service().SetServiceAccountStore(store)
+	return store, key.ID.String() + "." + base64.RawURLEncoding.EncodeToString(secret)
+}
+
+func TestPlugin_APIKeyMiddleware(t *testing.T) {
+	p, _ := newEnabledPlugin(t, noopEvents{})
+	store, token := seedServiceAccount(t, p)
+
+	var identity core.Identity
+	var acting *coremod.ActingContext
+	var resolved *core.ResolvedDomain
+	handler := p.APIKeyMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		identity, _ = core.GetIdentity(r.Context())
+		acting = coremod.GetActingContext(r.Context())
+		resolved, _, _ = p.ResolveDomain(r.Context(), r)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	serve := func(auth string) int {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if auth != "" {
+			r.Header.Set("Authorization", auth)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, r)
+		return rec.Code
+	}
+
+	require.Equal(t, http.StatusNoContent, serve("Bearer some.jwt"))
+	require.Nil(t, acting, "other schemes pass through")
+
+	require.Equal(t, http.StatusNoContent, serve("ApiKey "+token))
+	require.Equal(t, store.account.ID, identity.UserID)
+	require.Equal(t, core.IdentityTypeAPIKey, identity.Type)
+	require.Equal(t, "acme", identity.TenantID)
+	require.Equal(t, store.account.DomainID, acting.Domain.DomainID)
+	require.Equal(t, store.account.DomainID, resolved.DomainID)
+	for _, k := range store.keys {
+		require.NotNil(t, k.LastUsedAt)
+	}
+
+	require.Equal(t, http.StatusUnauthorized, serve("ApiKey not-a-key"))
+	store.account.Disabled = true
+	require.Equal(t, http.StatusUnauthorized, serve("apikey "+token))
+}
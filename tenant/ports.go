@@ -1,11 +1,14 @@
 package tenant
 
 import (
+	"context"
+
 	"github.com/leeforge/core"
 	"github.com/leeforge/framework/logging"
 	"github.com/leeforge/framework/plugin"
 	"github.com/leeforge/plugins/tenant/shared"
 	tenantmod "github.com/leeforge/plugins/tenant/tenant"
+	"github.com/leeforge/plugins/tracing"
 )
 
 const ServiceKeyTenantFactory = "adapter.tenant.factory"
@@ -24,7 +27,61 @@ type ServiceFactory interface {
 
 // Re-export interface types from shared so factory implementations import from this package.
 type (
-	RoleSeeder = shared.RoleSeeder
-	UserLookup = shared.UserLookup
-	UserInfo   = shared.UserInfo
+	RoleSeeder         = shared.RoleSeeder
+	RoleTemplateSeeder = shared.RoleTemplateSeeder
+	RoleCatalog        = shared.RoleCatalog
+	UserLookup         = shared.UserLookup
+	UserFinder         = shared.UserFinder
+	UserInfo           = shared.UserInfo
+
+	MemberTermStore      = shared.MemberTermStore
+	PermissionResolver   = shared.PermissionResolver
+	RoleTemplateSource   = shared.RoleTemplateSource
+	OrganizationCloner   = shared.OrganizationCloner
+	OrganizationArchiver = shared.OrganizationArchiver
+	OrganizationRecord   = shared.OrganizationRecord
+	ImpersonationStore   = shared.ImpersonationStore
+	ServiceAccountStore  = shared.ServiceAccountStore
+	TenantAttributeStore = shared.TenantAttributeStore
+	WebhookStore         = shared.WebhookStore
 )
+
+// OutboxMonitor is optionally implemented by a ServiceFactory whose host
+// relays events through a persistent outbox. The plugin reports the backlog
+// in its readiness checks.
+type OutboxMonitor interface {
+	OutboxBacklog(ctx context.Context) (int, error)
+}
+
+// RoleCatalogProvider is optionally implemented by a ServiceFactory that
+// lists and creates the roles of a tenant domain. Without it member roles
+// are not validated, role listings are empty and clones skip custom roles.
+type RoleCatalogProvider interface {
+	RoleCatalog() RoleCatalog
+}
+
+// MemberTermProvider is optionally implemented by a ServiceFactory that
+// persists membership types and validity windows. Without it they are kept
+// in memory and lost on restart.
+type MemberTermProvider interface {
+	MemberTerms() MemberTermStore
+}
+
+// PermissionProvider is optionally implemented by a ServiceFactory that
+// resolves the permissions of a tenant role. Without it the caller's
+// memberships are listed without their effective permissions.
+type PermissionProvider interface {
+	Permissions() PermissionResolver
+}
+
+// RoleTemplateProvider is optionally implemented by a ServiceFactory whose
+// host assigns role templates per tenant, for example by plan. Tenants
+// without their own templates use the roleTemplates config.
+type RoleTemplateProvider interface {
+	RoleTemplateSource() RoleTemplateSource
+}
+
+// TracerProvider is optionally implemented by a ServiceFactory to supply the
+// tracer used for service, port and event spans. Tracing is disabled when
+// the factory does not implement it.
+type TracerProvider = tracing.TracerProvider
@@ -0,0 +1,96 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/leeforge/framework/http/responder"
+
+	"github.com/leeforge/core"
+	coremod "github.com/leeforge/core/core"
+	"github.com/leeforge/core/server/httplog"
+
+	"github.com/leeforge/plugins/tenant/shared"
+	tenantmod "github.com/leeforge/plugins/tenant/tenant"
+)
+
+// apiKeyScheme is the Authorization scheme of service account API keys.
+const apiKeyScheme = "ApiKey"
+
+// apiKeyToken returns the API key sent as "Authorization: ApiKey <token>",
+// or "" when the request carries none.
+func apiKeyToken(r *http.Request) string {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, apiKeyScheme) {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// serviceAccount returns the service account that authenticated r: the one
+// set by APIKeyMiddleware, or else the owner of the API key sent with r. It
+// returns nil when the request carries no API key.
+func (p *TenantPlugin) serviceAccount(ctx context.Context, r *http.Request) (*shared.ServiceAccount, error) {
+	if account, ok := tenantmod.ServiceAccountFromContext(ctx); ok {
+		return account, nil
+	}
+	token := apiKeyToken(r)
+	svc := p.service()
+	if token == "" || svc == nil {
+		return nil, nil
+	}
+	return svc.AuthenticateAPIKey(ctx, token)
+}
+
+// APIKeyMiddleware authenticates requests sent with a service account API
+// key. It runs in place of the host's user authentication for such
+// requests: the identity is the service account (core.IdentityTypeAPIKey)
+// and the acting context is its tenant domain. Requests without an API key
+// pass through; unknown, expired or revoked keys and disabled accounts are
+// rejected with 401.
+func (p *TenantPlugin) APIKeyMiddleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			account, err := p.serviceAccount(ctx, r)
+			switch {
+			case errors.Is(err, shared.ErrAPIKeyNotFound), errors.Is(err, shared.ErrAPIKeyInactive),
+				errors.Is(err, shared.ErrServiceAccountDisabled), errors.Is(err, shared.ErrTenantNotFound):
+				responder.Unauthorized(w, r, "Invalid API key")
+				return
+			case err != nil:
+				httplog.Error(p.logger, r, "Failed to authenticate API key", err)
+				responder.DatabaseError(w, r, "Failed to authenticate API key")
+				return
+			case account == nil:
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			dom, err := p.requestDeps().domainSvc.ResolveDomainByID(ctx, account.DomainID)
+			if err != nil {
+				httplog.Error(p.logger, r, "Failed to resolve service account domain", err)
+				responder.DatabaseError(w, r, "Failed to authenticate API key")
+				return
+			}
+			ctx = core.WithIdentity(ctx, core.Identity{
+				UserID:   account.ID,
+				Type:     core.IdentityTypeAPIKey,
+				TenantID: account.TenantCode,
+			})
+			ctx = coremod.WithActingContext(ctx, &coremod.ActingContext{
+				ActorID: account.ID,
+				Domain: &coremod.ResolvedDomain{
+					DomainID:    dom.DomainID,
+					TypeCode:    dom.TypeCode,
+					Key:         dom.Key,
+					DisplayName: dom.DisplayName,
+				},
+			})
+			ctx = tenantmod.WithServiceAccount(ctx, account)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,491 @@
+package shared
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/leeforge/core"
+)
+
+// Config holds the host-tunable settings of the tenant plugin. Hosts set
+// these under the plugin's config section; unset keys keep their defaults.
+type Config struct {
+	// DefaultPageSize is used when a list request omits pageSize.
+	DefaultPageSize int `json:"defaultPageSize"`
+	// MaxPageSize caps the pageSize accepted by list endpoints.
+	MaxPageSize int `json:"maxPageSize"`
+	// DefaultMemberRole is assigned when a member is added without a role.
+	DefaultMemberRole string `json:"defaultMemberRole"`
+	// OwnerRole is assigned to the creator of a tenant.
+	OwnerRole string `json:"ownerRole"`
+	// TenantHeader is the request header carrying the tenant key for domain resolution.
+	TenantHeader string `json:"tenantHeader"`
+	// DomainTypeCode is the domain type under which tenant domains are registered.
+	DomainTypeCode string `json:"domainTypeCode"`
+	// OutboxBacklogWarn degrades readiness once the event outbox holds more
+	// pending entries than this.
+	OutboxBacklogWarn int `json:"outboxBacklogWarn"`
+	// MemberSweepIntervalSeconds is how often expired memberships are removed.
+	MemberSweepIntervalSeconds int `json:"memberSweepIntervalSeconds"`
+	// RoleTemplates are provisioned into every new tenant domain. The owner
+	// template must match OwnerRole; DefaultMemberRole must be one of them.
+	RoleTemplates []RoleTemplate `json:"roleTemplates"`
+	// TenantTemplates are the named templates new tenants can be cloned from.
+	TenantTemplates []TenantTemplate `json:"tenantTemplates"`
+	// ImpersonationHeader is the request header carrying an impersonation
+	// session token.
+	ImpersonationHeader string `json:"impersonationHeader"`
+	// ImpersonationTTLSeconds is the lifetime of an impersonation session
+	// when the request does not set one.
+	ImpersonationTTLSeconds int `json:"impersonationTtlSeconds"`
+	// MaxImpersonationTTLSeconds caps the lifetime of an impersonation session.
+	MaxImpersonationTTLSeconds int `json:"maxImpersonationTtlSeconds"`
+	// ImpersonationRetentionSeconds is how long an expired or revoked
+	// impersonation session is kept for listing before the sweeper removes it.
+	ImpersonationRetentionSeconds int `json:"impersonationRetentionSeconds"`
+	// WebhookMaxAttempts is the number of delivery attempts after which a
+	// webhook delivery is dead-lettered.
+	WebhookMaxAttempts int `json:"webhookMaxAttempts"`
+	// WebhookRetryBaseSeconds is the delay before the first retry; each
+	// further retry doubles it, up to WebhookRetryMaxSeconds.
+	WebhookRetryBaseSeconds int `json:"webhookRetryBaseSeconds"`
+	// WebhookRetryMaxSeconds caps the delay between delivery attempts.
+	WebhookRetryMaxSeconds int `json:"webhookRetryMaxSeconds"`
+	// WebhookTimeoutSeconds bounds a single delivery request.
+	WebhookTimeoutSeconds int `json:"webhookTimeoutSeconds"`
+	// WebhookPollIntervalSeconds is how often due deliveries are sent.
+	WebhookPollIntervalSeconds int `json:"webhookPollIntervalSeconds"`
+	// WebhookDeliveryRetentionSeconds is how long delivered and dead
+	// deliveries stay in the delivery log before the sweeper removes them.
+	WebhookDeliveryRetentionSeconds int `json:"webhookDeliveryRetentionSeconds"`
+	// WebhookAllowedHosts lists the host names, IP addresses and CIDR ranges
+	// that webhook endpoints may reach although they are loopback, private
+	// or link-local. Deliveries to any other such address are refused.
+	WebhookAllowedHosts []string `json:"webhookAllowedHosts"`
+	// IdempotencyTTLSeconds is how long the response to a request with an
+	// Idempotency-Key header is kept for replay.
+	IdempotencyTTLSeconds int `json:"idempotencyTtlSeconds"`
+}
+
+// DefaultConfig returns the built-in tenant plugin settings.
+func DefaultConfig() Config {
+	return Config{
+		DefaultPageSize:   20,
+		MaxPageSize:       100,
+		DefaultMemberRole: "member",
+		OwnerRole:         "tenant_admin",
+		TenantHeader:      "X-Tenant-ID",
+		DomainTypeCode:    "tenant",
+		OutboxBacklogWarn: 1000,
+
+		MemberSweepIntervalSeconds: 60,
+		RoleTemplates:              DefaultRoleTemplates("tenant_admin", "member"),
+
+		ImpersonationHeader:           "X-Impersonation-Token",
+		ImpersonationTTLSeconds:       900,
+		MaxImpersonationTTLSeconds:    3600,
+		ImpersonationRetentionSeconds: 604800,
+
+		WebhookMaxAttempts:         8,
+		WebhookRetryBaseSeconds:    30,
+		WebhookRetryMaxSeconds:     3600,
+		WebhookTimeoutSeconds:      10,
+		WebhookPollIntervalSeconds: 5,
+
+		WebhookDeliveryRetentionSeconds: 2592000,
+
+		IdempotencyTTLSeconds: 86400,
+	}
+}
+
+// WithDefaults returns c with every zero-valued setting replaced by its default.
+func (c Config) WithDefaults() Config {
+	d := DefaultConfig()
+	if c.OwnerRole == "" {
+		if owner, ok := OwnerTemplate(c.RoleTemplates); ok {
+			c.OwnerRole = owner.Code
+		}
+	}
+	if c.DefaultPageSize == 0 {
+		c.DefaultPageSize = d.DefaultPageSize
+	}
+	if c.MaxPageSize == 0 {
+		c.MaxPageSize = d.MaxPageSize
+	}
+	if c.DefaultMemberRole == "" {
+		c.DefaultMemberRole = d.DefaultMemberRole
+	}
+	if c.OwnerRole == "" {
+		c.OwnerRole = d.OwnerRole
+	}
+	if c.TenantHeader == "" {
+		c.TenantHeader = d.TenantHeader
+	}
+	if c.DomainTypeCode == "" {
+		c.DomainTypeCode = d.DomainTypeCode
+	}
+	if c.OutboxBacklogWarn == 0 {
+		c.OutboxBacklogWarn = d.OutboxBacklogWarn
+	}
+	if c.MemberSweepIntervalSeconds == 0 {
+		c.MemberSweepIntervalSeconds = d.MemberSweepIntervalSeconds
+	}
+	if len(c.RoleTemplates) == 0 {
+		c.RoleTemplates = DefaultRoleTemplates(c.OwnerRole, c.DefaultMemberRole)
+	}
+	if c.ImpersonationHeader == "" {
+		c.ImpersonationHeader = d.ImpersonationHeader
+	}
+	if c.ImpersonationTTLSeconds == 0 {
+		c.ImpersonationTTLSeconds = d.ImpersonationTTLSeconds
+	}
+	if c.MaxImpersonationTTLSeconds == 0 {
+		c.MaxImpersonationTTLSeconds = d.MaxImpersonationTTLSeconds
+	}
+	if c.ImpersonationRetentionSeconds == 0 {
+		c.ImpersonationRetentionSeconds = d.ImpersonationRetentionSeconds
+	}
+	if c.WebhookMaxAttempts == 0 {
+		c.WebhookMaxAttempts = d.WebhookMaxAttempts
+	}
+	if c.WebhookRetryBaseSeconds == 0 {
+		c.WebhookRetryBaseSeconds = d.WebhookRetryBaseSeconds
+	}
+	if c.WebhookRetryMaxSeconds == 0 {
+		c.WebhookRetryMaxSeconds = d.WebhookRetryMaxSeconds
+	}
+	if c.WebhookTimeoutSeconds == 0 {
+		c.WebhookTimeoutSeconds = d.WebhookTimeoutSeconds
+	}
+	if c.WebhookPollIntervalSeconds == 0 {
+		c.WebhookPollIntervalSeconds = d.WebhookPollIntervalSeconds
+	}
+	if c.WebhookDeliveryRetentionSeconds == 0 {
+		c.WebhookDeliveryRetentionSeconds = d.WebhookDeliveryRetentionSeconds
+	}
+	if c.IdempotencyTTLSeconds == 0 {
+		c.IdempotencyTTLSeconds = d.IdempotencyTTLSeconds
+	}
+	return c
+}
+
+// Validate reports every invalid setting in a single error.
+func (c Config) Validate() error {
+	var errs []error
+	if c.DefaultPageSize < 1 {
+		errs = append(errs, fmt.Errorf("defaultPageSize must be at least 1, got %d", c.DefaultPageSize))
+	}
+	if c.MaxPageSize < 1 {
+		errs = append(errs, fmt.Errorf("maxPageSize must be at least 1, got %d", c.MaxPageSize))
+	}
+	if c.DefaultPageSize > c.MaxPageSize {
+		errs = append(errs, fmt.Errorf("defaultPageSize (%d) must not exceed maxPageSize (%d)", c.DefaultPageSize, c.MaxPageSize))
+	}
+	if c.OutboxBacklogWarn < 1 {
+		errs = append(errs, fmt.Errorf("outboxBacklogWarn must be at least 1, got %d", c.OutboxBacklogWarn))
+	}
+	if c.MemberSweepIntervalSeconds < 1 {
+		errs = append(errs, fmt.Errorf("memberSweepIntervalSeconds must be at least 1, got %d", c.MemberSweepIntervalSeconds))
+	}
+	if strings.TrimSpace(c.DefaultMemberRole) == "" {
+		errs = append(errs, errors.New("defaultMemberRole must not be empty"))
+	}
+	if strings.TrimSpace(c.OwnerRole) == "" {
+		errs = append(errs, errors.New("ownerRole must not be empty"))
+	}
+	if err := ValidateRoleTemplates(c.RoleTemplates); err != nil {
+		errs = append(errs, fmt.Errorf("roleTemplates: %w", err))
+	} else {
+		if owner, _ := OwnerTemplate(c.RoleTemplates); owner.Code != c.OwnerRole {
+			errs = append(errs, fmt.Errorf("ownerRole %q must match the owner role template %q", c.OwnerRole, owner.Code))
+		}
+		if !HasRoleTemplate(c.RoleTemplates, c.DefaultMemberRole) {
+			errs = append(errs, fmt.Errorf("defaultMemberRole %q is not defined in roleTemplates", c.DefaultMemberRole))
+		}
+	}
+	if err := ValidateTenantTemplates(c.TenantTemplates); err != nil {
+		errs = append(errs, fmt.Errorf("tenantTemplates: %w", err))
+	}
+	if !isHeaderToken(c.TenantHeader) {
+		errs = append(errs, fmt.Errorf("tenantHeader %q is not a valid HTTP header name", c.TenantHeader))
+	}
+	if !isHeaderToken(c.ImpersonationHeader) {
+		errs = append(errs, fmt.Errorf("impersonationHeader %q is not a valid HTTP header name", c.ImpersonationHeader))
+	} else if strings.EqualFold(c.ImpersonationHeader, c.TenantHeader) {
+		errs = append(errs, errors.New("impersonationHeader must differ from tenantHeader"))
+	}
+	if c.ImpersonationTTLSeconds < 1 {
+		errs = append(errs, fmt.Errorf("impersonationTtlSeconds must be at least 1, got %d", c.ImpersonationTTLSeconds))
+	}
+	if c.ImpersonationTTLSeconds > c.MaxImpersonationTTLSeconds {
+		errs = append(errs, fmt.Errorf("impersonationTtlSeconds (%d) must not exceed maxImpersonationTtlSeconds (%d)", c.ImpersonationTTLSeconds, c.MaxImpersonationTTLSeconds))
+	}
+	for _, setting := range []struct {
+		name  string
+		value int
+	}{
+		{"impersonationRetentionSeconds", c.ImpersonationRetentionSeconds},
+		{"webhookMaxAttempts", c.WebhookMaxAttempts},
+		{"webhookRetryBaseSeconds", c.WebhookRetryBaseSeconds},
+		{"webhookTimeoutSeconds", c.WebhookTimeoutSeconds},
+		{"webhookPollIntervalSeconds", c.WebhookPollIntervalSeconds},
+		{"webhookDeliveryRetentionSeconds", c.WebhookDeliveryRetentionSeconds},
+		{"idempotencyTtlSeconds", c.IdempotencyTTLSeconds},
+	} {
+		if setting.value < 1 {
+			errs = append(errs, fmt.Errorf("%s must be at least 1, got %d", setting.name, setting.value))
+		}
+	}
+	if c.WebhookRetryBaseSeconds > c.WebhookRetryMaxSeconds {
+		errs = append(errs, fmt.Errorf("webhookRetryBaseSeconds (%d) must not exceed webhookRetryMaxSeconds (%d)", c.WebhookRetryBaseSeconds, c.WebhookRetryMaxSeconds))
+	}
+	for _, host := range c.WebhookAllowedHosts {
+		if !validAllowedHost(strings.TrimSpace(host)) {
+			errs = append(errs, fmt.Errorf("webhookAllowedHosts entry %q is not a host name, IP address or CIDR range", host))
+		}
+	}
+	switch code := strings.TrimSpace(c.DomainTypeCode); {
+	case code == "":
+		errs = append(errs, errors.New("domainTypeCode must not be empty"))
+	case code != c.DomainTypeCode || strings.ContainsAny(code, ": "):
+		errs = append(errs, fmt.Errorf("domainTypeCode %q must not contain spaces or ':'", c.DomainTypeCode))
+	case code == string(core.DomainPlatform):
+		errs = append(errs, fmt.Errorf("domainTypeCode must not be %q", core.DomainPlatform))
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %w", ErrInvalidConfig, errors.Join(errs...))
+}
+
+// validAllowedHost reports whether host is a CIDR range, an IP address or a
+// host name.
+func validAllowedHost(host string) bool {
+	if _, err := netip.ParsePrefix(host); err == nil {
+		return true
+	}
+	if _, err := netip.ParseAddr(host); err == nil {
+		return true
+	}
+	return host != "" && !strings.ContainsAny(host, " /:[]")
+}
+
+// CheckConfigKeys rejects keys in a raw config section that Config does not
+// define, so that typos fail fast instead of being silently ignored.
+func CheckConfigKeys(raw map[string]any) error {
+	known := make(map[string]struct{})
+	t := reflect.TypeOf(Config{})
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		known[name] = struct{}{}
+	}
+
+	var unknown []string
+	for key := range raw {
+		if _, ok := known[key]; !ok {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+	sort.Strings(unknown)
+	return fmt.Errorf("%w: unknown keys %s", ErrInvalidConfig, strings.Join(unknown, ", "))
+}
+
+// PageBounds normalizes page and pageSize against the configured limits.
+func (c Config) PageBounds(page, pageSize int) (int, int) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = c.DefaultPageSize
+	}
+	if pageSize > c.MaxPageSize {
+		pageSize = c.MaxPageSize
+	}
+	return page, pageSize
+}
+
+// isHeaderToken reports whether s is a valid RFC 7230 header field name.
+func isHeaderToken(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case strings.ContainsRune("!#$%&'*+-.^_`|~", r):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// ConfigSchema is the JSON schema describing Config, for hosts that
+// validate or render plugin settings.
+const ConfigSchema = `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "title": "Tenant plugin configuration",
+  "type": "object",
+  "additionalProperties": false,
+  "properties": {
+    "defaultPageSize": {
+      "type": "integer",
+      "minimum": 1,
+      "default": 20,
+      "description": "Page size used when a list request omits pageSize."
+    },
+    "maxPageSize": {
+      "type": "integer",
+      "minimum": 1,
+      "default": 100,
+      "description": "Upper bound for the pageSize accepted by list endpoints."
+    },
+    "defaultMemberRole": {
+      "type": "string",
+      "minLength": 1,
+      "default": "member",
+      "description": "Role assigned when a member is added without a role."
+    },
+    "ownerRole": {
+      "type": "string",
+      "minLength": 1,
+      "default": "tenant_admin",
+      "description": "Role assigned to the creator of a tenant."
+    },
+    "tenantHeader": {
+      "type": "string",
+      "pattern": "^[!#$%&'*+\\-.^_` + "`" + `|~0-9A-Za-z]+$",
+      "default": "X-Tenant-ID",
+      "description": "Request header carrying the tenant key for domain resolution."
+    },
+    "domainTypeCode": {
+      "type": "string",
+      "pattern": "^[^:\\s]+$",
+      "not": {"const": "platform"},
+      "default": "tenant",
+      "description": "Domain type under which tenant domains are registered."
+    },
+    "outboxBacklogWarn": {
+      "type": "integer",
+      "minimum": 1,
+      "default": 1000,
+      "description": "Pending outbox entries above which readiness is reported as degraded."
+    },
+    "memberSweepIntervalSeconds": {
+      "type": "integer",
+      "minimum": 1,
+      "default": 60,
+      "description": "Interval, in seconds, between runs of the expired membership sweeper."
+    },
+    "roleTemplates": {
+      "type": "array",
+      "minItems": 1,
+      "description": "Roles provisioned into every tenant domain. Exactly one template is the owner role.",
+      "items": {
+        "type": "object",
+        "additionalProperties": false,
+        "required": ["code", "name"],
+        "properties": {
+          "code": {"type": "string", "pattern": "^\\S+$"},
+          "name": {"type": "string", "minLength": 1},
+          "description": {"type": "string"},
+          "permissions": {"type": "array", "items": {"type": "string"}},
+          "owner": {"type": "boolean", "default": false}
+        }
+      }
+    },
+    "tenantTemplates": {
+      "type": "array",
+      "description": "Named templates new tenants can be cloned from.",
+      "items": {
+        "type": "object",
+        "additionalProperties": false,
+        "required": ["name", "sourceTenant"],
+        "properties": {
+          "name": {"type": "string", "pattern": "^[^\\s/]+$"},
+          "description": {"type": "string"},
+          "sourceTenant": {"type": "string", "minLength": 1, "description": "Code of the tenant to clone."},
+          "includeOrganizations": {"type": "boolean", "default": false}
+        }
+      }
+    },
+    "impersonationHeader": {
+      "type": "string",
+      "pattern": "^[!#$%&'*+\\-.^_` + "`" + `|~0-9A-Za-z]+$",
+      "default": "X-Impersonation-Token",
+      "description": "Request header carrying an impersonation session token."
+    },
+    "impersonationTtlSeconds": {
+      "type": "integer",
+      "minimum": 1,
+      "default": 900,
+      "description": "Lifetime, in seconds, of an impersonation session that does not set one."
+    },
+    "maxImpersonationTtlSeconds": {
+      "type": "integer",
+      "minimum": 1,
+      "default": 3600,
+      "description": "Upper bound, in seconds, for the lifetime of an impersonation session."
+    },
+    "impersonationRetentionSeconds": {
+      "type": "integer",
+      "minimum": 1,
+      "default": 604800,
+      "description": "How long, in seconds, an expired or revoked impersonation session is kept before it is pruned."
+    },
+    "webhookMaxAttempts": {
+      "type": "integer",
+      "minimum": 1,
+      "default": 8,
+      "description": "Delivery attempts after which a webhook delivery is dead-lettered."
+    },
+    "webhookRetryBaseSeconds": {
+      "type": "integer",
+      "minimum": 1,
+      "default": 30,
+      "description": "Delay, in seconds, before the first webhook retry; doubled for each further retry."
+    },
+    "webhookRetryMaxSeconds": {
+      "type": "integer",
+      "minimum": 1,
+      "default": 3600,
+      "description": "Upper bound, in seconds, for the delay between webhook delivery attempts."
+    },
+    "webhookTimeoutSeconds": {
+      "type": "integer",
+      "minimum": 1,
+      "default": 10,
+      "description": "Timeout, in seconds, of a single webhook delivery request."
+    },
+    "webhookPollIntervalSeconds": {
+      "type": "integer",
+      "minimum": 1,
+      "default": 5,
+      "description": "Interval, in seconds, between runs of the webhook delivery worker."
+    },
+    "webhookDeliveryRetentionSeconds": {
+      "type": "integer",
+      "minimum": 1,
+      "default": 2592000,
+      "description": "How long, in seconds, delivered and dead webhook deliveries are kept before they are pruned."
+    },
+    "webhookAllowedHosts": {
+      "type": "array",
+      "items": {"type": "string", "minLength": 1},
+      "description": "Host names, IP addresses and CIDR ranges webhook endpoints may reach although they are loopback, private or link-local."
+    },
+    "idempotencyTtlSeconds": {
+      "type": "integer",
+      "minimum": 1,
+      "default": 86400,
+      "description": "Time, in seconds, the response to a request with an Idempotency-Key header is kept for replay."
+    }
+  }
+}`
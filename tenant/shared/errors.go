@@ -1,14 +1,59 @@
 package shared
 
-import "errors"
+import (
+	"errors"
+	"net/http"
 
-// Tenant errors.
+	"github.com/leeforge/plugins/apierror"
+)
+
+// Tenant errors. Those declared with apierror.New are answered by the
+// handlers with their status, code and catalogue message (see messages.go).
 var (
 	ErrTenantNotFound      = errors.New("tenant not found")
-	ErrTenantCodeExists    = errors.New("tenant code already exists")
-	ErrInvalidTenant       = errors.New("invalid tenant data")
-	ErrMemberExists        = errors.New("user is already a member")
+	ErrVersionMismatch     = errors.New("tenant was modified since the expected version")
+	ErrTenantCodeExists    = apierror.New(http.StatusConflict, "tenant.code_exists", "tenant code already exists")
+	ErrInvalidTenant       = apierror.New(http.StatusBadRequest, "tenant.invalid", "invalid tenant data")
+	ErrMemberExists        = apierror.New(http.StatusConflict, "tenant.member_exists", "user is already a member")
 	ErrMemberNotFound      = errors.New("membership not found")
 	ErrPlatformDomainOnly  = errors.New("operation requires platform domain")
-	ErrParentTenantInvalid = errors.New("invalid parent tenant")
+	ErrParentTenantInvalid = apierror.New(http.StatusBadRequest, "tenant.parent_invalid", "invalid parent tenant")
+	ErrInvalidMemberTerm   = apierror.New(http.StatusBadRequest, "tenant.invalid_member_term", "invalid membership type or validity window")
+	ErrMemberSuspended     = apierror.New(http.StatusConflict, "tenant.member_suspended", "membership is suspended")
+	ErrMemberNotSuspended  = apierror.New(http.StatusConflict, "tenant.member_not_suspended", "membership is not suspended")
+	ErrInvalidMemberFilter = apierror.New(http.StatusBadRequest, "tenant.invalid_member_filter", "invalid member filter")
+	ErrInvalidRoleTemplate = apierror.New(http.StatusBadRequest, "tenant.invalid_role_template", "invalid role templates")
+	ErrInvalidRole         = apierror.New(http.StatusBadRequest, "tenant.invalid_role", "role is not defined in the tenant")
+
+	ErrTenantTemplateNotFound = errors.New("tenant template not found")
+	ErrTenantTemplateExists   = apierror.New(http.StatusConflict, "tenant.template_exists", "tenant template already exists")
+	ErrInvalidTenantTemplate  = apierror.New(http.StatusBadRequest, "tenant.invalid_template", "invalid tenant template")
+	ErrProvisioningFailed     = errors.New("tenant provisioning failed")
+	ErrOrganizationsDisabled  = errors.New("organization service is not available")
+	ErrInvalidArchive         = apierror.New(http.StatusBadRequest, "tenant.invalid_archive", "invalid tenant archive")
+
+	ErrImpersonationNotFound = errors.New("impersonation session not found")
+	ErrImpersonationInactive = apierror.New(http.StatusConflict, "tenant.impersonation_inactive", "impersonation session is expired or revoked")
+	ErrInvalidImpersonation  = apierror.New(http.StatusBadRequest, "tenant.invalid_impersonation", "invalid impersonation request")
+
+	ErrServiceAccountNotFound = errors.New("service account not found")
+	ErrServiceAccountExists   = apierror.New(http.StatusConflict, "tenant.service_account_exists", "service account name already exists")
+	ErrInvalidServiceAccount  = apierror.New(http.StatusBadRequest, "tenant.invalid_service_account", "invalid service account")
+	ErrServiceAccountDisabled = apierror.New(http.StatusConflict, "tenant.service_account_disabled", "service account is disabled")
+	ErrAPIKeyNotFound         = errors.New("api key not found")
+	ErrAPIKeyInactive         = apierror.New(http.StatusConflict, "tenant.api_key_inactive", "api key is expired or revoked")
+
+	ErrInvalidLabels        = apierror.New(http.StatusBadRequest, "tenant.invalid_labels", "invalid tenant labels or metadata")
+	ErrInvalidLabelSelector = apierror.New(http.StatusBadRequest, "tenant.invalid_label_selector", "invalid label selector")
+	ErrInvalidTenantFilter  = apierror.New(http.StatusBadRequest, "tenant.invalid_filter", "invalid tenant filter")
+
+	ErrWebhookNotFound         = errors.New("webhook not found")
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+	ErrInvalidWebhook          = apierror.New(http.StatusBadRequest, "tenant.invalid_webhook", "invalid webhook")
+	ErrTenantAdminRequired     = errors.New("operation requires the platform domain or a tenant admin")
+)
+
+// Configuration errors.
+var (
+	ErrInvalidConfig = errors.New("invalid tenant plugin config")
 )
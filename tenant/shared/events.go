@@ -1,14 +1,36 @@
 package shared
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/leeforge/plugins/tracing"
+)
 
 // Event topic constants.
 const (
-	EventTenantCreated       = "tenant.created"
-	EventTenantUpdated       = "tenant.updated"
-	EventTenantDeleted       = "tenant.deleted"
-	EventTenantMemberAdded   = "tenant.member.added"
-	EventTenantMemberRemoved = "tenant.member.removed"
+	EventTenantCreated           = "tenant.created"
+	EventTenantUpdated           = "tenant.updated"
+	EventTenantDeleted           = "tenant.deleted"
+	EventTenantMemberAdded       = "tenant.member.added"
+	EventTenantMemberRemoved     = "tenant.member.removed"
+	EventTenantMemberExpired     = "tenant.member.expired"
+	EventTenantMemberSuspended   = "tenant.member.suspended"
+	EventTenantMemberReactivated = "tenant.member.reactivated"
+	EventTenantProvisionProgress = "tenant.provision.progress"
+	EventTenantCloned            = "tenant.cloned"
+	EventTenantImported          = "tenant.imported"
+
+	EventTenantImpersonationStarted = "tenant.impersonation.started"
+	EventTenantImpersonationRevoked = "tenant.impersonation.revoked"
+
+	EventTenantServiceAccountCreated = "tenant.service_account.created"
+	EventTenantServiceAccountUpdated = "tenant.service_account.updated"
+	EventTenantServiceAccountDeleted = "tenant.service_account.deleted"
+	EventTenantAPIKeyCreated         = "tenant.api_key.created"
+	EventTenantAPIKeyRevoked         = "tenant.api_key.revoked"
+	EventTenantAPIKeyRotated         = "tenant.api_key.rotated"
 )
 
 // TenantEventData is the payload for tenant lifecycle events.
@@ -17,6 +39,10 @@ type TenantEventData struct {
 	TenantCode string    `json:"tenantCode"`
 	DomainID   uuid.UUID `json:"domainId"`
 	ActorID    uuid.UUID `json:"actorId"`
+	// Impersonation is set when the event was published while impersonating.
+	Impersonation *ImpersonationMark `json:"impersonation,omitempty"`
+	// Trace is the W3C traceparent of the publishing operation, if traced.
+	Trace string `json:"traceparent,omitempty"`
 }
 
 // MemberEventData is the payload for membership events.
@@ -25,4 +51,173 @@ type MemberEventData struct {
 	UserID   uuid.UUID `json:"userId"`
 	Role     string    `json:"role"`
 	ActorID  uuid.UUID `json:"actorId"`
+	// Reason is the optional explanation given for a suspension.
+	Reason string `json:"reason,omitempty"`
+	// Impersonation is set when the event was published while impersonating.
+	Impersonation *ImpersonationMark `json:"impersonation,omitempty"`
+	// Trace is the W3C traceparent of the publishing operation, if traced.
+	Trace string `json:"traceparent,omitempty"`
+}
+
+// ProvisionEventData is the payload of tenant.provision.progress, published
+// as each step of a provisioning flow finishes.
+type ProvisionEventData struct {
+	TenantID   uuid.UUID `json:"tenantId"`
+	TenantCode string    `json:"tenantCode"`
+	Step       string    `json:"step"`
+	Status     string    `json:"status"`
+	Count      int       `json:"count"`
+	Error      string    `json:"error,omitempty"`
+	// Impersonation is set when the event was published while impersonating.
+	Impersonation *ImpersonationMark `json:"impersonation,omitempty"`
+	// Trace is the W3C traceparent of the publishing operation, if traced.
+	Trace string `json:"traceparent,omitempty"`
+}
+
+// CloneEventData is the payload of tenant.cloned.
+type CloneEventData struct {
+	TenantID         uuid.UUID `json:"tenantId"`
+	TenantCode       string    `json:"tenantCode"`
+	DomainID         uuid.UUID `json:"domainId"`
+	SourceTenantID   uuid.UUID `json:"sourceTenantId"`
+	SourceTenantCode string    `json:"sourceTenantCode"`
+	// Template is the name of the tenant template cloned, if any.
+	Template string    `json:"template,omitempty"`
+	ActorID  uuid.UUID `json:"actorId"`
+	// Impersonation is set when the event was published while impersonating.
+	Impersonation *ImpersonationMark `json:"impersonation,omitempty"`
+	// Trace is the W3C traceparent of the publishing operation, if traced.
+	Trace string `json:"traceparent,omitempty"`
 }
+
+// ImpersonationEventData is the payload of tenant.impersonation.started and
+// tenant.impersonation.revoked. ActorID is the impersonating user; RevokedBy
+// is set on revocation.
+type ImpersonationEventData struct {
+	SessionID  uuid.UUID  `json:"sessionId"`
+	TenantID   uuid.UUID  `json:"tenantId"`
+	TenantCode string     `json:"tenantCode"`
+	DomainID   uuid.UUID  `json:"domainId"`
+	ActorID    uuid.UUID  `json:"actorId"`
+	Reason     string     `json:"reason"`
+	ExpiresAt  time.Time  `json:"expiresAt"`
+	RevokedBy  *uuid.UUID `json:"revokedBy,omitempty"`
+	// Trace is the W3C traceparent of the publishing operation, if traced.
+	Trace string `json:"traceparent,omitempty"`
+}
+
+// ServiceAccountEventData is the payload of service account and API key
+// events. KeyID is set on API key events; on tenant.api_key.rotated it is
+// the new key and PreviousKeyID the rotated one.
+type ServiceAccountEventData struct {
+	TenantID      uuid.UUID  `json:"tenantId"`
+	AccountID     uuid.UUID  `json:"accountId"`
+	Name          string     `json:"name"`
+	Role          string     `json:"role"`
+	KeyID         *uuid.UUID `json:"keyId,omitempty"`
+	PreviousKeyID *uuid.UUID `json:"previousKeyId,omitempty"`
+	ActorID       uuid.UUID  `json:"actorId"`
+	// Impersonation is set when the event was published while impersonating.
+	Impersonation *ImpersonationMark `json:"impersonation,omitempty"`
+	// Trace is the W3C traceparent of the publishing operation, if traced.
+	Trace string `json:"traceparent,omitempty"`
+}
+
+// TraceParent implements tracing.Carrier.
+func (d TenantEventData) TraceParent() string { return d.Trace }
+
+// WithTraceParent implements tracing.Carrier.
+func (d TenantEventData) WithTraceParent(tp string) any {
+	d.Trace = tp
+	return d
+}
+
+// TraceParent implements tracing.Carrier.
+func (d MemberEventData) TraceParent() string { return d.Trace }
+
+// WithTraceParent implements tracing.Carrier.
+func (d MemberEventData) WithTraceParent(tp string) any {
+	d.Trace = tp
+	return d
+}
+
+// TraceParent implements tracing.Carrier.
+func (d ProvisionEventData) TraceParent() string { return d.Trace }
+
+// WithTraceParent implements tracing.Carrier.
+func (d ProvisionEventData) WithTraceParent(tp string) any {
+	d.Trace = tp
+	return d
+}
+
+// TraceParent implements tracing.Carrier.
+func (d CloneEventData) TraceParent() string { return d.Trace }
+
+// WithTraceParent implements tracing.Carrier.
+func (d CloneEventData) WithTraceParent(tp string) any {
+	d.Trace = tp
+	return d
+}
+
+// TraceParent implements tracing.Carrier.
+func (d ImpersonationEventData) TraceParent() string { return d.Trace }
+
+// WithTraceParent implements tracing.Carrier.
+func (d ImpersonationEventData) WithTraceParent(tp string) any {
+	d.Trace = tp
+	return d
+}
+
+// TraceParent implements tracing.Carrier.
+func (d ServiceAccountEventData) TraceParent() string { return d.Trace }
+
+// WithTraceParent implements tracing.Carrier.
+func (d ServiceAccountEventData) WithTraceParent(tp string) any {
+	d.Trace = tp
+	return d
+}
+
+// WithImpersonation implements ImpersonationCarrier.
+func (d TenantEventData) WithImpersonation(m ImpersonationMark) any {
+	d.Impersonation = &m
+	return d
+}
+
+// WithImpersonation implements ImpersonationCarrier.
+func (d MemberEventData) WithImpersonation(m ImpersonationMark) any {
+	d.Impersonation = &m
+	return d
+}
+
+// WithImpersonation implements ImpersonationCarrier.
+func (d ProvisionEventData) WithImpersonation(m ImpersonationMark) any {
+	d.Impersonation = &m
+	return d
+}
+
+// WithImpersonation implements ImpersonationCarrier.
+func (d CloneEventData) WithImpersonation(m ImpersonationMark) any {
+	d.Impersonation = &m
+	return d
+}
+
+// WithImpersonation implements ImpersonationCarrier.
+func (d ServiceAccountEventData) WithImpersonation(m ImpersonationMark) any {
+	d.Impersonation = &m
+	return d
+}
+
+var (
+	_ tracing.Carrier = TenantEventData{}
+	_ tracing.Carrier = MemberEventData{}
+	_ tracing.Carrier = ProvisionEventData{}
+	_ tracing.Carrier = CloneEventData{}
+	_ tracing.Carrier = ImpersonationEventData{}
+	_ tracing.Carrier = ServiceAccountEventData{}
+
+	_ ImpersonationCarrier = TenantEventData{}
+	_ ImpersonationCarrier = MemberEventData{}
+	_ ImpersonationCarrier = ProvisionEventData{}
+	_ ImpersonationCarrier = CloneEventData{}
+	_ ImpersonationCarrier = ServiceAccountEventData{}
+)
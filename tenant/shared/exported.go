@@ -22,4 +22,6 @@ type TenantInfo struct {
 	Name     string    `json:"name"`
 	Status   string    `json:"status"`
 	DomainID uuid.UUID `json:"domainId"`
+	// Labels are the tenant's key/value labels.
+	Labels map[string]string `json:"labels,omitempty"`
 }
@@ -0,0 +1,291 @@
+package shared
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/leeforge/plugins/apierror"
+)
+
+// MaxMetadataSize bounds the encoded size of a tenant's metadata object.
+const MaxMetadataSize = 64 << 10
+
+// maxLabels bounds the number of labels on a tenant.
+const maxLabels = 64
+
+// TenantAttributes are the labels and free-form metadata of a tenant.
+type TenantAttributes struct {
+	TenantID uuid.UUID         `json:"tenantId"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	// Metadata is a JSON object, or nil.
+	Metadata  json.RawMessage `json:"metadata,omitempty"`
+	UpdatedAt time.Time       `json:"updatedAt"`
+}
+
+var (
+	labelNameRE   = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9_.-]{0,61}[A-Za-z0-9])?$`)
+	labelPrefixRE = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?(\.[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?)*$`)
+)
+
+// ValidateLabelKey checks a label key using Kubernetes rules: an optional
+// DNS subdomain prefix of at most 253 characters and a slash, followed by a
+// name of at most 63 alphanumerics, '-', '_' or '.', starting and ending
+// with an alphanumeric.
+func ValidateLabelKey(key string) error {
+	if f := labelKeyViolation(key); f != nil {
+		return ErrInvalidLabels.With(*f)
+	}
+	return nil
+}
+
+// ValidateLabelValue checks a label value: empty, or a name as accepted by
+// ValidateLabelKey without prefix.
+func ValidateLabelValue(value string) error {
+	if f := labelValueViolation("labels", value); f != nil {
+		return ErrInvalidLabels.With(*f)
+	}
+	return nil
+}
+
+func labelKeyViolation(key string) *apierror.FieldError {
+	name := key
+	if prefix, rest, ok := strings.Cut(key, "/"); ok {
+		if prefix == "" || len(prefix) > 253 || !labelPrefixRE.MatchString(prefix) {
+			f := apierror.Field("labels", CodeLabelPrefix, "value", key)
+			return &f
+		}
+		name = rest
+	}
+	if !labelNameRE.MatchString(name) {
+		f := apierror.Field("labels", CodeLabelKey, "value", key)
+		return &f
+	}
+	return nil
+}
+
+func labelValueViolation(field, value string) *apierror.FieldError {
+	if value != "" && !labelNameRE.MatchString(value) {
+		f := apierror.Field(field, CodeLabelValue, "value", value)
+		return &f
+	}
+	return nil
+}
+
+// ValidateLabels checks every key and value of labels, reporting all
+// violations in key order.
+func ValidateLabels(labels map[string]string) error {
+	if len(labels) > maxLabels {
+		return ErrInvalidLabels.With(apierror.Field("labels", apierror.TooMany, "max", maxLabels))
+	}
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var fields []apierror.FieldError
+	for _, k := range keys {
+		if f := labelKeyViolation(k); f != nil {
+			fields = append(fields, *f)
+		} else if f := labelValueViolation("labels."+k, labels[k]); f != nil {
+			fields = append(fields, *f)
+		}
+	}
+	if len(fields) > 0 {
+		return ErrInvalidLabels.With(fields...)
+	}
+	return nil
+}
+
+// ValidateMetadata checks that metadata is empty, null or a JSON object of
+// at most MaxMetadataSize bytes.
+func ValidateMetadata(metadata json.RawMessage) error {
+	trimmed := bytes.TrimSpace(metadata)
+	if len(trimmed) == 0 || bytes.Equal(trimmed, []byte("null")) {
+		return nil
+	}
+	if len(trimmed) > MaxMetadataSize {
+		return ErrInvalidLabels.With(apierror.Field("metadata", apierror.TooLarge, "max", MaxMetadataSize))
+	}
+	var obj map[string]any
+	if err := json.Unmarshal(trimmed, &obj); err != nil {
+		return ErrInvalidLabels.With(apierror.Field("metadata", CodeJSONObject))
+	}
+	return nil
+}
+
+// Label selector operators.
+const (
+	SelectorEquals       = "="
+	SelectorNotEquals    = "!="
+	SelectorIn           = "in"
+	SelectorNotIn        = "notin"
+	SelectorExists       = "exists"
+	SelectorDoesNotExist = "!"
+)
+
+// LabelRequirement is one comma-separated term of a label selector.
+type LabelRequirement struct {
+	Key      string
+	Operator string
+	Values   []string
+}
+
+// Matches reports whether labels satisfy the requirement.
+func (r LabelRequirement) Matches(labels map[string]string) bool {
+	v, ok := labels[r.Key]
+	switch r.Operator {
+	case SelectorExists:
+		return ok
+	case SelectorDoesNotExist:
+		return !ok
+	case SelectorEquals, SelectorIn:
+		return ok && slices.Contains(r.Values, v)
+	case SelectorNotEquals, SelectorNotIn:
+		return !ok || !slices.Contains(r.Values, v)
+	}
+	return false
+}
+
+func (r LabelRequirement) String() string {
+	switch r.Operator {
+	case SelectorExists:
+		return r.Key
+	case SelectorDoesNotExist:
+		return "!" + r.Key
+	case SelectorEquals, SelectorNotEquals:
+		return r.Key + r.Operator + r.Values[0]
+	}
+	return r.Key + " " + r.Operator + " (" + strings.Join(r.Values, ",") + ")"
+}
+
+// LabelSelector is a conjunction of label requirements. The empty selector
+// matches everything.
+type LabelSelector []LabelRequirement
+
+// Matches reports whether labels satisfy every requirement.
+func (s LabelSelector) Matches(labels map[string]string) bool {
+	for _, r := range s {
+		if !r.Matches(labels) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s LabelSelector) String() string {
+	parts := make([]string, len(s))
+	for i, r := range s {
+		parts[i] = r.String()
+	}
+	return strings.Join(parts, ",")
+}
+
+// ParseLabelSelector parses a Kubernetes-style label selector such as
+// "plan=pro,region in (eu,us),!trial". Supported terms are key=value,
+// key==value, key!=value, key in (v1,v2), key notin (v1,v2), key and !key.
+func ParseLabelSelector(selector string) (LabelSelector, error) {
+	var out LabelSelector
+	terms, err := splitSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+	for _, term := range terms {
+		r, err := parseRequirement(term)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, nil
+}
+
+// splitSelector splits selector at commas outside parentheses.
+func splitSelector(selector string) ([]string, error) {
+	var (
+		terms []string
+		depth int
+		start int
+	)
+	for i, c := range selector {
+		switch c {
+		case '(':
+			depth++
+			if depth > 1 {
+				return nil, fmt.Errorf("%w: nested parentheses", ErrInvalidLabelSelector)
+			}
+		case ')':
+			depth--
+			if depth < 0 {
+				return nil, fmt.Errorf("%w: unbalanced parentheses", ErrInvalidLabelSelector)
+			}
+		case ',':
+			if depth == 0 {
+				terms = append(terms, selector[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("%w: unbalanced parentheses", ErrInvalidLabelSelector)
+	}
+	terms = append(terms, selector[start:])
+	if len(terms) == 1 && strings.TrimSpace(terms[0]) == "" {
+		return nil, nil
+	}
+	return terms, nil
+}
+
+func parseRequirement(term string) (LabelRequirement, error) {
+	term = strings.TrimSpace(term)
+	if term == "" {
+		return LabelRequirement{}, fmt.Errorf("%w: empty requirement", ErrInvalidLabelSelector)
+	}
+
+	var r LabelRequirement
+	switch {
+	case strings.HasPrefix(term, "!") && !strings.ContainsAny(term, "=()"):
+		r = LabelRequirement{Key: strings.TrimSpace(term[1:]), Operator: SelectorDoesNotExist}
+	case strings.Contains(term, "("):
+		open := strings.Index(term, "(")
+		if !strings.HasSuffix(term, ")") {
+			return LabelRequirement{}, fmt.Errorf("%w: %q: expected ) at end", ErrInvalidLabelSelector, term)
+		}
+		fields := strings.Fields(term[:open])
+		if len(fields) != 2 || (fields[1] != SelectorIn && fields[1] != SelectorNotIn) {
+			return LabelRequirement{}, fmt.Errorf("%w: %q: expected \"key in (...)\" or \"key notin (...)\"", ErrInvalidLabelSelector, term)
+		}
+		r = LabelRequirement{Key: fields[0], Operator: fields[1]}
+		for _, v := range strings.Split(term[open+1:len(term)-1], ",") {
+			r.Values = append(r.Values, strings.TrimSpace(v))
+		}
+		sort.Strings(r.Values)
+	case strings.Contains(term, "!="):
+		k, v, _ := strings.Cut(term, "!=")
+		r = LabelRequirement{Key: strings.TrimSpace(k), Operator: SelectorNotEquals, Values: []string{strings.TrimSpace(v)}}
+	case strings.Contains(term, "="):
+		k, v, _ := strings.Cut(term, "=")
+		v = strings.TrimPrefix(v, "=")
+		r = LabelRequirement{Key: strings.TrimSpace(k), Operator: SelectorEquals, Values: []string{strings.TrimSpace(v)}}
+	default:
+		r = LabelRequirement{Key: term, Operator: SelectorExists}
+	}
+
+	if err := ValidateLabelKey(r.Key); err != nil {
+		return LabelRequirement{}, fmt.Errorf("%w: %q: invalid key %q", ErrInvalidLabelSelector, term, r.Key)
+	}
+	for _, v := range r.Values {
+		if err := ValidateLabelValue(v); err != nil {
+			return LabelRequirement{}, fmt.Errorf("%w: %q: invalid value %q", ErrInvalidLabelSelector, term, v)
+		}
+	}
+	return r, nil
+}
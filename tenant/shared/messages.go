@@ -0,0 +1,88 @@
+package shared
+
+import "github.com/leeforge/plugins/apierror"
+
+// Field violation codes specific to the tenant plugin.
+const (
+	CodeLabelKey       = "tenant.label_key"       // {value} is the key
+	CodeLabelPrefix    = "tenant.label_prefix"    // {value} is the key
+	CodeLabelValue     = "tenant.label_value"     // {value} is the value
+	CodeJSONObject     = "tenant.json_object"     // not a JSON object
+	CodeUndefinedRole  = "tenant.undefined_role"  // {value}, {tenant} and {roles}
+	CodeHTTPURL        = "tenant.http_url"        // not an absolute http(s) URL
+	CodeURLCredentials = "tenant.url_credentials" // URL with user info
+	CodeBlockedHost    = "tenant.blocked_host"    // loopback, private or link-local host
+)
+
+func init() {
+	apierror.Register(apierror.Messages{
+		apierror.English: {
+			ErrTenantCodeExists.Code:       "Tenant code already exists",
+			ErrInvalidTenant.Code:          "Invalid tenant data",
+			ErrMemberExists.Code:           "User is already a member",
+			ErrParentTenantInvalid.Code:    "Invalid parent tenant",
+			ErrInvalidMemberTerm.Code:      "Invalid membership type or validity window",
+			ErrMemberSuspended.Code:        "Membership is suspended",
+			ErrMemberNotSuspended.Code:     "Membership is not suspended",
+			ErrInvalidMemberFilter.Code:    "Invalid member filter",
+			ErrInvalidRoleTemplate.Code:    "Invalid role templates",
+			ErrInvalidRole.Code:            "Role is not defined in the tenant",
+			ErrTenantTemplateExists.Code:   "Tenant template already exists",
+			ErrInvalidTenantTemplate.Code:  "Invalid tenant template",
+			ErrInvalidArchive.Code:         "Invalid tenant archive",
+			ErrImpersonationInactive.Code:  "Impersonation session is expired or revoked",
+			ErrInvalidImpersonation.Code:   "Invalid impersonation request",
+			ErrServiceAccountExists.Code:   "Service account name already exists",
+			ErrInvalidServiceAccount.Code:  "Invalid service account",
+			ErrServiceAccountDisabled.Code: "Service account is disabled",
+			ErrAPIKeyInactive.Code:         "API key is expired or revoked",
+			ErrInvalidLabels.Code:          "Invalid tenant labels or metadata",
+			ErrInvalidLabelSelector.Code:   "Invalid label selector",
+			ErrInvalidTenantFilter.Code:    "Invalid tenant filter",
+			ErrInvalidWebhook.Code:         "Invalid webhook",
+
+			CodeLabelKey:       `label key "{value}" is not a valid name`,
+			CodeLabelPrefix:    `label key "{value}" has an invalid prefix`,
+			CodeLabelValue:     `label value "{value}" is not valid`,
+			CodeJSONObject:     "{field} must be a JSON object",
+			CodeUndefinedRole:  `role "{value}" is not defined in tenant {tenant}; defined roles: {roles}`,
+			CodeHTTPURL:        "{field} must be an absolute http or https URL",
+			CodeURLCredentials: "{field} must not contain credentials",
+			CodeBlockedHost:    "{field} must not point to a loopback, private or link-local address",
+		},
+		apierror.Chinese: {
+			ErrTenantCodeExists.Code:       "租户编码已存在",
+			ErrInvalidTenant.Code:          "租户数据无效",
+			ErrMemberExists.Code:           "用户已是租户成员",
+			ErrParentTenantInvalid.Code:    "父租户无效",
+			ErrInvalidMemberTerm.Code:      "成员类型或有效期无效",
+			ErrMemberSuspended.Code:        "成员已被挂起",
+			ErrMemberNotSuspended.Code:     "成员未被挂起",
+			ErrInvalidMemberFilter.Code:    "成员过滤条件无效",
+			ErrInvalidRoleTemplate.Code:    "角色模板无效",
+			ErrInvalidRole.Code:            "租户中未定义该角色",
+			ErrTenantTemplateExists.Code:   "租户模板已存在",
+			ErrInvalidTenantTemplate.Code:  "租户模板无效",
+			ErrInvalidArchive.Code:         "租户归档无效",
+			ErrImpersonationInactive.Code:  "模拟会话已过期或已撤销",
+			ErrInvalidImpersonation.Code:   "模拟请求无效",
+			ErrServiceAccountExists.Code:   "服务账号名称已存在",
+			ErrInvalidServiceAccount.Code:  "服务账号无效",
+			ErrServiceAccountDisabled.Code: "服务账号已停用",
+			ErrAPIKeyInactive.Code:         "API 密钥已过期或已撤销",
+			ErrInvalidLabels.Code:          "租户标签或元数据无效",
+			ErrInvalidLabelSelector.Code:   "标签选择器无效",
+			ErrInvalidTenantFilter.Code:    "租户过滤条件无效",
+			ErrInvalidWebhook.Code:         "Webhook 无效",
+
+			CodeLabelKey:       `标签键 "{value}" 不是合法的名称`,
+			CodeLabelPrefix:    `标签键 "{value}" 的前缀无效`,
+			CodeLabelValue:     `标签值 "{value}" 无效`,
+			CodeJSONObject:     "{field} 必须是 JSON 对象",
+			CodeUndefinedRole:  `租户 {tenant} 中未定义角色 "{value}"；已定义的角色：{roles}`,
+			CodeHTTPURL:        "{field} 必须是 http 或 https 的绝对 URL",
+			CodeURLCredentials: "{field} 不能包含凭据",
+			CodeBlockedHost:    "{field} 不能指向回环、私有或链路本地地址",
+		},
+	})
+}
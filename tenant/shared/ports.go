@@ -2,8 +2,11 @@ package shared
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
+
+	"github.com/leeforge/core/server/ent/predicate"
 )
 
 // RoleSeeder seeds baseline roles for a new tenant domain.
@@ -11,11 +14,86 @@ type RoleSeeder interface {
 	SeedBaselineRoles(ctx context.Context, domainID uuid.UUID) error
 }
 
+// RoleTemplateSeeder is optionally implemented by a RoleSeeder that
+// provisions role templates into tenant domains. Missing roles are created
+// and roles that still match the template they were last provisioned from
+// are updated; roles changed in the tenant are left as they are and reported
+// as customized. Seeders without it seed their baseline roles instead.
+type RoleTemplateSeeder interface {
+	SeedRoles(ctx context.Context, domainID uuid.UUID, templates []RoleTemplate) (RoleSyncResult, error)
+}
+
+// RoleCatalog lists the roles defined in a tenant domain. Member roles are
+// validated against it.
+type RoleCatalog interface {
+	ListRoles(ctx context.Context, domainID uuid.UUID) ([]RoleInfo, error)
+	// CreateRole adds a custom, non-system role to a domain. Cloning uses it
+	// to copy the custom roles of the source tenant.
+	CreateRole(ctx context.Context, domainID uuid.UUID, role RoleInfo) error
+}
+
+// RoleInfo is a role defined in a tenant domain.
+type RoleInfo struct {
+	Code        string   `json:"code"`
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Permissions []string `json:"permissions"`
+	IsSystem    bool     `json:"isSystem"`
+}
+
+// OrganizationCloner copies the organization tree of one domain into
+// another, without members. The ou plugin's organization service
+// implements it.
+type OrganizationCloner interface {
+	CloneOrganizationTree(ctx context.Context, fromDomainID, toDomainID uuid.UUID) (int, error)
+}
+
+// OrganizationArchiver exports and imports the organizations of a domain
+// with their members. Tenant export and import use it; the ou plugin's
+// organization service backs it.
+type OrganizationArchiver interface {
+	ExportOrganizations(ctx context.Context, domainID uuid.UUID) ([]OrganizationRecord, error)
+	// ImportOrganizations creates the organizations missing from domainID,
+	// matched by path, adds missing memberships and returns the number of
+	// organizations created.
+	ImportOrganizations(ctx context.Context, domainID uuid.UUID, orgs []OrganizationRecord) (int, error)
+}
+
+// OrganizationRecord is an organization and its members, ordered so that
+// parents come before their children.
+type OrganizationRecord struct {
+	Code    string
+	Name    string
+	Path    string
+	Members []OrganizationMemberRecord
+}
+
+// OrganizationMemberRecord is a member of an OrganizationRecord.
+type OrganizationMemberRecord struct {
+	UserID    uuid.UUID
+	IsPrimary bool
+}
+
 // UserLookup resolves user info for membership validation.
 type UserLookup interface {
 	GetUser(ctx context.Context, userID uuid.UUID) (*UserInfo, error)
 }
 
+// UserFinder is optionally implemented by a UserLookup that finds users by
+// name. Tenant imports use it to map archived users to local ones; without
+// it archived users are not mapped.
+type UserFinder interface {
+	// FindUser returns the user with username or, failing that, with
+	// email. It returns nil when neither matches.
+	FindUser(ctx context.Context, username, email string) (*UserInfo, error)
+}
+
+// PermissionResolver resolves the permissions a role grants in a domain.
+// Unknown roles resolve to no permissions.
+type PermissionResolver interface {
+	RolePermissions(ctx context.Context, domainID uuid.UUID, role string) ([]string, error)
+}
+
 // UserInfo is a minimal user representation for membership checks.
 type UserInfo struct {
 	ID       uuid.UUID
@@ -24,3 +102,153 @@ type UserInfo struct {
 	Nickname string
 	Status   string
 }
+
+// Membership types.
+const (
+	MembershipTypeStandard = "standard"
+	MembershipTypeGuest    = "guest"
+)
+
+// MemberTerm is the validity window and type of a membership. Memberships
+// without a term are standard and never expire.
+type MemberTerm struct {
+	TenantID  uuid.UUID  `json:"tenantId"`
+	UserID    uuid.UUID  `json:"userId"`
+	Type      string     `json:"type"`
+	ValidFrom *time.Time `json:"validFrom,omitempty"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+// ActiveAt reports whether the membership grants access at now.
+func (t *MemberTerm) ActiveAt(now time.Time) bool {
+	if t == nil {
+		return true
+	}
+	if t.ValidFrom != nil && now.Before(*t.ValidFrom) {
+		return false
+	}
+	return !t.ExpiredAt(now)
+}
+
+// ExpiredAt reports whether the membership has expired at now.
+func (t *MemberTerm) ExpiredAt(now time.Time) bool {
+	return t != nil && t.ExpiresAt != nil && !now.Before(*t.ExpiresAt)
+}
+
+// MemberTermStore persists membership terms.
+type MemberTermStore interface {
+	// PutTerm creates or replaces the term of a membership.
+	PutTerm(ctx context.Context, term MemberTerm) error
+	// GetTerm returns the term of a membership, or nil when it has none.
+	GetTerm(ctx context.Context, tenantID, userID uuid.UUID) (*MemberTerm, error)
+	// DeleteTerm removes the term of a membership. Missing terms are ignored.
+	DeleteTerm(ctx context.Context, tenantID, userID uuid.UUID) error
+	// ListTerms returns every term of a tenant.
+	ListTerms(ctx context.Context, tenantID uuid.UUID) ([]MemberTerm, error)
+	// ListExpired returns up to limit terms that expired at or before now.
+	ListExpired(ctx context.Context, now time.Time, limit int) ([]MemberTerm, error)
+}
+
+// ImpersonationStore persists impersonation sessions. Sessions are kept after
+// they expire or are revoked so that they can still be listed, until
+// DeleteEndedSessions prunes them.
+type ImpersonationStore interface {
+	// PutSession creates or replaces a session.
+	PutSession(ctx context.Context, session ImpersonationSession) error
+	// GetSession returns a session, or nil when it does not exist.
+	GetSession(ctx context.Context, id uuid.UUID) (*ImpersonationSession, error)
+	// ListSessions returns the sessions of a tenant, or of every tenant when
+	// tenantID is uuid.Nil.
+	ListSessions(ctx context.Context, tenantID uuid.UUID) ([]ImpersonationSession, error)
+	// OpenSessions returns the sessions of actorID in domainID that have not
+	// been revoked; they may have expired. It is called on every request of
+	// a non-member, so it must not scan the sessions of other actors.
+	OpenSessions(ctx context.Context, domainID, actorID uuid.UUID) ([]ImpersonationSession, error)
+	// DeleteEndedSessions removes the sessions that expired or were revoked
+	// before endedBefore and returns how many it removed.
+	DeleteEndedSessions(ctx context.Context, endedBefore time.Time) (int, error)
+}
+
+// ImpersonationAuditLog persists the audit records of impersonated requests.
+type ImpersonationAuditLog interface {
+	// RecordImpersonation writes the record of one request.
+	RecordImpersonation(ctx context.Context, audit ImpersonationAudit) error
+}
+
+// ServiceAccountStore persists service accounts and their API keys. Revoked
+// and expired keys are kept so that they can still be listed.
+type ServiceAccountStore interface {
+	// PutAccount creates or replaces a service account.
+	PutAccount(ctx context.Context, account ServiceAccount) error
+	// GetAccount returns a service account, or nil when it does not exist.
+	GetAccount(ctx context.Context, id uuid.UUID) (*ServiceAccount, error)
+	// ListAccounts returns the service accounts of a tenant.
+	ListAccounts(ctx context.Context, tenantID uuid.UUID) ([]ServiceAccount, error)
+	// DeleteAccount removes a service account and all of its keys.
+	DeleteAccount(ctx context.Context, id uuid.UUID) error
+	// PutKey creates or replaces an API key.
+	PutKey(ctx context.Context, key APIKey) error
+	// GetKey returns an API key, or nil when it does not exist.
+	GetKey(ctx context.Context, id uuid.UUID) (*APIKey, error)
+	// ListKeys returns the keys of a service account.
+	ListKeys(ctx context.Context, accountID uuid.UUID) ([]APIKey, error)
+	// TouchKey sets the LastUsedAt of a key, leaving its other fields as
+	// stored. Missing keys are ignored.
+	TouchKey(ctx context.Context, id uuid.UUID, usedAt time.Time) error
+}
+
+// TenantAttributeStore persists the labels and metadata of tenants. Stores
+// on the tenant database should read and write through the transaction
+// carried by ctx (ent.TxFromContext) when there is one: tenant updates merge
+// attributes in the transaction of the row update.
+type TenantAttributeStore interface {
+	// GetAttributes returns the attributes of a tenant, or nil when it has
+	// none.
+	GetAttributes(ctx context.Context, tenantID uuid.UUID) (*TenantAttributes, error)
+	// PutAttributes creates or replaces the attributes of a tenant.
+	PutAttributes(ctx context.Context, attrs TenantAttributes) error
+	// ListAttributesOf returns the attributes of those of tenantIDs that have
+	// any.
+	ListAttributesOf(ctx context.Context, tenantIDs []uuid.UUID) ([]TenantAttributes, error)
+	// LabelValues returns the value of label key for every tenant that has
+	// it. Label selectors are resolved through it, so it must read an index
+	// of the key rather than the attributes of every tenant.
+	LabelValues(ctx context.Context, key string) (map[uuid.UUID]string, error)
+}
+
+// TenantLabelIndex is optionally implemented by a TenantAttributeStore whose
+// label index is a table of the tenant database. Label selectors are then
+// resolved with a subquery on the index instead of a list of tenant IDs
+// built from LabelValues, which grows with the number of tenants.
+type TenantLabelIndex interface {
+	// LabelPredicate returns a predicate matching the tenants whose labels
+	// satisfy r.
+	LabelPredicate(r LabelRequirement) predicate.Tenant
+}
+
+// WebhookStore persists webhook endpoints and their delivery log.
+type WebhookStore interface {
+	// PutEndpoint creates or replaces an endpoint.
+	PutEndpoint(ctx context.Context, endpoint WebhookEndpoint) error
+	// GetEndpoint returns an endpoint, or nil when it does not exist.
+	GetEndpoint(ctx context.Context, id uuid.UUID) (*WebhookEndpoint, error)
+	// ListEndpoints returns the endpoints of a tenant, or the platform
+	// endpoints when tenantID is nil.
+	ListEndpoints(ctx context.Context, tenantID *uuid.UUID) ([]WebhookEndpoint, error)
+	// DeleteEndpoint removes an endpoint and its deliveries.
+	DeleteEndpoint(ctx context.Context, id uuid.UUID) error
+	// PutDelivery creates or replaces a delivery.
+	PutDelivery(ctx context.Context, delivery WebhookDelivery) error
+	// GetDelivery returns a delivery, or nil when it does not exist.
+	GetDelivery(ctx context.Context, id uuid.UUID) (*WebhookDelivery, error)
+	// ListDeliveries returns the deliveries of an endpoint.
+	ListDeliveries(ctx context.Context, endpointID uuid.UUID) ([]WebhookDelivery, error)
+	// ClaimDueDeliveries returns up to limit pending deliveries due at now
+	// and postpones each of them to until, so that a concurrent worker does
+	// not send them while they are in flight.
+	// It reads the due deliveries in due order without scanning the rest.
+	ClaimDueDeliveries(ctx context.Context, now, until time.Time, limit int) ([]WebhookDelivery, error)
+	// DeleteFinishedDeliveries removes the delivered and dead deliveries last
+	// updated before finishedBefore and returns how many it removed.
+	DeleteFinishedDeliveries(ctx context.Context, finishedBefore time.Time) (int, error)
+}
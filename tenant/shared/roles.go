@@ -0,0 +1,99 @@
+package shared
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// RoleTemplate describes a role provisioned into tenant domains.
+type RoleTemplate struct {
+	Code        string   `json:"code"`
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Permissions []string `json:"permissions,omitempty"`
+	// Owner marks the role assigned to the creator of a tenant. Exactly one
+	// template of a set is the owner role.
+	Owner bool `json:"owner,omitempty"`
+}
+
+// RoleSyncResult lists role codes by the outcome of provisioning a template
+// set into a domain.
+type RoleSyncResult struct {
+	Created   []string `json:"created"`
+	Updated   []string `json:"updated"`
+	Unchanged []string `json:"unchanged"`
+	// Customized roles were changed in the tenant since they were last
+	// provisioned and are left as they are.
+	Customized []string `json:"customized"`
+}
+
+// RoleTemplateSource supplies the role templates of a tenant, for hosts
+// whose plans define different role sets. An empty result falls back to the
+// configured templates.
+type RoleTemplateSource interface {
+	RoleTemplates(ctx context.Context, tenantID uuid.UUID, tenantCode string) ([]RoleTemplate, error)
+}
+
+// DefaultRoleTemplates returns the template set used when none is
+// configured: an owner role and a member role without permissions.
+func DefaultRoleTemplates(ownerRole, memberRole string) []RoleTemplate {
+	templates := []RoleTemplate{{Code: ownerRole, Name: "Owner", Owner: true}}
+	if memberRole != ownerRole {
+		templates = append(templates, RoleTemplate{Code: memberRole, Name: "Member"})
+	}
+	return templates
+}
+
+// OwnerTemplate returns the template marked as the owner role.
+func OwnerTemplate(templates []RoleTemplate) (RoleTemplate, bool) {
+	for _, t := range templates {
+		if t.Owner {
+			return t, true
+		}
+	}
+	return RoleTemplate{}, false
+}
+
+// HasRoleTemplate reports whether templates define a role with code.
+func HasRoleTemplate(templates []RoleTemplate, code string) bool {
+	for _, t := range templates {
+		if t.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateRoleTemplates checks that templates have unique, non-empty codes
+// without spaces, non-empty names and exactly one owner role.
+func ValidateRoleTemplates(templates []RoleTemplate) error {
+	if len(templates) == 0 {
+		return fmt.Errorf("%w: at least one template is required", ErrInvalidRoleTemplate)
+	}
+	seen := make(map[string]struct{}, len(templates))
+	owners := 0
+	for i, t := range templates {
+		switch {
+		case t.Code == "":
+			return fmt.Errorf("%w: template %d has no code", ErrInvalidRoleTemplate, i)
+		case strings.TrimSpace(t.Code) != t.Code || strings.ContainsAny(t.Code, " \t"):
+			return fmt.Errorf("%w: code %q must not contain spaces", ErrInvalidRoleTemplate, t.Code)
+		case strings.TrimSpace(t.Name) == "":
+			return fmt.Errorf("%w: template %q has no name", ErrInvalidRoleTemplate, t.Code)
+		}
+		if _, dup := seen[t.Code]; dup {
+			return fmt.Errorf("%w: duplicate code %q", ErrInvalidRoleTemplate, t.Code)
+		}
+		seen[t.Code] = struct{}{}
+		if t.Owner {
+			owners++
+		}
+	}
+	if owners != 1 {
+		return fmt.Errorf("%w: exactly one owner template is required, got %d", ErrInvalidRoleTemplate, owners)
+	}
+	return nil
+}
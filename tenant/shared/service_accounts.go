@@ -0,0 +1,61 @@
+package shared
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// API key statuses.
+const (
+	APIKeyActive  = "active"
+	APIKeyExpired = "expired"
+	APIKeyRevoked = "revoked"
+)
+
+// ServiceAccount is a non-human principal owned by a tenant. It is a member
+// of the tenant domain with a single role, and authenticates with API keys.
+type ServiceAccount struct {
+	ID          uuid.UUID `json:"id"`
+	TenantID    uuid.UUID `json:"tenantId"`
+	TenantCode  string    `json:"tenantCode"`
+	DomainID    uuid.UUID `json:"domainId"`
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	Role        string    `json:"role"`
+	// Disabled accounts keep their keys but cannot authenticate.
+	Disabled  bool      `json:"disabled"`
+	CreatedBy uuid.UUID `json:"createdBy"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// APIKey authenticates a service account. Only the SHA-256 hash of the key
+// secret is stored.
+type APIKey struct {
+	ID        uuid.UUID  `json:"id"`
+	AccountID uuid.UUID  `json:"accountId"`
+	TenantID  uuid.UUID  `json:"tenantId"`
+	Name      string     `json:"name"`
+	KeyHash   string     `json:"keyHash"`
+	CreatedBy uuid.UUID  `json:"createdBy"`
+	CreatedAt time.Time  `json:"createdAt"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	RevokedAt *time.Time `json:"revokedAt,omitempty"`
+	// LastUsedAt is refreshed when the key authenticates, at most once a
+	// minute.
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	// RotatedTo is the key that replaced this one, if it was rotated.
+	RotatedTo *uuid.UUID `json:"rotatedTo,omitempty"`
+}
+
+// StatusAt returns the status of the key at now.
+func (k *APIKey) StatusAt(now time.Time) string {
+	switch {
+	case k.RevokedAt != nil:
+		return APIKeyRevoked
+	case k.ExpiresAt != nil && !now.Before(*k.ExpiresAt):
+		return APIKeyExpired
+	}
+	return APIKeyActive
+}
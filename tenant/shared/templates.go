@@ -0,0 +1,48 @@
+package shared
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TenantTemplate is a named tenant that new tenants are cloned from, for
+// example a fully configured demo tenant.
+type TenantTemplate struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// SourceTenant is the code of the tenant whose settings, custom roles
+	// and, optionally, organization tree are copied.
+	SourceTenant string `json:"sourceTenant"`
+	// IncludeOrganizations copies the organization tree unless the clone
+	// request says otherwise.
+	IncludeOrganizations bool `json:"includeOrganizations,omitempty"`
+}
+
+// ValidateTenantTemplate checks that t has a name without spaces and a
+// source tenant.
+func ValidateTenantTemplate(t TenantTemplate) error {
+	switch {
+	case t.Name == "":
+		return fmt.Errorf("%w: name is required", ErrInvalidTenantTemplate)
+	case strings.ContainsAny(t.Name, " \t/"):
+		return fmt.Errorf("%w: name %q must not contain spaces or '/'", ErrInvalidTenantTemplate, t.Name)
+	case strings.TrimSpace(t.SourceTenant) == "":
+		return fmt.Errorf("%w: template %q has no sourceTenant", ErrInvalidTenantTemplate, t.Name)
+	}
+	return nil
+}
+
+// ValidateTenantTemplates checks every template and that names are unique.
+func ValidateTenantTemplates(templates []TenantTemplate) error {
+	seen := make(map[string]struct{}, len(templates))
+	for _, t := range templates {
+		if err := ValidateTenantTemplate(t); err != nil {
+			return err
+		}
+		if _, dup := seen[t.Name]; dup {
+			return fmt.Errorf("%w: duplicate name %q", ErrInvalidTenantTemplate, t.Name)
+		}
+		seen[t.Name] = struct{}{}
+	}
+	return nil
+}
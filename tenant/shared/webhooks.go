@@ -0,0 +1,146 @@
+package shared
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Webhook request headers. The signature is "sha256=" followed by the hex
+// HMAC-SHA256 of "<timestamp>.<body>" keyed with the endpoint secret.
+const (
+	WebhookHeaderEvent     = "X-Webhook-Event"
+	WebhookHeaderDelivery  = "X-Webhook-Delivery"
+	WebhookHeaderTimestamp = "X-Webhook-Timestamp"
+	WebhookHeaderSignature = "X-Webhook-Signature"
+)
+
+// WebhookAllEvents subscribes an endpoint to every webhook event.
+const WebhookAllEvents = "*"
+
+// Webhook delivery statuses. Dead deliveries exhausted their attempts.
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryDead      = "dead"
+)
+
+// WebhookEvents are the event types webhook endpoints can subscribe to.
+var WebhookEvents = []string{
+	EventTenantCreated,
+	EventTenantUpdated,
+	EventTenantDeleted,
+	EventTenantMemberAdded,
+	EventTenantMemberRemoved,
+	EventTenantMemberExpired,
+	EventTenantMemberSuspended,
+	EventTenantMemberReactivated,
+	EventTenantProvisionProgress,
+	EventTenantCloned,
+	EventTenantImported,
+	EventTenantImpersonationStarted,
+	EventTenantImpersonationRevoked,
+	EventTenantServiceAccountCreated,
+	EventTenantServiceAccountUpdated,
+	EventTenantServiceAccountDeleted,
+	EventTenantAPIKeyCreated,
+	EventTenantAPIKeyRevoked,
+	EventTenantAPIKeyRotated,
+}
+
+// WebhookEndpoint is a registered webhook receiver. Platform endpoints have
+// no TenantID and receive the events of every tenant; tenant endpoints only
+// receive the events of their tenant. Secret keys the delivery signatures.
+type WebhookEndpoint struct {
+	ID          uuid.UUID  `json:"id"`
+	TenantID    *uuid.UUID `json:"tenantId,omitempty"`
+	URL         string     `json:"url"`
+	Description string     `json:"description,omitempty"`
+	Events      []string   `json:"events"`
+	Secret      string     `json:"secret"`
+	Disabled    bool       `json:"disabled"`
+	CreatedBy   uuid.UUID  `json:"createdBy"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+}
+
+// Subscribed reports whether the endpoint wants event.
+func (e *WebhookEndpoint) Subscribed(event string) bool {
+	for _, name := range e.Events {
+		if name == WebhookAllEvents || name == event {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookAttempt records one delivery attempt. StatusCode is zero when no
+// response was received.
+type WebhookAttempt struct {
+	At         time.Time `json:"at"`
+	StatusCode int       `json:"statusCode,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	DurationMS int64     `json:"durationMs"`
+}
+
+// WebhookDelivery is one event queued for one endpoint. Body is the exact
+// request body, so retries and redeliveries send identical payloads.
+type WebhookDelivery struct {
+	ID         uuid.UUID        `json:"id"`
+	EndpointID uuid.UUID        `json:"endpointId"`
+	TenantID   *uuid.UUID       `json:"tenantId,omitempty"`
+	EventID    uuid.UUID        `json:"eventId"`
+	Event      string           `json:"event"`
+	Body       json.RawMessage  `json:"body"`
+	Status     string           `json:"status"`
+	Attempts   []WebhookAttempt `json:"attempts"`
+	// NextAttemptAt is when a pending delivery is due.
+	NextAttemptAt *time.Time `json:"nextAttemptAt,omitempty"`
+	// RedeliveryOf is the delivery this one was manually redelivered from.
+	RedeliveryOf *uuid.UUID `json:"redeliveryOf,omitempty"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	UpdatedAt    time.Time  `json:"updatedAt"`
+}
+
+// WebhookMessage is the JSON body of a webhook request. ID identifies the
+// event and is the same for every endpoint and redelivery.
+type WebhookMessage struct {
+	ID         uuid.UUID       `json:"id"`
+	Event      string          `json:"event"`
+	TenantID   *uuid.UUID      `json:"tenantId,omitempty"`
+	OccurredAt time.Time       `json:"occurredAt"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// SignWebhook returns the signature header value of body sent at timestamp.
+func SignWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte{'.'})
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhook checks the timestamp and signature headers of a received
+// webhook. Requests older or newer than tolerance are rejected to limit
+// replays.
+func VerifyWebhook(secret, timestamp, signature string, body []byte, tolerance time.Duration, now time.Time) error {
+	ts, err := strconv.ParseInt(strings.TrimSpace(timestamp), 10, 64)
+	if err != nil {
+		return fmt.Errorf("webhook: invalid timestamp %q", timestamp)
+	}
+	if d := now.Sub(time.Unix(ts, 0)); d > tolerance || d < -tolerance {
+		return fmt.Errorf("webhook: timestamp outside tolerance")
+	}
+	if !hmac.Equal([]byte(SignWebhook(secret, ts, body)), []byte(strings.TrimSpace(signature))) {
+		return fmt.Errorf("webhook: signature mismatch")
+	}
+	return nil
+}
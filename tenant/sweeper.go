@@ -0,0 +1,45 @@
+package tenant
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// runMemberSweeper removes expired memberships and prunes ended impersonation
+// sessions and finished webhook deliveries every interval until ctx is
+// cancelled by Disable.
+func (p *TenantPlugin) runMemberSweeper(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			removed, err := p.service().SweepExpiredMembers(ctx)
+			if err != nil && ctx.Err() == nil {
+				p.logger.Error("tenant: expired membership sweep failed", zap.Error(err))
+			}
+			if removed > 0 {
+				p.logger.Info("tenant: removed expired memberships", zap.Int("count", removed))
+			}
+			pruned, err := p.service().PruneImpersonations(ctx)
+			if err != nil && ctx.Err() == nil {
+				p.logger.Error("tenant: impersonation session pruning failed", zap.Error(err))
+			}
+			if pruned > 0 {
+				p.logger.Info("tenant: pruned ended impersonation sessions", zap.Int("count", pruned))
+			}
+			pruned, err = p.service().PruneWebhookDeliveries(ctx)
+			if err != nil && ctx.Err() == nil {
+				p.logger.Error("tenant: webhook delivery pruning failed", zap.Error(err))
+			}
+			if pruned > 0 {
+				p.logger.Info("tenant: pruned finished webhook deliveries", zap.Int("count", pruned))
+			}
+		}
+	}
+}
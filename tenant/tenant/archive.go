@@ -0,0 +1,141 @@
+package tenant
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/leeforge/plugins/tenant/shared"
+)
+
+// Archive encodings accepted by WriteArchive and ReadArchive.
+const (
+	ArchiveFormatJSON = "json"
+	ArchiveFormatTar  = "tar"
+)
+
+// Entries of a tar archive. The manifest holds the version and the tenant
+// record; every other section has its own entry.
+const (
+	tarEntryManifest      = "manifest.json"
+	tarEntryRoles         = "roles.json"
+	tarEntryMembers       = "members.json"
+	tarEntryOrganizations = "organizations.json"
+)
+
+// maxArchiveEntrySize bounds a single tar entry read by ReadArchive.
+const maxArchiveEntrySize = 64 << 20
+
+type archiveManifest struct {
+	Version    int            `json:"version"`
+	ExportedAt time.Time      `json:"exportedAt"`
+	Tenant     ArchivedTenant `json:"tenant"`
+}
+
+// WriteArchive encodes a in format, "json" or "tar".
+func WriteArchive(w io.Writer, a *TenantArchive, format string) error {
+	switch format {
+	case "", ArchiveFormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(a)
+	case ArchiveFormatTar:
+		return writeTarArchive(w, a)
+	default:
+		return fmt.Errorf("%w: unknown format %q", shared.ErrInvalidArchive, format)
+	}
+}
+
+// ReadArchive decodes an archive written by WriteArchive in format.
+func ReadArchive(r io.Reader, format string) (*TenantArchive, error) {
+	switch format {
+	case "", ArchiveFormatJSON:
+		var a TenantArchive
+		if err := json.NewDecoder(r).Decode(&a); err != nil {
+			return nil, fmt.Errorf("%w: %w", shared.ErrInvalidArchive, err)
+		}
+		return &a, nil
+	case ArchiveFormatTar:
+		return readTarArchive(r)
+	default:
+		return nil, fmt.Errorf("%w: unknown format %q", shared.ErrInvalidArchive, format)
+	}
+}
+
+func writeTarArchive(w io.Writer, a *TenantArchive) error {
+	tw := tar.NewWriter(w)
+	entries := []struct {
+		name string
+		v    any
+	}{
+		{tarEntryManifest, archiveManifest{Version: a.Version, ExportedAt: a.ExportedAt, Tenant: a.Tenant}},
+		{tarEntryRoles, a.Roles},
+		{tarEntryMembers, a.Members},
+		{tarEntryOrganizations, a.Organizations},
+	}
+	for _, e := range entries {
+		body, err := json.MarshalIndent(e.v, "", "  ")
+		if err != nil {
+			return fmt.Errorf("encode %s: %w", e.name, err)
+		}
+		hdr := &tar.Header{
+			Name:    e.name,
+			Mode:    0o644,
+			Size:    int64(len(body)),
+			ModTime: a.ExportedAt,
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return fmt.Errorf("write %s: %w", e.name, err)
+		}
+		if _, err := tw.Write(body); err != nil {
+			return fmt.Errorf("write %s: %w", e.name, err)
+		}
+	}
+	return tw.Close()
+}
+
+func readTarArchive(r io.Reader) (*TenantArchive, error) {
+	var (
+		a        TenantArchive
+		manifest archiveManifest
+		found    bool
+	)
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", shared.ErrInvalidArchive, err)
+		}
+
+		var dst any
+		switch hdr.Name {
+		case tarEntryManifest:
+			dst, found = &manifest, true
+		case tarEntryRoles:
+			dst = &a.Roles
+		case tarEntryMembers:
+			dst = &a.Members
+		case tarEntryOrganizations:
+			dst = &a.Organizations
+		default:
+			continue
+		}
+		if hdr.Size > maxArchiveEntrySize {
+			return nil, fmt.Errorf("%w: %s is too large", shared.ErrInvalidArchive, hdr.Name)
+		}
+		if err := json.NewDecoder(io.LimitReader(tr, hdr.Size)).Decode(dst); err != nil {
+			return nil, fmt.Errorf("%w: %s: %w", shared.ErrInvalidArchive, hdr.Name, err)
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("%w: missing %s", shared.ErrInvalidArchive, tarEntryManifest)
+	}
+	a.Version, a.ExportedAt, a.Tenant = manifest.Version, manifest.ExportedAt, manifest.Tenant
+	return &a, nil
+}
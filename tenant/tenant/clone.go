@@ -0,0 +1,324 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/leeforge/framework/plugin"
+	"go.uber.org/zap"
+
+	"github.com/leeforge/core"
+	coreent "github.com/leeforge/core/server/ent"
+	entTenant "github.com/leeforge/core/server/ent/tenant"
+
+	"github.com/leeforge/plugins/apierror"
+	"github.com/leeforge/plugins/tenant/shared"
+	"github.com/leeforge/plugins/tracing"
+)
+
+// Steps of the clone provisioning flow, in the order they run.
+const (
+	StepCreateTenant      = "create_tenant"
+	StepCopyRoles         = "copy_roles"
+	StepCopyOrganizations = "copy_organizations"
+)
+
+// Provisioning step statuses.
+const (
+	StepStatusDone    = "done"
+	StepStatusSkipped = "skipped"
+	StepStatusFailed  = "failed"
+)
+
+// errStepSkipped marks a provisioning step that had nothing to do.
+var errStepSkipped = errors.New("step skipped")
+
+// SetOrganizationCloner sets the port that copies organization trees. Without
+// one, clones never include organizations.
+func (s *Service) SetOrganizationCloner(c shared.OrganizationCloner) {
+	s.orgs = c
+	if s.tracer != tracing.Nop && c != nil {
+		s.orgs = &tracedOrganizationCloner{next: c, tracer: s.tracer}
+	}
+}
+
+func (s *Service) resetTenantTemplates(templates []shared.TenantTemplate) {
+	s.tenantTemplatesMu.Lock()
+	defer s.tenantTemplatesMu.Unlock()
+	s.tenantTemplates = make(map[string]shared.TenantTemplate, len(templates))
+	for _, t := range templates {
+		s.tenantTemplates[t.Name] = t
+	}
+}
+
+// RegisterTenantTemplate adds a named tenant template. The templates of the
+// tenantTemplates config are registered when the plugin is enabled.
+func (s *Service) RegisterTenantTemplate(t shared.TenantTemplate) error {
+	if err := shared.ValidateTenantTemplate(t); err != nil {
+		return err
+	}
+	s.tenantTemplatesMu.Lock()
+	defer s.tenantTemplatesMu.Unlock()
+	if _, ok := s.tenantTemplates[t.Name]; ok {
+		return fmt.Errorf("%w: %q", shared.ErrTenantTemplateExists, t.Name)
+	}
+	s.tenantTemplates[t.Name] = t
+	return nil
+}
+
+// ListTenantTemplates returns the registered tenant templates ordered by name.
+func (s *Service) ListTenantTemplates(ctx context.Context) (_ *TenantTemplateListResult, err error) {
+	ctx, end := s.instrument(ctx, "list_tenant_templates")
+	defer end(&err)
+
+	if err := requirePlatformDomain(ctx); err != nil {
+		return nil, err
+	}
+
+	s.tenantTemplatesMu.RLock()
+	templates := make([]shared.TenantTemplate, 0, len(s.tenantTemplates))
+	for _, t := range s.tenantTemplates {
+		templates = append(templates, t)
+	}
+	s.tenantTemplatesMu.RUnlock()
+
+	sort.Slice(templates, func(i, j int) bool { return templates[i].Name < templates[j].Name })
+	return &TenantTemplateListResult{Templates: templates}, nil
+}
+
+// CloneTenant creates a tenant from the settings, custom roles and,
+// optionally, organization tree of the tenant sourceID.
+func (s *Service) CloneTenant(ctx context.Context, sourceID uuid.UUID, req *CloneRequest) (_ *CloneResult, err error) {
+	ctx, end := s.instrument(ctx, "clone_tenant")
+	defer end(&err)
+
+	if err := requirePlatformDomain(ctx); err != nil {
+		return nil, err
+	}
+
+	src, err := s.client.Tenant.Query().
+		Where(entTenant.ID(sourceID), entTenant.DeletedAtIsNil()).
+		Only(ctx)
+	if err != nil {
+		if coreent.IsNotFound(err) {
+			return nil, shared.ErrTenantNotFound
+		}
+		return nil, fmt.Errorf("get source tenant: %w", err)
+	}
+
+	includeOrgs := req != nil && req.IncludeOrganizations != nil && *req.IncludeOrganizations
+	return s.cloneTenant(ctx, src, "", includeOrgs, req)
+}
+
+// CloneTenantTemplate creates a tenant from the named tenant template.
+func (s *Service) CloneTenantTemplate(ctx context.Context, name string, req *CloneRequest) (_ *CloneResult, err error) {
+	ctx, end := s.instrument(ctx, "clone_tenant_template")
+	defer end(&err)
+
+	if err := requirePlatformDomain(ctx); err != nil {
+		return nil, err
+	}
+
+	s.tenantTemplatesMu.RLock()
+	tpl, ok := s.tenantTemplates[name]
+	s.tenantTemplatesMu.RUnlock()
+	if !ok {
+		return nil, shared.ErrTenantTemplateNotFound
+	}
+
+	src, err := s.client.Tenant.Query().
+		Where(entTenant.CodeEQ(tpl.SourceTenant), entTenant.DeletedAtIsNil()).
+		Only(ctx)
+	if err != nil {
+		if coreent.IsNotFound(err) {
+			return nil, fmt.Errorf("%w: source tenant %q of template %q", shared.ErrTenantNotFound, tpl.SourceTenant, tpl.Name)
+		}
+		return nil, fmt.Errorf("get source tenant: %w", err)
+	}
+
+	includeOrgs := tpl.IncludeOrganizations
+	if req != nil && req.IncludeOrganizations != nil {
+		includeOrgs = *req.IncludeOrganizations
+	}
+	return s.cloneTenant(ctx, src, tpl.Name, includeOrgs, req)
+}
+
+// cloneTenant runs the clone provisioning flow. The tenant is created through
+// the CreateTenant path, then the custom roles and organizations of src are
+// copied into it, publishing tenant.provision.progress after each step. A
+// failed step stops the flow: the tenant is kept and the partial result is
+// returned with ErrProvisioningFailed.
+func (s *Service) cloneTenant(ctx context.Context, src *coreent.Tenant, template string, includeOrgs bool, req *CloneRequest) (*CloneResult, error) {
+	if req == nil {
+		return nil, shared.ErrInvalidTenant
+	}
+	ownerID, err := s.cloneOwner(ctx, req.OwnerID)
+	if err != nil {
+		return nil, err
+	}
+
+	create := &CreateRequest{
+		Code:           req.Code,
+		Name:           req.Name,
+		Description:    req.Description,
+		ParentTenantID: req.ParentTenantID,
+	}
+	if create.Description == "" {
+		create.Description = src.Description
+	}
+	attrs, err := s.attrs.GetAttributes(ctx, src.ID)
+	if err != nil {
+		return nil, fmt.Errorf("get tenant attributes: %w", err)
+	}
+	if attrs != nil {
+		create.Labels, create.Metadata = attrs.Labels, attrs.Metadata
+	}
+	if strings.TrimSpace(create.ParentTenantID) == "" && src.ParentTenantID != nil && *src.ParentTenantID != uuid.Nil {
+		create.ParentTenantID = src.ParentTenantID.String()
+	}
+
+	dto, err := s.createTenant(ctx, create, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	result := &CloneResult{
+		Tenant:         dto,
+		SourceTenantID: src.ID,
+		Template:       template,
+		Steps:          []ProvisionStep{},
+	}
+	_ = s.finishStep(ctx, result, StepCreateTenant, 1, nil)
+
+	srcDomainID := s.resolveDomainIDSafe(ctx, src.Code)
+	n, err := s.copyCustomRoles(ctx, srcDomainID, dto.DomainID)
+	if err := s.finishStep(ctx, result, StepCopyRoles, n, err); err != nil {
+		return result, err
+	}
+	n, err = s.copyOrganizations(ctx, srcDomainID, dto.DomainID, includeOrgs)
+	if err := s.finishStep(ctx, result, StepCopyOrganizations, n, err); err != nil {
+		return result, err
+	}
+
+	actorID, _ := core.GetUserID(ctx)
+	_ = s.events.Publish(ctx, plugin.Event{
+		Name:   shared.EventTenantCloned,
+		Source: "tenant",
+		Data: shared.CloneEventData{
+			TenantID:         dto.ID,
+			TenantCode:       dto.Code,
+			DomainID:         dto.DomainID,
+			SourceTenantID:   src.ID,
+			SourceTenantCode: src.Code,
+			Template:         template,
+			ActorID:          actorID,
+		},
+	})
+	return result, nil
+}
+
+// cloneOwner returns the owner of a clone: the requested user, or the
+// caller when none is given.
+func (s *Service) cloneOwner(ctx context.Context, raw string) (uuid.UUID, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		ownerID, _ := core.GetUserID(ctx)
+		return ownerID, nil
+	}
+	ownerID, err := uuid.Parse(raw)
+	if err != nil {
+		return uuid.Nil, shared.ErrInvalidTenant.With(apierror.Field("ownerId", apierror.Invalid, "value", raw))
+	}
+	if _, err := s.userLookup.GetUser(ctx, ownerID); err != nil {
+		return uuid.Nil, fmt.Errorf("%w: %w",
+			shared.ErrInvalidTenant.With(apierror.Field("ownerId", apierror.NotFound, "value", ownerID)), err)
+	}
+	return ownerID, nil
+}
+
+// copyCustomRoles copies the non-system roles of domain from into domain to.
+// Roles the target already defines, such as those provisioned from its role
+// templates, are left alone.
+func (s *Service) copyCustomRoles(ctx context.Context, from, to uuid.UUID) (int, error) {
+	if s.catalog == nil || from == uuid.Nil {
+		return 0, errStepSkipped
+	}
+	roles, err := s.catalog.ListRoles(ctx, from)
+	if err != nil {
+		return 0, fmt.Errorf("list source roles: %w", err)
+	}
+	existing, err := s.catalog.ListRoles(ctx, to)
+	if err != nil {
+		return 0, fmt.Errorf("list roles: %w", err)
+	}
+	have := make(map[string]struct{}, len(existing))
+	for _, r := range existing {
+		have[r.Code] = struct{}{}
+	}
+
+	copied := 0
+	for _, r := range roles {
+		if _, ok := have[r.Code]; ok || r.IsSystem {
+			continue
+		}
+		if err := s.catalog.CreateRole(ctx, to, r); err != nil {
+			return copied, fmt.Errorf("create role %s: %w", r.Code, err)
+		}
+		copied++
+	}
+	return copied, nil
+}
+
+// copyOrganizations copies the organization tree of domain from into
+// domain to when include is set.
+func (s *Service) copyOrganizations(ctx context.Context, from, to uuid.UUID, include bool) (int, error) {
+	switch {
+	case !include || from == uuid.Nil:
+		return 0, errStepSkipped
+	case s.orgs == nil:
+		return 0, shared.ErrOrganizationsDisabled
+	}
+	return s.orgs.CloneOrganizationTree(ctx, from, to)
+}
+
+// finishStep records the outcome of a provisioning step and publishes it. It
+// returns ErrProvisioningFailed when the step failed. Steps that could not
+// run because the organization service is missing are skipped, not failed.
+func (s *Service) finishStep(ctx context.Context, result *CloneResult, name string, count int, err error) error {
+	step := ProvisionStep{Name: name, Status: StepStatusDone, Count: count}
+	switch {
+	case errors.Is(err, errStepSkipped):
+		step.Status = StepStatusSkipped
+	case errors.Is(err, shared.ErrOrganizationsDisabled):
+		step.Status, step.Error = StepStatusSkipped, err.Error()
+	case err != nil:
+		step.Status, step.Error = StepStatusFailed, err.Error()
+	}
+	result.Steps = append(result.Steps, step)
+
+	_ = s.events.Publish(ctx, plugin.Event{
+		Name:   shared.EventTenantProvisionProgress,
+		Source: "tenant",
+		Data: shared.ProvisionEventData{
+			TenantID:   result.Tenant.ID,
+			TenantCode: result.Tenant.Code,
+			Step:       step.Name,
+			Status:     step.Status,
+			Count:      step.Count,
+			Error:      step.Error,
+		},
+	})
+
+	if step.Status != StepStatusFailed {
+		return nil
+	}
+	s.logger.Warn("tenant: provisioning step failed",
+		zap.String("tenant", result.Tenant.Code),
+		zap.String("step", name),
+		zap.Error(err),
+	)
+	return fmt.Errorf("%w: %s: %w", shared.ErrProvisioningFailed, name, err)
+}
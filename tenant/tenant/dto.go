@@ -1,43 +1,166 @@
 package tenant
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
+
+	"github.com/leeforge/plugins/mergepatch"
+	"github.com/leeforge/plugins/tenant/shared"
 )
 
 // --- Requests ---
 
 // CreateRequest is the input for creating a new tenant.
 type CreateRequest struct {
-	Code           string `json:"code"`
-	Name           string `json:"name"`
-	Description    string `json:"description,omitempty"`
-	Status         string `json:"status,omitempty"`
-	ParentTenantID string `json:"parentTenantId,omitempty"`
+	Code           string            `json:"code"`
+	Name           string            `json:"name"`
+	Description    string            `json:"description,omitempty"`
+	Status         string            `json:"status,omitempty"`
+	ParentTenantID string            `json:"parentTenantId,omitempty"`
+	Labels         map[string]string `json:"labels,omitempty"`
+	// Metadata is a free-form JSON object.
+	Metadata json.RawMessage `json:"metadata,omitempty"`
 }
 
 // UpdateRequest is the input for updating a tenant.
 type UpdateRequest struct {
-	Name           string `json:"name,omitempty"`
+	Name string `json:"name" binding:"required"`
+	// Description, ParentTenantID, Labels and Metadata replace those of the
+	// tenant; left empty they clear them.
 	Description    string `json:"description,omitempty"`
-	Status         string `json:"status,omitempty"`
 	ParentTenantID string `json:"parentTenantId,omitempty"`
+	// Status is active when empty.
+	Status   string            `json:"status,omitempty"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Metadata json.RawMessage   `json:"metadata,omitempty"`
+
+	// ExpectedVersion, when set, makes the update fail with
+	// shared.ErrVersionMismatch unless the tenant is still at this
+	// TenantDTO.Version. Over HTTP it is sent as the If-Match header.
+	ExpectedVersion string `json:"-"`
+}
+
+// PatchRequest is a JSON merge patch (RFC 7396) of a tenant, as sent to
+// PATCH /tenants/{id}. Absent members are left unchanged and null clears the
+// description or detaches the tenant from its parent; name and status cannot
+// be cleared. Labels are merged key by key, a null value removing the label,
+// and metadata is merged into the stored metadata.
+type PatchRequest struct {
+	Name           mergepatch.Field[string]             `json:"name,omitzero"`
+	Description    mergepatch.Field[string]             `json:"description,omitzero"`
+	Status         mergepatch.Field[string]             `json:"status,omitzero"`
+	ParentTenantID mergepatch.Field[string]             `json:"parentTenantId,omitzero"`
+	Labels         mergepatch.Field[map[string]*string] `json:"labels,omitzero"`
+	Metadata       mergepatch.Field[json.RawMessage]    `json:"metadata,omitzero"`
+
+	// ExpectedVersion works as in UpdateRequest.
+	ExpectedVersion string `json:"-"`
+}
+
+// CloneRequest is the input for cloning a tenant or tenant template. The
+// description and parent are copied from the source unless set. OwnerID
+// defaults to the caller; IncludeOrganizations defaults to false for tenants
+// and to the template setting for templates.
+type CloneRequest struct {
+	Code                 string `json:"code"`
+	Name                 string `json:"name"`
+	Description          string `json:"description,omitempty"`
+	ParentTenantID       string `json:"parentTenantId,omitempty"`
+	OwnerID              string `json:"ownerId,omitempty"`
+	IncludeOrganizations *bool  `json:"includeOrganizations,omitempty"`
 }
 
 // AddMemberRequest is the input for adding a member to a tenant.
 type AddMemberRequest struct {
 	UserID string `json:"userId"`
 	Role   string `json:"role,omitempty"`
+	MemberTerms
+}
+
+// SuspendMemberRequest is the optional input for suspending a member.
+type SuspendMemberRequest struct {
+	Reason string `json:"reason,omitempty"`
 }
 
-// ListFilters holds query parameters for listing tenants.
+// MemberTerms are the optional type and validity window of a membership.
+// Type is "standard" (default) or "guest"; a nil bound is open-ended.
+type MemberTerms struct {
+	Type      string     `json:"type,omitempty"`
+	ValidFrom *time.Time `json:"validFrom,omitempty"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+// ListFilters holds query parameters for listing tenants. Status and
+// Statuses are combined; a tenant matches when its status is any of them.
+// Date bounds are inclusive.
 type ListFilters struct {
-	Page           int    `json:"page,omitempty"`
-	PageSize       int    `json:"pageSize,omitempty"`
-	Query          string `json:"query,omitempty"`
-	Status         string `json:"status,omitempty"`
-	IncludeDeleted bool   `json:"includeDeleted,omitempty"`
+	Page           int      `json:"page,omitempty"`
+	PageSize       int      `json:"pageSize,omitempty"`
+	Query          string   `json:"query,omitempty"`
+	Status         string   `json:"status,omitempty"`
+	Statuses       []string `json:"statuses,omitempty"`
+	IncludeDeleted bool     `json:"includeDeleted,omitempty"`
+	// LabelSelector is a Kubernetes-style label selector, for example
+	// "plan=pro,region in (eu,us)".
+	LabelSelector string `json:"labelSelector,omitempty"`
+
+	OwnerID          *uuid.UUID `json:"ownerId,omitempty"`
+	ParentTenantID   *uuid.UUID `json:"parentTenantId,omitempty"`
+	AncestorTenantID *uuid.UUID `json:"ancestorTenantId,omitempty"`
+	MemberID         *uuid.UUID `json:"memberId,omitempty"`
+	CreatedAfter     *time.Time `json:"createdAfter,omitempty"`
+	CreatedBefore    *time.Time `json:"createdBefore,omitempty"`
+	UpdatedAfter     *time.Time `json:"updatedAfter,omitempty"`
+	UpdatedBefore    *time.Time `json:"updatedBefore,omitempty"`
+}
+
+// TenantFilter is a tenant search expression. Every condition set on a
+// filter must hold, every filter in And must match, and at least one filter
+// in Or must match when Or is not empty. ParentTenantID matches direct
+// children, AncestorTenantID any descendant, and MemberID tenants where the
+// user has a membership, suspended or not.
+type TenantFilter struct {
+	Query            string     `json:"query,omitempty"`
+	Statuses         []string   `json:"statuses,omitempty"`
+	LabelSelector    string     `json:"labelSelector,omitempty"`
+	OwnerID          *uuid.UUID `json:"ownerId,omitempty"`
+	ParentTenantID   *uuid.UUID `json:"parentTenantId,omitempty"`
+	AncestorTenantID *uuid.UUID `json:"ancestorTenantId,omitempty"`
+	MemberID         *uuid.UUID `json:"memberId,omitempty"`
+	CreatedAfter     *time.Time `json:"createdAfter,omitempty"`
+	CreatedBefore    *time.Time `json:"createdBefore,omitempty"`
+	UpdatedAfter     *time.Time `json:"updatedAfter,omitempty"`
+	UpdatedBefore    *time.Time `json:"updatedBefore,omitempty"`
+
+	And []TenantFilter `json:"and,omitempty"`
+	Or  []TenantFilter `json:"or,omitempty"`
+}
+
+// SearchRequest is the body of POST /tenants/search.
+type SearchRequest struct {
+	Filter         TenantFilter `json:"filter"`
+	Page           int          `json:"page,omitempty"`
+	PageSize       int          `json:"pageSize,omitempty"`
+	IncludeDeleted bool         `json:"includeDeleted,omitempty"`
+}
+
+// MemberListFilters holds query parameters for listing tenant members.
+// Query matches username, email or nickname substrings. Status is a
+// membership status: "active" (default), "suspended" or "all". Sort is one
+// of username, email, nickname, role, status or joinedAt (default), and
+// Order is "asc" or "desc" (default).
+type MemberListFilters struct {
+	Page         int        `json:"page,omitempty"`
+	PageSize     int        `json:"pageSize,omitempty"`
+	Query        string     `json:"query,omitempty"`
+	Roles        []string   `json:"roles,omitempty"`
+	Status       string     `json:"status,omitempty"`
+	JoinedAfter  *time.Time `json:"joinedAfter,omitempty"`
+	JoinedBefore *time.Time `json:"joinedBefore,omitempty"`
+	Sort         string     `json:"sort,omitempty"`
+	Order        string     `json:"order,omitempty"`
 }
 
 // --- Responses ---
@@ -54,6 +177,11 @@ type TenantDTO struct {
 	DomainID       uuid.UUID  `json:"domainId"`
 	CreatedAt      time.Time  `json:"createdAt"`
 	UpdatedAt      time.Time  `json:"updatedAt"`
+	// Version changes with every update; GET and PUT answer it as the ETag.
+	Version string `json:"version"`
+
+	Labels   map[string]string `json:"labels,omitempty"`
+	Metadata json.RawMessage   `json:"metadata,omitempty"`
 }
 
 // ListResult is the paginated tenant list response.
@@ -74,6 +202,18 @@ type MemberDTO struct {
 	Status    string    `json:"status"`
 	Role      string    `json:"role,omitempty"`
 	IsDefault bool      `json:"isDefault"`
+
+	MembershipType string     `json:"membershipType"`
+	ValidFrom      *time.Time `json:"validFrom,omitempty"`
+	ExpiresAt      *time.Time `json:"expiresAt,omitempty"`
+	// Expired is set for memberships past expiresAt that the sweeper has
+	// not removed yet.
+	Expired bool `json:"expired,omitempty"`
+
+	// MembershipStatus is "active" or "suspended"; Status is the user's
+	// account status.
+	MembershipStatus string    `json:"membershipStatus"`
+	JoinedAt         time.Time `json:"joinedAt"`
 }
 
 // MemberListResult is the paginated member list response.
@@ -83,19 +223,372 @@ type MemberListResult struct {
 	Page       int          `json:"page"`
 	PageSize   int          `json:"pageSize"`
 	TotalPages int          `json:"totalPages"`
+	Facets     MemberFacets `json:"facets"`
+}
+
+// MemberFacets counts matching members per role and membership status.
+// Each facet applies every filter except its own, so the counts show what
+// selecting another value would return.
+type MemberFacets struct {
+	Roles    map[string]int `json:"roles"`
+	Statuses map[string]int `json:"statuses"`
 }
 
 // MyTenantDTO is a summary of a tenant the current user belongs to.
 type MyTenantDTO struct {
-	ID        uuid.UUID `json:"id"`
-	Code      string    `json:"code"`
-	Name      string    `json:"name"`
-	Status    string    `json:"status"`
-	Role      string    `json:"role,omitempty"`
-	IsDefault bool      `json:"isDefault"`
+	ID        uuid.UUID     `json:"id"`
+	Code      string        `json:"code"`
+	Name      string        `json:"name"`
+	Status    string        `json:"status"`
+	Suspended bool          `json:"suspended"`
+	DomainID  uuid.UUID     `json:"domainId"`
+	Parent    *TenantRefDTO `json:"parent,omitempty"`
+	Role      string        `json:"role,omitempty"`
+	IsDefault bool          `json:"isDefault"`
+	// Permissions are the effective permissions granted by Role in the
+	// tenant domain.
+	Permissions []string `json:"permissions"`
+
+	JoinedAt       time.Time  `json:"joinedAt"`
+	MembershipType string     `json:"membershipType"`
+	ValidFrom      *time.Time `json:"validFrom,omitempty"`
+	ExpiresAt      *time.Time `json:"expiresAt,omitempty"`
+}
+
+// TenantRefDTO identifies a related tenant.
+type TenantRefDTO struct {
+	ID   uuid.UUID `json:"id"`
+	Code string    `json:"code"`
+	Name string    `json:"name"`
+}
+
+// RoleListResult lists the roles of a tenant domain.
+type RoleListResult struct {
+	Roles []shared.RoleInfo `json:"roles"`
+}
+
+// RoleSyncReport is the outcome of pushing role templates into a tenant.
+type RoleSyncReport struct {
+	TenantID   uuid.UUID `json:"tenantId"`
+	TenantCode string    `json:"tenantCode"`
+	shared.RoleSyncResult
+}
+
+// RoleSyncListResult is the outcome of re-syncing every tenant.
+type RoleSyncListResult struct {
+	Tenants []*RoleSyncReport `json:"tenants"`
+}
+
+// ProvisionStep is the outcome of one step of a provisioning flow. Status
+// is "done", "skipped" or "failed"; Count is the number of items copied.
+type ProvisionStep struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Count  int    `json:"count"`
+	Error  string `json:"error,omitempty"`
+}
+
+// CloneResult is the outcome of cloning a tenant.
+type CloneResult struct {
+	Tenant         *TenantDTO      `json:"tenant"`
+	SourceTenantID uuid.UUID       `json:"sourceTenantId"`
+	Template       string          `json:"template,omitempty"`
+	Steps          []ProvisionStep `json:"steps"`
+}
+
+// TenantTemplateListResult lists the registered tenant templates.
+type TenantTemplateListResult struct {
+	Templates []shared.TenantTemplate `json:"templates"`
+}
+
+// TenantArchive is the portable form of a tenant written by ExportTenant and
+// read by ImportTenant. Users are identified by username and email so that
+// archives can move between environments.
+type TenantArchive struct {
+	Version       int                    `json:"version"`
+	ExportedAt    time.Time              `json:"exportedAt"`
+	Tenant        ArchivedTenant         `json:"tenant"`
+	Roles         []shared.RoleInfo      `json:"roles"`
+	Members       []ArchivedMember       `json:"members"`
+	Organizations []ArchivedOrganization `json:"organizations"`
+}
+
+// ArchivedTenant is the tenant record and settings of an archive.
+type ArchivedTenant struct {
+	Code        string        `json:"code"`
+	Name        string        `json:"name"`
+	Description string        `json:"description,omitempty"`
+	Status      string        `json:"status"`
+	ParentCode  string        `json:"parentCode,omitempty"`
+	Owner       *ArchivedUser `json:"owner,omitempty"`
+
+	Labels   map[string]string `json:"labels,omitempty"`
+	Metadata json.RawMessage   `json:"metadata,omitempty"`
+}
+
+// ArchivedUser identifies a user across environments.
+type ArchivedUser struct {
+	Username string `json:"username"`
+	Email    string `json:"email"`
+}
+
+// ArchivedMember is a tenant membership of an archive.
+type ArchivedMember struct {
+	ArchivedUser
+	Role             string     `json:"role"`
+	MembershipStatus string     `json:"membershipStatus"`
+	MembershipType   string     `json:"membershipType"`
+	ValidFrom        *time.Time `json:"validFrom,omitempty"`
+	ExpiresAt        *time.Time `json:"expiresAt,omitempty"`
+}
+
+// ArchivedOrganization is an organization of the tenant domain.
+type ArchivedOrganization struct {
+	Code    string                       `json:"code"`
+	Name    string                       `json:"name"`
+	Path    string                       `json:"path"`
+	Members []ArchivedOrganizationMember `json:"members,omitempty"`
+}
+
+// ArchivedOrganizationMember is a member of an archived organization.
+type ArchivedOrganizationMember struct {
+	ArchivedUser
+	IsPrimary bool `json:"isPrimary"`
+}
+
+// ImportOptions control ImportTenant. Code and Name override the archive.
+// OnConflict decides what happens when the code is taken: "fail" (default),
+// "rename" to the first free "<code>-N", or "merge" into the existing
+// tenant. DryRun reports the changes without applying them.
+type ImportOptions struct {
+	Code       string `json:"code,omitempty"`
+	Name       string `json:"name,omitempty"`
+	OnConflict string `json:"onConflict,omitempty"`
+	DryRun     bool   `json:"dryRun,omitempty"`
+}
+
+// ImportReport lists the changes an import made, or would make in a dry
+// run. Action is "create" or "merge".
+type ImportReport struct {
+	DryRun     bool           `json:"dryRun"`
+	Action     string         `json:"action"`
+	TenantID   *uuid.UUID     `json:"tenantId,omitempty"`
+	TenantCode string         `json:"tenantCode"`
+	Changes    []ImportChange `json:"changes"`
+	Warnings   []string       `json:"warnings"`
+}
+
+// ImportChange is one entry of an import diff. Kind is tenant, role, member
+// or organization; Action is create, update, unchanged or skip.
+type ImportChange struct {
+	Kind   string `json:"kind"`
+	Key    string `json:"key"`
+	Action string `json:"action"`
+	Detail string `json:"detail,omitempty"`
 }
 
 // MyTenantListResult is the list of tenants for the current user.
 type MyTenantListResult struct {
 	Tenants []*MyTenantDTO `json:"tenants"`
 }
+
+// ImpersonateRequest is the input for starting an impersonation session.
+// TTLSeconds defaults to the impersonationTtlSeconds config.
+type ImpersonateRequest struct {
+	Reason     string `json:"reason"`
+	TTLSeconds int    `json:"ttlSeconds,omitempty"`
+}
+
+// ImpersonationDTO is an impersonation session. Status is active, expired
+// or revoked.
+type ImpersonationDTO struct {
+	ID         uuid.UUID  `json:"id"`
+	TenantID   uuid.UUID  `json:"tenantId"`
+	TenantCode string     `json:"tenantCode"`
+	DomainID   uuid.UUID  `json:"domainId"`
+	ActorID    uuid.UUID  `json:"actorId"`
+	Reason     string     `json:"reason"`
+	Status     string     `json:"status"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	ExpiresAt  time.Time  `json:"expiresAt"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+	RevokedBy  *uuid.UUID `json:"revokedBy,omitempty"`
+}
+
+// ImpersonationGrant is returned when a session starts. Token is sent in the
+// Header of requests made on behalf of the tenant; it is not shown again.
+type ImpersonationGrant struct {
+	Session *ImpersonationDTO `json:"session"`
+	Token   string            `json:"token"`
+	Header  string            `json:"header"`
+}
+
+// ImpersonationFilters narrow ListImpersonations. Status is active (default),
+// expired, revoked or all.
+type ImpersonationFilters struct {
+	TenantID uuid.UUID
+	ActorID  uuid.UUID
+	Status   string
+}
+
+// ImpersonationListResult is the impersonation session list response.
+type ImpersonationListResult struct {
+	Sessions []*ImpersonationDTO `json:"sessions"`
+}
+
+// CreateServiceAccountRequest is the input for creating a service account.
+// Role defaults to the defaultMemberRole config and cannot be changed later.
+type CreateServiceAccountRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Role        string `json:"role,omitempty"`
+}
+
+// UpdateServiceAccountRequest changes a service account. Nil fields are left
+// unchanged.
+type UpdateServiceAccountRequest struct {
+	Name        *string `json:"name,omitempty"`
+	Description *string `json:"description,omitempty"`
+	Disabled    *bool   `json:"disabled,omitempty"`
+}
+
+// ServiceAccountDTO is a service account. LastUsedAt is the latest use of
+// any of its keys.
+type ServiceAccountDTO struct {
+	ID          uuid.UUID  `json:"id"`
+	TenantID    uuid.UUID  `json:"tenantId"`
+	TenantCode  string     `json:"tenantCode"`
+	DomainID    uuid.UUID  `json:"domainId"`
+	Name        string     `json:"name"`
+	Description string     `json:"description,omitempty"`
+	Role        string     `json:"role"`
+	Disabled    bool       `json:"disabled"`
+	ActiveKeys  int        `json:"activeKeys"`
+	LastUsedAt  *time.Time `json:"lastUsedAt,omitempty"`
+	CreatedBy   uuid.UUID  `json:"createdBy"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+}
+
+// ServiceAccountListResult is the service account list response.
+type ServiceAccountListResult struct {
+	Accounts []*ServiceAccountDTO `json:"accounts"`
+}
+
+// CreateAPIKeyRequest is the input for issuing an API key. Keys without
+// ExpiresAt do not expire.
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+// RotateAPIKeyRequest is the input for rotating an API key. The old key
+// keeps working for GraceSeconds (0 revokes it at once). ExpiresAt defaults
+// to the lifetime of the old key counted from now.
+type RotateAPIKeyRequest struct {
+	GraceSeconds int        `json:"graceSeconds,omitempty"`
+	ExpiresAt    *time.Time `json:"expiresAt,omitempty"`
+}
+
+// APIKeyDTO is an API key without its secret. Status is active, expired or
+// revoked.
+type APIKeyDTO struct {
+	ID         uuid.UUID  `json:"id"`
+	AccountID  uuid.UUID  `json:"accountId"`
+	Name       string     `json:"name"`
+	Status     string     `json:"status"`
+	CreatedBy  uuid.UUID  `json:"createdBy"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	RotatedTo  *uuid.UUID `json:"rotatedTo,omitempty"`
+}
+
+// APIKeyGrant is returned when a key is issued or rotated. Token is sent as
+// "Authorization: ApiKey <token>"; it is not shown again.
+type APIKeyGrant struct {
+	Key   *APIKeyDTO `json:"key"`
+	Token string     `json:"token"`
+}
+
+// APIKeyListResult is the API key list response.
+type APIKeyListResult struct {
+	Keys []*APIKeyDTO `json:"keys"`
+}
+
+// CreateWebhookRequest registers a webhook endpoint. Events lists the event
+// types to deliver; "*" subscribes to all of them.
+type CreateWebhookRequest struct {
+	URL         string   `json:"url"`
+	Description string   `json:"description,omitempty"`
+	Events      []string `json:"events"`
+}
+
+// UpdateWebhookRequest changes a webhook endpoint. Nil fields are left
+// unchanged.
+type UpdateWebhookRequest struct {
+	URL         *string  `json:"url,omitempty"`
+	Description *string  `json:"description,omitempty"`
+	Events      []string `json:"events,omitempty"`
+	Disabled    *bool    `json:"disabled,omitempty"`
+}
+
+// WebhookDTO is a webhook endpoint. The signing secret is only returned
+// when the endpoint is created.
+type WebhookDTO struct {
+	ID          uuid.UUID  `json:"id"`
+	TenantID    *uuid.UUID `json:"tenantId,omitempty"`
+	URL         string     `json:"url"`
+	Description string     `json:"description,omitempty"`
+	Events      []string   `json:"events"`
+	Disabled    bool       `json:"disabled"`
+	CreatedBy   uuid.UUID  `json:"createdBy"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+}
+
+// WebhookGrant is returned when a webhook is created. Secret keys the
+// X-Webhook-Signature of every delivery; it is not shown again.
+type WebhookGrant struct {
+	Webhook *WebhookDTO `json:"webhook"`
+	Secret  string      `json:"secret"`
+}
+
+// WebhookListResult is the webhook list response.
+type WebhookListResult struct {
+	Webhooks []*WebhookDTO `json:"webhooks"`
+}
+
+// WebhookDeliveryFilters holds query parameters for the delivery log.
+// Status is "pending", "delivered" or "dead"; empty lists all.
+type WebhookDeliveryFilters struct {
+	Page     int    `json:"page,omitempty"`
+	PageSize int    `json:"pageSize,omitempty"`
+	Status   string `json:"status,omitempty"`
+	Event    string `json:"event,omitempty"`
+}
+
+// WebhookDeliveryDTO is one entry of the delivery log.
+type WebhookDeliveryDTO struct {
+	ID            uuid.UUID               `json:"id"`
+	WebhookID     uuid.UUID               `json:"webhookId"`
+	EventID       uuid.UUID               `json:"eventId"`
+	Event         string                  `json:"event"`
+	Status        string                  `json:"status"`
+	Attempts      []shared.WebhookAttempt `json:"attempts"`
+	NextAttemptAt *time.Time              `json:"nextAttemptAt,omitempty"`
+	RedeliveryOf  *uuid.UUID              `json:"redeliveryOf,omitempty"`
+	Payload       json.RawMessage         `json:"payload"`
+	CreatedAt     time.Time               `json:"createdAt"`
+	UpdatedAt     time.Time               `json:"updatedAt"`
+}
+
+// WebhookDeliveryListResult is the delivery log response, newest first.
+type WebhookDeliveryListResult struct {
+	Deliveries []*WebhookDeliveryDTO `json:"deliveries"`
+	Total      int                   `json:"total"`
+	Page       int                   `json:"page"`
+	PageSize   int                   `json:"pageSize"`
+	TotalPages int                   `json:"totalPages"`
+}
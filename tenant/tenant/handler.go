@@ -3,8 +3,12 @@ package tenant
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
@@ -15,6 +19,9 @@ import (
 	"github.com/leeforge/core"
 	"github.com/leeforge/core/server/httplog"
 
+	"github.com/leeforge/plugins/apierror"
+	"github.com/leeforge/plugins/etag"
+	"github.com/leeforge/plugins/mergepatch"
 	"github.com/leeforge/plugins/tenant/shared"
 )
 
@@ -39,7 +46,7 @@ func NewHandler(service *Service, logger logging.Logger) *Handler {
 // @Accept json
 // @Produce json
 // @Param body body CreateRequest true "Tenant payload"
-// @Success 200 {object} map[string]interface{}
+// @Success 200 {object} TenantDTO
 // @Failure 400 {object} map[string]interface{}
 // @Failure 403 {object} map[string]interface{}
 // @Failure 500 {object} map[string]interface{}
@@ -68,32 +75,94 @@ func (h *Handler) CreateTenant(w http.ResponseWriter, r *http.Request) {
 // @Param page query int false "Page number"
 // @Param pageSize query int false "Page size"
 // @Param query query string false "Search query"
-// @Param status query string false "Tenant status"
+// @Param status query string false "Tenant status (comma-separated or repeated)"
 // @Param includeDeleted query bool false "Include deleted"
-// @Success 200 {object} map[string]interface{}
+// @Param labelSelector query string false "Label selector, e.g. plan=pro,region in (eu,us)"
+// @Param ownerId query string false "Owner user ID"
+// @Param parentTenantId query string false "Direct parent tenant ID"
+// @Param ancestorTenantId query string false "Ancestor tenant ID, at any depth"
+// @Param memberId query string false "User ID of a member"
+// @Param createdAfter query string false "Created at or after (RFC 3339)"
+// @Param createdBefore query string false "Created at or before (RFC 3339)"
+// @Param updatedAfter query string false "Updated at or after (RFC 3339)"
+// @Param updatedBefore query string false "Updated at or before (RFC 3339)"
+// @Success 200 {object} ListResult
+// @Failure 400 {object} map[string]interface{}
 // @Failure 403 {object} map[string]interface{}
 // @Failure 500 {object} map[string]interface{}
 // @Router /api/v1/tenants [get]
 func (h *Handler) ListTenants(w http.ResponseWriter, r *http.Request) {
-	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
-	pageSize, _ := strconv.Atoi(r.URL.Query().Get("pageSize"))
+	q := r.URL.Query()
+	page, _ := strconv.Atoi(q.Get("page"))
+	pageSize, _ := strconv.Atoi(q.Get("pageSize"))
 
 	filters := ListFilters{
 		Page:           page,
 		PageSize:       pageSize,
-		Query:          r.URL.Query().Get("query"),
-		Status:         r.URL.Query().Get("status"),
-		IncludeDeleted: r.URL.Query().Get("includeDeleted") == "true",
+		Query:          q.Get("query"),
+		IncludeDeleted: q.Get("includeDeleted") == "true",
+		LabelSelector:  q.Get("labelSelector"),
+	}
+	for _, v := range q["status"] {
+		filters.Statuses = append(filters.Statuses, strings.Split(v, ",")...)
+	}
+	for name, dst := range map[string]**uuid.UUID{
+		"ownerId":          &filters.OwnerID,
+		"parentTenantId":   &filters.ParentTenantID,
+		"ancestorTenantId": &filters.AncestorTenantID,
+		"memberId":         &filters.MemberID,
+	} {
+		var err error
+		if *dst, err = parseUUIDParam(q.Get(name)); err != nil {
+			responder.BadRequest(w, r, "Invalid "+name)
+			return
+		}
+	}
+	for name, dst := range map[string]**time.Time{
+		"createdAfter":  &filters.CreatedAfter,
+		"createdBefore": &filters.CreatedBefore,
+		"updatedAfter":  &filters.UpdatedAfter,
+		"updatedBefore": &filters.UpdatedBefore,
+	} {
+		var err error
+		if *dst, err = parseTimeParam(q.Get(name)); err != nil {
+			responder.BadRequest(w, r, "Invalid "+name+", expected RFC 3339")
+			return
+		}
 	}
 
 	result, err := h.service.ListTenants(r.Context(), filters)
 	if err != nil {
-		if errors.Is(err, shared.ErrPlatformDomainOnly) {
-			responder.Forbidden(w, r, "Platform domain required")
-			return
-		}
-		httplog.Error(h.logger, r, "Failed to list tenants", err)
-		responder.DatabaseError(w, r, "Failed to list tenants")
+		h.mapTenantError(w, r, "Failed to list tenants", err)
+		return
+	}
+
+	responder.OK(w, r, result)
+}
+
+// SearchTenants handles POST /tenants/search
+//
+// @Summary Search tenants
+// @Description Filters tenants with a JSON expression: the conditions of a filter must all hold, "and" sub-filters must all match and at least one "or" sub-filter must match.
+// @Tags TenantPlugin-Tenants
+// @Accept json
+// @Produce json
+// @Param body body SearchRequest true "Search expression and paging"
+// @Success 200 {object} ListResult
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/tenants/search [post]
+func (h *Handler) SearchTenants(w http.ResponseWriter, r *http.Request) {
+	var req SearchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		responder.BindError(w, r, nil)
+		return
+	}
+
+	result, err := h.service.SearchTenants(r.Context(), &req)
+	if err != nil {
+		h.mapTenantError(w, r, "Failed to search tenants", err)
 		return
 	}
 
@@ -105,7 +174,7 @@ func (h *Handler) ListTenants(w http.ResponseWriter, r *http.Request) {
 // @Summary List my tenants
 // @Tags TenantPlugin-Tenants
 // @Produce json
-// @Success 200 {object} map[string]interface{}
+// @Success 200 {object} MyTenantListResult
 // @Failure 401 {object} map[string]interface{}
 // @Failure 500 {object} map[string]interface{}
 // @Router /api/v1/tenants/me [get]
@@ -126,13 +195,55 @@ func (h *Handler) ListMyTenants(w http.ResponseWriter, r *http.Request) {
 	responder.OK(w, r, result)
 }
 
+// GetMyTenant handles GET /tenants/me/{id}
+//
+// @Summary Get my membership in a tenant
+// @Tags TenantPlugin-Tenants
+// @Produce json
+// @Param id path string true "Tenant ID"
+// @Success 200 {object} MyTenantDTO
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/tenants/me/{id} [get]
+func (h *Handler) GetMyTenant(w http.ResponseWriter, r *http.Request) {
+	userID, ok := core.GetUserID(r.Context())
+	if !ok {
+		responder.Unauthorized(w, r, "Missing user context")
+		return
+	}
+
+	tenantID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		responder.BadRequest(w, r, "Invalid tenant ID")
+		return
+	}
+
+	result, err := h.service.GetMyTenant(r.Context(), userID, tenantID)
+	if err != nil {
+		switch {
+		case errors.Is(err, shared.ErrTenantNotFound):
+			responder.NotFound(w, r, "Tenant not found")
+		case errors.Is(err, shared.ErrMemberNotFound):
+			responder.NotFound(w, r, "Membership not found")
+		default:
+			httplog.Error(h.logger, r, "Failed to get my tenant", err)
+			responder.DatabaseError(w, r, "Failed to get my tenant")
+		}
+		return
+	}
+
+	responder.OK(w, r, result)
+}
+
 // GetTenant handles GET /tenants/{id}
 //
 // @Summary Get tenant
 // @Tags TenantPlugin-Tenants
 // @Produce json
 // @Param id path string true "Tenant ID"
-// @Success 200 {object} map[string]interface{}
+// @Success 200 {object} TenantDTO
 // @Failure 400 {object} map[string]interface{}
 // @Failure 404 {object} map[string]interface{}
 // @Failure 500 {object} map[string]interface{}
@@ -150,20 +261,25 @@ func (h *Handler) GetTenant(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	etag.Set(w, result.Version)
 	responder.OK(w, r, result)
 }
 
-// UpdateTenant handles PUT /tenants/{id}
+// UpdateTenant handles PUT /tenants/{id}. With an If-Match header the update
+// only applies while the tenant is at that ETag; otherwise it is answered
+// with 412 and the current tenant.
 //
 // @Summary Update tenant
 // @Tags TenantPlugin-Tenants
 // @Accept json
 // @Produce json
 // @Param id path string true "Tenant ID"
+// @Param If-Match header string false "ETag of the tenant version the update is based on"
 // @Param body body UpdateRequest true "Tenant update payload"
-// @Success 200 {object} map[string]interface{}
+// @Success 200 {object} TenantDTO
 // @Failure 400 {object} map[string]interface{}
 // @Failure 404 {object} map[string]interface{}
+// @Failure 412 {object} TenantDTO
 // @Failure 500 {object} map[string]interface{}
 // @Router /api/v1/tenants/{id} [put]
 func (h *Handler) UpdateTenant(w http.ResponseWriter, r *http.Request) {
@@ -178,6 +294,10 @@ func (h *Handler) UpdateTenant(w http.ResponseWriter, r *http.Request) {
 		responder.BindError(w, r, nil)
 		return
 	}
+	if req.ExpectedVersion, err = etag.IfMatch(r); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
 
 	result, err := h.service.UpdateTenant(r.Context(), tenantID, &req)
 	if err != nil {
@@ -185,6 +305,55 @@ func (h *Handler) UpdateTenant(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	etag.Set(w, result.Version)
+	responder.OK(w, r, result)
+}
+
+// PatchTenant handles PATCH /tenants/{id} with a JSON merge patch (RFC 7396):
+// absent members are left unchanged and null clears the description or the
+// parent tenant. If-Match is honoured as by UpdateTenant.
+//
+// @Summary Patch tenant
+// @Tags TenantPlugin-Tenants
+// @Accept application/merge-patch+json
+// @Produce json
+// @Param id path string true "Tenant ID"
+// @Param If-Match header string false "ETag of the tenant version the patch is based on"
+// @Param body body PatchRequest true "Tenant merge patch"
+// @Success 200 {object} TenantDTO
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 412 {object} TenantDTO
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/tenants/{id} [patch]
+func (h *Handler) PatchTenant(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		responder.BadRequest(w, r, "Invalid tenant ID")
+		return
+	}
+	if err := mergepatch.CheckMediaType(r); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	var req PatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		responder.BindError(w, r, nil)
+		return
+	}
+	if req.ExpectedVersion, err = etag.IfMatch(r); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	result, err := h.service.PatchTenant(r.Context(), tenantID, &req)
+	if err != nil {
+		h.mapTenantError(w, r, "Failed to patch tenant", err)
+		return
+	}
+
+	etag.Set(w, result.Version)
 	responder.OK(w, r, result)
 }
 
@@ -193,7 +362,7 @@ func (h *Handler) UpdateTenant(w http.ResponseWriter, r *http.Request) {
 // @Summary Delete tenant
 // @Tags TenantPlugin-Tenants
 // @Param id path string true "Tenant ID"
-// @Success 200 {object} map[string]interface{}
+// @Success 200 {object} map[string]string
 // @Failure 400 {object} map[string]interface{}
 // @Failure 404 {object} map[string]interface{}
 // @Failure 500 {object} map[string]interface{}
@@ -221,7 +390,7 @@ func (h *Handler) DeleteTenant(w http.ResponseWriter, r *http.Request) {
 // @Produce json
 // @Param id path string true "Tenant ID"
 // @Param body body AddMemberRequest true "Member payload"
-// @Success 200 {object} map[string]interface{}
+// @Success 200 {object} map[string]string
 // @Failure 400 {object} map[string]interface{}
 // @Failure 403 {object} map[string]interface{}
 // @Failure 404 {object} map[string]interface{}
@@ -247,14 +416,13 @@ func (h *Handler) AddMember(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.service.AddMember(r.Context(), tenantID, userID, req.Role); err != nil {
+	if err := h.service.AddMember(r.Context(), tenantID, userID, req.Role, req.MemberTerms); err != nil {
 		switch {
+		case apierror.Write(w, r, err):
 		case errors.Is(err, shared.ErrPlatformDomainOnly):
 			responder.Forbidden(w, r, "Platform domain required")
 		case errors.Is(err, shared.ErrTenantNotFound):
 			responder.NotFound(w, r, "Tenant not found")
-		case errors.Is(err, shared.ErrMemberExists):
-			responder.Conflict(w, r, "User is already a member")
 		default:
 			httplog.Error(h.logger, r, "Failed to add member", err)
 			responder.DatabaseError(w, r, "Failed to add member")
@@ -273,7 +441,14 @@ func (h *Handler) AddMember(w http.ResponseWriter, r *http.Request) {
 // @Param id path string true "Tenant ID"
 // @Param page query int false "Page number"
 // @Param pageSize query int false "Page size"
-// @Success 200 {object} map[string]interface{}
+// @Param query query string false "Username, email or nickname substring"
+// @Param role query string false "Role (comma-separated or repeated)"
+// @Param status query string false "Membership status: active (default), suspended or all"
+// @Param joinedAfter query string false "Joined at or after (RFC 3339)"
+// @Param joinedBefore query string false "Joined at or before (RFC 3339)"
+// @Param sort query string false "Sort field: username, email, nickname, role, status or joinedAt"
+// @Param order query string false "Sort order: asc or desc (default)"
+// @Success 200 {object} MemberListResult
 // @Failure 400 {object} map[string]interface{}
 // @Failure 403 {object} map[string]interface{}
 // @Failure 404 {object} map[string]interface{}
@@ -286,11 +461,35 @@ func (h *Handler) ListMembers(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
-	pageSize, _ := strconv.Atoi(r.URL.Query().Get("pageSize"))
+	q := r.URL.Query()
+	page, _ := strconv.Atoi(q.Get("page"))
+	pageSize, _ := strconv.Atoi(q.Get("pageSize"))
 
-	result, err := h.service.ListMembers(r.Context(), tenantID, page, pageSize)
+	filters := MemberListFilters{
+		Page:     page,
+		PageSize: pageSize,
+		Query:    q.Get("query"),
+		Status:   q.Get("status"),
+		Sort:     q.Get("sort"),
+		Order:    q.Get("order"),
+	}
+	for _, v := range q["role"] {
+		filters.Roles = append(filters.Roles, strings.Split(v, ",")...)
+	}
+	if filters.JoinedAfter, err = parseTimeParam(q.Get("joinedAfter")); err != nil {
+		responder.BadRequest(w, r, "Invalid joinedAfter, expected RFC 3339")
+		return
+	}
+	if filters.JoinedBefore, err = parseTimeParam(q.Get("joinedBefore")); err != nil {
+		responder.BadRequest(w, r, "Invalid joinedBefore, expected RFC 3339")
+		return
+	}
+
+	result, err := h.service.ListMembers(r.Context(), tenantID, filters)
 	if err != nil {
+		if apierror.Write(w, r, err) {
+			return
+		}
 		if errors.Is(err, shared.ErrPlatformDomainOnly) {
 			responder.Forbidden(w, r, "Platform domain required")
 			return
@@ -313,7 +512,7 @@ func (h *Handler) ListMembers(w http.ResponseWriter, r *http.Request) {
 // @Tags TenantPlugin-Tenants
 // @Param id path string true "Tenant ID"
 // @Param userId path string true "User ID"
-// @Success 200 {object} map[string]interface{}
+// @Success 200 {object} map[string]string
 // @Failure 400 {object} map[string]interface{}
 // @Failure 403 {object} map[string]interface{}
 // @Failure 404 {object} map[string]interface{}
@@ -350,19 +549,1167 @@ func (h *Handler) RemoveMember(w http.ResponseWriter, r *http.Request) {
 	responder.OK(w, r, map[string]string{"message": "Member removed successfully"})
 }
 
-// mapTenantError maps common tenant service errors to HTTP responses.
-func (h *Handler) mapTenantError(w http.ResponseWriter, r *http.Request, msg string, err error) {
+// SuspendMember handles POST /tenants/{id}/members/{userId}/suspend
+//
+// @Summary Suspend tenant member
+// @Tags TenantPlugin-Tenants
+// @Accept json
+// @Param id path string true "Tenant ID"
+// @Param userId path string true "User ID"
+// @Param body body SuspendMemberRequest false "Suspension reason"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/tenants/{id}/members/{userId}/suspend [post]
+func (h *Handler) SuspendMember(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		responder.BadRequest(w, r, "Invalid tenant ID")
+		return
+	}
+
+	userID, err := uuid.Parse(chi.URLParam(r, "userId"))
+	if err != nil {
+		responder.BadRequest(w, r, "Invalid user ID")
+		return
+	}
+
+	var req SuspendMemberRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		responder.BadRequest(w, r, "Invalid request body")
+		return
+	}
+
+	if err := h.service.SuspendMember(r.Context(), tenantID, userID, req.Reason); err != nil {
+		h.mapMemberStateError(w, r, "Failed to suspend member", err)
+		return
+	}
+
+	responder.OK(w, r, map[string]string{"message": "Member suspended successfully"})
+}
+
+// ReactivateMember handles POST /tenants/{id}/members/{userId}/reactivate
+//
+// @Summary Reactivate suspended tenant member
+// @Tags TenantPlugin-Tenants
+// @Param id path string true "Tenant ID"
+// @Param userId path string true "User ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/tenants/{id}/members/{userId}/reactivate [post]
+func (h *Handler) ReactivateMember(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		responder.BadRequest(w, r, "Invalid tenant ID")
+		return
+	}
+
+	userID, err := uuid.Parse(chi.URLParam(r, "userId"))
+	if err != nil {
+		responder.BadRequest(w, r, "Invalid user ID")
+		return
+	}
+
+	if err := h.service.ReactivateMember(r.Context(), tenantID, userID); err != nil {
+		h.mapMemberStateError(w, r, "Failed to reactivate member", err)
+		return
+	}
+
+	responder.OK(w, r, map[string]string{"message": "Member reactivated successfully"})
+}
+
+// mapMemberStateError maps suspend and reactivate errors to HTTP responses.
+func (h *Handler) mapMemberStateError(w http.ResponseWriter, r *http.Request, msg string, err error) {
 	switch {
-	case errors.Is(err, shared.ErrTenantNotFound):
-		responder.NotFound(w, r, "Tenant not found")
-	case errors.Is(err, shared.ErrTenantCodeExists):
-		responder.Conflict(w, r, "Tenant code already exists")
-	case errors.Is(err, shared.ErrInvalidTenant), errors.Is(err, shared.ErrParentTenantInvalid):
-		responder.BadRequest(w, r, "Invalid tenant data")
+	case apierror.Write(w, r, err):
 	case errors.Is(err, shared.ErrPlatformDomainOnly):
 		responder.Forbidden(w, r, "Platform domain required")
+	case errors.Is(err, shared.ErrTenantNotFound):
+		responder.NotFound(w, r, "Tenant not found")
+	case errors.Is(err, shared.ErrMemberNotFound):
+		responder.NotFound(w, r, "Membership not found")
 	default:
 		httplog.Error(h.logger, r, msg, err)
 		responder.DatabaseError(w, r, msg)
 	}
 }
+
+// ListRoles handles GET /tenants/{id}/roles
+//
+// @Summary List tenant roles
+// @Tags TenantPlugin-Tenants
+// @Produce json
+// @Param id path string true "Tenant ID"
+// @Success 200 {object} RoleListResult
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/tenants/{id}/roles [get]
+func (h *Handler) ListRoles(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		responder.BadRequest(w, r, "Invalid tenant ID")
+		return
+	}
+
+	result, err := h.service.ListRoles(r.Context(), tenantID)
+	if err != nil {
+		switch {
+		case errors.Is(err, shared.ErrPlatformDomainOnly):
+			responder.Forbidden(w, r, "Platform domain required")
+		case errors.Is(err, shared.ErrTenantNotFound):
+			responder.NotFound(w, r, "Tenant not found")
+		default:
+			httplog.Error(h.logger, r, "Failed to list roles", err)
+			responder.DatabaseError(w, r, "Failed to list roles")
+		}
+		return
+	}
+
+	responder.OK(w, r, result)
+}
+
+// SyncRoleTemplates handles POST /tenants/{id}/roles/sync
+//
+// @Summary Re-sync tenant roles from role templates
+// @Tags TenantPlugin-Tenants
+// @Produce json
+// @Param id path string true "Tenant ID"
+// @Success 200 {object} RoleSyncReport
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/tenants/{id}/roles/sync [post]
+func (h *Handler) SyncRoleTemplates(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		responder.BadRequest(w, r, "Invalid tenant ID")
+		return
+	}
+
+	result, err := h.service.SyncRoleTemplates(r.Context(), tenantID)
+	if err != nil {
+		h.mapRoleSyncError(w, r, err)
+		return
+	}
+
+	responder.OK(w, r, result)
+}
+
+// SyncAllRoleTemplates handles POST /tenants/roles/sync
+//
+// @Summary Re-sync roles of all tenants from role templates
+// @Tags TenantPlugin-Tenants
+// @Produce json
+// @Success 200 {object} RoleSyncListResult
+// @Failure 403 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/tenants/roles/sync [post]
+func (h *Handler) SyncAllRoleTemplates(w http.ResponseWriter, r *http.Request) {
+	reports, err := h.service.SyncAllRoleTemplates(r.Context())
+	if err != nil {
+		h.mapRoleSyncError(w, r, err)
+		return
+	}
+
+	responder.OK(w, r, &RoleSyncListResult{Tenants: reports})
+}
+
+// mapRoleSyncError maps role template sync errors to HTTP responses.
+func (h *Handler) mapRoleSyncError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case apierror.Write(w, r, err):
+	case errors.Is(err, shared.ErrPlatformDomainOnly):
+		responder.Forbidden(w, r, "Platform domain required")
+	case errors.Is(err, shared.ErrTenantNotFound):
+		responder.NotFound(w, r, "Tenant not found")
+	default:
+		httplog.Error(h.logger, r, "Failed to sync role templates", err)
+		responder.DatabaseError(w, r, "Failed to sync role templates")
+	}
+}
+
+// CloneTenant handles POST /tenants/{id}/clone
+//
+// @Summary Clone tenant
+// @Tags TenantPlugin-Tenants
+// @Accept json
+// @Produce json
+// @Param id path string true "Source tenant ID"
+// @Param body body CloneRequest true "Clone payload"
+// @Success 200 {object} CloneResult
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/tenants/{id}/clone [post]
+func (h *Handler) CloneTenant(w http.ResponseWriter, r *http.Request) {
+	sourceID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		responder.BadRequest(w, r, "Invalid tenant ID")
+		return
+	}
+
+	var req CloneRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		responder.BindError(w, r, nil)
+		return
+	}
+
+	result, err := h.service.CloneTenant(r.Context(), sourceID, &req)
+	if err != nil {
+		h.mapProvisioningError(w, r, "Failed to clone tenant", result, err)
+		return
+	}
+
+	responder.OK(w, r, result)
+}
+
+// ListTenantTemplates handles GET /tenants/templates
+//
+// @Summary List tenant templates
+// @Tags TenantPlugin-Tenants
+// @Produce json
+// @Success 200 {object} TenantTemplateListResult
+// @Failure 403 {object} map[string]interface{}
+// @Router /api/v1/tenants/templates [get]
+func (h *Handler) ListTenantTemplates(w http.ResponseWriter, r *http.Request) {
+	result, err := h.service.ListTenantTemplates(r.Context())
+	if err != nil {
+		h.mapTenantError(w, r, "Failed to list tenant templates", err)
+		return
+	}
+
+	responder.OK(w, r, result)
+}
+
+// CloneTenantTemplate handles POST /tenants/templates/{name}/clone
+//
+// @Summary Create tenant from template
+// @Tags TenantPlugin-Tenants
+// @Accept json
+// @Produce json
+// @Param name path string true "Template name"
+// @Param body body CloneRequest true "Clone payload"
+// @Success 200 {object} CloneResult
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/tenants/templates/{name}/clone [post]
+func (h *Handler) CloneTenantTemplate(w http.ResponseWriter, r *http.Request) {
+	var req CloneRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		responder.BindError(w, r, nil)
+		return
+	}
+
+	result, err := h.service.CloneTenantTemplate(r.Context(), chi.URLParam(r, "name"), &req)
+	if err != nil {
+		h.mapProvisioningError(w, r, "Failed to clone tenant", result, err)
+		return
+	}
+
+	responder.OK(w, r, result)
+}
+
+// ExportTenant handles GET /tenants/{id}/export
+//
+// The archive is sent as an attachment in the format given by the format
+// query parameter: json (default) or tar.
+//
+// @Summary Export tenant
+// @Tags TenantPlugin-Tenants
+// @Produce json
+// @Produce application/x-tar
+// @Param id path string true "Tenant ID"
+// @Param format query string false "json or tar"
+// @Success 200 {object} TenantArchive
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/tenants/{id}/export [get]
+func (h *Handler) ExportTenant(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		responder.BadRequest(w, r, "Invalid tenant ID")
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = ArchiveFormatJSON
+	}
+	if format != ArchiveFormatJSON && format != ArchiveFormatTar {
+		responder.BadRequest(w, r, "Invalid archive format")
+		return
+	}
+
+	archive, err := h.service.ExportTenant(r.Context(), tenantID)
+	if err != nil {
+		h.mapTenantError(w, r, "Failed to export tenant", err)
+		return
+	}
+
+	contentType := "application/json"
+	if format == ArchiveFormatTar {
+		contentType = "application/x-tar"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition",
+		fmt.Sprintf(`attachment; filename="tenant-%s.%s"`, archive.Tenant.Code, format))
+	w.WriteHeader(http.StatusOK)
+	if err := WriteArchive(w, archive, format); err != nil {
+		httplog.Error(h.logger, r, "Failed to write tenant archive", err)
+	}
+}
+
+// ImportTenant handles POST /tenants/import
+//
+// The body is an archive written by ExportTenant. Tar archives are sent with
+// format=tar or a Content-Type of application/x-tar.
+//
+// @Summary Import tenant
+// @Tags TenantPlugin-Tenants
+// @Accept json
+// @Accept application/x-tar
+// @Produce json
+// @Param body body TenantArchive true "Tenant archive"
+// @Param format query string false "json or tar"
+// @Param dryRun query bool false "Report changes without applying them"
+// @Param onConflict query string false "fail, rename or merge"
+// @Param code query string false "Tenant code override"
+// @Param name query string false "Tenant name override"
+// @Success 200 {object} ImportReport
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/tenants/import [post]
+func (h *Handler) ImportTenant(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	format := q.Get("format")
+	if format == "" && strings.HasPrefix(r.Header.Get("Content-Type"), "application/x-tar") {
+		format = ArchiveFormatTar
+	}
+	dryRun := false
+	if v := q.Get("dryRun"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			responder.BadRequest(w, r, "Invalid dryRun")
+			return
+		}
+		dryRun = b
+	}
+
+	archive, err := ReadArchive(r.Body, format)
+	if err != nil {
+		responder.BadRequest(w, r, "Invalid tenant archive")
+		return
+	}
+
+	report, err := h.service.ImportTenant(r.Context(), archive, ImportOptions{
+		Code:       q.Get("code"),
+		Name:       q.Get("name"),
+		OnConflict: q.Get("onConflict"),
+		DryRun:     dryRun,
+	})
+	if err != nil {
+		h.mapProvisioningError(w, r, "Failed to import tenant", report, err)
+		return
+	}
+
+	responder.OK(w, r, report)
+}
+
+// mapProvisioningError maps clone and import errors to HTTP responses. When
+// provisioning failed after the tenant was created, the partial result is
+// returned as error details so that callers can see what was applied.
+func (h *Handler) mapProvisioningError(w http.ResponseWriter, r *http.Request, msg string, details any, err error) {
+	switch {
+	case errors.Is(err, shared.ErrProvisioningFailed):
+		httplog.Error(h.logger, r, "Tenant provisioning failed", err)
+		responder.CustomError(w, r, http.StatusInternalServerError, responder.ErrCodeInternalServer,
+			"Tenant provisioning failed", details)
+	case errors.Is(err, shared.ErrTenantTemplateNotFound):
+		responder.NotFound(w, r, "Tenant template not found")
+	default:
+		h.mapTenantError(w, r, msg, err)
+	}
+}
+
+// Impersonate handles POST /tenants/{id}/impersonate
+//
+// @Summary Start impersonating a tenant
+// @Tags TenantPlugin-Tenants
+// @Accept json
+// @Produce json
+// @Param id path string true "Tenant ID"
+// @Param body body ImpersonateRequest true "Impersonation payload"
+// @Success 200 {object} ImpersonationGrant
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/tenants/{id}/impersonate [post]
+func (h *Handler) Impersonate(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		responder.BadRequest(w, r, "Invalid tenant ID")
+		return
+	}
+
+	var req ImpersonateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		responder.BindError(w, r, nil)
+		return
+	}
+
+	grant, err := h.service.Impersonate(r.Context(), tenantID, &req)
+	if err != nil {
+		h.mapImpersonationError(w, r, "Failed to start impersonation", err)
+		return
+	}
+
+	responder.OK(w, r, grant)
+}
+
+// ListImpersonations handles GET /tenants/impersonations
+//
+// @Summary List impersonation sessions
+// @Tags TenantPlugin-Tenants
+// @Produce json
+// @Param tenantId query string false "Tenant ID"
+// @Param actorId query string false "Impersonating user ID"
+// @Param status query string false "active (default), expired, revoked or all"
+// @Success 200 {object} ImpersonationListResult
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Router /api/v1/tenants/impersonations [get]
+func (h *Handler) ListImpersonations(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filters := ImpersonationFilters{Status: q.Get("status")}
+	if v := q.Get("tenantId"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			responder.BadRequest(w, r, "Invalid tenantId")
+			return
+		}
+		filters.TenantID = id
+	}
+	if v := q.Get("actorId"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			responder.BadRequest(w, r, "Invalid actorId")
+			return
+		}
+		filters.ActorID = id
+	}
+
+	result, err := h.service.ListImpersonations(r.Context(), filters)
+	if err != nil {
+		h.mapImpersonationError(w, r, "Failed to list impersonation sessions", err)
+		return
+	}
+
+	responder.OK(w, r, result)
+}
+
+// RevokeImpersonation handles POST /tenants/impersonations/{sessionId}/revoke
+//
+// @Summary Revoke impersonation session
+// @Tags TenantPlugin-Tenants
+// @Produce json
+// @Param sessionId path string true "Session ID"
+// @Success 200 {object} ImpersonationDTO
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/v1/tenants/impersonations/{sessionId}/revoke [post]
+func (h *Handler) RevokeImpersonation(w http.ResponseWriter, r *http.Request) {
+	sessionID, err := uuid.Parse(chi.URLParam(r, "sessionId"))
+	if err != nil {
+		responder.BadRequest(w, r, "Invalid session ID")
+		return
+	}
+
+	session, err := h.service.RevokeImpersonation(r.Context(), sessionID)
+	if err != nil {
+		h.mapImpersonationError(w, r, "Failed to revoke impersonation session", err)
+		return
+	}
+
+	responder.OK(w, r, session)
+}
+
+// mapImpersonationError maps impersonation errors to HTTP responses.
+func (h *Handler) mapImpersonationError(w http.ResponseWriter, r *http.Request, msg string, err error) {
+	switch {
+	case errors.Is(err, shared.ErrImpersonationNotFound):
+		responder.NotFound(w, r, "Impersonation session not found")
+	default:
+		h.mapTenantError(w, r, msg, err)
+	}
+}
+
+// CreateServiceAccount handles POST /tenants/{id}/service-accounts
+//
+// @Summary Create service account
+// @Tags TenantPlugin-Tenants
+// @Accept json
+// @Produce json
+// @Param id path string true "Tenant ID"
+// @Param body body CreateServiceAccountRequest true "Service account payload"
+// @Success 200 {object} ServiceAccountDTO
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/v1/tenants/{id}/service-accounts [post]
+func (h *Handler) CreateServiceAccount(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		responder.BadRequest(w, r, "Invalid tenant ID")
+		return
+	}
+
+	var req CreateServiceAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		responder.BindError(w, r, nil)
+		return
+	}
+
+	account, err := h.service.CreateServiceAccount(r.Context(), tenantID, &req)
+	if err != nil {
+		h.mapServiceAccountError(w, r, "Failed to create service account", err)
+		return
+	}
+
+	responder.OK(w, r, account)
+}
+
+// ListServiceAccounts handles GET /tenants/{id}/service-accounts
+//
+// @Summary List service accounts
+// @Tags TenantPlugin-Tenants
+// @Produce json
+// @Param id path string true "Tenant ID"
+// @Success 200 {object} ServiceAccountListResult
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/tenants/{id}/service-accounts [get]
+func (h *Handler) ListServiceAccounts(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		responder.BadRequest(w, r, "Invalid tenant ID")
+		return
+	}
+
+	result, err := h.service.ListServiceAccounts(r.Context(), tenantID)
+	if err != nil {
+		h.mapServiceAccountError(w, r, "Failed to list service accounts", err)
+		return
+	}
+
+	responder.OK(w, r, result)
+}
+
+// GetServiceAccount handles GET /tenants/{id}/service-accounts/{accountId}
+//
+// @Summary Get service account
+// @Tags TenantPlugin-Tenants
+// @Produce json
+// @Param id path string true "Tenant ID"
+// @Param accountId path string true "Service account ID"
+// @Success 200 {object} ServiceAccountDTO
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/tenants/{id}/service-accounts/{accountId} [get]
+func (h *Handler) GetServiceAccount(w http.ResponseWriter, r *http.Request) {
+	tenantID, accountID, ok := serviceAccountParams(w, r)
+	if !ok {
+		return
+	}
+
+	account, err := h.service.GetServiceAccount(r.Context(), tenantID, accountID)
+	if err != nil {
+		h.mapServiceAccountError(w, r, "Failed to get service account", err)
+		return
+	}
+
+	responder.OK(w, r, account)
+}
+
+// UpdateServiceAccount handles PUT /tenants/{id}/service-accounts/{accountId}
+//
+// @Summary Update service account
+// @Tags TenantPlugin-Tenants
+// @Accept json
+// @Produce json
+// @Param id path string true "Tenant ID"
+// @Param accountId path string true "Service account ID"
+// @Param body body UpdateServiceAccountRequest true "Service account changes"
+// @Success 200 {object} ServiceAccountDTO
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/v1/tenants/{id}/service-accounts/{accountId} [put]
+func (h *Handler) UpdateServiceAccount(w http.ResponseWriter, r *http.Request) {
+	tenantID, accountID, ok := serviceAccountParams(w, r)
+	if !ok {
+		return
+	}
+
+	var req UpdateServiceAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		responder.BindError(w, r, nil)
+		return
+	}
+
+	account, err := h.service.UpdateServiceAccount(r.Context(), tenantID, accountID, &req)
+	if err != nil {
+		h.mapServiceAccountError(w, r, "Failed to update service account", err)
+		return
+	}
+
+	responder.OK(w, r, account)
+}
+
+// DeleteServiceAccount handles DELETE /tenants/{id}/service-accounts/{accountId}
+//
+// @Summary Delete service account
+// @Tags TenantPlugin-Tenants
+// @Param id path string true "Tenant ID"
+// @Param accountId path string true "Service account ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/tenants/{id}/service-accounts/{accountId} [delete]
+func (h *Handler) DeleteServiceAccount(w http.ResponseWriter, r *http.Request) {
+	tenantID, accountID, ok := serviceAccountParams(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteServiceAccount(r.Context(), tenantID, accountID); err != nil {
+		h.mapServiceAccountError(w, r, "Failed to delete service account", err)
+		return
+	}
+
+	responder.OK(w, r, map[string]string{"message": "Service account deleted successfully"})
+}
+
+// CreateAPIKey handles POST /tenants/{id}/service-accounts/{accountId}/keys
+//
+// @Summary Issue API key
+// @Tags TenantPlugin-Tenants
+// @Accept json
+// @Produce json
+// @Param id path string true "Tenant ID"
+// @Param accountId path string true "Service account ID"
+// @Param body body CreateAPIKeyRequest true "API key payload"
+// @Success 200 {object} APIKeyGrant
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/v1/tenants/{id}/service-accounts/{accountId}/keys [post]
+func (h *Handler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	tenantID, accountID, ok := serviceAccountParams(w, r)
+	if !ok {
+		return
+	}
+
+	var req CreateAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		responder.BindError(w, r, nil)
+		return
+	}
+
+	grant, err := h.service.CreateAPIKey(r.Context(), tenantID, accountID, &req)
+	if err != nil {
+		h.mapServiceAccountError(w, r, "Failed to issue API key", err)
+		return
+	}
+
+	responder.OK(w, r, grant)
+}
+
+// ListAPIKeys handles GET /tenants/{id}/service-accounts/{accountId}/keys
+//
+// @Summary List API keys
+// @Tags TenantPlugin-Tenants
+// @Produce json
+// @Param id path string true "Tenant ID"
+// @Param accountId path string true "Service account ID"
+// @Success 200 {object} APIKeyListResult
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/tenants/{id}/service-accounts/{accountId}/keys [get]
+func (h *Handler) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	tenantID, accountID, ok := serviceAccountParams(w, r)
+	if !ok {
+		return
+	}
+
+	result, err := h.service.ListAPIKeys(r.Context(), tenantID, accountID)
+	if err != nil {
+		h.mapServiceAccountError(w, r, "Failed to list API keys", err)
+		return
+	}
+
+	responder.OK(w, r, result)
+}
+
+// RevokeAPIKey handles DELETE /tenants/{id}/service-accounts/{accountId}/keys/{keyId}
+//
+// @Summary Revoke API key
+// @Tags TenantPlugin-Tenants
+// @Produce json
+// @Param id path string true "Tenant ID"
+// @Param accountId path string true "Service account ID"
+// @Param keyId path string true "API key ID"
+// @Success 200 {object} APIKeyDTO
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/v1/tenants/{id}/service-accounts/{accountId}/keys/{keyId} [delete]
+func (h *Handler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	tenantID, accountID, ok := serviceAccountParams(w, r)
+	if !ok {
+		return
+	}
+	keyID, err := uuid.Parse(chi.URLParam(r, "keyId"))
+	if err != nil {
+		responder.BadRequest(w, r, "Invalid API key ID")
+		return
+	}
+
+	key, err := h.service.RevokeAPIKey(r.Context(), tenantID, accountID, keyID)
+	if err != nil {
+		h.mapServiceAccountError(w, r, "Failed to revoke API key", err)
+		return
+	}
+
+	responder.OK(w, r, key)
+}
+
+// RotateAPIKey handles POST /tenants/{id}/service-accounts/{accountId}/keys/{keyId}/rotate
+//
+// @Summary Rotate API key
+// @Tags TenantPlugin-Tenants
+// @Accept json
+// @Produce json
+// @Param id path string true "Tenant ID"
+// @Param accountId path string true "Service account ID"
+// @Param keyId path string true "API key ID"
+// @Param body body RotateAPIKeyRequest false "Rotation options"
+// @Success 200 {object} APIKeyGrant
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/v1/tenants/{id}/service-accounts/{accountId}/keys/{keyId}/rotate [post]
+func (h *Handler) RotateAPIKey(w http.ResponseWriter, r *http.Request) {
+	tenantID, accountID, ok := serviceAccountParams(w, r)
+	if !ok {
+		return
+	}
+	keyID, err := uuid.Parse(chi.URLParam(r, "keyId"))
+	if err != nil {
+		responder.BadRequest(w, r, "Invalid API key ID")
+		return
+	}
+
+	var req RotateAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		responder.BindError(w, r, nil)
+		return
+	}
+
+	grant, err := h.service.RotateAPIKey(r.Context(), tenantID, accountID, keyID, &req)
+	if err != nil {
+		h.mapServiceAccountError(w, r, "Failed to rotate API key", err)
+		return
+	}
+
+	responder.OK(w, r, grant)
+}
+
+// serviceAccountParams parses the tenant and service account path
+// parameters, answering 400 when either is malformed.
+func serviceAccountParams(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	tenantID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		responder.BadRequest(w, r, "Invalid tenant ID")
+		return uuid.Nil, uuid.Nil, false
+	}
+	accountID, err := uuid.Parse(chi.URLParam(r, "accountId"))
+	if err != nil {
+		responder.BadRequest(w, r, "Invalid service account ID")
+		return uuid.Nil, uuid.Nil, false
+	}
+	return tenantID, accountID, true
+}
+
+// mapServiceAccountError maps service account and API key errors to HTTP
+// responses.
+func (h *Handler) mapServiceAccountError(w http.ResponseWriter, r *http.Request, msg string, err error) {
+	switch {
+	case errors.Is(err, shared.ErrServiceAccountNotFound):
+		responder.NotFound(w, r, "Service account not found")
+	case errors.Is(err, shared.ErrAPIKeyNotFound):
+		responder.NotFound(w, r, "API key not found")
+	default:
+		h.mapTenantError(w, r, msg, err)
+	}
+}
+
+// CreateWebhook handles POST /tenants/webhooks and POST /tenants/{id}/webhooks
+//
+// @Summary Register webhook endpoint
+// @Description The signing secret is only returned by this call.
+// @Tags TenantPlugin-Tenants
+// @Accept json
+// @Produce json
+// @Param id path string false "Tenant ID; omitted for platform endpoints"
+// @Param body body CreateWebhookRequest true "Webhook payload"
+// @Success 200 {object} WebhookGrant
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/tenants/webhooks [post]
+// @Router /api/v1/tenants/{id}/webhooks [post]
+func (h *Handler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := webhookScope(w, r)
+	if !ok {
+		return
+	}
+
+	var req CreateWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		responder.BindError(w, r, nil)
+		return
+	}
+
+	grant, err := h.service.CreateWebhook(r.Context(), tenantID, &req)
+	if err != nil {
+		h.mapWebhookError(w, r, "Failed to create webhook", err)
+		return
+	}
+
+	responder.OK(w, r, grant)
+}
+
+// ListWebhooks handles GET /tenants/webhooks and GET /tenants/{id}/webhooks
+//
+// @Summary List webhook endpoints
+// @Tags TenantPlugin-Tenants
+// @Produce json
+// @Param id path string false "Tenant ID; omitted for platform endpoints"
+// @Success 200 {object} WebhookListResult
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/tenants/webhooks [get]
+// @Router /api/v1/tenants/{id}/webhooks [get]
+func (h *Handler) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := webhookScope(w, r)
+	if !ok {
+		return
+	}
+
+	result, err := h.service.ListWebhooks(r.Context(), tenantID)
+	if err != nil {
+		h.mapWebhookError(w, r, "Failed to list webhooks", err)
+		return
+	}
+
+	responder.OK(w, r, result)
+}
+
+// GetWebhook handles GET /tenants/webhooks/{webhookId} and
+// GET /tenants/{id}/webhooks/{webhookId}
+//
+// @Summary Get webhook endpoint
+// @Tags TenantPlugin-Tenants
+// @Produce json
+// @Param id path string false "Tenant ID; omitted for platform endpoints"
+// @Param webhookId path string true "Webhook ID"
+// @Success 200 {object} WebhookDTO
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/tenants/webhooks/{webhookId} [get]
+// @Router /api/v1/tenants/{id}/webhooks/{webhookId} [get]
+func (h *Handler) GetWebhook(w http.ResponseWriter, r *http.Request) {
+	tenantID, webhookID, ok := webhookParams(w, r)
+	if !ok {
+		return
+	}
+
+	webhook, err := h.service.GetWebhook(r.Context(), tenantID, webhookID)
+	if err != nil {
+		h.mapWebhookError(w, r, "Failed to get webhook", err)
+		return
+	}
+
+	responder.OK(w, r, webhook)
+}
+
+// UpdateWebhook handles PUT /tenants/webhooks/{webhookId} and
+// PUT /tenants/{id}/webhooks/{webhookId}
+//
+// @Summary Update webhook endpoint
+// @Tags TenantPlugin-Tenants
+// @Accept json
+// @Produce json
+// @Param id path string false "Tenant ID; omitted for platform endpoints"
+// @Param webhookId path string true "Webhook ID"
+// @Param body body UpdateWebhookRequest true "Webhook changes"
+// @Success 200 {object} WebhookDTO
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/tenants/webhooks/{webhookId} [put]
+// @Router /api/v1/tenants/{id}/webhooks/{webhookId} [put]
+func (h *Handler) UpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	tenantID, webhookID, ok := webhookParams(w, r)
+	if !ok {
+		return
+	}
+
+	var req UpdateWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		responder.BindError(w, r, nil)
+		return
+	}
+
+	webhook, err := h.service.UpdateWebhook(r.Context(), tenantID, webhookID, &req)
+	if err != nil {
+		h.mapWebhookError(w, r, "Failed to update webhook", err)
+		return
+	}
+
+	responder.OK(w, r, webhook)
+}
+
+// DeleteWebhook handles DELETE /tenants/webhooks/{webhookId} and
+// DELETE /tenants/{id}/webhooks/{webhookId}
+//
+// @Summary Delete webhook endpoint
+// @Tags TenantPlugin-Tenants
+// @Param id path string false "Tenant ID; omitted for platform endpoints"
+// @Param webhookId path string true "Webhook ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/tenants/webhooks/{webhookId} [delete]
+// @Router /api/v1/tenants/{id}/webhooks/{webhookId} [delete]
+func (h *Handler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	tenantID, webhookID, ok := webhookParams(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteWebhook(r.Context(), tenantID, webhookID); err != nil {
+		h.mapWebhookError(w, r, "Failed to delete webhook", err)
+		return
+	}
+
+	responder.OK(w, r, map[string]string{"message": "Webhook deleted successfully"})
+}
+
+// ListWebhookDeliveries handles GET /tenants/webhooks/{webhookId}/deliveries
+// and GET /tenants/{id}/webhooks/{webhookId}/deliveries
+//
+// @Summary List webhook deliveries
+// @Tags TenantPlugin-Tenants
+// @Produce json
+// @Param id path string false "Tenant ID; omitted for platform endpoints"
+// @Param webhookId path string true "Webhook ID"
+// @Param page query int false "Page number"
+// @Param pageSize query int false "Page size"
+// @Param status query string false "pending, delivered or dead"
+// @Param event query string false "Event type"
+// @Success 200 {object} WebhookDeliveryListResult
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/tenants/webhooks/{webhookId}/deliveries [get]
+// @Router /api/v1/tenants/{id}/webhooks/{webhookId}/deliveries [get]
+func (h *Handler) ListWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	tenantID, webhookID, ok := webhookParams(w, r)
+	if !ok {
+		return
+	}
+
+	q := r.URL.Query()
+	page, _ := strconv.Atoi(q.Get("page"))
+	pageSize, _ := strconv.Atoi(q.Get("pageSize"))
+	filters := WebhookDeliveryFilters{
+		Page:     page,
+		PageSize: pageSize,
+		Status:   q.Get("status"),
+		Event:    q.Get("event"),
+	}
+
+	result, err := h.service.ListWebhookDeliveries(r.Context(), tenantID, webhookID, filters)
+	if err != nil {
+		h.mapWebhookError(w, r, "Failed to list webhook deliveries", err)
+		return
+	}
+
+	responder.OK(w, r, result)
+}
+
+// RedeliverWebhook handles POST /tenants/webhooks/{webhookId}/deliveries/{deliveryId}/redeliver
+// and POST /tenants/{id}/webhooks/{webhookId}/deliveries/{deliveryId}/redeliver
+//
+// @Summary Redeliver webhook delivery
+// @Tags TenantPlugin-Tenants
+// @Produce json
+// @Param id path string false "Tenant ID; omitted for platform endpoints"
+// @Param webhookId path string true "Webhook ID"
+// @Param deliveryId path string true "Delivery ID"
+// @Success 200 {object} WebhookDeliveryDTO
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/tenants/webhooks/{webhookId}/deliveries/{deliveryId}/redeliver [post]
+// @Router /api/v1/tenants/{id}/webhooks/{webhookId}/deliveries/{deliveryId}/redeliver [post]
+func (h *Handler) RedeliverWebhook(w http.ResponseWriter, r *http.Request) {
+	tenantID, webhookID, ok := webhookParams(w, r)
+	if !ok {
+		return
+	}
+	deliveryID, err := uuid.Parse(chi.URLParam(r, "deliveryId"))
+	if err != nil {
+		responder.BadRequest(w, r, "Invalid delivery ID")
+		return
+	}
+
+	delivery, err := h.service.RedeliverWebhook(r.Context(), tenantID, webhookID, deliveryID)
+	if err != nil {
+		h.mapWebhookError(w, r, "Failed to redeliver webhook", err)
+		return
+	}
+
+	responder.OK(w, r, delivery)
+}
+
+// webhookScope parses the optional tenant path parameter of the webhook
+// routes. Platform routes have none and yield a nil tenant ID.
+func webhookScope(w http.ResponseWriter, r *http.Request) (*uuid.UUID, bool) {
+	v := chi.URLParam(r, "id")
+	if v == "" {
+		return nil, true
+	}
+	tenantID, err := uuid.Parse(v)
+	if err != nil {
+		responder.BadRequest(w, r, "Invalid tenant ID")
+		return nil, false
+	}
+	return &tenantID, true
+}
+
+// webhookParams parses the scope and webhook path parameters, answering 400
+// when either is malformed.
+func webhookParams(w http.ResponseWriter, r *http.Request) (*uuid.UUID, uuid.UUID, bool) {
+	tenantID, ok := webhookScope(w, r)
+	if !ok {
+		return nil, uuid.Nil, false
+	}
+	webhookID, err := uuid.Parse(chi.URLParam(r, "webhookId"))
+	if err != nil {
+		responder.BadRequest(w, r, "Invalid webhook ID")
+		return nil, uuid.Nil, false
+	}
+	return tenantID, webhookID, true
+}
+
+// mapWebhookError maps webhook errors to HTTP responses.
+func (h *Handler) mapWebhookError(w http.ResponseWriter, r *http.Request, msg string, err error) {
+	switch {
+	case errors.Is(err, shared.ErrWebhookNotFound):
+		responder.NotFound(w, r, "Webhook not found")
+	case errors.Is(err, shared.ErrWebhookDeliveryNotFound):
+		responder.NotFound(w, r, "Webhook delivery not found")
+	case errors.Is(err, shared.ErrTenantAdminRequired):
+		responder.Forbidden(w, r, "Tenant admin required")
+	default:
+		h.mapTenantError(w, r, msg, err)
+	}
+}
+
+// parseTimeParam parses an optional RFC 3339 query parameter.
+func parseTimeParam(v string) (*time.Time, error) {
+	if v == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// parseUUIDParam parses an optional UUID query parameter.
+func parseUUIDParam(v string) (*uuid.UUID, error) {
+	if v == "" {
+		return nil, nil
+	}
+	id, err := uuid.Parse(v)
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+// mapTenantError maps common tenant service errors to HTTP responses. Typed
+// errors, such as validation failures, are answered by apierror.Write with
+// their own status and a localized message.
+func (h *Handler) mapTenantError(w http.ResponseWriter, r *http.Request, msg string, err error) {
+	switch {
+	case apierror.Write(w, r, err):
+	case errors.Is(err, shared.ErrTenantNotFound):
+		responder.NotFound(w, r, "Tenant not found")
+	case errors.Is(err, shared.ErrPlatformDomainOnly):
+		responder.Forbidden(w, r, "Platform domain required")
+	case errors.Is(err, shared.ErrVersionMismatch):
+		h.writeCurrentTenant(w, r)
+	default:
+		httplog.Error(h.logger, r, msg, err)
+		responder.DatabaseError(w, r, msg)
+	}
+}
+
+// writeCurrentTenant answers a failed If-Match precondition with 412 and the
+// current tenant, or with 404 when the tenant was deleted meanwhile.
+func (h *Handler) writeCurrentTenant(w http.ResponseWriter, r *http.Request) {
+	id, _ := uuid.Parse(chi.URLParam(r, "id"))
+	current, err := h.service.GetTenant(r.Context(), id)
+	if err != nil {
+		h.mapTenantError(w, r, "Failed to get tenant", err)
+		return
+	}
+	etag.PreconditionFailed(w, r, current.Version, current)
+}
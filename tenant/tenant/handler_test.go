@@ -0,0 +1,52 @@
+package tenant
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	coremod "github.com/leeforge/core/core"
+	"github.com/leeforge/framework/logging"
+)
+
+func TestHandler_CreateTenant_ValidationError(t *testing.T) {
+	svc := NewService(nil, nil, nil, nil, mockRoleSeeder{}, mockUserLookup{})
+	h := NewHandler(svc, logging.FromZap(zap.NewNop()))
+	ctx := coremod.WithActingContext(context.Background(), &coremod.ActingContext{
+		Domain: &coremod.ResolvedDomain{TypeCode: string(coremod.DomainPlatform), Key: "root"},
+	})
+
+	create := func(acceptLanguage string) (*httptest.ResponseRecorder, map[string]any) {
+		req := httptest.NewRequest(http.MethodPost, "/tenants", strings.NewReader(`{"code":" "}`)).WithContext(ctx)
+		req.Header.Set("Accept-Language", acceptLanguage)
+		rec := httptest.NewRecorder()
+		h.CreateTenant(rec, req)
+		var body struct {
+			Error map[string]any `json:"error"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		return rec, body.Error
+	}
+
+	rec, body := create("")
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	require.Equal(t, "Invalid tenant data: code is required; name is required", body["message"])
+	require.Equal(t, map[string]any{
+		"code": "tenant.invalid",
+		"fields": []any{
+			map[string]any{"field": "code", "code": "required", "message": "code is required"},
+			map[string]any{"field": "name", "code": "required", "message": "name is required"},
+		},
+	}, body["details"])
+
+	rec, body = create("zh-CN,zh;q=0.9")
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	require.Equal(t, "zh", rec.Header().Get("Content-Language"))
+	require.Equal(t, "租户数据无效: code 为必填项; name 为必填项", body["message"])
+}
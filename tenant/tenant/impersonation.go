@@ -0,0 +1,496 @@
+package tenant
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/leeforge/framework/logging"
+	"github.com/leeforge/framework/plugin"
+	"go.uber.org/zap"
+
+	"github.com/leeforge/core"
+	coremod "github.com/leeforge/core/core"
+	coreent "github.com/leeforge/core/server/ent"
+	entTenant "github.com/leeforge/core/server/ent/tenant"
+
+	"github.com/leeforge/plugins/apierror"
+	"github.com/leeforge/plugins/tenant/shared"
+)
+
+// maxImpersonationReason bounds the reason recorded with a session.
+const maxImpersonationReason = 500
+
+// ImpersonationStatusAll lists sessions of every status.
+const ImpersonationStatusAll = "all"
+
+// SetImpersonationStore replaces the store holding impersonation sessions.
+// The factory calls it with a persistent store; without one, sessions are
+// kept in memory and lost on restart.
+func (s *Service) SetImpersonationStore(store shared.ImpersonationStore) {
+	if store != nil {
+		s.impersonations = store
+	}
+}
+
+// Impersonate starts an impersonation session that lets the caller act in the
+// domain of tenantID until it expires or is revoked. The returned token is
+// sent in the impersonationHeader of later requests; only its hash is kept.
+func (s *Service) Impersonate(ctx context.Context, tenantID uuid.UUID, req *ImpersonateRequest) (_ *ImpersonationGrant, err error) {
+	ctx, end := s.instrument(ctx, "impersonate")
+	defer end(&err)
+
+	if err := requirePlatformDomain(ctx); err != nil {
+		return nil, err
+	}
+	actorID, ok := core.GetUserID(ctx)
+	if !ok || actorID == uuid.Nil {
+		return nil, fmt.Errorf("%w: caller has no identity", shared.ErrInvalidImpersonation)
+	}
+	if req == nil {
+		return nil, shared.ErrInvalidImpersonation.With(apierror.Field("reason", apierror.Required))
+	}
+	reason := strings.TrimSpace(req.Reason)
+	switch {
+	case reason == "":
+		return nil, shared.ErrInvalidImpersonation.With(apierror.Field("reason", apierror.Required))
+	case len(reason) > maxImpersonationReason:
+		return nil, shared.ErrInvalidImpersonation.With(apierror.Field("reason", apierror.TooLong, "max", maxImpersonationReason))
+	}
+	ttl := req.TTLSeconds
+	if ttl == 0 {
+		ttl = s.cfg.ImpersonationTTLSeconds
+	}
+	if ttl < 1 || ttl > s.cfg.MaxImpersonationTTLSeconds {
+		return nil, shared.ErrInvalidImpersonation.With(
+			apierror.Field("ttlSeconds", apierror.OutOfRange, "min", 1, "max", s.cfg.MaxImpersonationTTLSeconds))
+	}
+
+	t, err := s.client.Tenant.Query().
+		Where(entTenant.ID(tenantID), entTenant.DeletedAtIsNil()).
+		Only(ctx)
+	if err != nil {
+		if coreent.IsNotFound(err) {
+			return nil, shared.ErrTenantNotFound
+		}
+		return nil, fmt.Errorf("get tenant: %w", err)
+	}
+	domainID := s.resolveDomainIDSafe(ctx, t.Code)
+	if domainID == uuid.Nil {
+		return nil, fmt.Errorf("%w: tenant %s has no domain", shared.ErrInvalidImpersonation, t.Code)
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("generate token: %w", err)
+	}
+	now := s.now().UTC()
+	session := shared.ImpersonationSession{
+		ID:         uuid.New(),
+		TenantID:   t.ID,
+		TenantCode: t.Code,
+		DomainID:   domainID,
+		ActorID:    actorID,
+		Reason:     reason,
+		TokenHash:  hashToken(secret),
+		CreatedAt:  now,
+		ExpiresAt:  now.Add(time.Duration(ttl) * time.Second),
+	}
+	if err := s.impersonations.PutSession(ctx, session); err != nil {
+		return nil, fmt.Errorf("store impersonation session: %w", err)
+	}
+
+	s.publishImpersonation(ctx, shared.EventTenantImpersonationStarted, &session)
+	return &ImpersonationGrant{
+		Session: s.toImpersonationDTO(&session, now),
+		Token:   session.ID.String() + "." + base64.RawURLEncoding.EncodeToString(secret),
+		Header:  s.cfg.ImpersonationHeader,
+	}, nil
+}
+
+// ListImpersonations returns impersonation sessions, newest first.
+func (s *Service) ListImpersonations(ctx context.Context, filters ImpersonationFilters) (_ *ImpersonationListResult, err error) {
+	ctx, end := s.instrument(ctx, "list_impersonations")
+	defer end(&err)
+
+	if err := requirePlatformDomain(ctx); err != nil {
+		return nil, err
+	}
+	status := filters.Status
+	switch status {
+	case "":
+		status = shared.ImpersonationActive
+	case shared.ImpersonationActive, shared.ImpersonationExpired, shared.ImpersonationRevoked, ImpersonationStatusAll:
+	default:
+		return nil, shared.ErrInvalidImpersonation.With(apierror.Field("status", apierror.Unknown, "value", filters.Status))
+	}
+
+	sessions, err := s.impersonations.ListSessions(ctx, filters.TenantID)
+	if err != nil {
+		return nil, fmt.Errorf("list impersonation sessions: %w", err)
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].CreatedAt.After(sessions[j].CreatedAt) })
+
+	now := s.now()
+	out := make([]*ImpersonationDTO, 0, len(sessions))
+	for i := range sessions {
+		if filters.ActorID != uuid.Nil && sessions[i].ActorID != filters.ActorID {
+			continue
+		}
+		dto := s.toImpersonationDTO(&sessions[i], now)
+		if status != ImpersonationStatusAll && dto.Status != status {
+			continue
+		}
+		out = append(out, dto)
+	}
+	return &ImpersonationListResult{Sessions: out}, nil
+}
+
+// RevokeImpersonation ends an active impersonation session.
+func (s *Service) RevokeImpersonation(ctx context.Context, sessionID uuid.UUID) (_ *ImpersonationDTO, err error) {
+	ctx, end := s.instrument(ctx, "revoke_impersonation")
+	defer end(&err)
+
+	if err := requirePlatformDomain(ctx); err != nil {
+		return nil, err
+	}
+	session, err := s.impersonations.GetSession(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("get impersonation session: %w", err)
+	}
+	if session == nil {
+		return nil, shared.ErrImpersonationNotFound
+	}
+	now := s.now().UTC()
+	if session.StatusAt(now) != shared.ImpersonationActive {
+		return nil, shared.ErrImpersonationInactive
+	}
+
+	actorID, _ := core.GetUserID(ctx)
+	session.RevokedAt = &now
+	session.RevokedBy = &actorID
+	if err := s.impersonations.PutSession(ctx, *session); err != nil {
+		return nil, fmt.Errorf("store impersonation session: %w", err)
+	}
+	s.publishImpersonation(ctx, shared.EventTenantImpersonationRevoked, session)
+	return s.toImpersonationDTO(session, now), nil
+}
+
+// SetImpersonationAuditLog replaces the log receiving the audit records of
+// impersonated requests. The factory calls it with a persistent log;
+// without one, the records are only written to the service logger.
+func (s *Service) SetImpersonationAuditLog(log shared.ImpersonationAuditLog) {
+	if log != nil {
+		s.impersonationAudits = log
+	}
+}
+
+// AuditImpersonation records a request made under session. The caller
+// fills in the request fields of audit; the session, impersonator and time
+// are set here. Requests whose record cannot be written must not be served.
+func (s *Service) AuditImpersonation(ctx context.Context, session *shared.ImpersonationSession, audit shared.ImpersonationAudit) (err error) {
+	ctx, end := s.instrument(ctx, "audit_impersonation")
+	defer end(&err)
+
+	audit.ID = uuid.New()
+	audit.SessionID = session.ID
+	audit.TenantID = session.TenantID
+	audit.TenantCode = session.TenantCode
+	audit.DomainID = session.DomainID
+	audit.ImpersonatorID = session.ActorID
+	audit.Impersonated = true
+	audit.At = s.now().UTC()
+	if err := s.impersonationAudits.RecordImpersonation(ctx, audit); err != nil {
+		return fmt.Errorf("record impersonated request: %w", err)
+	}
+	return nil
+}
+
+// ResolveImpersonation returns the active session of token for actorID, the
+// user presenting it. Tokens are bound to the user they were issued to.
+func (s *Service) ResolveImpersonation(ctx context.Context, token string, actorID uuid.UUID) (_ *shared.ImpersonationSession, err error) {
+	ctx, end := s.instrument(ctx, "resolve_impersonation")
+	defer end(&err)
+
+	sessionID, secret, ok := parseToken(token)
+	if !ok {
+		return nil, shared.ErrImpersonationNotFound
+	}
+
+	session, err := s.impersonations.GetSession(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("get impersonation session: %w", err)
+	}
+	if session == nil ||
+		subtle.ConstantTimeCompare([]byte(session.TokenHash), []byte(hashToken(secret))) != 1 ||
+		session.ActorID != actorID {
+		return nil, shared.ErrImpersonationNotFound
+	}
+	if session.StatusAt(s.now()) != shared.ImpersonationActive {
+		return nil, shared.ErrImpersonationInactive
+	}
+	return session, nil
+}
+
+// impersonating reports whether userID has an active impersonation session
+// for domainID.
+func (s *Service) impersonating(ctx context.Context, domainID, userID uuid.UUID) (bool, error) {
+	sessions, err := s.impersonations.OpenSessions(ctx, domainID, userID)
+	if err != nil {
+		return false, fmt.Errorf("list impersonation sessions: %w", err)
+	}
+	now := s.now()
+	for i := range sessions {
+		if sessions[i].StatusAt(now) == shared.ImpersonationActive {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// PruneImpersonations removes the impersonation sessions that ended more
+// than ImpersonationRetentionSeconds ago and returns how many it removed.
+func (s *Service) PruneImpersonations(ctx context.Context) (removed int, err error) {
+	ctx, end := s.instrument(ctx, "prune_impersonations")
+	defer end(&err)
+
+	retention := time.Duration(s.cfg.ImpersonationRetentionSeconds) * time.Second
+	removed, err = s.impersonations.DeleteEndedSessions(ctx, s.now().Add(-retention))
+	if err != nil {
+		return removed, fmt.Errorf("prune impersonation sessions: %w", err)
+	}
+	return removed, nil
+}
+
+func (s *Service) publishImpersonation(ctx context.Context, name string, session *shared.ImpersonationSession) {
+	_ = s.events.Publish(ctx, plugin.Event{
+		Name:   name,
+		Source: "tenant",
+		Data: shared.ImpersonationEventData{
+			SessionID:  session.ID,
+			TenantID:   session.TenantID,
+			TenantCode: session.TenantCode,
+			DomainID:   session.DomainID,
+			ActorID:    session.ActorID,
+			Reason:     session.Reason,
+			ExpiresAt:  session.ExpiresAt,
+			RevokedBy:  session.RevokedBy,
+		},
+	})
+}
+
+func (s *Service) toImpersonationDTO(session *shared.ImpersonationSession, now time.Time) *ImpersonationDTO {
+	return &ImpersonationDTO{
+		ID:         session.ID,
+		TenantID:   session.TenantID,
+		TenantCode: session.TenantCode,
+		DomainID:   session.DomainID,
+		ActorID:    session.ActorID,
+		Reason:     session.Reason,
+		Status:     session.StatusAt(now),
+		CreatedAt:  session.CreatedAt,
+		ExpiresAt:  session.ExpiresAt,
+		RevokedAt:  session.RevokedAt,
+		RevokedBy:  session.RevokedBy,
+	}
+}
+
+// parseToken splits a "<id>.<secret>" token issued for an impersonation
+// session or an API key.
+func parseToken(token string) (uuid.UUID, []byte, bool) {
+	rawID, rawSecret, ok := strings.Cut(token, ".")
+	if !ok {
+		return uuid.Nil, nil, false
+	}
+	id, err := uuid.Parse(rawID)
+	if err != nil {
+		return uuid.Nil, nil, false
+	}
+	secret, err := base64.RawURLEncoding.DecodeString(rawSecret)
+	if err != nil || len(secret) == 0 {
+		return uuid.Nil, nil, false
+	}
+	return id, secret, true
+}
+
+func hashToken(secret []byte) string {
+	sum := sha256.Sum256(secret)
+	return hex.EncodeToString(sum[:])
+}
+
+type impersonationKey struct{}
+
+// WithImpersonation returns ctx carrying the impersonation session of the
+// request. The plugin's impersonation middleware sets it.
+func WithImpersonation(ctx context.Context, session *shared.ImpersonationSession) context.Context {
+	return context.WithValue(ctx, impersonationKey{}, session)
+}
+
+// ImpersonationFromContext returns the impersonation session of ctx, if any.
+func ImpersonationFromContext(ctx context.Context) (*shared.ImpersonationSession, bool) {
+	session, ok := ctx.Value(impersonationKey{}).(*shared.ImpersonationSession)
+	return session, ok && session != nil
+}
+
+// ImpersonationMarkFromContext returns the mark of an impersonated request:
+// the session set by WithImpersonation or, failing that, an acting context
+// flagged as impersonating.
+func ImpersonationMarkFromContext(ctx context.Context) (shared.ImpersonationMark, bool) {
+	if session, ok := ImpersonationFromContext(ctx); ok {
+		return shared.ImpersonationMark{SessionID: session.ID, ActorID: session.ActorID, Reason: session.Reason}, true
+	}
+	if ac := coremod.GetActingContext(ctx); ac != nil && ac.IsImpersonating {
+		return shared.ImpersonationMark{ActorID: ac.ActorID, Reason: ac.ImpersonateReason}, true
+	}
+	return shared.ImpersonationMark{}, false
+}
+
+// WrapImpersonationEventBus marks the payload of every event published while
+// impersonating. Payloads implementing shared.ImpersonationCarrier and
+// map[string]any payloads are marked; others are published unchanged. Hosts
+// may wrap their shared event bus so that events of every plugin are marked.
+func WrapImpersonationEventBus(bus plugin.EventBus) plugin.EventBus {
+	if bus == nil {
+		return nil
+	}
+	if ib, ok := bus.(*impersonationBus); ok {
+		return ib
+	}
+	return &impersonationBus{EventBus: bus}
+}
+
+type impersonationBus struct {
+	plugin.EventBus
+}
+
+func (b *impersonationBus) Publish(ctx context.Context, e plugin.Event) error {
+	mark, ok := ImpersonationMarkFromContext(ctx)
+	if !ok {
+		return b.EventBus.Publish(ctx, e)
+	}
+	switch data := e.Data.(type) {
+	case shared.ImpersonationCarrier:
+		e.Data = data.WithImpersonation(mark)
+	case map[string]any:
+		copied := make(map[string]any, len(data)+1)
+		for k, v := range data {
+			copied[k] = v
+		}
+		copied["impersonation"] = mark
+		e.Data = copied
+	}
+	return b.EventBus.Publish(ctx, e)
+}
+
+// logImpersonationAuditLog is the default ImpersonationAuditLog: it writes
+// each record to the service logger only.
+type logImpersonationAuditLog struct {
+	logger logging.Logger
+}
+
+func (l logImpersonationAuditLog) RecordImpersonation(_ context.Context, audit shared.ImpersonationAudit) error {
+	l.logger.Info("tenant: impersonated request",
+		zap.String("session", audit.SessionID.String()),
+		zap.String("impersonator", audit.ImpersonatorID.String()),
+		zap.String("tenant", audit.TenantCode),
+		zap.String("method", audit.Method),
+		zap.String("path", audit.Path),
+	)
+	return nil
+}
+
+// memoryImpersonationStore is the default, non-persistent ImpersonationStore.
+type memoryImpersonationStore struct {
+	mu       sync.RWMutex
+	sessions map[uuid.UUID]shared.ImpersonationSession
+	// open indexes the unrevoked sessions by domain and actor.
+	open map[[2]uuid.UUID]map[uuid.UUID]struct{}
+}
+
+func newMemoryImpersonationStore() *memoryImpersonationStore {
+	return &memoryImpersonationStore{
+		sessions: make(map[uuid.UUID]shared.ImpersonationSession),
+		open:     make(map[[2]uuid.UUID]map[uuid.UUID]struct{}),
+	}
+}
+
+func (m *memoryImpersonationStore) PutSession(_ context.Context, session shared.ImpersonationSession) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sessions[session.ID] = session
+	if session.RevokedAt != nil {
+		m.unindex(session)
+		return nil
+	}
+	key := [2]uuid.UUID{session.DomainID, session.ActorID}
+	if m.open[key] == nil {
+		m.open[key] = make(map[uuid.UUID]struct{})
+	}
+	m.open[key][session.ID] = struct{}{}
+	return nil
+}
+
+func (m *memoryImpersonationStore) unindex(session shared.ImpersonationSession) {
+	key := [2]uuid.UUID{session.DomainID, session.ActorID}
+	delete(m.open[key], session.ID)
+	if len(m.open[key]) == 0 {
+		delete(m.open, key)
+	}
+}
+
+func (m *memoryImpersonationStore) GetSession(_ context.Context, id uuid.UUID) (*shared.ImpersonationSession, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	session, ok := m.sessions[id]
+	if !ok {
+		return nil, nil
+	}
+	return &session, nil
+}
+
+func (m *memoryImpersonationStore) ListSessions(_ context.Context, tenantID uuid.UUID) ([]shared.ImpersonationSession, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var out []shared.ImpersonationSession
+	for _, session := range m.sessions {
+		if tenantID == uuid.Nil || session.TenantID == tenantID {
+			out = append(out, session)
+		}
+	}
+	return out, nil
+}
+
+func (m *memoryImpersonationStore) OpenSessions(_ context.Context, domainID, actorID uuid.UUID) ([]shared.ImpersonationSession, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var out []shared.ImpersonationSession
+	for id := range m.open[[2]uuid.UUID{domainID, actorID}] {
+		out = append(out, m.sessions[id])
+	}
+	return out, nil
+}
+
+func (m *memoryImpersonationStore) DeleteEndedSessions(_ context.Context, endedBefore time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	removed := 0
+	for id, session := range m.sessions {
+		if session.EndedAt().Before(endedBefore) {
+			delete(m.sessions, id)
+			m.unindex(session)
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+var _ shared.ImpersonationStore = (*memoryImpersonationStore)(nil)
@@ -0,0 +1,210 @@
+package tenant
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"sync"
+
+	"github.com/google/uuid"
+
+	"github.com/leeforge/core/server/ent/predicate"
+	entTenant "github.com/leeforge/core/server/ent/tenant"
+
+	"github.com/leeforge/plugins/tenant/shared"
+)
+
+// SetAttributeStore replaces the store holding tenant labels and metadata.
+// The factory calls it with a persistent store; without one, attributes are
+// kept in memory and lost on restart.
+func (s *Service) SetAttributeStore(store shared.TenantAttributeStore) {
+	if store != nil {
+		s.attrs = store
+	}
+}
+
+// validateAttributes checks labels and metadata before they are stored.
+func validateAttributes(labels map[string]string, metadata json.RawMessage) error {
+	if err := shared.ValidateLabels(labels); err != nil {
+		return err
+	}
+	return shared.ValidateMetadata(metadata)
+}
+
+// normalizeMetadata returns nil for empty or null metadata.
+func normalizeMetadata(metadata json.RawMessage) json.RawMessage {
+	trimmed := bytes.TrimSpace(metadata)
+	if len(trimmed) == 0 || bytes.Equal(trimmed, []byte("null")) {
+		return nil
+	}
+	return trimmed
+}
+
+// jsonEqual reports whether a and b encode the same JSON value.
+func jsonEqual(a, b json.RawMessage) bool {
+	var va, vb any
+	if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+		return bytes.Equal(a, b)
+	}
+	return reflect.DeepEqual(va, vb)
+}
+
+// attributeUpdate returns the labels and metadata to store for a tenant,
+// given the stored attributes. Nil labels or metadata leave the stored value
+// unchanged.
+type attributeUpdate func(current shared.TenantAttributes) (map[string]string, json.RawMessage, error)
+
+// replaceAttributes returns the update storing labels and metadata as they
+// are, or nil when both are nil.
+func replaceAttributes(labels map[string]string, metadata json.RawMessage) attributeUpdate {
+	if labels == nil && metadata == nil {
+		return nil
+	}
+	return func(shared.TenantAttributes) (map[string]string, json.RawMessage, error) {
+		return labels, metadata, nil
+	}
+}
+
+// saveAttributes applies update to the stored labels and metadata of a
+// tenant. A nil update leaves them unchanged.
+func (s *Service) saveAttributes(ctx context.Context, tenantID uuid.UUID, update attributeUpdate) error {
+	if update == nil {
+		return nil
+	}
+	current, err := s.attrs.GetAttributes(ctx, tenantID)
+	if err != nil {
+		return fmt.Errorf("get tenant attributes: %w", err)
+	}
+	attrs := shared.TenantAttributes{TenantID: tenantID}
+	if current != nil {
+		attrs = *current
+	}
+	labels, metadata, err := update(attrs)
+	if err != nil {
+		return err
+	}
+	if labels != nil {
+		attrs.Labels = maps.Clone(labels)
+		if len(attrs.Labels) == 0 {
+			attrs.Labels = nil
+		}
+	}
+	if metadata != nil {
+		attrs.Metadata = normalizeMetadata(metadata)
+	}
+	attrs.UpdatedAt = s.now().UTC()
+	if err := s.attrs.PutAttributes(ctx, attrs); err != nil {
+		return fmt.Errorf("store tenant attributes: %w", err)
+	}
+	return nil
+}
+
+// attachAttributes fills in the labels and metadata of dtos.
+func (s *Service) attachAttributes(ctx context.Context, dtos ...*TenantDTO) error {
+	switch len(dtos) {
+	case 0:
+		return nil
+	case 1:
+		attrs, err := s.attrs.GetAttributes(ctx, dtos[0].ID)
+		if err != nil {
+			return fmt.Errorf("get tenant attributes: %w", err)
+		}
+		if attrs != nil {
+			dtos[0].Labels, dtos[0].Metadata = attrs.Labels, attrs.Metadata
+		}
+		return nil
+	}
+	ids := make([]uuid.UUID, len(dtos))
+	for i, dto := range dtos {
+		ids[i] = dto.ID
+	}
+	found, err := s.attrs.ListAttributesOf(ctx, ids)
+	if err != nil {
+		return fmt.Errorf("list tenant attributes: %w", err)
+	}
+	byID := make(map[uuid.UUID]shared.TenantAttributes, len(found))
+	for _, attrs := range found {
+		byID[attrs.TenantID] = attrs
+	}
+	for _, dto := range dtos {
+		if attrs, ok := byID[dto.ID]; ok {
+			dto.Labels, dto.Metadata = attrs.Labels, attrs.Metadata
+		}
+	}
+	return nil
+}
+
+// requirementPredicate returns a predicate matching the tenants whose labels
+// satisfy r, given the values of label r.Key from the label index. Tenants
+// without the label are matched as if their label set were empty. It is used
+// for stores that do not implement shared.TenantLabelIndex.
+func requirementPredicate(r shared.LabelRequirement, values map[uuid.UUID]string) predicate.Tenant {
+	var matching, other []uuid.UUID
+	for id, v := range values {
+		if r.Matches(map[string]string{r.Key: v}) {
+			matching = append(matching, id)
+		} else {
+			other = append(other, id)
+		}
+	}
+	if r.Matches(nil) {
+		return entTenant.IDNotIn(other...)
+	}
+	return entTenant.IDIn(matching...)
+}
+
+// memoryAttributeStore is the default, non-persistent TenantAttributeStore.
+type memoryAttributeStore struct {
+	mu    sync.RWMutex
+	attrs map[uuid.UUID]shared.TenantAttributes
+}
+
+func newMemoryAttributeStore() *memoryAttributeStore {
+	return &memoryAttributeStore{attrs: make(map[uuid.UUID]shared.TenantAttributes)}
+}
+
+func (m *memoryAttributeStore) GetAttributes(_ context.Context, tenantID uuid.UUID) (*shared.TenantAttributes, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	attrs, ok := m.attrs[tenantID]
+	if !ok {
+		return nil, nil
+	}
+	return &attrs, nil
+}
+
+func (m *memoryAttributeStore) PutAttributes(_ context.Context, attrs shared.TenantAttributes) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.attrs[attrs.TenantID] = attrs
+	return nil
+}
+
+func (m *memoryAttributeStore) ListAttributesOf(_ context.Context, tenantIDs []uuid.UUID) ([]shared.TenantAttributes, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	out := make([]shared.TenantAttributes, 0, len(tenantIDs))
+	for _, id := range tenantIDs {
+		if attrs, ok := m.attrs[id]; ok {
+			out = append(out, attrs)
+		}
+	}
+	return out, nil
+}
+
+func (m *memoryAttributeStore) LabelValues(_ context.Context, key string) (map[uuid.UUID]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	values := make(map[uuid.UUID]string)
+	for id, attrs := range m.attrs {
+		if v, ok := attrs.Labels[key]; ok {
+			values[id] = v
+		}
+	}
+	return values, nil
+}
+
+var _ shared.TenantAttributeStore = (*memoryAttributeStore)(nil)
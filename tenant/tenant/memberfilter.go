@@ -0,0 +1,203 @@
+package tenant
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"entgo.io/ent/dialect/sql"
+	"github.com/google/uuid"
+
+	coreent "github.com/leeforge/core/server/ent"
+	"github.com/leeforge/core/server/ent/predicate"
+	"github.com/leeforge/core/server/ent/tenantuser"
+	"github.com/leeforge/core/server/ent/user"
+
+	"github.com/leeforge/plugins/apierror"
+	"github.com/leeforge/plugins/tenant/shared"
+)
+
+// Membership statuses exposed by the API. Suspended memberships are stored
+// with the inactive TenantUser status.
+const (
+	MemberStatusActive    = "active"
+	MemberStatusSuspended = "suspended"
+	MemberStatusAll       = "all"
+)
+
+// Member sort fields accepted by ListMembers.
+const (
+	MemberSortUsername = "username"
+	MemberSortEmail    = "email"
+	MemberSortNickname = "nickname"
+	MemberSortRole     = "role"
+	MemberSortStatus   = "status"
+	MemberSortJoinedAt = "joinedAt"
+)
+
+// memberFilter is the validated form of MemberListFilters.
+type memberFilter struct {
+	query        string
+	roles        []string
+	status       string
+	joinedAfter  *time.Time
+	joinedBefore *time.Time
+	sort         string
+	desc         bool
+}
+
+func parseMemberFilters(in MemberListFilters) (*memberFilter, error) {
+	f := &memberFilter{
+		query:        strings.TrimSpace(in.Query),
+		joinedAfter:  in.JoinedAfter,
+		joinedBefore: in.JoinedBefore,
+		desc:         true,
+	}
+
+	for _, role := range in.Roles {
+		if role = strings.TrimSpace(role); role != "" {
+			f.roles = append(f.roles, role)
+		}
+	}
+
+	switch status := strings.TrimSpace(in.Status); status {
+	case "":
+		f.status = MemberStatusActive
+	case MemberStatusActive, MemberStatusSuspended, MemberStatusAll:
+		f.status = status
+	default:
+		return nil, shared.ErrInvalidMemberFilter.With(apierror.Field("status", apierror.Unknown, "value", in.Status))
+	}
+
+	switch sort := strings.TrimSpace(in.Sort); sort {
+	case "":
+		f.sort = MemberSortJoinedAt
+	case MemberSortUsername, MemberSortEmail, MemberSortNickname, MemberSortRole, MemberSortStatus, MemberSortJoinedAt:
+		f.sort = sort
+	default:
+		return nil, shared.ErrInvalidMemberFilter.With(apierror.Field("sort", apierror.Unknown, "value", in.Sort))
+	}
+
+	switch strings.ToLower(strings.TrimSpace(in.Order)) {
+	case "", "desc":
+	case "asc":
+		f.desc = false
+	default:
+		return nil, shared.ErrInvalidMemberFilter.With(apierror.Field("order", apierror.OneOf, "values", "asc, desc"))
+	}
+
+	if f.joinedAfter != nil && f.joinedBefore != nil && f.joinedBefore.Before(*f.joinedAfter) {
+		return nil, shared.ErrInvalidMemberFilter.With(apierror.Field("joinedBefore", apierror.NotBefore, "other", "joinedAfter"))
+	}
+	return f, nil
+}
+
+// predicates returns the member query conditions for tenantID. The role and
+// status filters can be left out so that facets count across their values.
+func (f *memberFilter) predicates(tenantID uuid.UUID, withRole, withStatus bool) []predicate.TenantUser {
+	ps := []predicate.TenantUser{
+		tenantuser.TenantIDEQ(tenantID),
+		tenantuser.DeletedAtIsNil(),
+	}
+	if f.query != "" {
+		ps = append(ps, tenantuser.HasUserWith(user.Or(
+			user.UsernameContainsFold(f.query),
+			user.EmailContainsFold(f.query),
+			user.NicknameContainsFold(f.query),
+		)))
+	}
+	if f.joinedAfter != nil {
+		ps = append(ps, tenantuser.CreatedAtGTE(*f.joinedAfter))
+	}
+	if f.joinedBefore != nil {
+		ps = append(ps, tenantuser.CreatedAtLTE(*f.joinedBefore))
+	}
+	if withRole && len(f.roles) > 0 {
+		ps = append(ps, tenantuser.RoleIn(f.roles...))
+	}
+	if withStatus {
+		switch f.status {
+		case MemberStatusActive:
+			ps = append(ps, tenantuser.StatusEQ(tenantuser.StatusActive))
+		case MemberStatusSuspended:
+			ps = append(ps, tenantuser.StatusEQ(tenantuser.StatusInactive))
+		}
+	}
+	return ps
+}
+
+// order returns the member ordering, with the membership ID as tie-breaker
+// so that pages are stable.
+func (f *memberFilter) order() []tenantuser.OrderOption {
+	dir := sql.OrderAsc()
+	if f.desc {
+		dir = sql.OrderDesc()
+	}
+
+	var by tenantuser.OrderOption
+	switch f.sort {
+	case MemberSortUsername:
+		by = tenantuser.ByUserField(user.FieldUsername, dir)
+	case MemberSortEmail:
+		by = tenantuser.ByUserField(user.FieldEmail, dir)
+	case MemberSortNickname:
+		by = tenantuser.ByUserField(user.FieldNickname, dir)
+	case MemberSortRole:
+		by = tenantuser.ByRole(dir)
+	case MemberSortStatus:
+		by = tenantuser.ByStatus(dir)
+	default:
+		by = tenantuser.ByCreatedAt(dir)
+	}
+	return []tenantuser.OrderOption{by, tenantuser.ByID(dir)}
+}
+
+// memberFacets counts members per role and per membership status.
+func (s *Service) memberFacets(ctx context.Context, tenantID uuid.UUID, f *memberFilter) (MemberFacets, error) {
+	facets := MemberFacets{
+		Roles:    make(map[string]int),
+		Statuses: map[string]int{MemberStatusActive: 0, MemberStatusSuspended: 0},
+	}
+
+	var roles []struct {
+		Role  string `json:"role"`
+		Count int    `json:"count"`
+	}
+	err := s.client.TenantUser.Query().
+		Where(f.predicates(tenantID, false, true)...).
+		GroupBy(tenantuser.FieldRole).
+		Aggregate(coreent.Count()).
+		Scan(ctx, &roles)
+	if err != nil {
+		return facets, fmt.Errorf("count member roles: %w", err)
+	}
+	for _, r := range roles {
+		facets.Roles[r.Role] = r.Count
+	}
+
+	var statuses []struct {
+		Status string `json:"status"`
+		Count  int    `json:"count"`
+	}
+	err = s.client.TenantUser.Query().
+		Where(f.predicates(tenantID, true, false)...).
+		GroupBy(tenantuser.FieldStatus).
+		Aggregate(coreent.Count()).
+		Scan(ctx, &statuses)
+	if err != nil {
+		return facets, fmt.Errorf("count member statuses: %w", err)
+	}
+	for _, st := range statuses {
+		facets.Statuses[membershipStatus(tenantuser.Status(st.Status))] += st.Count
+	}
+	return facets, nil
+}
+
+// membershipStatus maps a TenantUser status to its API name.
+func membershipStatus(status tenantuser.Status) string {
+	if status == tenantuser.StatusActive {
+		return MemberStatusActive
+	}
+	return MemberStatusSuspended
+}
@@ -0,0 +1,289 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/leeforge/framework/plugin"
+	"go.uber.org/zap"
+
+	coreent "github.com/leeforge/core/server/ent"
+	entTenant "github.com/leeforge/core/server/ent/tenant"
+	"github.com/leeforge/core/server/ent/tenantuser"
+
+	"github.com/leeforge/plugins/apierror"
+	"github.com/leeforge/plugins/tenant/shared"
+)
+
+// sweepBatchSize bounds the number of expired memberships removed per query.
+const sweepBatchSize = 100
+
+// SetMemberTermStore replaces the store holding membership validity windows.
+// The factory calls it with a persistent store; without one, terms are kept
+// in memory and lost on restart.
+func (s *Service) SetMemberTermStore(store shared.MemberTermStore) {
+	if store != nil {
+		s.terms = store
+	}
+}
+
+// memberTerm validates the requested membership type and window and returns
+// the term to store, or nil for a standard membership without a window.
+func (s *Service) memberTerm(tenantID, userID uuid.UUID, req MemberTerms) (*shared.MemberTerm, error) {
+	typ := strings.TrimSpace(req.Type)
+	switch typ {
+	case "":
+		typ = shared.MembershipTypeStandard
+	case shared.MembershipTypeStandard, shared.MembershipTypeGuest:
+	default:
+		return nil, shared.ErrInvalidMemberTerm.With(apierror.Field("type", apierror.Unknown, "value", req.Type))
+	}
+	if req.ExpiresAt != nil {
+		if !req.ExpiresAt.After(s.now()) {
+			return nil, shared.ErrInvalidMemberTerm.With(apierror.Field("expiresAt", apierror.Future))
+		}
+		if req.ValidFrom != nil && !req.ExpiresAt.After(*req.ValidFrom) {
+			return nil, shared.ErrInvalidMemberTerm.With(apierror.Field("expiresAt", apierror.After, "other", "validFrom"))
+		}
+	}
+	if typ == shared.MembershipTypeStandard && req.ValidFrom == nil && req.ExpiresAt == nil {
+		return nil, nil
+	}
+	return &shared.MemberTerm{
+		TenantID:  tenantID,
+		UserID:    userID,
+		Type:      typ,
+		ValidFrom: utcPtr(req.ValidFrom),
+		ExpiresAt: utcPtr(req.ExpiresAt),
+	}, nil
+}
+
+// saveMemberTerm stores term, or clears a previous term when term is nil.
+func (s *Service) saveMemberTerm(ctx context.Context, tenantID, userID uuid.UUID, term *shared.MemberTerm) error {
+	if term == nil {
+		return s.terms.DeleteTerm(ctx, tenantID, userID)
+	}
+	return s.terms.PutTerm(ctx, *term)
+}
+
+// termActive reports whether the membership window of userID in tenantID
+// is open now.
+func (s *Service) termActive(ctx context.Context, tenantID, userID uuid.UUID) (bool, error) {
+	term, err := s.terms.GetTerm(ctx, tenantID, userID)
+	if err != nil {
+		return false, fmt.Errorf("get membership term: %w", err)
+	}
+	return term.ActiveAt(s.now()), nil
+}
+
+// CheckDomainMembership reports whether userID is a member of the tenant
+// behind domainID whose membership window is open. It is used to validate
+// domain resolution so that expired memberships lose access before the
+// sweeper removes them. Users with an active impersonation session for the
+// domain are accepted too; disabled service accounts are rejected.
+func (s *Service) CheckDomainMembership(ctx context.Context, domainID, userID uuid.UUID) (_ bool, err error) {
+	ctx, end := s.instrument(ctx, "check_domain_membership")
+	defer end(&err)
+
+	ok, err := s.domainSvc.CheckMembership(ctx, domainID, userID)
+	if err != nil {
+		return false, err
+	}
+	if !ok {
+		return s.impersonating(ctx, domainID, userID)
+	}
+	if member, isAccount, err := s.serviceAccountMember(ctx, domainID, userID); err != nil || isAccount {
+		return member, err
+	}
+	dom, err := s.domainSvc.ResolveDomainByID(ctx, domainID)
+	if err != nil {
+		return false, fmt.Errorf("resolve domain: %w", err)
+	}
+	if dom.TypeCode != s.cfg.DomainTypeCode || s.client == nil {
+		return true, nil
+	}
+	t, err := s.client.Tenant.Query().
+		Where(entTenant.CodeEQ(dom.Key), entTenant.DeletedAtIsNil()).
+		Only(ctx)
+	if err != nil {
+		if coreent.IsNotFound(err) {
+			return true, nil
+		}
+		return false, fmt.Errorf("get tenant: %w", err)
+	}
+	return s.termActive(ctx, t.ID, userID)
+}
+
+// SweepExpiredMembers removes every membership whose window has closed from
+// the tenant and the domain service and publishes tenant.member.expired for
+// each. It returns the number of memberships removed.
+func (s *Service) SweepExpiredMembers(ctx context.Context) (removed int, err error) {
+	ctx, end := s.instrument(ctx, "sweep_expired_members")
+	defer end(&err)
+
+	for {
+		expired, err := s.terms.ListExpired(ctx, s.now(), sweepBatchSize)
+		if err != nil {
+			return removed, fmt.Errorf("list expired memberships: %w", err)
+		}
+		for _, term := range expired {
+			if err := s.expireMember(ctx, term); err != nil {
+				return removed, err
+			}
+			removed++
+		}
+		if len(expired) < sweepBatchSize {
+			return removed, nil
+		}
+	}
+}
+
+func (s *Service) expireMember(ctx context.Context, term shared.MemberTerm) error {
+	t, err := s.client.Tenant.Get(ctx, term.TenantID)
+	switch {
+	case coreent.IsNotFound(err):
+		return s.terms.DeleteTerm(ctx, term.TenantID, term.UserID)
+	case err != nil:
+		return fmt.Errorf("get tenant: %w", err)
+	}
+
+	membership, err := s.removeMembership(ctx, t, term.UserID)
+	if err != nil && !errors.Is(err, shared.ErrMemberNotFound) {
+		return fmt.Errorf("expire membership: %w", err)
+	}
+	if err := s.terms.DeleteTerm(ctx, term.TenantID, term.UserID); err != nil {
+		return fmt.Errorf("delete membership term: %w", err)
+	}
+	if membership == nil {
+		return nil
+	}
+
+	s.logger.Info("tenant: membership expired",
+		zap.Stringer("tenantID", t.ID),
+		zap.Stringer("userID", term.UserID),
+	)
+	_ = s.events.Publish(ctx, plugin.Event{
+		Name:   shared.EventTenantMemberExpired,
+		Source: "tenant",
+		Data: shared.MemberEventData{
+			TenantID: t.ID,
+			UserID:   term.UserID,
+			Role:     membership.Role,
+		},
+	})
+	return nil
+}
+
+// removeMembership soft-deletes the membership of userID in t, removes the
+// matching domain membership and moves the user's default tenant elsewhere
+// if needed. It returns ErrMemberNotFound when there is no membership.
+func (s *Service) removeMembership(ctx context.Context, t *coreent.Tenant, userID uuid.UUID) (*coreent.TenantUser, error) {
+	membership, err := s.findMembership(ctx, t.ID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.client.TenantUser.Update().Where(tenantuser.ID(membership.ID)).SetDeletedAt(s.now()).Save(ctx); err != nil {
+		return nil, fmt.Errorf("remove membership: %w", err)
+	}
+
+	// Remove domain membership.
+	domainID := s.resolveDomainIDSafe(ctx, t.Code)
+	if domainID != uuid.Nil {
+		_ = s.domainSvc.RemoveMembership(ctx, domainID, userID)
+	}
+
+	// Reassign default if needed.
+	if membership.IsDefault {
+		alt, err := s.client.TenantUser.Query().
+			Where(
+				tenantuser.UserID(userID),
+				tenantuser.DeletedAtIsNil(),
+				tenantuser.StatusEQ(tenantuser.StatusActive),
+			).
+			Order(coreent.Asc(tenantuser.FieldCreatedAt)).
+			First(ctx)
+		if err == nil {
+			_, _ = s.client.TenantUser.UpdateOneID(alt.ID).SetIsDefault(true).Save(ctx)
+		}
+	}
+	return membership, nil
+}
+
+func utcPtr(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	u := t.UTC()
+	return &u
+}
+
+// memoryTermStore is the default, non-persistent MemberTermStore.
+type memoryTermStore struct {
+	mu    sync.RWMutex
+	terms map[[2]uuid.UUID]shared.MemberTerm
+}
+
+func newMemoryTermStore() *memoryTermStore {
+	return &memoryTermStore{terms: make(map[[2]uuid.UUID]shared.MemberTerm)}
+}
+
+func (m *memoryTermStore) PutTerm(_ context.Context, term shared.MemberTerm) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.terms[[2]uuid.UUID{term.TenantID, term.UserID}] = term
+	return nil
+}
+
+func (m *memoryTermStore) GetTerm(_ context.Context, tenantID, userID uuid.UUID) (*shared.MemberTerm, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	term, ok := m.terms[[2]uuid.UUID{tenantID, userID}]
+	if !ok {
+		return nil, nil
+	}
+	return &term, nil
+}
+
+func (m *memoryTermStore) DeleteTerm(_ context.Context, tenantID, userID uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.terms, [2]uuid.UUID{tenantID, userID})
+	return nil
+}
+
+func (m *memoryTermStore) ListTerms(_ context.Context, tenantID uuid.UUID) ([]shared.MemberTerm, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var out []shared.MemberTerm
+	for _, term := range m.terms {
+		if term.TenantID == tenantID {
+			out = append(out, term)
+		}
+	}
+	return out, nil
+}
+
+func (m *memoryTermStore) ListExpired(_ context.Context, now time.Time, limit int) ([]shared.MemberTerm, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var out []shared.MemberTerm
+	for _, term := range m.terms {
+		if term.ExpiredAt(now) {
+			out = append(out, term)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ExpiresAt.Before(*out[j].ExpiresAt) })
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+var _ shared.MemberTermStore = (*memoryTermStore)(nil)
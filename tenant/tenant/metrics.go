@@ -0,0 +1,92 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	entTenant "github.com/leeforge/core/server/ent/tenant"
+	"github.com/leeforge/core/server/ent/tenantuser"
+
+	"github.com/leeforge/plugins/apierror"
+	"github.com/leeforge/plugins/metrics"
+	"github.com/leeforge/plugins/tenant/shared"
+)
+
+// Metric names exported by the tenant service.
+const (
+	MetricOperations        = "tenant_operations_total"
+	MetricOperationDuration = "tenant_operation_duration_seconds"
+	MetricTenants           = "tenant_tenants"
+	MetricMembers           = "tenant_members"
+)
+
+var operationMetrics = metrics.Operation{
+	Counter:   MetricOperations,
+	Histogram: MetricOperationDuration,
+	Classify:  outcomeOf,
+}
+
+// SetMetrics replaces the metrics recorder. Gauges are refreshed by
+// CollectGauges, which the plugin registers as a metrics collector.
+func (s *Service) SetMetrics(rec metrics.Recorder) {
+	s.metrics = metrics.OrNop(rec)
+	metrics.Describe(s.metrics, MetricOperations, "Tenant service operations by operation and outcome.")
+	metrics.Describe(s.metrics, MetricOperationDuration, "Latency of tenant service operations.")
+	metrics.Describe(s.metrics, MetricTenants, "Tenants that are not deleted, by status.")
+	metrics.Describe(s.metrics, MetricMembers, "Active tenant memberships.")
+}
+
+// CollectGauges refreshes the tenant and member count gauges.
+func (s *Service) CollectGauges(ctx context.Context, rec metrics.Recorder) error {
+	if s.client == nil {
+		return fmt.Errorf("tenant gauges: database client not initialized")
+	}
+	for _, status := range []entTenant.Status{entTenant.StatusActive, entTenant.StatusInactive} {
+		n, err := s.client.Tenant.Query().
+			Where(entTenant.DeletedAtIsNil(), entTenant.StatusEQ(status)).
+			Count(ctx)
+		if err != nil {
+			return fmt.Errorf("count tenants: %w", err)
+		}
+		rec.SetGauge(MetricTenants, float64(n), metrics.Labels{"status": string(status)})
+	}
+
+	members, err := s.client.TenantUser.Query().
+		Where(
+			tenantuser.DeletedAtIsNil(),
+			tenantuser.StatusEQ(tenantuser.StatusActive),
+		).
+		Count(ctx)
+	if err != nil {
+		return fmt.Errorf("count members: %w", err)
+	}
+	rec.SetGauge(MetricMembers, float64(members), nil)
+	return nil
+}
+
+// observe records the outcome and latency of a service operation that
+// ended with *errp.
+func (s *Service) observe(op string, start time.Time, errp *error) {
+	operationMetrics.Observe(s.metrics, op, start, *errp)
+}
+
+// outcomeOf maps a service error to a low-cardinality outcome label. API
+// errors are classified by their status; the plain sentinels below are
+// answered with the status they are classified as.
+func outcomeOf(err error) string {
+	var apiErr *apierror.Error
+	switch {
+	case errors.As(err, &apiErr):
+		return metrics.StatusOutcome(apiErr.Status)
+	case errors.Is(err, shared.ErrTenantNotFound), errors.Is(err, shared.ErrMemberNotFound):
+		return metrics.OutcomeNotFound
+	case errors.Is(err, shared.ErrVersionMismatch):
+		return metrics.OutcomeConflict
+	case errors.Is(err, shared.ErrPlatformDomainOnly):
+		return metrics.OutcomeForbidden
+	default:
+		return metrics.OutcomeError
+	}
+}
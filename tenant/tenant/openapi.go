@@ -0,0 +1,243 @@
+package tenant
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"github.com/leeforge/plugins/etag"
+	"github.com/leeforge/plugins/mergepatch"
+	"github.com/leeforge/plugins/openapi"
+)
+
+// Statuses the error mappers answer with. TestErrorMapperStatuses keeps
+// them in line with the mappers.
+var (
+	// apiErrorStatuses are those of typed errors answered by apierror.Write.
+	apiErrorStatuses            = []int{400, 409}
+	tenantErrorStatuses         = openapi.Statuses(apiErrorStatuses, []int{403, 404, 500})
+	memberStateErrorStatuses    = openapi.Statuses(apiErrorStatuses, []int{403, 404, 500})
+	roleSyncErrorStatuses       = openapi.Statuses(apiErrorStatuses, []int{403, 404, 500})
+	provisioningErrorStatuses   = openapi.Statuses(tenantErrorStatuses, []int{404, 500})
+	impersonationErrorStatuses  = openapi.Statuses(tenantErrorStatuses, []int{404})
+	serviceAccountErrorStatuses = openapi.Statuses(tenantErrorStatuses, []int{404})
+	webhookErrorStatuses        = openapi.Statuses(tenantErrorStatuses, []int{400, 403, 404})
+)
+
+const openAPITag = "TenantPlugin-Tenants"
+
+// DescribeRoutes adds the handler routes, as mounted under /tenants by the
+// plugin, to b. Every route also lists the statuses in errors, answered by
+// middleware in front of the handlers.
+func DescribeRoutes(b *openapi.Builder, errors ...int) {
+	b.Tag(openAPITag, "Tenants, their members, roles, service accounts and webhooks").
+		PathParam(openapi.Param{Name: "id", Description: "Tenant ID", Type: uuid.UUID{}}).
+		PathParam(openapi.Param{Name: "userId", Description: "User ID", Type: uuid.UUID{}}).
+		PathParam(openapi.Param{Name: "name", Description: "Template name"}).
+		PathParam(openapi.Param{Name: "sessionId", Description: "Session ID", Type: uuid.UUID{}}).
+		PathParam(openapi.Param{Name: "accountId", Description: "Service account ID", Type: uuid.UUID{}}).
+		PathParam(openapi.Param{Name: "keyId", Description: "API key ID", Type: uuid.UUID{}}).
+		PathParam(openapi.Param{Name: "webhookId", Description: "Webhook ID", Type: uuid.UUID{}}).
+		PathParam(openapi.Param{Name: "deliveryId", Description: "Delivery ID", Type: uuid.UUID{}})
+
+	routes := tenantRoutes()
+	routes = append(routes, webhookRoutes("/tenants", "")...)
+	routes = append(routes, webhookRoutes("/tenants/{id}", "Tenant")...)
+	for i := range routes {
+		routes[i].Tags = []string{openAPITag}
+		routes[i].Errors = openapi.Statuses(routes[i].Errors, errors)
+	}
+	b.Add(routes...)
+}
+
+func tenantRoutes() []openapi.Route {
+	page := []openapi.Param{
+		{Name: "page", Description: "Page number", Type: 0},
+		{Name: "pageSize", Description: "Page size", Type: 0},
+	}
+	withPage := func(params ...openapi.Param) []openapi.Param {
+		return append(append([]openapi.Param{}, page...), params...)
+	}
+	format := openapi.Param{Name: "format", Description: "json (default) or tar"}
+	update := openapi.Route{Method: http.MethodPut, Path: "/tenants/{id}", ID: "UpdateTenant", Summary: "Update tenant",
+		Description: "Replaces the tenant: absent or empty members clear the description, the parent tenant, " +
+			"the labels and the metadata, and an empty status means active.",
+		Body: UpdateRequest{}, Response: TenantDTO{}, Errors: tenantErrorStatuses}
+	etag.Describe(&update, TenantDTO{})
+	patch := openapi.Route{Method: http.MethodPatch, Path: "/tenants/{id}", ID: "PatchTenant", Summary: "Patch tenant",
+		Description: "Applies a JSON merge patch (RFC 7396): absent members are left unchanged and null " +
+			"clears the description or the parent tenant; labels and metadata are merged.",
+		Body: PatchRequest{}, Response: TenantDTO{}, Errors: tenantErrorStatuses}
+	etag.Describe(&patch, TenantDTO{})
+	mergepatch.Describe(&patch)
+
+	return []openapi.Route{
+		{Method: http.MethodGet, Path: "/tenants/me", ID: "ListMyTenants", Summary: "List my tenants",
+			Response: MyTenantListResult{}, Errors: []int{401, 500}},
+		{Method: http.MethodGet, Path: "/tenants/me/{id}", ID: "GetMyTenant", Summary: "Get my membership in a tenant",
+			Response: MyTenantDTO{}, Errors: []int{400, 401, 404, 500}},
+		{Method: http.MethodGet, Path: "/tenants", ID: "ListTenants", Summary: "List tenants",
+			Query: withPage(
+				openapi.Param{Name: "query", Description: "Search query"},
+				openapi.Param{Name: "status", Description: "Tenant status (comma-separated or repeated)", Type: []string{}},
+				openapi.Param{Name: "includeDeleted", Description: "Include deleted", Type: true},
+				openapi.Param{Name: "labelSelector", Description: "Label selector, e.g. plan=pro,region in (eu,us)"},
+				openapi.Param{Name: "ownerId", Description: "Owner user ID", Type: uuid.UUID{}},
+				openapi.Param{Name: "parentTenantId", Description: "Direct parent tenant ID", Type: uuid.UUID{}},
+				openapi.Param{Name: "ancestorTenantId", Description: "Ancestor tenant ID, at any depth", Type: uuid.UUID{}},
+				openapi.Param{Name: "memberId", Description: "User ID of a member", Type: uuid.UUID{}},
+				openapi.Param{Name: "createdAfter", Description: "Created at or after (RFC 3339)"},
+				openapi.Param{Name: "createdBefore", Description: "Created at or before (RFC 3339)"},
+				openapi.Param{Name: "updatedAfter", Description: "Updated at or after (RFC 3339)"},
+				openapi.Param{Name: "updatedBefore", Description: "Updated at or before (RFC 3339)"},
+			),
+			Response: ListResult{}, Errors: tenantErrorStatuses},
+		{Method: http.MethodPost, Path: "/tenants", ID: "CreateTenant", Summary: "Create tenant",
+			Body: CreateRequest{}, Response: TenantDTO{}, Errors: tenantErrorStatuses},
+		{Method: http.MethodPost, Path: "/tenants/roles/sync", ID: "SyncAllRoleTemplates",
+			Summary:  "Re-sync roles of all tenants from role templates",
+			Response: RoleSyncListResult{}, Errors: roleSyncErrorStatuses},
+		{Method: http.MethodGet, Path: "/tenants/templates", ID: "ListTenantTemplates", Summary: "List tenant templates",
+			Response: TenantTemplateListResult{}, Errors: tenantErrorStatuses},
+		{Method: http.MethodPost, Path: "/tenants/templates/{name}/clone", ID: "CloneTenantTemplate",
+			Summary: "Create tenant from template",
+			Body:    CloneRequest{}, Response: CloneResult{}, Errors: provisioningErrorStatuses},
+		{Method: http.MethodPost, Path: "/tenants/import", ID: "ImportTenant", Summary: "Import tenant",
+			Query: []openapi.Param{
+				format,
+				{Name: "dryRun", Description: "Report changes without applying them", Type: true},
+				{Name: "onConflict", Description: "fail, rename or merge"},
+				{Name: "code", Description: "Tenant code override"},
+				{Name: "name", Description: "Tenant name override"},
+			},
+			Body: TenantArchive{}, Consumes: []string{"application/x-tar"},
+			Response: ImportReport{}, Errors: provisioningErrorStatuses},
+		{Method: http.MethodPost, Path: "/tenants/search", ID: "SearchTenants", Summary: "Search tenants",
+			Description: "Filters tenants with a JSON expression: the conditions of a filter must all hold, " +
+				`"and" sub-filters must all match and at least one "or" sub-filter must match.`,
+			Body: SearchRequest{}, Response: ListResult{}, Errors: tenantErrorStatuses},
+		{Method: http.MethodGet, Path: "/tenants/impersonations", ID: "ListImpersonations",
+			Summary: "List impersonation sessions",
+			Query: []openapi.Param{
+				{Name: "tenantId", Description: "Tenant ID", Type: uuid.UUID{}},
+				{Name: "actorId", Description: "Impersonating user ID", Type: uuid.UUID{}},
+				{Name: "status", Description: "active (default), expired, revoked or all"},
+			},
+			Response: ImpersonationListResult{}, Errors: impersonationErrorStatuses},
+		{Method: http.MethodPost, Path: "/tenants/impersonations/{sessionId}/revoke", ID: "RevokeImpersonation",
+			Summary:  "Revoke impersonation session",
+			Response: ImpersonationDTO{}, Errors: impersonationErrorStatuses},
+
+		{Method: http.MethodGet, Path: "/tenants/{id}", ID: "GetTenant", Summary: "Get tenant",
+			Response: TenantDTO{}, Errors: tenantErrorStatuses},
+		update,
+		patch,
+		{Method: http.MethodDelete, Path: "/tenants/{id}", ID: "DeleteTenant", Summary: "Delete tenant",
+			Response: openapi.Message{}, Errors: tenantErrorStatuses},
+
+		{Method: http.MethodPost, Path: "/tenants/{id}/members", ID: "AddMember", Summary: "Add tenant member",
+			Body: AddMemberRequest{}, Response: openapi.Message{}, Errors: openapi.Statuses(apiErrorStatuses, []int{403, 404, 500})},
+		{Method: http.MethodGet, Path: "/tenants/{id}/members", ID: "ListMembers", Summary: "List tenant members",
+			Query: withPage(
+				openapi.Param{Name: "query", Description: "Username, email or nickname substring"},
+				openapi.Param{Name: "role", Description: "Role (comma-separated or repeated)", Type: []string{}},
+				openapi.Param{Name: "status", Description: "Membership status: active (default), suspended or all"},
+				openapi.Param{Name: "joinedAfter", Description: "Joined at or after (RFC 3339)"},
+				openapi.Param{Name: "joinedBefore", Description: "Joined at or before (RFC 3339)"},
+				openapi.Param{Name: "sort", Description: "Sort field: username, email, nickname, role, status or joinedAt"},
+				openapi.Param{Name: "order", Description: "Sort order: asc or desc (default)"},
+			),
+			Response: MemberListResult{}, Errors: openapi.Statuses(apiErrorStatuses, []int{403, 404, 500})},
+		{Method: http.MethodDelete, Path: "/tenants/{id}/members/{userId}", ID: "RemoveMember",
+			Summary:  "Remove tenant member",
+			Response: openapi.Message{}, Errors: []int{400, 403, 404, 500}},
+		{Method: http.MethodPost, Path: "/tenants/{id}/members/{userId}/suspend", ID: "SuspendMember",
+			Summary: "Suspend tenant member",
+			Body:    SuspendMemberRequest{}, BodyOptional: true,
+			Response: openapi.Message{}, Errors: openapi.Statuses([]int{400}, memberStateErrorStatuses)},
+		{Method: http.MethodPost, Path: "/tenants/{id}/members/{userId}/reactivate", ID: "ReactivateMember",
+			Summary:  "Reactivate suspended tenant member",
+			Response: openapi.Message{}, Errors: openapi.Statuses([]int{400}, memberStateErrorStatuses)},
+
+		{Method: http.MethodGet, Path: "/tenants/{id}/roles", ID: "ListRoles", Summary: "List tenant roles",
+			Response: RoleListResult{}, Errors: []int{400, 403, 404, 500}},
+		{Method: http.MethodPost, Path: "/tenants/{id}/roles/sync", ID: "SyncRoleTemplates",
+			Summary:  "Re-sync tenant roles from role templates",
+			Response: RoleSyncReport{}, Errors: openapi.Statuses([]int{400}, roleSyncErrorStatuses)},
+		{Method: http.MethodPost, Path: "/tenants/{id}/clone", ID: "CloneTenant", Summary: "Clone tenant",
+			Body: CloneRequest{}, Response: CloneResult{}, Errors: provisioningErrorStatuses},
+		{Method: http.MethodGet, Path: "/tenants/{id}/export", ID: "ExportTenant", Summary: "Export tenant",
+			Query:    []openapi.Param{format},
+			Response: TenantArchive{}, Raw: true, Produces: []string{"application/x-tar"},
+			Errors: tenantErrorStatuses},
+		{Method: http.MethodPost, Path: "/tenants/{id}/impersonate", ID: "Impersonate",
+			Summary: "Start impersonating a tenant",
+			Body:    ImpersonateRequest{}, Response: ImpersonationGrant{}, Errors: impersonationErrorStatuses},
+
+		{Method: http.MethodPost, Path: "/tenants/{id}/service-accounts", ID: "CreateServiceAccount",
+			Summary: "Create service account",
+			Body:    CreateServiceAccountRequest{}, Response: ServiceAccountDTO{}, Errors: serviceAccountErrorStatuses},
+		{Method: http.MethodGet, Path: "/tenants/{id}/service-accounts", ID: "ListServiceAccounts",
+			Summary:  "List service accounts",
+			Response: ServiceAccountListResult{}, Errors: serviceAccountErrorStatuses},
+		{Method: http.MethodGet, Path: "/tenants/{id}/service-accounts/{accountId}", ID: "GetServiceAccount",
+			Summary:  "Get service account",
+			Response: ServiceAccountDTO{}, Errors: serviceAccountErrorStatuses},
+		{Method: http.MethodPut, Path: "/tenants/{id}/service-accounts/{accountId}", ID: "UpdateServiceAccount",
+			Summary: "Update service account",
+			Body:    UpdateServiceAccountRequest{}, Response: ServiceAccountDTO{}, Errors: serviceAccountErrorStatuses},
+		{Method: http.MethodDelete, Path: "/tenants/{id}/service-accounts/{accountId}", ID: "DeleteServiceAccount",
+			Summary:  "Delete service account",
+			Response: openapi.Message{}, Errors: serviceAccountErrorStatuses},
+		{Method: http.MethodPost, Path: "/tenants/{id}/service-accounts/{accountId}/keys", ID: "CreateAPIKey",
+			Summary: "Issue API key",
+			Body:    CreateAPIKeyRequest{}, Response: APIKeyGrant{}, Errors: serviceAccountErrorStatuses},
+		{Method: http.MethodGet, Path: "/tenants/{id}/service-accounts/{accountId}/keys", ID: "ListAPIKeys",
+			Summary:  "List API keys",
+			Response: APIKeyListResult{}, Errors: serviceAccountErrorStatuses},
+		{Method: http.MethodDelete, Path: "/tenants/{id}/service-accounts/{accountId}/keys/{keyId}", ID: "RevokeAPIKey",
+			Summary:  "Revoke API key",
+			Response: APIKeyDTO{}, Errors: serviceAccountErrorStatuses},
+		{Method: http.MethodPost, Path: "/tenants/{id}/service-accounts/{accountId}/keys/{keyId}/rotate", ID: "RotateAPIKey",
+			Summary: "Rotate API key",
+			Body:    RotateAPIKeyRequest{}, BodyOptional: true,
+			Response: APIKeyGrant{}, Errors: serviceAccountErrorStatuses},
+	}
+}
+
+// webhookRoutes describes the webhook routes under prefix. The platform and
+// tenant variants share handlers, so the operationIds of the tenant variant
+// are told apart by scope, as in CreateTenantWebhook.
+func webhookRoutes(prefix, scope string) []openapi.Route {
+	id := func(verb, noun string) string { return verb + scope + noun }
+	return []openapi.Route{
+		{Method: http.MethodPost, Path: prefix + "/webhooks", ID: id("Create", "Webhook"),
+			Summary:     "Register webhook endpoint",
+			Description: "The signing secret is only returned by this call.",
+			Body:        CreateWebhookRequest{}, Response: WebhookGrant{}, Errors: webhookErrorStatuses},
+		{Method: http.MethodGet, Path: prefix + "/webhooks", ID: id("List", "Webhooks"),
+			Summary:  "List webhook endpoints",
+			Response: WebhookListResult{}, Errors: webhookErrorStatuses},
+		{Method: http.MethodGet, Path: prefix + "/webhooks/{webhookId}", ID: id("Get", "Webhook"),
+			Summary:  "Get webhook endpoint",
+			Response: WebhookDTO{}, Errors: webhookErrorStatuses},
+		{Method: http.MethodPut, Path: prefix + "/webhooks/{webhookId}", ID: id("Update", "Webhook"),
+			Summary: "Update webhook endpoint",
+			Body:    UpdateWebhookRequest{}, Response: WebhookDTO{}, Errors: webhookErrorStatuses},
+		{Method: http.MethodDelete, Path: prefix + "/webhooks/{webhookId}", ID: id("Delete", "Webhook"),
+			Summary:  "Delete webhook endpoint",
+			Response: openapi.Message{}, Errors: webhookErrorStatuses},
+		{Method: http.MethodGet, Path: prefix + "/webhooks/{webhookId}/deliveries", ID: id("List", "WebhookDeliveries"),
+			Summary: "List webhook deliveries",
+			Query: []openapi.Param{
+				{Name: "page", Description: "Page number", Type: 0},
+				{Name: "pageSize", Description: "Page size", Type: 0},
+				{Name: "status", Description: "pending, delivered or dead"},
+				{Name: "event", Description: "Event type"},
+			},
+			Response: WebhookDeliveryListResult{}, Errors: webhookErrorStatuses},
+		{Method: http.MethodPost, Path: prefix + "/webhooks/{webhookId}/deliveries/{deliveryId}/redeliver",
+			ID: id("Redeliver", "Webhook"), Summary: "Redeliver webhook delivery",
+			Response: WebhookDeliveryDTO{}, Errors: webhookErrorStatuses},
+	}
+}
@@ -0,0 +1,78 @@
+package tenant
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/leeforge/framework/logging"
+
+	"github.com/leeforge/plugins/tenant/shared"
+)
+
+// sharedErrors are the errors the service returns; the mappers fall back to
+// 500 for anything else.
+var sharedErrors = []error{
+	shared.ErrTenantNotFound, shared.ErrTenantCodeExists, shared.ErrInvalidTenant,
+	shared.ErrMemberExists, shared.ErrMemberNotFound, shared.ErrPlatformDomainOnly,
+	shared.ErrParentTenantInvalid, shared.ErrInvalidMemberTerm, shared.ErrMemberSuspended,
+	shared.ErrMemberNotSuspended, shared.ErrInvalidMemberFilter, shared.ErrInvalidRoleTemplate,
+	shared.ErrInvalidRole, shared.ErrTenantTemplateNotFound, shared.ErrTenantTemplateExists,
+	shared.ErrInvalidTenantTemplate, shared.ErrProvisioningFailed, shared.ErrOrganizationsDisabled,
+	shared.ErrInvalidArchive, shared.ErrImpersonationNotFound, shared.ErrImpersonationInactive,
+	shared.ErrInvalidImpersonation, shared.ErrServiceAccountNotFound, shared.ErrServiceAccountExists,
+	shared.ErrInvalidServiceAccount, shared.ErrServiceAccountDisabled, shared.ErrAPIKeyNotFound,
+	shared.ErrAPIKeyInactive, shared.ErrInvalidLabels, shared.ErrInvalidLabelSelector,
+	shared.ErrInvalidTenantFilter, shared.ErrWebhookNotFound, shared.ErrWebhookDeliveryNotFound,
+	shared.ErrInvalidWebhook, shared.ErrTenantAdminRequired, shared.ErrInvalidConfig,
+	errors.New("database is down"),
+}
+
+func TestErrorMapperStatuses(t *testing.T) {
+	h := NewHandler(nil, logging.FromZap(zap.NewNop()))
+	mappers := []struct {
+		name     string
+		mapError func(http.ResponseWriter, *http.Request, error)
+		statuses []int
+	}{
+		{"mapTenantError", func(w http.ResponseWriter, r *http.Request, err error) {
+			h.mapTenantError(w, r, "failed", err)
+		}, tenantErrorStatuses},
+		{"mapMemberStateError", func(w http.ResponseWriter, r *http.Request, err error) {
+			h.mapMemberStateError(w, r, "failed", err)
+		}, memberStateErrorStatuses},
+		{"mapRoleSyncError", h.mapRoleSyncError, roleSyncErrorStatuses},
+		{"mapProvisioningError", func(w http.ResponseWriter, r *http.Request, err error) {
+			h.mapProvisioningError(w, r, "failed", nil, err)
+		}, provisioningErrorStatuses},
+		{"mapImpersonationError", func(w http.ResponseWriter, r *http.Request, err error) {
+			h.mapImpersonationError(w, r, "failed", err)
+		}, impersonationErrorStatuses},
+		{"mapServiceAccountError", func(w http.ResponseWriter, r *http.Request, err error) {
+			h.mapServiceAccountError(w, r, "failed", err)
+		}, serviceAccountErrorStatuses},
+		{"mapWebhookError", func(w http.ResponseWriter, r *http.Request, err error) {
+			h.mapWebhookError(w, r, "failed", err)
+		}, webhookErrorStatuses},
+	}
+
+	for _, m := range mappers {
+		t.Run(m.name, func(t *testing.T) {
+			var got []int
+			for _, err := range sharedErrors {
+				rec := httptest.NewRecorder()
+				m.mapError(rec, httptest.NewRequest(http.MethodGet, "/tenants", nil), err)
+				if !slices.Contains(got, rec.Code) {
+					got = append(got, rec.Code)
+				}
+			}
+			slices.Sort(got)
+			require.Equal(t, m.statuses, got)
+		})
+	}
+}
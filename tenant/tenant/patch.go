@@ -0,0 +1,98 @@
+package tenant
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"strings"
+
+	"github.com/google/uuid"
+
+	entTenant "github.com/leeforge/core/server/ent/tenant"
+
+	"github.com/leeforge/plugins/apierror"
+	"github.com/leeforge/plugins/mergepatch"
+	"github.com/leeforge/plugins/tenant/shared"
+)
+
+// PatchTenant applies a JSON merge patch to a tenant, see PatchRequest.
+// With req.ExpectedVersion set it fails with shared.ErrVersionMismatch when
+// the tenant was updated since.
+func (s *Service) PatchTenant(ctx context.Context, id uuid.UUID, req *PatchRequest) (_ *TenantDTO, err error) {
+	ctx, end := s.instrument(ctx, "patch_tenant")
+	defer end(&err)
+
+	if err := requirePlatformDomain(ctx); err != nil {
+		return nil, err
+	}
+
+	change, err := patchChange(req)
+	if err != nil {
+		return nil, err
+	}
+	return s.updateTenant(ctx, id, req.ExpectedVersion, change)
+}
+
+// patchChange resolves req into a change. Its labels and metadata are merged
+// into the stored ones by updateTenant, in the transaction of the row update.
+func patchChange(req *PatchRequest) (tenantChange, error) {
+	var change tenantChange
+	var fields []apierror.FieldError
+	if req.Name.Set {
+		if strings.TrimSpace(req.Name.Value) == "" {
+			fields = append(fields, apierror.Field("name", apierror.Required))
+		}
+		change.name = &req.Name.Value
+	}
+	if req.Description.Set {
+		change.description = &req.Description.Value
+	}
+	if req.Status.Set {
+		if entTenant.StatusValidator(entTenant.Status(req.Status.Value)) != nil {
+			fields = append(fields, apierror.Field("status", apierror.OneOf, "values", "active, inactive"))
+		}
+		change.status = &req.Status.Value
+	}
+	if req.ParentTenantID.Set {
+		change.parent = &req.ParentTenantID.Value
+	}
+	if len(fields) > 0 {
+		return change, shared.ErrInvalidTenant.With(fields...)
+	}
+	if req.Labels.Set || req.Metadata.Set {
+		change.attributes = func(current shared.TenantAttributes) (map[string]string, json.RawMessage, error) {
+			return mergeAttributes(current, req)
+		}
+	}
+	return change, nil
+}
+
+// mergeAttributes returns the labels and metadata of req merged into current.
+func mergeAttributes(current shared.TenantAttributes, req *PatchRequest) (map[string]string, json.RawMessage, error) {
+	var labels map[string]string
+	if req.Labels.Set {
+		labels = make(map[string]string)
+		if !req.Labels.Null {
+			maps.Copy(labels, current.Labels)
+		}
+		for k, v := range req.Labels.Value {
+			if v == nil {
+				delete(labels, k)
+			} else {
+				labels[k] = *v
+			}
+		}
+	}
+	var metadata json.RawMessage
+	if req.Metadata.Set {
+		metadata = json.RawMessage("null")
+		if !req.Metadata.Null {
+			merged, err := mergepatch.Apply(current.Metadata, req.Metadata.Value)
+			if err != nil {
+				return nil, nil, shared.ErrInvalidTenant.With(apierror.Field("metadata", apierror.Invalid))
+			}
+			metadata = merged
+		}
+	}
+	return labels, metadata, validateAttributes(labels, metadata)
+}
@@ -0,0 +1,190 @@
+package tenant
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+
+	coreent "github.com/leeforge/core/server/ent"
+	entTenant "github.com/leeforge/core/server/ent/tenant"
+
+	"github.com/leeforge/plugins/apierror"
+	"github.com/leeforge/plugins/tenant/shared"
+	"github.com/leeforge/plugins/tracing"
+)
+
+// SetRoleTemplateSource sets the port that supplies per-tenant role
+// templates, for example from a billing plan. Tenants for which it returns
+// no templates use the configured ones.
+func (s *Service) SetRoleTemplateSource(src shared.RoleTemplateSource) {
+	s.templates = src
+}
+
+// SetRoleCatalog sets the port listing the roles of a tenant domain. Member
+// roles are validated against it; without one, any role is accepted.
+func (s *Service) SetRoleCatalog(c shared.RoleCatalog) {
+	s.catalog = c
+	if s.tracer != tracing.Nop && c != nil {
+		s.catalog = &tracedRoleCatalog{next: c, tracer: s.tracer}
+	}
+}
+
+// ListRoles returns the roles defined in a tenant's domain.
+func (s *Service) ListRoles(ctx context.Context, tenantID uuid.UUID) (_ *RoleListResult, err error) {
+	ctx, end := s.instrument(ctx, "list_roles")
+	defer end(&err)
+
+	if err := requirePlatformDomain(ctx); err != nil {
+		return nil, err
+	}
+
+	t, err := s.client.Tenant.Get(ctx, tenantID)
+	if err != nil {
+		if coreent.IsNotFound(err) {
+			return nil, shared.ErrTenantNotFound
+		}
+		return nil, fmt.Errorf("get tenant: %w", err)
+	}
+
+	result := &RoleListResult{Roles: []shared.RoleInfo{}}
+	domainID := s.resolveDomainIDSafe(ctx, t.Code)
+	if s.catalog == nil || domainID == uuid.Nil {
+		return result, nil
+	}
+	roles, err := s.catalog.ListRoles(ctx, domainID)
+	if err != nil {
+		return nil, fmt.Errorf("list roles: %w", err)
+	}
+	if roles != nil {
+		result.Roles = roles
+	}
+	return result, nil
+}
+
+// validateRole returns ErrInvalidRole when role is not defined in the domain
+// of the tenant with code tenantCode.
+func (s *Service) validateRole(ctx context.Context, domainID uuid.UUID, tenantCode, role string) error {
+	if s.catalog == nil || domainID == uuid.Nil {
+		return nil
+	}
+	roles, err := s.catalog.ListRoles(ctx, domainID)
+	if err != nil {
+		return fmt.Errorf("list roles: %w", err)
+	}
+	codes := make([]string, 0, len(roles))
+	for _, r := range roles {
+		if r.Code == role {
+			return nil
+		}
+		codes = append(codes, r.Code)
+	}
+	return shared.ErrInvalidRole.With(apierror.Field("role", shared.CodeUndefinedRole,
+		"value", role, "tenant", tenantCode, "roles", strings.Join(codes, ", ")))
+}
+
+// roleTemplatesFor returns the role templates of a tenant.
+func (s *Service) roleTemplatesFor(ctx context.Context, tenantID uuid.UUID, tenantCode string) ([]shared.RoleTemplate, error) {
+	if s.templates != nil {
+		templates, err := s.templates.RoleTemplates(ctx, tenantID, tenantCode)
+		if err != nil {
+			return nil, fmt.Errorf("load role templates: %w", err)
+		}
+		if len(templates) > 0 {
+			if err := shared.ValidateRoleTemplates(templates); err != nil {
+				return nil, err
+			}
+			return templates, nil
+		}
+	}
+	return s.cfg.RoleTemplates, nil
+}
+
+// SyncRoleTemplates pushes the current role templates into an existing
+// tenant. Roles the tenant changed since they were provisioned are kept and
+// reported as customized.
+func (s *Service) SyncRoleTemplates(ctx context.Context, tenantID uuid.UUID) (_ *RoleSyncReport, err error) {
+	ctx, end := s.instrument(ctx, "sync_role_templates")
+	defer end(&err)
+
+	if err := requirePlatformDomain(ctx); err != nil {
+		return nil, err
+	}
+
+	t, err := s.client.Tenant.Query().
+		Where(entTenant.ID(tenantID), entTenant.DeletedAtIsNil()).
+		Only(ctx)
+	if err != nil {
+		if coreent.IsNotFound(err) {
+			return nil, shared.ErrTenantNotFound
+		}
+		return nil, fmt.Errorf("get tenant: %w", err)
+	}
+	return s.syncTenantRoles(ctx, t)
+}
+
+// SyncAllRoleTemplates runs SyncRoleTemplates for every tenant that is not
+// deleted.
+func (s *Service) SyncAllRoleTemplates(ctx context.Context) (_ []*RoleSyncReport, err error) {
+	ctx, end := s.instrument(ctx, "sync_all_role_templates")
+	defer end(&err)
+
+	if err := requirePlatformDomain(ctx); err != nil {
+		return nil, err
+	}
+
+	tenants, err := s.client.Tenant.Query().
+		Where(entTenant.DeletedAtIsNil()).
+		Order(coreent.Asc(entTenant.FieldCode)).
+		All(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list tenants: %w", err)
+	}
+
+	reports := make([]*RoleSyncReport, 0, len(tenants))
+	for _, t := range tenants {
+		report, err := s.syncTenantRoles(ctx, t)
+		if err != nil {
+			return reports, fmt.Errorf("sync tenant %s: %w", t.Code, err)
+		}
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
+
+func (s *Service) syncTenantRoles(ctx context.Context, t *coreent.Tenant) (*RoleSyncReport, error) {
+	domainID := s.resolveDomainIDSafe(ctx, t.Code)
+	if domainID == uuid.Nil {
+		return nil, fmt.Errorf("resolve domain of tenant %s", t.Code)
+	}
+	templates, err := s.roleTemplatesFor(ctx, t.ID, t.Code)
+	if err != nil {
+		return nil, err
+	}
+	result, err := seedRoles(ctx, s.roleSeeder, domainID, templates)
+	if err != nil {
+		return nil, fmt.Errorf("seed roles: %w", err)
+	}
+	return &RoleSyncReport{
+		TenantID:       t.ID,
+		TenantCode:     t.Code,
+		RoleSyncResult: result,
+	}, nil
+}
+
+// seedRoles provisions templates into domainID through seeder. Seeders that
+// do not provision templates seed their baseline roles, reporting no
+// template as synced.
+func seedRoles(ctx context.Context, seeder shared.RoleSeeder, domainID uuid.UUID, templates []shared.RoleTemplate) (shared.RoleSyncResult, error) {
+	if ts, ok := seeder.(shared.RoleTemplateSeeder); ok {
+		return ts.SeedRoles(ctx, domainID, templates)
+	}
+	result := shared.RoleSyncResult{
+		Created:    []string{},
+		Updated:    []string{},
+		Unchanged:  []string{},
+		Customized: []string{},
+	}
+	return result, seeder.SeedBaselineRoles(ctx, domainID)
+}
@@ -2,8 +2,11 @@ package tenant
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
@@ -17,7 +20,11 @@ import (
 	entTenant "github.com/leeforge/core/server/ent/tenant"
 	"github.com/leeforge/core/server/ent/tenantuser"
 
+	"github.com/leeforge/plugins/apierror"
+	"github.com/leeforge/plugins/etag"
+	"github.com/leeforge/plugins/metrics"
 	"github.com/leeforge/plugins/tenant/shared"
+	"github.com/leeforge/plugins/tracing"
 )
 
 // Service handles tenant CRUD and membership operations.
@@ -28,6 +35,30 @@ type Service struct {
 	logger     logging.Logger
 	roleSeeder shared.RoleSeeder
 	userLookup shared.UserLookup
+	cfg        shared.Config
+	metrics    metrics.Recorder
+	tracer     tracing.Tracer
+	terms      shared.MemberTermStore
+	perms      shared.PermissionResolver
+	catalog    shared.RoleCatalog
+	templates  shared.RoleTemplateSource
+	orgs       shared.OrganizationCloner
+	archiver   shared.OrganizationArchiver
+	now        func() time.Time
+
+	impersonations      shared.ImpersonationStore
+	impersonationAudits shared.ImpersonationAuditLog
+	accounts            shared.ServiceAccountStore
+	attrs               shared.TenantAttributeStore
+	webhooks            shared.WebhookStore
+	webhookClient       *http.Client
+	webhookAllow        webhookAllowlist
+	// customWebhookClient is set by SetWebhookClient; SetConfig then keeps
+	// the client instead of rebuilding the default one.
+	customWebhookClient bool
+
+	tenantTemplatesMu sync.RWMutex
+	tenantTemplates   map[string]shared.TenantTemplate
 }
 
 // NewService creates a new tenant service.
@@ -39,13 +70,50 @@ func NewService(
 	roleSeeder shared.RoleSeeder,
 	userLookup shared.UserLookup,
 ) *Service {
+	cfg := shared.DefaultConfig()
+	allow := newWebhookAllowlist(cfg.WebhookAllowedHosts)
 	return &Service{
 		client:     client,
 		domainSvc:  domainSvc,
-		events:     events,
+		events:     WrapImpersonationEventBus(events),
 		logger:     logger,
 		roleSeeder: roleSeeder,
 		userLookup: userLookup,
+		cfg:        cfg,
+		metrics:    metrics.Nop,
+		tracer:     tracing.Nop,
+		terms:      newMemoryTermStore(),
+		now:        time.Now,
+
+		tenantTemplates:     make(map[string]shared.TenantTemplate),
+		impersonations:      newMemoryImpersonationStore(),
+		impersonationAudits: logImpersonationAuditLog{logger: logger},
+		accounts:            newMemoryServiceAccountStore(),
+		attrs:               newMemoryAttributeStore(),
+		webhooks:            newMemoryWebhookStore(),
+		webhookClient:       newWebhookClient(cfg, allow),
+		webhookAllow:        allow,
+	}
+}
+
+// SetConfig replaces the service settings. The plugin calls it with the
+// validated host configuration right after the factory builds the service.
+func (s *Service) SetConfig(cfg shared.Config) {
+	s.cfg = cfg
+	s.resetTenantTemplates(cfg.TenantTemplates)
+	s.webhookAllow = newWebhookAllowlist(cfg.WebhookAllowedHosts)
+	if !s.customWebhookClient {
+		s.webhookClient = newWebhookClient(cfg, s.webhookAllow)
+	}
+}
+
+// SetPermissionResolver sets the port used to report the effective role
+// permissions of the caller's memberships. Without one, permissions are
+// left out.
+func (s *Service) SetPermissionResolver(r shared.PermissionResolver) {
+	s.perms = r
+	if s.tracer != tracing.Nop && r != nil {
+		s.perms = &tracedPermissionResolver{next: r, tracer: s.tracer}
 	}
 }
 
@@ -59,15 +127,35 @@ func (s *Service) Ping(ctx context.Context) error {
 }
 
 // CreateTenant creates a tenant, its domain, and owner membership.
-func (s *Service) CreateTenant(ctx context.Context, req *CreateRequest) (*TenantDTO, error) {
+func (s *Service) CreateTenant(ctx context.Context, req *CreateRequest) (_ *TenantDTO, err error) {
+	ctx, end := s.instrument(ctx, "create_tenant")
+	defer end(&err)
+
 	if err := requirePlatformDomain(ctx); err != nil {
 		return nil, err
 	}
 
+	ownerID, _ := core.GetUserID(ctx)
+	return s.createTenant(ctx, req, ownerID)
+}
+
+// createTenant creates a tenant owned by ownerID, or without owner when
+// ownerID is uuid.Nil, and publishes tenant.created.
+func (s *Service) createTenant(ctx context.Context, req *CreateRequest, ownerID uuid.UUID) (*TenantDTO, error) {
 	code := strings.TrimSpace(req.Code)
 	name := strings.TrimSpace(req.Name)
-	if code == "" || name == "" {
-		return nil, shared.ErrInvalidTenant
+	var fields []apierror.FieldError
+	if code == "" {
+		fields = append(fields, apierror.Field("code", apierror.Required))
+	}
+	if name == "" {
+		fields = append(fields, apierror.Field("name", apierror.Required))
+	}
+	if len(fields) > 0 {
+		return nil, shared.ErrInvalidTenant.With(fields...)
+	}
+	if err := validateAttributes(req.Labels, req.Metadata); err != nil {
+		return nil, err
 	}
 
 	tx, err := s.client.Tx(ctx)
@@ -95,15 +183,14 @@ func (s *Service) CreateTenant(ctx context.Context, req *CreateRequest) (*Tenant
 		builder.SetStatus(entTenant.Status(status))
 	}
 
-	var ownerID uuid.UUID
-	var hasOwner bool
-	if id, ok := core.GetUserID(ctx); ok {
-		ownerID = id
-		hasOwner = true
+	hasOwner := ownerID != uuid.Nil
+	if hasOwner {
 		builder.SetOwnerID(ownerID)
 	}
 
-	t, err := builder.Save(ctx)
+	insertCtx, insertSpan := s.tracer.Start(ctx, "tenant.db.insert_tenant")
+	t, err := builder.Save(insertCtx)
+	tracing.End(insertSpan, &err)
 	if err != nil {
 		_ = tx.Rollback()
 		if coreent.IsConstraintError(err) {
@@ -113,35 +200,57 @@ func (s *Service) CreateTenant(ctx context.Context, req *CreateRequest) (*Tenant
 	}
 
 	// Create domain via DomainResolver (before seeding roles so we have the domainID).
-	dom, err := s.domainSvc.EnsureDomain(ctx, "tenant", code, name)
+	dom, err := s.domainSvc.EnsureDomain(ctx, s.cfg.DomainTypeCode, code, name)
 	if err != nil {
 		_ = tx.Rollback()
 		return nil, fmt.Errorf("ensure domain: %w", err)
 	}
 
-	// Seed baseline roles for the tenant using domain ID.
-	if err := s.roleSeeder.SeedBaselineRoles(ctx, dom.DomainID); err != nil {
+	// Provision the tenant's roles from its templates using domain ID.
+	templates, err := s.roleTemplatesFor(ctx, t.ID, code)
+	if err != nil {
+		_ = tx.Rollback()
+		return nil, err
+	}
+	if _, err := seedRoles(ctx, s.roleSeeder, dom.DomainID, templates); err != nil {
 		_ = tx.Rollback()
-		return nil, fmt.Errorf("seed baseline roles: %w", err)
+		return nil, fmt.Errorf("seed roles: %w", err)
+	}
+	ownerRole := s.cfg.OwnerRole
+	if owner, ok := shared.OwnerTemplate(templates); ok {
+		ownerRole = owner.Code
 	}
 
 	// Bind owner membership.
 	if hasOwner {
-		if err := s.domainSvc.AddMembership(ctx, dom.DomainID, ownerID, "tenant_admin", true); err != nil {
+		if err := s.domainSvc.AddMembership(ctx, dom.DomainID, ownerID, ownerRole, true); err != nil {
 			_ = tx.Rollback()
 			return nil, fmt.Errorf("add owner membership to domain: %w", err)
 		}
-		if err := s.ensureMembershipTx(ctx, tx, t.ID, ownerID, true, "tenant_admin"); err != nil {
+		if err := s.ensureMembershipTx(ctx, tx, t.ID, ownerID, true, ownerRole); err != nil {
 			_ = tx.Rollback()
 			return nil, fmt.Errorf("create owner tenant-user record: %w", err)
 		}
 	}
 
-	if err := tx.Commit(); err != nil {
+	// Attributes are written in the transaction of the tenant row, so a
+	// failed store leaves no tenant behind and the request can be retried.
+	if err := s.saveAttributes(coreent.NewTxContext(ctx, tx), t.ID, replaceAttributes(req.Labels, req.Metadata)); err != nil {
+		_ = tx.Rollback()
+		return nil, err
+	}
+
+	_, commitSpan := s.tracer.Start(ctx, "tenant.db.commit")
+	err = tx.Commit()
+	tracing.End(commitSpan, &err)
+	if err != nil {
 		return nil, fmt.Errorf("commit tenant creation: %w", err)
 	}
 
 	dto := s.toDTO(t, dom.DomainID)
+	if err := s.attachAttributes(ctx, dto); err != nil {
+		return nil, err
+	}
 
 	// Publish event.
 	actorID := ownerID
@@ -160,36 +269,46 @@ func (s *Service) CreateTenant(ctx context.Context, req *CreateRequest) (*Tenant
 }
 
 // ListTenants returns a paginated list of tenants.
-func (s *Service) ListTenants(ctx context.Context, filters ListFilters) (*ListResult, error) {
+func (s *Service) ListTenants(ctx context.Context, filters ListFilters) (_ *ListResult, err error) {
+	ctx, end := s.instrument(ctx, "list_tenants")
+	defer end(&err)
+
 	if err := requirePlatformDomain(ctx); err != nil {
 		return nil, err
 	}
 
-	if filters.Page < 1 {
-		filters.Page = 1
-	}
-	if filters.PageSize < 1 {
-		filters.PageSize = 20
+	return s.queryTenants(ctx, filters.filter(), filters.Page, filters.PageSize, filters.IncludeDeleted)
+}
+
+// SearchTenants returns a paginated list of the tenants matching a search
+// expression.
+func (s *Service) SearchTenants(ctx context.Context, req *SearchRequest) (_ *ListResult, err error) {
+	ctx, end := s.instrument(ctx, "search_tenants")
+	defer end(&err)
+
+	if err := requirePlatformDomain(ctx); err != nil {
+		return nil, err
 	}
-	if filters.PageSize > 100 {
-		filters.PageSize = 100
+	if req == nil {
+		req = &SearchRequest{}
 	}
+	return s.queryTenants(ctx, req.Filter, req.Page, req.PageSize, req.IncludeDeleted)
+}
+
+// queryTenants returns one page of the tenants matching f, newest first.
+func (s *Service) queryTenants(ctx context.Context, f TenantFilter, page, pageSize int, includeDeleted bool) (*ListResult, error) {
+	page, pageSize = s.cfg.PageBounds(page, pageSize)
 
+	pred, err := s.tenantPredicate(ctx, f)
+	if err != nil {
+		return nil, err
+	}
 	query := s.client.Tenant.Query()
-	if !filters.IncludeDeleted {
+	if !includeDeleted {
 		query = query.Where(entTenant.DeletedAtIsNil())
 	}
-	if filters.Query != "" {
-		search := strings.TrimSpace(filters.Query)
-		query = query.Where(
-			entTenant.Or(
-				entTenant.CodeContainsFold(search),
-				entTenant.NameContainsFold(search),
-			),
-		)
-	}
-	if filters.Status != "" {
-		query = query.Where(entTenant.StatusEQ(entTenant.Status(filters.Status)))
+	if pred != nil {
+		query = query.Where(pred)
 	}
 
 	total, err := query.Count(ctx)
@@ -197,10 +316,10 @@ func (s *Service) ListTenants(ctx context.Context, filters ListFilters) (*ListRe
 		return nil, fmt.Errorf("count tenants: %w", err)
 	}
 
-	offset := (filters.Page - 1) * filters.PageSize
+	offset := (page - 1) * pageSize
 	items, err := query.
 		Offset(offset).
-		Limit(filters.PageSize).
+		Limit(pageSize).
 		Order(coreent.Desc(entTenant.FieldCreatedAt)).
 		All(ctx)
 	if err != nil {
@@ -212,19 +331,25 @@ func (s *Service) ListTenants(ctx context.Context, filters ListFilters) (*ListRe
 		domainID := s.resolveDomainIDSafe(ctx, item.Code)
 		dtos[i] = s.toDTO(item, domainID)
 	}
+	if err := s.attachAttributes(ctx, dtos...); err != nil {
+		return nil, err
+	}
 
-	totalPages := (total + filters.PageSize - 1) / filters.PageSize
+	totalPages := (total + pageSize - 1) / pageSize
 	return &ListResult{
 		Tenants:    dtos,
 		Total:      total,
-		Page:       filters.Page,
-		PageSize:   filters.PageSize,
+		Page:       page,
+		PageSize:   pageSize,
 		TotalPages: totalPages,
 	}, nil
 }
 
 // GetTenant returns a single tenant by ID.
-func (s *Service) GetTenant(ctx context.Context, id uuid.UUID) (*TenantDTO, error) {
+func (s *Service) GetTenant(ctx context.Context, id uuid.UUID) (_ *TenantDTO, err error) {
+	ctx, end := s.instrument(ctx, "get_tenant")
+	defer end(&err)
+
 	t, err := s.client.Tenant.Get(ctx, id)
 	if err != nil {
 		if coreent.IsNotFound(err) {
@@ -233,11 +358,18 @@ func (s *Service) GetTenant(ctx context.Context, id uuid.UUID) (*TenantDTO, erro
 		return nil, fmt.Errorf("get tenant: %w", err)
 	}
 	domainID := s.resolveDomainIDSafe(ctx, t.Code)
-	return s.toDTO(t, domainID), nil
+	dto := s.toDTO(t, domainID)
+	if err := s.attachAttributes(ctx, dto); err != nil {
+		return nil, err
+	}
+	return dto, nil
 }
 
 // GetTenantByCode returns a single tenant by code.
-func (s *Service) GetTenantByCode(ctx context.Context, code string) (*TenantDTO, error) {
+func (s *Service) GetTenantByCode(ctx context.Context, code string) (_ *TenantDTO, err error) {
+	ctx, end := s.instrument(ctx, "get_tenant_by_code")
+	defer end(&err)
+
 	t, err := s.client.Tenant.Query().
 		Where(entTenant.CodeEQ(code), entTenant.DeletedAtIsNil()).
 		Only(ctx)
@@ -248,15 +380,77 @@ func (s *Service) GetTenantByCode(ctx context.Context, code string) (*TenantDTO,
 		return nil, fmt.Errorf("get tenant by code: %w", err)
 	}
 	domainID := s.resolveDomainIDSafe(ctx, t.Code)
-	return s.toDTO(t, domainID), nil
+	dto := s.toDTO(t, domainID)
+	if err := s.attachAttributes(ctx, dto); err != nil {
+		return nil, err
+	}
+	return dto, nil
 }
 
-// UpdateTenant updates tenant fields.
-func (s *Service) UpdateTenant(ctx context.Context, id uuid.UUID, req *UpdateRequest) (*TenantDTO, error) {
+// UpdateTenant replaces a tenant with req: an empty description, parent,
+// labels or metadata clears it and an empty status means active. Use
+// PatchTenant to change some fields only. With req.ExpectedVersion set it
+// fails with shared.ErrVersionMismatch when the tenant was updated since.
+func (s *Service) UpdateTenant(ctx context.Context, id uuid.UUID, req *UpdateRequest) (_ *TenantDTO, err error) {
+	ctx, end := s.instrument(ctx, "update_tenant")
+	defer end(&err)
+
 	if err := requirePlatformDomain(ctx); err != nil {
 		return nil, err
 	}
 
+	name := strings.TrimSpace(req.Name)
+	status := strings.TrimSpace(req.Status)
+	if status == "" {
+		status = string(entTenant.DefaultStatus)
+	}
+	var fields []apierror.FieldError
+	if name == "" {
+		fields = append(fields, apierror.Field("name", apierror.Required))
+	}
+	if entTenant.StatusValidator(entTenant.Status(status)) != nil {
+		fields = append(fields, apierror.Field("status", apierror.OneOf, "values", "active, inactive"))
+	}
+	if len(fields) > 0 {
+		return nil, shared.ErrInvalidTenant.With(fields...)
+	}
+	if err := validateAttributes(req.Labels, req.Metadata); err != nil {
+		return nil, err
+	}
+
+	labels := req.Labels
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	metadata := req.Metadata
+	if metadata == nil {
+		metadata = json.RawMessage("null")
+	}
+	parent := strings.TrimSpace(req.ParentTenantID)
+	change := tenantChange{
+		name:        &name,
+		description: &req.Description,
+		status:      &status,
+		parent:      &parent,
+		attributes:  replaceAttributes(labels, metadata),
+	}
+	return s.updateTenant(ctx, id, req.ExpectedVersion, change)
+}
+
+// tenantChange is an update of a tenant resolved from an UpdateRequest, a
+// PatchRequest or an imported archive. Nil fields are left unchanged, and an empty description or
+// parent clears it. Labels and metadata are stored as by saveAttributes.
+type tenantChange struct {
+	name        *string
+	description *string
+	status      *string
+	parent      *string
+	attributes  attributeUpdate
+}
+
+// updateTenant applies change to the tenant id, at expectedVersion unless
+// it is empty, and publishes tenant.updated.
+func (s *Service) updateTenant(ctx context.Context, id uuid.UUID, expectedVersion string, change tenantChange) (*TenantDTO, error) {
 	t, err := s.client.Tenant.Get(ctx, id)
 	if err != nil {
 		if coreent.IsNotFound(err) {
@@ -265,34 +459,74 @@ func (s *Service) UpdateTenant(ctx context.Context, id uuid.UUID, req *UpdateReq
 		return nil, fmt.Errorf("get tenant: %w", err)
 	}
 
-	updater := s.client.Tenant.UpdateOne(t)
-	parentTenantID, hasParent, err := s.resolveParentTenantID(ctx, req.ParentTenantID, id)
+	if expectedVersion != "" && etag.Version(t.UpdatedAt) != expectedVersion {
+		return nil, shared.ErrVersionMismatch
+	}
+
+	tx, err := s.client.Tx(ctx)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("start transaction: %w", err)
 	}
-	if hasParent {
-		updater.SetParentTenantID(parentTenantID)
+	updater := tx.Tenant.UpdateOne(t).SetUpdatedAt(etag.Now())
+	if expectedVersion != "" {
+		// The updated_at guard makes the version check atomic with the
+		// update; a concurrent update leaves no row to update.
+		updater.Where(entTenant.UpdatedAtEQ(t.UpdatedAt))
+	}
+	if change.parent != nil {
+		parentTenantID, hasParent, err := s.resolveParentTenantID(ctx, *change.parent, id)
+		if err != nil {
+			_ = tx.Rollback()
+			return nil, err
+		}
+		if hasParent {
+			updater.SetParentTenantID(parentTenantID)
+		} else {
+			updater.ClearParentTenantID()
+		}
 	}
-	if req.Name != "" {
-		updater.SetName(strings.TrimSpace(req.Name))
+	if change.name != nil {
+		updater.SetName(strings.TrimSpace(*change.name))
 	}
-	if req.Description != "" {
-		updater.SetDescription(req.Description)
+	if change.description != nil {
+		if *change.description == "" {
+			updater.ClearDescription()
+		} else {
+			updater.SetDescription(*change.description)
+		}
 	}
-	if req.Status != "" {
-		updater.SetStatus(entTenant.Status(req.Status))
+	if change.status != nil {
+		updater.SetStatus(entTenant.Status(*change.status))
 	}
 
 	t, err = updater.Save(ctx)
 	if err != nil {
+		_ = tx.Rollback()
+		if coreent.IsNotFound(err) && expectedVersion != "" {
+			return nil, shared.ErrVersionMismatch
+		}
 		if coreent.IsNotFound(err) {
 			return nil, shared.ErrTenantNotFound
 		}
 		return nil, fmt.Errorf("update tenant: %w", err)
 	}
 
+	// The row update holds the tenant row until commit, so attributes are
+	// read, merged and written after those of earlier updates. Stores on the
+	// tenant database join the transaction through the context.
+	if err := s.saveAttributes(coreent.NewTxContext(ctx, tx), t.ID, change.attributes); err != nil {
+		_ = tx.Rollback()
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit tenant update: %w", err)
+	}
+
 	domainID := s.resolveDomainIDSafe(ctx, t.Code)
 	dto := s.toDTO(t, domainID)
+	if err := s.attachAttributes(ctx, dto); err != nil {
+		return nil, err
+	}
 
 	actorID, _ := core.GetUserID(ctx)
 	_ = s.events.Publish(ctx, plugin.Event{
@@ -310,7 +544,10 @@ func (s *Service) UpdateTenant(ctx context.Context, id uuid.UUID, req *UpdateReq
 }
 
 // DeleteTenant soft-deletes a tenant.
-func (s *Service) DeleteTenant(ctx context.Context, id uuid.UUID) error {
+func (s *Service) DeleteTenant(ctx context.Context, id uuid.UUID) (err error) {
+	ctx, end := s.instrument(ctx, "delete_tenant")
+	defer end(&err)
+
 	if err := requirePlatformDomain(ctx); err != nil {
 		return err
 	}
@@ -347,12 +584,22 @@ func (s *Service) DeleteTenant(ctx context.Context, id uuid.UUID) error {
 	return nil
 }
 
-// AddMember adds a user to a tenant.
-func (s *Service) AddMember(ctx context.Context, tenantID, userID uuid.UUID, role string) error {
+// AddMember adds a user to a tenant. terms optionally make the membership a
+// guest membership and bound it to a validity window; adding an existing
+// member replaces its terms.
+func (s *Service) AddMember(ctx context.Context, tenantID, userID uuid.UUID, role string, terms MemberTerms) (err error) {
+	ctx, end := s.instrument(ctx, "add_member")
+	defer end(&err)
+
 	if err := requirePlatformDomain(ctx); err != nil {
 		return err
 	}
 
+	term, err := s.memberTerm(tenantID, userID, terms)
+	if err != nil {
+		return err
+	}
+
 	t, err := s.client.Tenant.Get(ctx, tenantID)
 	if err != nil {
 		if coreent.IsNotFound(err) {
@@ -388,12 +635,22 @@ func (s *Service) AddMember(ctx context.Context, tenantID, userID uuid.UUID, rol
 		}
 	}
 
+	// A suspended member keeps its membership; it must be reactivated
+	// rather than added again.
+	if existing, err := s.findMembership(ctx, t.ID, userID); err == nil && existing.Status != tenantuser.StatusActive {
+		return shared.ErrMemberSuspended
+	}
+
 	if role == "" {
-		role = "member"
+		role = s.cfg.DefaultMemberRole
 	}
 
-	// Add domain membership.
 	domainID := s.resolveDomainIDSafe(ctx, t.Code)
+	if err := s.validateRole(ctx, domainID, t.Code, role); err != nil {
+		return err
+	}
+
+	// Add domain membership.
 	if domainID != uuid.Nil {
 		if err := s.domainSvc.AddMembership(ctx, domainID, userID, role, false); err != nil {
 			return fmt.Errorf("add domain membership: %w", err)
@@ -404,6 +661,9 @@ func (s *Service) AddMember(ctx context.Context, tenantID, userID uuid.UUID, rol
 	if err := s.ensureMembership(ctx, t.ID, userID, false, role); err != nil {
 		return fmt.Errorf("ensure membership: %w", err)
 	}
+	if err := s.saveMemberTerm(ctx, t.ID, userID, term); err != nil {
+		return fmt.Errorf("save membership term: %w", err)
+	}
 
 	actorID, _ := core.GetUserID(ctx)
 	_ = s.events.Publish(ctx, plugin.Event{
@@ -421,7 +681,10 @@ func (s *Service) AddMember(ctx context.Context, tenantID, userID uuid.UUID, rol
 }
 
 // RemoveMember removes a user from a tenant.
-func (s *Service) RemoveMember(ctx context.Context, tenantID, userID uuid.UUID) error {
+func (s *Service) RemoveMember(ctx context.Context, tenantID, userID uuid.UUID) (err error) {
+	ctx, end := s.instrument(ctx, "remove_member")
+	defer end(&err)
+
 	if err := requirePlatformDomain(ctx); err != nil {
 		return err
 	}
@@ -434,90 +697,166 @@ func (s *Service) RemoveMember(ctx context.Context, tenantID, userID uuid.UUID)
 		return fmt.Errorf("get tenant: %w", err)
 	}
 
-	membership, err := s.client.TenantUser.Query().
-		Where(
-			tenantuser.TenantIDEQ(t.ID),
-			tenantuser.UserID(userID),
-			tenantuser.DeletedAtIsNil(),
-		).
-		First(ctx)
+	if _, err := s.removeMembership(ctx, t, userID); err != nil {
+		return err
+	}
+	if err := s.terms.DeleteTerm(ctx, t.ID, userID); err != nil {
+		return fmt.Errorf("delete membership term: %w", err)
+	}
+
+	actorID, _ := core.GetUserID(ctx)
+	_ = s.events.Publish(ctx, plugin.Event{
+		Name:   shared.EventTenantMemberRemoved,
+		Source: "tenant",
+		Data: shared.MemberEventData{
+			TenantID: tenantID,
+			UserID:   userID,
+			ActorID:  actorID,
+		},
+	})
+
+	return nil
+}
+
+// SuspendMember revokes a member's access without deleting the membership.
+// The domain membership is removed so that IsMember reports false, while the
+// tenant role and default flag are kept for ReactivateMember.
+func (s *Service) SuspendMember(ctx context.Context, tenantID, userID uuid.UUID, reason string) (err error) {
+	ctx, end := s.instrument(ctx, "suspend_member")
+	defer end(&err)
+
+	if err := requirePlatformDomain(ctx); err != nil {
+		return err
+	}
+
+	t, err := s.client.Tenant.Get(ctx, tenantID)
 	if err != nil {
 		if coreent.IsNotFound(err) {
-			return shared.ErrMemberNotFound
+			return shared.ErrTenantNotFound
 		}
-		return fmt.Errorf("get membership: %w", err)
+		return fmt.Errorf("get tenant: %w", err)
 	}
 
-	now := time.Now()
-	if _, err := s.client.TenantUser.Update().Where(tenantuser.ID(membership.ID)).SetDeletedAt(now).Save(ctx); err != nil {
-		return fmt.Errorf("remove membership: %w", err)
+	membership, err := s.findMembership(ctx, t.ID, userID)
+	if err != nil {
+		return err
+	}
+	if membership.Status != tenantuser.StatusActive {
+		return shared.ErrMemberSuspended
 	}
 
-	// Remove domain membership.
 	domainID := s.resolveDomainIDSafe(ctx, t.Code)
 	if domainID != uuid.Nil {
-		_ = s.domainSvc.RemoveMembership(ctx, domainID, userID)
+		if err := s.domainSvc.RemoveMembership(ctx, domainID, userID); err != nil {
+			return fmt.Errorf("suspend domain membership: %w", err)
+		}
 	}
 
-	// Reassign default if needed.
-	if membership.IsDefault {
-		alt, err := s.client.TenantUser.Query().
-			Where(
-				tenantuser.UserID(userID),
-				tenantuser.DeletedAtIsNil(),
-				tenantuser.StatusEQ(tenantuser.StatusActive),
-			).
-			Order(coreent.Asc(tenantuser.FieldCreatedAt)).
-			First(ctx)
-		if err == nil {
-			_, _ = s.client.TenantUser.UpdateOneID(alt.ID).SetIsDefault(true).Save(ctx)
+	if _, err := s.client.TenantUser.UpdateOneID(membership.ID).SetStatus(tenantuser.StatusInactive).Save(ctx); err != nil {
+		if domainID != uuid.Nil {
+			_ = s.domainSvc.AddMembership(ctx, domainID, userID, membership.Role, membership.IsDefault)
 		}
+		return fmt.Errorf("suspend membership: %w", err)
 	}
 
 	actorID, _ := core.GetUserID(ctx)
 	_ = s.events.Publish(ctx, plugin.Event{
-		Name:   shared.EventTenantMemberRemoved,
+		Name:   shared.EventTenantMemberSuspended,
 		Source: "tenant",
 		Data: shared.MemberEventData{
 			TenantID: tenantID,
 			UserID:   userID,
+			Role:     membership.Role,
 			ActorID:  actorID,
+			Reason:   strings.TrimSpace(reason),
 		},
 	})
 
 	return nil
 }
 
-// ListMembers returns a paginated list of tenant members.
-func (s *Service) ListMembers(ctx context.Context, tenantID uuid.UUID, page, pageSize int) (*MemberListResult, error) {
+// ReactivateMember restores a suspended membership with its previous role
+// and default flag.
+func (s *Service) ReactivateMember(ctx context.Context, tenantID, userID uuid.UUID) (err error) {
+	ctx, end := s.instrument(ctx, "reactivate_member")
+	defer end(&err)
+
 	if err := requirePlatformDomain(ctx); err != nil {
-		return nil, err
+		return err
 	}
 
 	t, err := s.client.Tenant.Get(ctx, tenantID)
 	if err != nil {
 		if coreent.IsNotFound(err) {
-			return nil, shared.ErrTenantNotFound
+			return shared.ErrTenantNotFound
 		}
-		return nil, fmt.Errorf("get tenant: %w", err)
+		return fmt.Errorf("get tenant: %w", err)
 	}
 
-	if page < 1 {
-		page = 1
+	membership, err := s.findMembership(ctx, t.ID, userID)
+	if err != nil {
+		return err
 	}
-	if pageSize < 1 {
-		pageSize = 20
+	if membership.Status == tenantuser.StatusActive {
+		return shared.ErrMemberNotSuspended
 	}
-	if pageSize > 100 {
-		pageSize = 100
+
+	domainID := s.resolveDomainIDSafe(ctx, t.Code)
+	if domainID != uuid.Nil {
+		if err := s.domainSvc.AddMembership(ctx, domainID, userID, membership.Role, membership.IsDefault); err != nil {
+			return fmt.Errorf("restore domain membership: %w", err)
+		}
+	}
+
+	if _, err := s.client.TenantUser.UpdateOneID(membership.ID).SetStatus(tenantuser.StatusActive).Save(ctx); err != nil {
+		if domainID != uuid.Nil {
+			_ = s.domainSvc.RemoveMembership(ctx, domainID, userID)
+		}
+		return fmt.Errorf("reactivate membership: %w", err)
 	}
 
+	actorID, _ := core.GetUserID(ctx)
+	_ = s.events.Publish(ctx, plugin.Event{
+		Name:   shared.EventTenantMemberReactivated,
+		Source: "tenant",
+		Data: shared.MemberEventData{
+			TenantID: tenantID,
+			UserID:   userID,
+			Role:     membership.Role,
+			ActorID:  actorID,
+		},
+	})
+
+	return nil
+}
+
+// ListMembers returns a filtered, sorted page of tenant members together
+// with role and status counts for the admin UI facets.
+func (s *Service) ListMembers(ctx context.Context, tenantID uuid.UUID, filters MemberListFilters) (_ *MemberListResult, err error) {
+	ctx, end := s.instrument(ctx, "list_members")
+	defer end(&err)
+
+	if err := requirePlatformDomain(ctx); err != nil {
+		return nil, err
+	}
+
+	f, err := parseMemberFilters(filters)
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := s.client.Tenant.Get(ctx, tenantID)
+	if err != nil {
+		if coreent.IsNotFound(err) {
+			return nil, shared.ErrTenantNotFound
+		}
+		return nil, fmt.Errorf("get tenant: %w", err)
+	}
+
+	page, pageSize := s.cfg.PageBounds(filters.Page, filters.PageSize)
+
 	query := s.client.TenantUser.Query().
-		Where(
-			tenantuser.TenantIDEQ(t.ID),
-			tenantuser.DeletedAtIsNil(),
-			tenantuser.StatusEQ(tenantuser.StatusActive),
-		).
+		Where(f.predicates(t.ID, true, true)...).
 		WithUser()
 
 	total, err := query.Count(ctx)
@@ -529,27 +868,52 @@ func (s *Service) ListMembers(ctx context.Context, tenantID uuid.UUID, page, pag
 	items, err := query.
 		Offset(offset).
 		Limit(pageSize).
-		Order(coreent.Desc(tenantuser.FieldCreatedAt)).
+		Order(f.order()...).
 		All(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("list members: %w", err)
 	}
 
+	facets, err := s.memberFacets(ctx, t.ID, f)
+	if err != nil {
+		return nil, err
+	}
+
+	terms, err := s.terms.ListTerms(ctx, t.ID)
+	if err != nil {
+		return nil, fmt.Errorf("list membership terms: %w", err)
+	}
+	termByUser := make(map[uuid.UUID]shared.MemberTerm, len(terms))
+	for _, term := range terms {
+		termByUser[term.UserID] = term
+	}
+
+	now := s.now()
 	dtos := make([]*MemberDTO, 0, len(items))
 	for _, item := range items {
 		u := item.Edges.User
 		if u == nil {
 			continue
 		}
-		dtos = append(dtos, &MemberDTO{
-			ID:        u.ID,
-			Username:  u.Username,
-			Email:     u.Email,
-			Nickname:  u.Nickname,
-			Status:    string(u.Status),
-			Role:      item.Role,
-			IsDefault: item.IsDefault,
-		})
+		dto := &MemberDTO{
+			ID:               u.ID,
+			Username:         u.Username,
+			Email:            u.Email,
+			Nickname:         u.Nickname,
+			Status:           string(u.Status),
+			Role:             item.Role,
+			IsDefault:        item.IsDefault,
+			MembershipType:   shared.MembershipTypeStandard,
+			MembershipStatus: membershipStatus(item.Status),
+			JoinedAt:         item.CreatedAt,
+		}
+		if term, ok := termByUser[u.ID]; ok {
+			dto.MembershipType = term.Type
+			dto.ValidFrom = term.ValidFrom
+			dto.ExpiresAt = term.ExpiresAt
+			dto.Expired = term.ExpiredAt(now)
+		}
+		dtos = append(dtos, dto)
 	}
 
 	totalPages := (total + pageSize - 1) / pageSize
@@ -559,11 +923,17 @@ func (s *Service) ListMembers(ctx context.Context, tenantID uuid.UUID, page, pag
 		Page:       page,
 		PageSize:   pageSize,
 		TotalPages: totalPages,
+		Facets:     facets,
 	}, nil
 }
 
-// ListMyTenants returns the tenants the given user belongs to.
-func (s *Service) ListMyTenants(ctx context.Context, userID uuid.UUID) (*MyTenantListResult, error) {
+// ListMyTenants returns the tenants the given user belongs to. Deleted
+// tenants and memberships outside their validity window are left out;
+// suspended tenants are flagged.
+func (s *Service) ListMyTenants(ctx context.Context, userID uuid.UUID) (_ *MyTenantListResult, err error) {
+	ctx, end := s.instrument(ctx, "list_my_tenants")
+	defer end(&err)
+
 	ctxNoTenant := coremod.WithoutTenant(ctx)
 
 	memberships, err := s.client.TenantUser.Query().
@@ -595,7 +965,7 @@ func (s *Service) ListMyTenants(ctx context.Context, userID uuid.UUID) (*MyTenan
 	}
 
 	tenants, err := s.client.Tenant.Query().
-		Where(entTenant.IDIn(tenantIDs...)).
+		Where(entTenant.IDIn(tenantIDs...), entTenant.DeletedAtIsNil()).
 		All(ctxNoTenant)
 	if err != nil {
 		return nil, fmt.Errorf("list tenants: %w", err)
@@ -605,6 +975,10 @@ func (s *Service) ListMyTenants(ctx context.Context, userID uuid.UUID) (*MyTenan
 	for _, t := range tenants {
 		tenantMap[t.ID] = t
 	}
+	parents, err := s.parentRefs(ctxNoTenant, tenants)
+	if err != nil {
+		return nil, err
+	}
 
 	results := make([]*MyTenantDTO, 0, len(memberships))
 	for _, m := range memberships {
@@ -612,21 +986,131 @@ func (s *Service) ListMyTenants(ctx context.Context, userID uuid.UUID) (*MyTenan
 		if !ok {
 			continue
 		}
-		results = append(results, &MyTenantDTO{
-			ID:        t.ID,
-			Code:      t.Code,
-			Name:      t.Name,
-			Status:    string(t.Status),
-			Role:      m.Role,
-			IsDefault: m.IsDefault,
-		})
+		dto, err := s.toMyTenantDTO(ctxNoTenant, m, t, parents)
+		if err != nil {
+			return nil, err
+		}
+		if dto != nil {
+			results = append(results, dto)
+		}
 	}
 
 	return &MyTenantListResult{Tenants: results}, nil
 }
 
+// GetMyTenant returns the membership of userID in one tenant. It returns
+// ErrMemberNotFound when the user has no active membership there.
+func (s *Service) GetMyTenant(ctx context.Context, userID, tenantID uuid.UUID) (_ *MyTenantDTO, err error) {
+	ctx, end := s.instrument(ctx, "get_my_tenant")
+	defer end(&err)
+
+	ctxNoTenant := coremod.WithoutTenant(ctx)
+
+	t, err := s.client.Tenant.Query().
+		Where(entTenant.ID(tenantID), entTenant.DeletedAtIsNil()).
+		Only(ctxNoTenant)
+	if err != nil {
+		if coreent.IsNotFound(err) {
+			return nil, shared.ErrTenantNotFound
+		}
+		return nil, fmt.Errorf("get tenant: %w", err)
+	}
+
+	m, err := s.findMembership(ctxNoTenant, t.ID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if m.Status != tenantuser.StatusActive {
+		return nil, shared.ErrMemberNotFound
+	}
+
+	parents, err := s.parentRefs(ctxNoTenant, []*coreent.Tenant{t})
+	if err != nil {
+		return nil, err
+	}
+	dto, err := s.toMyTenantDTO(ctxNoTenant, m, t, parents)
+	if err != nil {
+		return nil, err
+	}
+	if dto == nil {
+		return nil, shared.ErrMemberNotFound
+	}
+	return dto, nil
+}
+
+// toMyTenantDTO builds the caller's view of membership m in t. It returns
+// nil when the membership window is not open.
+func (s *Service) toMyTenantDTO(ctx context.Context, m *coreent.TenantUser, t *coreent.Tenant, parents map[uuid.UUID]*TenantRefDTO) (*MyTenantDTO, error) {
+	term, err := s.terms.GetTerm(ctx, t.ID, m.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("get membership term: %w", err)
+	}
+	if !term.ActiveAt(s.now()) {
+		return nil, nil
+	}
+
+	dto := &MyTenantDTO{
+		ID:             t.ID,
+		Code:           t.Code,
+		Name:           t.Name,
+		Status:         string(t.Status),
+		Suspended:      t.Status != entTenant.StatusActive,
+		DomainID:       s.resolveDomainIDSafe(ctx, t.Code),
+		Role:           m.Role,
+		IsDefault:      m.IsDefault,
+		JoinedAt:       m.CreatedAt,
+		MembershipType: shared.MembershipTypeStandard,
+		Permissions:    []string{},
+	}
+	if term != nil {
+		dto.MembershipType = term.Type
+		dto.ValidFrom = term.ValidFrom
+		dto.ExpiresAt = term.ExpiresAt
+	}
+	if t.ParentTenantID != nil {
+		dto.Parent = parents[*t.ParentTenantID]
+	}
+	if s.perms != nil && dto.DomainID != uuid.Nil && m.Role != "" {
+		perms, err := s.perms.RolePermissions(ctx, dto.DomainID, m.Role)
+		if err != nil {
+			return nil, fmt.Errorf("resolve role permissions: %w", err)
+		}
+		if perms != nil {
+			dto.Permissions = perms
+		}
+	}
+	return dto, nil
+}
+
+// parentRefs loads the non-deleted parents of tenants keyed by ID.
+func (s *Service) parentRefs(ctx context.Context, tenants []*coreent.Tenant) (map[uuid.UUID]*TenantRefDTO, error) {
+	var ids []uuid.UUID
+	for _, t := range tenants {
+		if t.ParentTenantID != nil && *t.ParentTenantID != uuid.Nil {
+			ids = append(ids, *t.ParentTenantID)
+		}
+	}
+	refs := make(map[uuid.UUID]*TenantRefDTO, len(ids))
+	if len(ids) == 0 {
+		return refs, nil
+	}
+	parents, err := s.client.Tenant.Query().
+		Where(entTenant.IDIn(ids...), entTenant.DeletedAtIsNil()).
+		All(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list parent tenants: %w", err)
+	}
+	for _, p := range parents {
+		refs[p.ID] = &TenantRefDTO{ID: p.ID, Code: p.Code, Name: p.Name}
+	}
+	return refs, nil
+}
+
 // IsMember reports whether a user is a member of the given tenant.
-func (s *Service) IsMember(ctx context.Context, tenantID, userID uuid.UUID) (bool, error) {
+func (s *Service) IsMember(ctx context.Context, tenantID, userID uuid.UUID) (_ bool, err error) {
+	ctx, end := s.instrument(ctx, "is_member")
+	defer end(&err)
+
 	t, err := s.client.Tenant.Get(ctx, tenantID)
 	if err != nil {
 		if coreent.IsNotFound(err) {
@@ -635,6 +1119,12 @@ func (s *Service) IsMember(ctx context.Context, tenantID, userID uuid.UUID) (boo
 		return false, fmt.Errorf("get tenant: %w", err)
 	}
 
+	// A membership outside its validity window does not count, even before
+	// the sweeper removes it.
+	if active, err := s.termActive(ctx, t.ID, userID); err != nil || !active {
+		return false, err
+	}
+
 	domainID := s.resolveDomainIDSafe(ctx, t.Code)
 	if domainID != uuid.Nil {
 		return s.domainSvc.CheckMembership(ctx, domainID, userID)
@@ -652,8 +1142,11 @@ func (s *Service) IsMember(ctx context.Context, tenantID, userID uuid.UUID) (boo
 }
 
 // GetDomainID returns the domain ID for the given tenant code.
-func (s *Service) GetDomainID(ctx context.Context, tenantCode string) (uuid.UUID, error) {
-	dom, err := s.domainSvc.ResolveDomain(ctx, "tenant", tenantCode)
+func (s *Service) GetDomainID(ctx context.Context, tenantCode string) (_ uuid.UUID, err error) {
+	ctx, end := s.instrument(ctx, "get_domain_id")
+	defer end(&err)
+
+	dom, err := s.domainSvc.ResolveDomain(ctx, s.cfg.DomainTypeCode, tenantCode)
 	if err != nil {
 		return uuid.Nil, fmt.Errorf("resolve domain: %w", err)
 	}
@@ -661,7 +1154,10 @@ func (s *Service) GetDomainID(ctx context.Context, tenantCode string) (uuid.UUID
 }
 
 // OnUserDeleted cleans up memberships when a user is deleted.
-func (s *Service) OnUserDeleted(ctx context.Context, data any) error {
+func (s *Service) OnUserDeleted(ctx context.Context, data any) (err error) {
+	ctx, end := s.instrument(ctx, "on_user_deleted")
+	defer end(&err)
+
 	type userDeletedPayload struct {
 		UserID uuid.UUID `json:"userId"`
 	}
@@ -716,6 +1212,7 @@ func (s *Service) toDTO(t *coreent.Tenant, domainID uuid.UUID) *TenantDTO {
 		DomainID:    domainID,
 		CreatedAt:   t.CreatedAt,
 		UpdatedAt:   t.UpdatedAt,
+		Version:     etag.Version(t.UpdatedAt),
 	}
 	if t.OwnerID != uuid.Nil {
 		ownerID := t.OwnerID
@@ -729,7 +1226,7 @@ func (s *Service) toDTO(t *coreent.Tenant, domainID uuid.UUID) *TenantDTO {
 }
 
 func (s *Service) resolveDomainIDSafe(ctx context.Context, tenantCode string) uuid.UUID {
-	dom, err := s.domainSvc.ResolveDomain(ctx, "tenant", tenantCode)
+	dom, err := s.domainSvc.ResolveDomain(ctx, s.cfg.DomainTypeCode, tenantCode)
 	if err != nil {
 		return uuid.Nil
 	}
@@ -755,18 +1252,39 @@ func (s *Service) resolveParentTenantID(ctx context.Context, parentRef string, s
 	}
 	if err != nil {
 		if coreent.IsNotFound(err) {
-			return uuid.Nil, false, shared.ErrParentTenantInvalid
+			return uuid.Nil, false, shared.ErrParentTenantInvalid.With(
+				apierror.Field("parentTenantId", apierror.NotFound, "value", parentRef))
 		}
 		return uuid.Nil, false, fmt.Errorf("resolve parent tenant: %w", err)
 	}
 
 	if selfID != uuid.Nil && parentEntity.ID == selfID {
-		return uuid.Nil, false, shared.ErrParentTenantInvalid
+		return uuid.Nil, false, shared.ErrParentTenantInvalid.With(
+			apierror.Field("parentTenantId", apierror.Invalid, "value", parentRef))
 	}
 
 	return parentEntity.ID, true, nil
 }
 
+// findMembership returns the non-deleted membership of userID in tenantID,
+// whatever its status.
+func (s *Service) findMembership(ctx context.Context, tenantID, userID uuid.UUID) (*coreent.TenantUser, error) {
+	membership, err := s.client.TenantUser.Query().
+		Where(
+			tenantuser.TenantIDEQ(tenantID),
+			tenantuser.UserID(userID),
+			tenantuser.DeletedAtIsNil(),
+		).
+		First(ctx)
+	if err != nil {
+		if coreent.IsNotFound(err) {
+			return nil, shared.ErrMemberNotFound
+		}
+		return nil, fmt.Errorf("get membership: %w", err)
+	}
+	return membership, nil
+}
+
 func (s *Service) ensureMembership(ctx context.Context, tenantID uuid.UUID, userID uuid.UUID, forceDefault bool, roleName string) error {
 	existing, err := s.client.TenantUser.Query().
 		Where(
@@ -0,0 +1,1640 @@
+//go:build integration
+// +build integration
+
+package tenant
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"entgo.io/ent/dialect"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/leeforge/framework/logging"
+	"github.com/leeforge/framework/plugin"
+
+	"github.com/leeforge/core"
+	coremod "github.com/leeforge/core/core"
+	coreent "github.com/leeforge/core/server/ent"
+	"github.com/leeforge/core/server/ent/enttest"
+	"github.com/leeforge/core/server/ent/user"
+
+	"github.com/leeforge/plugins/apierror"
+	"github.com/leeforge/plugins/mergepatch"
+	"github.com/leeforge/plugins/tenant/shared"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// memDomainWriter is an in-memory core.DomainWriter for integration tests.
+type memDomainWriter struct {
+	core.DomainWriter
+
+	mu      sync.Mutex
+	domains map[string]*core.ResolvedDomain
+	members map[[2]uuid.UUID]string
+	// addErr, when set, fails AddMembership.
+	addErr error
+}
+
+func newMemDomainWriter() *memDomainWriter {
+	return &memDomainWriter{
+		domains: make(map[string]*core.ResolvedDomain),
+		members: make(map[[2]uuid.UUID]string),
+	}
+}
+
+func (m *memDomainWriter) ResolveDomain(_ context.Context, typeCode, key string) (*core.ResolvedDomain, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if d, ok := m.domains[typeCode+":"+key]; ok {
+		return d, nil
+	}
+	return nil, shared.ErrTenantNotFound
+}
+
+func (m *memDomainWriter) ResolveDomainByID(_ context.Context, domainID uuid.UUID) (*core.ResolvedDomain, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, d := range m.domains {
+		if d.DomainID == domainID {
+			return d, nil
+		}
+	}
+	return nil, shared.ErrTenantNotFound
+}
+
+func (m *memDomainWriter) EnsureDomain(_ context.Context, typeCode, key, displayName string) (*core.ResolvedDomain, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if d, ok := m.domains[typeCode+":"+key]; ok {
+		return d, nil
+	}
+	d := &core.ResolvedDomain{DomainID: uuid.New(), TypeCode: typeCode, Key: key, DisplayName: displayName}
+	m.domains[typeCode+":"+key] = d
+	return d, nil
+}
+
+func (m *memDomainWriter) CheckMembership(_ context.Context, domainID, subjectID uuid.UUID) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.members[[2]uuid.UUID{domainID, subjectID}]
+	return ok, nil
+}
+
+func (m *memDomainWriter) AddMembership(_ context.Context, domainID, subjectID uuid.UUID, role string, _ bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.addErr != nil {
+		return m.addErr
+	}
+	m.members[[2]uuid.UUID{domainID, subjectID}] = role
+	return nil
+}
+
+func (m *memDomainWriter) RemoveMembership(_ context.Context, domainID, subjectID uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.members, [2]uuid.UUID{domainID, subjectID})
+	return nil
+}
+
+// capturingBus records published events.
+type capturingBus struct {
+	mu     sync.Mutex
+	events []plugin.Event
+}
+
+func (b *capturingBus) Publish(_ context.Context, e plugin.Event) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.events = append(b.events, e)
+	return nil
+}
+
+func (b *capturingBus) Subscribe(string, plugin.EventHandler) plugin.Subscription { return nil }
+func (b *capturingBus) Close() error                                              { return nil }
+
+func (b *capturingBus) named(name string) []plugin.Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var out []plugin.Event
+	for _, e := range b.events {
+		if e.Name == name {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// entUserLookup resolves users from the test database.
+type entUserLookup struct{ client *coreent.Client }
+
+func (l entUserLookup) GetUser(ctx context.Context, userID uuid.UUID) (*shared.UserInfo, error) {
+	u, err := l.client.User.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &shared.UserInfo{ID: u.ID, Username: u.Username, Email: u.Email, Nickname: u.Nickname}, nil
+}
+
+func (l entUserLookup) FindUser(ctx context.Context, username, email string) (*shared.UserInfo, error) {
+	u, err := l.client.User.Query().Where(user.Or(user.Username(username), user.Email(email))).First(ctx)
+	if coreent.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &shared.UserInfo{ID: u.ID, Username: u.Username, Email: u.Email, Nickname: u.Nickname}, nil
+}
+
+type integrationEnv struct {
+	client  *coreent.Client
+	domains *memDomainWriter
+	bus     *capturingBus
+	svc     *Service
+	ctx     context.Context
+}
+
+func newIntegrationEnv(t *testing.T) *integrationEnv {
+	t.Helper()
+	client := enttest.Open(t, dialect.SQLite, "file:"+t.Name()+"?mode=memory&cache=shared&_fk=1")
+	t.Cleanup(func() { _ = client.Close() })
+
+	env := &integrationEnv{
+		client:  client,
+		domains: newMemDomainWriter(),
+		bus:     &capturingBus{},
+	}
+	env.svc = NewService(client, env.domains, env.bus, logging.FromZap(zap.NewNop()), mockRoleSeeder{}, entUserLookup{client: client})
+	env.ctx = coremod.WithActingContext(context.Background(), &coremod.ActingContext{
+		Domain: &coremod.ResolvedDomain{TypeCode: string(coremod.DomainPlatform), Key: "root"},
+	})
+	return env
+}
+
+func (e *integrationEnv) createTenant(t *testing.T, code string) *TenantDTO {
+	t.Helper()
+	dto, err := e.svc.CreateTenant(e.ctx, &CreateRequest{Code: code, Name: code})
+	require.NoError(t, err)
+	return dto
+}
+
+func (e *integrationEnv) createUser(t *testing.T, username string) uuid.UUID {
+	t.Helper()
+	u, err := e.client.User.Create().
+		SetUsername(username).
+		SetEmail(username + "@example.com").
+		Save(e.ctx)
+	require.NoError(t, err)
+	return u.ID
+}
+
+func TestService_TimeBoundMembership_ExpiresAndIsSwept(t *testing.T) {
+	env := newIntegrationEnv(t)
+	acme := env.createTenant(t, "acme")
+	guest := env.createUser(t, "auditor")
+
+	start := time.Now()
+	expiresAt := start.Add(time.Hour)
+	require.NoError(t, env.svc.AddMember(env.ctx, acme.ID, guest, "", MemberTerms{
+		Type:      shared.MembershipTypeGuest,
+		ExpiresAt: &expiresAt,
+	}))
+
+	ok, err := env.svc.IsMember(env.ctx, acme.ID, guest)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	members, err := env.svc.ListMembers(env.ctx, acme.ID, MemberListFilters{})
+	require.NoError(t, err)
+	require.Len(t, members.Members, 1)
+	require.Equal(t, shared.MembershipTypeGuest, members.Members[0].MembershipType)
+	require.False(t, members.Members[0].Expired)
+
+	// After expiry the membership no longer counts, even before the sweep.
+	env.svc.now = func() time.Time { return start.Add(2 * time.Hour) }
+	ok, err = env.svc.IsMember(env.ctx, acme.ID, guest)
+	require.NoError(t, err)
+	require.False(t, ok)
+	ok, err = env.svc.CheckDomainMembership(env.ctx, acme.DomainID, guest)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	members, err = env.svc.ListMembers(env.ctx, acme.ID, MemberListFilters{})
+	require.NoError(t, err)
+	require.True(t, members.Members[0].Expired)
+
+	removed, err := env.svc.SweepExpiredMembers(env.ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, removed)
+
+	members, err = env.svc.ListMembers(env.ctx, acme.ID, MemberListFilters{})
+	require.NoError(t, err)
+	require.Empty(t, members.Members)
+	ok, err = env.domains.CheckMembership(env.ctx, acme.DomainID, guest)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	expired := env.bus.named(shared.EventTenantMemberExpired)
+	require.Len(t, expired, 1)
+	require.Equal(t, guest, expired[0].Data.(shared.MemberEventData).UserID)
+
+	removed, err = env.svc.SweepExpiredMembers(env.ctx)
+	require.NoError(t, err)
+	require.Zero(t, removed)
+}
+
+func TestService_TimeBoundMembership_NotYetValid(t *testing.T) {
+	env := newIntegrationEnv(t)
+	acme := env.createTenant(t, "acme")
+	user := env.createUser(t, "contractor")
+
+	validFrom := time.Now().Add(24 * time.Hour)
+	require.NoError(t, env.svc.AddMember(env.ctx, acme.ID, user, "", MemberTerms{ValidFrom: &validFrom}))
+
+	ok, err := env.svc.IsMember(env.ctx, acme.ID, user)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	// Re-adding without terms turns it into a standard membership.
+	require.NoError(t, env.svc.AddMember(env.ctx, acme.ID, user, "", MemberTerms{}))
+	ok, err = env.svc.IsMember(env.ctx, acme.ID, user)
+	require.NoError(t, err)
+	require.True(t, ok)
+}
+
+func TestService_AddMember_RejectsInvalidTerms(t *testing.T) {
+	env := newIntegrationEnv(t)
+	acme := env.createTenant(t, "acme")
+	user := env.createUser(t, "someone")
+
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+	later := future.Add(time.Hour)
+	for name, terms := range map[string]MemberTerms{
+		"expired":        {ExpiresAt: &past},
+		"unknown type":   {Type: "vip"},
+		"inverted range": {ValidFrom: &later, ExpiresAt: &future},
+	} {
+		err := env.svc.AddMember(env.ctx, acme.ID, user, "", terms)
+		require.ErrorIs(t, err, shared.ErrInvalidMemberTerm, name)
+	}
+}
+
+func TestService_SuspendAndReactivateMember(t *testing.T) {
+	env := newIntegrationEnv(t)
+	acme := env.createTenant(t, "acme")
+	user := env.createUser(t, "operator")
+
+	require.NoError(t, env.svc.AddMember(env.ctx, acme.ID, user, "admin", MemberTerms{}))
+
+	require.NoError(t, env.svc.SuspendMember(env.ctx, acme.ID, user, " policy review "))
+	ok, err := env.svc.IsMember(env.ctx, acme.ID, user)
+	require.NoError(t, err)
+	require.False(t, ok)
+	ok, err = env.domains.CheckMembership(env.ctx, acme.DomainID, user)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	require.ErrorIs(t, env.svc.SuspendMember(env.ctx, acme.ID, user, ""), shared.ErrMemberSuspended)
+	require.ErrorIs(t, env.svc.AddMember(env.ctx, acme.ID, user, "", MemberTerms{}), shared.ErrMemberSuspended)
+
+	// The membership row keeps its role and default flag while suspended.
+	membership, err := env.svc.findMembership(env.ctx, acme.ID, user)
+	require.NoError(t, err)
+	require.Equal(t, "admin", membership.Role)
+	require.True(t, membership.IsDefault)
+
+	require.NoError(t, env.svc.ReactivateMember(env.ctx, acme.ID, user))
+	ok, err = env.svc.IsMember(env.ctx, acme.ID, user)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "admin", env.domains.members[[2]uuid.UUID{acme.DomainID, user}])
+
+	require.ErrorIs(t, env.svc.ReactivateMember(env.ctx, acme.ID, user), shared.ErrMemberNotSuspended)
+	require.ErrorIs(t, env.svc.SuspendMember(env.ctx, acme.ID, uuid.New(), ""), shared.ErrMemberNotFound)
+
+	suspended := env.bus.named(shared.EventTenantMemberSuspended)
+	require.Len(t, suspended, 1)
+	data := suspended[0].Data.(shared.MemberEventData)
+	require.Equal(t, user, data.UserID)
+	require.Equal(t, "admin", data.Role)
+	require.Equal(t, "policy review", data.Reason)
+	require.Len(t, env.bus.named(shared.EventTenantMemberReactivated), 1)
+}
+
+func TestService_ListMembers_FiltersSortsAndFacets(t *testing.T) {
+	env := newIntegrationEnv(t)
+	acme := env.createTenant(t, "acme")
+
+	users := map[string]uuid.UUID{}
+	for _, m := range []struct{ name, role string }{
+		{"alice", "admin"},
+		{"bob", "member"},
+		{"carol", "member"},
+		{"dave", "viewer"},
+	} {
+		users[m.name] = env.createUser(t, m.name)
+		require.NoError(t, env.svc.AddMember(env.ctx, acme.ID, users[m.name], m.role, MemberTerms{}))
+	}
+	require.NoError(t, env.svc.SuspendMember(env.ctx, acme.ID, users["carol"], ""))
+
+	usernames := func(res *MemberListResult) []string {
+		out := make([]string, 0, len(res.Members))
+		for _, m := range res.Members {
+			out = append(out, m.Username)
+		}
+		return out
+	}
+
+	res, err := env.svc.ListMembers(env.ctx, acme.ID, MemberListFilters{Sort: MemberSortUsername, Order: "asc"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"alice", "bob", "dave"}, usernames(res))
+	require.Equal(t, map[string]int{"admin": 1, "member": 1, "viewer": 1}, res.Facets.Roles)
+	require.Equal(t, map[string]int{MemberStatusActive: 3, MemberStatusSuspended: 1}, res.Facets.Statuses)
+
+	res, err = env.svc.ListMembers(env.ctx, acme.ID, MemberListFilters{Status: MemberStatusAll, Roles: []string{"member"}, Sort: MemberSortUsername, Order: "desc"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"carol", "bob"}, usernames(res))
+	require.Equal(t, MemberStatusSuspended, res.Members[0].MembershipStatus)
+	require.Equal(t, map[string]int{"admin": 1, "member": 2, "viewer": 1}, res.Facets.Roles)
+	require.Equal(t, map[string]int{MemberStatusActive: 1, MemberStatusSuspended: 1}, res.Facets.Statuses)
+
+	res, err = env.svc.ListMembers(env.ctx, acme.ID, MemberListFilters{Query: "DAVE@example"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"dave"}, usernames(res))
+	require.Equal(t, 1, res.Total)
+
+	future := time.Now().Add(time.Hour)
+	res, err = env.svc.ListMembers(env.ctx, acme.ID, MemberListFilters{JoinedAfter: &future})
+	require.NoError(t, err)
+	require.Empty(t, res.Members)
+
+	for name, filters := range map[string]MemberListFilters{
+		"status": {Status: "banned"},
+		"sort":   {Sort: "password"},
+		"order":  {Order: "sideways"},
+		"range":  {JoinedAfter: &future, JoinedBefore: ptrTime(future.Add(-time.Minute))},
+	} {
+		_, err := env.svc.ListMembers(env.ctx, acme.ID, filters)
+		require.ErrorIs(t, err, shared.ErrInvalidMemberFilter, name)
+	}
+}
+
+func ptrTime(t time.Time) *time.Time { return &t }
+
+// staticPermissions resolves role permissions from a fixed table.
+type staticPermissions map[string][]string
+
+func (p staticPermissions) RolePermissions(_ context.Context, _ uuid.UUID, role string) ([]string, error) {
+	return p[role], nil
+}
+
+func TestService_ListMyTenants_DetailsAndStaleTenants(t *testing.T) {
+	env := newIntegrationEnv(t)
+	env.svc.SetPermissionResolver(staticPermissions{"admin": {"tenant:read", "tenant:write"}})
+
+	parent, err := env.svc.CreateTenant(env.ctx, &CreateRequest{Code: "holding", Name: "Holding"})
+	require.NoError(t, err)
+	child, err := env.svc.CreateTenant(env.ctx, &CreateRequest{Code: "acme", Name: "Acme", ParentTenantID: parent.ID.String()})
+	require.NoError(t, err)
+	frozen := env.createTenant(t, "frozen")
+	gone := env.createTenant(t, "gone")
+
+	user := env.createUser(t, "switcher")
+	for _, id := range []uuid.UUID{child.ID, frozen.ID, gone.ID} {
+		require.NoError(t, env.svc.AddMember(env.ctx, id, user, "admin", MemberTerms{}))
+	}
+	require.NoError(t, env.client.Tenant.UpdateOneID(frozen.ID).SetStatus("inactive").Exec(env.ctx))
+	require.NoError(t, env.client.Tenant.UpdateOneID(gone.ID).SetDeletedAt(time.Now()).Exec(env.ctx))
+
+	res, err := env.svc.ListMyTenants(env.ctx, user)
+	require.NoError(t, err)
+	byCode := map[string]*MyTenantDTO{}
+	for _, dto := range res.Tenants {
+		byCode[dto.Code] = dto
+	}
+	require.Len(t, byCode, 2)
+	require.NotContains(t, byCode, "gone")
+	require.True(t, byCode["frozen"].Suspended)
+
+	acme := byCode["acme"]
+	require.False(t, acme.Suspended)
+	require.Equal(t, child.DomainID, acme.DomainID)
+	require.NotNil(t, acme.Parent)
+	require.Equal(t, "holding", acme.Parent.Code)
+	require.Equal(t, []string{"tenant:read", "tenant:write"}, acme.Permissions)
+
+	one, err := env.svc.GetMyTenant(env.ctx, user, child.ID)
+	require.NoError(t, err)
+	require.Equal(t, "admin", one.Role)
+	require.Equal(t, shared.MembershipTypeStandard, one.MembershipType)
+
+	_, err = env.svc.GetMyTenant(env.ctx, user, gone.ID)
+	require.ErrorIs(t, err, shared.ErrTenantNotFound)
+	_, err = env.svc.GetMyTenant(env.ctx, user, parent.ID)
+	require.ErrorIs(t, err, shared.ErrMemberNotFound)
+
+	require.NoError(t, env.svc.SuspendMember(env.ctx, child.ID, user, ""))
+	_, err = env.svc.GetMyTenant(env.ctx, user, child.ID)
+	require.ErrorIs(t, err, shared.ErrMemberNotFound)
+}
+
+// recordingSeeder records the templates provisioned into each domain.
+type recordingSeeder struct {
+	mu    sync.Mutex
+	seeds map[uuid.UUID][]shared.RoleTemplate
+}
+
+func (r *recordingSeeder) SeedRoles(_ context.Context, domainID uuid.UUID, templates []shared.RoleTemplate) (shared.RoleSyncResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.seeds == nil {
+		r.seeds = make(map[uuid.UUID][]shared.RoleTemplate)
+	}
+	res := shared.RoleSyncResult{}
+	for _, tpl := range templates {
+		if _, ok := r.seeds[domainID]; ok {
+			res.Unchanged = append(res.Unchanged, tpl.Code)
+		} else {
+			res.Created = append(res.Created, tpl.Code)
+		}
+	}
+	r.seeds[domainID] = templates
+	return res, nil
+}
+
+func (r *recordingSeeder) SeedBaselineRoles(context.Context, uuid.UUID) error { return nil }
+
+// baselineSeeder only seeds baseline roles, counting the domains seeded.
+type baselineSeeder struct {
+	seeded atomic.Int32
+}
+
+func (b *baselineSeeder) SeedBaselineRoles(context.Context, uuid.UUID) error {
+	b.seeded.Add(1)
+	return nil
+}
+
+func TestService_RoleSeeder_FallsBackToBaselineRoles(t *testing.T) {
+	env := newIntegrationEnv(t)
+	seeder := &baselineSeeder{}
+	env.svc.roleSeeder = seeder
+
+	owner := env.createUser(t, "founder")
+	ctx := core.WithIdentity(env.ctx, core.Identity{UserID: owner})
+	_, err := env.svc.CreateTenant(ctx, &CreateRequest{Code: "acme", Name: "Acme"})
+	require.NoError(t, err)
+	require.EqualValues(t, 1, seeder.seeded.Load())
+
+	reports, err := env.svc.SyncAllRoleTemplates(env.ctx)
+	require.NoError(t, err)
+	require.Len(t, reports, 1)
+	require.Empty(t, reports[0].Created)
+	require.EqualValues(t, 2, seeder.seeded.Load())
+}
+
+// planTemplates gives tenants whose code starts with "pro-" their own roles.
+type planTemplates struct{}
+
+func (planTemplates) RoleTemplates(_ context.Context, _ uuid.UUID, code string) ([]shared.RoleTemplate, error) {
+	if len(code) < 4 || code[:4] != "pro-" {
+		return nil, nil
+	}
+	return []shared.RoleTemplate{
+		{Code: "pro_owner", Name: "Pro Owner", Owner: true},
+		{Code: "member", Name: "Member"},
+	}, nil
+}
+
+func TestService_RoleTemplates_ProvisionAndSync(t *testing.T) {
+	env := newIntegrationEnv(t)
+	seeder := &recordingSeeder{}
+	env.svc.roleSeeder = seeder
+	env.svc.SetRoleTemplateSource(planTemplates{})
+
+	owner := env.createUser(t, "founder")
+	ctx := core.WithIdentity(env.ctx, core.Identity{UserID: owner})
+
+	basic, err := env.svc.CreateTenant(ctx, &CreateRequest{Code: "basic", Name: "Basic"})
+	require.NoError(t, err)
+	pro, err := env.svc.CreateTenant(ctx, &CreateRequest{Code: "pro-acme", Name: "Pro"})
+	require.NoError(t, err)
+
+	require.Equal(t, shared.DefaultConfig().RoleTemplates, seeder.seeds[basic.DomainID])
+	require.Equal(t, "pro_owner", seeder.seeds[pro.DomainID][0].Code)
+
+	one, err := env.svc.GetMyTenant(ctx, owner, pro.ID)
+	require.NoError(t, err)
+	require.Equal(t, "pro_owner", one.Role)
+	one, err = env.svc.GetMyTenant(ctx, owner, basic.ID)
+	require.NoError(t, err)
+	require.Equal(t, "tenant_admin", one.Role)
+
+	report, err := env.svc.SyncRoleTemplates(env.ctx, pro.ID)
+	require.NoError(t, err)
+	require.Equal(t, "pro-acme", report.TenantCode)
+	require.Equal(t, []string{"pro_owner", "member"}, report.Unchanged)
+
+	reports, err := env.svc.SyncAllRoleTemplates(env.ctx)
+	require.NoError(t, err)
+	require.Len(t, reports, 2)
+	require.Equal(t, "basic", reports[0].TenantCode)
+
+	_, err = env.svc.SyncRoleTemplates(env.ctx, uuid.New())
+	require.ErrorIs(t, err, shared.ErrTenantNotFound)
+}
+
+// staticCatalog lists the same roles for every domain.
+type staticCatalog []shared.RoleInfo
+
+func (c staticCatalog) ListRoles(context.Context, uuid.UUID) ([]shared.RoleInfo, error) {
+	return c, nil
+}
+
+func (staticCatalog) CreateRole(context.Context, uuid.UUID, shared.RoleInfo) error {
+	return errors.New("static catalog is read-only")
+}
+
+func TestService_AddMember_ValidatesRoleAgainstCatalog(t *testing.T) {
+	env := newIntegrationEnv(t)
+	env.svc.SetRoleCatalog(staticCatalog{
+		{Code: "tenant_admin", Name: "Owner", IsSystem: true},
+		{Code: "member", Name: "Member", IsSystem: true},
+		{Code: "admin", Name: "Admin", Permissions: []string{"tenant:*"}},
+	})
+	acme := env.createTenant(t, "acme")
+	user := env.createUser(t, "typo")
+
+	err := env.svc.AddMember(env.ctx, acme.ID, user, "admni", MemberTerms{})
+	require.ErrorIs(t, err, shared.ErrInvalidRole)
+	require.Contains(t, err.Error(), `"admni"`)
+	require.Contains(t, err.Error(), "admin")
+	ok, err := env.svc.IsMember(env.ctx, acme.ID, user)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	require.NoError(t, env.svc.AddMember(env.ctx, acme.ID, user, "admin", MemberTerms{}))
+	other := env.createUser(t, "plain")
+	require.NoError(t, env.svc.AddMember(env.ctx, acme.ID, other, "", MemberTerms{}))
+
+	roles, err := env.svc.ListRoles(env.ctx, acme.ID)
+	require.NoError(t, err)
+	require.Len(t, roles.Roles, 3)
+	require.Equal(t, "admin", roles.Roles[2].Code)
+
+	_, err = env.svc.ListRoles(env.ctx, uuid.New())
+	require.ErrorIs(t, err, shared.ErrTenantNotFound)
+}
+
+// memCatalog keeps the roles of each domain in memory.
+type memCatalog struct {
+	mu    sync.Mutex
+	roles map[uuid.UUID][]shared.RoleInfo
+}
+
+func (c *memCatalog) ListRoles(_ context.Context, domainID uuid.UUID) ([]shared.RoleInfo, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]shared.RoleInfo(nil), c.roles[domainID]...), nil
+}
+
+func (c *memCatalog) CreateRole(_ context.Context, domainID uuid.UUID, role shared.RoleInfo) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	role.IsSystem = false
+	c.roles[domainID] = append(c.roles[domainID], role)
+	return nil
+}
+
+// failingCatalog lists roles but cannot create them.
+type failingCatalog struct{ *memCatalog }
+
+func (failingCatalog) CreateRole(context.Context, uuid.UUID, shared.RoleInfo) error {
+	return errors.New("catalog is read-only")
+}
+
+// recordingCloner records the organization trees it was asked to copy.
+type recordingCloner struct {
+	copies [][2]uuid.UUID
+}
+
+func (c *recordingCloner) CloneOrganizationTree(_ context.Context, from, to uuid.UUID) (int, error) {
+	c.copies = append(c.copies, [2]uuid.UUID{from, to})
+	return 4, nil
+}
+
+func TestService_CloneTenant_CopiesSettingsRolesAndOrganizations(t *testing.T) {
+	env := newIntegrationEnv(t)
+	catalog := &memCatalog{roles: make(map[uuid.UUID][]shared.RoleInfo)}
+	env.svc.SetRoleCatalog(catalog)
+	orgs := &recordingCloner{}
+	env.svc.SetOrganizationCloner(orgs)
+
+	parent := env.createTenant(t, "holding")
+	src, err := env.svc.CreateTenant(env.ctx, &CreateRequest{
+		Code: "demo", Name: "Demo", Description: "Sales demo", ParentTenantID: parent.Code,
+	})
+	require.NoError(t, err)
+	catalog.roles[src.DomainID] = []shared.RoleInfo{
+		{Code: "tenant_admin", Name: "Owner", IsSystem: true},
+		{Code: "sales", Name: "Sales", Permissions: []string{"crm:*"}},
+		{Code: "viewer", Name: "Viewer", Permissions: []string{"crm:read"}},
+	}
+
+	seller := env.createUser(t, "seller")
+	include := true
+	result, err := env.svc.CloneTenant(env.ctx, src.ID, &CloneRequest{
+		Code: "acme-demo", Name: "Acme Demo", OwnerID: seller.String(), IncludeOrganizations: &include,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "acme-demo", result.Tenant.Code)
+	require.Equal(t, "Sales demo", result.Tenant.Description)
+	require.Equal(t, parent.ID, *result.Tenant.ParentTenantID)
+	require.Equal(t, seller, *result.Tenant.OwnerID)
+	require.Equal(t, []ProvisionStep{
+		{Name: StepCreateTenant, Status: StepStatusDone, Count: 1},
+		{Name: StepCopyRoles, Status: StepStatusDone, Count: 2},
+		{Name: StepCopyOrganizations, Status: StepStatusDone, Count: 4},
+	}, result.Steps)
+
+	roles, err := catalog.ListRoles(env.ctx, result.Tenant.DomainID)
+	require.NoError(t, err)
+	require.Len(t, roles, 2)
+	require.Equal(t, []string{"crm:*"}, roles[0].Permissions)
+	require.Equal(t, [][2]uuid.UUID{{src.DomainID, result.Tenant.DomainID}}, orgs.copies)
+
+	mine, err := env.svc.GetMyTenant(env.ctx, seller, result.Tenant.ID)
+	require.NoError(t, err)
+	require.Equal(t, "tenant_admin", mine.Role)
+
+	require.Len(t, env.bus.named(shared.EventTenantCreated), 3)
+	require.Len(t, env.bus.named(shared.EventTenantProvisionProgress), 3)
+	cloned := env.bus.named(shared.EventTenantCloned)
+	require.Len(t, cloned, 1)
+	require.Equal(t, "demo", cloned[0].Data.(shared.CloneEventData).SourceTenantCode)
+
+	_, err = env.svc.CloneTenant(env.ctx, src.ID, &CloneRequest{Code: "acme-demo", Name: "Again"})
+	require.ErrorIs(t, err, shared.ErrTenantCodeExists)
+	_, err = env.svc.CloneTenant(env.ctx, uuid.New(), &CloneRequest{Code: "x", Name: "X"})
+	require.ErrorIs(t, err, shared.ErrTenantNotFound)
+	_, err = env.svc.CloneTenant(env.ctx, src.ID, &CloneRequest{Code: "y", Name: "Y", OwnerID: "nope"})
+	require.ErrorIs(t, err, shared.ErrInvalidTenant)
+}
+
+func TestService_CloneTenantTemplate(t *testing.T) {
+	env := newIntegrationEnv(t)
+	cfg := shared.DefaultConfig()
+	cfg.TenantTemplates = []shared.TenantTemplate{
+		{Name: "sales-demo", SourceTenant: "demo", IncludeOrganizations: true},
+		{Name: "gone", SourceTenant: "missing"},
+	}
+	env.svc.SetConfig(cfg)
+	src := env.createTenant(t, "demo")
+
+	// Without an organization cloner the step is skipped, not failed.
+	result, err := env.svc.CloneTenantTemplate(env.ctx, "sales-demo", &CloneRequest{Code: "beta", Name: "Beta"})
+	require.NoError(t, err)
+	require.Equal(t, "sales-demo", result.Template)
+	require.Equal(t, src.ID, result.SourceTenantID)
+	require.Equal(t, StepStatusSkipped, result.Steps[1].Status)
+	require.Equal(t, StepStatusSkipped, result.Steps[2].Status)
+	require.Contains(t, result.Steps[2].Error, "not available")
+
+	orgs := &recordingCloner{}
+	env.svc.SetOrganizationCloner(orgs)
+	exclude := false
+	result, err = env.svc.CloneTenantTemplate(env.ctx, "sales-demo", &CloneRequest{
+		Code: "gamma", Name: "Gamma", IncludeOrganizations: &exclude,
+	})
+	require.NoError(t, err)
+	require.Equal(t, StepStatusSkipped, result.Steps[2].Status)
+	require.Empty(t, orgs.copies)
+
+	_, err = env.svc.CloneTenantTemplate(env.ctx, "nope", &CloneRequest{Code: "z", Name: "Z"})
+	require.ErrorIs(t, err, shared.ErrTenantTemplateNotFound)
+	_, err = env.svc.CloneTenantTemplate(env.ctx, "gone", &CloneRequest{Code: "z", Name: "Z"})
+	require.ErrorIs(t, err, shared.ErrTenantNotFound)
+
+	// A failing step keeps the tenant and reports the partial result.
+	catalog := failingCatalog{&memCatalog{roles: map[uuid.UUID][]shared.RoleInfo{
+		src.DomainID: {{Code: "sales", Name: "Sales"}},
+	}}}
+	env.svc.SetRoleCatalog(catalog)
+	result, err = env.svc.CloneTenantTemplate(env.ctx, "sales-demo", &CloneRequest{Code: "delta", Name: "Delta"})
+	require.ErrorIs(t, err, shared.ErrProvisioningFailed)
+	require.Len(t, result.Steps, 2)
+	require.Equal(t, StepStatusFailed, result.Steps[1].Status)
+	_, err = env.svc.GetTenantByCode(env.ctx, "delta")
+	require.NoError(t, err)
+}
+
+// SeedRoles lets memCatalog stand in for the role seeder, so that provisioned
+// roles show up in the catalog.
+func (c *memCatalog) SeedRoles(_ context.Context, domainID uuid.UUID, templates []shared.RoleTemplate) (shared.RoleSyncResult, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var result shared.RoleSyncResult
+	for _, tpl := range templates {
+		c.roles[domainID] = append(c.roles[domainID], shared.RoleInfo{
+			Code: tpl.Code, Name: tpl.Name, Permissions: tpl.Permissions, IsSystem: true,
+		})
+		result.Created = append(result.Created, tpl.Code)
+	}
+	return result, nil
+}
+
+func (c *memCatalog) SeedBaselineRoles(context.Context, uuid.UUID) error { return nil }
+
+// memArchiver keeps the organizations of each domain in memory.
+type memArchiver struct {
+	orgs map[uuid.UUID][]shared.OrganizationRecord
+}
+
+func (a *memArchiver) ExportOrganizations(_ context.Context, domainID uuid.UUID) ([]shared.OrganizationRecord, error) {
+	return a.orgs[domainID], nil
+}
+
+func (a *memArchiver) ImportOrganizations(_ context.Context, domainID uuid.UUID, orgs []shared.OrganizationRecord) (int, error) {
+	created := 0
+	for _, o := range orgs {
+		found := false
+		for _, have := range a.orgs[domainID] {
+			found = found || have.Code == o.Code
+		}
+		if !found {
+			a.orgs[domainID] = append(a.orgs[domainID], o)
+			created++
+		}
+	}
+	return created, nil
+}
+
+// brokenArchiver fails every organization import.
+type brokenArchiver struct{ memArchiver }
+
+func (a *brokenArchiver) ImportOrganizations(context.Context, uuid.UUID, []shared.OrganizationRecord) (int, error) {
+	return 0, errors.New("organization service unavailable")
+}
+
+func TestService_ImportTenant_RollsBackCreatedTenant(t *testing.T) {
+	env := newIntegrationEnv(t)
+	catalog := &memCatalog{roles: make(map[uuid.UUID][]shared.RoleInfo)}
+	env.svc.roleSeeder = catalog
+	env.svc.SetRoleCatalog(catalog)
+	env.svc.SetOrganizationArchiver(&brokenArchiver{memArchiver{orgs: make(map[uuid.UUID][]shared.OrganizationRecord)}})
+	alice := env.createUser(t, "alice")
+	bob := env.createUser(t, "bob")
+	ctx := core.WithIdentity(env.ctx, core.Identity{UserID: alice})
+
+	archive := &TenantArchive{
+		Version:       ArchiveVersion,
+		Tenant:        ArchivedTenant{Code: "acme", Name: "Acme", Labels: map[string]string{"env": "prod"}},
+		Roles:         []shared.RoleInfo{{Code: "auditor", Name: "Auditor"}},
+		Members:       []ArchivedMember{{ArchivedUser: ArchivedUser{Username: "bob"}, Role: "auditor"}},
+		Organizations: []ArchivedOrganization{{Code: "hq", Name: "HQ", Path: "hq"}},
+	}
+
+	// Invalid items are rejected before anything is applied.
+	invalid := *archive
+	invalid.Members = []ArchivedMember{{ArchivedUser: ArchivedUser{Username: "bob"}, Role: "auditor", MembershipType: "contractor"}}
+	_, err := env.svc.ImportTenant(ctx, &invalid, ImportOptions{})
+	require.ErrorIs(t, err, shared.ErrInvalidArchive)
+	require.ErrorIs(t, err, shared.ErrInvalidMemberTerm)
+	_, err = env.svc.GetTenantByCode(ctx, "acme")
+	require.ErrorIs(t, err, shared.ErrTenantNotFound)
+
+	report, err := env.svc.ImportTenant(ctx, archive, ImportOptions{})
+	require.ErrorIs(t, err, shared.ErrProvisioningFailed)
+	require.Nil(t, report.TenantID)
+	_, err = env.svc.GetTenantByCode(ctx, "acme")
+	require.ErrorIs(t, err, shared.ErrTenantNotFound)
+	require.Len(t, env.bus.named(shared.EventTenantDeleted), 1)
+	domainID := env.bus.named(shared.EventTenantDeleted)[0].Data.(shared.TenantEventData).DomainID
+	ok, err := env.domains.CheckMembership(env.ctx, domainID, bob)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	// Importing again reuses the domain and the roles created for it.
+	env.svc.SetOrganizationArchiver(&memArchiver{orgs: make(map[uuid.UUID][]shared.OrganizationRecord)})
+	report, err = env.svc.ImportTenant(ctx, archive, ImportOptions{})
+	require.NoError(t, err)
+	require.Equal(t, ImportUnchanged, changeOf(report, ImportKindRole, "auditor").Action)
+	imported, err := env.svc.GetTenant(ctx, *report.TenantID)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"env": "prod"}, imported.Labels)
+	member, err := env.svc.IsMember(env.ctx, imported.ID, bob)
+	require.NoError(t, err)
+	require.True(t, member)
+}
+
+func changeOf(report *ImportReport, kind, key string) ImportChange {
+	for _, c := range report.Changes {
+		if c.Kind == kind && c.Key == key {
+			return c
+		}
+	}
+	return ImportChange{}
+}
+
+func TestService_ExportImportTenant(t *testing.T) {
+	env := newIntegrationEnv(t)
+	catalog := &memCatalog{roles: make(map[uuid.UUID][]shared.RoleInfo)}
+	env.svc.roleSeeder = catalog
+	env.svc.SetRoleCatalog(catalog)
+	archiver := &memArchiver{orgs: make(map[uuid.UUID][]shared.OrganizationRecord)}
+	env.svc.SetOrganizationArchiver(archiver)
+
+	alice := env.createUser(t, "alice")
+	bob := env.createUser(t, "bob")
+	carol := env.createUser(t, "carol")
+	ctx := core.WithIdentity(env.ctx, core.Identity{UserID: alice})
+
+	src, err := env.svc.CreateTenant(ctx, &CreateRequest{Code: "acme", Name: "Acme", Description: "Production"})
+	require.NoError(t, err)
+	require.NoError(t, catalog.CreateRole(ctx, src.DomainID, shared.RoleInfo{Code: "auditor", Name: "Auditor", Permissions: []string{"audit:read"}}))
+	expires := time.Now().Add(48 * time.Hour).UTC().Truncate(time.Second)
+	require.NoError(t, env.svc.AddMember(ctx, src.ID, bob, "member", MemberTerms{}))
+	require.NoError(t, env.svc.SuspendMember(ctx, src.ID, bob, "leave"))
+	require.NoError(t, env.svc.AddMember(ctx, src.ID, carol, "auditor", MemberTerms{Type: shared.MembershipTypeGuest, ExpiresAt: &expires}))
+	archiver.orgs[src.DomainID] = []shared.OrganizationRecord{
+		{Code: "hq", Name: "HQ", Path: "hq", Members: []shared.OrganizationMemberRecord{{UserID: alice, IsPrimary: true}}},
+		{Code: "audit", Name: "Audit", Path: "hq/audit", Members: []shared.OrganizationMemberRecord{{UserID: carol}}},
+	}
+
+	archive, err := env.svc.ExportTenant(ctx, src.ID)
+	require.NoError(t, err)
+	require.Equal(t, ArchiveVersion, archive.Version)
+	require.Equal(t, "Production", archive.Tenant.Description)
+	require.Equal(t, &ArchivedUser{Username: "alice", Email: "alice@example.com"}, archive.Tenant.Owner)
+	require.Len(t, archive.Roles, 3)
+	require.Len(t, archive.Members, 3)
+	require.Equal(t, MemberStatusSuspended, archive.Members[1].MembershipStatus)
+	require.Equal(t, shared.MembershipTypeGuest, archive.Members[2].MembershipType)
+	require.Len(t, archive.Organizations, 2)
+	require.Equal(t, "carol", archive.Organizations[1].Members[0].Username)
+
+	// The tar encoding carries the same archive as the JSON one.
+	var jsonBuf, tarBuf bytes.Buffer
+	require.NoError(t, WriteArchive(&jsonBuf, archive, ArchiveFormatJSON))
+	require.NoError(t, WriteArchive(&tarBuf, archive, ArchiveFormatTar))
+	fromTar, err := ReadArchive(&tarBuf, ArchiveFormatTar)
+	require.NoError(t, err)
+	var roundTrip bytes.Buffer
+	require.NoError(t, WriteArchive(&roundTrip, fromTar, ArchiveFormatJSON))
+	require.JSONEq(t, jsonBuf.String(), roundTrip.String())
+
+	archive.Members = append(archive.Members, ArchivedMember{
+		ArchivedUser: ArchivedUser{Username: "ghost", Email: "ghost@elsewhere.test"}, Role: "member",
+	})
+
+	_, err = env.svc.ImportTenant(ctx, archive, ImportOptions{})
+	require.ErrorIs(t, err, shared.ErrTenantCodeExists)
+
+	// A dry run reports the diff and changes nothing.
+	report, err := env.svc.ImportTenant(ctx, archive, ImportOptions{OnConflict: ConflictRename, DryRun: true})
+	require.NoError(t, err)
+	require.True(t, report.DryRun)
+	require.Equal(t, ImportCreate, report.Action)
+	require.Equal(t, "acme-2", report.TenantCode)
+	require.Nil(t, report.TenantID)
+	require.Equal(t, ImportCreate, changeOf(report, ImportKindRole, "auditor").Action)
+	require.Equal(t, ImportUnchanged, changeOf(report, ImportKindRole, "member").Action)
+	require.Equal(t, ImportUnchanged, changeOf(report, ImportKindMember, "alice").Action)
+	require.Equal(t, ImportCreate, changeOf(report, ImportKindMember, "bob").Action)
+	require.Equal(t, ImportSkip, changeOf(report, ImportKindMember, "ghost").Action)
+	require.Equal(t, ImportCreate, changeOf(report, ImportKindOrganization, "hq/audit").Action)
+	_, err = env.svc.GetTenantByCode(ctx, "acme-2")
+	require.ErrorIs(t, err, shared.ErrTenantNotFound)
+
+	report, err = env.svc.ImportTenant(ctx, archive, ImportOptions{OnConflict: ConflictRename, Name: "Acme Staging"})
+	require.NoError(t, err)
+	require.NotNil(t, report.TenantID)
+	imported, err := env.svc.GetTenant(ctx, *report.TenantID)
+	require.NoError(t, err)
+	require.Equal(t, "acme-2", imported.Code)
+	require.Equal(t, "Acme Staging", imported.Name)
+	require.Equal(t, alice, *imported.OwnerID)
+
+	members, err := env.svc.ListMembers(ctx, imported.ID, MemberListFilters{Status: MemberStatusAll})
+	require.NoError(t, err)
+	require.Equal(t, 3, members.Total)
+	for _, m := range members.Members {
+		switch m.ID {
+		case bob:
+			require.Equal(t, MemberStatusSuspended, m.MembershipStatus)
+		case carol:
+			require.Equal(t, "auditor", m.Role)
+			require.Equal(t, expires, m.ExpiresAt.UTC())
+		}
+	}
+	orgs := archiver.orgs[imported.DomainID]
+	require.Len(t, orgs, 2)
+	require.Equal(t, carol, orgs[1].Members[0].UserID)
+	imports := env.bus.named(shared.EventTenantImported)
+	require.Len(t, imports, 1)
+	require.Equal(t, imported.ID, imports[0].Data.(shared.TenantEventData).TenantID)
+
+	// Merging into the source only updates what differs.
+	archive.Tenant.Name = "Acme Corp"
+	report, err = env.svc.ImportTenant(ctx, archive, ImportOptions{OnConflict: ConflictMerge})
+	require.NoError(t, err)
+	require.Equal(t, ImportMerge, report.Action)
+	require.Equal(t, src.ID, *report.TenantID)
+	require.Equal(t, ImportChange{Kind: ImportKindTenant, Key: "acme", Action: ImportUpdate, Detail: "name"},
+		changeOf(report, ImportKindTenant, "acme"))
+	require.Equal(t, ImportUnchanged, changeOf(report, ImportKindMember, "carol").Action)
+	require.Equal(t, ImportUnchanged, changeOf(report, ImportKindOrganization, "hq").Action)
+	merged, err := env.svc.GetTenant(ctx, src.ID)
+	require.NoError(t, err)
+	require.Equal(t, "Acme Corp", merged.Name)
+
+	archive.Version = 99
+	_, err = env.svc.ImportTenant(ctx, archive, ImportOptions{})
+	require.ErrorIs(t, err, shared.ErrInvalidArchive)
+	_, err = env.svc.ImportTenant(ctx, &TenantArchive{Version: ArchiveVersion, Tenant: ArchivedTenant{Code: "x", Name: "X"}},
+		ImportOptions{OnConflict: "replace"})
+	require.ErrorIs(t, err, shared.ErrInvalidArchive)
+}
+
+// getOnlyLookup is a UserLookup that cannot find users by name.
+type getOnlyLookup struct{ shared.UserLookup }
+
+func TestService_ImportTenant_WithoutUserFinder(t *testing.T) {
+	env := newIntegrationEnv(t)
+	env.svc.userLookup = getOnlyLookup{env.svc.userLookup}
+	alice := env.createUser(t, "alice")
+	env.createUser(t, "bob")
+	ctx := core.WithIdentity(env.ctx, core.Identity{UserID: alice})
+
+	archive := &TenantArchive{
+		Version: ArchiveVersion,
+		Tenant:  ArchivedTenant{Code: "acme", Name: "Acme", Owner: &ArchivedUser{Username: "bob"}},
+		Members: []ArchivedMember{{ArchivedUser: ArchivedUser{Username: "bob"}, Role: "member"}},
+	}
+	report, err := env.svc.ImportTenant(ctx, archive, ImportOptions{})
+	require.NoError(t, err)
+	require.Equal(t, ImportChange{Kind: ImportKindMember, Key: "bob", Action: ImportSkip, Detail: "user lookup unavailable"},
+		changeOf(report, ImportKindMember, "bob"))
+	require.Len(t, report.Warnings, 1)
+	require.Contains(t, report.Warnings[0], "user lookup unavailable")
+
+	imported, err := env.svc.GetTenant(ctx, *report.TenantID)
+	require.NoError(t, err)
+	require.Equal(t, alice, *imported.OwnerID)
+}
+
+func TestService_Impersonation_Lifecycle(t *testing.T) {
+	env := newIntegrationEnv(t)
+	acme := env.createTenant(t, "acme")
+	admin := env.createUser(t, "admin")
+	ctx := core.WithIdentity(env.ctx, core.Identity{UserID: admin})
+	start := time.Now()
+	env.svc.now = func() time.Time { return start }
+
+	_, err := env.svc.Impersonate(ctx, acme.ID, &ImpersonateRequest{})
+	require.ErrorIs(t, err, shared.ErrInvalidImpersonation, "a reason is required")
+	_, err = env.svc.Impersonate(ctx, acme.ID, &ImpersonateRequest{Reason: "debug", TTLSeconds: 7200})
+	require.ErrorIs(t, err, shared.ErrInvalidImpersonation, "ttl is capped by maxImpersonationTtlSeconds")
+
+	grant, err := env.svc.Impersonate(ctx, acme.ID, &ImpersonateRequest{Reason: "support ticket 42"})
+	require.NoError(t, err)
+	require.Equal(t, shared.ImpersonationActive, grant.Session.Status)
+	require.Equal(t, "X-Impersonation-Token", grant.Header)
+	require.Equal(t, start.UTC().Add(15*time.Minute), grant.Session.ExpiresAt)
+	require.Len(t, env.bus.named(shared.EventTenantImpersonationStarted), 1)
+
+	session, err := env.svc.ResolveImpersonation(env.ctx, grant.Token, admin)
+	require.NoError(t, err)
+	require.Equal(t, acme.ID, session.TenantID)
+	_, err = env.svc.ResolveImpersonation(env.ctx, grant.Token, uuid.New())
+	require.ErrorIs(t, err, shared.ErrImpersonationNotFound, "tokens are bound to their actor")
+	_, err = env.svc.ResolveImpersonation(env.ctx, grant.Session.ID.String()+".AAAA", admin)
+	require.ErrorIs(t, err, shared.ErrImpersonationNotFound)
+
+	member, err := env.svc.CheckDomainMembership(env.ctx, session.DomainID, admin)
+	require.NoError(t, err)
+	require.True(t, member, "an active session grants access to the tenant domain")
+
+	// Events published while impersonating carry the mark.
+	impCtx := WithImpersonation(ctx, session)
+	require.NoError(t, env.svc.events.Publish(impCtx, plugin.Event{Name: "custom", Data: map[string]any{"k": "v"}}))
+	custom := env.bus.named("custom")
+	require.Len(t, custom, 1)
+	mark, ok := custom[0].Data.(map[string]any)["impersonation"].(shared.ImpersonationMark)
+	require.True(t, ok)
+	require.Equal(t, admin, mark.ActorID)
+	require.Equal(t, session.ID, mark.SessionID)
+
+	list, err := env.svc.ListImpersonations(env.ctx, ImpersonationFilters{TenantID: acme.ID})
+	require.NoError(t, err)
+	require.Len(t, list.Sessions, 1)
+
+	revoked, err := env.svc.RevokeImpersonation(ctx, grant.Session.ID)
+	require.NoError(t, err)
+	require.Equal(t, shared.ImpersonationRevoked, revoked.Status)
+	require.Equal(t, admin, *revoked.RevokedBy)
+	_, err = env.svc.RevokeImpersonation(ctx, grant.Session.ID)
+	require.ErrorIs(t, err, shared.ErrImpersonationInactive)
+	_, err = env.svc.ResolveImpersonation(env.ctx, grant.Token, admin)
+	require.ErrorIs(t, err, shared.ErrImpersonationInactive)
+	require.Len(t, env.bus.named(shared.EventTenantImpersonationRevoked), 1)
+
+	member, err = env.svc.CheckDomainMembership(env.ctx, session.DomainID, admin)
+	require.NoError(t, err)
+	require.False(t, member)
+
+	// Expired sessions are listed under their own status.
+	grant, err = env.svc.Impersonate(ctx, acme.ID, &ImpersonateRequest{Reason: "short", TTLSeconds: 60})
+	require.NoError(t, err)
+	env.svc.now = func() time.Time { return start.Add(time.Hour) }
+	_, err = env.svc.ResolveImpersonation(env.ctx, grant.Token, admin)
+	require.ErrorIs(t, err, shared.ErrImpersonationInactive)
+
+	list, err = env.svc.ListImpersonations(env.ctx, ImpersonationFilters{})
+	require.NoError(t, err)
+	require.Empty(t, list.Sessions, "only active sessions are listed by default")
+	list, err = env.svc.ListImpersonations(env.ctx, ImpersonationFilters{Status: shared.ImpersonationExpired})
+	require.NoError(t, err)
+	require.Len(t, list.Sessions, 1)
+	list, err = env.svc.ListImpersonations(env.ctx, ImpersonationFilters{Status: ImpersonationStatusAll, ActorID: admin})
+	require.NoError(t, err)
+	require.Len(t, list.Sessions, 2)
+
+	// Ended sessions are pruned once the retention has passed.
+	pruned, err := env.svc.PruneImpersonations(env.ctx)
+	require.NoError(t, err)
+	require.Zero(t, pruned)
+	env.svc.now = func() time.Time { return start.Add(8 * 24 * time.Hour) }
+	pruned, err = env.svc.PruneImpersonations(env.ctx)
+	require.NoError(t, err)
+	require.Equal(t, 2, pruned)
+	list, err = env.svc.ListImpersonations(env.ctx, ImpersonationFilters{Status: ImpersonationStatusAll})
+	require.NoError(t, err)
+	require.Empty(t, list.Sessions)
+}
+
+func TestService_ServiceAccounts_KeysAndRotation(t *testing.T) {
+	env := newIntegrationEnv(t)
+	acme := env.createTenant(t, "acme")
+	admin := env.createUser(t, "admin")
+	ctx := core.WithIdentity(env.ctx, core.Identity{UserID: admin})
+	start := time.Now().UTC()
+	env.svc.now = func() time.Time { return start }
+	env.svc.SetRoleCatalog(staticCatalog{{Code: "member"}, {Code: "tenant_admin"}})
+
+	_, err := env.svc.CreateServiceAccount(ctx, acme.ID, &CreateServiceAccountRequest{Name: "ci", Role: "auditor"})
+	require.ErrorIs(t, err, shared.ErrInvalidRole)
+	_, err = env.svc.CreateServiceAccount(ctx, acme.ID, &CreateServiceAccountRequest{Name: " "})
+	require.ErrorIs(t, err, shared.ErrInvalidServiceAccount)
+
+	account, err := env.svc.CreateServiceAccount(ctx, acme.ID, &CreateServiceAccountRequest{Name: "ci"})
+	require.NoError(t, err)
+	require.Equal(t, "member", account.Role, "role defaults to defaultMemberRole")
+	require.Len(t, env.bus.named(shared.EventTenantServiceAccountCreated), 1)
+	_, err = env.svc.CreateServiceAccount(ctx, acme.ID, &CreateServiceAccountRequest{Name: "CI"})
+	require.ErrorIs(t, err, shared.ErrServiceAccountExists)
+
+	// An account whose membership cannot be added is not kept.
+	env.domains.addErr = errors.New("domain service down")
+	_, err = env.svc.CreateServiceAccount(ctx, acme.ID, &CreateServiceAccountRequest{Name: "deploy"})
+	require.ErrorIs(t, err, env.domains.addErr)
+	env.domains.addErr = nil
+	listed, err := env.svc.ListServiceAccounts(ctx, acme.ID)
+	require.NoError(t, err)
+	require.Len(t, listed.Accounts, 1)
+
+	member, err := env.svc.CheckDomainMembership(env.ctx, account.DomainID, account.ID)
+	require.NoError(t, err)
+	require.True(t, member, "service accounts join the tenant domain")
+
+	_, err = env.svc.CreateAPIKey(ctx, acme.ID, account.ID, &CreateAPIKeyRequest{Name: "deploy", ExpiresAt: ptrTime(start.Add(-time.Hour))})
+	require.ErrorIs(t, err, shared.ErrInvalidServiceAccount)
+	grant, err := env.svc.CreateAPIKey(ctx, acme.ID, account.ID, &CreateAPIKeyRequest{Name: "deploy", ExpiresAt: ptrTime(start.Add(24 * time.Hour))})
+	require.NoError(t, err)
+	require.Equal(t, shared.APIKeyActive, grant.Key.Status)
+
+	authed, err := env.svc.AuthenticateAPIKey(env.ctx, grant.Token)
+	require.NoError(t, err)
+	require.Equal(t, account.ID, authed.ID)
+	_, err = env.svc.AuthenticateAPIKey(env.ctx, grant.Key.ID.String()+".AAAA")
+	require.ErrorIs(t, err, shared.ErrAPIKeyNotFound)
+
+	got, err := env.svc.GetServiceAccount(ctx, acme.ID, account.ID)
+	require.NoError(t, err)
+	require.Equal(t, 1, got.ActiveKeys)
+	require.NotNil(t, got.LastUsedAt, "authentication records last use")
+
+	// Rotation with a grace period keeps the old key working until it ends,
+	// and carries the old key's lifetime over to the new one.
+	env.svc.now = func() time.Time { return start.Add(30 * time.Second) }
+	rotated, err := env.svc.RotateAPIKey(ctx, acme.ID, account.ID, grant.Key.ID, &RotateAPIKeyRequest{GraceSeconds: 60})
+	require.NoError(t, err)
+	require.Equal(t, start.Add(24*time.Hour+30*time.Second), *rotated.Key.ExpiresAt)
+	_, err = env.svc.AuthenticateAPIKey(env.ctx, grant.Token)
+	require.NoError(t, err)
+	env.svc.now = func() time.Time { return start.Add(2 * time.Minute) }
+	_, err = env.svc.AuthenticateAPIKey(env.ctx, grant.Token)
+	require.ErrorIs(t, err, shared.ErrAPIKeyInactive)
+	_, err = env.svc.AuthenticateAPIKey(env.ctx, rotated.Token)
+	require.NoError(t, err)
+	require.Len(t, env.bus.named(shared.EventTenantAPIKeyRotated), 1)
+
+	keys, err := env.svc.ListAPIKeys(ctx, acme.ID, account.ID)
+	require.NoError(t, err)
+	require.Len(t, keys.Keys, 2)
+	require.Equal(t, rotated.Key.ID, keys.Keys[0].ID, "newest first")
+	require.Equal(t, rotated.Key.ID, *keys.Keys[1].RotatedTo)
+
+	// Disabled accounts neither authenticate nor pass membership checks.
+	disabled := true
+	_, err = env.svc.UpdateServiceAccount(ctx, acme.ID, account.ID, &UpdateServiceAccountRequest{Disabled: &disabled})
+	require.NoError(t, err)
+	_, err = env.svc.AuthenticateAPIKey(env.ctx, rotated.Token)
+	require.ErrorIs(t, err, shared.ErrServiceAccountDisabled)
+	member, err = env.svc.CheckDomainMembership(env.ctx, account.DomainID, account.ID)
+	require.NoError(t, err)
+	require.False(t, member)
+	enabled := false
+	_, err = env.svc.UpdateServiceAccount(ctx, acme.ID, account.ID, &UpdateServiceAccountRequest{Disabled: &enabled})
+	require.NoError(t, err)
+
+	revoked, err := env.svc.RevokeAPIKey(ctx, acme.ID, account.ID, rotated.Key.ID)
+	require.NoError(t, err)
+	require.Equal(t, shared.APIKeyRevoked, revoked.Status)
+	_, err = env.svc.RevokeAPIKey(ctx, acme.ID, account.ID, rotated.Key.ID)
+	require.ErrorIs(t, err, shared.ErrAPIKeyInactive)
+	_, err = env.svc.AuthenticateAPIKey(env.ctx, rotated.Token)
+	require.ErrorIs(t, err, shared.ErrAPIKeyInactive)
+
+	other := env.createTenant(t, "globex")
+	_, err = env.svc.GetServiceAccount(ctx, other.ID, account.ID)
+	require.ErrorIs(t, err, shared.ErrServiceAccountNotFound, "accounts are scoped to their tenant")
+
+	require.NoError(t, env.svc.DeleteServiceAccount(ctx, acme.ID, account.ID))
+	list, err := env.svc.ListServiceAccounts(ctx, acme.ID)
+	require.NoError(t, err)
+	require.Empty(t, list.Accounts)
+	member, err = env.svc.CheckDomainMembership(env.ctx, account.DomainID, account.ID)
+	require.NoError(t, err)
+	require.False(t, member)
+}
+
+// failingAttributeStore fails every write with err.
+type failingAttributeStore struct {
+	shared.TenantAttributeStore
+	err error
+}
+
+func (s failingAttributeStore) PutAttributes(context.Context, shared.TenantAttributes) error {
+	return s.err
+}
+
+func TestService_CreateTenant_AttributeFailureLeavesNoTenant(t *testing.T) {
+	env := newIntegrationEnv(t)
+	stored := newMemoryAttributeStore()
+	errStore := errors.New("attribute store down")
+	env.svc.SetAttributeStore(failingAttributeStore{TenantAttributeStore: stored, err: errStore})
+
+	req := &CreateRequest{Code: "acme", Name: "Acme", Labels: map[string]string{"plan": "pro"}}
+	_, err := env.svc.CreateTenant(env.ctx, req)
+	require.ErrorIs(t, err, errStore)
+	_, err = env.svc.GetTenantByCode(env.ctx, "acme")
+	require.ErrorIs(t, err, shared.ErrTenantNotFound, "the tenant row is rolled back with its attributes")
+	require.Empty(t, env.bus.named(shared.EventTenantCreated))
+
+	env.svc.SetAttributeStore(stored)
+	acme, err := env.svc.CreateTenant(env.ctx, req)
+	require.NoError(t, err, "a retry is not answered with a code conflict")
+	require.Equal(t, map[string]string{"plan": "pro"}, acme.Labels)
+	require.Len(t, env.bus.named(shared.EventTenantCreated), 1)
+}
+
+func TestService_Labels_SelectorCloneAndArchive(t *testing.T) {
+	env := newIntegrationEnv(t)
+
+	acme, err := env.svc.CreateTenant(env.ctx, &CreateRequest{
+		Code: "acme", Name: "Acme",
+		Labels:   map[string]string{"plan": "pro", "region": "eu"},
+		Metadata: json.RawMessage(`{"crm":{"id":42}}`),
+	})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"plan": "pro", "region": "eu"}, acme.Labels)
+	require.JSONEq(t, `{"crm":{"id":42}}`, string(acme.Metadata))
+
+	_, err = env.svc.CreateTenant(env.ctx, &CreateRequest{Code: "beta", Name: "Beta", Labels: map[string]string{"plan": "free", "region": "us"}})
+	require.NoError(t, err)
+	env.createTenant(t, "gamma")
+
+	_, err = env.svc.CreateTenant(env.ctx, &CreateRequest{Code: "bad", Name: "Bad", Labels: map[string]string{"no spaces": "x"}})
+	require.ErrorIs(t, err, shared.ErrInvalidLabels)
+	_, err = env.svc.GetTenantByCode(env.ctx, "bad")
+	require.ErrorIs(t, err, shared.ErrTenantNotFound)
+
+	codes := func(selector string) []string {
+		t.Helper()
+		result, err := env.svc.ListTenants(env.ctx, ListFilters{LabelSelector: selector})
+		require.NoError(t, err)
+		out := make([]string, 0, len(result.Tenants))
+		for _, dto := range result.Tenants {
+			out = append(out, dto.Code)
+		}
+		sort.Strings(out)
+		require.Equal(t, len(out), result.Total)
+		return out
+	}
+	require.Equal(t, []string{"acme", "beta", "gamma"}, codes(""))
+	require.Equal(t, []string{"acme"}, codes("plan=pro,region in (eu,us)"))
+	require.Equal(t, []string{"acme", "beta"}, codes("region in (eu,us)"))
+	require.Equal(t, []string{"beta", "gamma"}, codes("plan!=pro"))
+	require.Equal(t, []string{"gamma"}, codes("!plan"))
+
+	_, err = env.svc.ListTenants(env.ctx, ListFilters{LabelSelector: "region in (eu"})
+	require.ErrorIs(t, err, shared.ErrInvalidLabelSelector)
+
+	// PUT replaces labels and metadata, clearing those left out.
+	updated, err := env.svc.UpdateTenant(env.ctx, acme.ID, &UpdateRequest{
+		Name: "Acme", Labels: map[string]string{"plan": "enterprise"}, Metadata: json.RawMessage(`{"crm":{"id":42}}`),
+	})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"plan": "enterprise"}, updated.Labels)
+	require.JSONEq(t, `{"crm":{"id":42}}`, string(updated.Metadata))
+	require.Equal(t, []string{"acme"}, codes("plan=enterprise"))
+
+	updated, err = env.svc.UpdateTenant(env.ctx, acme.ID, &UpdateRequest{Name: "Acme", Labels: map[string]string{"plan": "enterprise"}})
+	require.NoError(t, err)
+	require.Nil(t, updated.Metadata)
+	require.Equal(t, map[string]string{"plan": "enterprise"}, updated.Labels)
+
+	_, err = env.svc.UpdateTenant(env.ctx, acme.ID, &UpdateRequest{Name: "Acme", Metadata: json.RawMessage(`"text"`)})
+	require.ErrorIs(t, err, shared.ErrInvalidLabels)
+
+	updated, err = env.svc.UpdateTenant(env.ctx, acme.ID, &UpdateRequest{Name: "Acme"})
+	require.NoError(t, err)
+	require.Empty(t, updated.Labels)
+	require.Empty(t, codes("plan=enterprise"))
+	_, err = env.svc.UpdateTenant(env.ctx, acme.ID, &UpdateRequest{Name: "Acme", Labels: map[string]string{"plan": "enterprise"}})
+	require.NoError(t, err)
+
+	// Clones inherit the labels of their source.
+	clone, err := env.svc.CloneTenant(env.ctx, acme.ID, &CloneRequest{Code: "acme-2", Name: "Acme 2"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"plan": "enterprise"}, clone.Tenant.Labels)
+
+	// Labels travel with archives and show up in merge diffs.
+	archive, err := env.svc.ExportTenant(env.ctx, acme.ID)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"plan": "enterprise"}, archive.Tenant.Labels)
+
+	report, err := env.svc.ImportTenant(env.ctx, archive, ImportOptions{Code: "acme-eu", OnConflict: ConflictFail})
+	require.NoError(t, err)
+	imported, err := env.svc.GetTenant(env.ctx, *report.TenantID)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"plan": "enterprise"}, imported.Labels)
+
+	archive.Tenant.Labels = map[string]string{"plan": "pro"}
+	report, err = env.svc.ImportTenant(env.ctx, archive, ImportOptions{OnConflict: ConflictMerge, DryRun: true})
+	require.NoError(t, err)
+	require.Equal(t, ImportUpdate, changeOf(report, ImportKindTenant, "acme").Action)
+	require.Equal(t, "labels", changeOf(report, ImportKindTenant, "acme").Detail)
+
+	archive.Tenant.Labels = map[string]string{"bad key": "x"}
+	_, err = env.svc.ImportTenant(env.ctx, archive, ImportOptions{OnConflict: ConflictMerge, DryRun: true})
+	require.ErrorIs(t, err, shared.ErrInvalidArchive)
+}
+
+func TestService_PatchTenant_MergePatchSemantics(t *testing.T) {
+	env := newIntegrationEnv(t)
+
+	parent := env.createTenant(t, "holding")
+	acme, err := env.svc.CreateTenant(env.ctx, &CreateRequest{
+		Code: "acme", Name: "Acme", Description: "Widgets", ParentTenantID: "holding",
+		Labels:   map[string]string{"plan": "pro", "region": "eu"},
+		Metadata: json.RawMessage(`{"crm":{"id":42,"owner":"ann"},"tier":1}`),
+	})
+	require.NoError(t, err)
+	require.NotNil(t, acme.ParentTenantID)
+	require.Equal(t, parent.ID, *acme.ParentTenantID)
+
+	// Absent members are kept, null clears and objects are merged.
+	var patch PatchRequest
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"description": null,
+		"parentTenantId": null,
+		"labels": {"plan": "enterprise", "region": null},
+		"metadata": {"crm": {"owner": null}, "tier": 2}
+	}`), &patch))
+	patched, err := env.svc.PatchTenant(env.ctx, acme.ID, &patch)
+	require.NoError(t, err)
+	require.Equal(t, "Acme", patched.Name)
+	require.Empty(t, patched.Description)
+	require.Nil(t, patched.ParentTenantID)
+	require.Equal(t, map[string]string{"plan": "enterprise"}, patched.Labels)
+	require.JSONEq(t, `{"crm":{"id":42},"tier":2}`, string(patched.Metadata))
+
+	got, err := env.svc.GetTenant(env.ctx, acme.ID)
+	require.NoError(t, err)
+	require.Empty(t, got.Description)
+	require.Nil(t, got.ParentTenantID)
+	require.Equal(t, patched.Version, got.Version)
+
+	patched, err = env.svc.PatchTenant(env.ctx, acme.ID, &PatchRequest{
+		Name:           mergepatch.Value("Acme Corp"),
+		Status:         mergepatch.Value("inactive"),
+		ParentTenantID: mergepatch.Value("holding"),
+		Labels:         mergepatch.Null[map[string]*string](),
+		Metadata:       mergepatch.Null[json.RawMessage](),
+	})
+	require.NoError(t, err)
+	require.Equal(t, "Acme Corp", patched.Name)
+	require.Equal(t, "inactive", patched.Status)
+	require.Equal(t, parent.ID, *patched.ParentTenantID)
+	require.Empty(t, patched.Labels)
+	require.Nil(t, patched.Metadata)
+
+	// Name and status cannot be cleared.
+	_, err = env.svc.PatchTenant(env.ctx, acme.ID, &PatchRequest{Name: mergepatch.Null[string](), Status: mergepatch.Value("gone")})
+	require.ErrorIs(t, err, shared.ErrInvalidTenant)
+	var apiErr *apierror.Error
+	require.ErrorAs(t, err, &apiErr)
+	require.Len(t, apiErr.Fields, 2)
+
+	_, err = env.svc.PatchTenant(env.ctx, acme.ID, &PatchRequest{Metadata: mergepatch.Value(json.RawMessage(`"text"`))})
+	require.ErrorIs(t, err, shared.ErrInvalidLabels)
+
+	// Attributes are merged in the transaction of the row update, so invalid
+	// merged attributes leave the row unchanged too.
+	_, err = env.svc.PatchTenant(env.ctx, acme.ID, &PatchRequest{
+		Name:   mergepatch.Value("Renamed"),
+		Labels: mergepatch.Value(map[string]*string{"bad key": new(string)}),
+	})
+	require.ErrorIs(t, err, shared.ErrInvalidLabels)
+	got, err = env.svc.GetTenant(env.ctx, acme.ID)
+	require.NoError(t, err)
+	require.Equal(t, "Acme Corp", got.Name)
+	require.Equal(t, patched.Version, got.Version)
+
+	_, err = env.svc.PatchTenant(env.ctx, acme.ID, &PatchRequest{Description: mergepatch.Value("Stale"), ExpectedVersion: acme.Version})
+	require.ErrorIs(t, err, shared.ErrVersionMismatch)
+
+	_, err = env.svc.PatchTenant(env.ctx, uuid.New(), &PatchRequest{Description: mergepatch.Value("Nobody")})
+	require.ErrorIs(t, err, shared.ErrTenantNotFound)
+}
+
+func TestService_ListAndSearchTenants_Filters(t *testing.T) {
+	env := newIntegrationEnv(t)
+	alice := env.createUser(t, "alice")
+	bob := env.createUser(t, "bob")
+	asAlice := core.WithIdentity(env.ctx, core.Identity{UserID: alice})
+	asBob := core.WithIdentity(env.ctx, core.Identity{UserID: bob})
+
+	create := func(ctx context.Context, req *CreateRequest) *TenantDTO {
+		t.Helper()
+		dto, err := env.svc.CreateTenant(ctx, req)
+		require.NoError(t, err)
+		return dto
+	}
+	root := create(asAlice, &CreateRequest{Code: "root", Name: "Root", Labels: map[string]string{"plan": "pro"}})
+	child := create(asAlice, &CreateRequest{Code: "child", Name: "Child", ParentTenantID: root.Code})
+	grandchild := create(asAlice, &CreateRequest{Code: "grandchild", Name: "Grandchild", ParentTenantID: child.Code})
+	require.NoError(t, env.svc.AddMember(env.ctx, grandchild.ID, bob, "member", MemberTerms{}))
+	cut := time.Now()
+	create(asBob, &CreateRequest{Code: "other", Name: "Other", Status: "inactive", Labels: map[string]string{"plan": "pro"}})
+
+	past := time.Now().Add(-48 * time.Hour).UTC()
+	require.NoError(t, env.client.Tenant.UpdateOneID(root.ID).SetUpdatedAt(past).Exec(env.ctx))
+
+	codes := func(result *ListResult) []string {
+		out := make([]string, 0, len(result.Tenants))
+		for _, dto := range result.Tenants {
+			out = append(out, dto.Code)
+		}
+		sort.Strings(out)
+		require.Equal(t, len(out), result.Total)
+		return out
+	}
+	list := func(filters ListFilters) []string {
+		t.Helper()
+		result, err := env.svc.ListTenants(env.ctx, filters)
+		require.NoError(t, err)
+		return codes(result)
+	}
+	search := func(filter TenantFilter) []string {
+		t.Helper()
+		result, err := env.svc.SearchTenants(env.ctx, &SearchRequest{Filter: filter})
+		require.NoError(t, err)
+		return codes(result)
+	}
+
+	require.Equal(t, []string{"child", "grandchild", "root"}, list(ListFilters{OwnerID: &alice}))
+	require.Equal(t, []string{"child"}, list(ListFilters{ParentTenantID: &root.ID}))
+	require.Equal(t, []string{"child", "grandchild"}, list(ListFilters{AncestorTenantID: &root.ID}))
+	require.Empty(t, list(ListFilters{AncestorTenantID: &grandchild.ID}))
+	require.Equal(t, []string{"grandchild", "other"}, list(ListFilters{MemberID: &bob}))
+	require.Equal(t, []string{"other"}, list(ListFilters{Status: "inactive"}))
+	require.Equal(t, []string{"child", "grandchild", "other", "root"}, list(ListFilters{Statuses: []string{"active", "inactive"}}))
+	require.Equal(t, []string{"other"}, list(ListFilters{CreatedAfter: &cut}))
+	require.Equal(t, []string{"child", "grandchild", "root"}, list(ListFilters{CreatedBefore: &cut}))
+	before := past.Add(time.Hour)
+	require.Equal(t, []string{"root"}, list(ListFilters{UpdatedBefore: &before}))
+	require.Equal(t, []string{"grandchild"}, list(ListFilters{MemberID: &bob, AncestorTenantID: &root.ID}))
+
+	_, err := env.svc.ListTenants(env.ctx, ListFilters{Status: "archived"})
+	require.ErrorIs(t, err, shared.ErrInvalidTenantFilter)
+	_, err = env.svc.ListTenants(env.ctx, ListFilters{CreatedAfter: &cut, CreatedBefore: &past})
+	require.ErrorIs(t, err, shared.ErrInvalidTenantFilter)
+
+	// (parent is root) OR (bob is a member AND status is inactive)
+	require.Equal(t, []string{"child", "other"}, search(TenantFilter{Or: []TenantFilter{
+		{ParentTenantID: &root.ID},
+		{MemberID: &bob, Statuses: []string{"inactive"}},
+	}}))
+	// plan=pro AND (owned by bob OR updated long ago)
+	require.Equal(t, []string{"other", "root"}, search(TenantFilter{
+		LabelSelector: "plan=pro",
+		And: []TenantFilter{{Or: []TenantFilter{
+			{OwnerID: &bob},
+			{UpdatedBefore: &before},
+		}}},
+	}))
+	require.Len(t, search(TenantFilter{}), 4)
+	require.Len(t, search(TenantFilter{Or: []TenantFilter{{}, {OwnerID: &bob}}}), 4)
+
+	deep := TenantFilter{}
+	for range maxFilterDepth {
+		deep = TenantFilter{And: []TenantFilter{deep}}
+	}
+	_, err = env.svc.SearchTenants(env.ctx, &SearchRequest{Filter: deep})
+	require.ErrorIs(t, err, shared.ErrInvalidTenantFilter)
+	_, err = env.svc.SearchTenants(env.ctx, &SearchRequest{Filter: TenantFilter{Or: []TenantFilter{{LabelSelector: "plan in (pro"}}}})
+	require.ErrorIs(t, err, shared.ErrInvalidLabelSelector)
+}
+
+func TestService_Webhooks_SignedDeliveryRetryAndRedeliver(t *testing.T) {
+	env := newIntegrationEnv(t)
+	acme := env.createTenant(t, "acme")
+	globex := env.createTenant(t, "globex")
+	admin := env.createUser(t, "admin")
+	member := env.createUser(t, "member")
+	require.NoError(t, env.svc.AddMember(env.ctx, acme.ID, admin, "tenant_admin", MemberTerms{}))
+	require.NoError(t, env.svc.AddMember(env.ctx, acme.ID, member, "member", MemberTerms{}))
+
+	start := time.Now().UTC().Truncate(time.Second)
+	clock := start
+	env.svc.now = func() time.Time { return clock }
+	env.svc.cfg.WebhookMaxAttempts = 3
+
+	var (
+		mu       sync.Mutex
+		secrets  = map[string]string{}
+		received = map[string][]shared.WebhookMessage{}
+		failing  = map[string]bool{}
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		body, _ := io.ReadAll(r.Body)
+		err := shared.VerifyWebhook(secrets[r.URL.Path], r.Header.Get(shared.WebhookHeaderTimestamp),
+			r.Header.Get(shared.WebhookHeaderSignature), body, 5*time.Minute, clock)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		if failing[r.URL.Path] {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		var msg shared.WebhookMessage
+		_ = json.Unmarshal(body, &msg)
+		received[r.URL.Path] = append(received[r.URL.Path], msg)
+	}))
+	t.Cleanup(srv.Close)
+	env.svc.SetWebhookClient(srv.Client())
+	cfg := env.svc.cfg
+	cfg.WebhookAllowedHosts = []string{"127.0.0.1"}
+	env.svc.SetConfig(cfg)
+
+	adminCtx := coremod.WithActingContext(core.WithIdentity(context.Background(), core.Identity{UserID: admin}), &coremod.ActingContext{
+		Domain: &coremod.ResolvedDomain{TypeCode: "tenant", Key: "acme"},
+	})
+	memberCtx := coremod.WithActingContext(core.WithIdentity(context.Background(), core.Identity{UserID: member}), &coremod.ActingContext{
+		Domain: &coremod.ResolvedDomain{TypeCode: "tenant", Key: "acme"},
+	})
+
+	// Tenant admins manage the endpoints of their own tenant only.
+	_, err := env.svc.CreateWebhook(memberCtx, &acme.ID, &CreateWebhookRequest{URL: srv.URL + "/acme", Events: []string{"*"}})
+	require.ErrorIs(t, err, shared.ErrTenantAdminRequired)
+	_, err = env.svc.ListWebhooks(adminCtx, &globex.ID)
+	require.ErrorIs(t, err, shared.ErrTenantAdminRequired)
+	_, err = env.svc.ListWebhooks(adminCtx, nil)
+	require.ErrorIs(t, err, shared.ErrPlatformDomainOnly)
+	_, err = env.svc.CreateWebhook(adminCtx, &acme.ID, &CreateWebhookRequest{URL: "ftp://example.com", Events: []string{"*"}})
+	require.ErrorIs(t, err, shared.ErrInvalidWebhook)
+	_, err = env.svc.CreateWebhook(adminCtx, &acme.ID, &CreateWebhookRequest{URL: srv.URL, Events: []string{"tenant.unknown"}})
+	require.ErrorIs(t, err, shared.ErrInvalidWebhook)
+
+	platform, err := env.svc.CreateWebhook(env.ctx, nil, &CreateWebhookRequest{URL: srv.URL + "/platform", Events: []string{"*"}})
+	require.NoError(t, err)
+	tenantHook, err := env.svc.CreateWebhook(adminCtx, &acme.ID, &CreateWebhookRequest{
+		URL:    srv.URL + "/acme",
+		Events: []string{shared.EventTenantMemberRemoved},
+	})
+	require.NoError(t, err)
+	require.Contains(t, tenantHook.Secret, "whsec_")
+	secrets["/platform"], secrets["/acme"] = platform.Secret, tenantHook.Secret
+
+	list, err := env.svc.ListWebhooks(env.ctx, &acme.ID)
+	require.NoError(t, err)
+	require.Len(t, list.Webhooks, 1, "tenant and platform endpoints are listed apart")
+	_, err = env.svc.GetWebhook(adminCtx, &acme.ID, platform.Webhook.ID)
+	require.ErrorIs(t, err, shared.ErrWebhookNotFound)
+
+	// Platform endpoints receive every tenant's events; tenant endpoints only
+	// their tenant's subscribed events.
+	require.NoError(t, env.svc.RemoveMember(env.ctx, acme.ID, member))
+	require.NoError(t, env.svc.AddMember(env.ctx, globex.ID, member, "member", MemberTerms{}))
+	for _, name := range []string{shared.EventTenantMemberRemoved, shared.EventTenantMemberAdded} {
+		for _, e := range env.bus.named(name) {
+			require.NoError(t, env.svc.EnqueueWebhookEvent(env.ctx, e))
+		}
+	}
+	require.NoError(t, env.svc.EnqueueWebhookEvent(env.ctx, plugin.Event{Name: "user.deleted"}))
+
+	failing["/acme"] = true
+	sent, err := env.svc.DeliverWebhooks(env.ctx)
+	require.NoError(t, err)
+	require.Equal(t, 5, sent, "four member events to the platform, one to the tenant")
+	require.Len(t, received["/platform"], 4)
+	require.Empty(t, received["/acme"])
+
+	log, err := env.svc.ListWebhookDeliveries(adminCtx, &acme.ID, tenantHook.Webhook.ID, WebhookDeliveryFilters{})
+	require.NoError(t, err)
+	require.Equal(t, 1, log.Total)
+	failed := log.Deliveries[0]
+	require.Equal(t, shared.WebhookDeliveryPending, failed.Status)
+	require.Equal(t, http.StatusServiceUnavailable, failed.Attempts[0].StatusCode)
+	require.Equal(t, start.Add(30*time.Second), *failed.NextAttemptAt)
+
+	// Retries back off exponentially until the delivery is dead-lettered.
+	sent, err = env.svc.DeliverWebhooks(env.ctx)
+	require.NoError(t, err)
+	require.Zero(t, sent, "nothing is due before the backoff ends")
+	clock = start.Add(30 * time.Second)
+	_, err = env.svc.DeliverWebhooks(env.ctx)
+	require.NoError(t, err)
+	log, err = env.svc.ListWebhookDeliveries(adminCtx, &acme.ID, tenantHook.Webhook.ID, WebhookDeliveryFilters{})
+	require.NoError(t, err)
+	require.Equal(t, clock.Add(60*time.Second), *log.Deliveries[0].NextAttemptAt)
+	clock = clock.Add(60 * time.Second)
+	_, err = env.svc.DeliverWebhooks(env.ctx)
+	require.NoError(t, err)
+	dead, err := env.svc.ListWebhookDeliveries(adminCtx, &acme.ID, tenantHook.Webhook.ID, WebhookDeliveryFilters{Status: shared.WebhookDeliveryDead})
+	require.NoError(t, err)
+	require.Equal(t, 1, dead.Total)
+	require.Len(t, dead.Deliveries[0].Attempts, 3)
+	require.Nil(t, dead.Deliveries[0].NextAttemptAt)
+
+	// A manual redelivery sends the same event again.
+	failing["/acme"] = false
+	redelivery, err := env.svc.RedeliverWebhook(adminCtx, &acme.ID, tenantHook.Webhook.ID, failed.ID)
+	require.NoError(t, err)
+	require.Equal(t, failed.ID, *redelivery.RedeliveryOf)
+	require.Equal(t, failed.EventID, redelivery.EventID)
+	_, err = env.svc.DeliverWebhooks(env.ctx)
+	require.NoError(t, err)
+	require.Len(t, received["/acme"], 1)
+	require.Equal(t, failed.EventID, received["/acme"][0].ID)
+	require.Equal(t, acme.ID, *received["/acme"][0].TenantID)
+	delivered, err := env.svc.ListWebhookDeliveries(adminCtx, &acme.ID, tenantHook.Webhook.ID, WebhookDeliveryFilters{Status: shared.WebhookDeliveryDelivered})
+	require.NoError(t, err)
+	require.Equal(t, 1, delivered.Total)
+	_, err = env.svc.RedeliverWebhook(adminCtx, &acme.ID, tenantHook.Webhook.ID, uuid.New())
+	require.ErrorIs(t, err, shared.ErrWebhookDeliveryNotFound)
+
+	// Disabled endpoints are skipped; deleted endpoints lose their log.
+	disabled := true
+	_, err = env.svc.UpdateWebhook(env.ctx, nil, platform.Webhook.ID, &UpdateWebhookRequest{Disabled: &disabled})
+	require.NoError(t, err)
+	require.NoError(t, env.svc.EnqueueWebhookEvent(env.ctx, env.bus.named(shared.EventTenantMemberAdded)[0]))
+	sent, err = env.svc.DeliverWebhooks(env.ctx)
+	require.NoError(t, err)
+	require.Zero(t, sent)
+
+	require.NoError(t, env.svc.DeleteWebhook(adminCtx, &acme.ID, tenantHook.Webhook.ID))
+	_, err = env.svc.ListWebhookDeliveries(adminCtx, &acme.ID, tenantHook.Webhook.ID, WebhookDeliveryFilters{})
+	require.ErrorIs(t, err, shared.ErrWebhookNotFound)
+}
@@ -1,19 +1,32 @@
 package tenant
 
 import (
+	"archive/tar"
+	"bytes"
 	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
 
+	"github.com/leeforge/core"
+
+	"github.com/leeforge/plugins/apierror"
+	"github.com/leeforge/plugins/mergepatch"
+	"github.com/leeforge/plugins/metrics"
 	"github.com/leeforge/plugins/tenant/shared"
+	"github.com/leeforge/plugins/tracing"
 )
 
 // mockRoleSeeder is a no-op role seeder for testing.
 type mockRoleSeeder struct{}
 
-func (mockRoleSeeder) SeedBaselineRoles(_ context.Context, _ uuid.UUID) error { return nil }
+func (mockRoleSeeder) SeedBaselineRoles(context.Context, uuid.UUID) error { return nil }
 
 // mockUserLookup returns a stub user for testing.
 type mockUserLookup struct{}
@@ -38,3 +51,212 @@ func TestService_Ping_NilClient(t *testing.T) {
 	err := svc.Ping(context.Background())
 	require.Error(t, err, "Ping should return an error when client is nil")
 }
+
+func TestService_Metrics_RecordsOutcome(t *testing.T) {
+	reg := metrics.NewRegistry()
+	svc := NewService(nil, nil, nil, nil, mockRoleSeeder{}, mockUserLookup{})
+	svc.SetMetrics(reg)
+
+	_, err := svc.CreateTenant(context.Background(), &CreateRequest{Code: "acme", Name: "Acme"})
+	require.ErrorIs(t, err, shared.ErrPlatformDomainOnly)
+
+	labels := metrics.Labels{"operation": "create_tenant", "outcome": "forbidden"}
+	require.Equal(t, 1.0, reg.Value(MetricOperations, labels))
+	require.Equal(t, 1.0, reg.Value(MetricOperationDuration, labels))
+}
+
+func TestOutcomeOf_ClassifiesAPIErrorsByStatus(t *testing.T) {
+	require.Equal(t, "invalid", outcomeOf(fmt.Errorf("wrap: %w", shared.ErrInvalidLabels)))
+	require.Equal(t, "invalid", outcomeOf(shared.ErrInvalidTenantFilter))
+	require.Equal(t, "conflict", outcomeOf(shared.ErrServiceAccountDisabled))
+	require.Equal(t, "not_found", outcomeOf(shared.ErrTenantNotFound))
+	require.Equal(t, "error", outcomeOf(shared.ErrProvisioningFailed))
+}
+
+// stubDomainWriter resolves every domain to a fixed ID.
+type stubDomainWriter struct {
+	core.DomainWriter
+	domainID uuid.UUID
+}
+
+func (d stubDomainWriter) ResolveDomain(_ context.Context, typeCode, key string) (*core.ResolvedDomain, error) {
+	return &core.ResolvedDomain{DomainID: d.domainID, TypeCode: typeCode, Key: key}, nil
+}
+
+func TestService_Tracer_WrapsOperationAndPortCalls(t *testing.T) {
+	exp := tracing.NewInMemoryExporter()
+	domainID := uuid.New()
+	svc := NewService(nil, stubDomainWriter{domainID: domainID}, nil, nil, mockRoleSeeder{}, mockUserLookup{})
+	svc.SetTracer(tracing.NewTracer(exp))
+	svc.SetTracer(tracing.NewTracer(exp)) // re-setting must not stack wrappers
+
+	got, err := svc.GetDomainID(context.Background(), "acme")
+	require.NoError(t, err)
+	require.Equal(t, domainID, got)
+
+	require.Equal(t, []string{"domain_writer.resolve_domain", "tenant.get_domain_id"}, exp.Names())
+	port, _ := exp.Find("domain_writer.resolve_domain")
+	op, _ := exp.Find("tenant.get_domain_id")
+	require.Equal(t, op.Context.SpanID, port.Parent.SpanID)
+	require.Equal(t, "acme", port.Attributes["domain.key"])
+}
+
+func TestService_Tracer_RecordsError(t *testing.T) {
+	exp := tracing.NewInMemoryExporter()
+	svc := NewService(nil, nil, nil, nil, mockRoleSeeder{}, mockUserLookup{})
+	svc.SetTracer(tracing.NewTracer(exp))
+
+	_, err := svc.CreateTenant(context.Background(), &CreateRequest{Code: "acme", Name: "Acme"})
+	require.Error(t, err)
+
+	span, ok := exp.Find("tenant.create_tenant")
+	require.True(t, ok)
+	require.ErrorIs(t, span.Err, shared.ErrPlatformDomainOnly)
+}
+
+func TestReadArchive_RejectsMalformedInput(t *testing.T) {
+	_, err := ReadArchive(strings.NewReader("{"), ArchiveFormatJSON)
+	require.ErrorIs(t, err, shared.ErrInvalidArchive)
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: tarEntryRoles, Mode: 0o644, Size: 2}))
+	_, err = tw.Write([]byte("[]"))
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+	_, err = ReadArchive(&buf, ArchiveFormatTar)
+	require.ErrorIs(t, err, shared.ErrInvalidArchive)
+
+	_, err = ReadArchive(strings.NewReader("{}"), "zip")
+	require.ErrorIs(t, err, shared.ErrInvalidArchive)
+}
+
+func TestParseLabelSelector(t *testing.T) {
+	labels := map[string]string{"plan": "pro", "region": "eu", "example.com/owner": "alice"}
+	tests := []struct {
+		selector string
+		want     string
+		matches  bool
+	}{
+		{"", "", true},
+		{"plan=pro", "plan=pro", true},
+		{"plan==pro", "plan=pro", true},
+		{"plan!=pro", "plan!=pro", false},
+		{"plan=pro, region in (us, eu)", "plan=pro,region in (eu,us)", true},
+		{"region notin (eu)", "region notin (eu)", false},
+		{"example.com/owner", "example.com/owner", true},
+		{"!trial", "!trial", true},
+		{"trial", "trial", false},
+		{"tier!=gold", "tier!=gold", true},
+	}
+	for _, tt := range tests {
+		sel, err := shared.ParseLabelSelector(tt.selector)
+		require.NoError(t, err, tt.selector)
+		require.Equal(t, tt.want, sel.String(), tt.selector)
+		require.Equal(t, tt.matches, sel.Matches(labels), tt.selector)
+	}
+
+	for _, bad := range []string{"=pro", "region in (eu", "region in eu)", "region within (eu)", "a,,b", "-plan=pro", "plan=pro!"} {
+		_, err := shared.ParseLabelSelector(bad)
+		require.ErrorIs(t, err, shared.ErrInvalidLabelSelector, bad)
+	}
+}
+
+func TestValidateAttributes(t *testing.T) {
+	require.NoError(t, validateAttributes(map[string]string{"plan": "pro", "example.com/tier": ""}, []byte(`{"crm":{"id":42}}`)))
+	require.NoError(t, validateAttributes(nil, []byte("null")))
+
+	require.ErrorIs(t, validateAttributes(map[string]string{"bad key": "x"}, nil), shared.ErrInvalidLabels)
+	require.ErrorIs(t, validateAttributes(map[string]string{"plan": strings.Repeat("x", 64)}, nil), shared.ErrInvalidLabels)
+	require.ErrorIs(t, validateAttributes(map[string]string{"Bad_Prefix/plan": "pro"}, nil), shared.ErrInvalidLabels)
+	require.ErrorIs(t, validateAttributes(nil, []byte(`[1,2]`)), shared.ErrInvalidLabels)
+	require.ErrorIs(t, validateAttributes(nil, []byte(`{"big":"`+strings.Repeat("x", shared.MaxMetadataSize)+`"}`)), shared.ErrInvalidLabels)
+}
+
+func TestMergeAttributes(t *testing.T) {
+	plan := "pro"
+	current := shared.TenantAttributes{Labels: map[string]string{"plan": "free", "region": "eu"}, Metadata: json.RawMessage(`{"crm":{"id":42}}`)}
+	labels, metadata, err := mergeAttributes(current, &PatchRequest{
+		Labels:   mergepatch.Value(map[string]*string{"plan": &plan, "region": nil}),
+		Metadata: mergepatch.Value(json.RawMessage(`{"tier":2}`)),
+	})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"plan": "pro"}, labels)
+	require.JSONEq(t, `{"crm":{"id":42},"tier":2}`, string(metadata))
+	require.Equal(t, "free", current.Labels["plan"], "the stored labels are not modified")
+
+	// Stored metadata that cannot be merged into is an invalid tenant, not
+	// a server error.
+	_, _, err = mergeAttributes(shared.TenantAttributes{Metadata: json.RawMessage(`{"crm":`)}, &PatchRequest{
+		Metadata: mergepatch.Value(json.RawMessage(`{"tier":2}`)),
+	})
+	require.ErrorIs(t, err, shared.ErrInvalidTenant)
+	var apiErr *apierror.Error
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, "metadata", apiErr.Fields[0].Field)
+}
+
+func TestWebhookClient_RefusesBlockedAddresses(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "/target", http.StatusFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(srv.Close)
+
+	cfg := shared.DefaultConfig()
+	_, err := newWebhookClient(cfg, newWebhookAllowlist(nil)).Get(srv.URL)
+	require.ErrorIs(t, err, errBlockedAddress, "loopback is refused once resolved")
+
+	client := newWebhookClient(cfg, newWebhookAllowlist([]string{"127.0.0.0/8"}))
+	resp, err := client.Get(srv.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	resp, err = client.Get(srv.URL + "/redirect")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusFound, resp.StatusCode, "redirects are not followed")
+}
+
+func TestService_WebhookURL_RejectsBlockedHosts(t *testing.T) {
+	svc := NewService(nil, nil, nil, nil, mockRoleSeeder{}, mockUserLookup{})
+	for _, raw := range []string{
+		"http://127.0.0.1/hook",
+		"http://localhost:8080/hook",
+		"http://10.1.2.3/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://[::1]/hook",
+		"http://[::ffff:192.168.0.1]/hook",
+		"http://[::ffff:7f00:1]/hook",
+		"http://[64:ff9b::a9fe:a9fe]/hook",
+		"http://[64:ff9b:1::a00:1]/hook",
+		"http://[::10.0.0.1]/hook",
+		"http://[::ffff:0:a00:1]/hook",
+	} {
+		_, err := svc.webhookURL(raw)
+		require.ErrorIs(t, err, shared.ErrInvalidWebhook, raw)
+	}
+	_, err := svc.webhookURL("https://hooks.example.com/tenant")
+	require.NoError(t, err)
+
+	cfg := shared.DefaultConfig()
+	cfg.WebhookAllowedHosts = []string{"10.0.0.0/8", "localhost"}
+	svc.SetConfig(cfg)
+	_, err = svc.webhookURL("http://10.1.2.3/hook")
+	require.NoError(t, err)
+	_, err = svc.webhookURL("http://localhost:8080/hook")
+	require.NoError(t, err)
+	_, err = svc.webhookURL("http://192.168.0.1/hook")
+	require.ErrorIs(t, err, shared.ErrInvalidWebhook)
+	_, err = svc.webhookURL("http://[::ffff:10.1.2.3]/hook")
+	require.NoError(t, err, "mapped addresses match unmapped allowlist entries")
+
+	cfg.WebhookAllowedHosts = []string{"::ffff:172.16.0.0/108"}
+	svc.SetConfig(cfg)
+	_, err = svc.webhookURL("http://172.16.5.1/hook")
+	require.NoError(t, err, "mapped allowlist entries match unmapped addresses")
+}
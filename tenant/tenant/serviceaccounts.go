@@ -0,0 +1,710 @@
+package tenant
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/leeforge/framework/plugin"
+	"go.uber.org/zap"
+
+	"github.com/leeforge/core"
+	coreent "github.com/leeforge/core/server/ent"
+	entTenant "github.com/leeforge/core/server/ent/tenant"
+
+	"github.com/leeforge/plugins/apierror"
+	"github.com/leeforge/plugins/tenant/shared"
+)
+
+const (
+	// maxServiceAccountName bounds service account and API key names.
+	maxServiceAccountName = 100
+	// maxAPIKeyGrace bounds how long a rotated key keeps working.
+	maxAPIKeyGrace = 7 * 24 * time.Hour
+	// apiKeyTouchInterval is the resolution of APIKey.LastUsedAt; a key is
+	// written back at most once per interval.
+	apiKeyTouchInterval = time.Minute
+)
+
+// SetServiceAccountStore replaces the store holding service accounts and API
+// keys. The factory calls it with a persistent store; without one, accounts
+// are kept in memory and lost on restart.
+func (s *Service) SetServiceAccountStore(store shared.ServiceAccountStore) {
+	if store != nil {
+		s.accounts = store
+	}
+}
+
+// CreateServiceAccount creates a service account in a tenant and adds it to
+// the tenant domain with its role.
+func (s *Service) CreateServiceAccount(ctx context.Context, tenantID uuid.UUID, req *CreateServiceAccountRequest) (_ *ServiceAccountDTO, err error) {
+	ctx, end := s.instrument(ctx, "create_service_account")
+	defer end(&err)
+
+	if err := requirePlatformDomain(ctx); err != nil {
+		return nil, err
+	}
+	if req == nil {
+		return nil, shared.ErrInvalidServiceAccount
+	}
+	name, err := serviceAccountName(req.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	t, domainID, err := s.serviceAccountTenant(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	role := strings.TrimSpace(req.Role)
+	if role == "" {
+		role = s.cfg.DefaultMemberRole
+	}
+	if err := s.validateRole(ctx, domainID, t.Code, role); err != nil {
+		return nil, err
+	}
+	if err := s.checkServiceAccountName(ctx, t.ID, uuid.Nil, name); err != nil {
+		return nil, err
+	}
+
+	actorID, _ := core.GetUserID(ctx)
+	now := s.now().UTC()
+	account := shared.ServiceAccount{
+		ID:          uuid.New(),
+		TenantID:    t.ID,
+		TenantCode:  t.Code,
+		DomainID:    domainID,
+		Name:        name,
+		Description: strings.TrimSpace(req.Description),
+		Role:        role,
+		CreatedBy:   actorID,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	// The account is stored before it becomes a domain member, and removed
+	// again when the membership cannot be added, so that a failure never
+	// leaves a member without an account.
+	if err := s.accounts.PutAccount(ctx, account); err != nil {
+		return nil, fmt.Errorf("store service account: %w", err)
+	}
+	if err := s.domainSvc.AddMembership(ctx, domainID, account.ID, role, false); err != nil {
+		if delErr := s.accounts.DeleteAccount(ctx, account.ID); delErr != nil {
+			s.logger.Warn("tenant: failed to remove service account after failed membership",
+				zap.String("account", account.ID.String()),
+				zap.Error(delErr),
+			)
+		}
+		return nil, fmt.Errorf("add domain membership: %w", err)
+	}
+
+	s.publishServiceAccount(ctx, shared.EventTenantServiceAccountCreated, &account, nil, nil)
+	return s.toServiceAccountDTO(&account, nil), nil
+}
+
+// ListServiceAccounts returns the service accounts of a tenant by name.
+func (s *Service) ListServiceAccounts(ctx context.Context, tenantID uuid.UUID) (_ *ServiceAccountListResult, err error) {
+	ctx, end := s.instrument(ctx, "list_service_accounts")
+	defer end(&err)
+
+	if err := requirePlatformDomain(ctx); err != nil {
+		return nil, err
+	}
+	if _, err := s.client.Tenant.Get(ctx, tenantID); err != nil {
+		if coreent.IsNotFound(err) {
+			return nil, shared.ErrTenantNotFound
+		}
+		return nil, fmt.Errorf("get tenant: %w", err)
+	}
+
+	accounts, err := s.accounts.ListAccounts(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("list service accounts: %w", err)
+	}
+	sort.Slice(accounts, func(i, j int) bool { return accounts[i].Name < accounts[j].Name })
+
+	out := make([]*ServiceAccountDTO, 0, len(accounts))
+	for i := range accounts {
+		keys, err := s.accounts.ListKeys(ctx, accounts[i].ID)
+		if err != nil {
+			return nil, fmt.Errorf("list api keys: %w", err)
+		}
+		out = append(out, s.toServiceAccountDTO(&accounts[i], keys))
+	}
+	return &ServiceAccountListResult{Accounts: out}, nil
+}
+
+// GetServiceAccount returns a service account of a tenant.
+func (s *Service) GetServiceAccount(ctx context.Context, tenantID, accountID uuid.UUID) (_ *ServiceAccountDTO, err error) {
+	ctx, end := s.instrument(ctx, "get_service_account")
+	defer end(&err)
+
+	if err := requirePlatformDomain(ctx); err != nil {
+		return nil, err
+	}
+	account, err := s.serviceAccount(ctx, tenantID, accountID)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := s.accounts.ListKeys(ctx, account.ID)
+	if err != nil {
+		return nil, fmt.Errorf("list api keys: %w", err)
+	}
+	return s.toServiceAccountDTO(account, keys), nil
+}
+
+// UpdateServiceAccount renames, describes, disables or re-enables a service
+// account. Disabled accounts cannot authenticate and fail domain membership
+// checks; their keys are kept.
+func (s *Service) UpdateServiceAccount(ctx context.Context, tenantID, accountID uuid.UUID, req *UpdateServiceAccountRequest) (_ *ServiceAccountDTO, err error) {
+	ctx, end := s.instrument(ctx, "update_service_account")
+	defer end(&err)
+
+	if err := requirePlatformDomain(ctx); err != nil {
+		return nil, err
+	}
+	if req == nil {
+		return nil, shared.ErrInvalidServiceAccount
+	}
+	account, err := s.serviceAccount(ctx, tenantID, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		name, err := serviceAccountName(*req.Name)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.checkServiceAccountName(ctx, account.TenantID, account.ID, name); err != nil {
+			return nil, err
+		}
+		account.Name = name
+	}
+	if req.Description != nil {
+		account.Description = strings.TrimSpace(*req.Description)
+	}
+	if req.Disabled != nil {
+		account.Disabled = *req.Disabled
+	}
+	account.UpdatedAt = s.now().UTC()
+	if err := s.accounts.PutAccount(ctx, *account); err != nil {
+		return nil, fmt.Errorf("store service account: %w", err)
+	}
+
+	s.publishServiceAccount(ctx, shared.EventTenantServiceAccountUpdated, account, nil, nil)
+	keys, err := s.accounts.ListKeys(ctx, account.ID)
+	if err != nil {
+		return nil, fmt.Errorf("list api keys: %w", err)
+	}
+	return s.toServiceAccountDTO(account, keys), nil
+}
+
+// DeleteServiceAccount deletes a service account and its keys and removes it
+// from the tenant domain.
+func (s *Service) DeleteServiceAccount(ctx context.Context, tenantID, accountID uuid.UUID) (err error) {
+	ctx, end := s.instrument(ctx, "delete_service_account")
+	defer end(&err)
+
+	if err := requirePlatformDomain(ctx); err != nil {
+		return err
+	}
+	account, err := s.serviceAccount(ctx, tenantID, accountID)
+	if err != nil {
+		return err
+	}
+	if err := s.domainSvc.RemoveMembership(ctx, account.DomainID, account.ID); err != nil {
+		return fmt.Errorf("remove domain membership: %w", err)
+	}
+	if err := s.accounts.DeleteAccount(ctx, account.ID); err != nil {
+		return fmt.Errorf("delete service account: %w", err)
+	}
+
+	s.publishServiceAccount(ctx, shared.EventTenantServiceAccountDeleted, account, nil, nil)
+	return nil
+}
+
+// CreateAPIKey issues an API key for a service account. The returned token
+// is shown once; only its hash is kept.
+func (s *Service) CreateAPIKey(ctx context.Context, tenantID, accountID uuid.UUID, req *CreateAPIKeyRequest) (_ *APIKeyGrant, err error) {
+	ctx, end := s.instrument(ctx, "create_api_key")
+	defer end(&err)
+
+	if err := requirePlatformDomain(ctx); err != nil {
+		return nil, err
+	}
+	if req == nil {
+		return nil, shared.ErrInvalidServiceAccount
+	}
+	name, err := serviceAccountName(req.Name)
+	if err != nil {
+		return nil, err
+	}
+	account, err := s.serviceAccount(ctx, tenantID, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if account.Disabled {
+		return nil, shared.ErrServiceAccountDisabled
+	}
+	now := s.now().UTC()
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(now) {
+		return nil, shared.ErrInvalidServiceAccount.With(apierror.Field("expiresAt", apierror.Future))
+	}
+
+	key, token, err := s.issueAPIKey(ctx, account, name, req.ExpiresAt, now)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.accounts.PutKey(ctx, key); err != nil {
+		return nil, fmt.Errorf("store api key: %w", err)
+	}
+
+	s.publishServiceAccount(ctx, shared.EventTenantAPIKeyCreated, account, &key.ID, nil)
+	return &APIKeyGrant{Key: toAPIKeyDTO(&key, now), Token: token}, nil
+}
+
+// ListAPIKeys returns the API keys of a service account, newest first.
+func (s *Service) ListAPIKeys(ctx context.Context, tenantID, accountID uuid.UUID) (_ *APIKeyListResult, err error) {
+	ctx, end := s.instrument(ctx, "list_api_keys")
+	defer end(&err)
+
+	if err := requirePlatformDomain(ctx); err != nil {
+		return nil, err
+	}
+	account, err := s.serviceAccount(ctx, tenantID, accountID)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := s.accounts.ListKeys(ctx, account.ID)
+	if err != nil {
+		return nil, fmt.Errorf("list api keys: %w", err)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].CreatedAt.After(keys[j].CreatedAt) })
+
+	now := s.now()
+	out := make([]*APIKeyDTO, 0, len(keys))
+	for i := range keys {
+		out = append(out, toAPIKeyDTO(&keys[i], now))
+	}
+	return &APIKeyListResult{Keys: out}, nil
+}
+
+// RevokeAPIKey revokes an active API key of a service account.
+func (s *Service) RevokeAPIKey(ctx context.Context, tenantID, accountID, keyID uuid.UUID) (_ *APIKeyDTO, err error) {
+	ctx, end := s.instrument(ctx, "revoke_api_key")
+	defer end(&err)
+
+	if err := requirePlatformDomain(ctx); err != nil {
+		return nil, err
+	}
+	account, key, err := s.apiKey(ctx, tenantID, accountID, keyID)
+	if err != nil {
+		return nil, err
+	}
+	now := s.now().UTC()
+	if key.StatusAt(now) != shared.APIKeyActive {
+		return nil, shared.ErrAPIKeyInactive
+	}
+	key.RevokedAt = &now
+	if err := s.accounts.PutKey(ctx, *key); err != nil {
+		return nil, fmt.Errorf("store api key: %w", err)
+	}
+
+	s.publishServiceAccount(ctx, shared.EventTenantAPIKeyRevoked, account, &key.ID, nil)
+	return toAPIKeyDTO(key, now), nil
+}
+
+// RotateAPIKey replaces an active API key with a new one. The old key is
+// revoked, or keeps working until the grace period ends.
+func (s *Service) RotateAPIKey(ctx context.Context, tenantID, accountID, keyID uuid.UUID, req *RotateAPIKeyRequest) (_ *APIKeyGrant, err error) {
+	ctx, end := s.instrument(ctx, "rotate_api_key")
+	defer end(&err)
+
+	if err := requirePlatformDomain(ctx); err != nil {
+		return nil, err
+	}
+	if req == nil {
+		req = &RotateAPIKeyRequest{}
+	}
+	grace := time.Duration(req.GraceSeconds) * time.Second
+	if grace < 0 || grace > maxAPIKeyGrace {
+		return nil, shared.ErrInvalidServiceAccount.With(
+			apierror.Field("graceSeconds", apierror.OutOfRange, "min", 0, "max", int(maxAPIKeyGrace.Seconds())))
+	}
+	account, old, err := s.apiKey(ctx, tenantID, accountID, keyID)
+	if err != nil {
+		return nil, err
+	}
+	if account.Disabled {
+		return nil, shared.ErrServiceAccountDisabled
+	}
+	now := s.now().UTC()
+	if old.StatusAt(now) != shared.APIKeyActive {
+		return nil, shared.ErrAPIKeyInactive
+	}
+
+	expiresAt := req.ExpiresAt
+	switch {
+	case expiresAt != nil && !expiresAt.After(now):
+		return nil, shared.ErrInvalidServiceAccount.With(apierror.Field("expiresAt", apierror.Future))
+	case expiresAt == nil && old.ExpiresAt != nil:
+		t := now.Add(old.ExpiresAt.Sub(old.CreatedAt))
+		expiresAt = &t
+	}
+	key, token, err := s.issueAPIKey(ctx, account, old.Name, expiresAt, now)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.accounts.PutKey(ctx, key); err != nil {
+		return nil, fmt.Errorf("store api key: %w", err)
+	}
+
+	old.RotatedTo = &key.ID
+	if grace == 0 {
+		old.RevokedAt = &now
+	} else if until := now.Add(grace); old.ExpiresAt == nil || until.Before(*old.ExpiresAt) {
+		old.ExpiresAt = &until
+	}
+	if err := s.accounts.PutKey(ctx, *old); err != nil {
+		return nil, fmt.Errorf("store api key: %w", err)
+	}
+
+	s.publishServiceAccount(ctx, shared.EventTenantAPIKeyRotated, account, &key.ID, &old.ID)
+	return &APIKeyGrant{Key: toAPIKeyDTO(&key, now), Token: token}, nil
+}
+
+// AuthenticateAPIKey returns the service account of an API key token. The
+// key must be active, its account enabled and its tenant not deleted. The
+// key's LastUsedAt is refreshed.
+func (s *Service) AuthenticateAPIKey(ctx context.Context, token string) (_ *shared.ServiceAccount, err error) {
+	ctx, end := s.instrument(ctx, "authenticate_api_key")
+	defer end(&err)
+
+	keyID, secret, ok := parseToken(token)
+	if !ok {
+		return nil, shared.ErrAPIKeyNotFound
+	}
+	key, err := s.accounts.GetKey(ctx, keyID)
+	if err != nil {
+		return nil, fmt.Errorf("get api key: %w", err)
+	}
+	if key == nil || subtle.ConstantTimeCompare([]byte(key.KeyHash), []byte(hashToken(secret))) != 1 {
+		return nil, shared.ErrAPIKeyNotFound
+	}
+	now := s.now().UTC()
+	if key.StatusAt(now) != shared.APIKeyActive {
+		return nil, shared.ErrAPIKeyInactive
+	}
+	account, err := s.accounts.GetAccount(ctx, key.AccountID)
+	if err != nil {
+		return nil, fmt.Errorf("get service account: %w", err)
+	}
+	if account == nil {
+		return nil, shared.ErrAPIKeyNotFound
+	}
+	if account.Disabled {
+		return nil, shared.ErrServiceAccountDisabled
+	}
+	if s.client != nil {
+		exists, err := s.client.Tenant.Query().
+			Where(entTenant.ID(account.TenantID), entTenant.DeletedAtIsNil()).
+			Exist(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("get tenant: %w", err)
+		}
+		if !exists {
+			return nil, shared.ErrTenantNotFound
+		}
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= apiKeyTouchInterval {
+		if err := s.accounts.TouchKey(ctx, key.ID, now); err != nil {
+			s.logger.Warn("tenant: failed to record api key use",
+				zap.String("key", key.ID.String()),
+				zap.Error(err),
+			)
+		}
+	}
+	return account, nil
+}
+
+// serviceAccountMember reports whether subjectID is a service account and,
+// if so, whether it may act in domainID.
+func (s *Service) serviceAccountMember(ctx context.Context, domainID, subjectID uuid.UUID) (member, isAccount bool, err error) {
+	account, err := s.accounts.GetAccount(ctx, subjectID)
+	if err != nil {
+		return false, false, fmt.Errorf("get service account: %w", err)
+	}
+	if account == nil {
+		return false, false, nil
+	}
+	return !account.Disabled && account.DomainID == domainID, true, nil
+}
+
+// serviceAccountTenant returns a live tenant and its domain ID.
+func (s *Service) serviceAccountTenant(ctx context.Context, tenantID uuid.UUID) (*coreent.Tenant, uuid.UUID, error) {
+	t, err := s.client.Tenant.Query().
+		Where(entTenant.ID(tenantID), entTenant.DeletedAtIsNil()).
+		Only(ctx)
+	if err != nil {
+		if coreent.IsNotFound(err) {
+			return nil, uuid.Nil, shared.ErrTenantNotFound
+		}
+		return nil, uuid.Nil, fmt.Errorf("get tenant: %w", err)
+	}
+	domainID := s.resolveDomainIDSafe(ctx, t.Code)
+	if domainID == uuid.Nil {
+		return nil, uuid.Nil, fmt.Errorf("%w: tenant %s has no domain", shared.ErrInvalidServiceAccount, t.Code)
+	}
+	return t, domainID, nil
+}
+
+// serviceAccount returns a service account of tenantID.
+func (s *Service) serviceAccount(ctx context.Context, tenantID, accountID uuid.UUID) (*shared.ServiceAccount, error) {
+	account, err := s.accounts.GetAccount(ctx, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("get service account: %w", err)
+	}
+	if account == nil || account.TenantID != tenantID {
+		return nil, shared.ErrServiceAccountNotFound
+	}
+	return account, nil
+}
+
+// apiKey returns a key of a service account of tenantID.
+func (s *Service) apiKey(ctx context.Context, tenantID, accountID, keyID uuid.UUID) (*shared.ServiceAccount, *shared.APIKey, error) {
+	account, err := s.serviceAccount(ctx, tenantID, accountID)
+	if err != nil {
+		return nil, nil, err
+	}
+	key, err := s.accounts.GetKey(ctx, keyID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("get api key: %w", err)
+	}
+	if key == nil || key.AccountID != account.ID {
+		return nil, nil, shared.ErrAPIKeyNotFound
+	}
+	return account, key, nil
+}
+
+// checkServiceAccountName returns ErrServiceAccountExists when another
+// account of the tenant than self is called name.
+func (s *Service) checkServiceAccountName(ctx context.Context, tenantID, self uuid.UUID, name string) error {
+	accounts, err := s.accounts.ListAccounts(ctx, tenantID)
+	if err != nil {
+		return fmt.Errorf("list service accounts: %w", err)
+	}
+	for _, a := range accounts {
+		if a.ID != self && strings.EqualFold(a.Name, name) {
+			return shared.ErrServiceAccountExists
+		}
+	}
+	return nil
+}
+
+func (s *Service) issueAPIKey(ctx context.Context, account *shared.ServiceAccount, name string, expiresAt *time.Time, now time.Time) (shared.APIKey, string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return shared.APIKey{}, "", fmt.Errorf("generate api key: %w", err)
+	}
+	actorID, _ := core.GetUserID(ctx)
+	key := shared.APIKey{
+		ID:        uuid.New(),
+		AccountID: account.ID,
+		TenantID:  account.TenantID,
+		Name:      name,
+		KeyHash:   hashToken(secret),
+		CreatedBy: actorID,
+		CreatedAt: now,
+		ExpiresAt: expiresAt,
+	}
+	return key, key.ID.String() + "." + base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+func (s *Service) publishServiceAccount(ctx context.Context, name string, account *shared.ServiceAccount, keyID, previousKeyID *uuid.UUID) {
+	actorID, _ := core.GetUserID(ctx)
+	_ = s.events.Publish(ctx, plugin.Event{
+		Name:   name,
+		Source: "tenant",
+		Data: shared.ServiceAccountEventData{
+			TenantID:      account.TenantID,
+			AccountID:     account.ID,
+			Name:          account.Name,
+			Role:          account.Role,
+			KeyID:         keyID,
+			PreviousKeyID: previousKeyID,
+			ActorID:       actorID,
+		},
+	})
+}
+
+func (s *Service) toServiceAccountDTO(account *shared.ServiceAccount, keys []shared.APIKey) *ServiceAccountDTO {
+	dto := &ServiceAccountDTO{
+		ID:          account.ID,
+		TenantID:    account.TenantID,
+		TenantCode:  account.TenantCode,
+		DomainID:    account.DomainID,
+		Name:        account.Name,
+		Description: account.Description,
+		Role:        account.Role,
+		Disabled:    account.Disabled,
+		CreatedBy:   account.CreatedBy,
+		CreatedAt:   account.CreatedAt,
+		UpdatedAt:   account.UpdatedAt,
+	}
+	now := s.now()
+	for i := range keys {
+		if keys[i].StatusAt(now) == shared.APIKeyActive {
+			dto.ActiveKeys++
+		}
+		if used := keys[i].LastUsedAt; used != nil && (dto.LastUsedAt == nil || used.After(*dto.LastUsedAt)) {
+			dto.LastUsedAt = used
+		}
+	}
+	return dto
+}
+
+func toAPIKeyDTO(key *shared.APIKey, now time.Time) *APIKeyDTO {
+	return &APIKeyDTO{
+		ID:         key.ID,
+		AccountID:  key.AccountID,
+		Name:       key.Name,
+		Status:     key.StatusAt(now),
+		CreatedBy:  key.CreatedBy,
+		CreatedAt:  key.CreatedAt,
+		ExpiresAt:  key.ExpiresAt,
+		RevokedAt:  key.RevokedAt,
+		LastUsedAt: key.LastUsedAt,
+		RotatedTo:  key.RotatedTo,
+	}
+}
+
+// serviceAccountName validates a service account or API key name.
+func serviceAccountName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	switch {
+	case name == "":
+		return "", shared.ErrInvalidServiceAccount.With(apierror.Field("name", apierror.Required))
+	case len(name) > maxServiceAccountName:
+		return "", shared.ErrInvalidServiceAccount.With(apierror.Field("name", apierror.TooLong, "max", maxServiceAccountName))
+	}
+	return name, nil
+}
+
+type serviceAccountKey struct{}
+
+// WithServiceAccount returns ctx carrying the service account that
+// authenticated the request. The plugin's API key middleware sets it.
+func WithServiceAccount(ctx context.Context, account *shared.ServiceAccount) context.Context {
+	return context.WithValue(ctx, serviceAccountKey{}, account)
+}
+
+// ServiceAccountFromContext returns the service account of ctx, if any.
+func ServiceAccountFromContext(ctx context.Context) (*shared.ServiceAccount, bool) {
+	account, ok := ctx.Value(serviceAccountKey{}).(*shared.ServiceAccount)
+	return account, ok && account != nil
+}
+
+// memoryServiceAccountStore is the default, non-persistent
+// ServiceAccountStore.
+type memoryServiceAccountStore struct {
+	mu       sync.RWMutex
+	accounts map[uuid.UUID]shared.ServiceAccount
+	keys     map[uuid.UUID]shared.APIKey
+}
+
+func newMemoryServiceAccountStore() *memoryServiceAccountStore {
+	return &memoryServiceAccountStore{
+		accounts: make(map[uuid.UUID]shared.ServiceAccount),
+		keys:     make(map[uuid.UUID]shared.APIKey),
+	}
+}
+
+func (m *memoryServiceAccountStore) PutAccount(_ context.Context, account shared.ServiceAccount) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.accounts[account.ID] = account
+	return nil
+}
+
+func (m *memoryServiceAccountStore) GetAccount(_ context.Context, id uuid.UUID) (*shared.ServiceAccount, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	account, ok := m.accounts[id]
+	if !ok {
+		return nil, nil
+	}
+	return &account, nil
+}
+
+func (m *memoryServiceAccountStore) ListAccounts(_ context.Context, tenantID uuid.UUID) ([]shared.ServiceAccount, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var out []shared.ServiceAccount
+	for _, account := range m.accounts {
+		if account.TenantID == tenantID {
+			out = append(out, account)
+		}
+	}
+	return out, nil
+}
+
+func (m *memoryServiceAccountStore) DeleteAccount(_ context.Context, id uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.accounts, id)
+	for keyID, key := range m.keys {
+		if key.AccountID == id {
+			delete(m.keys, keyID)
+		}
+	}
+	return nil
+}
+
+func (m *memoryServiceAccountStore) PutKey(_ context.Context, key shared.APIKey) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.keys[key.ID] = key
+	return nil
+}
+
+func (m *memoryServiceAccountStore) GetKey(_ context.Context, id uuid.UUID) (*shared.APIKey, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	key, ok := m.keys[id]
+	if !ok {
+		return nil, nil
+	}
+	return &key, nil
+}
+
+func (m *memoryServiceAccountStore) ListKeys(_ context.Context, accountID uuid.UUID) ([]shared.APIKey, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var out []shared.APIKey
+	for _, key := range m.keys {
+		if key.AccountID == accountID {
+			out = append(out, key)
+		}
+	}
+	return out, nil
+}
+
+func (m *memoryServiceAccountStore) TouchKey(_ context.Context, id uuid.UUID, usedAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if key, ok := m.keys[id]; ok {
+		key.LastUsedAt = &usedAt
+		m.keys[id] = key
+	}
+	return nil
+}
+
+var _ shared.ServiceAccountStore = (*memoryServiceAccountStore)(nil)
@@ -0,0 +1,252 @@
+package tenant
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"entgo.io/ent/dialect/sql"
+	"github.com/google/uuid"
+
+	"github.com/leeforge/core/server/ent/predicate"
+	entTenant "github.com/leeforge/core/server/ent/tenant"
+	"github.com/leeforge/core/server/ent/tenantuser"
+
+	"github.com/leeforge/plugins/apierror"
+	"github.com/leeforge/plugins/tenant/shared"
+)
+
+// Bounds on search expressions, so that a single request cannot build an
+// arbitrarily large query.
+const (
+	maxFilterDepth = 4
+	maxFilterNodes = 32
+)
+
+// filter returns the search expression equivalent to the list filters.
+func (f ListFilters) filter() TenantFilter {
+	tf := TenantFilter{
+		Query:            f.Query,
+		Statuses:         f.Statuses,
+		LabelSelector:    f.LabelSelector,
+		OwnerID:          f.OwnerID,
+		ParentTenantID:   f.ParentTenantID,
+		AncestorTenantID: f.AncestorTenantID,
+		MemberID:         f.MemberID,
+		CreatedAfter:     f.CreatedAfter,
+		CreatedBefore:    f.CreatedBefore,
+		UpdatedAfter:     f.UpdatedAfter,
+		UpdatedBefore:    f.UpdatedBefore,
+	}
+	if f.Status != "" {
+		tf.Statuses = append([]string{f.Status}, f.Statuses...)
+	}
+	return tf
+}
+
+// tenantFilterBuilder turns a TenantFilter into an ent predicate. The label
+// index of each selected key is loaded at most once per expression.
+type tenantFilterBuilder struct {
+	s      *Service
+	nodes  int
+	labels map[string]map[uuid.UUID]string
+}
+
+// tenantPredicate validates f and returns the predicate it describes, or nil
+// when f has no conditions.
+func (s *Service) tenantPredicate(ctx context.Context, f TenantFilter) (predicate.Tenant, error) {
+	b := &tenantFilterBuilder{s: s}
+	return b.build(ctx, f, 1)
+}
+
+func (b *tenantFilterBuilder) build(ctx context.Context, f TenantFilter, depth int) (predicate.Tenant, error) {
+	if depth > maxFilterDepth {
+		return nil, shared.ErrInvalidTenantFilter.With(apierror.Field("filters", apierror.TooDeep, "max", maxFilterDepth))
+	}
+	if b.nodes++; b.nodes > maxFilterNodes {
+		return nil, shared.ErrInvalidTenantFilter.With(apierror.Field("filters", apierror.TooMany, "max", maxFilterNodes))
+	}
+
+	ps, err := b.conditions(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+	for _, sub := range f.And {
+		p, err := b.build(ctx, sub, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		if p != nil {
+			ps = append(ps, p)
+		}
+	}
+	if len(f.Or) > 0 {
+		var alts []predicate.Tenant
+		for _, sub := range f.Or {
+			p, err := b.build(ctx, sub, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			if p == nil {
+				// An empty alternative matches everything.
+				alts = nil
+				break
+			}
+			alts = append(alts, p)
+		}
+		if len(alts) > 0 {
+			ps = append(ps, entTenant.Or(alts...))
+		}
+	}
+
+	switch len(ps) {
+	case 0:
+		return nil, nil
+	case 1:
+		return ps[0], nil
+	}
+	return entTenant.And(ps...), nil
+}
+
+// conditions returns the predicates of the conditions set directly on f.
+func (b *tenantFilterBuilder) conditions(ctx context.Context, f TenantFilter) ([]predicate.Tenant, error) {
+	var ps []predicate.Tenant
+	if search := strings.TrimSpace(f.Query); search != "" {
+		ps = append(ps, entTenant.Or(
+			entTenant.CodeContainsFold(search),
+			entTenant.NameContainsFold(search),
+		))
+	}
+
+	if len(f.Statuses) > 0 {
+		statuses := make([]entTenant.Status, 0, len(f.Statuses))
+		for _, st := range f.Statuses {
+			status := entTenant.Status(strings.TrimSpace(st))
+			if err := entTenant.StatusValidator(status); err != nil {
+				return nil, shared.ErrInvalidTenantFilter.With(apierror.Field("statuses", apierror.Unknown, "value", st))
+			}
+			statuses = append(statuses, status)
+		}
+		ps = append(ps, entTenant.StatusIn(statuses...))
+	}
+
+	if f.LabelSelector != "" {
+		sel, err := shared.ParseLabelSelector(f.LabelSelector)
+		if err != nil {
+			return nil, err
+		}
+		index, indexed := b.s.attrs.(shared.TenantLabelIndex)
+		for _, req := range sel {
+			if indexed {
+				ps = append(ps, index.LabelPredicate(req))
+				continue
+			}
+			values, err := b.labelValues(ctx, req.Key)
+			if err != nil {
+				return nil, err
+			}
+			ps = append(ps, requirementPredicate(req, values))
+		}
+	}
+
+	if f.OwnerID != nil {
+		ps = append(ps, entTenant.OwnerID(*f.OwnerID))
+	}
+	if f.ParentTenantID != nil {
+		ps = append(ps, entTenant.ParentTenantID(*f.ParentTenantID))
+	}
+	if f.AncestorTenantID != nil {
+		ids, err := b.s.descendantIDs(ctx, *f.AncestorTenantID)
+		if err != nil {
+			return nil, err
+		}
+		ps = append(ps, entTenant.IDIn(ids...))
+	}
+	if f.MemberID != nil {
+		ps = append(ps, hasMember(*f.MemberID))
+	}
+
+	if err := checkRange("created", f.CreatedAfter, f.CreatedBefore); err != nil {
+		return nil, err
+	}
+	if f.CreatedAfter != nil {
+		ps = append(ps, entTenant.CreatedAtGTE(*f.CreatedAfter))
+	}
+	if f.CreatedBefore != nil {
+		ps = append(ps, entTenant.CreatedAtLTE(*f.CreatedBefore))
+	}
+	if err := checkRange("updated", f.UpdatedAfter, f.UpdatedBefore); err != nil {
+		return nil, err
+	}
+	if f.UpdatedAfter != nil {
+		ps = append(ps, entTenant.UpdatedAtGTE(*f.UpdatedAfter))
+	}
+	if f.UpdatedBefore != nil {
+		ps = append(ps, entTenant.UpdatedAtLTE(*f.UpdatedBefore))
+	}
+	return ps, nil
+}
+
+func (b *tenantFilterBuilder) labelValues(ctx context.Context, key string) (map[uuid.UUID]string, error) {
+	if values, ok := b.labels[key]; ok {
+		return values, nil
+	}
+	values, err := b.s.attrs.LabelValues(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("list tenant label %s: %w", key, err)
+	}
+	if b.labels == nil {
+		b.labels = make(map[string]map[uuid.UUID]string)
+	}
+	b.labels[key] = values
+	return values, nil
+}
+
+func checkRange(name string, after, before *time.Time) error {
+	if after != nil && before != nil && before.Before(*after) {
+		return shared.ErrInvalidTenantFilter.With(apierror.Field(name+"Before", apierror.NotBefore, "other", name+"After"))
+	}
+	return nil
+}
+
+// hasMember matches tenants with a non-deleted membership of userID.
+func hasMember(userID uuid.UUID) predicate.Tenant {
+	return predicate.Tenant(func(s *sql.Selector) {
+		t := sql.Table(tenantuser.Table)
+		s.Where(sql.In(
+			s.C(entTenant.FieldID),
+			sql.Select(t.C(tenantuser.FieldTenantID)).
+				From(t).
+				Where(sql.And(
+					sql.EQ(t.C(tenantuser.FieldUserID), userID),
+					sql.IsNull(t.C(tenantuser.FieldDeletedAt)),
+				)),
+		))
+	})
+}
+
+// descendantIDs returns the IDs of all tenants below rootID in the tenant
+// hierarchy, deleted ones included so that their children are reached.
+func (s *Service) descendantIDs(ctx context.Context, rootID uuid.UUID) ([]uuid.UUID, error) {
+	seen := map[uuid.UUID]struct{}{rootID: {}}
+	var out []uuid.UUID
+	for frontier := []uuid.UUID{rootID}; len(frontier) > 0; {
+		children, err := s.client.Tenant.Query().
+			Where(entTenant.ParentTenantIDIn(frontier...)).
+			IDs(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("list child tenants: %w", err)
+		}
+		frontier = frontier[:0]
+		for _, id := range children {
+			if _, ok := seen[id]; ok {
+				continue
+			}
+			seen[id] = struct{}{}
+			out = append(out, id)
+			frontier = append(frontier, id)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,297 @@
+package tenant
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/leeforge/core"
+
+	"github.com/leeforge/plugins/tenant/shared"
+	"github.com/leeforge/plugins/tracing"
+)
+
+// SetTracer wraps every service operation and outbound port call in spans
+// started by t. Published events carry the trace context in their payload.
+// The plugin calls it with the tracer supplied by the service factory.
+func (s *Service) SetTracer(t tracing.Tracer) {
+	s.tracer = tracing.OrNop(t)
+	s.events = tracing.WrapEventBus(s.events, s.tracer)
+
+	if d, ok := s.domainSvc.(*tracedDomainWriter); ok {
+		s.domainSvc = d.next
+	}
+	if s.domainSvc != nil {
+		s.domainSvc = &tracedDomainWriter{next: s.domainSvc, tracer: s.tracer}
+	}
+	if r, ok := s.roleSeeder.(*tracedRoleSeeder); ok {
+		s.roleSeeder = r.next
+	}
+	if s.roleSeeder != nil {
+		s.roleSeeder = &tracedRoleSeeder{next: s.roleSeeder, tracer: s.tracer}
+	}
+	if c, ok := s.catalog.(*tracedRoleCatalog); ok {
+		s.catalog = c.next
+	}
+	if s.catalog != nil {
+		s.catalog = &tracedRoleCatalog{next: s.catalog, tracer: s.tracer}
+	}
+	if o, ok := s.orgs.(*tracedOrganizationCloner); ok {
+		s.orgs = o.next
+	}
+	if s.orgs != nil {
+		s.orgs = &tracedOrganizationCloner{next: s.orgs, tracer: s.tracer}
+	}
+	if a, ok := s.archiver.(*tracedOrganizationArchiver); ok {
+		s.archiver = a.next
+	}
+	if s.archiver != nil {
+		s.archiver = &tracedOrganizationArchiver{next: s.archiver, tracer: s.tracer}
+	}
+	if p, ok := s.perms.(*tracedPermissionResolver); ok {
+		s.perms = p.next
+	}
+	if s.perms != nil {
+		s.perms = &tracedPermissionResolver{next: s.perms, tracer: s.tracer}
+	}
+	switch u := s.userLookup.(type) {
+	case *tracedUserLookup:
+		s.userLookup = u.next
+	case tracedUserFinder:
+		s.userLookup = u.next
+	}
+	if _, ok := s.userLookup.(shared.UserFinder); ok {
+		s.userLookup = tracedUserFinder{&tracedUserLookup{next: s.userLookup, tracer: s.tracer}}
+	} else if s.userLookup != nil {
+		s.userLookup = &tracedUserLookup{next: s.userLookup, tracer: s.tracer}
+	}
+}
+
+// instrument starts the span of a service operation. The returned function
+// is deferred with a pointer to the operation's named error result; it ends
+// the span and records the operation metrics. Spans of impersonated
+// requests record the impersonating user.
+func (s *Service) instrument(ctx context.Context, op string) (context.Context, func(*error)) {
+	start := time.Now()
+	ctx, span := s.tracer.Start(ctx, "tenant."+op)
+	if mark, ok := ImpersonationMarkFromContext(ctx); ok {
+		span.SetAttributes(
+			tracing.Attr("tenant.impersonated", true),
+			tracing.Attr("tenant.impersonator_id", mark.ActorID.String()),
+		)
+	}
+	return ctx, func(errp *error) {
+		tracing.End(span, errp)
+		s.observe(op, start, errp)
+	}
+}
+
+// tracedDomainWriter wraps core.DomainWriter calls in "domain_writer.*" spans.
+type tracedDomainWriter struct {
+	next   core.DomainWriter
+	tracer tracing.Tracer
+}
+
+func (d *tracedDomainWriter) ResolveDomain(ctx context.Context, typeCode, key string) (_ *core.ResolvedDomain, err error) {
+	ctx, span := d.tracer.Start(ctx, "domain_writer.resolve_domain",
+		tracing.Attr("domain.type_code", typeCode),
+		tracing.Attr("domain.key", key),
+	)
+	defer tracing.End(span, &err)
+	return d.next.ResolveDomain(ctx, typeCode, key)
+}
+
+func (d *tracedDomainWriter) ResolveDomainByID(ctx context.Context, domainID uuid.UUID) (_ *core.ResolvedDomain, err error) {
+	ctx, span := d.tracer.Start(ctx, "domain_writer.resolve_domain_by_id",
+		tracing.Attr("domain.id", domainID.String()),
+	)
+	defer tracing.End(span, &err)
+	return d.next.ResolveDomainByID(ctx, domainID)
+}
+
+func (d *tracedDomainWriter) CheckMembership(ctx context.Context, domainID, subjectID uuid.UUID) (_ bool, err error) {
+	ctx, span := d.tracer.Start(ctx, "domain_writer.check_membership",
+		tracing.Attr("domain.id", domainID.String()),
+	)
+	defer tracing.End(span, &err)
+	return d.next.CheckMembership(ctx, domainID, subjectID)
+}
+
+func (d *tracedDomainWriter) GetUserDefaultDomain(ctx context.Context, userID uuid.UUID) (_ *core.ResolvedDomain, err error) {
+	ctx, span := d.tracer.Start(ctx, "domain_writer.get_user_default_domain")
+	defer tracing.End(span, &err)
+	return d.next.GetUserDefaultDomain(ctx, userID)
+}
+
+func (d *tracedDomainWriter) GetDomainString(typeCode, key string) string {
+	return d.next.GetDomainString(typeCode, key)
+}
+
+func (d *tracedDomainWriter) ListUserDomains(ctx context.Context, userID uuid.UUID) (_ []*core.UserDomainInfo, err error) {
+	ctx, span := d.tracer.Start(ctx, "domain_writer.list_user_domains")
+	defer tracing.End(span, &err)
+	return d.next.ListUserDomains(ctx, userID)
+}
+
+func (d *tracedDomainWriter) EnsureDomain(ctx context.Context, typeCode, key, displayName string) (_ *core.ResolvedDomain, err error) {
+	ctx, span := d.tracer.Start(ctx, "domain_writer.ensure_domain",
+		tracing.Attr("domain.type_code", typeCode),
+		tracing.Attr("domain.key", key),
+	)
+	defer tracing.End(span, &err)
+	return d.next.EnsureDomain(ctx, typeCode, key, displayName)
+}
+
+func (d *tracedDomainWriter) AddMembership(ctx context.Context, domainID, subjectID uuid.UUID, memberRole string, isDefault bool) (err error) {
+	ctx, span := d.tracer.Start(ctx, "domain_writer.add_membership",
+		tracing.Attr("domain.id", domainID.String()),
+		tracing.Attr("member.role", memberRole),
+	)
+	defer tracing.End(span, &err)
+	return d.next.AddMembership(ctx, domainID, subjectID, memberRole, isDefault)
+}
+
+func (d *tracedDomainWriter) RemoveMembership(ctx context.Context, domainID, subjectID uuid.UUID) (err error) {
+	ctx, span := d.tracer.Start(ctx, "domain_writer.remove_membership",
+		tracing.Attr("domain.id", domainID.String()),
+	)
+	defer tracing.End(span, &err)
+	return d.next.RemoveMembership(ctx, domainID, subjectID)
+}
+
+// tracedRoleSeeder wraps shared.RoleSeeder calls in "role_seeder.*" spans.
+type tracedRoleSeeder struct {
+	next   shared.RoleSeeder
+	tracer tracing.Tracer
+}
+
+func (r *tracedRoleSeeder) SeedBaselineRoles(ctx context.Context, domainID uuid.UUID) (err error) {
+	ctx, span := r.tracer.Start(ctx, "role_seeder.seed_baseline_roles",
+		tracing.Attr("domain.id", domainID.String()),
+	)
+	defer tracing.End(span, &err)
+	return r.next.SeedBaselineRoles(ctx, domainID)
+}
+
+func (r *tracedRoleSeeder) SeedRoles(ctx context.Context, domainID uuid.UUID, templates []shared.RoleTemplate) (_ shared.RoleSyncResult, err error) {
+	ctx, span := r.tracer.Start(ctx, "role_seeder.seed_roles",
+		tracing.Attr("domain.id", domainID.String()),
+		tracing.Attr("role.templates", len(templates)),
+	)
+	defer tracing.End(span, &err)
+	return seedRoles(ctx, r.next, domainID, templates)
+}
+
+// tracedRoleCatalog wraps shared.RoleCatalog calls in "role_catalog.*" spans.
+type tracedRoleCatalog struct {
+	next   shared.RoleCatalog
+	tracer tracing.Tracer
+}
+
+func (c *tracedRoleCatalog) ListRoles(ctx context.Context, domainID uuid.UUID) (_ []shared.RoleInfo, err error) {
+	ctx, span := c.tracer.Start(ctx, "role_catalog.list_roles",
+		tracing.Attr("domain.id", domainID.String()),
+	)
+	defer tracing.End(span, &err)
+	return c.next.ListRoles(ctx, domainID)
+}
+
+func (c *tracedRoleCatalog) CreateRole(ctx context.Context, domainID uuid.UUID, role shared.RoleInfo) (err error) {
+	ctx, span := c.tracer.Start(ctx, "role_catalog.create_role",
+		tracing.Attr("domain.id", domainID.String()),
+		tracing.Attr("role.code", role.Code),
+	)
+	defer tracing.End(span, &err)
+	return c.next.CreateRole(ctx, domainID, role)
+}
+
+// tracedOrganizationCloner wraps shared.OrganizationCloner calls in
+// "organization_cloner.*" spans.
+type tracedOrganizationCloner struct {
+	next   shared.OrganizationCloner
+	tracer tracing.Tracer
+}
+
+func (o *tracedOrganizationCloner) CloneOrganizationTree(ctx context.Context, fromDomainID, toDomainID uuid.UUID) (_ int, err error) {
+	ctx, span := o.tracer.Start(ctx, "organization_cloner.clone_tree",
+		tracing.Attr("domain.from", fromDomainID.String()),
+		tracing.Attr("domain.to", toDomainID.String()),
+	)
+	defer tracing.End(span, &err)
+	return o.next.CloneOrganizationTree(ctx, fromDomainID, toDomainID)
+}
+
+// tracedOrganizationArchiver wraps shared.OrganizationArchiver calls in
+// "organization_archiver.*" spans.
+type tracedOrganizationArchiver struct {
+	next   shared.OrganizationArchiver
+	tracer tracing.Tracer
+}
+
+func (a *tracedOrganizationArchiver) ExportOrganizations(ctx context.Context, domainID uuid.UUID) (_ []shared.OrganizationRecord, err error) {
+	ctx, span := a.tracer.Start(ctx, "organization_archiver.export",
+		tracing.Attr("domain.id", domainID.String()),
+	)
+	defer tracing.End(span, &err)
+	return a.next.ExportOrganizations(ctx, domainID)
+}
+
+func (a *tracedOrganizationArchiver) ImportOrganizations(ctx context.Context, domainID uuid.UUID, orgs []shared.OrganizationRecord) (_ int, err error) {
+	ctx, span := a.tracer.Start(ctx, "organization_archiver.import",
+		tracing.Attr("domain.id", domainID.String()),
+		tracing.Attr("organizations", len(orgs)),
+	)
+	defer tracing.End(span, &err)
+	return a.next.ImportOrganizations(ctx, domainID, orgs)
+}
+
+// tracedUserLookup wraps shared.UserLookup calls in "user_lookup.*" spans.
+type tracedUserLookup struct {
+	next   shared.UserLookup
+	tracer tracing.Tracer
+}
+
+func (u *tracedUserLookup) GetUser(ctx context.Context, userID uuid.UUID) (_ *shared.UserInfo, err error) {
+	ctx, span := u.tracer.Start(ctx, "user_lookup.get_user")
+	defer tracing.End(span, &err)
+	return u.next.GetUser(ctx, userID)
+}
+
+// tracedUserFinder adds "user_lookup.find_user" spans to a tracedUserLookup
+// whose UserLookup is a shared.UserFinder.
+type tracedUserFinder struct {
+	*tracedUserLookup
+}
+
+func (u tracedUserFinder) FindUser(ctx context.Context, username, email string) (_ *shared.UserInfo, err error) {
+	ctx, span := u.tracer.Start(ctx, "user_lookup.find_user")
+	defer tracing.End(span, &err)
+	return u.next.(shared.UserFinder).FindUser(ctx, username, email)
+}
+
+// tracedPermissionResolver wraps shared.PermissionResolver calls in
+// "permission_resolver.*" spans.
+type tracedPermissionResolver struct {
+	next   shared.PermissionResolver
+	tracer tracing.Tracer
+}
+
+func (p *tracedPermissionResolver) RolePermissions(ctx context.Context, domainID uuid.UUID, role string) (_ []string, err error) {
+	ctx, span := p.tracer.Start(ctx, "permission_resolver.role_permissions",
+		tracing.Attr("domain.id", domainID.String()),
+		tracing.Attr("member.role", role),
+	)
+	defer tracing.End(span, &err)
+	return p.next.RolePermissions(ctx, domainID, role)
+}
+
+var (
+	_ core.DomainWriter           = (*tracedDomainWriter)(nil)
+	_ shared.RoleSeeder           = (*tracedRoleSeeder)(nil)
+	_ shared.RoleCatalog          = (*tracedRoleCatalog)(nil)
+	_ shared.OrganizationCloner   = (*tracedOrganizationCloner)(nil)
+	_ shared.OrganizationArchiver = (*tracedOrganizationArchiver)(nil)
+	_ shared.UserLookup           = (*tracedUserLookup)(nil)
+	_ shared.PermissionResolver   = (*tracedPermissionResolver)(nil)
+)
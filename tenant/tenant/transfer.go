@@ -0,0 +1,786 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/leeforge/framework/plugin"
+
+	"github.com/leeforge/core"
+	coreent "github.com/leeforge/core/server/ent"
+	entTenant "github.com/leeforge/core/server/ent/tenant"
+	"github.com/leeforge/core/server/ent/tenantuser"
+
+	"github.com/leeforge/plugins/tenant/shared"
+	"github.com/leeforge/plugins/tracing"
+)
+
+// ArchiveVersion is the TenantArchive format written by ExportTenant.
+const ArchiveVersion = 1
+
+// Code conflict strategies of ImportTenant.
+const (
+	ConflictFail   = "fail"
+	ConflictRename = "rename"
+	ConflictMerge  = "merge"
+)
+
+// Import actions and change kinds reported by ImportTenant.
+const (
+	ImportCreate    = "create"
+	ImportMerge     = "merge"
+	ImportUpdate    = "update"
+	ImportUnchanged = "unchanged"
+	ImportSkip      = "skip"
+
+	ImportKindTenant       = "tenant"
+	ImportKindRole         = "role"
+	ImportKindMember       = "member"
+	ImportKindOrganization = "organization"
+)
+
+// userFinderUnavailable explains archived users left unmapped because the
+// UserLookup is not a shared.UserFinder.
+const userFinderUnavailable = "user lookup unavailable"
+
+// maxRenameAttempts bounds the "<code>-N" candidates tried on rename.
+const maxRenameAttempts = 100
+
+// SetOrganizationArchiver sets the port that exports and imports the
+// organizations of a tenant domain. Without one, archives carry no
+// organizations.
+func (s *Service) SetOrganizationArchiver(a shared.OrganizationArchiver) {
+	s.archiver = a
+	if s.tracer != tracing.Nop && a != nil {
+		s.archiver = &tracedOrganizationArchiver{next: a, tracer: s.tracer}
+	}
+}
+
+// ExportTenant returns the archive of a tenant: its record, roles, members
+// and the organizations of its domain.
+func (s *Service) ExportTenant(ctx context.Context, tenantID uuid.UUID) (_ *TenantArchive, err error) {
+	ctx, end := s.instrument(ctx, "export_tenant")
+	defer end(&err)
+
+	if err := requirePlatformDomain(ctx); err != nil {
+		return nil, err
+	}
+
+	t, err := s.client.Tenant.Query().
+		Where(entTenant.ID(tenantID), entTenant.DeletedAtIsNil()).
+		Only(ctx)
+	if err != nil {
+		if coreent.IsNotFound(err) {
+			return nil, shared.ErrTenantNotFound
+		}
+		return nil, fmt.Errorf("get tenant: %w", err)
+	}
+	domainID := s.resolveDomainIDSafe(ctx, t.Code)
+
+	archive := &TenantArchive{
+		Version:    ArchiveVersion,
+		ExportedAt: s.now().UTC(),
+		Tenant: ArchivedTenant{
+			Code:        t.Code,
+			Name:        t.Name,
+			Description: t.Description,
+			Status:      string(t.Status),
+		},
+		Roles:         []shared.RoleInfo{},
+		Members:       []ArchivedMember{},
+		Organizations: []ArchivedOrganization{},
+	}
+	if t.ParentTenantID != nil && *t.ParentTenantID != uuid.Nil {
+		parent, err := s.client.Tenant.Get(ctx, *t.ParentTenantID)
+		if err != nil && !coreent.IsNotFound(err) {
+			return nil, fmt.Errorf("get parent tenant: %w", err)
+		}
+		if parent != nil {
+			archive.Tenant.ParentCode = parent.Code
+		}
+	}
+
+	attrs, err := s.attrs.GetAttributes(ctx, t.ID)
+	if err != nil {
+		return nil, fmt.Errorf("get tenant attributes: %w", err)
+	}
+	if attrs != nil {
+		archive.Tenant.Labels, archive.Tenant.Metadata = attrs.Labels, attrs.Metadata
+	}
+
+	if s.catalog != nil && domainID != uuid.Nil {
+		roles, err := s.catalog.ListRoles(ctx, domainID)
+		if err != nil {
+			return nil, fmt.Errorf("list roles: %w", err)
+		}
+		archive.Roles = append(archive.Roles, roles...)
+	}
+
+	memberships, err := s.client.TenantUser.Query().
+		Where(tenantuser.TenantIDEQ(t.ID), tenantuser.DeletedAtIsNil()).
+		WithUser().
+		Order(coreent.Asc(tenantuser.FieldCreatedAt)).
+		All(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list members: %w", err)
+	}
+	terms, err := s.terms.ListTerms(ctx, t.ID)
+	if err != nil {
+		return nil, fmt.Errorf("list membership terms: %w", err)
+	}
+	termByUser := make(map[uuid.UUID]shared.MemberTerm, len(terms))
+	for _, term := range terms {
+		termByUser[term.UserID] = term
+	}
+	users := make(map[uuid.UUID]ArchivedUser, len(memberships))
+	for _, m := range memberships {
+		u := m.Edges.User
+		if u == nil {
+			continue
+		}
+		users[u.ID] = ArchivedUser{Username: u.Username, Email: u.Email}
+		member := ArchivedMember{
+			ArchivedUser:     users[u.ID],
+			Role:             m.Role,
+			MembershipStatus: membershipStatus(m.Status),
+			MembershipType:   shared.MembershipTypeStandard,
+		}
+		if term, ok := termByUser[u.ID]; ok {
+			member.MembershipType = term.Type
+			member.ValidFrom = term.ValidFrom
+			member.ExpiresAt = term.ExpiresAt
+		}
+		archive.Members = append(archive.Members, member)
+	}
+	if owner, ok := users[t.OwnerID]; ok {
+		archive.Tenant.Owner = &owner
+	}
+
+	orgs, err := s.exportOrganizations(ctx, domainID, users)
+	if err != nil {
+		return nil, err
+	}
+	archive.Organizations = append(archive.Organizations, orgs...)
+	return archive, nil
+}
+
+// exportOrganizations returns the organizations of domainID with members
+// identified by username and email. users caches known users and is
+// extended through the UserLookup.
+func (s *Service) exportOrganizations(ctx context.Context, domainID uuid.UUID, users map[uuid.UUID]ArchivedUser) ([]ArchivedOrganization, error) {
+	if s.archiver == nil || domainID == uuid.Nil {
+		return nil, nil
+	}
+	records, err := s.archiver.ExportOrganizations(ctx, domainID)
+	if errors.Is(err, shared.ErrOrganizationsDisabled) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("export organizations: %w", err)
+	}
+
+	out := make([]ArchivedOrganization, 0, len(records))
+	for _, rec := range records {
+		org := ArchivedOrganization{Code: rec.Code, Name: rec.Name, Path: rec.Path}
+		for _, m := range rec.Members {
+			u, ok := users[m.UserID]
+			if !ok {
+				info, err := s.userLookup.GetUser(ctx, m.UserID)
+				if err != nil {
+					return nil, fmt.Errorf("get organization member %s: %w", m.UserID, err)
+				}
+				u = ArchivedUser{Username: info.Username, Email: info.Email}
+				users[m.UserID] = u
+			}
+			org.Members = append(org.Members, ArchivedOrganizationMember{ArchivedUser: u, IsPrimary: m.IsPrimary})
+		}
+		out = append(out, org)
+	}
+	return out, nil
+}
+
+// importPlan is the resolved form of an archive against this environment.
+type importPlan struct {
+	code, name string
+	target     *coreent.Tenant // set when merging
+	create     *CreateRequest  // set when creating
+	update     *tenantChange   // set when merging changes fields
+	ownerID    uuid.UUID
+	roles      []shared.RoleInfo
+	members    []plannedMember
+	orgs       []shared.OrganizationRecord
+}
+
+type plannedMember struct {
+	userID    uuid.UUID
+	key       string
+	role      string
+	terms     MemberTerms
+	suspended bool
+}
+
+// ImportTenant creates or updates a tenant from an archive. Users are mapped
+// by username, then email, when the UserLookup is a shared.UserFinder;
+// members without a match are skipped. Roles and organizations the tenant already has are kept. With
+// opts.DryRun the report lists the changes without applying them. Every
+// archive item is validated before anything is applied. A failure while
+// applying returns the report with ErrProvisioningFailed: a tenant the import
+// created is removed again, changes merged into an existing tenant up to that
+// point are kept.
+func (s *Service) ImportTenant(ctx context.Context, archive *TenantArchive, opts ImportOptions) (_ *ImportReport, err error) {
+	ctx, end := s.instrument(ctx, "import_tenant")
+	defer end(&err)
+
+	if err := requirePlatformDomain(ctx); err != nil {
+		return nil, err
+	}
+
+	report := &ImportReport{DryRun: opts.DryRun, Changes: []ImportChange{}, Warnings: []string{}}
+	plan, err := s.planImport(ctx, archive, opts, report)
+	if err != nil {
+		return nil, err
+	}
+	if opts.DryRun {
+		return report, nil
+	}
+	if err := s.applyImport(ctx, plan, report); err != nil {
+		return report, fmt.Errorf("%w: %w", shared.ErrProvisioningFailed, err)
+	}
+	return report, nil
+}
+
+func (s *Service) planImport(ctx context.Context, archive *TenantArchive, opts ImportOptions, report *ImportReport) (*importPlan, error) {
+	if archive == nil {
+		return nil, fmt.Errorf("%w: archive is empty", shared.ErrInvalidArchive)
+	}
+	if archive.Version != ArchiveVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", shared.ErrInvalidArchive, archive.Version)
+	}
+
+	plan := &importPlan{
+		code: strings.TrimSpace(archive.Tenant.Code),
+		name: strings.TrimSpace(archive.Tenant.Name),
+	}
+	if code := strings.TrimSpace(opts.Code); code != "" {
+		plan.code = code
+	}
+	if name := strings.TrimSpace(opts.Name); name != "" {
+		plan.name = name
+	}
+	if plan.code == "" || plan.name == "" {
+		return nil, fmt.Errorf("%w: tenant code and name are required", shared.ErrInvalidArchive)
+	}
+	if err := s.validateArchive(archive); err != nil {
+		return nil, err
+	}
+	switch opts.OnConflict {
+	case "":
+		opts.OnConflict = ConflictFail
+	case ConflictFail, ConflictRename, ConflictMerge:
+	default:
+		return nil, fmt.Errorf("%w: unknown onConflict %q", shared.ErrInvalidArchive, opts.OnConflict)
+	}
+
+	if err := s.planTenant(ctx, archive, opts, plan, report); err != nil {
+		return nil, err
+	}
+	roles, err := s.planRoles(ctx, archive, plan, report)
+	if err != nil {
+		return nil, err
+	}
+	users, err := s.planMembers(ctx, archive, plan, roles, report)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.planOrganizations(ctx, archive, plan, users, report); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// validateArchive checks every archive item ImportTenant may apply, so that
+// an import does not fail halfway on invalid data.
+func (s *Service) validateArchive(archive *TenantArchive) error {
+	invalid := func(format string, args ...any) error {
+		return fmt.Errorf("%w: "+format, append([]any{shared.ErrInvalidArchive}, args...)...)
+	}
+	if err := validateAttributes(archive.Tenant.Labels, archive.Tenant.Metadata); err != nil {
+		return fmt.Errorf("%w: %w", shared.ErrInvalidArchive, err)
+	}
+	if status := archive.Tenant.Status; status != "" {
+		if err := entTenant.StatusValidator(entTenant.Status(status)); err != nil {
+			return invalid("tenant status %q", status)
+		}
+	}
+	for _, r := range archive.Roles {
+		if strings.TrimSpace(r.Code) == "" || strings.TrimSpace(r.Name) == "" {
+			return invalid("role code and name are required")
+		}
+	}
+	now := s.now()
+	for _, m := range archive.Members {
+		key := userKey(m.ArchivedUser)
+		if m.Username == "" && m.Email == "" {
+			return invalid("member username or email is required")
+		}
+		switch m.MembershipStatus {
+		case "", MemberStatusActive, MemberStatusSuspended:
+		default:
+			return invalid("member %s: membership status %q", key, m.MembershipStatus)
+		}
+		if m.ExpiresAt != nil && !m.ExpiresAt.After(now) {
+			continue // skipped as expired
+		}
+		if _, err := s.memberTerm(uuid.Nil, uuid.Nil, MemberTerms{Type: m.MembershipType, ValidFrom: m.ValidFrom, ExpiresAt: m.ExpiresAt}); err != nil {
+			return fmt.Errorf("%w: member %s: %w", shared.ErrInvalidArchive, key, err)
+		}
+	}
+	for _, o := range archive.Organizations {
+		if o.Code == "" || o.Name == "" || o.Path == "" {
+			return invalid("organization code, name and path are required")
+		}
+	}
+	return nil
+}
+
+// planTenant resolves the code conflict, the parent and the owner.
+func (s *Service) planTenant(ctx context.Context, archive *TenantArchive, opts ImportOptions, plan *importPlan, report *ImportReport) error {
+	// Soft-deleted tenants keep their code, so they count as conflicts.
+	existing, err := s.client.Tenant.Query().Where(entTenant.CodeEQ(plan.code)).Only(ctx)
+	if err != nil && !coreent.IsNotFound(err) {
+		return fmt.Errorf("get tenant: %w", err)
+	}
+
+	report.Action = ImportCreate
+	change := ImportChange{Kind: ImportKindTenant, Key: plan.code, Action: ImportCreate}
+	if existing != nil {
+		switch opts.OnConflict {
+		case ConflictFail:
+			return shared.ErrTenantCodeExists
+		case ConflictRename:
+			code, err := s.freeTenantCode(ctx, plan.code)
+			if err != nil {
+				return err
+			}
+			change.Detail = fmt.Sprintf("renamed from %s", plan.code)
+			plan.code, change.Key = code, code
+		case ConflictMerge:
+			if !existing.DeletedAt.IsZero() {
+				return fmt.Errorf("%w: %s is deleted", shared.ErrTenantCodeExists, plan.code)
+			}
+			plan.target = existing
+			report.Action = ImportMerge
+			id := existing.ID
+			report.TenantID = &id
+			attrs, err := s.attrs.GetAttributes(ctx, existing.ID)
+			if err != nil {
+				return fmt.Errorf("get tenant attributes: %w", err)
+			}
+			change.Action, change.Detail = tenantDiff(existing, attrs, plan.name, archive.Tenant)
+			if change.Action == ImportUpdate {
+				// Merging keeps the parent, and the fields the archive
+				// leaves empty, of the existing tenant.
+				plan.update = &tenantChange{
+					name:       &plan.name,
+					attributes: replaceAttributes(archive.Tenant.Labels, archive.Tenant.Metadata),
+				}
+				if archive.Tenant.Description != "" {
+					plan.update.description = &archive.Tenant.Description
+				}
+				if archive.Tenant.Status != "" {
+					plan.update.status = &archive.Tenant.Status
+				}
+			}
+		}
+	}
+	report.TenantCode = plan.code
+	report.Changes = append(report.Changes, change)
+
+	if plan.target == nil {
+		plan.create = &CreateRequest{
+			Code:        plan.code,
+			Name:        plan.name,
+			Description: archive.Tenant.Description,
+			Status:      archive.Tenant.Status,
+			Labels:      archive.Tenant.Labels,
+			Metadata:    archive.Tenant.Metadata,
+		}
+	}
+	if code := archive.Tenant.ParentCode; code != "" && plan.target == nil {
+		parent, err := s.client.Tenant.Query().
+			Where(entTenant.CodeEQ(code), entTenant.DeletedAtIsNil()).
+			Only(ctx)
+		switch {
+		case coreent.IsNotFound(err):
+			report.Warnings = append(report.Warnings, fmt.Sprintf("parent tenant %s not found; imported without parent", code))
+		case err != nil:
+			return fmt.Errorf("get parent tenant: %w", err)
+		default:
+			plan.create.ParentTenantID = parent.ID.String()
+		}
+	}
+
+	plan.ownerID, _ = core.GetUserID(ctx)
+	if owner := archive.Tenant.Owner; owner != nil && plan.target == nil {
+		finder, ok := s.userLookup.(shared.UserFinder)
+		if !ok {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("owner %s not mapped: %s; the importing user owns the tenant", userKey(*owner), userFinderUnavailable))
+			return nil
+		}
+		u, err := finder.FindUser(ctx, owner.Username, owner.Email)
+		if err != nil {
+			return fmt.Errorf("find owner: %w", err)
+		}
+		if u != nil {
+			plan.ownerID = u.ID
+		} else {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("owner %s not found; the importing user owns the tenant", userKey(*owner)))
+		}
+	}
+	return nil
+}
+
+// tenantDiff compares an existing tenant with the archived record.
+func tenantDiff(t *coreent.Tenant, attrs *shared.TenantAttributes, name string, rec ArchivedTenant) (string, string) {
+	var fields []string
+	if t.Name != name {
+		fields = append(fields, "name")
+	}
+	if rec.Description != "" && t.Description != rec.Description {
+		fields = append(fields, "description")
+	}
+	if rec.Status != "" && string(t.Status) != rec.Status {
+		fields = append(fields, "status")
+	}
+	if attrs == nil {
+		attrs = &shared.TenantAttributes{}
+	}
+	if len(rec.Labels) > 0 && !maps.Equal(attrs.Labels, rec.Labels) {
+		fields = append(fields, "labels")
+	}
+	if len(rec.Metadata) > 0 && !jsonEqual(attrs.Metadata, rec.Metadata) {
+		fields = append(fields, "metadata")
+	}
+	if len(fields) == 0 {
+		return ImportUnchanged, ""
+	}
+	return ImportUpdate, strings.Join(fields, ", ")
+}
+
+// planRoles plans the archive roles the tenant lacks and returns the role
+// codes the tenant will have.
+func (s *Service) planRoles(ctx context.Context, archive *TenantArchive, plan *importPlan, report *ImportReport) (map[string]struct{}, error) {
+	have := make(map[string]struct{})
+	// A new tenant may reuse the domain of an earlier, rolled back import,
+	// which keeps the roles created for it.
+	domainID := s.resolveDomainIDSafe(ctx, plan.code)
+	if plan.target != nil {
+		domainID = s.resolveDomainIDSafe(ctx, plan.target.Code)
+	}
+	if s.catalog != nil && domainID != uuid.Nil {
+		roles, err := s.catalog.ListRoles(ctx, domainID)
+		if err != nil {
+			return nil, fmt.Errorf("list roles: %w", err)
+		}
+		for _, r := range roles {
+			have[r.Code] = struct{}{}
+		}
+	}
+	if plan.target == nil {
+		templates, err := s.roleTemplatesFor(ctx, uuid.Nil, plan.code)
+		if err != nil {
+			return nil, err
+		}
+		for _, tpl := range templates {
+			have[tpl.Code] = struct{}{}
+		}
+	}
+
+	for _, r := range archive.Roles {
+		change := ImportChange{Kind: ImportKindRole, Key: r.Code, Action: ImportCreate}
+		switch _, ok := have[r.Code]; {
+		case ok:
+			change.Action = ImportUnchanged
+		case s.catalog == nil:
+			change.Action, change.Detail = ImportSkip, "role catalog unavailable"
+		default:
+			plan.roles = append(plan.roles, r)
+			have[r.Code] = struct{}{}
+		}
+		report.Changes = append(report.Changes, change)
+	}
+	return have, nil
+}
+
+// planMembers maps archive members to users and returns the users found,
+// keyed by their archive identity.
+func (s *Service) planMembers(ctx context.Context, archive *TenantArchive, plan *importPlan, roles map[string]struct{}, report *ImportReport) (map[ArchivedUser]uuid.UUID, error) {
+	users := make(map[ArchivedUser]uuid.UUID)
+	var existing map[uuid.UUID]struct{}
+	if plan.target != nil {
+		memberships, err := s.client.TenantUser.Query().
+			Where(tenantuser.TenantIDEQ(plan.target.ID), tenantuser.DeletedAtIsNil()).
+			All(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("list members: %w", err)
+		}
+		existing = make(map[uuid.UUID]struct{}, len(memberships))
+		for _, m := range memberships {
+			existing[m.UserID] = struct{}{}
+		}
+	}
+
+	_, canFind := s.userLookup.(shared.UserFinder)
+	now := s.now()
+	for _, m := range archive.Members {
+		change := ImportChange{Kind: ImportKindMember, Key: userKey(m.ArchivedUser), Action: ImportCreate, Detail: m.Role}
+		userID, err := s.mapUser(ctx, m.ArchivedUser, users)
+		if err != nil {
+			return nil, err
+		}
+		_, isMember := existing[userID]
+		_, hasRole := roles[m.Role]
+		switch {
+		case !canFind:
+			change.Action, change.Detail = ImportSkip, userFinderUnavailable
+		case userID == uuid.Nil:
+			change.Action, change.Detail = ImportSkip, "user not found"
+		case isMember:
+			change.Action, change.Detail = ImportUnchanged, ""
+		case plan.target == nil && userID == plan.ownerID:
+			change.Action, change.Detail = ImportUnchanged, "owner"
+		case !hasRole:
+			change.Action, change.Detail = ImportSkip, fmt.Sprintf("role %s is not defined", m.Role)
+		case m.ExpiresAt != nil && !m.ExpiresAt.After(now):
+			change.Action, change.Detail = ImportSkip, "membership expired"
+		default:
+			pm := plannedMember{
+				userID:    userID,
+				key:       change.Key,
+				role:      m.Role,
+				terms:     MemberTerms{Type: m.MembershipType, ValidFrom: m.ValidFrom, ExpiresAt: m.ExpiresAt},
+				suspended: m.MembershipStatus == MemberStatusSuspended,
+			}
+			if pm.suspended {
+				change.Detail += " (suspended)"
+			}
+			plan.members = append(plan.members, pm)
+		}
+		report.Changes = append(report.Changes, change)
+	}
+	return users, nil
+}
+
+// planOrganizations plans the organizations the tenant domain lacks.
+func (s *Service) planOrganizations(ctx context.Context, archive *TenantArchive, plan *importPlan, users map[ArchivedUser]uuid.UUID, report *ImportReport) error {
+	if len(archive.Organizations) == 0 {
+		return nil
+	}
+	have := make(map[string]struct{})
+	if s.archiver != nil && plan.target != nil {
+		domainID := s.resolveDomainIDSafe(ctx, plan.target.Code)
+		if domainID != uuid.Nil {
+			records, err := s.archiver.ExportOrganizations(ctx, domainID)
+			if err != nil && !errors.Is(err, shared.ErrOrganizationsDisabled) {
+				return fmt.Errorf("export organizations: %w", err)
+			}
+			for _, rec := range records {
+				have[rec.Code] = struct{}{}
+			}
+		}
+	}
+
+	skipped := 0
+	for _, o := range archive.Organizations {
+		change := ImportChange{Kind: ImportKindOrganization, Key: o.Path, Action: ImportCreate}
+		if _, ok := have[o.Code]; ok {
+			change.Action = ImportUnchanged
+		}
+		if s.archiver == nil {
+			change.Action, change.Detail = ImportSkip, "organization service unavailable"
+		}
+		report.Changes = append(report.Changes, change)
+
+		rec := shared.OrganizationRecord{Code: o.Code, Name: o.Name, Path: o.Path}
+		for _, m := range o.Members {
+			userID, err := s.mapUser(ctx, m.ArchivedUser, users)
+			if err != nil {
+				return err
+			}
+			if userID == uuid.Nil {
+				skipped++
+				continue
+			}
+			rec.Members = append(rec.Members, shared.OrganizationMemberRecord{UserID: userID, IsPrimary: m.IsPrimary})
+		}
+		plan.orgs = append(plan.orgs, rec)
+	}
+	if s.archiver == nil {
+		plan.orgs = nil
+	}
+	if skipped > 0 {
+		reason := "user not found"
+		if _, ok := s.userLookup.(shared.UserFinder); !ok {
+			reason = userFinderUnavailable
+		}
+		report.Warnings = append(report.Warnings, fmt.Sprintf("%d organization memberships skipped: %s", skipped, reason))
+	}
+	return nil
+}
+
+// mapUser returns the ID of the local user matching u, or uuid.Nil when
+// there is none or the UserLookup cannot find users.
+func (s *Service) mapUser(ctx context.Context, u ArchivedUser, cache map[ArchivedUser]uuid.UUID) (uuid.UUID, error) {
+	if id, ok := cache[u]; ok {
+		return id, nil
+	}
+	finder, ok := s.userLookup.(shared.UserFinder)
+	if !ok {
+		return uuid.Nil, nil
+	}
+	info, err := finder.FindUser(ctx, u.Username, u.Email)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("find user %s: %w", userKey(u), err)
+	}
+	id := uuid.Nil
+	if info != nil {
+		id = info.ID
+	}
+	cache[u] = id
+	return id, nil
+}
+
+func (s *Service) applyImport(ctx context.Context, plan *importPlan, report *ImportReport) (err error) {
+	var tenantID, domainID uuid.UUID
+	if plan.target == nil {
+		dto, createErr := s.createTenant(ctx, plan.create, plan.ownerID)
+		if createErr != nil {
+			return fmt.Errorf("create tenant: %w", createErr)
+		}
+		tenantID, domainID = dto.ID, dto.DomainID
+		report.TenantID = &dto.ID
+		defer func() {
+			if err == nil {
+				return
+			}
+			if rbErr := s.discardTenant(ctx, dto.ID, dto.DomainID, plan); rbErr != nil {
+				err = fmt.Errorf("%w; roll back tenant: %w", err, rbErr)
+				return
+			}
+			report.TenantID = nil
+		}()
+	} else {
+		tenantID = plan.target.ID
+		domainID = s.resolveDomainIDSafe(ctx, plan.target.Code)
+		if plan.update != nil {
+			if _, err := s.updateTenant(ctx, tenantID, "", *plan.update); err != nil {
+				return fmt.Errorf("update tenant: %w", err)
+			}
+		}
+	}
+
+	for _, r := range plan.roles {
+		if err := s.catalog.CreateRole(ctx, domainID, r); err != nil {
+			return fmt.Errorf("create role %s: %w", r.Code, err)
+		}
+	}
+	for _, m := range plan.members {
+		if err := s.AddMember(ctx, tenantID, m.userID, m.role, m.terms); err != nil {
+			return fmt.Errorf("add member %s: %w", m.key, err)
+		}
+		if m.suspended {
+			if err := s.SuspendMember(ctx, tenantID, m.userID, "imported as suspended"); err != nil {
+				return fmt.Errorf("suspend member %s: %w", m.key, err)
+			}
+		}
+	}
+	if len(plan.orgs) > 0 {
+		if _, err := s.archiver.ImportOrganizations(ctx, domainID, plan.orgs); err != nil {
+			return fmt.Errorf("import organizations: %w", err)
+		}
+	}
+
+	actorID, _ := core.GetUserID(ctx)
+	_ = s.events.Publish(ctx, plugin.Event{
+		Name:   shared.EventTenantImported,
+		Source: "tenant",
+		Data: shared.TenantEventData{
+			TenantID:   tenantID,
+			TenantCode: plan.code,
+			DomainID:   domainID,
+			ActorID:    actorID,
+		},
+	})
+	return nil
+}
+
+// discardTenant removes a tenant created by a failed import: its
+// memberships in the tenant and its domain, their terms, its attributes and
+// the tenant record. The domain and its roles are kept and reused when the
+// archive is imported again.
+func (s *Service) discardTenant(ctx context.Context, tenantID, domainID uuid.UUID, plan *importPlan) error {
+	memberships, err := s.client.TenantUser.Query().
+		Where(tenantuser.TenantIDEQ(tenantID)).
+		All(ctx)
+	if err != nil {
+		return fmt.Errorf("list members: %w", err)
+	}
+	for _, m := range memberships {
+		if err := s.domainSvc.RemoveMembership(ctx, domainID, m.UserID); err != nil {
+			return fmt.Errorf("remove domain membership: %w", err)
+		}
+		if err := s.terms.DeleteTerm(ctx, tenantID, m.UserID); err != nil {
+			return fmt.Errorf("delete membership term: %w", err)
+		}
+	}
+	if _, err := s.client.TenantUser.Delete().Where(tenantuser.TenantIDEQ(tenantID)).Exec(ctx); err != nil {
+		return fmt.Errorf("delete members: %w", err)
+	}
+	if plan.create.Labels != nil || plan.create.Metadata != nil {
+		if err := s.attrs.PutAttributes(ctx, shared.TenantAttributes{TenantID: tenantID, UpdatedAt: s.now().UTC()}); err != nil {
+			return fmt.Errorf("clear tenant attributes: %w", err)
+		}
+	}
+	if err := s.client.Tenant.DeleteOneID(tenantID).Exec(ctx); err != nil {
+		return fmt.Errorf("delete tenant: %w", err)
+	}
+
+	actorID, _ := core.GetUserID(ctx)
+	_ = s.events.Publish(ctx, plugin.Event{
+		Name:   shared.EventTenantDeleted,
+		Source: "tenant",
+		Data: shared.TenantEventData{
+			TenantID:   tenantID,
+			TenantCode: plan.code,
+			DomainID:   domainID,
+			ActorID:    actorID,
+		},
+	})
+	return nil
+}
+
+// freeTenantCode returns the first "<code>-N" no tenant uses.
+func (s *Service) freeTenantCode(ctx context.Context, code string) (string, error) {
+	for i := 2; i < maxRenameAttempts+2; i++ {
+		candidate := fmt.Sprintf("%s-%d", code, i)
+		taken, err := s.client.Tenant.Query().Where(entTenant.CodeEQ(candidate)).Exist(ctx)
+		if err != nil {
+			return "", fmt.Errorf("check tenant code: %w", err)
+		}
+		if !taken {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("%w: no free code for %s", shared.ErrTenantCodeExists, code)
+}
+
+func userKey(u ArchivedUser) string {
+	if u.Username != "" {
+		return u.Username
+	}
+	return u.Email
+}
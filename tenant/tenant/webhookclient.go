@@ -0,0 +1,149 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/leeforge/plugins/tenant/shared"
+)
+
+// errBlockedAddress is returned when a delivery would reach an address that
+// is not publicly routable and is not allowlisted.
+var errBlockedAddress = errors.New("webhook address is not publicly routable")
+
+// reservedPrefixes are the non-public ranges that netip has no predicate for,
+// and the IPv6 ranges that embed an IPv4 address a gateway or host may
+// translate to: NAT64 (RFC 6052 and RFC 8215), IPv4-compatible and
+// IPv4-translated (SIIT) addresses. IPv4-mapped addresses are unmapped
+// before the check instead.
+var reservedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("64:ff9b:1::/48"),
+	netip.MustParsePrefix("::/96"),
+	netip.MustParsePrefix("::ffff:0:0:0/96"),
+}
+
+// blockedAddr reports whether deliveries must not reach addr: loopback,
+// private, link-local, unspecified, multicast and other reserved addresses.
+func blockedAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() {
+		return true
+	}
+	for _, p := range reservedPrefixes {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// webhookAllowlist holds the hosts and networks of WebhookAllowedHosts, which
+// deliveries may reach even though they are not publicly routable.
+type webhookAllowlist struct {
+	hosts map[string]struct{}
+	nets  []netip.Prefix
+}
+
+// newWebhookAllowlist parses entries validated by Config.Validate: CIDR
+// ranges, IP addresses and host names. IPv4-mapped entries are unmapped, as
+// the addresses they are matched against are.
+func newWebhookAllowlist(entries []string) webhookAllowlist {
+	a := webhookAllowlist{hosts: make(map[string]struct{})}
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if p, err := netip.ParsePrefix(entry); err == nil {
+			if p.Addr().Is4In6() && p.Bits() >= 96 {
+				p = netip.PrefixFrom(p.Addr().Unmap(), p.Bits()-96)
+			}
+			a.nets = append(a.nets, p.Masked())
+			continue
+		}
+		if addr, err := netip.ParseAddr(entry); err == nil {
+			a.nets = append(a.nets, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		a.hosts[strings.ToLower(entry)] = struct{}{}
+	}
+	return a
+}
+
+func (a webhookAllowlist) allowsHost(host string) bool {
+	_, ok := a.hosts[strings.ToLower(strings.TrimSuffix(host, "."))]
+	return ok
+}
+
+func (a webhookAllowlist) allowsAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, p := range a.nets {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// permits reports whether deliveries may reach the URL host: host names are
+// checked once resolved, so only literal addresses and localhost are judged
+// here.
+func (a webhookAllowlist) permits(host string) bool {
+	if a.allowsHost(host) {
+		return true
+	}
+	if addr, err := netip.ParseAddr(strings.Trim(host, "[]")); err == nil {
+		return !blockedAddr(addr) || a.allowsAddr(addr)
+	}
+	return !strings.EqualFold(strings.TrimSuffix(host, "."), "localhost")
+}
+
+// control rejects connections to blocked addresses. It runs after name
+// resolution, for every address dialed, so that a public name resolving to
+// a private address is refused too.
+func (a webhookAllowlist) control(_, address string, _ syscall.RawConn) error {
+	ap, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", errBlockedAddress, address)
+	}
+	if addr := ap.Addr(); blockedAddr(addr) && !a.allowsAddr(addr) {
+		return fmt.Errorf("%w: %s", errBlockedAddress, addr.Unmap())
+	}
+	return nil
+}
+
+// newWebhookClient returns the default delivery client. It refuses blocked
+// addresses unless allowlisted, ignores proxy settings so that the check
+// applies to the endpoint itself, does not follow redirects (a 3xx is a
+// failed delivery) and bounds each request by WebhookTimeoutSeconds.
+func newWebhookClient(cfg shared.Config, allow webhookAllowlist) *http.Client {
+	timeout := time.Duration(cfg.WebhookTimeoutSeconds) * time.Second
+	guarded := &net.Dialer{Timeout: timeout, Control: allow.control}
+	direct := &net.Dialer{Timeout: timeout}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if host, _, err := net.SplitHostPort(addr); err == nil && allow.allowsHost(host) {
+			return direct.DialContext(ctx, network, addr)
+		}
+		return guarded.DialContext(ctx, network, addr)
+	}
+	return &http.Client{
+		Transport: transport,
+		Timeout:   timeout,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
@@ -0,0 +1,744 @@
+package tenant
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/leeforge/framework/plugin"
+	"go.uber.org/zap"
+
+	"github.com/leeforge/core"
+	coremod "github.com/leeforge/core/core"
+	coreent "github.com/leeforge/core/server/ent"
+	entTenant "github.com/leeforge/core/server/ent/tenant"
+	"github.com/leeforge/core/server/ent/tenantuser"
+
+	"github.com/leeforge/plugins/apierror"
+	"github.com/leeforge/plugins/tenant/shared"
+)
+
+const (
+	// maxWebhookURL bounds the length of an endpoint URL.
+	maxWebhookURL = 2048
+	// webhookBatchSize is the number of deliveries claimed per worker pass.
+	webhookBatchSize = 50
+	// webhookSecretPrefix marks endpoint signing secrets.
+	webhookSecretPrefix = "whsec_"
+	// webhookUserAgent identifies delivery requests.
+	webhookUserAgent = "leeforge-tenant-webhooks/1.0"
+)
+
+// SetWebhookStore replaces the store of webhook endpoints and deliveries.
+// The factory calls it with a persistent store; without one, webhooks are
+// kept in memory and lost on restart.
+func (s *Service) SetWebhookStore(store shared.WebhookStore) {
+	if store != nil {
+		s.webhooks = store
+	}
+}
+
+// SetWebhookClient replaces the HTTP client used to send deliveries. Tests
+// point it at an httptest server. The client is used as is: the address
+// checks of the default client do not apply to it.
+func (s *Service) SetWebhookClient(client *http.Client) {
+	if client != nil {
+		s.webhookClient = client
+		s.customWebhookClient = true
+	}
+}
+
+// CreateWebhook registers an endpoint. A nil tenantID registers a platform
+// endpoint that receives the events of every tenant.
+func (s *Service) CreateWebhook(ctx context.Context, tenantID *uuid.UUID, req *CreateWebhookRequest) (_ *WebhookGrant, err error) {
+	ctx, end := s.instrument(ctx, "create_webhook")
+	defer end(&err)
+
+	if err := s.authorizeWebhooks(ctx, tenantID); err != nil {
+		return nil, err
+	}
+	if req == nil {
+		return nil, shared.ErrInvalidWebhook
+	}
+	target, err := s.webhookURL(req.URL)
+	if err != nil {
+		return nil, err
+	}
+	events, err := webhookEvents(req.Events)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := webhookSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	actorID, _ := core.GetUserID(ctx)
+	now := s.now().UTC()
+	endpoint := shared.WebhookEndpoint{
+		ID:          uuid.New(),
+		TenantID:    tenantID,
+		URL:         target,
+		Description: strings.TrimSpace(req.Description),
+		Events:      events,
+		Secret:      secret,
+		CreatedBy:   actorID,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := s.webhooks.PutEndpoint(ctx, endpoint); err != nil {
+		return nil, fmt.Errorf("store webhook: %w", err)
+	}
+	return &WebhookGrant{Webhook: toWebhookDTO(&endpoint), Secret: secret}, nil
+}
+
+// ListWebhooks returns the endpoints of a tenant, or the platform endpoints
+// when tenantID is nil.
+func (s *Service) ListWebhooks(ctx context.Context, tenantID *uuid.UUID) (_ *WebhookListResult, err error) {
+	ctx, end := s.instrument(ctx, "list_webhooks")
+	defer end(&err)
+
+	if err := s.authorizeWebhooks(ctx, tenantID); err != nil {
+		return nil, err
+	}
+	endpoints, err := s.webhooks.ListEndpoints(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("list webhooks: %w", err)
+	}
+	out := make([]*WebhookDTO, 0, len(endpoints))
+	for i := range endpoints {
+		out = append(out, toWebhookDTO(&endpoints[i]))
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return &WebhookListResult{Webhooks: out}, nil
+}
+
+// GetWebhook returns an endpoint of the scope.
+func (s *Service) GetWebhook(ctx context.Context, tenantID *uuid.UUID, webhookID uuid.UUID) (_ *WebhookDTO, err error) {
+	ctx, end := s.instrument(ctx, "get_webhook")
+	defer end(&err)
+
+	endpoint, err := s.webhook(ctx, tenantID, webhookID)
+	if err != nil {
+		return nil, err
+	}
+	return toWebhookDTO(endpoint), nil
+}
+
+// UpdateWebhook changes the URL, description, events or disabled flag of
+// an endpoint.
+func (s *Service) UpdateWebhook(ctx context.Context, tenantID *uuid.UUID, webhookID uuid.UUID, req *UpdateWebhookRequest) (_ *WebhookDTO, err error) {
+	ctx, end := s.instrument(ctx, "update_webhook")
+	defer end(&err)
+
+	if req == nil {
+		return nil, shared.ErrInvalidWebhook
+	}
+	endpoint, err := s.webhook(ctx, tenantID, webhookID)
+	if err != nil {
+		return nil, err
+	}
+	if req.URL != nil {
+		if endpoint.URL, err = s.webhookURL(*req.URL); err != nil {
+			return nil, err
+		}
+	}
+	if req.Description != nil {
+		endpoint.Description = strings.TrimSpace(*req.Description)
+	}
+	if req.Events != nil {
+		if endpoint.Events, err = webhookEvents(req.Events); err != nil {
+			return nil, err
+		}
+	}
+	if req.Disabled != nil {
+		endpoint.Disabled = *req.Disabled
+	}
+	endpoint.UpdatedAt = s.now().UTC()
+	if err := s.webhooks.PutEndpoint(ctx, *endpoint); err != nil {
+		return nil, fmt.Errorf("store webhook: %w", err)
+	}
+	return toWebhookDTO(endpoint), nil
+}
+
+// DeleteWebhook removes an endpoint and its delivery log.
+func (s *Service) DeleteWebhook(ctx context.Context, tenantID *uuid.UUID, webhookID uuid.UUID) (err error) {
+	ctx, end := s.instrument(ctx, "delete_webhook")
+	defer end(&err)
+
+	if _, err := s.webhook(ctx, tenantID, webhookID); err != nil {
+		return err
+	}
+	if err := s.webhooks.DeleteEndpoint(ctx, webhookID); err != nil {
+		return fmt.Errorf("delete webhook: %w", err)
+	}
+	return nil
+}
+
+// ListWebhookDeliveries returns the delivery log of an endpoint, newest
+// first, with every attempt.
+func (s *Service) ListWebhookDeliveries(ctx context.Context, tenantID *uuid.UUID, webhookID uuid.UUID, filters WebhookDeliveryFilters) (_ *WebhookDeliveryListResult, err error) {
+	ctx, end := s.instrument(ctx, "list_webhook_deliveries")
+	defer end(&err)
+
+	switch filters.Status {
+	case "", shared.WebhookDeliveryPending, shared.WebhookDeliveryDelivered, shared.WebhookDeliveryDead:
+	default:
+		return nil, shared.ErrInvalidWebhook.With(apierror.Field("status", apierror.Unknown, "value", filters.Status))
+	}
+	if _, err := s.webhook(ctx, tenantID, webhookID); err != nil {
+		return nil, err
+	}
+	deliveries, err := s.webhooks.ListDeliveries(ctx, webhookID)
+	if err != nil {
+		return nil, fmt.Errorf("list webhook deliveries: %w", err)
+	}
+	deliveries = slices.DeleteFunc(deliveries, func(d shared.WebhookDelivery) bool {
+		return (filters.Status != "" && d.Status != filters.Status) ||
+			(filters.Event != "" && d.Event != filters.Event)
+	})
+	sort.Slice(deliveries, func(i, j int) bool {
+		if !deliveries[i].CreatedAt.Equal(deliveries[j].CreatedAt) {
+			return deliveries[i].CreatedAt.After(deliveries[j].CreatedAt)
+		}
+		return deliveries[i].ID.String() > deliveries[j].ID.String()
+	})
+
+	page, pageSize := s.cfg.PageBounds(filters.Page, filters.PageSize)
+	total := len(deliveries)
+	from := min((page-1)*pageSize, total)
+	to := min(from+pageSize, total)
+	out := make([]*WebhookDeliveryDTO, 0, to-from)
+	for i := from; i < to; i++ {
+		out = append(out, toWebhookDeliveryDTO(&deliveries[i]))
+	}
+	return &WebhookDeliveryListResult{
+		Deliveries: out,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: (total + pageSize - 1) / pageSize,
+	}, nil
+}
+
+// RedeliverWebhook queues a copy of a delivery, whatever its status, to be
+// sent at once. The copy carries the same event ID and body.
+func (s *Service) RedeliverWebhook(ctx context.Context, tenantID *uuid.UUID, webhookID, deliveryID uuid.UUID) (_ *WebhookDeliveryDTO, err error) {
+	ctx, end := s.instrument(ctx, "redeliver_webhook")
+	defer end(&err)
+
+	if _, err := s.webhook(ctx, tenantID, webhookID); err != nil {
+		return nil, err
+	}
+	original, err := s.webhooks.GetDelivery(ctx, deliveryID)
+	if err != nil {
+		return nil, fmt.Errorf("get webhook delivery: %w", err)
+	}
+	if original == nil || original.EndpointID != webhookID {
+		return nil, shared.ErrWebhookDeliveryNotFound
+	}
+
+	now := s.now().UTC()
+	delivery := shared.WebhookDelivery{
+		ID:            uuid.New(),
+		EndpointID:    webhookID,
+		TenantID:      original.TenantID,
+		EventID:       original.EventID,
+		Event:         original.Event,
+		Body:          original.Body,
+		Status:        shared.WebhookDeliveryPending,
+		Attempts:      []shared.WebhookAttempt{},
+		NextAttemptAt: &now,
+		RedeliveryOf:  &original.ID,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if err := s.webhooks.PutDelivery(ctx, delivery); err != nil {
+		return nil, fmt.Errorf("store webhook delivery: %w", err)
+	}
+	return toWebhookDeliveryDTO(&delivery), nil
+}
+
+// EnqueueWebhookEvent queues e for every enabled endpoint subscribed to it:
+// platform endpoints, and the endpoints of the tenant named by the
+// payload's tenantId. Events that are not webhook events are ignored.
+func (s *Service) EnqueueWebhookEvent(ctx context.Context, e plugin.Event) (err error) {
+	if !slices.Contains(shared.WebhookEvents, e.Name) {
+		return nil
+	}
+	ctx, end := s.instrument(ctx, "enqueue_webhook_event")
+	defer end(&err)
+
+	data, err := json.Marshal(e.Data)
+	if err != nil {
+		return fmt.Errorf("encode %s payload: %w", e.Name, err)
+	}
+	var scope struct {
+		TenantID uuid.UUID `json:"tenantId"`
+	}
+	_ = json.Unmarshal(data, &scope)
+
+	endpoints, err := s.webhooks.ListEndpoints(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("list webhooks: %w", err)
+	}
+	if scope.TenantID != uuid.Nil {
+		tenantEndpoints, err := s.webhooks.ListEndpoints(ctx, &scope.TenantID)
+		if err != nil {
+			return fmt.Errorf("list webhooks: %w", err)
+		}
+		endpoints = append(endpoints, tenantEndpoints...)
+	}
+	now := s.now().UTC()
+	msg := shared.WebhookMessage{ID: uuid.New(), Event: e.Name, OccurredAt: now, Data: data}
+	if scope.TenantID != uuid.Nil {
+		msg.TenantID = &scope.TenantID
+	}
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("encode webhook message: %w", err)
+	}
+
+	for _, endpoint := range endpoints {
+		if endpoint.Disabled || !endpoint.Subscribed(e.Name) {
+			continue
+		}
+		delivery := shared.WebhookDelivery{
+			ID:            uuid.New(),
+			EndpointID:    endpoint.ID,
+			TenantID:      msg.TenantID,
+			EventID:       msg.ID,
+			Event:         e.Name,
+			Body:          body,
+			Status:        shared.WebhookDeliveryPending,
+			Attempts:      []shared.WebhookAttempt{},
+			NextAttemptAt: &now,
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		}
+		if err := s.webhooks.PutDelivery(ctx, delivery); err != nil {
+			return fmt.Errorf("store webhook delivery: %w", err)
+		}
+	}
+	return nil
+}
+
+// DeliverWebhooks sends every due delivery once and returns the number of
+// deliveries attempted. Failed deliveries are retried with exponential
+// backoff and dead-lettered after WebhookMaxAttempts attempts.
+func (s *Service) DeliverWebhooks(ctx context.Context) (sent int, err error) {
+	ctx, end := s.instrument(ctx, "deliver_webhooks")
+	defer end(&err)
+
+	timeout := time.Duration(s.cfg.WebhookTimeoutSeconds) * time.Second
+	for {
+		now := s.now().UTC()
+		// Claimed deliveries stay hidden from other workers for longer than
+		// one attempt can take.
+		due, err := s.webhooks.ClaimDueDeliveries(ctx, now, now.Add(2*timeout), webhookBatchSize)
+		if err != nil {
+			return sent, fmt.Errorf("claim webhook deliveries: %w", err)
+		}
+		for i := range due {
+			if err := s.attemptDelivery(ctx, &due[i]); err != nil {
+				return sent, err
+			}
+			sent++
+		}
+		if len(due) < webhookBatchSize {
+			return sent, nil
+		}
+	}
+}
+
+// attemptDelivery sends one delivery and records the outcome.
+func (s *Service) attemptDelivery(ctx context.Context, delivery *shared.WebhookDelivery) error {
+	endpoint, err := s.webhooks.GetEndpoint(ctx, delivery.EndpointID)
+	if err != nil {
+		return fmt.Errorf("get webhook: %w", err)
+	}
+
+	start := s.now().UTC()
+	attempt := shared.WebhookAttempt{At: start}
+	switch {
+	case endpoint == nil:
+		// The endpoint was deleted after the delivery was claimed.
+		return nil
+	case endpoint.Disabled:
+		attempt.Error = "webhook is disabled"
+	default:
+		attempt.StatusCode, err = s.sendWebhook(ctx, endpoint, delivery)
+		if err != nil {
+			attempt.Error = err.Error()
+		}
+	}
+	attempt.DurationMS = s.now().Sub(start).Milliseconds()
+
+	delivery.Attempts = append(delivery.Attempts, attempt)
+	delivery.UpdatedAt = s.now().UTC()
+	delivery.NextAttemptAt = nil
+	switch {
+	case attempt.Error == "":
+		delivery.Status = shared.WebhookDeliveryDelivered
+	case endpoint.Disabled || len(delivery.Attempts) >= s.cfg.WebhookMaxAttempts:
+		delivery.Status = shared.WebhookDeliveryDead
+		s.logger.Warn("tenant: webhook delivery dead-lettered",
+			zap.String("delivery_id", delivery.ID.String()),
+			zap.String("webhook_id", endpoint.ID.String()),
+			zap.String("event", delivery.Event),
+			zap.String("error", attempt.Error),
+		)
+	default:
+		next := delivery.UpdatedAt.Add(s.webhookBackoff(len(delivery.Attempts)))
+		delivery.NextAttemptAt = &next
+	}
+	if err := s.webhooks.PutDelivery(ctx, *delivery); err != nil {
+		return fmt.Errorf("store webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// sendWebhook posts the delivery body to the endpoint and returns the
+// response status. Non-2xx responses are errors.
+func (s *Service) sendWebhook(ctx context.Context, endpoint *shared.WebhookEndpoint, delivery *shared.WebhookDelivery) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(s.cfg.WebhookTimeoutSeconds)*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(delivery.Body))
+	if err != nil {
+		return 0, err
+	}
+	ts := s.now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", webhookUserAgent)
+	req.Header.Set(shared.WebhookHeaderEvent, delivery.Event)
+	req.Header.Set(shared.WebhookHeaderDelivery, delivery.ID.String())
+	req.Header.Set(shared.WebhookHeaderTimestamp, strconv.FormatInt(ts, 10))
+	req.Header.Set(shared.WebhookHeaderSignature, shared.SignWebhook(endpoint.Secret, ts, delivery.Body))
+
+	resp, err := s.webhookClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("endpoint responded %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// PruneWebhookDeliveries removes the delivered and dead deliveries that
+// finished more than WebhookDeliveryRetentionSeconds ago and returns how many
+// it removed.
+func (s *Service) PruneWebhookDeliveries(ctx context.Context) (removed int, err error) {
+	ctx, end := s.instrument(ctx, "prune_webhook_deliveries")
+	defer end(&err)
+
+	retention := time.Duration(s.cfg.WebhookDeliveryRetentionSeconds) * time.Second
+	removed, err = s.webhooks.DeleteFinishedDeliveries(ctx, s.now().Add(-retention))
+	if err != nil {
+		return removed, fmt.Errorf("prune webhook deliveries: %w", err)
+	}
+	return removed, nil
+}
+
+// webhookBackoff returns the delay after the given number of failed
+// attempts: the base delay doubled for each attempt after the first, capped
+// at the configured maximum.
+func (s *Service) webhookBackoff(attempts int) time.Duration {
+	delay := time.Duration(s.cfg.WebhookRetryBaseSeconds) * time.Second
+	limit := time.Duration(s.cfg.WebhookRetryMaxSeconds) * time.Second
+	for i := 1; i < attempts && delay < limit; i++ {
+		delay *= 2
+	}
+	return min(delay, limit)
+}
+
+// authorizeWebhooks allows platform users to manage every endpoint, and
+// the tenant admins acting in a tenant's domain to manage its endpoints.
+func (s *Service) authorizeWebhooks(ctx context.Context, tenantID *uuid.UUID) error {
+	if requirePlatformDomain(ctx) == nil {
+		if tenantID != nil {
+			_, err := s.webhookTenant(ctx, *tenantID)
+			return err
+		}
+		return nil
+	}
+	if tenantID == nil {
+		return shared.ErrPlatformDomainOnly
+	}
+
+	t, err := s.webhookTenant(ctx, *tenantID)
+	if err != nil {
+		return err
+	}
+	ac := coremod.GetActingContext(ctx)
+	if ac == nil || ac.Domain == nil || ac.Domain.TypeCode != s.cfg.DomainTypeCode || ac.Domain.Key != t.Code {
+		return shared.ErrTenantAdminRequired
+	}
+	userID, ok := core.GetUserID(ctx)
+	if !ok {
+		return shared.ErrTenantAdminRequired
+	}
+	membership, err := s.findMembership(ctx, t.ID, userID)
+	if err != nil {
+		if errors.Is(err, shared.ErrMemberNotFound) {
+			return shared.ErrTenantAdminRequired
+		}
+		return err
+	}
+	if membership.Status != tenantuser.StatusActive || membership.Role != s.cfg.OwnerRole {
+		return shared.ErrTenantAdminRequired
+	}
+	return nil
+}
+
+func (s *Service) webhookTenant(ctx context.Context, tenantID uuid.UUID) (*coreent.Tenant, error) {
+	t, err := s.client.Tenant.Query().
+		Where(entTenant.ID(tenantID), entTenant.DeletedAtIsNil()).
+		Only(ctx)
+	if err != nil {
+		if coreent.IsNotFound(err) {
+			return nil, shared.ErrTenantNotFound
+		}
+		return nil, fmt.Errorf("get tenant: %w", err)
+	}
+	return t, nil
+}
+
+// webhook returns an endpoint of the scope after checking access to it.
+func (s *Service) webhook(ctx context.Context, tenantID *uuid.UUID, webhookID uuid.UUID) (*shared.WebhookEndpoint, error) {
+	if err := s.authorizeWebhooks(ctx, tenantID); err != nil {
+		return nil, err
+	}
+	endpoint, err := s.webhooks.GetEndpoint(ctx, webhookID)
+	if err != nil {
+		return nil, fmt.Errorf("get webhook: %w", err)
+	}
+	if endpoint == nil || !sameScope(endpoint.TenantID, tenantID) {
+		return nil, shared.ErrWebhookNotFound
+	}
+	return endpoint, nil
+}
+
+func sameScope(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// webhookURL validates an endpoint URL: absolute http or https, with a host
+// that is not a blocked address unless allowlisted, and without credentials.
+func (s *Service) webhookURL(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", shared.ErrInvalidWebhook.With(apierror.Field("url", apierror.Required))
+	}
+	if len(raw) > maxWebhookURL {
+		return "", shared.ErrInvalidWebhook.With(apierror.Field("url", apierror.TooLong, "max", maxWebhookURL))
+	}
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", shared.ErrInvalidWebhook.With(apierror.Field("url", shared.CodeHTTPURL))
+	}
+	if u.User != nil {
+		return "", shared.ErrInvalidWebhook.With(apierror.Field("url", shared.CodeURLCredentials))
+	}
+	if !s.webhookAllow.permits(u.Hostname()) {
+		return "", shared.ErrInvalidWebhook.With(apierror.Field("url", shared.CodeBlockedHost))
+	}
+	return u.String(), nil
+}
+
+// webhookEvents validates and deduplicates a list of event types.
+func webhookEvents(events []string) ([]string, error) {
+	var out []string
+	for _, e := range events {
+		e = strings.TrimSpace(e)
+		if e != shared.WebhookAllEvents && !slices.Contains(shared.WebhookEvents, e) {
+			return nil, shared.ErrInvalidWebhook.With(apierror.Field("events", apierror.Unknown, "value", e))
+		}
+		if !slices.Contains(out, e) {
+			out = append(out, e)
+		}
+	}
+	if len(out) == 0 {
+		return nil, shared.ErrInvalidWebhook.With(apierror.Field("events", apierror.Required))
+	}
+	return out, nil
+}
+
+func webhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate webhook secret: %w", err)
+	}
+	return webhookSecretPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func toWebhookDTO(e *shared.WebhookEndpoint) *WebhookDTO {
+	return &WebhookDTO{
+		ID:          e.ID,
+		TenantID:    e.TenantID,
+		URL:         e.URL,
+		Description: e.Description,
+		Events:      e.Events,
+		Disabled:    e.Disabled,
+		CreatedBy:   e.CreatedBy,
+		CreatedAt:   e.CreatedAt,
+		UpdatedAt:   e.UpdatedAt,
+	}
+}
+
+func toWebhookDeliveryDTO(d *shared.WebhookDelivery) *WebhookDeliveryDTO {
+	attempts := d.Attempts
+	if attempts == nil {
+		attempts = []shared.WebhookAttempt{}
+	}
+	return &WebhookDeliveryDTO{
+		ID:            d.ID,
+		WebhookID:     d.EndpointID,
+		EventID:       d.EventID,
+		Event:         d.Event,
+		Status:        d.Status,
+		Attempts:      attempts,
+		NextAttemptAt: d.NextAttemptAt,
+		RedeliveryOf:  d.RedeliveryOf,
+		Payload:       d.Body,
+		CreatedAt:     d.CreatedAt,
+		UpdatedAt:     d.UpdatedAt,
+	}
+}
+
+// memoryWebhookStore is the default, non-persistent WebhookStore.
+type memoryWebhookStore struct {
+	mu         sync.Mutex
+	endpoints  map[uuid.UUID]shared.WebhookEndpoint
+	deliveries map[uuid.UUID]shared.WebhookDelivery
+}
+
+func newMemoryWebhookStore() *memoryWebhookStore {
+	return &memoryWebhookStore{
+		endpoints:  make(map[uuid.UUID]shared.WebhookEndpoint),
+		deliveries: make(map[uuid.UUID]shared.WebhookDelivery),
+	}
+}
+
+func (m *memoryWebhookStore) PutEndpoint(_ context.Context, endpoint shared.WebhookEndpoint) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.endpoints[endpoint.ID] = endpoint
+	return nil
+}
+
+func (m *memoryWebhookStore) GetEndpoint(_ context.Context, id uuid.UUID) (*shared.WebhookEndpoint, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	endpoint, ok := m.endpoints[id]
+	if !ok {
+		return nil, nil
+	}
+	return &endpoint, nil
+}
+
+func (m *memoryWebhookStore) ListEndpoints(_ context.Context, tenantID *uuid.UUID) ([]shared.WebhookEndpoint, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []shared.WebhookEndpoint
+	for _, endpoint := range m.endpoints {
+		if sameScope(endpoint.TenantID, tenantID) {
+			out = append(out, endpoint)
+		}
+	}
+	return out, nil
+}
+
+func (m *memoryWebhookStore) DeleteEndpoint(_ context.Context, id uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.endpoints, id)
+	for did, d := range m.deliveries {
+		if d.EndpointID == id {
+			delete(m.deliveries, did)
+		}
+	}
+	return nil
+}
+
+func (m *memoryWebhookStore) PutDelivery(_ context.Context, delivery shared.WebhookDelivery) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.deliveries[delivery.ID] = delivery
+	return nil
+}
+
+func (m *memoryWebhookStore) GetDelivery(_ context.Context, id uuid.UUID) (*shared.WebhookDelivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	d, ok := m.deliveries[id]
+	if !ok {
+		return nil, nil
+	}
+	return &d, nil
+}
+
+func (m *memoryWebhookStore) ListDeliveries(_ context.Context, endpointID uuid.UUID) ([]shared.WebhookDelivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []shared.WebhookDelivery
+	for _, d := range m.deliveries {
+		if d.EndpointID == endpointID {
+			out = append(out, d)
+		}
+	}
+	return out, nil
+}
+
+func (m *memoryWebhookStore) ClaimDueDeliveries(_ context.Context, now, until time.Time, limit int) ([]shared.WebhookDelivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []shared.WebhookDelivery
+	for id, d := range m.deliveries {
+		if len(out) == limit {
+			break
+		}
+		if d.Status != shared.WebhookDeliveryPending || d.NextAttemptAt == nil || d.NextAttemptAt.After(now) {
+			continue
+		}
+		out = append(out, d)
+		d.NextAttemptAt = &until
+		m.deliveries[id] = d
+	}
+	return out, nil
+}
+
+func (m *memoryWebhookStore) DeleteFinishedDeliveries(_ context.Context, finishedBefore time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	removed := 0
+	for id, d := range m.deliveries {
+		if d.Status != shared.WebhookDeliveryPending && d.UpdatedAt.Before(finishedBefore) {
+			delete(m.deliveries, id)
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+var _ shared.WebhookStore = (*memoryWebhookStore)(nil)
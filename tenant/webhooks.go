@@ -0,0 +1,26 @@
+package tenant
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// runWebhookDispatcher sends due webhook deliveries every interval until ctx
+// is cancelled by Disable.
+func (p *TenantPlugin) runWebhookDispatcher(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := p.service().DeliverWebhooks(ctx); err != nil && ctx.Err() == nil {
+				p.logger.Error("tenant: webhook delivery failed", zap.Error(err))
+			}
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/leeforge/framework/plugin"
+)
+
+// Carrier is implemented by event payloads that carry a W3C traceparent.
+// WithTraceParent returns a copy of the payload so that value payloads can
+// be updated without mutating the caller's value.
+type Carrier interface {
+	TraceParent() string
+	WithTraceParent(traceParent string) any
+}
+
+// Inject stores the span context of ctx in the payload of e. Payloads that
+// implement Carrier and map[string]any payloads are supported; others are
+// returned unchanged.
+func Inject(ctx context.Context, e plugin.Event) plugin.Event {
+	tp := SpanContextFromContext(ctx).TraceParent()
+	if tp == "" {
+		return e
+	}
+	switch data := e.Data.(type) {
+	case Carrier:
+		e.Data = data.WithTraceParent(tp)
+	case map[string]any:
+		copied := make(map[string]any, len(data)+1)
+		for k, v := range data {
+			copied[k] = v
+		}
+		copied[TraceParentKey] = tp
+		e.Data = copied
+	}
+	return e
+}
+
+// Extract returns ctx continued from the trace context carried by the
+// payload of e, if any.
+func Extract(ctx context.Context, e plugin.Event) context.Context {
+	var tp string
+	switch data := e.Data.(type) {
+	case Carrier:
+		tp = data.TraceParent()
+	case map[string]any:
+		tp, _ = data[TraceParentKey].(string)
+	}
+	if sc, ok := ParseTraceParent(tp); ok {
+		return ContextWithSpanContext(ctx, sc)
+	}
+	return ctx
+}
+
+// WrapEventBus traces publishing and handling of events. Publish runs in an
+// "event_bus.publish" span whose context is injected into the payload;
+// subscribed handlers run in an "event_bus.handle" span that continues the
+// publisher's trace.
+func WrapEventBus(bus plugin.EventBus, t Tracer) plugin.EventBus {
+	if bus == nil {
+		return nil
+	}
+	if tb, ok := bus.(*tracedBus); ok {
+		bus = tb.EventBus
+	}
+	return &tracedBus{EventBus: bus, tracer: OrNop(t)}
+}
+
+type tracedBus struct {
+	plugin.EventBus
+	tracer Tracer
+}
+
+func (b *tracedBus) Publish(ctx context.Context, e plugin.Event) (err error) {
+	ctx, span := b.tracer.Start(ctx, "event_bus.publish", Attr("event.name", e.Name))
+	defer End(span, &err)
+	return b.EventBus.Publish(ctx, Inject(ctx, e))
+}
+
+func (b *tracedBus) Subscribe(topic string, handler plugin.EventHandler) plugin.Subscription {
+	return b.EventBus.Subscribe(topic, func(ctx context.Context, e plugin.Event) (err error) {
+		ctx, span := b.tracer.Start(Extract(ctx, e), "event_bus.handle",
+			Attr("event.name", e.Name),
+			Attr("event.source", e.Source),
+		)
+		defer End(span, &err)
+		return handler(ctx, e)
+	})
+}
+
+// HTTPMiddleware starts a "<prefix>.http" span for every request,
+// continuing the trace from an incoming traceparent header.
+func HTTPMiddleware(t Tracer, prefix string) func(http.Handler) http.Handler {
+	t = OrNop(t)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			if sc, ok := ParseTraceParent(r.Header.Get(TraceParentKey)); ok {
+				ctx = ContextWithSpanContext(ctx, sc)
+			}
+			ctx, span := t.Start(ctx, prefix+".http",
+				Attr("http.method", r.Method),
+				Attr("http.target", r.URL.Path),
+			)
+			defer span.End()
+
+			sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(sw, r.WithContext(ctx))
+
+			attrs := []Attribute{Attr("http.status_code", sw.status)}
+			if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+				attrs = append(attrs, Attr("http.route", rctx.RoutePattern()))
+			}
+			span.SetAttributes(attrs...)
+			if sw.status >= http.StatusInternalServerError {
+				span.RecordError(fmt.Errorf("http status %d", sw.status))
+			}
+		})
+	}
+}
+
+// statusWriter captures the status code written by the wrapped handler.
+type statusWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.status = code
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
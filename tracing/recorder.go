@@ -0,0 +1,154 @@
+package tracing
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// SpanData is the immutable record of an ended span.
+type SpanData struct {
+	Name       string
+	Context    SpanContext
+	Parent     SpanContext
+	Attributes map[string]any
+	Err        error
+	Start      time.Time
+	End        time.Time
+}
+
+// Duration returns how long the span ran.
+func (d SpanData) Duration() time.Duration { return d.End.Sub(d.Start) }
+
+// Exporter receives spans when they end.
+type Exporter interface {
+	ExportSpan(span SpanData)
+}
+
+// NewTracer returns a tracer that assigns W3C trace and span IDs, links
+// spans to the parent carried by the context and hands every ended span to
+// exp. It is intended for tests and simple hosts; production hosts usually
+// adapt their own tracing SDK to the Tracer interface.
+func NewTracer(exp Exporter) Tracer {
+	return &tracer{exp: exp}
+}
+
+type tracer struct {
+	exp Exporter
+}
+
+func (t *tracer) Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span) {
+	parent := SpanContextFromContext(ctx)
+	sc := SpanContext{TraceID: parent.TraceID, SpanID: newSpanID()}
+	if !parent.IsValid() {
+		sc.TraceID = newTraceID()
+	}
+
+	s := &span{
+		exp: t.exp,
+		data: SpanData{
+			Name:       name,
+			Context:    sc,
+			Parent:     parent,
+			Attributes: make(map[string]any, len(attrs)),
+			Start:      time.Now(),
+		},
+	}
+	s.SetAttributes(attrs...)
+	return context.WithValue(ctx, spanContextKey{}, sc), s
+}
+
+type span struct {
+	mu    sync.Mutex
+	exp   Exporter
+	data  SpanData
+	ended bool
+}
+
+func (s *span) SpanContext() SpanContext { return s.data.Context }
+
+func (s *span) SetAttributes(attrs ...Attribute) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, a := range attrs {
+		s.data.Attributes[a.Key] = a.Value
+	}
+}
+
+func (s *span) RecordError(err error) {
+	if err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Err = err
+}
+
+func (s *span) End() {
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.End = time.Now()
+	data := s.data
+	s.mu.Unlock()
+
+	if s.exp != nil {
+		s.exp.ExportSpan(data)
+	}
+}
+
+// InMemoryExporter keeps ended spans in memory. It is safe for concurrent
+// use.
+type InMemoryExporter struct {
+	mu    sync.Mutex
+	spans []SpanData
+}
+
+// NewInMemoryExporter creates an empty exporter.
+func NewInMemoryExporter() *InMemoryExporter {
+	return &InMemoryExporter{}
+}
+
+// ExportSpan records span.
+func (e *InMemoryExporter) ExportSpan(span SpanData) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = append(e.spans, span)
+}
+
+// Spans returns the recorded spans in the order they ended.
+func (e *InMemoryExporter) Spans() []SpanData {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]SpanData(nil), e.spans...)
+}
+
+// Names returns the names of the recorded spans in the order they ended.
+func (e *InMemoryExporter) Names() []string {
+	spans := e.Spans()
+	names := make([]string, len(spans))
+	for i, s := range spans {
+		names[i] = s.Name
+	}
+	return names
+}
+
+// Find returns the first recorded span named name.
+func (e *InMemoryExporter) Find(name string) (SpanData, bool) {
+	for _, s := range e.Spans() {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return SpanData{}, false
+}
+
+// Reset discards all recorded spans.
+func (e *InMemoryExporter) Reset() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = nil
+}
@@ -0,0 +1,160 @@
+// Package tracing defines the span interface used by the Leeforge plugins,
+// W3C trace context propagation over HTTP headers and event payloads, and a
+// minimal tracer with an in-memory exporter for tests.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+)
+
+// TraceParentKey is the HTTP header and event payload key that carries the
+// W3C trace context.
+const TraceParentKey = "traceparent"
+
+// Attribute is a key/value pair attached to a span.
+type Attribute struct {
+	Key   string
+	Value any
+}
+
+// Attr builds an Attribute.
+func Attr(key string, value any) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// SpanContext identifies a span within a trace.
+type SpanContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+}
+
+// IsValid reports whether both identifiers are set.
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != [16]byte{} && sc.SpanID != [8]byte{}
+}
+
+// TraceParent formats sc as a W3C traceparent value, or returns "" when sc
+// is invalid.
+func (sc SpanContext) TraceParent() string {
+	if !sc.IsValid() {
+		return ""
+	}
+	return "00-" + hex.EncodeToString(sc.TraceID[:]) + "-" + hex.EncodeToString(sc.SpanID[:]) + "-01"
+}
+
+// ParseTraceParent parses a W3C traceparent value.
+func ParseTraceParent(s string) (SpanContext, bool) {
+	parts := strings.Split(strings.TrimSpace(s), "-")
+	if len(parts) != 4 || len(parts[0]) != 2 || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return SpanContext{}, false
+	}
+	if parts[0] == "ff" {
+		return SpanContext{}, false
+	}
+
+	var sc SpanContext
+	if _, err := hex.Decode(sc.TraceID[:], []byte(parts[1])); err != nil {
+		return SpanContext{}, false
+	}
+	if _, err := hex.Decode(sc.SpanID[:], []byte(parts[2])); err != nil {
+		return SpanContext{}, false
+	}
+	if !sc.IsValid() {
+		return SpanContext{}, false
+	}
+	return sc, true
+}
+
+// Span is one timed operation within a trace.
+type Span interface {
+	SpanContext() SpanContext
+	SetAttributes(attrs ...Attribute)
+	// RecordError marks the span as failed. A nil err is ignored.
+	RecordError(err error)
+	End()
+}
+
+// Tracer starts spans. The returned context carries the new span so that
+// spans started from it become its children.
+type Tracer interface {
+	Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span)
+}
+
+// TracerProvider is optionally implemented by service factories that carry
+// a host tracer into the plugin.
+type TracerProvider interface {
+	Tracer() Tracer
+}
+
+// FromFactory returns the tracer of factory when it implements
+// TracerProvider, or Nop otherwise.
+func FromFactory(factory any) Tracer {
+	if p, ok := factory.(TracerProvider); ok {
+		return OrNop(p.Tracer())
+	}
+	return Nop
+}
+
+// End records *errp on span and ends it. It is deferred with a pointer to
+// the traced function's named error result.
+func End(span Span, errp *error) {
+	if errp != nil && *errp != nil {
+		span.RecordError(*errp)
+	}
+	span.End()
+}
+
+type spanContextKey struct{}
+
+// ContextWithSpanContext returns a context whose parent span is sc. It is
+// used to continue a trace received from another process or event.
+func ContextWithSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	if !sc.IsValid() {
+		return ctx
+	}
+	return context.WithValue(ctx, spanContextKey{}, sc)
+}
+
+// SpanContextFromContext returns the span context carried by ctx.
+func SpanContextFromContext(ctx context.Context) SpanContext {
+	sc, _ := ctx.Value(spanContextKey{}).(SpanContext)
+	return sc
+}
+
+// OrNop returns t, or Nop when t is nil.
+func OrNop(t Tracer) Tracer {
+	if t == nil {
+		return Nop
+	}
+	return t
+}
+
+// Nop starts spans that record nothing. The incoming span context is kept
+// so that propagation still works without a tracer.
+var Nop Tracer = nopTracer{}
+
+type nopTracer struct{}
+
+func (nopTracer) Start(ctx context.Context, _ string, _ ...Attribute) (context.Context, Span) {
+	return ctx, nopSpan{sc: SpanContextFromContext(ctx)}
+}
+
+type nopSpan struct{ sc SpanContext }
+
+func (s nopSpan) SpanContext() SpanContext { return s.sc }
+func (nopSpan) SetAttributes(...Attribute) {}
+func (nopSpan) RecordError(error)          {}
+func (nopSpan) End()                       {}
+
+func newTraceID() (id [16]byte) {
+	_, _ = rand.Read(id[:])
+	return id
+}
+
+func newSpanID() (id [8]byte) {
+	_, _ = rand.Read(id[:])
+	return id
+}
@@ -0,0 +1,161 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/leeforge/framework/plugin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTraceParent(t *testing.T) {
+	const tp = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	sc, ok := ParseTraceParent(tp)
+	require.True(t, ok)
+	require.Equal(t, tp, sc.TraceParent())
+
+	for _, bad := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e473z-00f067aa0ba902b7-01",
+	} {
+		_, ok := ParseTraceParent(bad)
+		require.False(t, ok, bad)
+	}
+}
+
+func TestTracer_ChildSpansShareTrace(t *testing.T) {
+	exp := NewInMemoryExporter()
+	tr := NewTracer(exp)
+
+	ctx, parent := tr.Start(context.Background(), "parent", Attr("k", "v"))
+	_, child := tr.Start(ctx, "child")
+	child.RecordError(errors.New("boom"))
+	child.End()
+	parent.End()
+	parent.End()
+
+	require.Equal(t, []string{"child", "parent"}, exp.Names())
+	c, _ := exp.Find("child")
+	p, _ := exp.Find("parent")
+	require.Equal(t, p.Context.TraceID, c.Context.TraceID)
+	require.Equal(t, p.Context.SpanID, c.Parent.SpanID)
+	require.False(t, p.Parent.IsValid())
+	require.EqualError(t, c.Err, "boom")
+	require.Equal(t, "v", p.Attributes["k"])
+}
+
+func TestNop_KeepsIncomingContext(t *testing.T) {
+	sc, _ := ParseTraceParent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx := ContextWithSpanContext(context.Background(), sc)
+
+	ctx, span := Nop.Start(ctx, "ignored")
+	require.Equal(t, sc, span.SpanContext())
+	require.Equal(t, sc, SpanContextFromContext(ctx))
+}
+
+type payload struct {
+	Trace string
+}
+
+func (p payload) TraceParent() string { return p.Trace }
+
+func (p payload) WithTraceParent(tp string) any {
+	p.Trace = tp
+	return p
+}
+
+func TestInjectExtract(t *testing.T) {
+	exp := NewInMemoryExporter()
+	ctx, span := NewTracer(exp).Start(context.Background(), "publish")
+	want := span.SpanContext()
+
+	original := map[string]any{"userId": "u1"}
+	for _, data := range []any{payload{}, original} {
+		e := Inject(ctx, plugin.Event{Name: "demo", Data: data})
+		got := SpanContextFromContext(Extract(context.Background(), e))
+		require.Equal(t, want, got)
+	}
+	require.NotContains(t, original, TraceParentKey, "map payloads are copied, not mutated")
+
+	e := Inject(ctx, plugin.Event{Name: "demo", Data: "opaque"})
+	require.Equal(t, "opaque", e.Data)
+}
+
+// syncBus delivers events synchronously to every subscriber.
+type syncBus struct {
+	handlers map[string][]plugin.EventHandler
+}
+
+type nopSub struct{}
+
+func (nopSub) Unsubscribe() {}
+
+func (b *syncBus) Publish(ctx context.Context, e plugin.Event) error {
+	for _, h := range b.handlers[e.Name] {
+		if err := h(context.Background(), e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *syncBus) Subscribe(topic string, h plugin.EventHandler) plugin.Subscription {
+	b.handlers[topic] = append(b.handlers[topic], h)
+	return nopSub{}
+}
+
+func (b *syncBus) Close() error { return nil }
+
+func TestWrapEventBus_ContinuesTraceInSubscriber(t *testing.T) {
+	exp := NewInMemoryExporter()
+	tr := NewTracer(exp)
+	bus := WrapEventBus(&syncBus{handlers: map[string][]plugin.EventHandler{}}, tr)
+	require.Same(t, bus.(*tracedBus).EventBus, WrapEventBus(bus, tr).(*tracedBus).EventBus)
+
+	var handled SpanContext
+	bus.Subscribe("demo", func(ctx context.Context, _ plugin.Event) error {
+		handled = SpanContextFromContext(ctx)
+		return nil
+	})
+
+	ctx, root := tr.Start(context.Background(), "operation")
+	require.NoError(t, bus.Publish(ctx, plugin.Event{Name: "demo", Data: payload{}}))
+	root.End()
+
+	publish, ok := exp.Find("event_bus.publish")
+	require.True(t, ok)
+	handle, ok := exp.Find("event_bus.handle")
+	require.True(t, ok)
+
+	require.Equal(t, root.SpanContext().SpanID, publish.Parent.SpanID)
+	require.Equal(t, publish.Context.SpanID, handle.Parent.SpanID)
+	require.Equal(t, root.SpanContext().TraceID, handled.TraceID)
+	require.Equal(t, "demo", handle.Attributes["event.name"])
+}
+
+func TestHTTPMiddleware_ContinuesIncomingTrace(t *testing.T) {
+	exp := NewInMemoryExporter()
+	router := chi.NewRouter()
+	router.Use(HTTPMiddleware(NewTracer(exp), "demo"))
+	router.Get("/items/{id}", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/items/1", nil)
+	req.Header.Set(TraceParentKey, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	span, ok := exp.Find("demo.http")
+	require.True(t, ok)
+	require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.Context.TraceParent()[3:35])
+	require.Equal(t, "/items/{id}", span.Attributes["http.route"])
+	require.Equal(t, http.StatusInternalServerError, span.Attributes["http.status_code"])
+	require.Error(t, span.Err)
+}